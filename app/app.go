@@ -9,6 +9,7 @@ import (
 
 	"github.com/kaspanet/kaspad/infrastructure/db/database"
 	"github.com/kaspanet/kaspad/infrastructure/db/database/ldb"
+	"github.com/kaspanet/kaspad/infrastructure/db/database/memorydb"
 
 	"github.com/kaspanet/kaspad/infrastructure/os/signal"
 	"github.com/kaspanet/kaspad/util/profiling"
@@ -173,12 +174,22 @@ func databasePath(cfg *config.Config) string {
 	return filepath.Join(cfg.DataDir, "db")
 }
 
+// mempoolFilePath returns the path to the file the mempool is saved to on shutdown.
+func mempoolFilePath(cfg *config.Config) string {
+	return filepath.Join(cfg.DataDir, "mempool.dat")
+}
+
 func removeDatabase(cfg *config.Config) error {
 	dbPath := databasePath(cfg)
 	return os.RemoveAll(dbPath)
 }
 
 func openDB(cfg *config.Config) (database.Database, error) {
+	if cfg.DbType == config.MemoryDBType {
+		log.Warnf("Using an in-memory database. All of its data will be lost on shutdown")
+		return memorydb.NewMemoryDB(), nil
+	}
+
 	dbPath := databasePath(cfg)
 	log.Infof("Loading database from '%s'", dbPath)
 	return ldb.NewLevelDB(dbPath)
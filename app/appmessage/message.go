@@ -123,6 +123,14 @@ const (
 	CmdNotifyVirtualSelectedParentBlueScoreChangedRequestMessage
 	CmdNotifyVirtualSelectedParentBlueScoreChangedResponseMessage
 	CmdVirtualSelectedParentBlueScoreChangedNotificationMessage
+	CmdGetTransactionRequestMessage
+	CmdGetTransactionResponseMessage
+	CmdGetFeeEstimateRequestMessage
+	CmdGetFeeEstimateResponseMessage
+	CmdGetMempoolInfoRequestMessage
+	CmdGetMempoolInfoResponseMessage
+	CmdGetUTXOCacheStatsRequestMessage
+	CmdGetUTXOCacheStatsResponseMessage
 )
 
 // ProtocolMessageCommandToString maps all MessageCommands to their string representation
@@ -220,6 +228,14 @@ var RPCMessageCommandToString = map[MessageCommand]string{
 	CmdNotifyVirtualSelectedParentBlueScoreChangedRequestMessage:  "NotifyVirtualSelectedParentBlueScoreChangedRequest",
 	CmdNotifyVirtualSelectedParentBlueScoreChangedResponseMessage: "NotifyVirtualSelectedParentBlueScoreChangedResponse",
 	CmdVirtualSelectedParentBlueScoreChangedNotificationMessage:   "VirtualSelectedParentBlueScoreChangedNotification",
+	CmdGetTransactionRequestMessage:                               "GetTransactionRequest",
+	CmdGetTransactionResponseMessage:                              "GetTransactionResponse",
+	CmdGetFeeEstimateRequestMessage:                               "GetFeeEstimateRequest",
+	CmdGetFeeEstimateResponseMessage:                              "GetFeeEstimateResponse",
+	CmdGetMempoolInfoRequestMessage:                               "GetMempoolInfoRequest",
+	CmdGetMempoolInfoResponseMessage:                              "GetMempoolInfoResponse",
+	CmdGetUTXOCacheStatsRequestMessage:                            "GetUTXOCacheStatsRequest",
+	CmdGetUTXOCacheStatsResponseMessage:                           "GetUTXOCacheStatsResponse",
 }
 
 // Message is an interface that describes a kaspa message. A type that
@@ -5,12 +5,20 @@
 package appmessage
 
 import (
-	"github.com/kaspanet/kaspad/util/mstime"
+	"crypto/ed25519"
+	"encoding/base32"
 	"net"
+	"strconv"
+	"strings"
+
+	"github.com/kaspanet/kaspad/util/mstime"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
 )
 
 // NetAddress defines information about a peer on the network including the time
-// it was last seen, the services it supports, its IP address, and port.
+// it was last seen, the services it supports, its IP address or onion service,
+// and port.
 type NetAddress struct {
 	// Last time the address was seen.
 	Timestamp mstime.Time
@@ -18,9 +26,13 @@ type NetAddress struct {
 	// Bitfield which identifies the services supported by the address.
 	Services ServiceFlag
 
-	// IP address of the peer.
+	// IP address of the peer. It's nil if the peer is a Tor onion service.
 	IP net.IP
 
+	// OnionPublicKey is the ed25519 public key of the Tor v3 onion service
+	// of the peer. It's nil if the peer is reachable at IP.
+	OnionPublicKey []byte
+
 	// Port the peer is using. This is encoded in big endian on the appmessage
 	// which differs from most everything else.
 	Port uint16
@@ -37,7 +49,28 @@ func (na *NetAddress) AddService(service ServiceFlag) {
 	na.Services |= service
 }
 
-// TCPAddress converts the NetAddress to *net.TCPAddr
+// IsOnion returns whether the address is a Tor v3 onion service.
+func (na *NetAddress) IsOnion() bool {
+	return len(na.OnionPublicKey) != 0
+}
+
+// Host returns the IP of the address, or its .onion host name if it's
+// an onion service.
+func (na *NetAddress) Host() string {
+	if na.IsOnion() {
+		return OnionHost(na.OnionPublicKey)
+	}
+	return na.IP.String()
+}
+
+// String returns the host and port of the address, in the form accepted
+// by net.Dial.
+func (na *NetAddress) String() string {
+	return net.JoinHostPort(na.Host(), strconv.Itoa(int(na.Port)))
+}
+
+// TCPAddress converts the NetAddress to *net.TCPAddr. Onion services have
+// no IP, so their TCPAddress has a nil IP.
 func (na *NetAddress) TCPAddress() *net.TCPAddr {
 	return &net.TCPAddr{
 		IP:   na.IP,
@@ -72,3 +105,96 @@ func NewNetAddressTimestamp(
 func NewNetAddress(addr *net.TCPAddr, services ServiceFlag) *NetAddress {
 	return NewNetAddressIPPort(addr.IP, uint16(addr.Port), services)
 }
+
+// NewNetAddressOnion returns a new NetAddress using the provided onion
+// service public key, port, and supported services with defaults for the
+// remaining fields.
+func NewNetAddressOnion(onionPublicKey []byte, port uint16, services ServiceFlag) *NetAddress {
+	return &NetAddress{
+		Timestamp:      mstime.Now(),
+		Services:       services,
+		OnionPublicKey: onionPublicKey,
+		Port:           port,
+	}
+}
+
+// NewNetAddressHostPort returns a new NetAddress for the given host and
+// port, where host is either an IP or a .onion host name.
+func NewNetAddressHostPort(host string, port uint16, services ServiceFlag) (*NetAddress, error) {
+	if IsOnionHost(host) {
+		onionPublicKey, err := ParseOnionHost(host)
+		if err != nil {
+			return nil, err
+		}
+		return NewNetAddressOnion(onionPublicKey, port, services), nil
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, errors.Errorf("%s is neither an IP nor an onion host", host)
+	}
+	return NewNetAddressIPPort(ip, port, services), nil
+}
+
+// Tor v3 onion host names are defined in section 6 of
+// https://gitweb.torproject.org/torspec.git/tree/rend-spec-v3.txt
+const (
+	onionHostSuffix        = ".onion"
+	onionVersion           = 3
+	onionChecksumSize      = 2
+	onionChecksumPrefix    = ".onion checksum"
+	onionEncodedHostLength = 56
+)
+
+var onionEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// IsOnionHost returns whether host is a .onion host name. It doesn't
+// check that host is a valid onion service.
+func IsOnionHost(host string) bool {
+	return strings.HasSuffix(strings.ToLower(host), onionHostSuffix)
+}
+
+// OnionHost returns the .onion host name of the Tor v3 onion service
+// with the given public key.
+func OnionHost(onionPublicKey []byte) string {
+	encoded := make([]byte, 0, ed25519.PublicKeySize+onionChecksumSize+1)
+	encoded = append(encoded, onionPublicKey...)
+	encoded = append(encoded, onionChecksum(onionPublicKey)...)
+	encoded = append(encoded, onionVersion)
+	return strings.ToLower(onionEncoding.EncodeToString(encoded)) + onionHostSuffix
+}
+
+// ParseOnionHost returns the public key of the Tor v3 onion service with
+// the given .onion host name.
+func ParseOnionHost(host string) ([]byte, error) {
+	if !IsOnionHost(host) {
+		return nil, errors.Errorf("%s is not an onion host", host)
+	}
+	encodedHost := strings.ToUpper(host[:len(host)-len(onionHostSuffix)])
+	if len(encodedHost) != onionEncodedHostLength {
+		return nil, errors.Errorf("%s is not a Tor v3 onion host", host)
+	}
+	decoded, err := onionEncoding.DecodeString(encodedHost)
+	if err != nil {
+		return nil, errors.Wrapf(err, "malformed onion host %s", host)
+	}
+
+	onionPublicKey := decoded[:ed25519.PublicKeySize]
+	checksum := decoded[ed25519.PublicKeySize : ed25519.PublicKeySize+onionChecksumSize]
+	version := decoded[ed25519.PublicKeySize+onionChecksumSize]
+	if version != onionVersion {
+		return nil, errors.Errorf("onion host %s has unsupported version %d", host, version)
+	}
+	expectedChecksum := onionChecksum(onionPublicKey)
+	if checksum[0] != expectedChecksum[0] || checksum[1] != expectedChecksum[1] {
+		return nil, errors.Errorf("onion host %s has a wrong checksum", host)
+	}
+	return onionPublicKey, nil
+}
+
+func onionChecksum(onionPublicKey []byte) []byte {
+	hasher := sha3.New256()
+	hasher.Write([]byte(onionChecksumPrefix))
+	hasher.Write(onionPublicKey)
+	hasher.Write([]byte{onionVersion})
+	return hasher.Sum(nil)[:onionChecksumSize]
+}
@@ -6,6 +6,7 @@ package appmessage
 
 import (
 	"net"
+	"strings"
 	"testing"
 )
 
@@ -43,3 +44,52 @@ func TestNetAddress(t *testing.T) {
 		t.Errorf("HasService: SFNodeNetwork service not set")
 	}
 }
+
+// TestOnionNetAddress tests the NetAddress API with Tor v3 onion services.
+func TestOnionNetAddress(t *testing.T) {
+	const host = "2gzyxa5ihm7nsggfxnu52rck2vv4rvmdlkiu3zzui5du4xyclen53wid.onion"
+
+	na, err := NewNetAddressHostPort(host, 16111, SFNodeNetwork)
+	if err != nil {
+		t.Fatalf("NewNetAddressHostPort: %s", err)
+	}
+	if !na.IsOnion() || na.IP != nil {
+		t.Fatalf("NewNetAddressHostPort: expected an onion address, got IP %s", na.IP)
+	}
+	if na.Host() != host {
+		t.Errorf("Host: got %s, want %s", na.Host(), host)
+	}
+	if na.String() != host+":16111" {
+		t.Errorf("String: got %s, want %s", na.String(), host+":16111")
+	}
+
+	// Host names are case insensitive
+	onionPublicKey, err := ParseOnionHost(strings.ToUpper(host))
+	if err != nil {
+		t.Fatalf("ParseOnionHost: %s", err)
+	}
+	if OnionHost(onionPublicKey) != host {
+		t.Errorf("OnionHost: got %s, want %s", OnionHost(onionPublicKey), host)
+	}
+
+	invalidHosts := []string{
+		// Wrong checksum
+		"3gzyxa5ihm7nsggfxnu52rck2vv4rvmdlkiu3zzui5du4xyclen53wid.onion",
+		// Tor v2
+		"expyuzz4wqqyqhjn.onion",
+		// Not base32
+		"1gzyxa5ihm7nsggfxnu52rck2vv4rvmdlkiu3zzui5du4xyclen53wid.onion",
+		"example.com",
+	}
+	for _, invalidHost := range invalidHosts {
+		_, err := ParseOnionHost(invalidHost)
+		if err == nil {
+			t.Errorf("ParseOnionHost: expected %s to be rejected", invalidHost)
+		}
+	}
+
+	na = NewNetAddressIPPort(net.ParseIP("::1"), 16111, 0)
+	if na.IsOnion() || na.String() != "[::1]:16111" {
+		t.Errorf("String: got %s, want [::1]:16111", na.String())
+	}
+}
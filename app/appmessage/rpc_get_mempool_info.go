@@ -0,0 +1,53 @@
+package appmessage
+
+// GetMempoolInfoRequestMessage is an appmessage corresponding to
+// its respective RPC message
+type GetMempoolInfoRequestMessage struct {
+	baseMessage
+}
+
+// Command returns the protocol command string for the message
+func (msg *GetMempoolInfoRequestMessage) Command() MessageCommand {
+	return CmdGetMempoolInfoRequestMessage
+}
+
+// NewGetMempoolInfoRequestMessage returns a instance of the message
+func NewGetMempoolInfoRequestMessage() *GetMempoolInfoRequestMessage {
+	return &GetMempoolInfoRequestMessage{}
+}
+
+// GetMempoolInfoResponseMessage is an appmessage corresponding to
+// its respective RPC message
+type GetMempoolInfoResponseMessage struct {
+	baseMessage
+	TransactionCount        uint64
+	ChainedTransactionCount uint64
+	OrphanCount             uint64
+	TotalMass               uint64
+	MaxMass                 uint64
+	RollingMinimumFeeRate   float64
+	EvictedTransactionCount uint64
+
+	Error *RPCError
+}
+
+// Command returns the protocol command string for the message
+func (msg *GetMempoolInfoResponseMessage) Command() MessageCommand {
+	return CmdGetMempoolInfoResponseMessage
+}
+
+// NewGetMempoolInfoResponseMessage returns a instance of the message
+func NewGetMempoolInfoResponseMessage(transactionCount uint64, chainedTransactionCount uint64,
+	orphanCount uint64, totalMass uint64, maxMass uint64, rollingMinimumFeeRate float64,
+	evictedTransactionCount uint64) *GetMempoolInfoResponseMessage {
+
+	return &GetMempoolInfoResponseMessage{
+		TransactionCount:        transactionCount,
+		ChainedTransactionCount: chainedTransactionCount,
+		OrphanCount:             orphanCount,
+		TotalMass:               totalMass,
+		MaxMass:                 maxMass,
+		RollingMinimumFeeRate:   rollingMinimumFeeRate,
+		EvictedTransactionCount: evictedTransactionCount,
+	}
+}
@@ -0,0 +1,47 @@
+package appmessage
+
+// GetTransactionRequestMessage is an appmessage corresponding to
+// its respective RPC message
+type GetTransactionRequestMessage struct {
+	baseMessage
+	TxID string
+}
+
+// Command returns the protocol command string for the message
+func (msg *GetTransactionRequestMessage) Command() MessageCommand {
+	return CmdGetTransactionRequestMessage
+}
+
+// NewGetTransactionRequestMessage returns a instance of the message
+func NewGetTransactionRequestMessage(txID string) *GetTransactionRequestMessage {
+	return &GetTransactionRequestMessage{TxID: txID}
+}
+
+// GetTransactionResponseMessage is an appmessage corresponding to
+// its respective RPC message
+type GetTransactionResponseMessage struct {
+	baseMessage
+	TransactionVerboseData *TransactionVerboseData
+	BlockHash              string
+	AcceptingBlockHash     string
+	Confirmations          uint64
+
+	Error *RPCError
+}
+
+// Command returns the protocol command string for the message
+func (msg *GetTransactionResponseMessage) Command() MessageCommand {
+	return CmdGetTransactionResponseMessage
+}
+
+// NewGetTransactionResponseMessage returns a instance of the message
+func NewGetTransactionResponseMessage(transactionVerboseData *TransactionVerboseData,
+	blockHash string, acceptingBlockHash string, confirmations uint64) *GetTransactionResponseMessage {
+
+	return &GetTransactionResponseMessage{
+		TransactionVerboseData: transactionVerboseData,
+		BlockHash:              blockHash,
+		AcceptingBlockHash:     acceptingBlockHash,
+		Confirmations:          confirmations,
+	}
+}
@@ -0,0 +1,48 @@
+package appmessage
+
+// GetUTXOCacheStatsRequestMessage is an appmessage corresponding to
+// its respective RPC message
+type GetUTXOCacheStatsRequestMessage struct {
+	baseMessage
+}
+
+// Command returns the protocol command string for the message
+func (msg *GetUTXOCacheStatsRequestMessage) Command() MessageCommand {
+	return CmdGetUTXOCacheStatsRequestMessage
+}
+
+// NewGetUTXOCacheStatsRequestMessage returns a instance of the message
+func NewGetUTXOCacheStatsRequestMessage() *GetUTXOCacheStatsRequestMessage {
+	return &GetUTXOCacheStatsRequestMessage{}
+}
+
+// GetUTXOCacheStatsResponseMessage is an appmessage corresponding to
+// its respective RPC message
+type GetUTXOCacheStatsResponseMessage struct {
+	baseMessage
+	EntryCount uint64
+	Size       uint64
+	MaxSize    uint64
+	Hits       uint64
+	Misses     uint64
+
+	Error *RPCError
+}
+
+// Command returns the protocol command string for the message
+func (msg *GetUTXOCacheStatsResponseMessage) Command() MessageCommand {
+	return CmdGetUTXOCacheStatsResponseMessage
+}
+
+// NewGetUTXOCacheStatsResponseMessage returns a instance of the message
+func NewGetUTXOCacheStatsResponseMessage(entryCount uint64, size uint64, maxSize uint64,
+	hits uint64, misses uint64) *GetUTXOCacheStatsResponseMessage {
+
+	return &GetUTXOCacheStatsResponseMessage{
+		EntryCount: entryCount,
+		Size:       size,
+		MaxSize:    maxSize,
+		Hits:       hits,
+		Misses:     misses,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sync/atomic"
 
+	"github.com/kaspanet/kaspad/domain/txindex"
 	"github.com/kaspanet/kaspad/domain/utxoindex"
 
 	infrastructuredatabase "github.com/kaspanet/kaspad/infrastructure/db/database"
@@ -18,20 +19,29 @@ import (
 	"github.com/kaspanet/kaspad/app/protocol"
 	"github.com/kaspanet/kaspad/app/rpc"
 	"github.com/kaspanet/kaspad/infrastructure/config"
+	"github.com/kaspanet/kaspad/infrastructure/metrics"
 	"github.com/kaspanet/kaspad/infrastructure/network/connmanager"
 	"github.com/kaspanet/kaspad/infrastructure/network/dnsseed"
+	"github.com/kaspanet/kaspad/infrastructure/network/natmanager"
 	"github.com/kaspanet/kaspad/infrastructure/network/netadapter"
+	"github.com/kaspanet/kaspad/infrastructure/network/onionservice"
+	"github.com/kaspanet/kaspad/infrastructure/tracing"
 	"github.com/kaspanet/kaspad/util/panics"
 )
 
 // ComponentManager is a wrapper for all the kaspad services
 type ComponentManager struct {
 	cfg               *config.Config
+	domain            domain.Domain
 	addressManager    *addressmanager.AddressManager
 	protocolManager   *protocol.Manager
 	rpcManager        *rpc.Manager
 	connectionManager *connmanager.ConnectionManager
 	netAdapter        *netadapter.NetAdapter
+	natManager        *natmanager.Manager
+	onionService      *onionservice.Manager
+	metricsServer     *metrics.Server
+	tracer            *tracing.Tracer
 
 	started, shutdown int32
 }
@@ -45,11 +55,33 @@ func (a *ComponentManager) Start() {
 
 	log.Trace("Starting kaspad")
 
+	// The mempool is loaded before any new blocks or transactions
+	// arrive from the network. The saved mempool is only an optimization,
+	// so failing to load it doesn't stop the node from starting.
+	if a.shouldPersistMempool() {
+		err := a.domain.MiningManager().LoadMempool(mempoolFilePath(a.cfg))
+		if err != nil {
+			log.Errorf("Error loading the saved mempool: %+v", err)
+		}
+	}
+
 	err := a.netAdapter.Start()
 	if err != nil {
 		panics.Exit(log, fmt.Sprintf("Error starting the net adapter: %+v", err))
 	}
 
+	if a.natManager != nil {
+		a.natManager.Start()
+	}
+
+	if a.onionService != nil {
+		a.onionService.Start()
+	}
+
+	if a.metricsServer != nil {
+		a.metricsServer.Start()
+	}
+
 	a.maybeSeedFromDNS()
 
 	a.connectionManager.Start()
@@ -67,20 +99,67 @@ func (a *ComponentManager) Stop() {
 
 	a.connectionManager.Stop()
 
+	if a.natManager != nil {
+		a.natManager.Stop()
+	}
+
+	if a.onionService != nil {
+		a.onionService.Stop()
+	}
+
+	if a.metricsServer != nil {
+		err := a.metricsServer.Stop()
+		if err != nil {
+			log.Errorf("Error stopping the metrics server: %+v", err)
+		}
+	}
+
 	err := a.netAdapter.Stop()
 	if err != nil {
 		log.Errorf("Error stopping the net adapter: %+v", err)
 	}
 
+	if a.shouldPersistMempool() {
+		err = a.domain.MiningManager().SaveMempool(mempoolFilePath(a.cfg))
+		if err != nil {
+			log.Errorf("Error saving the mempool: %+v", err)
+		}
+	}
+
+	a.domain.Consensus().Close()
+
+	err = a.tracer.Close()
+	if err != nil {
+		log.Errorf("Error closing the trace file: %+v", err)
+	}
+
 	return
 }
 
+// shouldPersistMempool returns whether the mempool is saved on shutdown and
+// loaded on startup. It isn't with an in-memory database, since the DAG its
+// transactions were validated against is gone by the next start.
+func (a *ComponentManager) shouldPersistMempool() bool {
+	return a.cfg.DbType != config.MemoryDBType
+}
+
 // NewComponentManager returns a new ComponentManager instance.
 // Use Start() to begin all services within this ComponentManager
 func NewComponentManager(cfg *config.Config, db infrastructuredatabase.Database, interrupt chan<- struct{}) (
 	*ComponentManager, error) {
 
-	domain, err := domain.New(cfg.ActiveNetParams, db, cfg.IsArchivalNode)
+	var tracer *tracing.Tracer
+	if cfg.TraceFile != "" {
+		exporter, err := tracing.NewJSONFileExporter(cfg.TraceFile)
+		if err != nil {
+			return nil, err
+		}
+		tracer = tracing.New(exporter)
+		log.Infof("Tracing the processing of blocks into %s", cfg.TraceFile)
+	}
+
+	domain, err := domain.New(cfg.ActiveNetParams, db, cfg.IsArchivalNode, cfg.MaxMempoolMass, cfg.MaxUTXOCacheSize,
+		cfg.SigCacheMaxSize, cfg.ScriptVerifyWorkers, tracer)
 	if err != nil {
 		return nil, err
 	}
@@ -90,17 +169,44 @@ func NewComponentManager(cfg *config.Config, db infrastructuredatabase.Database,
 		return nil, err
 	}
 
-	addressManager, err := addressmanager.New(addressmanager.NewConfig(cfg))
+	addressManager, err := addressmanager.New(addressmanager.NewConfig(cfg), db)
 	if err != nil {
 		return nil, err
 	}
 
+	// Addresses given by --externalip are advertised instead of
+	// the one obtained from the NAT gateway
+	var natManager *natmanager.Manager
+	if cfg.Upnp && !cfg.DisableListen && len(cfg.ExternalIPs) == 0 {
+		natManager, err = natmanager.New(cfg, addressManager)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var onionService *onionservice.Manager
+	if cfg.TorControl != "" {
+		onionService, err = onionservice.New(cfg, addressManager)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var utxoIndex *utxoindex.UTXOIndex
 	if cfg.UTXOIndex {
 		utxoIndex = utxoindex.New(domain.Consensus(), db)
 		log.Infof("UTXO index started")
 	}
 
+	var txIndex *txindex.TXIndex
+	if cfg.TXIndex {
+		txIndex, err = txindex.New(domain.Consensus(), db)
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("Transaction index started")
+	}
+
 	connectionManager, err := connmanager.New(cfg, netAdapter, addressManager)
 	if err != nil {
 		return nil, err
@@ -109,14 +215,28 @@ func NewComponentManager(cfg *config.Config, db infrastructuredatabase.Database,
 	if err != nil {
 		return nil, err
 	}
-	rpcManager := setupRPC(cfg, domain, netAdapter, protocolManager, connectionManager, addressManager, utxoIndex, interrupt)
+	rpcManager := setupRPC(cfg, domain, netAdapter, protocolManager, connectionManager, addressManager, utxoIndex,
+		txIndex, interrupt)
+
+	var metricsServer *metrics.Server
+	if cfg.MetricsListen != "" {
+		metricsServer, err = metrics.NewServer(cfg.MetricsListen, newNodeCollector(domain, protocolManager))
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return &ComponentManager{
 		cfg:               cfg,
+		domain:            domain,
 		protocolManager:   protocolManager,
 		rpcManager:        rpcManager,
 		connectionManager: connectionManager,
 		netAdapter:        netAdapter,
+		natManager:        natManager,
+		onionService:      onionService,
+		metricsServer:     metricsServer,
+		tracer:            tracer,
 		addressManager:    addressManager,
 	}, nil
 
@@ -130,6 +250,7 @@ func setupRPC(
 	connectionManager *connmanager.ConnectionManager,
 	addressManager *addressmanager.AddressManager,
 	utxoIndex *utxoindex.UTXOIndex,
+	txIndex *txindex.TXIndex,
 	shutDownChan chan<- struct{},
 ) *rpc.Manager {
 
@@ -141,6 +262,7 @@ func setupRPC(
 		connectionManager,
 		addressManager,
 		utxoIndex,
+		txIndex,
 		shutDownChan,
 	)
 	protocolManager.SetOnBlockAddedToDAGHandler(rpcManager.NotifyBlockAddedToDAG)
@@ -155,12 +277,18 @@ func (a *ComponentManager) maybeSeedFromDNS() {
 				// Kaspad uses a lookup of the dns seeder here. Since seeder returns
 				// IPs of nodes and not its own IP, we can not know real IP of
 				// source. So we'll take first returned address as source.
-				a.addressManager.AddAddresses(addresses...)
+				err := a.addressManager.AddAddresses(addresses...)
+				if err != nil {
+					log.Errorf("Error adding addresses from DNS seed: %s", err)
+				}
 			})
 
 		dnsseed.SeedFromGRPC(a.cfg.NetParams(), a.cfg.GRPCSeed, appmessage.SFNodeNetwork, false, nil,
 			func(addresses []*appmessage.NetAddress) {
-				a.addressManager.AddAddresses(addresses...)
+				err := a.addressManager.AddAddresses(addresses...)
+				if err != nil {
+					log.Errorf("Error adding addresses from gRPC seed: %s", err)
+				}
 			})
 	}
 }
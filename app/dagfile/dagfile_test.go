@@ -0,0 +1,240 @@
+package dagfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaspanet/kaspad/domain/consensus"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/model/testapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/hashset"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/testutils"
+	"github.com/kaspanet/kaspad/domain/dagconfig"
+	"github.com/pkg/errors"
+)
+
+// addTestDAG adds to the given consensus a DAG with a block that merges a
+// side branch, and a tip that isn't in the past of the selected tip.
+// It returns the hashes of all the blocks.
+func addTestDAG(t *testing.T, tc testapi.TestConsensus) []*externalapi.DomainHash {
+	genesisHash := tc.DAGParams().GenesisHash
+	addBlock := func(parentHashes ...*externalapi.DomainHash) *externalapi.DomainHash {
+		blockHash, _, err := tc.AddBlock(parentHashes, nil, nil)
+		if err != nil {
+			t.Fatalf("AddBlock: %+v", err)
+		}
+		return blockHash
+	}
+
+	a := addBlock(genesisHash)
+	b := addBlock(a)
+	c := addBlock(genesisHash)
+	d := addBlock(b, c)
+	e := addBlock(d)
+	f := addBlock(e)
+	g := addBlock(b)
+	return []*externalapi.DomainHash{genesisHash, a, b, c, d, e, f, g}
+}
+
+func newTestFilePath(t *testing.T, testName string) (path string, teardown func()) {
+	tempDir, err := ioutil.TempDir("", testName)
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	return filepath.Join(tempDir, "dag.kdag"), func() { os.RemoveAll(tempDir) }
+}
+
+func TestExportAndImport(t *testing.T) {
+	testutils.ForAllNets(t, true, func(t *testing.T, params *dagconfig.Params) {
+		factory := consensus.NewFactory()
+		tc, teardown, err := factory.NewTestConsensus(params, false, "TestExportAndImport")
+		if err != nil {
+			t.Fatalf("Error setting up consensus: %+v", err)
+		}
+		defer teardown(false)
+		blockHashes := addTestDAG(t, tc)
+
+		path, teardownPath := newTestFilePath(t, "TestExportAndImport")
+		defer teardownPath()
+		err = ExportFile(tc, params.GenesisHash, path, false, nil)
+		if err != nil {
+			t.Fatalf("ExportFile: %+v", err)
+		}
+
+		importingTC, teardownImporting, err := factory.NewTestConsensus(params, false, "TestExportAndImport")
+		if err != nil {
+			t.Fatalf("Error setting up consensus: %+v", err)
+		}
+		defer teardownImporting(false)
+		err = ImportFile(importingTC, params.GenesisHash, path, nil)
+		if err != nil {
+			t.Fatalf("ImportFile: %+v", err)
+		}
+		for _, blockHash := range blockHashes {
+			expectedBlockInfo, err := tc.GetBlockInfo(blockHash)
+			if err != nil {
+				t.Fatalf("GetBlockInfo: %+v", err)
+			}
+			blockInfo, err := importingTC.GetBlockInfo(blockHash)
+			if err != nil {
+				t.Fatalf("GetBlockInfo: %+v", err)
+			}
+			// The status of blocks that aren't in the selected chain depends on
+			// the order in which they were inserted
+			if !blockInfo.Exists || blockInfo.BlueScore != expectedBlockInfo.BlueScore {
+				t.Fatalf("expected block %s to be imported as %+v, got %+v", blockHash, expectedBlockInfo, blockInfo)
+			}
+		}
+		expectedTips, err := tc.Tips()
+		if err != nil {
+			t.Fatalf("Tips: %+v", err)
+		}
+		tips, err := importingTC.Tips()
+		if err != nil {
+			t.Fatalf("Tips: %+v", err)
+		}
+		if len(tips) != len(expectedTips) || !hashset.NewFromSlice(tips...).ContainsAllInSlice(expectedTips) {
+			t.Fatalf("expected the tips to be %s, got %s", expectedTips, tips)
+		}
+		expectedVirtualSelectedParent, err := tc.GetVirtualSelectedParent()
+		if err != nil {
+			t.Fatalf("GetVirtualSelectedParent: %+v", err)
+		}
+		virtualSelectedParent, err := importingTC.GetVirtualSelectedParent()
+		if err != nil {
+			t.Fatalf("GetVirtualSelectedParent: %+v", err)
+		}
+		if !virtualSelectedParent.Equal(expectedVirtualSelectedParent) {
+			t.Fatalf("expected the virtual selected parent to be %s, got %s",
+				expectedVirtualSelectedParent, virtualSelectedParent)
+		}
+
+		// Importing the same file again, like after an interrupted import,
+		// skips all the blocks
+		err = ImportFile(importingTC, params.GenesisHash, path, nil)
+		if err != nil {
+			t.Fatalf("ImportFile: %+v", err)
+		}
+
+		// Only the headers are imported from a headers-only export
+		err = ExportFile(tc, params.GenesisHash, path, true, nil)
+		if err != nil {
+			t.Fatalf("ExportFile: %+v", err)
+		}
+		headersTC, teardownHeaders, err := factory.NewTestConsensus(params, false, "TestExportAndImport")
+		if err != nil {
+			t.Fatalf("Error setting up consensus: %+v", err)
+		}
+		defer teardownHeaders(false)
+		err = ImportFile(headersTC, params.GenesisHash, path, nil)
+		if err != nil {
+			t.Fatalf("ImportFile: %+v", err)
+		}
+		for _, blockHash := range blockHashes[1:] {
+			blockInfo, err := headersTC.GetBlockInfo(blockHash)
+			if err != nil {
+				t.Fatalf("GetBlockInfo: %+v", err)
+			}
+			if !blockInfo.Exists || blockInfo.BlockStatus != externalapi.StatusHeaderOnly {
+				t.Fatalf("expected the header of block %s to be imported, got %+v", blockHash, blockInfo)
+			}
+		}
+	})
+}
+
+func TestImportCorruptFile(t *testing.T) {
+	testutils.ForAllNets(t, true, func(t *testing.T, params *dagconfig.Params) {
+		factory := consensus.NewFactory()
+		tc, teardown, err := factory.NewTestConsensus(params, false, "TestImportCorruptFile")
+		if err != nil {
+			t.Fatalf("Error setting up consensus: %+v", err)
+		}
+		defer teardown(false)
+		addTestDAG(t, tc)
+
+		path, teardownPath := newTestFilePath(t, "TestImportCorruptFile")
+		defer teardownPath()
+		err = ExportFile(tc, params.GenesisHash, path, false, nil)
+		if err != nil {
+			t.Fatalf("ExportFile: %+v", err)
+		}
+		fileContent, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile: %s", err)
+		}
+
+		importCorruptFile := func(content []byte) error {
+			err := ioutil.WriteFile(path, content, 0600)
+			if err != nil {
+				t.Fatalf("WriteFile: %s", err)
+			}
+			importingTC, teardownImporting, err := factory.NewTestConsensus(params, false, "TestImportCorruptFile")
+			if err != nil {
+				t.Fatalf("Error setting up consensus: %+v", err)
+			}
+			defer teardownImporting(false)
+			return ImportFile(importingTC, params.GenesisHash, path, nil)
+		}
+
+		flipped := make([]byte, len(fileContent))
+		copy(flipped, fileContent)
+		flipped[len(flipped)-10] ^= 0xff
+		err = importCorruptFile(flipped)
+		if !errors.Is(err, ErrCorruptFile) {
+			t.Fatalf("expected a file with a flipped byte to be corrupt, got: %+v", err)
+		}
+
+		err = importCorruptFile(fileContent[:len(fileContent)-1])
+		if !errors.Is(err, ErrCorruptFile) {
+			t.Fatalf("expected a truncated file to be corrupt, got: %+v", err)
+		}
+
+		otherGenesis := make([]byte, len(fileContent))
+		copy(otherGenesis, fileContent)
+		otherGenesis[len(fileMagic)+4] ^= 0xff
+		err = importCorruptFile(otherGenesis)
+		if err == nil {
+			t.Fatalf("expected a file of another DAG not to be imported")
+		}
+	})
+}
+
+func TestInterrupt(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.SkipProofOfWork = true
+	tc, teardown, err := consensus.NewFactory().NewTestConsensus(&params, false, "TestInterrupt")
+	if err != nil {
+		t.Fatalf("Error setting up consensus: %+v", err)
+	}
+	defer teardown(false)
+	addTestDAG(t, tc)
+
+	path, teardownPath := newTestFilePath(t, "TestInterrupt")
+	defer teardownPath()
+	interrupt := make(chan struct{})
+	close(interrupt)
+
+	err = ExportFile(tc, params.GenesisHash, path, false, interrupt)
+	if !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("expected the export to be interrupted, got: %+v", err)
+	}
+	_, err = os.Stat(path)
+	if !os.IsNotExist(err) {
+		t.Fatalf("expected an interrupted export not to leave a file behind")
+	}
+	_, err = os.Stat(path + ".tmp")
+	if !os.IsNotExist(err) {
+		t.Fatalf("expected an interrupted export not to leave a temporary file behind")
+	}
+
+	err = ExportFile(tc, params.GenesisHash, path, false, nil)
+	if err != nil {
+		t.Fatalf("ExportFile: %+v", err)
+	}
+	err = ImportFile(tc, params.GenesisHash, path, interrupt)
+	if !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("expected the import to be interrupted, got: %+v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package dagfile
+
+import (
+	"os"
+
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/hashset"
+	"github.com/pkg/errors"
+)
+
+// GetHashesBetween is a relatively heavy operation, so the DAG is walked
+// in batches of blocks, like in IBD
+const exportMaxBlueScoreDifference = 1 << 10
+
+type exporter struct {
+	consensus   externalapi.Consensus
+	writer      *Writer
+	headersOnly bool
+	interrupt   <-chan struct{}
+	exported    hashset.HashSet
+	progress    *progressLogger
+}
+
+// ExportFile writes the DAG of the given consensus to the file at the given
+// path, in topological order, so that every block comes after its parents.
+//
+// If headersOnly is false, all the blocks in the past of the virtual are
+// written, which requires the node to be archival. Otherwise, only the
+// headers of the blocks in the past of the headers selected tip and of the
+// virtual are written.
+//
+// The file is only created once the export is complete, so an interrupted
+// export never leaves a partial file behind.
+func ExportFile(consensus externalapi.Consensus, genesisHash *externalapi.DomainHash, path string,
+	headersOnly bool, interrupt <-chan struct{}) error {
+
+	return writeFileAtomically(path, func(file *os.File) error {
+		return export(consensus, genesisHash, file, headersOnly, interrupt)
+	})
+}
+
+// writeFileAtomically creates the file at the given path with the content
+// that write writes to it. The content is written to a temporary file that
+// is only renamed to path once write succeeds.
+func writeFileAtomically(path string, write func(file *os.File) error) error {
+	tempPath := path + ".tmp"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = write(file)
+	closeErr := file.Close()
+	if err == nil {
+		err = errors.WithStack(closeErr)
+	}
+	if err != nil {
+		removeErr := os.Remove(tempPath)
+		if removeErr != nil {
+			log.Warnf("Error removing %s: %s", tempPath, removeErr)
+		}
+		return err
+	}
+
+	return errors.WithStack(os.Rename(tempPath, path))
+}
+
+func export(consensus externalapi.Consensus, genesisHash *externalapi.DomainHash, file *os.File,
+	headersOnly bool, interrupt <-chan struct{}) error {
+
+	writer, err := NewWriter(file, genesisHash)
+	if err != nil {
+		return err
+	}
+	e := &exporter{
+		consensus:   consensus,
+		writer:      writer,
+		headersOnly: headersOnly,
+		interrupt:   interrupt,
+		exported:    hashset.New(),
+		progress:    newProgressLogger("Exported"),
+	}
+
+	var highHash *externalapi.DomainHash
+	if headersOnly {
+		highHash, err = consensus.GetHeadersSelectedTip()
+	} else {
+		highHash, err = consensus.GetVirtualSelectedParent()
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Exporting the DAG up to block %s to %s", highHash, file.Name())
+	err = e.exportBlock(genesisHash)
+	if err != nil {
+		return err
+	}
+	err = e.exportPastOfSelectedChain(genesisHash, highHash)
+	if err != nil {
+		return err
+	}
+
+	// The tips that aren't in the past of highHash have some more blocks
+	// in their past
+	tips, err := consensus.Tips()
+	if err != nil {
+		return err
+	}
+	for _, tip := range tips {
+		err := e.exportRemainingPast(tip)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return err
+	}
+	log.Infof("Exported %d blocks", e.exported.Length())
+	return nil
+}
+
+// exportPastOfSelectedChain exports the blocks in the past of highHash
+// that aren't in the past of lowHash, which must be in the selected
+// chain of highHash
+func (e *exporter) exportPastOfSelectedChain(lowHash, highHash *externalapi.DomainHash) error {
+	for !lowHash.Equal(highHash) {
+		if isInterrupted(e.interrupt) {
+			return ErrInterrupted
+		}
+
+		blockHashes, err := e.consensus.GetHashesBetween(lowHash, highHash, exportMaxBlueScoreDifference)
+		if err != nil {
+			return err
+		}
+		for _, blockHash := range blockHashes {
+			if e.exported.Contains(blockHash) {
+				continue
+			}
+			err := e.exportBlock(blockHash)
+			if err != nil {
+				return err
+			}
+		}
+
+		// The next lowHash is the last element in blockHashes
+		lowHash = blockHashes[len(blockHashes)-1]
+	}
+	return nil
+}
+
+// exportRemainingPast exports the blocks in the past of the given block
+// that weren't exported yet. These are expected to be few, so they're
+// all collected and sorted in memory.
+func (e *exporter) exportRemainingPast(blockHash *externalapi.DomainHash) error {
+	remaining := hashset.New()
+	parents := make(map[externalapi.DomainHash][]*externalapi.DomainHash)
+	queue := []*externalapi.DomainHash{blockHash}
+	for len(queue) > 0 {
+		var current *externalapi.DomainHash
+		current, queue = queue[0], queue[1:]
+		if e.exported.Contains(current) || remaining.Contains(current) {
+			continue
+		}
+		remaining.Add(current)
+
+		header, err := e.consensus.GetBlockHeader(current)
+		if err != nil {
+			return err
+		}
+		parents[*current] = header.ParentHashes()
+		queue = append(queue, header.ParentHashes()...)
+	}
+
+	for remaining.Length() > 0 {
+		exportedAny := false
+		for _, current := range remaining.ToSlice() {
+			if !e.exported.ContainsAllInSlice(parents[*current]) {
+				continue
+			}
+			err := e.exportBlock(current)
+			if err != nil {
+				return err
+			}
+			remaining.Remove(current)
+			exportedAny = true
+		}
+		if !exportedAny {
+			return errors.Errorf("the parents of blocks %s could never be exported", remaining)
+		}
+	}
+	return nil
+}
+
+func (e *exporter) exportBlock(blockHash *externalapi.DomainHash) error {
+	var block *externalapi.DomainBlock
+	if e.headersOnly {
+		header, err := e.consensus.GetBlockHeader(blockHash)
+		if err != nil {
+			return err
+		}
+		block = &externalapi.DomainBlock{Header: header}
+	} else {
+		blockInfo, err := e.consensus.GetBlockInfo(blockHash)
+		if err != nil {
+			return err
+		}
+		if blockInfo.BlockStatus == externalapi.StatusHeaderOnly {
+			return errors.Errorf("block %s has no body. Full blocks can only be exported "+
+				"from archival nodes", blockHash)
+		}
+		block, err = e.consensus.GetBlock(blockHash)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := e.writer.WriteBlock(block)
+	if err != nil {
+		return err
+	}
+	e.exported.Add(blockHash)
+	e.progress.logBlock(block)
+	return nil
+}
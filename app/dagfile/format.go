@@ -0,0 +1,242 @@
+package dagfile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/server/grpcserver/protowire"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// A DAG file starts with a header made of fileMagic, the version of the
+// format, and the hash of the genesis of the DAG the blocks belong to.
+// Every block that follows is a record made of the length of the block,
+// the block serialized as a protowire BlockMessage, and the CRC-32C
+// checksum of the serialized block. Snapshot files share the same layout,
+// with different records.
+const (
+	fileMagic   = "KDAG"
+	fileVersion = uint32(1)
+)
+
+// maxRecordLength is the maximum length of a record. It protects
+// readers of corrupted files from allocating absurd amounts of memory.
+const maxRecordLength = appmessage.MaxMessagePayload
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrCorruptFile is returned when a DAG file or a snapshot file isn't well
+// formed
+var ErrCorruptFile = errors.New("corrupt file")
+
+// Writer writes blocks to a DAG file
+type Writer struct {
+	*recordWriter
+}
+
+// NewWriter returns a Writer that writes to the given writer, after writing
+// the header of the file for the DAG that starts with the given genesis
+func NewWriter(writer io.Writer, genesisHash *externalapi.DomainHash) (*Writer, error) {
+	w, err := newRecordWriter(writer, fileMagic, fileVersion, genesisHash)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{recordWriter: w}, nil
+}
+
+// WriteBlock writes the given block. A block without transactions is read
+// back as a header-only block.
+func (w *Writer) WriteBlock(block *externalapi.DomainBlock) error {
+	return w.writeBlock(block)
+}
+
+// Reader reads blocks from a DAG file
+type Reader struct {
+	*recordReader
+}
+
+// NewReader returns a Reader that reads from the given reader, after reading
+// the header of the file
+func NewReader(reader io.Reader) (*Reader, error) {
+	r, err := newRecordReader(reader, fileMagic, fileVersion, "DAG file")
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{recordReader: r}, nil
+}
+
+// ReadBlock reads the next block. It returns io.EOF once all the blocks
+// were read.
+func (r *Reader) ReadBlock() (*externalapi.DomainBlock, error) {
+	return r.readBlock()
+}
+
+// recordWriter writes the header of a file, followed by checksummed records
+type recordWriter struct {
+	writer *bufio.Writer
+}
+
+func newRecordWriter(writer io.Writer, magic string, version uint32,
+	genesisHash *externalapi.DomainHash) (*recordWriter, error) {
+
+	bufferedWriter := bufio.NewWriter(writer)
+
+	header := make([]byte, 0, len(magic)+4+externalapi.DomainHashSize)
+	header = append(header, magic...)
+	header = appendUint32(header, version)
+	header = append(header, genesisHash.ByteSlice()...)
+	_, err := bufferedWriter.Write(header)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &recordWriter{writer: bufferedWriter}, nil
+}
+
+func (w *recordWriter) writeRecord(payload []byte) error {
+	record := make([]byte, 0, 4+len(payload)+4)
+	record = appendUint32(record, uint32(len(payload)))
+	record = append(record, payload...)
+	record = appendUint32(record, crc32.Checksum(payload, crc32cTable))
+	_, err := w.writer.Write(record)
+	return errors.WithStack(err)
+}
+
+func (w *recordWriter) writeBlock(block *externalapi.DomainBlock) error {
+	message, err := protowire.FromAppMessage(appmessage.DomainBlockToMsgBlock(block))
+	if err != nil {
+		return err
+	}
+	serializedBlock, err := proto.Marshal(message.GetBlock())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return w.writeRecord(serializedBlock)
+}
+
+// Flush writes any buffered data to the underlying writer
+func (w *recordWriter) Flush() error {
+	return errors.WithStack(w.writer.Flush())
+}
+
+// recordReader reads the header of a file, followed by checksummed records
+type recordReader struct {
+	reader      *bufio.Reader
+	genesisHash *externalapi.DomainHash
+	offset      int64
+}
+
+func newRecordReader(reader io.Reader, magic string, version uint32, fileKind string) (*recordReader, error) {
+	r := &recordReader{reader: bufio.NewReader(reader)}
+
+	header := make([]byte, len(magic)+4+externalapi.DomainHashSize)
+	err := r.readFull(header)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.Wrapf(ErrCorruptFile, "the file is empty")
+		}
+		return nil, err
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, errors.Wrapf(ErrCorruptFile, "the file is not a %s", fileKind)
+	}
+	fileVersion := binary.LittleEndian.Uint32(header[len(magic):])
+	if fileVersion != version {
+		return nil, errors.Errorf("unsupported %s version %d", fileKind, fileVersion)
+	}
+	r.genesisHash, err = externalapi.NewDomainHashFromByteSlice(header[len(magic)+4:])
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GenesisHash returns the hash of the genesis of the DAG in the file
+func (r *recordReader) GenesisHash() *externalapi.DomainHash {
+	return r.genesisHash
+}
+
+// Offset returns the number of bytes that were read from the file so far
+func (r *recordReader) Offset() int64 {
+	return r.offset
+}
+
+// readRecord reads the payload of the next record. It returns io.EOF if
+// the file ends before the record.
+func (r *recordReader) readRecord() ([]byte, error) {
+	lengthBytes := make([]byte, 4)
+	err := r.readFull(lengthBytes)
+	if err != nil {
+		// The file may only end between records
+		return nil, err
+	}
+	length := binary.LittleEndian.Uint32(lengthBytes)
+	if length > maxRecordLength {
+		return nil, errors.Wrapf(ErrCorruptFile, "the record at offset %d is %d bytes long, which is more "+
+			"than the maximum of %d", r.offset-4, length, maxRecordLength)
+	}
+
+	record := make([]byte, length+4)
+	err = r.readFull(record)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.Wrapf(ErrCorruptFile, "the file ends in the middle of a record")
+		}
+		return nil, err
+	}
+	payload := record[:length]
+	checksum := binary.LittleEndian.Uint32(record[length:])
+	if crc32.Checksum(payload, crc32cTable) != checksum {
+		return nil, errors.Wrapf(ErrCorruptFile, "bad checksum of the record at offset %d",
+			r.offset-int64(len(record))-4)
+	}
+	return payload, nil
+}
+
+func (r *recordReader) readBlock() (*externalapi.DomainBlock, error) {
+	serializedBlock, err := r.readRecord()
+	if err != nil {
+		return nil, err
+	}
+
+	blockMessage := &protowire.BlockMessage{}
+	err = proto.Unmarshal(serializedBlock, blockMessage)
+	if err != nil {
+		return nil, errors.Wrapf(ErrCorruptFile, "error deserializing block: %s", err)
+	}
+	message := &protowire.KaspadMessage{Payload: &protowire.KaspadMessage_Block{Block: blockMessage}}
+	appMessage, err := message.ToAppMessage()
+	if err != nil {
+		return nil, err
+	}
+	return appmessage.MsgBlockToDomainBlock(appMessage.(*appmessage.MsgBlock)), nil
+}
+
+// readFull fills the given buffer. It returns io.EOF if nothing was read,
+// and io.ErrUnexpectedEOF if the file ended before the buffer was filled.
+func (r *recordReader) readFull(buffer []byte) error {
+	n, err := io.ReadFull(r.reader, buffer)
+	r.offset += int64(n)
+	if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return errors.Wrapf(ErrCorruptFile, "the file ends in the middle of a record")
+		}
+		if errors.Is(err, io.EOF) {
+			return err
+		}
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func appendUint32(bytes []byte, value uint32) []byte {
+	var valueBytes [4]byte
+	binary.LittleEndian.PutUint32(valueBytes[:], value)
+	return append(bytes, valueBytes[:]...)
+}
@@ -0,0 +1,100 @@
+package dagfile
+
+import (
+	"io"
+	"os"
+
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/pkg/errors"
+)
+
+// ErrInterrupted is returned when an import or an export is interrupted
+var ErrInterrupted = errors.New("interrupted")
+
+// ImportFile validates and inserts into the given consensus all the blocks
+// in the DAG file at the given path. Blocks that consensus already has are
+// skipped, so an interrupted import is resumed by importing the same file
+// again.
+func ImportFile(consensus externalapi.Consensus, genesisHash *externalapi.DomainHash, path string,
+	interrupt <-chan struct{}) error {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	reader, err := NewReader(file)
+	if err != nil {
+		return err
+	}
+	err = validateFileGenesis(path, reader.GenesisHash(), genesisHash)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Importing the DAG from %s", path)
+	progress := newProgressLogger("Imported")
+	progress.fileSize = fileInfo.Size()
+	importedCount, skippedCount := 0, 0
+	for {
+		if isInterrupted(interrupt) {
+			return ErrInterrupted
+		}
+
+		block, err := reader.ReadBlock()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		progress.offset = reader.Offset()
+
+		blockHash := consensushashing.BlockHash(block)
+		blockInfo, err := consensus.GetBlockInfo(blockHash)
+		if err != nil {
+			return err
+		}
+		isHeaderOnly := len(block.Transactions) == 0
+		if blockInfo.Exists && (blockInfo.BlockStatus != externalapi.StatusHeaderOnly || isHeaderOnly) {
+			log.Debugf("Skipping block %s which is already known", blockHash)
+			skippedCount++
+			progress.logBlock(block)
+			continue
+		}
+
+		_, err = consensus.ValidateAndInsertBlock(block)
+		if err != nil {
+			return errors.Wrapf(err, "error importing block %s", blockHash)
+		}
+		importedCount++
+		progress.logBlock(block)
+	}
+
+	log.Infof("Imported %d blocks, and skipped %d blocks that were already known", importedCount, skippedCount)
+	return nil
+}
+
+func validateFileGenesis(path string, fileGenesisHash, genesisHash *externalapi.DomainHash) error {
+	if !fileGenesisHash.Equal(genesisHash) {
+		return errors.Errorf("%s belongs to the DAG with genesis %s, but this node's genesis is %s",
+			path, fileGenesisHash, genesisHash)
+	}
+	return nil
+}
+
+func isInterrupted(interrupt <-chan struct{}) bool {
+	select {
+	case <-interrupt:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,7 @@
+package dagfile
+
+import (
+	"github.com/kaspanet/kaspad/infrastructure/logger"
+)
+
+var log, _ = logger.Get(logger.SubsystemTags.DAGF)
@@ -0,0 +1,69 @@
+package dagfile
+
+import (
+	"time"
+
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/util/mstime"
+)
+
+// progressLogInterval is the minimum time between two progress messages
+const progressLogInterval = 10 * time.Second
+
+// progressLogger logs the progress of an import or an export. In order to
+// prevent spam, it limits logging to one message every progressLogInterval.
+type progressLogger struct {
+	verb        string
+	blockCount  int
+	utxoCount   int
+	lastLogTime time.Time
+
+	// fileSize and offset are the size of the file that is read and how
+	// much of it was read, if the progress is of reading a file
+	fileSize int64
+	offset   int64
+}
+
+func newProgressLogger(verb string) *progressLogger {
+	return &progressLogger{
+		verb:        verb,
+		lastLogTime: time.Now(),
+	}
+}
+
+func (p *progressLogger) logBlock(block *externalapi.DomainBlock) {
+	p.blockCount++
+	if !p.shouldLog() {
+		return
+	}
+
+	blockTime := mstime.UnixMilliseconds(block.Header.TimeInMilliseconds())
+	if p.fileSize > 0 {
+		log.Infof("%s %d blocks so far, %.2f%% of the file (%s)", p.verb, p.blockCount,
+			float64(p.offset)*100/float64(p.fileSize), blockTime)
+		return
+	}
+	log.Infof("%s %d blocks so far (%s)", p.verb, p.blockCount, blockTime)
+}
+
+func (p *progressLogger) logUTXOs(count int) {
+	p.utxoCount += count
+	if !p.shouldLog() {
+		return
+	}
+
+	if p.fileSize > 0 {
+		log.Infof("%s %d UTXOs so far, %.2f%% of the file", p.verb, p.utxoCount,
+			float64(p.offset)*100/float64(p.fileSize))
+		return
+	}
+	log.Infof("%s %d UTXOs so far", p.verb, p.utxoCount)
+}
+
+func (p *progressLogger) shouldLog() bool {
+	if time.Since(p.lastLogTime) < progressLogInterval {
+		return false
+	}
+	p.lastLogTime = time.Now()
+	return true
+}
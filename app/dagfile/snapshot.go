@@ -0,0 +1,218 @@
+package dagfile
+
+import (
+	"io"
+	"os"
+
+	"github.com/kaspanet/kaspad/domain/consensus/model"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/multiset"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/utxo"
+	"github.com/pkg/errors"
+)
+
+// snapshotChunkSize is the number of UTXOs in every chunk of a snapshot
+// file. It's the same as the size of the chunks that are sent in IBD.
+const snapshotChunkSize = 1000
+
+// ExportSnapshot writes the pruning point of the given consensus and its UTXO
+// set to the snapshot file at the given path. The UTXO set is verified
+// against the UTXO commitment of the pruning point before the file is
+// created.
+func ExportSnapshot(consensus externalapi.Consensus, genesisHash *externalapi.DomainHash, path string,
+	interrupt <-chan struct{}) error {
+
+	return writeFileAtomically(path, func(file *os.File) error {
+		return exportSnapshot(consensus, genesisHash, file, interrupt)
+	})
+}
+
+func exportSnapshot(consensus externalapi.Consensus, genesisHash *externalapi.DomainHash, file *os.File,
+	interrupt <-chan struct{}) error {
+
+	pruningPointHash, err := consensus.PruningPoint()
+	if err != nil {
+		return err
+	}
+	if pruningPointHash.Equal(genesisHash) {
+		return errors.Errorf("the pruning point is still the genesis, so there's no UTXO set to export")
+	}
+	pruningPoint, err := consensus.GetBlock(pruningPointHash)
+	if err != nil {
+		return err
+	}
+
+	writer, err := NewSnapshotWriter(file, genesisHash, pruningPoint)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Exporting the UTXO set of pruning point %s to %s", pruningPointHash, file.Name())
+	progress := newProgressLogger("Exported")
+	utxoSetMultiset := multiset.New()
+	var fromOutpoint *externalapi.DomainOutpoint
+	for {
+		if isInterrupted(interrupt) {
+			return ErrInterrupted
+		}
+
+		outpointAndUTXOEntryPairs, err := consensus.GetPruningPointUTXOs(pruningPointHash, fromOutpoint,
+			snapshotChunkSize)
+		if err != nil {
+			return err
+		}
+		if len(outpointAndUTXOEntryPairs) > 0 {
+			err := writer.WriteUTXOs(outpointAndUTXOEntryPairs)
+			if err != nil {
+				return err
+			}
+			err = addUTXOsToMultiset(utxoSetMultiset, outpointAndUTXOEntryPairs)
+			if err != nil {
+				return err
+			}
+			progress.logUTXOs(len(outpointAndUTXOEntryPairs))
+		}
+
+		if len(outpointAndUTXOEntryPairs) < snapshotChunkSize {
+			break
+		}
+		fromOutpoint = outpointAndUTXOEntryPairs[len(outpointAndUTXOEntryPairs)-1].Outpoint
+	}
+
+	// This is a sanity check, to make sure that a snapshot that can't be
+	// imported is never written
+	if !utxoSetMultiset.Hash().Equal(pruningPoint.Header.UTXOCommitment()) {
+		return errors.Errorf("the UTXO set of pruning point %s doesn't match its UTXO commitment %s",
+			pruningPointHash, pruningPoint.Header.UTXOCommitment())
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return err
+	}
+	log.Infof("Exported %d UTXOs", progress.utxoCount)
+	return nil
+}
+
+// SnapshotPruningPointHash returns the hash of the pruning point of the
+// snapshot file at the given path
+func SnapshotPruningPointHash(path string) (*externalapi.DomainHash, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer file.Close()
+
+	reader, err := NewSnapshotReader(file)
+	if err != nil {
+		return nil, err
+	}
+	return consensushashing.BlockHash(reader.PruningPoint()), nil
+}
+
+// ImportSnapshot imports the pruning point and its UTXO set from the snapshot
+// file at the given path into the given consensus, the same way they are
+// imported in IBD. The header of the pruning point, and enough headers above
+// it for it to be a valid pruning point, must already be in consensus.
+//
+// The blocks above the pruning point can then be synced from peers, or
+// imported from a DAG file. An interrupted import is started over by
+// importing the same file again.
+func ImportSnapshot(consensus externalapi.Consensus, genesisHash *externalapi.DomainHash, path string,
+	interrupt <-chan struct{}) (err error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	reader, err := NewSnapshotReader(file)
+	if err != nil {
+		return err
+	}
+	err = validateFileGenesis(path, reader.GenesisHash(), genesisHash)
+	if err != nil {
+		return err
+	}
+
+	pruningPoint := reader.PruningPoint()
+	pruningPointHash := consensushashing.BlockHash(pruningPoint)
+	blockInfo, err := consensus.GetBlockInfo(pruningPointHash)
+	if err != nil {
+		return err
+	}
+	if !blockInfo.Exists {
+		return errors.Errorf("the header of pruning point %s is missing. The headers have to be synced "+
+			"or imported before the snapshot", pruningPointHash)
+	}
+	if blockInfo.BlockStatus != externalapi.StatusHeaderOnly {
+		log.Infof("Already has the block data of pruning point %s", pruningPointHash)
+		return nil
+	}
+	isValidPruningPoint, err := consensus.IsValidPruningPoint(pruningPointHash)
+	if err != nil {
+		return err
+	}
+	if !isValidPruningPoint {
+		return errors.Errorf("%s is not a valid pruning point of this node's DAG", pruningPointHash)
+	}
+
+	// Clear the UTXOs of any previous import that was interrupted, and of
+	// this import once it's done
+	err = consensus.ClearImportedPruningPointData()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		clearErr := consensus.ClearImportedPruningPointData()
+		if err == nil {
+			err = clearErr
+		}
+	}()
+
+	log.Infof("Importing the UTXO set of pruning point %s from %s", pruningPointHash, path)
+	progress := newProgressLogger("Imported")
+	progress.fileSize = fileInfo.Size()
+	for {
+		if isInterrupted(interrupt) {
+			return ErrInterrupted
+		}
+
+		outpointAndUTXOEntryPairs, err := reader.ReadUTXOs()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		err = consensus.AppendImportedPruningPointUTXOs(outpointAndUTXOEntryPairs)
+		if err != nil {
+			return err
+		}
+		progress.offset = reader.Offset()
+		progress.logUTXOs(len(outpointAndUTXOEntryPairs))
+	}
+	log.Infof("Imported %d UTXOs", progress.utxoCount)
+
+	return consensus.ValidateAndInsertImportedPruningPoint(pruningPoint)
+}
+
+func addUTXOsToMultiset(utxoSetMultiset model.Multiset,
+	outpointAndUTXOEntryPairs []*externalapi.OutpointAndUTXOEntryPair) error {
+
+	for _, outpointAndUTXOEntryPair := range outpointAndUTXOEntryPairs {
+		serializedUTXO, err := utxo.SerializeUTXO(outpointAndUTXOEntryPair.UTXOEntry, outpointAndUTXOEntryPair.Outpoint)
+		if err != nil {
+			return err
+		}
+		utxoSetMultiset.Add(serializedUTXO)
+	}
+	return nil
+}
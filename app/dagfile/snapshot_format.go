@@ -0,0 +1,136 @@
+package dagfile
+
+import (
+	"io"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/domain/consensus/model"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/multiset"
+	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/server/grpcserver/protowire"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// A snapshot file has the same header and records as a DAG file, with
+// snapshotFileMagic instead of fileMagic. Its first record is the pruning
+// point block, and every record that follows is a chunk of the UTXO set of
+// the pruning point, serialized as a protowire PruningPointUtxoSetChunkMessage.
+const (
+	snapshotFileMagic   = "KUTX"
+	snapshotFileVersion = uint32(1)
+)
+
+// SnapshotWriter writes the UTXO set of a pruning point to a snapshot file
+type SnapshotWriter struct {
+	*recordWriter
+}
+
+// NewSnapshotWriter returns a SnapshotWriter that writes to the given writer,
+// after writing the header of the file and the given pruning point block
+func NewSnapshotWriter(writer io.Writer, genesisHash *externalapi.DomainHash,
+	pruningPoint *externalapi.DomainBlock) (*SnapshotWriter, error) {
+
+	w, err := newRecordWriter(writer, snapshotFileMagic, snapshotFileVersion, genesisHash)
+	if err != nil {
+		return nil, err
+	}
+	err = w.writeBlock(pruningPoint)
+	if err != nil {
+		return nil, err
+	}
+	return &SnapshotWriter{recordWriter: w}, nil
+}
+
+// WriteUTXOs writes the given chunk of the UTXO set
+func (w *SnapshotWriter) WriteUTXOs(outpointAndUTXOEntryPairs []*externalapi.OutpointAndUTXOEntryPair) error {
+	message, err := protowire.FromAppMessage(appmessage.NewMsgPruningPointUTXOSetChunk(
+		appmessage.DomainOutpointAndUTXOEntryPairsToOutpointAndUTXOEntryPairs(outpointAndUTXOEntryPairs)))
+	if err != nil {
+		return err
+	}
+	serializedChunk, err := proto.Marshal(message.GetPruningPointUtxoSetChunk())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return w.writeRecord(serializedChunk)
+}
+
+// SnapshotReader reads the UTXO set of a pruning point from a snapshot file.
+// It verifies the UTXO set against the UTXO commitment of the pruning point
+// while it is read.
+type SnapshotReader struct {
+	*recordReader
+	pruningPoint *externalapi.DomainBlock
+	multiset     model.Multiset
+}
+
+// NewSnapshotReader returns a SnapshotReader that reads from the given reader,
+// after reading the header of the file and the pruning point block
+func NewSnapshotReader(reader io.Reader) (*SnapshotReader, error) {
+	r, err := newRecordReader(reader, snapshotFileMagic, snapshotFileVersion, "snapshot file")
+	if err != nil {
+		return nil, err
+	}
+	pruningPoint, err := r.readBlock()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.Wrapf(ErrCorruptFile, "the snapshot has no pruning point")
+		}
+		return nil, err
+	}
+	return &SnapshotReader{
+		recordReader: r,
+		pruningPoint: pruningPoint,
+		multiset:     multiset.New(),
+	}, nil
+}
+
+// PruningPoint returns the pruning point block of the snapshot
+func (r *SnapshotReader) PruningPoint() *externalapi.DomainBlock {
+	return r.pruningPoint
+}
+
+// ReadUTXOs reads the next chunk of the UTXO set. It returns io.EOF once the
+// whole UTXO set was read, and only if it matches the UTXO commitment of the
+// pruning point.
+func (r *SnapshotReader) ReadUTXOs() ([]*externalapi.OutpointAndUTXOEntryPair, error) {
+	serializedChunk, err := r.readRecord()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, r.verifyUTXOCommitment()
+		}
+		return nil, err
+	}
+
+	chunkMessage := &protowire.PruningPointUtxoSetChunkMessage{}
+	err = proto.Unmarshal(serializedChunk, chunkMessage)
+	if err != nil {
+		return nil, errors.Wrapf(ErrCorruptFile, "error deserializing UTXO set chunk: %s", err)
+	}
+	message := &protowire.KaspadMessage{Payload: &protowire.KaspadMessage_PruningPointUtxoSetChunk{
+		PruningPointUtxoSetChunk: chunkMessage,
+	}}
+	appMessage, err := message.ToAppMessage()
+	if err != nil {
+		return nil, err
+	}
+	outpointAndUTXOEntryPairs := appmessage.OutpointAndUTXOEntryPairsToDomainOutpointAndUTXOEntryPairs(
+		appMessage.(*appmessage.MsgPruningPointUTXOSetChunk).OutpointAndUTXOEntryPairs)
+
+	err = addUTXOsToMultiset(r.multiset, outpointAndUTXOEntryPairs)
+	if err != nil {
+		return nil, err
+	}
+	return outpointAndUTXOEntryPairs, nil
+}
+
+func (r *SnapshotReader) verifyUTXOCommitment() error {
+	utxoCommitment := r.pruningPoint.Header.UTXOCommitment()
+	if !r.multiset.Hash().Equal(utxoCommitment) {
+		return errors.Wrapf(ErrCorruptFile, "the UTXO set in the snapshot doesn't match the UTXO commitment %s "+
+			"of pruning point %s", utxoCommitment, consensushashing.BlockHash(r.pruningPoint))
+	}
+	return io.EOF
+}
@@ -0,0 +1,244 @@
+package dagfile
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/domain/consensus"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/model/testapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/testutils"
+	"github.com/kaspanet/kaspad/domain/dagconfig"
+	"github.com/pkg/errors"
+)
+
+// addBlocksUntilPruningPointMoves adds blocks to the given consensus until
+// its pruning point is no longer the genesis. The params are expected to
+// have a small finality duration.
+func addBlocksUntilPruningPointMoves(t *testing.T, tc testapi.TestConsensus) {
+	tipHash := tc.DAGParams().GenesisHash
+	for {
+		var err error
+		tipHash, _, err = tc.AddBlock([]*externalapi.DomainHash{tipHash}, nil, nil)
+		if err != nil {
+			t.Fatalf("AddBlock: %+v", err)
+		}
+
+		pruningPoint, err := tc.PruningPoint()
+		if err != nil {
+			t.Fatalf("PruningPoint: %+v", err)
+		}
+		if !pruningPoint.Equal(tc.DAGParams().GenesisHash) {
+			return
+		}
+	}
+}
+
+func TestExportAndImportSnapshot(t *testing.T) {
+	testutils.ForAllNets(t, true, func(t *testing.T, params *dagconfig.Params) {
+		// This is done to reduce the pruning depth to 6 blocks
+		finalityDepth := 3
+		params.FinalityDuration = time.Duration(finalityDepth) * params.TargetTimePerBlock
+		params.K = 0
+
+		factory := consensus.NewFactory()
+		tcSyncer, teardownSyncer, err := factory.NewTestConsensus(params, false, "TestExportAndImportSnapshotSyncer")
+		if err != nil {
+			t.Fatalf("Error setting up tcSyncer: %+v", err)
+		}
+		defer teardownSyncer(false)
+		tcSyncee, teardownSyncee, err := factory.NewTestConsensus(params, false, "TestExportAndImportSnapshotSyncee")
+		if err != nil {
+			t.Fatalf("Error setting up tcSyncee: %+v", err)
+		}
+		defer teardownSyncee(false)
+		addBlocksUntilPruningPointMoves(t, tcSyncer)
+
+		path, teardownPath := newTestFilePath(t, "TestExportAndImportSnapshot")
+		defer teardownPath()
+
+		// A snapshot can't be imported before the headers
+		err = ExportSnapshot(tcSyncer, params.GenesisHash, path, nil)
+		if err != nil {
+			t.Fatalf("ExportSnapshot: %+v", err)
+		}
+		err = ImportSnapshot(tcSyncee, params.GenesisHash, path, nil)
+		if err == nil {
+			t.Fatalf("expected a snapshot not to be imported without the header of its pruning point")
+		}
+
+		headersPath, teardownHeadersPath := newTestFilePath(t, "TestExportAndImportSnapshotHeaders")
+		defer teardownHeadersPath()
+		err = ExportFile(tcSyncer, params.GenesisHash, headersPath, true, nil)
+		if err != nil {
+			t.Fatalf("ExportFile: %+v", err)
+		}
+		err = ImportFile(tcSyncee, params.GenesisHash, headersPath, nil)
+		if err != nil {
+			t.Fatalf("ImportFile: %+v", err)
+		}
+		err = ImportSnapshot(tcSyncee, params.GenesisHash, path, nil)
+		if err != nil {
+			t.Fatalf("ImportSnapshot: %+v", err)
+		}
+
+		pruningPoint, err := tcSyncer.PruningPoint()
+		if err != nil {
+			t.Fatalf("PruningPoint: %+v", err)
+		}
+		snapshotPruningPoint, err := SnapshotPruningPointHash(path)
+		if err != nil {
+			t.Fatalf("SnapshotPruningPointHash: %+v", err)
+		}
+		if !snapshotPruningPoint.Equal(pruningPoint) {
+			t.Fatalf("expected the pruning point of the snapshot to be %s, got %s", pruningPoint, snapshotPruningPoint)
+		}
+		blockInfo, err := tcSyncee.GetBlockInfo(pruningPoint)
+		if err != nil {
+			t.Fatalf("GetBlockInfo: %+v", err)
+		}
+		if blockInfo.BlockStatus == externalapi.StatusHeaderOnly {
+			t.Fatalf("expected the block of the pruning point to be imported")
+		}
+		expectedUTXOs, err := tcSyncer.GetPruningPointUTXOs(pruningPoint, nil, 1000)
+		if err != nil {
+			t.Fatalf("GetPruningPointUTXOs: %+v", err)
+		}
+		utxos, err := tcSyncee.GetPruningPointUTXOs(pruningPoint, nil, 1000)
+		if err != nil {
+			t.Fatalf("GetPruningPointUTXOs: %+v", err)
+		}
+		if len(utxos) != len(expectedUTXOs) {
+			t.Fatalf("expected %d UTXOs to be imported, got %d", len(expectedUTXOs), len(utxos))
+		}
+
+		// The blocks above the pruning point are synced like in IBD
+		headersSelectedTip, err := tcSyncee.GetHeadersSelectedTip()
+		if err != nil {
+			t.Fatalf("GetHeadersSelectedTip: %+v", err)
+		}
+		missingBlockBodyHashes, err := tcSyncee.GetMissingBlockBodyHashes(headersSelectedTip)
+		if err != nil {
+			t.Fatalf("GetMissingBlockBodyHashes: %+v", err)
+		}
+		for _, blockHash := range missingBlockBodyHashes {
+			block, err := tcSyncer.GetBlock(blockHash)
+			if err != nil {
+				t.Fatalf("GetBlock: %+v", err)
+			}
+			_, err = tcSyncee.ValidateAndInsertBlock(block)
+			if err != nil {
+				t.Fatalf("ValidateAndInsertBlock: %+v", err)
+			}
+		}
+		expectedVirtualSelectedParent, err := tcSyncer.GetVirtualSelectedParent()
+		if err != nil {
+			t.Fatalf("GetVirtualSelectedParent: %+v", err)
+		}
+		virtualSelectedParent, err := tcSyncee.GetVirtualSelectedParent()
+		if err != nil {
+			t.Fatalf("GetVirtualSelectedParent: %+v", err)
+		}
+		if !virtualSelectedParent.Equal(expectedVirtualSelectedParent) {
+			t.Fatalf("expected the virtual selected parent to be %s, got %s",
+				expectedVirtualSelectedParent, virtualSelectedParent)
+		}
+	})
+}
+
+func TestImportCorruptSnapshot(t *testing.T) {
+	testutils.ForAllNets(t, true, func(t *testing.T, params *dagconfig.Params) {
+		finalityDepth := 3
+		params.FinalityDuration = time.Duration(finalityDepth) * params.TargetTimePerBlock
+		params.K = 0
+
+		factory := consensus.NewFactory()
+		tc, teardown, err := factory.NewTestConsensus(params, false, "TestImportCorruptSnapshot")
+		if err != nil {
+			t.Fatalf("Error setting up consensus: %+v", err)
+		}
+		defer teardown(false)
+		addBlocksUntilPruningPointMoves(t, tc)
+
+		path, teardownPath := newTestFilePath(t, "TestImportCorruptSnapshot")
+		defer teardownPath()
+		headersPath, teardownHeadersPath := newTestFilePath(t, "TestImportCorruptSnapshotHeaders")
+		defer teardownHeadersPath()
+		err = ExportFile(tc, params.GenesisHash, headersPath, true, nil)
+		if err != nil {
+			t.Fatalf("ExportFile: %+v", err)
+		}
+
+		importCorruptSnapshot := func() error {
+			importingTC, teardownImporting, err := factory.NewTestConsensus(params, false, "TestImportCorruptSnapshot")
+			if err != nil {
+				t.Fatalf("Error setting up consensus: %+v", err)
+			}
+			defer teardownImporting(false)
+			err = ImportFile(importingTC, params.GenesisHash, headersPath, nil)
+			if err != nil {
+				t.Fatalf("ImportFile: %+v", err)
+			}
+			return ImportSnapshot(importingTC, params.GenesisHash, path, nil)
+		}
+
+		err = ExportSnapshot(tc, params.GenesisHash, path, nil)
+		if err != nil {
+			t.Fatalf("ExportSnapshot: %+v", err)
+		}
+		fileContent, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile: %s", err)
+		}
+		fileContent[len(fileContent)-10] ^= 0xff
+		err = ioutil.WriteFile(path, fileContent, 0600)
+		if err != nil {
+			t.Fatalf("WriteFile: %s", err)
+		}
+		err = importCorruptSnapshot()
+		if !errors.Is(err, ErrCorruptFile) {
+			t.Fatalf("expected a snapshot with a flipped byte to be corrupt, got: %+v", err)
+		}
+
+		// A snapshot with well formed records but a missing UTXO doesn't
+		// match the UTXO commitment of the pruning point
+		pruningPointHash, err := tc.PruningPoint()
+		if err != nil {
+			t.Fatalf("PruningPoint: %+v", err)
+		}
+		pruningPoint, err := tc.GetBlock(pruningPointHash)
+		if err != nil {
+			t.Fatalf("GetBlock: %+v", err)
+		}
+		utxos, err := tc.GetPruningPointUTXOs(pruningPointHash, nil, 1000)
+		if err != nil {
+			t.Fatalf("GetPruningPointUTXOs: %+v", err)
+		}
+		if len(utxos) < 2 {
+			t.Fatalf("expected the pruning point to have at least 2 UTXOs, got %d", len(utxos))
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatalf("Create: %s", err)
+		}
+		writer, err := NewSnapshotWriter(file, params.GenesisHash, pruningPoint)
+		if err != nil {
+			t.Fatalf("NewSnapshotWriter: %+v", err)
+		}
+		err = writer.WriteUTXOs(utxos[1:])
+		if err != nil {
+			t.Fatalf("WriteUTXOs: %+v", err)
+		}
+		err = writer.Flush()
+		if err != nil {
+			t.Fatalf("Flush: %+v", err)
+		}
+		file.Close()
+		err = importCorruptSnapshot()
+		if !errors.Is(err, ErrCorruptFile) {
+			t.Fatalf("expected a snapshot with a missing UTXO to be corrupt, got: %+v", err)
+		}
+	})
+}
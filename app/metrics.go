@@ -0,0 +1,122 @@
+package app
+
+import (
+	"github.com/kaspanet/kaspad/app/protocol"
+	"github.com/kaspanet/kaspad/domain"
+	"github.com/kaspanet/kaspad/infrastructure/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// nodeCollector collects the metrics that are read from the components of
+// the node whenever the metrics are scraped
+type nodeCollector struct {
+	domain          domain.Domain
+	protocolManager *protocol.Manager
+
+	mempoolTransactions        *prometheus.Desc
+	mempoolChainedTransactions *prometheus.Desc
+	mempoolOrphans             *prometheus.Desc
+	mempoolMass                *prometheus.Desc
+	mempoolMaxMass             *prometheus.Desc
+	peers                      *prometheus.Desc
+	virtualBlueScore           *prometheus.Desc
+	utxoCacheEntries           *prometheus.Desc
+	utxoCacheBytes             *prometheus.Desc
+	utxoCacheMaxBytes          *prometheus.Desc
+	utxoCacheHits              *prometheus.Desc
+	utxoCacheMisses            *prometheus.Desc
+}
+
+func newNodeCollector(domain domain.Domain, protocolManager *protocol.Manager) *nodeCollector {
+	newDesc := func(subsystem string, name string, help string, variableLabels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metrics.Namespace, subsystem, name), help, variableLabels, nil)
+	}
+
+	return &nodeCollector{
+		domain:          domain,
+		protocolManager: protocolManager,
+
+		mempoolTransactions: newDesc("mempool", "transactions",
+			"Number of transactions in the mempool that can be included in the next block"),
+		mempoolChainedTransactions: newDesc("mempool", "chained_transactions",
+			"Number of transactions in the mempool that spend outputs of other transactions in the mempool"),
+		mempoolOrphans: newDesc("mempool", "orphans",
+			"Number of orphan transactions"),
+		mempoolMass: newDesc("mempool", "mass",
+			"Total mass of the transactions in the mempool"),
+		mempoolMaxMass: newDesc("mempool", "max_mass",
+			"Total mass of the transactions in the mempool beyond which transactions are evicted"),
+		peers: newDesc("network", "peers",
+			"Number of peers that completed their handshake, by direction", "direction"),
+		virtualBlueScore: newDesc("consensus", "virtual_blue_score",
+			"Blue score of the virtual block"),
+		utxoCacheEntries: newDesc("consensus", "utxo_cache_entries",
+			"Number of entries in the UTXO set cache"),
+		utxoCacheBytes: newDesc("consensus", "utxo_cache_bytes",
+			"Estimated size of the entries in the UTXO set cache"),
+		utxoCacheMaxBytes: newDesc("consensus", "utxo_cache_max_bytes",
+			"Size beyond which entries are evicted from the UTXO set cache"),
+		utxoCacheHits: newDesc("consensus", "utxo_cache_hits_total",
+			"Number of UTXO set lookups that were served by the cache"),
+		utxoCacheMisses: newDesc("consensus", "utxo_cache_misses_total",
+			"Number of UTXO set lookups that had to read from the database"),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *nodeCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.mempoolTransactions
+	descs <- c.mempoolChainedTransactions
+	descs <- c.mempoolOrphans
+	descs <- c.mempoolMass
+	descs <- c.mempoolMaxMass
+	descs <- c.peers
+	descs <- c.virtualBlueScore
+	descs <- c.utxoCacheEntries
+	descs <- c.utxoCacheBytes
+	descs <- c.utxoCacheMaxBytes
+	descs <- c.utxoCacheHits
+	descs <- c.utxoCacheMisses
+}
+
+// Collect implements prometheus.Collector
+func (c *nodeCollector) Collect(collectedMetrics chan<- prometheus.Metric) {
+	gauge := func(desc *prometheus.Desc, value float64, labelValues ...string) {
+		collectedMetrics <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labelValues...)
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		collectedMetrics <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+
+	mempoolInfo := c.domain.MiningManager().GetMempoolInfo()
+	gauge(c.mempoolTransactions, float64(mempoolInfo.TransactionCount))
+	gauge(c.mempoolChainedTransactions, float64(mempoolInfo.ChainedTransactionCount))
+	gauge(c.mempoolOrphans, float64(mempoolInfo.OrphanCount))
+	gauge(c.mempoolMass, float64(mempoolInfo.TotalMass))
+	gauge(c.mempoolMaxMass, float64(mempoolInfo.MaxMass))
+
+	inboundCount, outboundCount := 0, 0
+	for _, peer := range c.protocolManager.Peers() {
+		if peer.IsOutbound() {
+			outboundCount++
+		} else {
+			inboundCount++
+		}
+	}
+	gauge(c.peers, float64(inboundCount), "inbound")
+	gauge(c.peers, float64(outboundCount), "outbound")
+
+	virtualInfo, err := c.domain.Consensus().GetVirtualInfo()
+	if err != nil {
+		collectedMetrics <- prometheus.NewInvalidMetric(c.virtualBlueScore, err)
+	} else {
+		gauge(c.virtualBlueScore, float64(virtualInfo.BlueScore))
+	}
+
+	utxoCacheStats := c.domain.Consensus().GetUTXOCacheStats()
+	gauge(c.utxoCacheEntries, float64(utxoCacheStats.EntryCount))
+	gauge(c.utxoCacheBytes, float64(utxoCacheStats.Size))
+	gauge(c.utxoCacheMaxBytes, float64(utxoCacheStats.MaxSize))
+	counter(c.utxoCacheHits, float64(utxoCacheStats.Hits))
+	counter(c.utxoCacheMisses, float64(utxoCacheStats.Misses))
+}
@@ -41,6 +41,88 @@ func (f *FlowContext) RemoveFromPeers(peer *peerpkg.Peer) {
 	delete(f.peers, *peer.ID())
 }
 
+// IncreaseBanScore increases the ban score associated with the address of
+// the given connection by the given amount, and returns the resulting score.
+// Ban scores are kept per address, rather than per peer, so that a
+// misbehaving peer can't reset its score by reconnecting, and so that all
+// the connections from the same address add to the same score.
+func (f *FlowContext) IncreaseBanScore(netConnection *netadapter.NetConnection, amount uint32) uint32 {
+	f.banScoresMutex.Lock()
+	defer f.banScoresMutex.Unlock()
+
+	key := netConnection.NetAddress().Host()
+	banScore, ok := f.banScores[key]
+	if !ok {
+		f.pruneBanScoresNoLock()
+		banScore = peerpkg.NewBanScore()
+		f.banScores[key] = banScore
+	}
+	return banScore.Increase(amount)
+}
+
+// BanScore returns the current ban score associated with the address of the
+// given connection
+func (f *FlowContext) BanScore(netConnection *netadapter.NetConnection) uint32 {
+	f.banScoresMutex.Lock()
+	defer f.banScoresMutex.Unlock()
+
+	banScore, ok := f.banScores[netConnection.NetAddress().Host()]
+	if !ok {
+		return 0
+	}
+	return banScore.Value()
+}
+
+// ForgetBanScore stops tracking the ban score of the address of the
+// given connection. This is used once the address is banned.
+func (f *FlowContext) ForgetBanScore(netConnection *netadapter.NetConnection) {
+	f.banScoresMutex.Lock()
+	defer f.banScoresMutex.Unlock()
+
+	delete(f.banScores, netConnection.NetAddress().Host())
+}
+
+// pruneBanScoresNoLock removes all the ban scores that have fully decayed.
+// This is safe because ban scores never leave the lock, so a removed score
+// is indistinguishable from a new one.
+func (f *FlowContext) pruneBanScoresNoLock() {
+	for key, banScore := range f.banScores {
+		if banScore.Value() == 0 {
+			delete(f.banScores, key)
+		}
+	}
+}
+
+// IsWhitelisted returns whether the address of the given connection
+// is in one of the whitelisted IP networks. Whitelisted peers never
+// have their ban score increased.
+func (f *FlowContext) IsWhitelisted(netConnection *netadapter.NetConnection) bool {
+	return f.connectionManager.IsWhitelisted(netConnection)
+}
+
+// PeerStats returns what the connection manager needs to know about the
+// ready peer on the given connection in order to decide whether to evict it
+func (f *FlowContext) PeerStats(netConnection *netadapter.NetConnection) (*connmanager.PeerStats, bool) {
+	peerID := netConnection.ID()
+	if peerID == nil {
+		return nil, false
+	}
+
+	f.peersMutex.RLock()
+	defer f.peersMutex.RUnlock()
+
+	peer, ok := f.peers[*peerID]
+	if !ok || peer.Connection() != netConnection {
+		return nil, false
+	}
+	return &connmanager.PeerStats{
+		PingDuration:        peer.LastPingDuration(),
+		LastBlockTime:       peer.LastBlockTime(),
+		LastTransactionTime: peer.LastTransactionTime(),
+		TimeConnected:       peer.TimeConnected(),
+	}, true
+}
+
 // readyPeerConnections returns the NetConnections of all the ready peers.
 func (f *FlowContext) readyPeerConnections() []*netadapter.NetConnection {
 	f.peersMutex.RLock()
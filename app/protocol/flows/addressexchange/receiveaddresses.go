@@ -32,9 +32,9 @@ func ReceiveAddresses(context ReceiveAddressesContext, incomingRoute *router.Rou
 
 	msgAddresses := message.(*appmessage.MsgAddresses)
 	if len(msgAddresses.AddressList) > addressmanager.GetAddressesMax {
-		return protocolerrors.Errorf(true, "address count exceeded %d", addressmanager.GetAddressesMax)
+		return protocolerrors.ErrorfWithMisbehavior(protocolerrors.SevereMisbehavior, "address count exceeded %d", addressmanager.GetAddressesMax)
 	}
 
-	context.AddressManager().AddAddresses(msgAddresses.AddressList...)
-	return nil
+	sourceAddress := peer.Connection().NetAddress()
+	return context.AddressManager().AddAddressesFromSource(sourceAddress, msgAddresses.AddressList...)
 }
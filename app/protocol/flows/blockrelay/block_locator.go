@@ -22,7 +22,7 @@ func (flow *handleRelayInvsFlow) receiveBlockLocator() (blockLocatorHashes []*ex
 	msgBlockLocator, ok := message.(*appmessage.MsgBlockLocator)
 	if !ok {
 		return nil,
-			protocolerrors.Errorf(true, "received unexpected message type. "+
+			protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "received unexpected message type. "+
 				"expected: %s, got: %s", appmessage.CmdBlockLocator, message.Command())
 	}
 	return msgBlockLocator.BlockLocatorHashes, nil
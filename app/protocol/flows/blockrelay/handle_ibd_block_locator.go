@@ -34,7 +34,7 @@ func HandleIBDBlockLocator(context HandleIBDBlockLocatorContext, incomingRoute *
 			return err
 		}
 		if !blockInfo.Exists {
-			return protocolerrors.Errorf(true, "received IBDBlockLocator "+
+			return protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "received IBDBlockLocator "+
 				"with an unknown targetHash %s", targetHash)
 		}
 
@@ -70,7 +70,7 @@ func HandleIBDBlockLocator(context HandleIBDBlockLocatorContext, incomingRoute *
 		}
 
 		if !foundHighestHashInTheSelectedParentChainOfTargetHash {
-			return protocolerrors.Errorf(true, "no hash was found in the blockLocator "+
+			return protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "no hash was found in the blockLocator "+
 				"that was in the selected parent chain of targetHash %s", targetHash)
 		}
 	}
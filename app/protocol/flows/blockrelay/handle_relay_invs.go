@@ -72,7 +72,7 @@ func (flow *handleRelayInvsFlow) start() error {
 		}
 		if blockInfo.Exists && blockInfo.BlockStatus != externalapi.StatusHeaderOnly {
 			if blockInfo.BlockStatus == externalapi.StatusInvalid {
-				return protocolerrors.Errorf(true, "sent inv of an invalid block %s",
+				return protocolerrors.ErrorfWithMisbehavior(protocolerrors.SevereMisbehavior, "sent inv of an invalid block %s",
 					inv.Hash)
 			}
 			log.Debugf("Block %s already exists. continuing...", inv.Hash)
@@ -128,6 +128,7 @@ func (flow *handleRelayInvsFlow) start() error {
 			return err
 		}
 		log.Infof("Accepted block %s via relay", inv.Hash)
+		flow.peer.UpdateLastBlockTime()
 		err = flow.OnNewBlock(block, blockInsertionResult)
 		if err != nil {
 			return err
@@ -149,7 +150,7 @@ func (flow *handleRelayInvsFlow) readInv() (*appmessage.MsgInvRelayBlock, error)
 
 	inv, ok := msg.(*appmessage.MsgInvRelayBlock)
 	if !ok {
-		return nil, protocolerrors.Errorf(true, "unexpected %s message in the block relay handleRelayInvsFlow while "+
+		return nil, protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "unexpected %s message in the block relay handleRelayInvsFlow while "+
 			"expecting an inv message", msg.Command())
 	}
 	return inv, nil
@@ -179,7 +180,7 @@ func (flow *handleRelayInvsFlow) requestBlock(requestHash *externalapi.DomainHas
 	block := appmessage.MsgBlockToDomainBlock(msgBlock)
 	blockHash := consensushashing.BlockHash(block)
 	if !blockHash.Equal(requestHash) {
-		return nil, false, protocolerrors.Errorf(true, "got unrequested block %s", blockHash)
+		return nil, false, protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "got unrequested block %s", blockHash)
 	}
 
 	return block, false, nil
@@ -219,7 +220,7 @@ func (flow *handleRelayInvsFlow) processBlock(block *externalapi.DomainBlock) ([
 			return missingParentsError.MissingParentHashes, nil, nil
 		}
 		log.Warnf("Rejected block %s from %s: %s", blockHash, flow.peer, err)
-		return nil, nil, protocolerrors.Wrapf(true, err, "got invalid block %s from relay", blockHash)
+		return nil, nil, protocolerrors.WrapfWithMisbehavior(protocolerrors.SevereMisbehavior, err, "got invalid block %s from relay", blockHash)
 	}
 	return nil, blockInsertionResult, nil
 }
@@ -44,7 +44,7 @@ func (flow *handleRequestBlockLocatorFlow) start() error {
 			if err != nil {
 				log.Debugf("Received error from CreateBlockLocator: %s", err)
 			}
-			return protocolerrors.Errorf(true, "couldn't build a block "+
+			return protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "couldn't build a block "+
 				"locator between blocks %s and %s", lowHash, highHash)
 		}
 
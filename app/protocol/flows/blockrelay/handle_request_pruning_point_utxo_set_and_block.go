@@ -74,7 +74,7 @@ func (flow *handleRequestPruningPointUTXOSetAndBlockFlow) waitForRequestPruningP
 	}
 	msgRequestPruningPointUTXOSetAndBlock, ok := message.(*appmessage.MsgRequestPruningPointUTXOSetAndBlock)
 	if !ok {
-		return nil, protocolerrors.Errorf(true, "received unexpected message type. "+
+		return nil, protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "received unexpected message type. "+
 			"expected: %s, got: %s", appmessage.CmdRequestPruningPointUTXOSetAndBlock, message.Command())
 	}
 	return msgRequestPruningPointUTXOSetAndBlock, nil
@@ -136,7 +136,7 @@ func (flow *handleRequestPruningPointUTXOSetAndBlockFlow) sendPruningPointUTXOSe
 			}
 			_, ok := message.(*appmessage.MsgRequestNextPruningPointUTXOSetChunk)
 			if !ok {
-				return protocolerrors.Errorf(true, "received unexpected message type. "+
+				return protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "received unexpected message type. "+
 					"expected: %s, got: %s", appmessage.CmdRequestNextPruningPointUTXOSetChunk, message.Command())
 			}
 		}
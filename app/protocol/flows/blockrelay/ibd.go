@@ -9,6 +9,7 @@ import (
 	"github.com/kaspanet/kaspad/domain/consensus/model"
 
 	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/app/dagfile"
 	"github.com/kaspanet/kaspad/app/protocol/common"
 	"github.com/kaspanet/kaspad/app/protocol/protocolerrors"
 	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
@@ -25,6 +26,9 @@ func (flow *handleRelayInvsFlow) runIBDIfNotRunning(highHash *externalapi.Domain
 	}
 	defer flow.UnsetIBDRunning()
 
+	ibdRunningGauge.Set(1)
+	defer ibdRunningGauge.Set(0)
+
 	log.Debugf("IBD started with peer %s and highHash %s", flow.peer, highHash)
 
 	log.Debugf("Syncing headers up to %s", highHash)
@@ -150,7 +154,7 @@ func (flow *handleRelayInvsFlow) findHighestHashIndex(
 		}
 	}
 	if !highestHashIndexFound {
-		return 0, protocolerrors.Errorf(true, "highest hash %s "+
+		return 0, protocolerrors.ErrorfWithMisbehavior(protocolerrors.SevereMisbehavior, "highest hash %s "+
 			"returned from peer %s is not in the original blockLocator", highestHash, flow.peer)
 	}
 	log.Debugf("The index of the highest hash in the original "+
@@ -173,7 +177,7 @@ func (flow *handleRelayInvsFlow) fetchHighestHash(
 	}
 	ibdBlockLocatorHighestHashMessage, ok := message.(*appmessage.MsgIBDBlockLocatorHighestHash)
 	if !ok {
-		return nil, protocolerrors.Errorf(true, "received unexpected message type. "+
+		return nil, protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "received unexpected message type. "+
 			"expected: %s, got: %s", appmessage.CmdIBDBlockLocatorHighestHash, message.Command())
 	}
 	highestHash := ibdBlockLocatorHighestHashMessage.HighestHash
@@ -254,7 +258,7 @@ func (flow *handleRelayInvsFlow) receiveHeaders() (msgIBDBlock *appmessage.Block
 		return nil, true, nil
 	default:
 		return nil, false,
-			protocolerrors.Errorf(true, "received unexpected message type. "+
+			protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "received unexpected message type. "+
 				"expected: %s or %s, got: %s", appmessage.CmdHeader, appmessage.CmdDoneHeaders, message.Command())
 	}
 }
@@ -285,9 +289,10 @@ func (flow *handleRelayInvsFlow) processHeader(msgBlockHeader *appmessage.MsgBlo
 			log.Debugf("Skipping block header %s as it is a duplicate", blockHash)
 		} else {
 			log.Infof("Rejected block header %s from %s during IBD: %s", blockHash, flow.peer, err)
-			return protocolerrors.Wrapf(true, err, "got invalid block header %s during IBD", blockHash)
+			return protocolerrors.WrapfWithMisbehavior(protocolerrors.SevereMisbehavior, err, "got invalid block header %s during IBD", blockHash)
 		}
 	}
+	ibdHeadersProcessedCounter.Inc()
 
 	return nil
 }
@@ -304,7 +309,7 @@ func (flow *handleRelayInvsFlow) syncPruningPointUTXOSet() (bool, error) {
 	}
 	msgPruningPointHash, ok := message.(*appmessage.MsgPruningPointHashMessage)
 	if !ok {
-		return false, protocolerrors.Errorf(true, "received unexpected message type. "+
+		return false, protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "received unexpected message type. "+
 			"expected: %s, got: %s", appmessage.CmdPruningPointHash, message.Command())
 	}
 
@@ -334,6 +339,10 @@ func (flow *handleRelayInvsFlow) syncPruningPointUTXOSet() (bool, error) {
 		return false, nil
 	}
 
+	if flow.Config().UTXOSnapshot != "" && flow.importUTXOSnapshot(msgPruningPointHash.Hash) {
+		return true, nil
+	}
+
 	log.Info("Fetching the pruning point UTXO set")
 	succeed, err := flow.fetchMissingUTXOSet(msgPruningPointHash.Hash)
 	if err != nil {
@@ -387,6 +396,34 @@ func (flow *handleRelayInvsFlow) fetchMissingUTXOSet(pruningPointHash *externala
 	return true, nil
 }
 
+// importUTXOSnapshot imports the pruning point UTXO set from the configured
+// snapshot file, if it's of the given pruning point. It returns false if it
+// didn't, in which case the UTXO set should be fetched from the peer.
+func (flow *handleRelayInvsFlow) importUTXOSnapshot(pruningPointHash *externalapi.DomainHash) bool {
+	snapshotPath := flow.Config().UTXOSnapshot
+	snapshotPruningPointHash, err := dagfile.SnapshotPruningPointHash(snapshotPath)
+	if err != nil {
+		log.Warnf("Couldn't read the UTXO snapshot %s: %s", snapshotPath, err)
+		return false
+	}
+	if !snapshotPruningPointHash.Equal(pruningPointHash) {
+		log.Infof("The UTXO snapshot is of pruning point %s rather than %s, so fetching the "+
+			"pruning point UTXO set from peer %s", snapshotPruningPointHash, pruningPointHash, flow.peer)
+		return false
+	}
+
+	log.Infof("Importing the pruning point UTXO set from the UTXO snapshot %s", snapshotPath)
+	err = dagfile.ImportSnapshot(flow.Domain().Consensus(), flow.Config().ActiveNetParams.GenesisHash,
+		snapshotPath, nil)
+	if err != nil {
+		log.Warnf("Couldn't import the UTXO snapshot %s, so fetching the pruning point UTXO set "+
+			"from peer %s: %s", snapshotPath, flow.peer, err)
+		return false
+	}
+	log.Info("Imported the pruning point UTXO set from the UTXO snapshot")
+	return true
+}
+
 func (flow *handleRelayInvsFlow) receivePruningPointBlock() (*externalapi.DomainBlock, error) {
 	onEnd := logger.LogAndMeasureExecutionTime(log, "receivePruningPointBlock")
 	defer onEnd()
@@ -398,7 +435,7 @@ func (flow *handleRelayInvsFlow) receivePruningPointBlock() (*externalapi.Domain
 
 	ibdBlockMessage, ok := message.(*appmessage.MsgIBDBlock)
 	if !ok {
-		return nil, protocolerrors.Errorf(true, "received unexpected message type. "+
+		return nil, protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "received unexpected message type. "+
 			"expected: %s, got: %s", appmessage.CmdIBDBlock, message.Command())
 	}
 	block := appmessage.MsgBlockToDomainBlock(ibdBlockMessage.MsgBlock)
@@ -425,6 +462,7 @@ func (flow *handleRelayInvsFlow) receiveAndInsertPruningPointUTXOSet(
 		switch message := message.(type) {
 		case *appmessage.MsgPruningPointUTXOSetChunk:
 			receivedUTXOCount += len(message.OutpointAndUTXOEntryPairs)
+			ibdPruningPointUTXOsReceivedCounter.Add(float64(len(message.OutpointAndUTXOEntryPairs)))
 			domainOutpointAndUTXOEntryPairs :=
 				appmessage.OutpointAndUTXOEntryPairsToDomainOutpointAndUTXOEntryPairs(message.OutpointAndUTXOEntryPairs)
 
@@ -455,7 +493,7 @@ func (flow *handleRelayInvsFlow) receiveAndInsertPruningPointUTXOSet(
 			return false, nil
 
 		default:
-			return false, protocolerrors.Errorf(true, "received unexpected message type. "+
+			return false, protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "received unexpected message type. "+
 				"expected: %s or %s or %s, got: %s", appmessage.CmdPruningPointUTXOSetChunk,
 				appmessage.CmdDonePruningPointUTXOSetChunks, appmessage.CmdUnexpectedPruningPoint, message.Command(),
 			)
@@ -468,6 +506,8 @@ func (flow *handleRelayInvsFlow) syncMissingBlockBodies(highHash *externalapi.Do
 	if err != nil {
 		return err
 	}
+	ibdBlockBodiesRemainingGauge.Set(float64(len(hashes)))
+	defer ibdBlockBodiesRemainingGauge.Set(0)
 
 	for offset := 0; offset < len(hashes); offset += ibdBatchSize {
 		var hashesToRequest []*externalapi.DomainHash
@@ -490,16 +530,17 @@ func (flow *handleRelayInvsFlow) syncMissingBlockBodies(highHash *externalapi.Do
 
 			msgIBDBlock, ok := message.(*appmessage.MsgIBDBlock)
 			if !ok {
-				return protocolerrors.Errorf(true, "received unexpected message type. "+
+				return protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "received unexpected message type. "+
 					"expected: %s, got: %s", appmessage.CmdIBDBlock, message.Command())
 			}
 
 			block := appmessage.MsgBlockToDomainBlock(msgIBDBlock.MsgBlock)
 			blockHash := consensushashing.BlockHash(block)
 			if !expectedHash.Equal(blockHash) {
-				return protocolerrors.Errorf(true, "expected block %s but got %s", expectedHash, blockHash)
+				return protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "expected block %s but got %s", expectedHash, blockHash)
 			}
 
+			ibdBlockBodiesRemainingGauge.Dec()
 			blockInsertionResult, err := flow.Domain().Consensus().ValidateAndInsertBlock(block)
 			if err != nil {
 				if errors.Is(err, ruleerrors.ErrDuplicateBlock) {
@@ -508,6 +549,7 @@ func (flow *handleRelayInvsFlow) syncMissingBlockBodies(highHash *externalapi.Do
 				}
 				return protocolerrors.ConvertToBanningProtocolErrorIfRuleError(err, "invalid block %s", blockHash)
 			}
+			ibdBlockBodiesProcessedCounter.Inc()
 			err = flow.OnNewBlock(block, blockInsertionResult)
 			if err != nil {
 				return err
@@ -0,0 +1,44 @@
+package blockrelay
+
+import (
+	"github.com/kaspanet/kaspad/infrastructure/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	ibdRunningGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "ibd",
+		Name:      "running",
+		Help:      "Whether IBD is running",
+	})
+
+	ibdHeadersProcessedCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "ibd",
+		Name:      "headers_processed_total",
+		Help:      "Number of block headers that were received and processed during IBD",
+	})
+
+	ibdPruningPointUTXOsReceivedCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "ibd",
+		Name:      "pruning_point_utxos_received_total",
+		Help:      "Number of UTXOs of the pruning point UTXO set that were received during IBD",
+	})
+
+	ibdBlockBodiesRemainingGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "ibd",
+		Name:      "block_bodies_remaining",
+		Help:      "Number of block bodies that the running IBD has yet to download",
+	})
+
+	ibdBlockBodiesProcessedCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "ibd",
+		Name:      "block_bodies_processed_total",
+		Help:      "Number of block bodies that were received and processed during IBD",
+	})
+)
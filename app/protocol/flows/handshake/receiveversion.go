@@ -56,7 +56,7 @@ func (flow *receiveVersionFlow) start() (*appmessage.NetAddress, error) {
 
 	msgVersion, ok := message.(*appmessage.MsgVersion)
 	if !ok {
-		return nil, protocolerrors.New(true, "a version message must precede all others")
+		return nil, protocolerrors.NewWithMisbehavior(protocolerrors.MinorMisbehavior, "a version message must precede all others")
 	}
 
 	if !allowSelfConnections && flow.NetAdapter().ID().IsEqual(msgVersion.ID) {
@@ -65,7 +65,7 @@ func (flow *receiveVersionFlow) start() (*appmessage.NetAddress, error) {
 
 	// Disconnect and ban peers from a different network
 	if msgVersion.Network != flow.Config().ActiveNetParams.Name {
-		return nil, protocolerrors.Errorf(true, "wrong network")
+		return nil, protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "wrong network")
 	}
 
 	// Notify and disconnect clients that have a protocol version that is
@@ -81,7 +81,7 @@ func (flow *receiveVersionFlow) start() (*appmessage.NetAddress, error) {
 
 	// Disconnect from partial nodes in networks that don't allow them
 	if !flow.Config().ActiveNetParams.EnableNonNativeSubnetworks && msgVersion.SubnetworkID != nil {
-		return nil, protocolerrors.New(true, "partial nodes are not allowed")
+		return nil, protocolerrors.NewWithMisbehavior(protocolerrors.MinorMisbehavior, "partial nodes are not allowed")
 	}
 
 	// Disconnect if:
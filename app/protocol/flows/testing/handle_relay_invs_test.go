@@ -230,6 +230,14 @@ func (f *fakeRelayInvsContext) GetHeadersSelectedTip() (*externalapi.DomainHash,
 	panic(errors.Errorf("called unimplemented function from test '%s'", f.testName))
 }
 
+func (f *fakeRelayInvsContext) GetUTXOCacheStats() *externalapi.UTXOCacheStats {
+	panic(errors.Errorf("called unimplemented function from test '%s'", f.testName))
+}
+
+func (f *fakeRelayInvsContext) Close() {
+	panic(errors.Errorf("called unimplemented function from test '%s'", f.testName))
+}
+
 func (f *fakeRelayInvsContext) MiningManager() miningmanager.MiningManager {
 	panic(errors.Errorf("called unimplemented function from test '%s'", f.testName))
 }
@@ -3,6 +3,7 @@ package transactionrelay
 import (
 	"github.com/kaspanet/kaspad/app/appmessage"
 	"github.com/kaspanet/kaspad/app/protocol/common"
+	peerpkg "github.com/kaspanet/kaspad/app/protocol/peer"
 	"github.com/kaspanet/kaspad/app/protocol/protocolerrors"
 	"github.com/kaspanet/kaspad/domain"
 	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
@@ -26,16 +27,20 @@ type TransactionsRelayContext interface {
 type handleRelayedTransactionsFlow struct {
 	TransactionsRelayContext
 	incomingRoute, outgoingRoute *router.Route
+	peer                         *peerpkg.Peer
 	invsQueue                    []*appmessage.MsgInvTransaction
 }
 
 // HandleRelayedTransactions listens to appmessage.MsgInvTransaction messages, requests their corresponding transactions if they
 // are missing, adds them to the mempool and propagates them to the rest of the network.
-func HandleRelayedTransactions(context TransactionsRelayContext, incomingRoute *router.Route, outgoingRoute *router.Route) error {
+func HandleRelayedTransactions(context TransactionsRelayContext, incomingRoute *router.Route, outgoingRoute *router.Route,
+	peer *peerpkg.Peer) error {
+
 	flow := &handleRelayedTransactionsFlow{
 		TransactionsRelayContext: context,
 		incomingRoute:            incomingRoute,
 		outgoingRoute:            outgoingRoute,
+		peer:                     peer,
 		invsQueue:                make([]*appmessage.MsgInvTransaction, 0),
 	}
 	return flow.start()
@@ -112,7 +117,7 @@ func (flow *handleRelayedTransactionsFlow) readInv() (*appmessage.MsgInvTransact
 
 	inv, ok := msg.(*appmessage.MsgInvTransaction)
 	if !ok {
-		return nil, protocolerrors.Errorf(true, "unexpected %s message in the block relay flow while "+
+		return nil, protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "unexpected %s message in the block relay flow while "+
 			"expecting an inv message", msg.Command())
 	}
 	return inv, nil
@@ -160,7 +165,7 @@ func (flow *handleRelayedTransactionsFlow) receiveTransactions(requestedTransact
 		}
 		if msgTxNotFound != nil {
 			if !msgTxNotFound.ID.Equal(expectedID) {
-				return protocolerrors.Errorf(true, "expected transaction %s, but got %s",
+				return protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "expected transaction %s, but got %s",
 					expectedID, msgTxNotFound.ID)
 			}
 
@@ -169,7 +174,7 @@ func (flow *handleRelayedTransactionsFlow) receiveTransactions(requestedTransact
 		tx := appmessage.MsgTxToDomainTransaction(msgTx)
 		txID := consensushashing.TransactionID(tx)
 		if !txID.Equal(expectedID) {
-			return protocolerrors.Errorf(true, "expected transaction %s, but got %s",
+			return protocolerrors.ErrorfWithMisbehavior(protocolerrors.MinorMisbehavior, "expected transaction %s, but got %s",
 				expectedID, txID)
 		}
 
@@ -191,12 +196,13 @@ func (flow *handleRelayedTransactionsFlow) receiveTransactions(requestedTransact
 				continue
 			}
 
-			return protocolerrors.Errorf(true, "rejected transaction %s", txID)
+			return protocolerrors.ErrorfWithMisbehavior(protocolerrors.SevereMisbehavior, "rejected transaction %s", txID)
 		}
 		err = flow.broadcastAcceptedTransactions([]*externalapi.DomainTransactionID{txID})
 		if err != nil {
 			return err
 		}
+		flow.peer.UpdateLastTransactionTime()
 		flow.OnTransactionAddedToMempool()
 	}
 	return nil
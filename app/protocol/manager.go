@@ -29,6 +29,7 @@ func NewManager(cfg *config.Config, domain domain.Domain, netAdapter *netadapter
 	}
 
 	netAdapter.SetP2PRouterInitializer(manager.routerInitializer)
+	connectionManager.SetPeerStatsFunc(manager.context.PeerStats)
 	return &manager, nil
 }
 
@@ -43,6 +44,11 @@ func (m *Manager) IBDPeer() *peerpkg.Peer {
 	return m.context.IBDPeer()
 }
 
+// BanScore returns the current ban score of the address of the given peer
+func (m *Manager) BanScore(peer *peerpkg.Peer) uint32 {
+	return m.context.BanScore(peer.Connection())
+}
+
 // AddTransaction adds transaction to the mempool and propagates it.
 func (m *Manager) AddTransaction(tx *externalapi.DomainTransaction) error {
 	return m.context.AddTransaction(tx)
@@ -0,0 +1,62 @@
+package peer
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// banScoreHalfLife is the time it takes a ban score to decay to half its value
+const banScoreHalfLife = 10 * time.Minute
+
+// BanScore is a misbehavior score that decays exponentially over time,
+// so that occasional misbehavior is eventually forgiven while repeated
+// misbehavior accumulates.
+type BanScore struct {
+	mutex      sync.Mutex
+	score      float64
+	lastUpdate time.Time
+}
+
+// NewBanScore returns a new, zero, BanScore
+func NewBanScore() *BanScore {
+	return &BanScore{}
+}
+
+// Increase increases the score by the given amount and returns
+// the resulting score
+func (s *BanScore) Increase(amount uint32) uint32 {
+	return s.increaseAt(amount, time.Now())
+}
+
+// Value returns the current score
+func (s *BanScore) Value() uint32 {
+	return s.valueAt(time.Now())
+}
+
+func (s *BanScore) increaseAt(amount uint32, now time.Time) uint32 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.score = s.decayedScoreNoLock(now) + float64(amount)
+	s.lastUpdate = now
+	return uint32(s.score)
+}
+
+func (s *BanScore) valueAt(now time.Time) uint32 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return uint32(s.decayedScoreNoLock(now))
+}
+
+func (s *BanScore) decayedScoreNoLock(now time.Time) float64 {
+	if s.score == 0 {
+		return 0
+	}
+	elapsed := now.Sub(s.lastUpdate)
+	if elapsed <= 0 {
+		return s.score
+	}
+	return s.score * math.Pow(0.5, float64(elapsed)/float64(banScoreHalfLife))
+}
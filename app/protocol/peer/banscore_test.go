@@ -0,0 +1,60 @@
+package peer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/infrastructure/config"
+)
+
+func TestBanScore(t *testing.T) {
+	banScore := NewBanScore()
+	now := time.Now()
+
+	if value := banScore.valueAt(now); value != 0 {
+		t.Fatalf("expected a new ban score to be 0, got %d", value)
+	}
+
+	// Increases accumulate
+	banScore.increaseAt(20, now)
+	if value := banScore.increaseAt(30, now); value != 50 {
+		t.Fatalf("expected ban score to be 50, got %d", value)
+	}
+
+	// The score halves every half-life
+	if value := banScore.valueAt(now.Add(banScoreHalfLife)); value != 25 {
+		t.Fatalf("expected ban score to be 25 after one half-life, got %d", value)
+	}
+	if value := banScore.valueAt(now.Add(2 * banScoreHalfLife)); value != 12 {
+		t.Fatalf("expected ban score to be 12 after two half-lives, got %d", value)
+	}
+
+	// Increases are added to the decayed score
+	if value := banScore.increaseAt(100, now.Add(banScoreHalfLife)); value != 125 {
+		t.Fatalf("expected ban score to be 125, got %d", value)
+	}
+
+	// Eventually the score decays to 0
+	if value := banScore.valueAt(now.Add(24 * time.Hour)); value != 0 {
+		t.Fatalf("expected ban score to decay to 0, got %d", value)
+	}
+}
+
+// TestBanScoreRepeatedSevereMisbehavior makes sure that, with the default
+// settings, a peer that misbehaves severely once every half-life reaches the
+// ban threshold rather than settling just below it
+func TestBanScoreRepeatedSevereMisbehavior(t *testing.T) {
+	cfg := config.DefaultConfig()
+	banScore := NewBanScore()
+	now := time.Now()
+
+	const maxMisbehaviors = 10
+	for i := 0; i < maxMisbehaviors; i++ {
+		value := banScore.increaseAt(cfg.SevereBanScore, now.Add(time.Duration(i)*banScoreHalfLife))
+		if value >= cfg.BanThreshold {
+			return
+		}
+	}
+	t.Fatalf("expected a severe misbehavior every half-life to reach the ban threshold %d within %d misbehaviors, "+
+		"got %d", cfg.BanThreshold, maxMisbehaviors, banScore.valueAt(now.Add((maxMisbehaviors-1)*banScoreHalfLife)))
+}
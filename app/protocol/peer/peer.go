@@ -35,6 +35,10 @@ type Peer struct {
 	lastPingNonce    uint64        // The nonce of the last ping we sent
 	lastPingTime     time.Time     // Time we sent last ping
 	lastPingDuration time.Duration // Time for last ping to return
+
+	relayTimesLock      sync.RWMutex
+	lastBlockTime       time.Time // Time the peer last relayed a new block
+	lastTransactionTime time.Time // Time the peer last relayed a transaction that was accepted to the mempool
 }
 
 // New returns a new Peer
@@ -142,3 +146,39 @@ func (p *Peer) LastPingDuration() time.Duration {
 
 	return p.lastPingDuration
 }
+
+// UpdateLastBlockTime records that this peer has just relayed
+// a new block
+func (p *Peer) UpdateLastBlockTime() {
+	p.relayTimesLock.Lock()
+	defer p.relayTimesLock.Unlock()
+
+	p.lastBlockTime = time.Now()
+}
+
+// LastBlockTime returns the last time this peer relayed a new block,
+// or the zero time if it never did
+func (p *Peer) LastBlockTime() time.Time {
+	p.relayTimesLock.RLock()
+	defer p.relayTimesLock.RUnlock()
+
+	return p.lastBlockTime
+}
+
+// UpdateLastTransactionTime records that this peer has just relayed
+// a transaction that was accepted to the mempool
+func (p *Peer) UpdateLastTransactionTime() {
+	p.relayTimesLock.Lock()
+	defer p.relayTimesLock.Unlock()
+
+	p.lastTransactionTime = time.Now()
+}
+
+// LastTransactionTime returns the last time this peer relayed a transaction
+// that was accepted to the mempool, or the zero time if it never did
+func (p *Peer) LastTransactionTime() time.Time {
+	p.relayTimesLock.RLock()
+	defer p.relayTimesLock.RUnlock()
+
+	return p.lastTransactionTime
+}
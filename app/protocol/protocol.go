@@ -51,7 +51,7 @@ func (m *Manager) routerInitializer(router *routerpkg.Router, netConnection *net
 
 		netConnection.SetOnInvalidMessageHandler(func(err error) {
 			if atomic.AddUint32(&isStopping, 1) == 1 {
-				errChan <- protocolerrors.Wrap(true, err, "received bad message")
+				errChan <- protocolerrors.WrapWithMisbehavior(protocolerrors.MinorMisbehavior, err, "received bad message")
 			}
 		})
 
@@ -76,13 +76,7 @@ func (m *Manager) routerInitializer(router *routerpkg.Router, netConnection *net
 func (m *Manager) handleError(err error, netConnection *netadapter.NetConnection, outgoingRoute *routerpkg.Route) {
 	if protocolErr := &(protocolerrors.ProtocolError{}); errors.As(err, &protocolErr) {
 		if !m.context.Config().DisableBanning && protocolErr.ShouldBan {
-			log.Warnf("Banning %s (reason: %s)", netConnection, protocolErr.Cause)
-
-			m.context.ConnectionManager().Ban(netConnection)
-			err = outgoingRoute.Enqueue(appmessage.NewMsgReject(protocolErr.Error()))
-			if err != nil && !errors.Is(err, routerpkg.ErrRouteClosed) {
-				panic(err)
-			}
+			m.increaseBanScore(netConnection, outgoingRoute, protocolErr)
 		}
 		log.Debugf("Disconnecting from %s (reason: %s)", netConnection, protocolErr.Cause)
 		netConnection.Disconnect()
@@ -218,7 +212,7 @@ func (m *Manager) registerTransactionRelayFlow(router *routerpkg.Router, isStopp
 		m.registerFlow("HandleRelayedTransactions", router,
 			[]appmessage.MessageCommand{appmessage.CmdInvTransaction, appmessage.CmdTx, appmessage.CmdTransactionNotFound}, isStopping, errChan,
 			func(incomingRoute *routerpkg.Route, peer *peerpkg.Peer) error {
-				return transactionrelay.HandleRelayedTransactions(m.context, incomingRoute, outgoingRoute)
+				return transactionrelay.HandleRelayedTransactions(m.context, incomingRoute, outgoingRoute, peer)
 			},
 		),
 		m.registerFlow("HandleRequestTransactions", router,
@@ -305,9 +299,65 @@ func registerHandshakeRoutes(router *routerpkg.Router) (
 	return receiveVersionRoute, sendVersionRoute
 }
 
+// misbehaviorBanScore returns the ban score that is added for the given
+// misbehavior, as set in the config
+func (m *Manager) misbehaviorBanScore(misbehavior protocolerrors.Misbehavior) uint32 {
+	switch misbehavior {
+	case protocolerrors.MinorMisbehavior:
+		return m.context.Config().MinorBanScore
+	case protocolerrors.SevereMisbehavior:
+		return m.context.Config().SevereBanScore
+	default:
+		return 0
+	}
+}
+
 func removeHandshakeRoutes(router *routerpkg.Router) {
 	err := router.RemoveRoute([]appmessage.MessageCommand{appmessage.CmdVersion, appmessage.CmdVerAck})
 	if err != nil {
 		panic(err)
 	}
 }
+
+// increaseBanScore increases the ban score of the given connection by
+// the ban score of the given protocol error, and bans it once its score
+// reaches the ban threshold
+func (m *Manager) increaseBanScore(netConnection *netadapter.NetConnection, outgoingRoute *routerpkg.Route,
+	protocolErr *protocolerrors.ProtocolError) {
+
+	if m.context.IsWhitelisted(netConnection) {
+		log.Infof("Not increasing the ban score of whitelisted peer %s (reason: %s)",
+			netConnection, protocolErr.Cause)
+		return
+	}
+
+	// Ban scores are kept per address, and local peers, including everyone
+	// who connects through the onion service, share theirs
+	if addressmanager.IsLocal(netConnection.NetAddress()) {
+		log.Warnf("Not punishing local peer %s (reason: %s)", netConnection, protocolErr.Cause)
+		return
+	}
+
+	banScore := m.context.IncreaseBanScore(netConnection, m.misbehaviorBanScore(protocolErr.Misbehavior))
+	banThreshold := m.context.Config().BanThreshold
+	if banScore < banThreshold {
+		log.Warnf("Misbehaving peer %s: %s -- ban score increased to %d (threshold: %d)",
+			netConnection, protocolErr.Cause, banScore, banThreshold)
+		return
+	}
+
+	log.Warnf("Banning %s (reason: %s, ban score: %d)", netConnection, protocolErr.Cause, banScore)
+
+	// The peer is disconnected either way, so failing to persist the ban
+	// isn't a reason to bring the node down
+	err := m.context.ConnectionManager().Ban(netConnection)
+	if err != nil {
+		log.Errorf("Could not ban %s: %s", netConnection, err)
+	}
+	m.context.ForgetBanScore(netConnection)
+
+	err = outgoingRoute.Enqueue(appmessage.NewMsgReject(protocolErr.Error()))
+	if err != nil && !errors.Is(err, routerpkg.ErrRouteClosed) {
+		panic(err)
+	}
+}
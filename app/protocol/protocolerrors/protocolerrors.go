@@ -5,11 +5,31 @@ import (
 	"github.com/pkg/errors"
 )
 
+// Misbehavior is how severe a violation of the protocol is. The ban score
+// that is added for each kind of misbehavior is set in the config.
+type Misbehavior int
+
+const (
+	// NoMisbehavior is the misbehavior of violations that don't increase
+	// the peer's ban score
+	NoMisbehavior Misbehavior = iota
+
+	// MinorMisbehavior is misbehavior that could be the result of a bug
+	// or a network issue rather than of malice, such as sending an
+	// unexpected message
+	MinorMisbehavior
+
+	// SevereMisbehavior is misbehavior that is most likely malicious,
+	// such as sending invalid blocks
+	SevereMisbehavior
+)
+
 // ProtocolError is an error that signifies a violation
 // of the peer-to-peer protocol
 type ProtocolError struct {
-	ShouldBan bool
-	Cause     error
+	ShouldBan   bool
+	Misbehavior Misbehavior
+	Cause       error
 }
 
 func (e *ProtocolError) Error() string {
@@ -25,8 +45,19 @@ func (e *ProtocolError) Unwrap() error {
 // as a ProtocolError.
 func Errorf(shouldBan bool, format string, args ...interface{}) error {
 	return &ProtocolError{
-		ShouldBan: shouldBan,
-		Cause:     errors.Errorf(format, args...),
+		ShouldBan:   shouldBan,
+		Misbehavior: misbehaviorFromShouldBan(shouldBan),
+		Cause:       errors.Errorf(format, args...),
+	}
+}
+
+// ErrorfWithMisbehavior formats according to a format specifier and returns the string
+// as a ProtocolError that increases the peer's ban score by the score of misbehavior.
+func ErrorfWithMisbehavior(misbehavior Misbehavior, format string, args ...interface{}) error {
+	return &ProtocolError{
+		ShouldBan:   misbehavior != NoMisbehavior,
+		Misbehavior: misbehavior,
+		Cause:       errors.Errorf(format, args...),
 	}
 }
 
@@ -34,34 +65,77 @@ func Errorf(shouldBan bool, format string, args ...interface{}) error {
 // New also records the stack trace at the point it was called.
 func New(shouldBan bool, message string) error {
 	return &ProtocolError{
-		ShouldBan: shouldBan,
-		Cause:     errors.New(message),
+		ShouldBan:   shouldBan,
+		Misbehavior: misbehaviorFromShouldBan(shouldBan),
+		Cause:       errors.New(message),
+	}
+}
+
+// NewWithMisbehavior returns a ProtocolError with the supplied message that
+// increases the peer's ban score by the score of misbehavior.
+func NewWithMisbehavior(misbehavior Misbehavior, message string) error {
+	return &ProtocolError{
+		ShouldBan:   misbehavior != NoMisbehavior,
+		Misbehavior: misbehavior,
+		Cause:       errors.New(message),
 	}
 }
 
 // Wrap wraps the given error and returns it as a ProtocolError.
 func Wrap(shouldBan bool, err error, message string) error {
 	return &ProtocolError{
-		ShouldBan: shouldBan,
-		Cause:     errors.Wrap(err, message),
+		ShouldBan:   shouldBan,
+		Misbehavior: misbehaviorFromShouldBan(shouldBan),
+		Cause:       errors.Wrap(err, message),
+	}
+}
+
+// WrapWithMisbehavior wraps the given error and returns it as a ProtocolError
+// that increases the peer's ban score by the score of misbehavior.
+func WrapWithMisbehavior(misbehavior Misbehavior, err error, message string) error {
+	return &ProtocolError{
+		ShouldBan:   misbehavior != NoMisbehavior,
+		Misbehavior: misbehavior,
+		Cause:       errors.Wrap(err, message),
 	}
 }
 
 // Wrapf wraps the given error with the given format and returns it as a ProtocolError.
 func Wrapf(shouldBan bool, err error, format string, args ...interface{}) error {
 	return &ProtocolError{
-		ShouldBan: shouldBan,
-		Cause:     errors.Wrapf(err, format, args...),
+		ShouldBan:   shouldBan,
+		Misbehavior: misbehaviorFromShouldBan(shouldBan),
+		Cause:       errors.Wrapf(err, format, args...),
+	}
+}
+
+// WrapfWithMisbehavior wraps the given error with the given format and returns it
+// as a ProtocolError that increases the peer's ban score by the score of misbehavior.
+func WrapfWithMisbehavior(misbehavior Misbehavior, err error, format string, args ...interface{}) error {
+	return &ProtocolError{
+		ShouldBan:   misbehavior != NoMisbehavior,
+		Misbehavior: misbehavior,
+		Cause:       errors.Wrapf(err, format, args...),
+	}
+}
+
+// misbehaviorFromShouldBan returns the misbehavior of errors that were
+// created with the shouldBan flag. Errors that should ban are
+// considered severe.
+func misbehaviorFromShouldBan(shouldBan bool) Misbehavior {
+	if shouldBan {
+		return SevereMisbehavior
 	}
+	return NoMisbehavior
 }
 
 // ConvertToBanningProtocolErrorIfRuleError converts the given error to
-// a banning protocol error if it's a rule error, and otherwise keep it
-// as is.
+// a protocol error of severe misbehavior if it's a rule error, and
+// otherwise keep it as is.
 func ConvertToBanningProtocolErrorIfRuleError(err error, format string, args ...interface{}) error {
 	if !errors.As(err, &ruleerrors.RuleError{}) {
 		return err
 	}
 
-	return Wrapf(true, err, format, args...)
+	return WrapfWithMisbehavior(SevereMisbehavior, err, format, args...)
 }
@@ -0,0 +1,252 @@
+package rpc
+
+import (
+	"time"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+)
+
+// rateLimit defines a token bucket: a client may send burst requests at
+// once, after which it may send requestsPerSecond requests every second
+type rateLimit struct {
+	requestsPerSecond float64
+	burst             float64
+}
+
+// rateLimits are the per-client rate limits of requests that are
+// expensive to handle
+var rateLimits = map[appmessage.MessageCommand]rateLimit{
+	appmessage.CmdGetUTXOsByAddressesRequestMessage: {requestsPerSecond: 1, burst: 5},
+	appmessage.CmdGetBlocksRequestMessage:           {requestsPerSecond: 2, burst: 10},
+	appmessage.CmdGetHeadersRequestMessage:          {requestsPerSecond: 2, burst: 10},
+}
+
+// notificationCommands are the requests with which a client registers for notifications
+var notificationCommands = map[appmessage.MessageCommand]struct{}{
+	appmessage.CmdNotifyBlockAddedRequestMessage:                            {},
+	appmessage.CmdNotifyVirtualSelectedParentChainChangedRequestMessage:     {},
+	appmessage.CmdNotifyFinalityConflictsRequestMessage:                     {},
+	appmessage.CmdNotifyUTXOsChangedRequestMessage:                          {},
+	appmessage.CmdNotifyVirtualSelectedParentBlueScoreChangedRequestMessage: {},
+}
+
+// errorResponses build the responses with which rejected requests are
+// rejected. Any request may be rejected, since all the requests of a client
+// that exceeds the maximum number of clients are.
+var errorResponses = map[appmessage.MessageCommand]func(rpcError *appmessage.RPCError) appmessage.Message{
+	appmessage.CmdGetCurrentNetworkRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetCurrentNetworkResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdSubmitBlockRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.SubmitBlockResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetBlockTemplateRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetBlockTemplateResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdNotifyBlockAddedRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.NotifyBlockAddedResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetPeerAddressesRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetPeerAddressesResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetSelectedTipHashRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetSelectedTipHashResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetMempoolEntryRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetMempoolEntryResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetConnectedPeerInfoRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetConnectedPeerInfoResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdAddPeerRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.AddPeerResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdSubmitTransactionRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.SubmitTransactionResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdNotifyVirtualSelectedParentChainChangedRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.NotifyVirtualSelectedParentChainChangedResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetBlockRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetBlockResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetSubnetworkRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetSubnetworkResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetVirtualSelectedParentChainFromBlockRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetVirtualSelectedParentChainFromBlockResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetBlocksRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetBlocksResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetBlockCountRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetBlockCountResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetBlockDAGInfoRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetBlockDAGInfoResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdResolveFinalityConflictRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.ResolveFinalityConflictResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdNotifyFinalityConflictsRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.NotifyFinalityConflictsResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetMempoolEntriesRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetMempoolEntriesResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdShutDownRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.ShutDownResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetHeadersRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetHeadersResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdNotifyUTXOsChangedRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.NotifyUTXOsChangedResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetUTXOsByAddressesRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetUTXOsByAddressesResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetVirtualSelectedParentBlueScoreRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetVirtualSelectedParentBlueScoreResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdNotifyVirtualSelectedParentBlueScoreChangedRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.NotifyVirtualSelectedParentBlueScoreChangedResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetTransactionRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetTransactionResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetFeeEstimateRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetFeeEstimateResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetMempoolInfoRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetMempoolInfoResponseMessage{Error: rpcError}
+	},
+	appmessage.CmdGetUTXOCacheStatsRequestMessage: func(rpcError *appmessage.RPCError) appmessage.Message {
+		return &appmessage.GetUTXOCacheStatsResponseMessage{Error: rpcError}
+	},
+}
+
+type tokenBucket struct {
+	limit      rateLimit
+	tokens     float64
+	lastRefill time.Time
+}
+
+func newTokenBucket(limit rateLimit, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		limit:      limit,
+		tokens:     limit.burst,
+		lastRefill: now,
+	}
+}
+
+// take takes a token out of the bucket, and returns false if there
+// aren't any left
+func (b *tokenBucket) take(now time.Time) bool {
+	elapsed := now.Sub(b.lastRefill)
+	if elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.limit.requestsPerSecond
+		if b.tokens > b.limit.burst {
+			b.tokens = b.limit.burst
+		}
+		b.lastRefill = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rejectedClientTimeout is how long a client that was rejected because the
+// maximum number of clients has been reached has to send the request that is
+// replied with the rejection, before it is disconnected
+const rejectedClientTimeout = 10 * time.Second
+
+// clientLimits is the limits state of a single RPC client. It's only
+// accessed by the goroutine that handles the client's requests, so it
+// requires no locking.
+type clientLimits struct {
+	tokenBuckets             map[appmessage.MessageCommand]*tokenBucket
+	isNotificationSubscriber bool
+}
+
+func newClientLimits() *clientLimits {
+	return &clientLimits{
+		tokenBuckets: make(map[appmessage.MessageCommand]*tokenBucket),
+	}
+}
+
+// checkLimits returns an RPCError describing why the given request
+// should be rejected, or nil if it may be handled
+func (m *Manager) checkLimits(limits *clientLimits, command appmessage.MessageCommand, now time.Time) *appmessage.RPCError {
+	if limit, ok := rateLimits[command]; ok {
+		bucket, ok := limits.tokenBuckets[command]
+		if !ok {
+			bucket = newTokenBucket(limit, now)
+			limits.tokenBuckets[command] = bucket
+		}
+		if !bucket.take(now) {
+			return appmessage.RPCErrorf("Rate limit exceeded for %s: at most %g requests per second "+
+				"are allowed, with bursts of up to %g requests", command, limit.requestsPerSecond, limit.burst)
+		}
+	}
+
+	if _, ok := notificationCommands[command]; ok && !limits.isNotificationSubscriber {
+		if !m.addNotificationSubscriber() {
+			return appmessage.RPCErrorf("Too many clients are registered for notifications: "+
+				"at most %d are allowed", m.context.Config.RPCMaxWebsockets)
+		}
+		limits.isNotificationSubscriber = true
+	}
+
+	return nil
+}
+
+// releaseClientLimits releases the shared resources held by a
+// disconnected client
+func (m *Manager) releaseClientLimits(limits *clientLimits) {
+	if limits.isNotificationSubscriber {
+		m.removeNotificationSubscriber()
+	}
+}
+
+func (m *Manager) addNotificationSubscriber() bool {
+	m.notificationSubscribersLock.Lock()
+	defer m.notificationSubscribersLock.Unlock()
+
+	maxSubscribers := m.context.Config.RPCMaxWebsockets
+	if maxSubscribers > 0 && m.notificationSubscriberCount >= maxSubscribers {
+		return false
+	}
+	m.notificationSubscriberCount++
+	return true
+}
+
+func (m *Manager) removeNotificationSubscriber() {
+	m.notificationSubscribersLock.Lock()
+	defer m.notificationSubscribersLock.Unlock()
+
+	m.notificationSubscriberCount--
+}
+
+// addClient registers a new client, and returns false if the maximum number
+// of clients has already been reached
+func (m *Manager) addClient() bool {
+	m.clientCountLock.Lock()
+	defer m.clientCountLock.Unlock()
+
+	maxClients := m.context.Config.RPCMaxClients
+	if maxClients > 0 && m.clientCount >= maxClients {
+		return false
+	}
+	m.clientCount++
+	return true
+}
+
+func (m *Manager) removeClient() {
+	m.clientCountLock.Lock()
+	defer m.clientCountLock.Unlock()
+
+	m.clientCount--
+}
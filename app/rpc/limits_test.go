@@ -0,0 +1,114 @@
+package rpc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/app/rpc/rpccontext"
+	"github.com/kaspanet/kaspad/infrastructure/config"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	bucket := newTokenBucket(rateLimit{requestsPerSecond: 2, burst: 3}, now)
+
+	for i := 0; i < 3; i++ {
+		if !bucket.take(now) {
+			t.Fatalf("expected request %d of the burst to be allowed", i)
+		}
+	}
+	if bucket.take(now) {
+		t.Fatalf("expected a request beyond the burst to be rejected")
+	}
+
+	// After half a second a single token is refilled
+	now = now.Add(500 * time.Millisecond)
+	if !bucket.take(now) {
+		t.Fatalf("expected a request to be allowed after a token was refilled")
+	}
+	if bucket.take(now) {
+		t.Fatalf("expected a request to be rejected after the refilled token was taken")
+	}
+
+	// The bucket never holds more than the burst
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if !bucket.take(now) {
+			t.Fatalf("expected request %d of the burst to be allowed", i)
+		}
+	}
+	if bucket.take(now) {
+		t.Fatalf("expected a request beyond the burst to be rejected")
+	}
+}
+
+func TestNotificationSubscriberLimit(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RPCMaxWebsockets = 1
+	manager := &Manager{context: &rpccontext.Context{Config: cfg}}
+	now := time.Now()
+
+	client1 := newClientLimits()
+	client2 := newClientLimits()
+
+	if rejection := manager.checkLimits(client1, appmessage.CmdNotifyBlockAddedRequestMessage, now); rejection != nil {
+		t.Fatalf("expected the first subscriber to be allowed, but got: %s", rejection.Message)
+	}
+	// Registering for more notifications doesn't count as another subscriber
+	if rejection := manager.checkLimits(client1, appmessage.CmdNotifyUTXOsChangedRequestMessage, now); rejection != nil {
+		t.Fatalf("expected the first subscriber to be allowed more notifications, but got: %s", rejection.Message)
+	}
+	if rejection := manager.checkLimits(client2, appmessage.CmdNotifyBlockAddedRequestMessage, now); rejection == nil {
+		t.Fatalf("expected the second subscriber to be rejected")
+	}
+	// Other requests aren't affected
+	if rejection := manager.checkLimits(client2, appmessage.CmdGetBlockCountRequestMessage, now); rejection != nil {
+		t.Fatalf("expected a non-notification request to be allowed, but got: %s", rejection.Message)
+	}
+
+	manager.releaseClientLimits(client1)
+	if rejection := manager.checkLimits(client2, appmessage.CmdNotifyBlockAddedRequestMessage, now); rejection != nil {
+		t.Fatalf("expected the second subscriber to be allowed after the first disconnected, but got: %s",
+			rejection.Message)
+	}
+}
+
+func TestClientLimit(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RPCMaxClients = 2
+	manager := &Manager{context: &rpccontext.Context{Config: cfg}}
+
+	for i := 0; i < cfg.RPCMaxClients; i++ {
+		if !manager.addClient() {
+			t.Fatalf("expected client %d to be allowed", i)
+		}
+	}
+	if manager.addClient() {
+		t.Fatalf("expected a client beyond the maximum to be rejected")
+	}
+	manager.removeClient()
+	if !manager.addClient() {
+		t.Fatalf("expected a client to be allowed after another client disconnected")
+	}
+}
+
+// TestRequestsHaveErrorResponses makes sure that every request can be
+// rejected, since a client that exceeds the maximum number of clients may
+// send any request
+func TestRequestsHaveErrorResponses(t *testing.T) {
+	for command := range handlers {
+		errorResponse, ok := errorResponses[command]
+		if !ok {
+			t.Errorf("command %s has no error response", command)
+			continue
+		}
+		rpcError := appmessage.RPCErrorf("rejected")
+		response := errorResponse(rpcError)
+		responseError := reflect.ValueOf(response).Elem().FieldByName("Error").Interface().(*appmessage.RPCError)
+		if responseError != rpcError {
+			t.Errorf("the error response of command %s doesn't contain the error", command)
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package rpc
 
 import (
+	"sync"
+
 	"github.com/kaspanet/kaspad/app/appmessage"
 	"github.com/kaspanet/kaspad/app/protocol"
 	"github.com/kaspanet/kaspad/app/rpc/rpccontext"
 	"github.com/kaspanet/kaspad/domain"
 	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/txindex"
 	"github.com/kaspanet/kaspad/domain/utxoindex"
 	"github.com/kaspanet/kaspad/infrastructure/config"
 	"github.com/kaspanet/kaspad/infrastructure/logger"
@@ -17,6 +20,16 @@ import (
 // Manager is an RPC manager
 type Manager struct {
 	context *rpccontext.Context
+
+	// requestSemaphore bounds the number of requests that are
+	// handled concurrently. It's nil if there's no such bound.
+	requestSemaphore chan struct{}
+
+	clientCount     int
+	clientCountLock sync.Mutex
+
+	notificationSubscriberCount int
+	notificationSubscribersLock sync.Mutex
 }
 
 // NewManager creates a new RPC Manager
@@ -28,6 +41,7 @@ func NewManager(
 	connectionManager *connmanager.ConnectionManager,
 	addressManager *addressmanager.AddressManager,
 	utxoIndex *utxoindex.UTXOIndex,
+	txIndex *txindex.TXIndex,
 	shutDownChan chan<- struct{}) *Manager {
 
 	manager := Manager{
@@ -39,9 +53,13 @@ func NewManager(
 			connectionManager,
 			addressManager,
 			utxoIndex,
+			txIndex,
 			shutDownChan,
 		),
 	}
+	if cfg.RPCMaxConcurrentReqs > 0 {
+		manager.requestSemaphore = make(chan struct{}, cfg.RPCMaxConcurrentReqs)
+	}
 	netAdapter.SetRPCRouterInitializer(manager.routerInitializer)
 
 	return &manager
@@ -59,6 +77,13 @@ func (m *Manager) NotifyBlockAddedToDAG(block *externalapi.DomainBlock, blockIns
 		}
 	}
 
+	if m.context.Config.TXIndex {
+		err := m.context.TXIndex.Update(blockInsertionResult.VirtualSelectedParentChainChanges)
+		if err != nil {
+			return err
+		}
+	}
+
 	err := m.notifyVirtualSelectedParentBlueScoreChanged()
 	if err != nil {
 		return err
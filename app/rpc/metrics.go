@@ -0,0 +1,32 @@
+package rpc
+
+import (
+	"github.com/kaspanet/kaspad/infrastructure/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// The statuses of handled requests, as recorded in requestsCounter
+const (
+	requestStatusHandled  = "handled"
+	requestStatusRejected = "rejected"
+	requestStatusFailed   = "failed"
+)
+
+var (
+	requestsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "rpc",
+		Name:      "requests_total",
+		Help: "Number of RPC requests, by method and status. Requests are rejected when they exceed " +
+			"the limits of the client, and fail when handling them breaks the connection",
+	}, []string{"method", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "rpc",
+		Name:      "request_duration_seconds",
+		Help:      "Time it takes to handle an RPC request, by method",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 15),
+	}, []string{"method"})
+)
@@ -7,6 +7,7 @@ import (
 	"github.com/kaspanet/kaspad/infrastructure/network/netadapter"
 	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/router"
 	"github.com/pkg/errors"
+	"time"
 )
 
 type handler func(context *rpccontext.Context, router *router.Router, request appmessage.Message) (appmessage.Message, error)
@@ -38,6 +39,10 @@ var handlers = map[appmessage.MessageCommand]handler{
 	appmessage.CmdGetUTXOsByAddressesRequestMessage:                         rpchandlers.HandleGetUTXOsByAddresses,
 	appmessage.CmdGetVirtualSelectedParentBlueScoreRequestMessage:           rpchandlers.HandleGetVirtualSelectedParentBlueScore,
 	appmessage.CmdNotifyVirtualSelectedParentBlueScoreChangedRequestMessage: rpchandlers.HandleNotifyVirtualSelectedParentBlueScoreChanged,
+	appmessage.CmdGetTransactionRequestMessage:                              rpchandlers.HandleGetTransaction,
+	appmessage.CmdGetFeeEstimateRequestMessage:                              rpchandlers.HandleGetFeeEstimate,
+	appmessage.CmdGetMempoolInfoRequestMessage:                              rpchandlers.HandleGetMempoolInfo,
+	appmessage.CmdGetUTXOCacheStatsRequestMessage:                           rpchandlers.HandleGetUTXOCacheStats,
 }
 
 func (m *Manager) routerInitializer(router *router.Router, netConnection *netadapter.NetConnection) {
@@ -49,9 +54,19 @@ func (m *Manager) routerInitializer(router *router.Router, netConnection *netada
 	if err != nil {
 		panic(err)
 	}
+
+	if !m.addClient() {
+		log.Warnf("Rejecting RPC connection from %s: the maximum of %d RPC clients has been reached",
+			netConnection, m.context.Config.RPCMaxClients)
+		spawn("routerInitializer-rejectClient", func() {
+			m.rejectClient(router, incomingRoute, netConnection)
+		})
+		return
+	}
 	m.context.NotificationManager.AddListener(router)
 
 	spawn("routerInitializer-handleIncomingMessages", func() {
+		defer m.removeClient()
 		defer m.context.NotificationManager.RemoveListener(router)
 
 		err := m.handleIncomingMessages(router, incomingRoute)
@@ -59,7 +74,35 @@ func (m *Manager) routerInitializer(router *router.Router, netConnection *netada
 	})
 }
 
+// rejectClient replies to the first request of a client that was rejected
+// because the maximum number of RPC clients has been reached with an error
+// response, so that the client knows why it's disconnected, and then
+// disconnects it
+func (m *Manager) rejectClient(router *router.Router, incomingRoute *router.Route,
+	netConnection *netadapter.NetConnection) {
+
+	request, err := incomingRoute.DequeueWithTimeout(rejectedClientTimeout)
+	if err != nil {
+		netConnection.Disconnect()
+		return
+	}
+	rejection := appmessage.RPCErrorf("The maximum of %d RPC clients has been reached",
+		m.context.Config.RPCMaxClients)
+	err = router.OutgoingRoute().Enqueue(errorResponses[request.Command()](rejection))
+	if err != nil {
+		netConnection.Disconnect()
+		return
+	}
+
+	// Closing the outgoing route, rather than disconnecting right away,
+	// lets the connection send the error response before it disconnects
+	router.OutgoingRoute().Close()
+}
+
 func (m *Manager) handleIncomingMessages(router *router.Router, incomingRoute *router.Route) error {
+	limits := newClientLimits()
+	defer m.releaseClientLimits(limits)
+
 	outgoingRoute := router.OutgoingRoute()
 	for {
 		request, err := incomingRoute.Dequeue()
@@ -70,7 +113,7 @@ func (m *Manager) handleIncomingMessages(router *router.Router, incomingRoute *r
 		if !ok {
 			return err
 		}
-		response, err := handler(m.context, router, request)
+		response, err := m.handleRequest(handler, router, request, limits)
 		if err != nil {
 			return err
 		}
@@ -81,6 +124,36 @@ func (m *Manager) handleIncomingMessages(router *router.Router, incomingRoute *r
 	}
 }
 
+// handleRequest handles the given request, unless it's rejected by
+// the client's limits. Requests are handled by at most
+// RPCMaxConcurrentReqs goroutines at a time.
+func (m *Manager) handleRequest(handler handler, router *router.Router, request appmessage.Message,
+	limits *clientLimits) (appmessage.Message, error) {
+
+	method := request.Command().String()
+	rejection := m.checkLimits(limits, request.Command(), time.Now())
+	if rejection != nil {
+		log.Debugf("Rejected %s: %s", request.Command(), rejection.Message)
+		requestsCounter.WithLabelValues(method, requestStatusRejected).Inc()
+		return errorResponses[request.Command()](rejection), nil
+	}
+
+	if m.requestSemaphore != nil {
+		m.requestSemaphore <- struct{}{}
+		defer func() { <-m.requestSemaphore }()
+	}
+
+	start := time.Now()
+	response, err := handler(m.context, router, request)
+	requestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		requestsCounter.WithLabelValues(method, requestStatusFailed).Inc()
+		return nil, err
+	}
+	requestsCounter.WithLabelValues(method, requestStatusHandled).Inc()
+	return response, nil
+}
+
 func (m *Manager) handleError(err error, netConnection *netadapter.NetConnection) {
 	if errors.Is(err, router.ErrTimeout) {
 		log.Warnf("Got timeout from %s. Disconnecting...", netConnection)
@@ -0,0 +1,15 @@
+package rpchandlers
+
+import (
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/app/rpc/rpccontext"
+	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/router"
+)
+
+// HandleGetMempoolInfo handles the respectively named RPC command
+func HandleGetMempoolInfo(context *rpccontext.Context, _ *router.Router, _ appmessage.Message) (appmessage.Message, error) {
+	info := context.Domain.MiningManager().GetMempoolInfo()
+	return appmessage.NewGetMempoolInfoResponseMessage(uint64(info.TransactionCount),
+		uint64(info.ChainedTransactionCount), uint64(info.OrphanCount), info.TotalMass, info.MaxMass,
+		info.RollingMinimumFeeRate, info.EvictedTransactionCount), nil
+}
@@ -0,0 +1,81 @@
+package rpchandlers
+
+import (
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/app/rpc/rpccontext"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/transactionid"
+	"github.com/kaspanet/kaspad/infrastructure/db/database"
+	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/router"
+	"github.com/pkg/errors"
+)
+
+// HandleGetTransaction handles the respectively named RPC command
+func HandleGetTransaction(context *rpccontext.Context, _ *router.Router, request appmessage.Message) (appmessage.Message, error) {
+	if !context.Config.TXIndex {
+		errorMessage := &appmessage.GetTransactionResponseMessage{}
+		errorMessage.Error = appmessage.RPCErrorf("Method unavailable when kaspad is run without --txindex")
+		return errorMessage, nil
+	}
+
+	getTransactionRequest := request.(*appmessage.GetTransactionRequestMessage)
+	transactionID, err := transactionid.FromString(getTransactionRequest.TxID)
+	if err != nil {
+		errorMessage := &appmessage.GetTransactionResponseMessage{}
+		errorMessage.Error = appmessage.RPCErrorf("Transaction ID could not be parsed: %s", err)
+		return errorMessage, nil
+	}
+
+	entry, found, err := context.TXIndex.TransactionEntry(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		errorMessage := &appmessage.GetTransactionResponseMessage{}
+		errorMessage.Error = appmessage.RPCErrorf("Transaction %s was not found", transactionID)
+		return errorMessage, nil
+	}
+
+	block, err := context.Domain.Consensus().GetBlock(entry.BlockHash)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorMessage := &appmessage.GetTransactionResponseMessage{}
+			errorMessage.Error = appmessage.RPCErrorf("Block %s that contains transaction %s was pruned",
+				entry.BlockHash, transactionID)
+			return errorMessage, nil
+		}
+		return nil, err
+	}
+	var transactionVerboseData *appmessage.TransactionVerboseData
+	for _, transaction := range block.Transactions {
+		if !consensushashing.TransactionID(transaction).Equal(transactionID) {
+			continue
+		}
+		transactionVerboseData, err = context.BuildTransactionVerboseData(
+			transaction, getTransactionRequest.TxID, block.Header, entry.BlockHash.String())
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+	if transactionVerboseData == nil {
+		return nil, errors.Errorf("transaction %s is missing from block %s", transactionID, entry.BlockHash)
+	}
+
+	acceptingBlockInfo, err := context.Domain.Consensus().GetBlockInfo(entry.AcceptingBlockHash)
+	if err != nil {
+		return nil, err
+	}
+	virtualSelectedParent, err := context.Domain.Consensus().GetVirtualSelectedParent()
+	if err != nil {
+		return nil, err
+	}
+	virtualSelectedParentInfo, err := context.Domain.Consensus().GetBlockInfo(virtualSelectedParent)
+	if err != nil {
+		return nil, err
+	}
+	confirmations := virtualSelectedParentInfo.BlueScore - acceptingBlockInfo.BlueScore + 1
+
+	return appmessage.NewGetTransactionResponseMessage(transactionVerboseData, entry.BlockHash.String(),
+		entry.AcceptingBlockHash.String(), confirmations), nil
+}
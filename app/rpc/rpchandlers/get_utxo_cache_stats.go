@@ -0,0 +1,14 @@
+package rpchandlers
+
+import (
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/app/rpc/rpccontext"
+	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/router"
+)
+
+// HandleGetUTXOCacheStats handles the respectively named RPC command
+func HandleGetUTXOCacheStats(context *rpccontext.Context, _ *router.Router, _ appmessage.Message) (appmessage.Message, error) {
+	stats := context.Domain.Consensus().GetUTXOCacheStats()
+	return appmessage.NewGetUTXOCacheStatsResponseMessage(stats.EntryCount, stats.Size, stats.MaxSize,
+		stats.Hits, stats.Misses), nil
+}
@@ -27,8 +27,12 @@ var commandTypes = []reflect.Type{
 	reflect.TypeOf(protowire.KaspadMessage_GetBlockTemplateRequest{}),
 	reflect.TypeOf(protowire.KaspadMessage_SubmitBlockRequest{}),
 
+	reflect.TypeOf(protowire.KaspadMessage_GetTransactionRequest{}),
 	reflect.TypeOf(protowire.KaspadMessage_GetMempoolEntryRequest{}),
 	reflect.TypeOf(protowire.KaspadMessage_GetMempoolEntriesRequest{}),
+	reflect.TypeOf(protowire.KaspadMessage_GetFeeEstimateRequest{}),
+	reflect.TypeOf(protowire.KaspadMessage_GetMempoolInfoRequest{}),
+	reflect.TypeOf(protowire.KaspadMessage_GetUtxoCacheStatsRequest{}),
 	reflect.TypeOf(protowire.KaspadMessage_SubmitTransactionRequest{}),
 
 	reflect.TypeOf(protowire.KaspadMessage_GetUtxosByAddressesRequest{}),
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/jessevdk/go-flags"
 	"github.com/kaspanet/kaspad/infrastructure/config"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient/grpcclient"
 	"github.com/pkg/errors"
 )
 
@@ -18,12 +19,14 @@ type configFlags struct {
 	ListCommands         bool   `short:"l" long:"list-commands" description:"List all commands and exit"`
 	CommandAndParameters []string
 	config.NetworkFlags
+	grpcclient.ConnectOptions
 }
 
 func parseConfig() (*configFlags, error) {
 	cfg := &configFlags{
-		RPCServer: defaultRPCServer,
-		Timeout:   defaultTimeout,
+		RPCServer:      defaultRPCServer,
+		Timeout:        defaultTimeout,
+		ConnectOptions: grpcclient.ConnectOptions{DefaultRPCCert: config.DefaultRPCCertFile},
 	}
 	parser := flags.NewParser(cfg, flags.HelpFlag)
 	parser.Usage = "kaspactl [OPTIONS] [COMMAND] [COMMAND PARAMETERS].\n\nCommand can be supplied only if --json is not used." +
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/kaspanet/kaspad/infrastructure/config"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	exportSubCmd         = "export"
+	importSubCmd         = "import"
+	exportSnapshotSubCmd = "export-snapshot"
+	importSnapshotSubCmd = "import-snapshot"
+)
+
+const (
+	defaultLogFilename    = "kaspadag.log"
+	defaultErrLogFilename = "kaspadag_err.log"
+)
+
+var (
+	// Default configuration options
+	defaultHomeDir    = util.AppDataDir("kaspadag", false)
+	defaultLogFile    = filepath.Join(defaultHomeDir, defaultLogFilename)
+	defaultErrLogFile = filepath.Join(defaultHomeDir, defaultErrLogFilename)
+)
+
+// databaseFlags are the flags that locate the database of kaspad
+type databaseFlags struct {
+	DataDir string `long:"datadir" description:"The data directory of kaspad (default: ~/.kaspad/data)"`
+	config.NetworkFlags
+}
+
+// databasePath returns the path to the database of kaspad, the same way
+// kaspad resolves it
+func (flags *databaseFlags) databasePath() string {
+	dataDir := flags.DataDir
+	if dataDir == "" {
+		dataDir = config.DefaultConfig().DataDir
+	}
+	return filepath.Join(dataDir, flags.ActiveNetParams.Name, "db")
+}
+
+type exportConfig struct {
+	File        string `long:"file" short:"f" description:"The file to export the DAG to" required:"true"`
+	HeadersOnly bool   `long:"headers-only" description:"Export only the headers of the blocks. Required for nodes that aren't archival"`
+	databaseFlags
+}
+
+type importConfig struct {
+	File     string `long:"file" short:"f" description:"The file to import the DAG from" required:"true"`
+	Archival bool   `long:"archival" description:"Don't prune the imported blocks, for data directories of kaspad nodes that run with --archival"`
+	databaseFlags
+}
+
+type exportSnapshotConfig struct {
+	File string `long:"file" short:"f" description:"The file to export the snapshot to" required:"true"`
+	databaseFlags
+}
+
+type importSnapshotConfig struct {
+	File     string `long:"file" short:"f" description:"The file to import the snapshot from" required:"true"`
+	Archival bool   `long:"archival" description:"Don't prune the imported blocks, for data directories of kaspad nodes that run with --archival"`
+	databaseFlags
+}
+
+func parseCommandLine() (subCommand string, config interface{}) {
+	cfg := &struct{}{}
+	parser := flags.NewParser(cfg, flags.PrintErrors|flags.HelpFlag)
+
+	exportConf := &exportConfig{}
+	parser.AddCommand(exportSubCmd, "Exports the DAG to a file",
+		"Writes the blocks of the DAG of a kaspad data directory to a file, in topological order. "+
+			"kaspad must not be running", exportConf)
+
+	importConf := &importConfig{}
+	parser.AddCommand(importSubCmd, "Imports the DAG from a file",
+		"Validates and inserts the blocks in a file written by export into a kaspad data directory. "+
+			"An interrupted import is resumed by running it again. kaspad must not be running", importConf)
+
+	exportSnapshotConf := &exportSnapshotConfig{}
+	parser.AddCommand(exportSnapshotSubCmd, "Exports the UTXO set of the pruning point to a snapshot file",
+		"Writes the pruning point block of a kaspad data directory and its UTXO set to a file. "+
+			"kaspad must not be running", exportSnapshotConf)
+
+	importSnapshotConf := &importSnapshotConfig{}
+	parser.AddCommand(importSnapshotSubCmd, "Imports the UTXO set of the pruning point from a snapshot file",
+		"Verifies and imports the pruning point block and its UTXO set in a file written by export-snapshot "+
+			"into a kaspad data directory. The headers up to the pruning point and above it have to be "+
+			"imported first. kaspad must not be running", importSnapshotConf)
+
+	_, err := parser.Parse()
+	if err != nil {
+		var flagsErr *flags.Error
+		if ok := errors.As(err, &flagsErr); ok && flagsErr.Type == flags.ErrHelp {
+			os.Exit(0)
+		} else {
+			os.Exit(1)
+		}
+		return "", nil
+	}
+
+	switch parser.Command.Active.Name {
+	case exportSubCmd:
+		err := exportConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = exportConf
+	case importSubCmd:
+		err := importConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = importConf
+	case exportSnapshotSubCmd:
+		err := exportSnapshotConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = exportSnapshotConf
+	case importSnapshotSubCmd:
+		err := importSnapshotConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = importSnapshotConf
+	}
+
+	return parser.Command.Active.Name, config
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"github.com/kaspanet/kaspad/infrastructure/logger"
+)
+
+// The DAG is imported and exported by the same packages that run inside
+// kaspad, so their logs are written to kaspad's logging backend
+var log = logger.BackendLog.Logger("KDAG")
+
+func initLog(logFile, errLogFile string) {
+	logger.InitLog(logFile, errLogFile)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kaspanet/kaspad/app/dagfile"
+	"github.com/kaspanet/kaspad/domain/consensus"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
+	"github.com/kaspanet/kaspad/infrastructure/config"
+	"github.com/kaspanet/kaspad/infrastructure/db/database"
+	"github.com/kaspanet/kaspad/infrastructure/db/database/ldb"
+	"github.com/kaspanet/kaspad/infrastructure/os/signal"
+	"github.com/kaspanet/kaspad/util/panics"
+	"github.com/kaspanet/kaspad/version"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	defer panics.HandlePanic(log, "MAIN", nil)
+	interrupt := signal.InterruptListener()
+
+	subCmd, cfg := parseCommandLine()
+	initLog(defaultLogFile, defaultErrLogFile)
+	log.Infof("Version %s", version.Version())
+
+	var err error
+	switch subCmd {
+	case exportSubCmd:
+		err = exportDAG(cfg.(*exportConfig), interrupt)
+	case importSubCmd:
+		err = importDAG(cfg.(*importConfig), interrupt)
+	case exportSnapshotSubCmd:
+		err = exportSnapshot(cfg.(*exportSnapshotConfig), interrupt)
+	case importSnapshotSubCmd:
+		err = importSnapshot(cfg.(*importSnapshotConfig), interrupt)
+	default:
+		err = errors.Errorf("Unknown sub-command '%s'\n", subCmd)
+	}
+
+	if err != nil {
+		printErrorAndExit(err)
+	}
+}
+
+func exportDAG(cfg *exportConfig, interrupt <-chan struct{}) error {
+	db, consensusInstance, err := openConsensus(&cfg.databaseFlags, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	defer consensusInstance.Close()
+
+	return dagfile.ExportFile(consensusInstance, cfg.ActiveNetParams.GenesisHash, cfg.File, cfg.HeadersOnly,
+		interrupt)
+}
+
+func importDAG(cfg *importConfig, interrupt <-chan struct{}) error {
+	db, consensusInstance, err := openConsensus(&cfg.databaseFlags, cfg.Archival)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	defer consensusInstance.Close()
+
+	err = dagfile.ImportFile(consensusInstance, cfg.ActiveNetParams.GenesisHash, cfg.File, interrupt)
+	if errors.Is(err, dagfile.ErrInterrupted) {
+		log.Infof("The import was interrupted. Run it again to resume it")
+	}
+	return err
+}
+
+func exportSnapshot(cfg *exportSnapshotConfig, interrupt <-chan struct{}) error {
+	db, consensusInstance, err := openConsensus(&cfg.databaseFlags, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	defer consensusInstance.Close()
+
+	return dagfile.ExportSnapshot(consensusInstance, cfg.ActiveNetParams.GenesisHash, cfg.File, interrupt)
+}
+
+func importSnapshot(cfg *importSnapshotConfig, interrupt <-chan struct{}) error {
+	db, consensusInstance, err := openConsensus(&cfg.databaseFlags, cfg.Archival)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	defer consensusInstance.Close()
+
+	err = dagfile.ImportSnapshot(consensusInstance, cfg.ActiveNetParams.GenesisHash, cfg.File, interrupt)
+	if errors.Is(err, dagfile.ErrInterrupted) {
+		log.Infof("The import was interrupted. Run it again to start it over")
+	}
+	return err
+}
+
+// openConsensus opens the database of kaspad, and the consensus on top of it
+func openConsensus(flags *databaseFlags, isArchivalNode bool) (database.Database, externalapi.Consensus, error) {
+	databasePath := flags.databasePath()
+	log.Infof("Loading database from '%s'", databasePath)
+	db, err := ldb.NewLevelDB(databasePath)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "error opening the database at %s. Is kaspad running?", databasePath)
+	}
+
+	defaultConfig := config.DefaultConfig()
+	consensusInstance, err := consensus.NewFactory().NewConsensus(flags.ActiveNetParams, db, isArchivalNode,
+		defaultConfig.MaxUTXOCacheSize, txscript.NewSigCache(defaultConfig.SigCacheMaxSize), 0, nil)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return db, consensusInstance, nil
+}
+
+func printErrorAndExit(err error) {
+	fmt.Fprintf(os.Stderr, "%s\n", err)
+	os.Exit(1)
+}
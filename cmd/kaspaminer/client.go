@@ -21,7 +21,7 @@ func newMinerClient(cfg *configFlags) (*minerClient, error) {
 	if err != nil {
 		return nil, err
 	}
-	rpcClient, err := rpcclient.NewRPCClient(rpcAddress)
+	rpcClient, err := rpcclient.NewRPCClient(rpcAddress, &cfg.ConnectOptions)
 	if err != nil {
 		return nil, err
 	}
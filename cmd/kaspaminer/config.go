@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/kaspanet/kaspad/infrastructure/config"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient/grpcclient"
 
 	"github.com/kaspanet/kaspad/util"
 	"github.com/pkg/errors"
@@ -39,11 +40,13 @@ type configFlags struct {
 	Profile               string   `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
 	TargetBlocksPerSecond *float64 `long:"target-blocks-per-second" description:"Sets a maximum block rate. 0 means no limit (The default one is 2 * target network block rate)"`
 	config.NetworkFlags
+	grpcclient.ConnectOptions
 }
 
 func parseConfig() (*configFlags, error) {
 	cfg := &configFlags{
-		RPCServer: defaultRPCServer,
+		RPCServer:      defaultRPCServer,
+		ConnectOptions: grpcclient.ConnectOptions{DefaultRPCCert: config.DefaultRPCCertFile},
 	}
 	parser := flags.NewParser(cfg, flags.PrintErrors|flags.HelpFlag)
 	_, err := parser.Parse()
@@ -1,18 +1,35 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient"
 
+	"github.com/kaspanet/kaspad/cmd/wallet/daemon/client"
+	"github.com/kaspanet/kaspad/cmd/wallet/daemon/pb"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient"
 	"github.com/kaspanet/kaspad/util"
 )
 
 func balance(conf *balanceConfig) error {
-	client, err := rpcclient.NewRPCClient(conf.RPCServer)
+	if conf.DaemonAddress != "" {
+		return balanceFromDaemon(conf)
+	}
+
+	keystore, err := libwallet.LoadKeystore(conf.keysFilePath(&conf.NetworkFlags))
 	if err != nil {
 		return err
 	}
-	getUTXOsByAddressesResponse, err := client.GetUTXOsByAddresses([]string{conf.Address})
+	addresses, err := keystore.Addresses(conf.ActiveNetParams.Prefix)
+	if err != nil {
+		return err
+	}
+
+	client, err := rpcclient.NewRPCClient(conf.RPCServer, &conf.ConnectOptions)
+	if err != nil {
+		return err
+	}
+	utxos, err := libwallet.FetchUTXOs(client, addresses)
 	if err != nil {
 		return err
 	}
@@ -22,19 +39,58 @@ func balance(conf *balanceConfig) error {
 	}
 	virtualSelectedParentBlueScore := virtualSelectedParentBlueScoreResponse.BlueScore
 
-	var availableBalance, pendingBalance uint64
-	for _, entry := range getUTXOsByAddressesResponse.Entries {
-		if isUTXOSpendable(entry, virtualSelectedParentBlueScore, conf.ActiveNetParams.BlockCoinbaseMaturity) {
-			availableBalance += entry.UTXOEntry.Amount
-		} else {
-			pendingBalance += entry.UTXOEntry.Amount
+	total, balancesByAddress := libwallet.CalculateBalances(utxos, virtualSelectedParentBlueScore,
+		conf.ActiveNetParams.BlockCoinbaseMaturity)
+
+	if conf.Verbose {
+		for _, address := range addresses {
+			addressBalance, ok := balancesByAddress[address.Address.String()]
+			if !ok {
+				continue
+			}
+			printAddressBalance(address.Address.String(), addressBalance.Available, addressBalance.Pending)
 		}
+		fmt.Println()
+	}
+	printBalance(total.Available, total.Pending)
+
+	return nil
+}
+
+func balanceFromDaemon(conf *balanceConfig) error {
+	daemonClient, closeConnection, err := client.Connect(conf.DaemonAddress)
+	if err != nil {
+		return err
 	}
+	defer closeConnection()
 
-	fmt.Printf("Balance:\t\tKAS %f\n", float64(availableBalance)/util.SompiPerKaspa)
-	if pendingBalance > 0 {
-		fmt.Printf("Pending balance:\tKAS %f\n", float64(pendingBalance)/util.SompiPerKaspa)
+	response, err := daemonClient.GetBalance(context.Background(), &pb.GetBalanceRequest{})
+	if err != nil {
+		return err
 	}
 
+	if conf.Verbose {
+		for _, addressBalance := range response.AddressBalances {
+			printAddressBalance(addressBalance.Address, addressBalance.Available, addressBalance.Pending)
+		}
+		fmt.Println()
+	}
+	printBalance(response.Available, response.Pending)
+
 	return nil
 }
+
+func printAddressBalance(address string, available uint64, pending uint64) {
+	fmt.Printf("%s\tKAS %f", address, float64(available)/util.SompiPerKaspa)
+	if pending > 0 {
+		fmt.Printf("\t(pending KAS %f)", float64(pending)/util.SompiPerKaspa)
+	}
+	fmt.Println()
+}
+
+func printBalance(available uint64, pending uint64) {
+	fmt.Printf("Balance:\t\tKAS %f\n", float64(available)/util.SompiPerKaspa)
+	if pending > 0 {
+		fmt.Printf("Pending balance:\tKAS %f\n", float64(pending)/util.SompiPerKaspa)
+	}
+}
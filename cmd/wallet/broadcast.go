@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient"
+)
+
+func broadcast(conf *broadcastConfig) error {
+	pskt, err := readPartiallySignedTransaction(conf.TransactionFiles[0])
+	if err != nil {
+		return err
+	}
+	for _, transactionFile := range conf.TransactionFiles[1:] {
+		other, err := readPartiallySignedTransaction(transactionFile)
+		if err != nil {
+			return err
+		}
+		err = pskt.Merge(other)
+		if err != nil {
+			return err
+		}
+	}
+
+	transaction, err := pskt.FinalizedTransaction()
+	if err != nil {
+		return err
+	}
+
+	client, err := rpcclient.NewRPCClient(conf.RPCServer, &conf.ConnectOptions)
+	if err != nil {
+		return err
+	}
+	transactionID, err := sendTransaction(client, appmessage.DomainTransactionToRPCTransaction(transaction))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Transaction was sent successfully")
+	fmt.Printf("Transaction ID: \t%s\n", transactionID)
+	return nil
+}
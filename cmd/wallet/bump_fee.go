@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient"
+	"github.com/kaspanet/kaspad/util"
+)
+
+func bumpFee(conf *bumpFeeConfig) error {
+	keystore, err := libwallet.LoadKeystore(conf.keysFilePath(&conf.NetworkFlags))
+	if err != nil {
+		return err
+	}
+	addresses, err := keystore.Addresses(conf.ActiveNetParams.Prefix)
+	if err != nil {
+		return err
+	}
+	passphrase, err := readPassphrase("Enter the wallet passphrase: ")
+	if err != nil {
+		return err
+	}
+	accountPrivateKey, err := keystore.AccountPrivateKey(passphrase)
+	if err != nil {
+		return err
+	}
+
+	client, err := rpcclient.NewRPCClient(conf.RPCServer, &conf.ConnectOptions)
+	if err != nil {
+		return err
+	}
+	mempoolEntryResponse, err := client.GetMempoolEntry(conf.TransactionID)
+	if err != nil {
+		return err
+	}
+	original := mempoolEntryResponse.Entry
+
+	// Transactions that spend the outputs of the original transaction are
+	// replaced along with it, so the new fee has to out-bid theirs as well
+	replacedFee, err := libwallet.FetchReplacedFee(client, conf.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	// The UTXOs spent by the original transaction are still in the UTXO set,
+	// since it was not accepted yet
+	utxos, err := libwallet.FetchUTXOs(client, addresses)
+	if err != nil {
+		return err
+	}
+	feeRate, err := libwallet.FetchFeeRate(client, libwallet.FeePriority(conf.FeePriority))
+	if err != nil {
+		return err
+	}
+
+	transaction, fee, err := libwallet.CreateFeeBumpTransaction(conf.ActiveNetParams, accountPrivateKey,
+		original.TransactionVerboseData, replacedFee, utxos, addresses, feeRate)
+	if err != nil {
+		return err
+	}
+
+	transactionID, err := sendTransaction(client, appmessage.DomainTransactionToRPCTransaction(transaction))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Transaction %s was replaced successfully\n", conf.TransactionID)
+	fmt.Printf("Transaction ID: \t%s\n", transactionID)
+	fmt.Printf("Fee: \t\t%f KAS (was %f KAS)\n",
+		float64(fee)/util.SompiPerKaspa, float64(original.Fee)/util.SompiPerKaspa)
+
+	return nil
+}
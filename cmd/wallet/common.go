@@ -1,20 +1,70 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
-	"github.com/kaspanet/kaspad/app/appmessage"
 	"os"
-)
+	"strings"
 
-func isUTXOSpendable(entry *appmessage.UTXOsByAddressesEntry, virtualSelectedParentBlueScore uint64, coinbaseMaturity uint64) bool {
-	if !entry.UTXOEntry.IsCoinbase {
-		return true
-	}
-	blockBlueScore := entry.UTXOEntry.BlockBlueScore
-	return blockBlueScore+coinbaseMaturity < virtualSelectedParentBlueScore
-}
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh/terminal"
+)
 
 func printErrorAndExit(err error) {
 	fmt.Fprintf(os.Stderr, "%s\n", err)
 	os.Exit(1)
 }
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// readLine prints the given prompt and reads a line from stdin
+func readLine(prompt string) (string, error) {
+	fmt.Print(prompt)
+	line, err := stdinReader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// readPassphrase prints the given prompt and reads a passphrase from stdin,
+// without echoing it if stdin is a terminal
+func readPassphrase(prompt string) ([]byte, error) {
+	stdinFileDescriptor := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(stdinFileDescriptor) {
+		line, err := readLine(prompt)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(line), nil
+	}
+
+	fmt.Print(prompt)
+	passphrase, err := terminal.ReadPassword(stdinFileDescriptor)
+	fmt.Println()
+	if err != nil {
+		return nil, err
+	}
+	return passphrase, nil
+}
+
+// readNewPassphrase reads a new passphrase from stdin, asking for it twice
+// to make sure it was typed correctly
+func readNewPassphrase() ([]byte, error) {
+	passphrase, err := readPassphrase("Enter a passphrase to encrypt the wallet with: ")
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("the passphrase must not be empty")
+	}
+	confirmation, err := readPassphrase("Confirm the passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(passphrase, confirmation) {
+		return nil, errors.New("the passphrases don't match")
+	}
+	return passphrase, nil
+}
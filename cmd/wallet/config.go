@@ -1,35 +1,153 @@
 package main
 
 import (
+	"github.com/kaspanet/kaspad/cmd/wallet/daemon/client"
 	"github.com/kaspanet/kaspad/infrastructure/config"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient/grpcclient"
+	"github.com/kaspanet/kaspad/util"
 	"github.com/pkg/errors"
 	"os"
+	"path/filepath"
 
 	"github.com/jessevdk/go-flags"
 )
 
 const (
-	createSubCmd  = "create"
-	balanceSubCmd = "balance"
-	sendSubCmd    = "send"
+	createSubCmd        = "create"
+	balanceSubCmd       = "balance"
+	sendSubCmd          = "send"
+	bumpFeeSubCmd       = "bump-fee"
+	newAddressSubCmd    = "new-address"
+	dumpAddressesSubCmd = "dump-addresses"
+	startDaemonSubCmd   = "start-daemon"
+
+	multisigPublicKeySubCmd         = "multisig-public-key"
+	createMultisigSubCmd            = "create-multisig"
+	createMultisigTransactionSubCmd = "create-multisig-transaction"
+	signSubCmd                      = "sign"
+	broadcastSubCmd                 = "broadcast"
 )
 
+const keysFileName = "keys.json"
+
+var defaultAppDir = util.AppDataDir("kaspawallet", false)
+
+// keysFileFlags are the flags of all the commands that use the wallet keys file
+type keysFileFlags struct {
+	KeysFile string `long:"keys-file" short:"f" description:"Keys file location (default: ~/.kaspawallet/<network>/keys.json)"`
+}
+
+// keysFilePath returns the path of the keys file, resolving
+// the default location for the given network if needed
+func (flags *keysFileFlags) keysFilePath(networkFlags *config.NetworkFlags) string {
+	if flags.KeysFile != "" {
+		return flags.KeysFile
+	}
+	return filepath.Join(defaultAppDir, networkFlags.ActiveNetParams.Name, keysFileName)
+}
+
 type createConfig struct {
+	Import bool `long:"import" short:"i" description:"Import an existing mnemonic instead of creating a new one"`
+	keysFileFlags
 	config.NetworkFlags
 }
 
+// daemonFlags are the flags of all the commands that can be served by the wallet daemon
+type daemonFlags struct {
+	DaemonAddress string `long:"daemonaddress" short:"d" description:"Wallet daemon to use instead of connecting to kaspad directly"`
+}
+
 type balanceConfig struct {
 	RPCServer string `long:"rpcserver" short:"s" description:"RPC server to connect to"`
-	Address   string `long:"address" short:"d" description:"The public address to check the balance of" required:"true"`
+	Verbose   bool   `long:"verbose" short:"v" description:"Show the balance of every address"`
+	daemonFlags
+	keysFileFlags
 	config.NetworkFlags
+	grpcclient.ConnectOptions
 }
 
 type sendConfig struct {
-	RPCServer  string  `long:"rpcserver" short:"s" description:"RPC server to connect to"`
-	PrivateKey string  `long:"private-key" short:"k" description:"The private key of the sender (encoded in hex)" required:"true"`
-	ToAddress  string  `long:"to-address" short:"t" description:"The public address to send Kaspa to" required:"true"`
-	SendAmount float64 `long:"send-amount" short:"v" description:"An amount to send in Kaspa (e.g. 1234.12345678)" required:"true"`
+	RPCServer   string  `long:"rpcserver" short:"s" description:"RPC server to connect to"`
+	ToAddress   string  `long:"to-address" short:"t" description:"The public address to send Kaspa to" required:"true"`
+	SendAmount  float64 `long:"send-amount" short:"v" description:"An amount to send in Kaspa (e.g. 1234.12345678)" required:"true"`
+	FeePriority string  `long:"fee-priority" description:"Which of kaspad's fee rate estimates to pay: low, normal or priority" default:"normal" choice:"low" choice:"normal" choice:"priority"`
+	Replaceable bool    `long:"replaceable" description:"Allow the transaction to be replaced by bump-fee while it's unconfirmed"`
+	daemonFlags
+	keysFileFlags
 	config.NetworkFlags
+	grpcclient.ConnectOptions
+}
+
+type bumpFeeConfig struct {
+	RPCServer     string `long:"rpcserver" short:"s" description:"RPC server to connect to"`
+	TransactionID string `long:"transaction-id" short:"i" description:"The ID of the unconfirmed transaction to replace" required:"true"`
+	FeePriority   string `long:"fee-priority" description:"Which of kaspad's fee rate estimates to pay: low, normal or priority" default:"priority" choice:"low" choice:"normal" choice:"priority"`
+	keysFileFlags
+	config.NetworkFlags
+	grpcclient.ConnectOptions
+}
+
+type newAddressConfig struct {
+	daemonFlags
+	keysFileFlags
+	config.NetworkFlags
+}
+
+type dumpAddressesConfig struct {
+	keysFileFlags
+	config.NetworkFlags
+}
+
+type startDaemonConfig struct {
+	RPCServer string `long:"rpcserver" short:"s" description:"RPC server to connect to"`
+	Listen    string `long:"listen" short:"l" description:"Address to listen on (default: localhost:8082)"`
+	keysFileFlags
+	config.NetworkFlags
+	grpcclient.ConnectOptions
+}
+
+type multisigPublicKeyConfig struct {
+	keysFileFlags
+	config.NetworkFlags
+}
+
+type createMultisigConfig struct {
+	MinimumSignatures int      `long:"required" short:"m" description:"The number of signatures required to spend from the address" required:"true"`
+	PublicKeys        []string `long:"public-key" short:"p" description:"A public key of a cosigner, as printed by multisig-public-key (may be used multiple times)" required:"true"`
+	config.NetworkFlags
+}
+
+type createMultisigTransactionConfig struct {
+	RPCServer    string  `long:"rpcserver" short:"s" description:"RPC server to connect to"`
+	RedeemScript string  `long:"redeem-script" short:"r" description:"The redeem script of the multisig address to send from, as printed by create-multisig" required:"true"`
+	ToAddress    string  `long:"to-address" short:"t" description:"The public address to send Kaspa to" required:"true"`
+	SendAmount   float64 `long:"send-amount" short:"v" description:"An amount to send in Kaspa (e.g. 1234.12345678)" required:"true"`
+	Output       string  `long:"output" short:"o" description:"The file to write the unsigned transaction to" required:"true"`
+	FeePriority  string  `long:"fee-priority" description:"Which of kaspad's fee rate estimates to pay: low, normal or priority" default:"normal" choice:"low" choice:"normal" choice:"priority"`
+	config.NetworkFlags
+	grpcclient.ConnectOptions
+}
+
+type signConfig struct {
+	TransactionFile string `long:"transaction-file" short:"F" description:"The partially signed transaction file to sign. It is signed in place unless --output is given" required:"true"`
+	Output          string `long:"output" short:"o" description:"The file to write the signed transaction to"`
+	RPCServer       string `long:"rpcserver" short:"s" description:"RPC server to look up the spent outputs on, in order to verify the fee. Without it, the fee is printed as unverified"`
+	keysFileFlags
+	config.NetworkFlags
+	grpcclient.ConnectOptions
+}
+
+type broadcastConfig struct {
+	RPCServer        string   `long:"rpcserver" short:"s" description:"RPC server to connect to"`
+	TransactionFiles []string `long:"transaction-file" short:"F" description:"A partially signed transaction file. Signatures from multiple copies of the same transaction are combined (may be used multiple times)" required:"true"`
+	config.NetworkFlags
+	grpcclient.ConnectOptions
+}
+
+// defaultConnectOptions returns the connection options of the commands that
+// connect to kaspad, which validate kaspad's auto-generated certificate by default
+func defaultConnectOptions() grpcclient.ConnectOptions {
+	return grpcclient.ConnectOptions{DefaultRPCCert: config.DefaultRPCCertFile}
 }
 
 func parseCommandLine() (subCommand string, config interface{}) {
@@ -38,16 +156,60 @@ func parseCommandLine() (subCommand string, config interface{}) {
 
 	createConf := &createConfig{}
 	parser.AddCommand(createSubCmd, "Creates a new wallet",
-		"Creates a private key and 3 public addresses, one for each of MainNet, TestNet and DevNet", createConf)
+		"Creates a new wallet from a new or an imported mnemonic, and stores it in a keys file encrypted with a passphrase",
+		createConf)
 
-	balanceConf := &balanceConfig{}
-	parser.AddCommand(balanceSubCmd, "Shows the balance of a public address",
-		"Shows the balance for a public address in Kaspa", balanceConf)
+	balanceConf := &balanceConfig{ConnectOptions: defaultConnectOptions()}
+	parser.AddCommand(balanceSubCmd, "Shows the balance of the wallet",
+		"Shows the balance of all the addresses of the wallet in Kaspa", balanceConf)
 
-	sendConf := &sendConfig{}
+	sendConf := &sendConfig{ConnectOptions: defaultConnectOptions()}
 	parser.AddCommand(sendSubCmd, "Sends a Kaspa transaction to a public address",
 		"Sends a Kaspa transaction to a public address", sendConf)
 
+	bumpFeeConf := &bumpFeeConfig{ConnectOptions: defaultConnectOptions()}
+	parser.AddCommand(bumpFeeSubCmd, "Replaces an unconfirmed transaction with one that pays a higher fee",
+		"Replaces an unconfirmed transaction that was sent by the wallet with one that spends the same UTXOs, "+
+			"makes the same payments and pays a higher fee out of its change", bumpFeeConf)
+
+	newAddressConf := &newAddressConfig{}
+	parser.AddCommand(newAddressSubCmd, "Generates a new receive address",
+		"Derives the next receive address of the wallet", newAddressConf)
+
+	dumpAddressesConf := &dumpAddressesConfig{}
+	parser.AddCommand(dumpAddressesSubCmd, "Prints all the addresses of the wallet",
+		"Prints all the receive and change addresses that were derived by the wallet", dumpAddressesConf)
+
+	startDaemonConf := &startDaemonConfig{Listen: client.DefaultAddress, ConnectOptions: defaultConnectOptions()}
+	parser.AddCommand(startDaemonSubCmd, "Starts the wallet daemon",
+		"Starts a long-running wallet daemon that tracks the wallet UTXOs through kaspad notifications, "+
+			"and serves the balance, send and new-address commands given --daemonaddress", startDaemonConf)
+
+	multisigPublicKeyConf := &multisigPublicKeyConfig{}
+	parser.AddCommand(multisigPublicKeySubCmd, "Prints the public key the wallet cosigns multisig addresses with",
+		"Prints the public key the wallet cosigns multisig addresses with, to be passed to create-multisig",
+		multisigPublicKeyConf)
+
+	createMultisigConf := &createMultisigConfig{}
+	parser.AddCommand(createMultisigSubCmd, "Creates a multisig address",
+		"Creates an m-of-n multisig pay-to-script-hash address out of the public keys of its cosigners, "+
+			"and prints it along with its redeem script", createMultisigConf)
+
+	createMultisigTransactionConf := &createMultisigTransactionConfig{ConnectOptions: defaultConnectOptions()}
+	parser.AddCommand(createMultisigTransactionSubCmd, "Creates an unsigned transaction that spends from a multisig address",
+		"Creates an unsigned transaction that spends from a multisig address, and writes it to a partially "+
+			"signed transaction file to be signed by the cosigners", createMultisigTransactionConf)
+
+	signConf := &signConfig{ConnectOptions: defaultConnectOptions()}
+	parser.AddCommand(signSubCmd, "Signs a partially signed transaction",
+		"Signs a partially signed transaction with the wallet's multisig key. Doesn't require a connection to kaspad, "+
+			"but the fee can only be verified with one", signConf)
+
+	broadcastConf := &broadcastConfig{ConnectOptions: defaultConnectOptions()}
+	parser.AddCommand(broadcastSubCmd, "Broadcasts a multisig transaction",
+		"Combines the signatures of one or more partially signed copies of a transaction, "+
+			"and submits it to kaspad once it has enough signatures", broadcastConf)
+
 	_, err := parser.Parse()
 
 	if err != nil {
@@ -78,7 +240,65 @@ func parseCommandLine() (subCommand string, config interface{}) {
 		if err != nil {
 			printErrorAndExit(err)
 		}
+		if sendConf.Replaceable && sendConf.DaemonAddress != "" {
+			printErrorAndExit(errors.New("--replaceable can't be used with --daemonaddress, " +
+				"since the daemon only sends final transactions"))
+		}
 		config = sendConf
+	case bumpFeeSubCmd:
+		err := bumpFeeConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = bumpFeeConf
+	case newAddressSubCmd:
+		err := newAddressConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = newAddressConf
+	case dumpAddressesSubCmd:
+		err := dumpAddressesConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = dumpAddressesConf
+	case startDaemonSubCmd:
+		err := startDaemonConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = startDaemonConf
+	case multisigPublicKeySubCmd:
+		err := multisigPublicKeyConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = multisigPublicKeyConf
+	case createMultisigSubCmd:
+		err := createMultisigConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = createMultisigConf
+	case createMultisigTransactionSubCmd:
+		err := createMultisigTransactionConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = createMultisigTransactionConf
+	case signSubCmd:
+		err := signConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = signConf
+	case broadcastSubCmd:
+		err := broadcastConf.ResolveNetwork(parser)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+		config = broadcastConf
 	}
 
 	return parser.Command.Active.Name, config
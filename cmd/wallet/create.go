@@ -3,35 +3,48 @@ package main
 import (
 	"fmt"
 
-	"github.com/kaspanet/go-secp256k1"
-	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
 	"github.com/pkg/errors"
 )
 
 func create(conf *createConfig) error {
-	privateKey, err := secp256k1.GeneratePrivateKey()
-	if err != nil {
-		return errors.Wrap(err, "Failed to generate private key")
+	var mnemonic string
+	var err error
+	if conf.Import {
+		mnemonic, err = readLine("Enter the mnemonic to import: ")
+		if err != nil {
+			return err
+		}
+	} else {
+		mnemonic, err = libwallet.CreateMnemonic()
+		if err != nil {
+			return errors.Wrap(err, "Failed to generate a mnemonic")
+		}
 	}
 
-	fmt.Println("This is your private key, granting access to all wallet funds. Keep it safe. Use it only when sending Kaspa.")
-	fmt.Printf("Private key (hex):\t%s\n\n", privateKey.SerializePrivateKey())
-
-	fmt.Println("This is your public address, where money is to be sent.")
-	publicKey, err := privateKey.SchnorrPublicKey()
+	passphrase, err := readNewPassphrase()
 	if err != nil {
-		return errors.Wrap(err, "Failed to generate public key")
+		return err
 	}
-	publicKeySerialized, err := publicKey.Serialize()
+
+	keystore, err := libwallet.CreateKeystore(conf.keysFilePath(&conf.NetworkFlags), mnemonic, passphrase)
 	if err != nil {
-		return errors.Wrap(err, "Failed to serialize public key")
+		return err
+	}
+
+	if !conf.Import {
+		fmt.Println("This is your mnemonic, granting access to all wallet funds. Write it down and keep it safe.")
+		fmt.Println("It is the only way to restore the wallet if the keys file or its passphrase are lost.")
+		fmt.Printf("Mnemonic:\t%s\n\n", mnemonic)
 	}
 
-	addr, err := util.NewAddressPubKeyHashFromPublicKey(publicKeySerialized[:], conf.ActiveNetParams.Prefix)
+	addresses, err := keystore.Addresses(conf.ActiveNetParams.Prefix)
 	if err != nil {
-		return errors.Wrap(err, "Failed to generate p2pkh address")
+		return err
 	}
-	fmt.Printf("Address (%s):\t%s\n", conf.ActiveNetParams.Name, addr)
+	fmt.Printf("The wallet was written to %s\n", keystore.Path())
+	fmt.Println("This is your first public address, where money is to be sent.")
+	fmt.Printf("Address (%s):\t%s\n", conf.ActiveNetParams.Name, addresses[0].Address)
 
 	return nil
 }
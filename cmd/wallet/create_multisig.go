@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
+	"github.com/pkg/errors"
+)
+
+func createMultisig(conf *createMultisigConfig) error {
+	publicKeys := make([][]byte, len(conf.PublicKeys))
+	for i, publicKey := range conf.PublicKeys {
+		var err error
+		publicKeys[i], err = hex.DecodeString(publicKey)
+		if err != nil {
+			return errors.Wrapf(err, "malformed public key %s", publicKey)
+		}
+	}
+
+	redeemScript, err := libwallet.CreateMultisigRedeemScript(publicKeys, conf.MinimumSignatures)
+	if err != nil {
+		return err
+	}
+	address, err := libwallet.MultisigAddress(redeemScript, conf.ActiveNetParams.Prefix)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Multisig address (%d-of-%d):\t%s\n", conf.MinimumSignatures, len(publicKeys), address)
+	fmt.Printf("Redeem script:\t\t\t%s\n", hex.EncodeToString(redeemScript))
+	fmt.Println("Keep the redeem script: it is required in order to spend from the address.")
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+func createMultisigTransaction(conf *createMultisigTransactionConfig) error {
+	redeemScript, err := hex.DecodeString(conf.RedeemScript)
+	if err != nil {
+		return errors.Wrap(err, "malformed redeem script")
+	}
+	fromAddress, err := libwallet.MultisigAddress(redeemScript, conf.ActiveNetParams.Prefix)
+	if err != nil {
+		return err
+	}
+	toAddress, err := util.DecodeAddress(conf.ToAddress, conf.ActiveNetParams.Prefix)
+	if err != nil {
+		return err
+	}
+	sendAmountSompi := uint64(conf.SendAmount * util.SompiPerKaspa)
+
+	client, err := rpcclient.NewRPCClient(conf.RPCServer, &conf.ConnectOptions)
+	if err != nil {
+		return err
+	}
+	utxos, err := libwallet.FetchMultisigUTXOs(client, fromAddress)
+	if err != nil {
+		return err
+	}
+	utxos, err = filterSpendableUTXOs(client, utxos, conf.ActiveNetParams.BlockCoinbaseMaturity)
+	if err != nil {
+		return err
+	}
+
+	feeRate, err := libwallet.FetchFeeRate(client, libwallet.FeePriority(conf.FeePriority))
+	if err != nil {
+		return err
+	}
+
+	// Multisig signature scripts are large, so the largest UTXOs are
+	// selected first in order to keep the number of inputs low
+	sort.Slice(utxos, func(i, j int) bool { return utxos[i].Amount > utxos[j].Amount })
+	pskt, fee, err := libwallet.CreateMultisigTransactionWithFee(conf.ActiveNetParams, redeemScript, utxos,
+		toAddress, sendAmountSompi, feeRate)
+	if err != nil {
+		return err
+	}
+
+	err = writePartiallySignedTransaction(conf.Output, pskt)
+	if err != nil {
+		return err
+	}
+	transactionID, err := pskt.TransactionID()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Unsigned transaction %s, which pays a fee of %f KAS, was written to %s\n",
+		transactionID, float64(fee)/util.SompiPerKaspa, conf.Output)
+	return nil
+}
+
+func readPartiallySignedTransaction(path string) (*libwallet.PartiallySignedTransaction, error) {
+	serialized, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pskt, err := libwallet.DeserializePartiallySignedTransaction(serialized)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading %s", path)
+	}
+	return pskt, nil
+}
+
+func writePartiallySignedTransaction(path string, pskt *libwallet.PartiallySignedTransaction) error {
+	serialized, err := pskt.Serialize()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, serialized, 0644)
+}
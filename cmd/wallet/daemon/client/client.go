@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/kaspanet/kaspad/cmd/wallet/daemon/pb"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// DefaultAddress is the default address of the wallet daemon
+const DefaultAddress = "localhost:8082"
+
+const connectTimeout = 5 * time.Second
+
+// Connect connects to the wallet daemon at the given address, and returns
+// a client for it along with a function that closes the connection
+func Connect(address string) (pb.WalletdClient, func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
+	// The daemon only listens on loopback addresses, so the connection isn't encrypted
+	connection, err := grpc.DialContext(ctx, address, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "error connecting to the wallet daemon at %s", address)
+	}
+	closeConnection := func() {
+		err := connection.Close()
+		if err != nil {
+			panic(err)
+		}
+	}
+	return pb.NewWalletdClient(connection), closeConnection, nil
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+
+	"github.com/kaspanet/kaspad/cmd/wallet/daemon/pb"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
+)
+
+// GetBalance returns the balance of the wallet
+func (s *Server) GetBalance(_ context.Context, _ *pb.GetBalanceRequest) (*pb.GetBalanceResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.isSynced {
+		return nil, errNotSynced
+	}
+
+	utxos := make([]*libwallet.UTXO, 0, len(s.utxos))
+	for outpoint, utxo := range s.utxos {
+		if s.isPendingSpendNoLock(outpoint) {
+			continue
+		}
+		utxos = append(utxos, utxo)
+	}
+	total, balancesByAddress := libwallet.CalculateBalances(utxos, s.virtualSelectedParentBlueScore,
+		s.params.BlockCoinbaseMaturity)
+
+	addressBalances := make([]*pb.AddressBalance, 0, len(balancesByAddress))
+	for address, balance := range balancesByAddress {
+		addressBalances = append(addressBalances, &pb.AddressBalance{
+			Address:   address,
+			Available: balance.Available,
+			Pending:   balance.Pending,
+		})
+	}
+
+	return &pb.GetBalanceResponse{
+		Available:       total.Available,
+		Pending:         total.Pending,
+		AddressBalances: addressBalances,
+	}, nil
+}
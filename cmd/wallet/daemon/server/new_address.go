@@ -0,0 +1,20 @@
+package server
+
+import (
+	"context"
+
+	"github.com/kaspanet/kaspad/cmd/wallet/daemon/pb"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
+)
+
+// NewAddress derives the next receive address of the wallet
+func (s *Server) NewAddress(_ context.Context, _ *pb.NewAddressRequest) (*pb.NewAddressResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	address, err := s.newAddressNoLock(libwallet.ExternalKeychain)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.NewAddressResponse{Address: address.Address.String()}, nil
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"time"
+
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	// pendingSpendRecheckInterval is the time after which a pending spend
+	// is checked against the mempool, in case its transaction was dropped
+	pendingSpendRecheckInterval = time.Minute
+
+	// pendingSpendReleaseBlueScoreGrace is how far the virtual selected
+	// parent blue score has to advance after the transaction of a pending
+	// spend was found missing from the mempool before the spend is released.
+	// A transaction leaves the mempool when it's added in a block as well,
+	// and until the block's UTXO changes arrive it can't be told apart from
+	// a dropped one.
+	pendingSpendReleaseBlueScoreGrace = 100
+)
+
+// pendingSpend is an outpoint that is spent by a transaction the daemon has
+// sent, but that wasn't accepted yet. Such outpoints are excluded from the
+// balance and from coin selection, so that concurrent sends don't attempt
+// to double-spend them.
+type pendingSpend struct {
+	transactionID string
+	sentAt        time.Time
+
+	// isMissing is set once the transaction is found missing from the
+	// mempool, at virtual selected parent blue score missingSinceBlueScore
+	isMissing             bool
+	missingSinceBlueScore uint64
+}
+
+func (s *Server) addPendingSpendsNoLock(outpoints []externalapi.DomainOutpoint, transactionID string) {
+	now := time.Now()
+	for _, outpoint := range outpoints {
+		s.pendingSpends[outpoint] = &pendingSpend{transactionID: transactionID, sentAt: now}
+	}
+}
+
+func (s *Server) releasePendingSpendsNoLock(outpoints []externalapi.DomainOutpoint) {
+	for _, outpoint := range outpoints {
+		delete(s.pendingSpends, outpoint)
+	}
+}
+
+func (s *Server) isPendingSpendNoLock(outpoint externalapi.DomainOutpoint) bool {
+	_, ok := s.pendingSpends[outpoint]
+	return ok
+}
+
+// pendingSpendsLoop periodically releases pending spends whose transaction
+// is no longer in the mempool and yet wasn't accepted, so that their
+// outpoints become spendable again. A pending spend is released only if
+// kaspad answers that its transaction isn't in the mempool, and not when
+// kaspad can't be reached. Since a transaction that was added in a block
+// leaves the mempool too, the spend is released only if the UTXO changes of
+// such a block don't remove it within pendingSpendReleaseBlueScoreGrace.
+func (s *Server) pendingSpendsLoop() {
+	for s.sleep(pendingSpendRecheckInterval) {
+		s.releaseDroppedPendingSpends()
+	}
+}
+
+func (s *Server) releaseDroppedPendingSpends() {
+	s.lock.Lock()
+	client := s.rpcClient
+	isSynced := s.isSynced
+	transactionIDs := make(map[string]struct{})
+	for _, spend := range s.pendingSpends {
+		if time.Since(spend.sentAt) >= pendingSpendRecheckInterval {
+			transactionIDs[spend.transactionID] = struct{}{}
+		}
+	}
+	s.lock.Unlock()
+
+	if !isSynced || len(transactionIDs) == 0 {
+		return
+	}
+
+	missingTransactionIDs := make(map[string]struct{})
+	for transactionID := range transactionIDs {
+		_, err := client.GetMempoolEntry(transactionID)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, rpcclient.ErrRPC) {
+			log.Warnf("Error checking whether transaction %s is in the mempool: %s", transactionID, err)
+			return
+		}
+		missingTransactionIDs[transactionID] = struct{}{}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.updateMissingPendingSpendsNoLock(transactionIDs, missingTransactionIDs)
+}
+
+// updateMissingPendingSpendsNoLock records which of the checked transactions
+// were found missing from the mempool, and releases the pending spends of
+// the transactions that have been missing for longer than the grace period
+func (s *Server) updateMissingPendingSpendsNoLock(checkedTransactionIDs map[string]struct{},
+	missingTransactionIDs map[string]struct{}) {
+
+	for outpoint, spend := range s.pendingSpends {
+		if _, ok := checkedTransactionIDs[spend.transactionID]; !ok {
+			continue
+		}
+		if _, ok := missingTransactionIDs[spend.transactionID]; !ok {
+			spend.isMissing = false
+			continue
+		}
+		if !spend.isMissing {
+			spend.isMissing = true
+			spend.missingSinceBlueScore = s.virtualSelectedParentBlueScore
+			continue
+		}
+		if s.virtualSelectedParentBlueScore < spend.missingSinceBlueScore+pendingSpendReleaseBlueScoreGrace {
+			continue
+		}
+		log.Infof("Transaction %s is no longer in the mempool and wasn't accepted. Releasing %s:%d",
+			spend.transactionID, outpoint.TransactionID, outpoint.Index)
+		delete(s.pendingSpends, outpoint)
+	}
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"sort"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/cmd/wallet/daemon/pb"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet/bip32"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+// Send sends the requested amount to the requested address. The lock isn't
+// held while waiting for kaspad, but the selected UTXOs are marked as pending
+// spends before the lock is released, so concurrent sends never select the
+// same UTXOs.
+func (s *Server) Send(_ context.Context, request *pb.SendRequest) (*pb.SendResponse, error) {
+	s.lock.Lock()
+	isSynced := s.isSynced
+	client := s.rpcClient
+	s.lock.Unlock()
+	if !isSynced {
+		return nil, errNotSynced
+	}
+
+	toAddress, err := util.DecodeAddress(request.ToAddress, s.params.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	keystore, err := libwallet.LoadKeystore(s.keysFilePath)
+	if err != nil {
+		return nil, err
+	}
+	accountPrivateKey, err := keystore.AccountPrivateKey([]byte(request.Passphrase))
+	if err != nil {
+		return nil, err
+	}
+	feeRate, err := libwallet.FetchFeeRate(client, libwallet.FeePriority(request.FeePriority))
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, outpoints, fee, err := s.createTransaction(accountPrivateKey, toAddress, request.Amount, feeRate)
+	if err != nil {
+		return nil, err
+	}
+	transactionID := consensushashing.TransactionID(transaction).String()
+
+	_, err = client.SubmitTransaction(appmessage.DomainTransactionToRPCTransaction(transaction))
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err != nil {
+		// If kaspad didn't answer, the transaction might have still reached
+		// it, so its outpoints are released only once it's known to not be
+		// in the mempool
+		if errors.Is(err, rpcclient.ErrRPC) {
+			s.releasePendingSpendsNoLock(outpoints)
+		}
+		return nil, errors.Wrap(err, "error submitting transaction")
+	}
+
+	log.Infof("Sent transaction %s", transactionID)
+	return &pb.SendResponse{TransactionId: transactionID, Fee: fee}, nil
+}
+
+// createTransaction creates and signs a transaction that sends the given
+// amount to the given address, and marks the UTXOs it spends as pending
+// spends. The change address is saved as given out as soon as the
+// transaction is created, and it's never given back even if the transaction
+// fails to be sent, since the transaction might have still reached kaspad.
+func (s *Server) createTransaction(accountPrivateKey *bip32.ExtendedKey, toAddress util.Address, amount uint64,
+	feeRate float64) (transaction *externalapi.DomainTransaction, outpoints []externalapi.DomainOutpoint,
+	fee uint64, err error) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// The keystore is reloaded before every change, so that changes made
+	// to it by other wallet instances aren't overwritten
+	keystore, err := libwallet.LoadKeystore(s.keysFilePath)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	changeAddress, err := keystore.NewAddress(libwallet.InternalKeychain, s.params.Prefix)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	transaction, selectedUTXOs, fee, err := libwallet.CreateSignedTransactionWithFee(s.params, accountPrivateKey,
+		s.spendableUTXOsNoLock(), toAddress, amount, changeAddress.Address, feeRate, false)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	err = keystore.Save()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	s.updateAddressCountNoLock(libwallet.InternalKeychain, changeAddress.Index+1)
+
+	outpoints = make([]externalapi.DomainOutpoint, len(selectedUTXOs))
+	for i, utxo := range selectedUTXOs {
+		outpoints[i] = utxo.Outpoint
+	}
+	s.addPendingSpendsNoLock(outpoints, consensushashing.TransactionID(transaction).String())
+
+	return transaction, outpoints, fee, nil
+}
+
+// spendableUTXOsNoLock returns the UTXOs that can be spent right away,
+// largest first in order to minimize the number of inputs
+func (s *Server) spendableUTXOsNoLock() []*libwallet.UTXO {
+	utxos := make([]*libwallet.UTXO, 0, len(s.utxos))
+	for outpoint, utxo := range s.utxos {
+		if s.isPendingSpendNoLock(outpoint) {
+			continue
+		}
+		if !utxo.IsSpendable(s.virtualSelectedParentBlueScore, s.params.BlockCoinbaseMaturity) {
+			continue
+		}
+		utxos = append(utxos, utxo)
+	}
+	sort.Slice(utxos, func(i, j int) bool {
+		return utxos[i].Amount > utxos[j].Amount
+	})
+	return utxos
+}
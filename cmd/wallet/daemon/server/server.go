@@ -0,0 +1,171 @@
+package server
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/cmd/wallet/daemon/pb"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/dagconfig"
+	"github.com/kaspanet/kaspad/infrastructure/logger"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient/grpcclient"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// Server is a long-running wallet daemon. It keeps a local copy of the UTXO
+// set of the wallet addresses, which it keeps up to date through kaspad
+// notifications, and serves balance and send requests over gRPC.
+type Server struct {
+	pb.UnimplementedWalletdServer
+
+	params         *dagconfig.Params
+	keysFilePath   string
+	rpcServer      string
+	connectOptions *grpcclient.ConnectOptions
+
+	grpcServer *grpc.Server
+	listener   net.Listener
+	resyncChan chan struct{}
+	quitChan   chan struct{}
+
+	lock      sync.Mutex
+	rpcClient *rpcclient.RPCClient
+	isSynced  bool
+
+	// addresses are all the addresses the daemon is subscribed to, including
+	// addresses the wallet hasn't given out yet, so that UTXOs sent to them
+	// are noticed
+	addresses               map[string]*libwallet.WalletAddress
+	subscribedAddressCounts map[libwallet.Keychain]uint32
+
+	// addressCounts are the numbers of addresses the wallet gave out
+	addressCounts map[libwallet.Keychain]uint32
+
+	// bufferedUTXOsChangedNotifications are the notifications that arrived
+	// while syncing, which are applied after the fetched UTXOs. It's nil
+	// when not syncing.
+	bufferedUTXOsChangedNotifications []*appmessage.UTXOsChangedNotificationMessage
+
+	utxos                          map[externalapi.DomainOutpoint]*libwallet.UTXO
+	pendingSpends                  map[externalapi.DomainOutpoint]*pendingSpend
+	virtualSelectedParentBlueScore uint64
+}
+
+// NewServer creates a new wallet daemon for the keys file at the given path,
+// which tracks the wallet addresses through the given kaspad RPC server
+func NewServer(params *dagconfig.Params, keysFilePath string, rpcServer string,
+	connectOptions *grpcclient.ConnectOptions) (*Server, error) {
+
+	// Make sure the wallet exists before starting to serve it
+	_, err := libwallet.LoadKeystore(keysFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		params:         params,
+		keysFilePath:   keysFilePath,
+		rpcServer:      rpcServer,
+		connectOptions: connectOptions,
+
+		resyncChan: make(chan struct{}, 1),
+		quitChan:   make(chan struct{}),
+
+		addresses:               make(map[string]*libwallet.WalletAddress),
+		subscribedAddressCounts: make(map[libwallet.Keychain]uint32),
+		addressCounts:           make(map[libwallet.Keychain]uint32),
+		utxos:                   make(map[externalapi.DomainOutpoint]*libwallet.UTXO),
+		pendingSpends:           make(map[externalapi.DomainOutpoint]*pendingSpend),
+	}, nil
+}
+
+// Start starts syncing with kaspad and serving gRPC requests on the given
+// address. Since requests carry the wallet passphrase, only loopback
+// addresses are allowed.
+func (s *Server) Start(listenAddress string) error {
+	err := validateListenAddress(listenAddress)
+	if err != nil {
+		return err
+	}
+	s.listener, err = net.Listen("tcp", listenAddress)
+	if err != nil {
+		return errors.Wrapf(err, "error listening on %s", listenAddress)
+	}
+	s.grpcServer = grpc.NewServer()
+	pb.RegisterWalletdServer(s.grpcServer, s)
+
+	spawn("Server.syncLoop", s.syncLoop)
+	spawn("Server.pendingSpendsLoop", s.pendingSpendsLoop)
+	spawn("Server.serve", func() {
+		err := s.grpcServer.Serve(s.listener)
+		if err != nil {
+			log.Errorf("The gRPC server stopped: %s", err)
+		}
+	})
+
+	log.Infof("Wallet daemon listening on %s", s.listener.Addr())
+	return nil
+}
+
+// Address returns the address the daemon is listening on
+func (s *Server) Address() string {
+	return s.listener.Addr().String()
+}
+
+// Stop stops the daemon
+func (s *Server) Stop() {
+	close(s.quitChan)
+	s.grpcServer.Stop()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.rpcClient != nil {
+		s.rpcClient.Close()
+		s.rpcClient = nil
+	}
+}
+
+// SetLogLevel sets the level of the daemon's logs
+func SetLogLevel(level logger.Level) {
+	log.SetLevel(level)
+}
+
+func validateListenAddress(listenAddress string) error {
+	host, _, err := net.SplitHostPort(listenAddress)
+	if err != nil {
+		return errors.Wrapf(err, "invalid listen address %s", listenAddress)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsLoopback() {
+		return errors.Errorf("the wallet daemon may only listen on loopback addresses, but got %s", listenAddress)
+	}
+	return nil
+}
+
+func (s *Server) isQuitting() bool {
+	select {
+	case <-s.quitChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// sleep sleeps for the given duration, and returns false if the
+// daemon was stopped in the meantime
+func (s *Server) sleep(duration time.Duration) bool {
+	select {
+	case <-s.quitChan:
+		return false
+	case <-time.After(duration):
+		return true
+	}
+}
@@ -0,0 +1,375 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/cmd/wallet/daemon/pb"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/dagconfig"
+)
+
+func newServerForTest(t *testing.T, testName string) (server *Server, teardown func()) {
+	directory, err := ioutil.TempDir("", testName)
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	keysFilePath := filepath.Join(directory, "keys.json")
+
+	mnemonic, err := libwallet.CreateMnemonic()
+	if err != nil {
+		t.Fatalf("CreateMnemonic: %s", err)
+	}
+	_, err = libwallet.CreateKeystore(keysFilePath, mnemonic, []byte("passphrase"))
+	if err != nil {
+		t.Fatalf("CreateKeystore: %s", err)
+	}
+
+	server, err = NewServer(&dagconfig.SimnetParams, keysFilePath, "", nil)
+	if err != nil {
+		t.Fatalf("NewServer: %s", err)
+	}
+	_, err = server.resetSubscriptions(nil)
+	if err != nil {
+		t.Fatalf("resetSubscriptions: %s", err)
+	}
+	server.finishSyncNoLock(nil, 0)
+
+	return server, func() { os.RemoveAll(directory) }
+}
+
+func utxoEntryForTest(address *libwallet.WalletAddress, transactionIndex int, amount uint64,
+	blockBlueScore uint64, isCoinbase bool) *appmessage.UTXOsByAddressesEntry {
+
+	return &appmessage.UTXOsByAddressesEntry{
+		Address: address.Address.String(),
+		Outpoint: &appmessage.RPCOutpoint{
+			TransactionID: fmt.Sprintf("%064x", transactionIndex),
+			Index:         0,
+		},
+		UTXOEntry: &appmessage.RPCUTXOEntry{
+			Amount:         amount,
+			BlockBlueScore: blockBlueScore,
+			IsCoinbase:     isCoinbase,
+		},
+	}
+}
+
+func walletAddressForTest(t *testing.T, server *Server, keychain libwallet.Keychain, index uint32) *libwallet.WalletAddress {
+	for _, address := range server.addresses {
+		if address.Keychain == keychain && address.Index == index {
+			return address
+		}
+	}
+	t.Fatalf("the server isn't subscribed to address %d of keychain %s", index, keychain)
+	return nil
+}
+
+func TestUTXOTracking(t *testing.T) {
+	server, teardown := newServerForTest(t, "TestUTXOTracking")
+	defer teardown()
+
+	maturity := server.params.BlockCoinbaseMaturity
+	server.virtualSelectedParentBlueScore = maturity + 10
+
+	firstAddress := walletAddressForTest(t, server, libwallet.ExternalKeychain, 0)
+	lookaheadAddress := walletAddressForTest(t, server, libwallet.ExternalKeychain, 5)
+	server.onUTXOsChanged(nil, &appmessage.UTXOsChangedNotificationMessage{
+		Added: []*appmessage.UTXOsByAddressesEntry{
+			utxoEntryForTest(firstAddress, 1, 100, 1, true),
+			utxoEntryForTest(firstAddress, 2, 200, maturity, true),
+			utxoEntryForTest(lookaheadAddress, 3, 300, maturity+10, false),
+			{Address: "kaspasim:qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqq"},
+		},
+	})
+
+	balance, err := server.GetBalance(context.Background(), &pb.GetBalanceRequest{})
+	if err != nil {
+		t.Fatalf("GetBalance: %s", err)
+	}
+	if balance.Available != 400 || balance.Pending != 200 {
+		t.Fatalf("expected an available balance of 400 and a pending balance of 200, got %d and %d",
+			balance.Available, balance.Pending)
+	}
+
+	// Receiving funds to a lookahead address marks all the addresses
+	// up to it as given out
+	keystore, err := libwallet.LoadKeystore(server.keysFilePath)
+	if err != nil {
+		t.Fatalf("LoadKeystore: %s", err)
+	}
+	if keystore.ExternalAddressCount != lookaheadAddress.Index+1 {
+		t.Fatalf("expected the keystore to have %d external addresses, got %d",
+			lookaheadAddress.Index+1, keystore.ExternalAddressCount)
+	}
+
+	spendableUTXOs := server.spendableUTXOsNoLock()
+	if len(spendableUTXOs) != 2 || spendableUTXOs[0].Amount != 300 || spendableUTXOs[1].Amount != 100 {
+		t.Fatalf("expected the spendable UTXOs to be the matured ones, largest first")
+	}
+
+	server.addPendingSpendsNoLock([]externalapi.DomainOutpoint{spendableUTXOs[0].Outpoint}, "pending")
+	spendableUTXOs = server.spendableUTXOsNoLock()
+	if len(spendableUTXOs) != 1 || spendableUTXOs[0].Amount != 100 {
+		t.Fatalf("expected pending spends to not be spendable")
+	}
+	balance, err = server.GetBalance(context.Background(), &pb.GetBalanceRequest{})
+	if err != nil {
+		t.Fatalf("GetBalance: %s", err)
+	}
+	if balance.Available != 100 {
+		t.Fatalf("expected pending spends to be excluded from the balance, got %d", balance.Available)
+	}
+
+	// Once the spending transaction is accepted, the UTXO is removed
+	// along with its pending spend
+	pendingEntry := utxoEntryForTest(lookaheadAddress, 3, 300, maturity+10, false)
+	server.onUTXOsChanged(nil, &appmessage.UTXOsChangedNotificationMessage{
+		Removed: []*appmessage.UTXOsByAddressesEntry{pendingEntry},
+	})
+	if len(server.utxos) != 2 || len(server.pendingSpends) != 0 {
+		t.Fatalf("expected the spent UTXO and its pending spend to be removed, "+
+			"got %d UTXOs and %d pending spends", len(server.utxos), len(server.pendingSpends))
+	}
+
+	// The coinbase UTXO matures once the virtual selected parent blue score advances
+	server.onVirtualSelectedParentBlueScoreChanged(nil, &appmessage.VirtualSelectedParentBlueScoreChangedNotificationMessage{
+		VirtualSelectedParentBlueScore: 2*maturity + 1,
+	})
+	balance, err = server.GetBalance(context.Background(), &pb.GetBalanceRequest{})
+	if err != nil {
+		t.Fatalf("GetBalance: %s", err)
+	}
+	if balance.Available != 300 || balance.Pending != 0 {
+		t.Fatalf("expected an available balance of 300 and no pending balance, got %d and %d",
+			balance.Available, balance.Pending)
+	}
+}
+
+func TestNotificationsWhileSyncing(t *testing.T) {
+	server, teardown := newServerForTest(t, "TestNotificationsWhileSyncing")
+	defer teardown()
+
+	address := walletAddressForTest(t, server, libwallet.ExternalKeychain, 0)
+	spentEntry := utxoEntryForTest(address, 1, 100, 1, false)
+	receivedEntry := utxoEntryForTest(address, 2, 200, 1, false)
+	spentUTXO, err := libwallet.UTXOFromRPC(spentEntry, address)
+	if err != nil {
+		t.Fatalf("UTXOFromRPC: %s", err)
+	}
+
+	// A change that arrives while the UTXOs are being fetched might be newer
+	// than the fetched UTXOs, so it's applied on top of them
+	_, err = server.resetSubscriptions(nil)
+	if err != nil {
+		t.Fatalf("resetSubscriptions: %s", err)
+	}
+	server.onUTXOsChanged(nil, &appmessage.UTXOsChangedNotificationMessage{
+		Added:   []*appmessage.UTXOsByAddressesEntry{receivedEntry},
+		Removed: []*appmessage.UTXOsByAddressesEntry{spentEntry},
+	})
+	if len(server.utxos) != 0 {
+		t.Fatalf("expected the notification to not be applied before the sync finishes")
+	}
+	server.finishSyncNoLock([]*libwallet.UTXO{spentUTXO}, 1)
+
+	if len(server.utxos) != 1 {
+		t.Fatalf("expected a single UTXO after the sync, got %d", len(server.utxos))
+	}
+	for _, utxo := range server.utxos {
+		if utxo.Amount != 200 {
+			t.Fatalf("expected the received UTXO to replace the spent one, got a UTXO of %d", utxo.Amount)
+		}
+	}
+}
+
+func TestMissingPendingSpendsAreReleasedAfterGrace(t *testing.T) {
+	server, teardown := newServerForTest(t, "TestMissingPendingSpendsAreReleasedAfterGrace")
+	defer teardown()
+
+	address := walletAddressForTest(t, server, libwallet.ExternalKeychain, 0)
+	acceptedEntry := utxoEntryForTest(address, 1, 100, 1, false)
+	droppedEntry := utxoEntryForTest(address, 2, 200, 1, false)
+	server.onUTXOsChanged(nil, &appmessage.UTXOsChangedNotificationMessage{
+		Added: []*appmessage.UTXOsByAddressesEntry{acceptedEntry, droppedEntry},
+	})
+	acceptedOutpoint, err := libwallet.OutpointFromRPC(acceptedEntry.Outpoint)
+	if err != nil {
+		t.Fatalf("OutpointFromRPC: %s", err)
+	}
+	droppedOutpoint, err := libwallet.OutpointFromRPC(droppedEntry.Outpoint)
+	if err != nil {
+		t.Fatalf("OutpointFromRPC: %s", err)
+	}
+	server.addPendingSpendsNoLock([]externalapi.DomainOutpoint{*acceptedOutpoint}, "accepted")
+	server.addPendingSpendsNoLock([]externalapi.DomainOutpoint{*droppedOutpoint}, "dropped")
+
+	// Both transactions are missing from the mempool: one was added in a
+	// block whose UTXO changes didn't arrive yet, and the other was dropped
+	checkedTransactionIDs := map[string]struct{}{"accepted": {}, "dropped": {}}
+	server.virtualSelectedParentBlueScore = 10
+	server.updateMissingPendingSpendsNoLock(checkedTransactionIDs, checkedTransactionIDs)
+	server.virtualSelectedParentBlueScore = 10 + pendingSpendReleaseBlueScoreGrace - 1
+	server.updateMissingPendingSpendsNoLock(checkedTransactionIDs, checkedTransactionIDs)
+	if len(server.pendingSpends) != 2 {
+		t.Fatalf("expected no pending spend to be released within the grace period, got %d pending spends",
+			len(server.pendingSpends))
+	}
+
+	// The UTXO changes of the accepted transaction remove its pending spend,
+	// and only the dropped transaction's spend is released after the grace
+	server.onUTXOsChanged(nil, &appmessage.UTXOsChangedNotificationMessage{
+		Removed: []*appmessage.UTXOsByAddressesEntry{acceptedEntry},
+	})
+	server.virtualSelectedParentBlueScore = 10 + pendingSpendReleaseBlueScoreGrace
+	server.updateMissingPendingSpendsNoLock(checkedTransactionIDs, checkedTransactionIDs)
+	if len(server.pendingSpends) != 0 {
+		t.Fatalf("expected the pending spend of the dropped transaction to be released, got %d pending spends",
+			len(server.pendingSpends))
+	}
+	if _, ok := server.utxos[*droppedOutpoint]; !ok {
+		t.Fatalf("expected the UTXO spent by the dropped transaction to remain")
+	}
+
+	// A transaction that's back in the mempool starts a new grace period
+	// once it goes missing again
+	server.addPendingSpendsNoLock([]externalapi.DomainOutpoint{*droppedOutpoint}, "dropped")
+	checkedTransactionIDs = map[string]struct{}{"dropped": {}}
+	server.updateMissingPendingSpendsNoLock(checkedTransactionIDs, checkedTransactionIDs)
+	server.updateMissingPendingSpendsNoLock(checkedTransactionIDs, map[string]struct{}{})
+	server.virtualSelectedParentBlueScore += pendingSpendReleaseBlueScoreGrace
+	server.updateMissingPendingSpendsNoLock(checkedTransactionIDs, checkedTransactionIDs)
+	if len(server.pendingSpends) != 1 {
+		t.Fatalf("expected a transaction that returned to the mempool to get a new grace period")
+	}
+}
+
+func TestRequestsFailWhenNotSynced(t *testing.T) {
+	server, teardown := newServerForTest(t, "TestRequestsFailWhenNotSynced")
+	defer teardown()
+
+	server.isSynced = false
+	_, err := server.GetBalance(context.Background(), &pb.GetBalanceRequest{})
+	if err != errNotSynced {
+		t.Fatalf("expected GetBalance to fail with errNotSynced, got %v", err)
+	}
+	_, err = server.Send(context.Background(), &pb.SendRequest{})
+	if err != errNotSynced {
+		t.Fatalf("expected Send to fail with errNotSynced, got %v", err)
+	}
+}
+
+func TestNewAddressRequestsResync(t *testing.T) {
+	server, teardown := newServerForTest(t, "TestNewAddressRequestsResync")
+	defer teardown()
+
+	for i := 0; i < addressLookahead/2; i++ {
+		select {
+		case <-server.resyncChan:
+			t.Fatalf("unexpected resync request after %d new addresses", i)
+		default:
+		}
+		_, err := server.NewAddress(context.Background(), &pb.NewAddressRequest{})
+		if err != nil {
+			t.Fatalf("NewAddress: %s", err)
+		}
+	}
+
+	// The wallet started with a single address, so by now half of the
+	// lookahead addresses were given out, and the next one runs it low
+	_, err := server.NewAddress(context.Background(), &pb.NewAddressRequest{})
+	if err != nil {
+		t.Fatalf("NewAddress: %s", err)
+	}
+	select {
+	case <-server.resyncChan:
+	default:
+		t.Fatalf("expected a resync request once the lookahead ran low")
+	}
+}
+
+func TestChangeAddressesAreNeverReused(t *testing.T) {
+	server, teardown := newServerForTest(t, "TestChangeAddressesAreNeverReused")
+	defer teardown()
+
+	firstAddress := walletAddressForTest(t, server, libwallet.ExternalKeychain, 0)
+	server.onUTXOsChanged(nil, &appmessage.UTXOsChangedNotificationMessage{
+		Added: []*appmessage.UTXOsByAddressesEntry{
+			utxoEntryForTest(firstAddress, 1, 100000000, 0, false),
+			utxoEntryForTest(firstAddress, 2, 100000000, 0, false),
+		},
+	})
+	keystore, err := libwallet.LoadKeystore(server.keysFilePath)
+	if err != nil {
+		t.Fatalf("LoadKeystore: %s", err)
+	}
+	accountPrivateKey, err := keystore.AccountPrivateKey([]byte("passphrase"))
+	if err != nil {
+		t.Fatalf("AccountPrivateKey: %s", err)
+	}
+
+	// Every created transaction takes a change address of its own, whether
+	// it's eventually sent or not
+	for i := uint32(1); i <= 2; i++ {
+		_, _, _, err := server.createTransaction(accountPrivateKey, firstAddress.Address, 1000, 1)
+		if err != nil {
+			t.Fatalf("createTransaction: %s", err)
+		}
+		keystore, err := libwallet.LoadKeystore(server.keysFilePath)
+		if err != nil {
+			t.Fatalf("LoadKeystore: %s", err)
+		}
+		if keystore.InternalAddressCount != i || server.addressCounts[libwallet.InternalKeychain] != i {
+			t.Fatalf("expected %d change addresses to be given out, got %d in the keystore and %d in the server",
+				i, keystore.InternalAddressCount, server.addressCounts[libwallet.InternalKeychain])
+		}
+	}
+
+	// A transaction that couldn't be created doesn't take a change address
+	_, _, _, err = server.createTransaction(accountPrivateKey, firstAddress.Address, 1000, 1)
+	if err == nil {
+		t.Fatalf("expected createTransaction to fail once all the UTXOs are pending spends")
+	}
+	keystore, err = libwallet.LoadKeystore(server.keysFilePath)
+	if err != nil {
+		t.Fatalf("LoadKeystore: %s", err)
+	}
+	if keystore.InternalAddressCount != 2 {
+		t.Fatalf("expected a failed transaction to not take a change address, got %d change addresses",
+			keystore.InternalAddressCount)
+	}
+}
+
+func TestValidateListenAddress(t *testing.T) {
+	tests := []struct {
+		address       string
+		expectedError string
+	}{
+		{address: "localhost:8082"},
+		{address: "127.0.0.1:8082"},
+		{address: "[::1]:8082"},
+		{address: "0.0.0.0:8082", expectedError: "loopback"},
+		{address: "example.com:8082", expectedError: "loopback"},
+		{address: "localhost", expectedError: "invalid listen address"},
+	}
+	for _, test := range tests {
+		err := validateListenAddress(test.address)
+		if test.expectedError == "" {
+			if err != nil {
+				t.Errorf("validateListenAddress(%s): unexpected error: %s", test.address, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+			t.Errorf("validateListenAddress(%s): expected an error containing %q, got %v",
+				test.address, test.expectedError, err)
+		}
+	}
+}
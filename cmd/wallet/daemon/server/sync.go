@@ -0,0 +1,314 @@
+package server
+
+import (
+	"time"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient"
+	"github.com/pkg/errors"
+)
+
+const (
+	// addressLookahead is the number of addresses beyond the ones the wallet
+	// has given out that the daemon is subscribed to in every keychain
+	addressLookahead = 20
+
+	// syncRetryInterval is the time to wait before retrying to sync
+	// after a failure
+	syncRetryInterval = 5 * time.Second
+)
+
+// syncLoop syncs the UTXO set with kaspad, and then waits until a resync
+// is required, either because the connection to kaspad was lost or because
+// the wallet outgrew the addresses the daemon is subscribed to
+func (s *Server) syncLoop() {
+	for {
+		err := s.sync()
+		if err != nil {
+			if s.isQuitting() {
+				return
+			}
+			log.Warnf("Error syncing with kaspad: %s. Retrying in %s", err, syncRetryInterval)
+			if !s.sleep(syncRetryInterval) {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-s.quitChan:
+			return
+		case <-s.resyncChan:
+			log.Infof("Resyncing with kaspad")
+		}
+	}
+}
+
+func (s *Server) requestResync() {
+	select {
+	case s.resyncChan <- struct{}{}:
+	default:
+	}
+}
+
+// sync connects to kaspad, subscribes to changes in the UTXOs of the wallet
+// addresses and in the virtual selected parent blue score, and then fetches
+// their current state. The subscriptions are made first so that no change
+// is missed between the fetch and the subscription, and the changes that
+// arrive until the fetch is done are applied on top of the fetched UTXOs,
+// since they might be newer than them.
+func (s *Server) sync() error {
+	client, err := rpcclient.NewRPCClient(s.rpcServer, s.connectOptions)
+	if err != nil {
+		return err
+	}
+	client.SetOnErrorHandler(func(err error) {
+		log.Warnf("Lost the connection to kaspad: %s", err)
+		s.lock.Lock()
+		s.isSynced = false
+		s.lock.Unlock()
+		s.requestResync()
+	})
+
+	addresses, err := s.resetSubscriptions(client)
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	err = client.RegisterForUTXOsChangedNotifications(addressStrings(addresses),
+		func(notification *appmessage.UTXOsChangedNotificationMessage) {
+			s.onUTXOsChanged(client, notification)
+		})
+	if err != nil {
+		client.Close()
+		return err
+	}
+	err = client.RegisterForVirtualSelectedParentBlueScoreChangedNotifications(
+		func(notification *appmessage.VirtualSelectedParentBlueScoreChangedNotificationMessage) {
+			s.onVirtualSelectedParentBlueScoreChanged(client, notification)
+		})
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	utxos, err := libwallet.FetchUTXOs(client, addresses)
+	if err != nil {
+		client.Close()
+		return err
+	}
+	virtualSelectedParentBlueScoreResponse, err := client.GetVirtualSelectedParentBlueScore()
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.finishSyncNoLock(utxos, virtualSelectedParentBlueScoreResponse.BlueScore)
+	log.Infof("Synced %d UTXOs of %d addresses", len(s.utxos), len(addresses))
+	return nil
+}
+
+// finishSyncNoLock replaces the UTXOs of the wallet with the fetched ones,
+// and applies the notifications that arrived while they were fetched
+func (s *Server) finishSyncNoLock(utxos []*libwallet.UTXO, virtualSelectedParentBlueScore uint64) {
+	s.utxos = make(map[externalapi.DomainOutpoint]*libwallet.UTXO, len(utxos))
+	for _, utxo := range utxos {
+		s.addUTXONoLock(utxo)
+	}
+	bufferedNotifications := s.bufferedUTXOsChangedNotifications
+	s.bufferedUTXOsChangedNotifications = nil
+	for _, notification := range bufferedNotifications {
+		s.applyUTXOsChangedNoLock(notification)
+	}
+
+	for outpoint := range s.pendingSpends {
+		if _, ok := s.utxos[outpoint]; !ok {
+			delete(s.pendingSpends, outpoint)
+		}
+	}
+	if virtualSelectedParentBlueScore > s.virtualSelectedParentBlueScore {
+		s.virtualSelectedParentBlueScore = virtualSelectedParentBlueScore
+	}
+	s.isSynced = true
+}
+
+// resetSubscriptions replaces the RPC client with the given one, and
+// returns the addresses it should be subscribed to
+func (s *Server) resetSubscriptions(client *rpcclient.RPCClient) ([]*libwallet.WalletAddress, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.rpcClient != nil {
+		s.rpcClient.Close()
+	}
+	s.rpcClient = client
+	s.isSynced = false
+	s.bufferedUTXOsChangedNotifications = make([]*appmessage.UTXOsChangedNotificationMessage, 0)
+	s.virtualSelectedParentBlueScore = 0
+
+	keystore, err := libwallet.LoadKeystore(s.keysFilePath)
+	if err != nil {
+		return nil, err
+	}
+	accountPublicKey, err := keystore.AccountPublicKeyExtended()
+	if err != nil {
+		return nil, err
+	}
+
+	s.addresses = make(map[string]*libwallet.WalletAddress)
+	s.addressCounts = map[libwallet.Keychain]uint32{
+		libwallet.ExternalKeychain: keystore.ExternalAddressCount,
+		libwallet.InternalKeychain: keystore.InternalAddressCount,
+	}
+	addresses := make([]*libwallet.WalletAddress, 0)
+	for keychain, count := range s.addressCounts {
+		subscribedCount := count + addressLookahead
+		for index := uint32(0); index < subscribedCount; index++ {
+			address, err := libwallet.DeriveAddress(accountPublicKey, keychain, index, s.params.Prefix)
+			if err != nil {
+				return nil, err
+			}
+			walletAddress := &libwallet.WalletAddress{Keychain: keychain, Index: index, Address: address}
+			s.addresses[address.String()] = walletAddress
+			addresses = append(addresses, walletAddress)
+		}
+		s.subscribedAddressCounts[keychain] = subscribedCount
+	}
+	return addresses, nil
+}
+
+// onUTXOsChanged handles a UTXOs changed notification of the given client.
+// Notifications of clients that were already replaced are ignored.
+func (s *Server) onUTXOsChanged(client *rpcclient.RPCClient, notification *appmessage.UTXOsChangedNotificationMessage) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if client != s.rpcClient {
+		return
+	}
+	if s.bufferedUTXOsChangedNotifications != nil {
+		s.bufferedUTXOsChangedNotifications = append(s.bufferedUTXOsChangedNotifications, notification)
+		return
+	}
+	s.applyUTXOsChangedNoLock(notification)
+}
+
+func (s *Server) applyUTXOsChangedNoLock(notification *appmessage.UTXOsChangedNotificationMessage) {
+	for _, removed := range notification.Removed {
+		outpoint, err := libwallet.OutpointFromRPC(removed.Outpoint)
+		if err != nil {
+			log.Errorf("Got a malformed outpoint from kaspad: %s", err)
+			continue
+		}
+		delete(s.utxos, *outpoint)
+		delete(s.pendingSpends, *outpoint)
+	}
+	for _, added := range notification.Added {
+		address, ok := s.addresses[added.Address]
+		if !ok {
+			log.Warnf("Got a UTXO of %s, which is not a wallet address", added.Address)
+			continue
+		}
+		utxo, err := libwallet.UTXOFromRPC(added, address)
+		if err != nil {
+			log.Errorf("Got a malformed UTXO from kaspad: %s", err)
+			continue
+		}
+		s.addUTXONoLock(utxo)
+	}
+}
+
+func (s *Server) onVirtualSelectedParentBlueScoreChanged(client *rpcclient.RPCClient,
+	notification *appmessage.VirtualSelectedParentBlueScoreChangedNotificationMessage) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if client != s.rpcClient {
+		return
+	}
+	s.virtualSelectedParentBlueScore = notification.VirtualSelectedParentBlueScore
+}
+
+func (s *Server) addUTXONoLock(utxo *libwallet.UTXO) {
+	s.utxos[utxo.Outpoint] = utxo
+	err := s.markAddressUsedNoLock(utxo.Address)
+	if err != nil {
+		log.Errorf("Error marking %s as used: %s", utxo.Address.Address, err)
+	}
+}
+
+// markAddressUsedNoLock makes sure the wallet counts the given address as
+// given out. This happens when funds are received to an address within the
+// lookahead, for example in a wallet that was restored from its mnemonic.
+func (s *Server) markAddressUsedNoLock(address *libwallet.WalletAddress) error {
+	if address.Index < s.addressCounts[address.Keychain] {
+		return nil
+	}
+
+	// The keystore is reloaded before every change, so that changes made
+	// to it by other wallet instances aren't overwritten
+	keystore, err := libwallet.LoadKeystore(s.keysFilePath)
+	if err != nil {
+		return err
+	}
+	count := &keystore.ExternalAddressCount
+	if address.Keychain == libwallet.InternalKeychain {
+		count = &keystore.InternalAddressCount
+	}
+	if address.Index >= *count {
+		*count = address.Index + 1
+		err = keystore.Save()
+		if err != nil {
+			return err
+		}
+	}
+	s.updateAddressCountNoLock(address.Keychain, *count)
+	return nil
+}
+
+// newAddressNoLock derives the next unused address in the given keychain
+func (s *Server) newAddressNoLock(keychain libwallet.Keychain) (*libwallet.WalletAddress, error) {
+	keystore, err := libwallet.LoadKeystore(s.keysFilePath)
+	if err != nil {
+		return nil, err
+	}
+	address, err := keystore.NewAddress(keychain, s.params.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	err = keystore.Save()
+	if err != nil {
+		return nil, err
+	}
+	s.updateAddressCountNoLock(keychain, address.Index+1)
+	return address, nil
+}
+
+// updateAddressCountNoLock records that the wallet gave out the given number
+// of addresses in the given keychain, and requests a resync if that doesn't
+// leave enough lookahead addresses
+func (s *Server) updateAddressCountNoLock(keychain libwallet.Keychain, count uint32) {
+	if count > s.addressCounts[keychain] {
+		s.addressCounts[keychain] = count
+	}
+	if count+addressLookahead/2 > s.subscribedAddressCounts[keychain] {
+		s.requestResync()
+	}
+}
+
+func addressStrings(addresses []*libwallet.WalletAddress) []string {
+	result := make([]string, len(addresses))
+	for i, address := range addresses {
+		result[i] = address.Address.String()
+	}
+	return result
+}
+
+var errNotSynced = errors.New("the wallet daemon is not synced with kaspad yet")
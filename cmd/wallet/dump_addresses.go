@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
+)
+
+func dumpAddresses(conf *dumpAddressesConfig) error {
+	keystore, err := libwallet.LoadKeystore(conf.keysFilePath(&conf.NetworkFlags))
+	if err != nil {
+		return err
+	}
+	addresses, err := keystore.Addresses(conf.ActiveNetParams.Prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, address := range addresses {
+		fmt.Printf("%s\t%s\t%s\n", address.Address, address.Keychain,
+			libwallet.DerivationPath(address.Keychain, address.Index))
+	}
+	return nil
+}
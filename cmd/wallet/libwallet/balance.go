@@ -0,0 +1,35 @@
+package libwallet
+
+// Balance is the balance of a set of UTXOs. Available is the amount that can
+// be spent right away, while Pending is the amount of coinbase outputs that
+// haven't matured yet.
+type Balance struct {
+	Available uint64
+	Pending   uint64
+}
+
+func (balance *Balance) add(utxo *UTXO, virtualSelectedParentBlueScore uint64, coinbaseMaturity uint64) {
+	if utxo.IsSpendable(virtualSelectedParentBlueScore, coinbaseMaturity) {
+		balance.Available += utxo.Amount
+	} else {
+		balance.Pending += utxo.Amount
+	}
+}
+
+// CalculateBalances calculates the total balance of the given UTXOs, as well
+// as the balance of every address they belong to
+func CalculateBalances(utxos []*UTXO, virtualSelectedParentBlueScore uint64, coinbaseMaturity uint64) (
+	total *Balance, balancesByAddress map[string]*Balance) {
+
+	total = &Balance{}
+	balancesByAddress = make(map[string]*Balance)
+	for _, utxo := range utxos {
+		address := utxo.Address.Address.String()
+		if _, ok := balancesByAddress[address]; !ok {
+			balancesByAddress[address] = &Balance{}
+		}
+		total.add(utxo, virtualSelectedParentBlueScore, coinbaseMaturity)
+		balancesByAddress[address].add(utxo, virtualSelectedParentBlueScore, coinbaseMaturity)
+	}
+	return total, balancesByAddress
+}
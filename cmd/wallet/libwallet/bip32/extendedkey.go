@@ -0,0 +1,410 @@
+package bip32
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// HardenedKeyStart is the index of the first hardened child key
+	HardenedKeyStart = 0x80000000
+
+	// MinSeedLength is the minimum allowed length of a master seed
+	MinSeedLength = 16
+
+	// MaxSeedLength is the maximum allowed length of a master seed
+	MaxSeedLength = 64
+
+	// SerializedKeyLength is the length of a serialized extended key
+	SerializedKeyLength = 78
+
+	chainCodeLength = 32
+)
+
+var (
+	// masterKeyHMACKey is the HMAC key used to derive the master key
+	// from a seed, as defined in BIP32
+	masterKeyHMACKey = []byte("Bitcoin seed")
+
+	privateKeyVersion = [4]byte{0x04, 0x88, 0xad, 0xe4}
+	publicKeyVersion  = [4]byte{0x04, 0x88, 0xb2, 0x1e}
+
+	// curveOrder is the order of the secp256k1 group, which tweaks must
+	// be lower than
+	curveOrder, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+)
+
+const (
+	evenPublicKeyPrefix = 0x02
+	oddPublicKeyPrefix  = 0x03
+)
+
+// ErrInvalidChild is returned when a derived child key is invalid,
+// which happens with a probability lower than 1 in 2^127. BIP32
+// requires the caller to skip to the next index in that case.
+var ErrInvalidChild = errors.New("the derived child key is invalid")
+
+// ExtendedKey is a BIP32 extended key. It can be either a private key,
+// from which both private and public child keys can be derived, or a
+// public key, from which only non-hardened public child keys can be
+// derived.
+type ExtendedKey struct {
+	// key is a 32-byte private key if isPrivate is set, and a
+	// 33-byte compressed public key otherwise
+	key               []byte
+	chainCode         []byte
+	depth             uint8
+	parentFingerprint uint32
+	childIndex        uint32
+	isPrivate         bool
+}
+
+// NewMasterKey derives the master extended private key from the given seed
+func NewMasterKey(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < MinSeedLength || len(seed) > MaxSeedLength {
+		return nil, errors.Errorf("seed length must be between %d and %d bytes, but got %d",
+			MinSeedLength, MaxSeedLength, len(seed))
+	}
+
+	hmacHash := hmac.New(sha512.New, masterKeyHMACKey)
+	hmacHash.Write(seed)
+	digest := hmacHash.Sum(nil)
+	key, chainCode := digest[:32], digest[32:]
+
+	_, err := secp256k1.DeserializePrivateKeyFromSlice(key)
+	if err != nil {
+		return nil, errors.New("the seed produces an invalid master key")
+	}
+
+	return &ExtendedKey{
+		key:       key,
+		chainCode: chainCode,
+		isPrivate: true,
+	}, nil
+}
+
+// IsPrivate returns whether this is an extended private key
+func (k *ExtendedKey) IsPrivate() bool {
+	return k.isPrivate
+}
+
+// Depth returns the number of derivations between the master key and this key
+func (k *ExtendedKey) Depth() uint8 {
+	return k.depth
+}
+
+// ChildIndex returns the index this key was derived with
+func (k *ExtendedKey) ChildIndex() uint32 {
+	return k.childIndex
+}
+
+// Child derives the child extended key at the given index. Indexes
+// starting at HardenedKeyStart derive hardened keys, which can only
+// be derived from private keys.
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	isHardened := index >= HardenedKeyStart
+	if isHardened && !k.isPrivate {
+		return nil, errors.New("cannot derive a hardened child from a public key")
+	}
+	if k.depth == 255 {
+		return nil, errors.New("cannot derive a child beyond depth 255")
+	}
+
+	data := make([]byte, 0, 37)
+	if isHardened {
+		data = append(data, 0x00)
+		data = append(data, k.key...)
+	} else {
+		publicKey, err := k.compressedPublicKey()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, publicKey...)
+	}
+	var indexBytes [4]byte
+	binary.BigEndian.PutUint32(indexBytes[:], index)
+	data = append(data, indexBytes[:]...)
+
+	hmacHash := hmac.New(sha512.New, k.chainCode)
+	hmacHash.Write(data)
+	digest := hmacHash.Sum(nil)
+	tweak, childChainCode := digest[:32], digest[32:]
+
+	tweakNum := new(big.Int).SetBytes(tweak)
+	if tweakNum.Cmp(curveOrder) >= 0 {
+		return nil, ErrInvalidChild
+	}
+
+	var childKey []byte
+	if k.isPrivate {
+		childKeyNum := new(big.Int).Add(tweakNum, new(big.Int).SetBytes(k.key))
+		childKeyNum.Mod(childKeyNum, curveOrder)
+		if childKeyNum.Sign() == 0 {
+			return nil, ErrInvalidChild
+		}
+		childKey = make([]byte, 32)
+		childKeyNum.FillBytes(childKey)
+	} else {
+		var err error
+		childKey, err = addTweakToPublicKey(k.key, tweakNum)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	parentFingerprint, err := k.fingerprint()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExtendedKey{
+		key:               childKey,
+		chainCode:         childChainCode,
+		depth:             k.depth + 1,
+		parentFingerprint: parentFingerprint,
+		childIndex:        index,
+		isPrivate:         k.isPrivate,
+	}, nil
+}
+
+// DerivePath derives the descendant extended key at the given path,
+// relative to this key
+func (k *ExtendedKey) DerivePath(path []uint32) (*ExtendedKey, error) {
+	descendant := k
+	for _, index := range path {
+		var err error
+		descendant, err = descendant.Child(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return descendant, nil
+}
+
+// Public returns the extended public key that corresponds to this key
+func (k *ExtendedKey) Public() (*ExtendedKey, error) {
+	if !k.isPrivate {
+		return k, nil
+	}
+	publicKey, err := k.compressedPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return &ExtendedKey{
+		key:               publicKey,
+		chainCode:         k.chainCode,
+		depth:             k.depth,
+		parentFingerprint: k.parentFingerprint,
+		childIndex:        k.childIndex,
+		isPrivate:         false,
+	}, nil
+}
+
+// PrivateKey returns the key pair of this extended private key
+func (k *ExtendedKey) PrivateKey() (*secp256k1.SchnorrKeyPair, error) {
+	if !k.isPrivate {
+		return nil, errors.New("cannot get the private key of an extended public key")
+	}
+	return secp256k1.DeserializePrivateKeyFromSlice(k.key)
+}
+
+// SchnorrPublicKey returns the x-only public key of this extended key,
+// as used by kaspa addresses and signatures
+func (k *ExtendedKey) SchnorrPublicKey() (*secp256k1.SchnorrPublicKey, error) {
+	publicKey, err := k.compressedPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return secp256k1.DeserializeSchnorrPubKey(publicKey[1:])
+}
+
+// Address returns the pay-to-pubkey-hash address of this extended key
+func (k *ExtendedKey) Address(prefix util.Bech32Prefix) (*util.AddressPubKeyHash, error) {
+	publicKey, err := k.SchnorrPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	serializedPublicKey, err := publicKey.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return util.NewAddressPubKeyHashFromPublicKey(serializedPublicKey[:], prefix)
+}
+
+func (k *ExtendedKey) compressedPublicKey() ([]byte, error) {
+	if !k.isPrivate {
+		return k.key, nil
+	}
+	keyPair, err := secp256k1.DeserializePrivateKeyFromSlice(k.key)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := keyPair.SchnorrPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	serializedPublicKey, err := publicKey.Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	// Tweaking a key pair negates its private key first if its public key
+	// has an odd y coordinate, so tweaking it by zero leaves the private
+	// key as is only if the y coordinate is even
+	err = keyPair.Add([32]byte{})
+	if err != nil {
+		return nil, err
+	}
+	isOdd := subtle.ConstantTimeCompare(keyPair.SerializePrivateKey()[:], k.key) == 0
+
+	return compressPublicKey(serializedPublicKey[:], isOdd), nil
+}
+
+// addTweakToPublicKey returns the compressed public key of P + tweak*G,
+// where P is the point of the given compressed public key
+func addTweakToPublicKey(compressedPublicKey []byte, tweak *big.Int) ([]byte, error) {
+	if tweak.Sign() == 0 {
+		return append([]byte{}, compressedPublicKey...), nil
+	}
+
+	// x-only public keys stand for the point with an even y coordinate,
+	// so if P has an odd y coordinate, the sum is computed as
+	// -(-P + (-tweak)*G)
+	xOnlyPublicKey := compressedPublicKey[1:]
+	isOdd := compressedPublicKey[0] == oddPublicKeyPrefix
+	xOnlyTweak := tweak
+	if isOdd {
+		xOnlyTweak = new(big.Int).Sub(curveOrder, tweak)
+	}
+	xOnlySum, err := addTweakToXOnlyPublicKey(xOnlyPublicKey, xOnlyTweak)
+	if err != nil {
+		return nil, ErrInvalidChild
+	}
+
+	// The y coordinate of the sum is lost, so it's recovered by taking the
+	// tweak off the even point of the sum: it gives back the even point of
+	// P only if the sum is that even point
+	xOnlyDifference, err := addTweakToXOnlyPublicKey(xOnlySum, new(big.Int).Sub(curveOrder, xOnlyTweak))
+	isSumOdd := err != nil || !bytes.Equal(xOnlyDifference, xOnlyPublicKey)
+
+	return compressPublicKey(xOnlySum, isSumOdd != isOdd), nil
+}
+
+// addTweakToXOnlyPublicKey returns the x-only public key of P + tweak*G,
+// where P is the even point of the given x-only public key
+func addTweakToXOnlyPublicKey(xOnlyPublicKey []byte, tweak *big.Int) ([]byte, error) {
+	publicKey, err := secp256k1.DeserializeSchnorrPubKey(xOnlyPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	var tweakBytes [32]byte
+	tweak.FillBytes(tweakBytes[:])
+	err = publicKey.Add(tweakBytes)
+	if err != nil {
+		return nil, err
+	}
+	serializedPublicKey, err := publicKey.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return serializedPublicKey[:], nil
+}
+
+func compressPublicKey(xOnlyPublicKey []byte, isOdd bool) []byte {
+	compressedPublicKey := make([]byte, 0, 33)
+	if isOdd {
+		compressedPublicKey = append(compressedPublicKey, oddPublicKeyPrefix)
+	} else {
+		compressedPublicKey = append(compressedPublicKey, evenPublicKeyPrefix)
+	}
+	return append(compressedPublicKey, xOnlyPublicKey...)
+}
+
+// fingerprint returns the first four bytes of the HASH160 of the
+// compressed public key, which is used to identify a parent key
+func (k *ExtendedKey) fingerprint() (uint32, error) {
+	publicKey, err := k.compressedPublicKey()
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(util.Hash160(publicKey)[:4]), nil
+}
+
+// Serialize serializes the extended key in the 78-byte format defined in BIP32
+func (k *ExtendedKey) Serialize() []byte {
+	serialized := make([]byte, 0, SerializedKeyLength)
+	if k.isPrivate {
+		serialized = append(serialized, privateKeyVersion[:]...)
+	} else {
+		serialized = append(serialized, publicKeyVersion[:]...)
+	}
+	serialized = append(serialized, k.depth)
+	var uint32Bytes [4]byte
+	binary.BigEndian.PutUint32(uint32Bytes[:], k.parentFingerprint)
+	serialized = append(serialized, uint32Bytes[:]...)
+	binary.BigEndian.PutUint32(uint32Bytes[:], k.childIndex)
+	serialized = append(serialized, uint32Bytes[:]...)
+	serialized = append(serialized, k.chainCode...)
+	if k.isPrivate {
+		serialized = append(serialized, 0x00)
+	}
+	serialized = append(serialized, k.key...)
+	return serialized
+}
+
+// DeserializeExtendedKey deserializes an extended key that was
+// serialized with Serialize
+func DeserializeExtendedKey(serialized []byte) (*ExtendedKey, error) {
+	if len(serialized) != SerializedKeyLength {
+		return nil, errors.Errorf("serialized extended key must be %d bytes long, but got %d",
+			SerializedKeyLength, len(serialized))
+	}
+
+	version := serialized[:4]
+	var isPrivate bool
+	switch {
+	case bytes.Equal(version, privateKeyVersion[:]):
+		isPrivate = true
+	case bytes.Equal(version, publicKeyVersion[:]):
+		isPrivate = false
+	default:
+		return nil, errors.Errorf("unknown extended key version %x", version)
+	}
+
+	extendedKey := &ExtendedKey{
+		depth:             serialized[4],
+		parentFingerprint: binary.BigEndian.Uint32(serialized[5:9]),
+		childIndex:        binary.BigEndian.Uint32(serialized[9:13]),
+		chainCode:         append([]byte{}, serialized[13:13+chainCodeLength]...),
+		isPrivate:         isPrivate,
+	}
+	keyData := serialized[13+chainCodeLength:]
+	if isPrivate {
+		if keyData[0] != 0x00 {
+			return nil, errors.New("malformed extended private key")
+		}
+		_, err := secp256k1.DeserializePrivateKeyFromSlice(keyData[1:])
+		if err != nil {
+			return nil, errors.New("extended private key is out of range")
+		}
+		extendedKey.key = append([]byte{}, keyData[1:]...)
+	} else {
+		if keyData[0] != evenPublicKeyPrefix && keyData[0] != oddPublicKeyPrefix {
+			return nil, errors.New("malformed extended public key")
+		}
+		_, err := secp256k1.DeserializeSchnorrPubKey(keyData[1:])
+		if err != nil {
+			return nil, err
+		}
+		extendedKey.key = append([]byte{}, keyData...)
+	}
+	return extendedKey, nil
+}
@@ -0,0 +1,182 @@
+package bip32
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// TestBIP32Vectors checks derivation against the first test vector
+// in BIP32. The expected keys are the hex encodings of the base58
+// strings that appear in the BIP.
+func TestBIP32Vectors(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		path            string
+		expectedPrivate string
+		expectedPublic  string
+	}{
+		{
+			path: "m",
+			expectedPrivate: "0488ade4000000000000000000873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508" +
+				"00e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35",
+			expectedPublic: "0488b21e000000000000000000873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508" +
+				"0339a36013301597daef41fbe593a02cc513d0b55527ec2df1050e2e8ff49c85c2",
+		},
+		{
+			path: "m/0'",
+			expectedPrivate: "0488ade4013442193e8000000047fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141" +
+				"00edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+			expectedPublic: "0488b21e013442193e8000000047fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141" +
+				"035a784662a4a20a65bf6aab9ae98a6c068a81c52e4b032c0fb5400c706cfccc56",
+		},
+		{
+			path: "m/0'/1",
+			expectedPrivate: "0488ade4025c1bd648000000012a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19" +
+				"003c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368",
+			expectedPublic: "0488b21e025c1bd648000000012a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19" +
+				"03501e454bf00751f24b1b489aa925215d66af2234e3891c3b21a52bedb3cd711c",
+		},
+		{
+			path: "m/0'/1/2'/2",
+			expectedPrivate: "0488ade404ee7ab90c00000002cfb71883f01676f587d023cc53a35bc7f88f724b1f8c2892ac1275ac822a3edd" +
+				"000f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4",
+			expectedPublic: "0488b21e04ee7ab90c00000002cfb71883f01676f587d023cc53a35bc7f88f724b1f8c2892ac1275ac822a3edd" +
+				"02e8445082a72f29b75ca48748a914df60622a609cacfce8ed0e35804560741d29",
+		},
+		{
+			path: "m/0'/1/2'/2/1000000000",
+			expectedPrivate: "0488ade405d880d7d83b9aca00c783e67b921d2beb8f6b389cc646d7263b4145701dadd2161548a8b078e65e9e" +
+				"00471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8",
+			expectedPublic: "0488b21e05d880d7d83b9aca00c783e67b921d2beb8f6b389cc646d7263b4145701dadd2161548a8b078e65e9e" +
+				"022a471424da5e657499d1ff51cb43c47481a03b1e77f951fe64cec9f5a48f7011",
+		},
+	}
+
+	masterKey, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatalf("NewMasterKey: %s", err)
+	}
+	for _, test := range tests {
+		path, err := ParsePath(test.path)
+		if err != nil {
+			t.Fatalf("ParsePath: %s", err)
+		}
+		privateKey, err := masterKey.DerivePath(path)
+		if err != nil {
+			t.Fatalf("DerivePath: %s", err)
+		}
+		if serialized := hex.EncodeToString(privateKey.Serialize()); serialized != test.expectedPrivate {
+			t.Fatalf("unexpected private key at %s. Want: %s, got: %s", test.path, test.expectedPrivate, serialized)
+		}
+		publicKey, err := privateKey.Public()
+		if err != nil {
+			t.Fatalf("Public: %s", err)
+		}
+		if serialized := hex.EncodeToString(publicKey.Serialize()); serialized != test.expectedPublic {
+			t.Fatalf("unexpected public key at %s. Want: %s, got: %s", test.path, test.expectedPublic, serialized)
+		}
+
+		deserialized, err := DeserializeExtendedKey(publicKey.Serialize())
+		if err != nil {
+			t.Fatalf("DeserializeExtendedKey: %s", err)
+		}
+		if !bytes.Equal(deserialized.Serialize(), publicKey.Serialize()) {
+			t.Fatalf("extended public key at %s changed after a serialization round trip", test.path)
+		}
+	}
+}
+
+func TestPublicDerivationMatchesPrivateDerivation(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x42}, 32)
+	masterKey, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatalf("NewMasterKey: %s", err)
+	}
+	accountPath, _ := ParsePath("m/44'/111111'/0'")
+	accountKey, err := masterKey.DerivePath(accountPath)
+	if err != nil {
+		t.Fatalf("DerivePath: %s", err)
+	}
+	accountPublicKey, err := accountKey.Public()
+	if err != nil {
+		t.Fatalf("Public: %s", err)
+	}
+
+	// Deriving through different parents covers parents with both even and
+	// odd public keys
+	for index := uint32(0); index < 20; index++ {
+		privateChild, err := accountKey.DerivePath([]uint32{index, index})
+		if err != nil {
+			t.Fatalf("DerivePath: %s", err)
+		}
+		publicChild, err := accountPublicKey.DerivePath([]uint32{index, index})
+		if err != nil {
+			t.Fatalf("DerivePath: %s", err)
+		}
+		expectedPublicChild, err := privateChild.Public()
+		if err != nil {
+			t.Fatalf("Public: %s", err)
+		}
+		if !bytes.Equal(publicChild.Serialize(), expectedPublicChild.Serialize()) {
+			t.Fatalf("public derivation of index %d doesn't match private derivation", index)
+		}
+
+		keyPair, err := privateChild.PrivateKey()
+		if err != nil {
+			t.Fatalf("PrivateKey: %s", err)
+		}
+		keyPairPublicKey, err := keyPair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatalf("SchnorrPublicKey: %s", err)
+		}
+		publicChildPublicKey, err := publicChild.SchnorrPublicKey()
+		if err != nil {
+			t.Fatalf("SchnorrPublicKey: %s", err)
+		}
+		if !keyPairPublicKey.IsEqual(publicChildPublicKey) {
+			t.Fatalf("public key of index %d doesn't match its private key", index)
+		}
+	}
+
+	hardenedIndex := uint32(HardenedKeyStart)
+	_, err = accountPublicKey.Child(hardenedIndex)
+	if err == nil {
+		t.Fatalf("expected hardened derivation from a public key to fail")
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path          string
+		expected      []uint32
+		expectedError bool
+	}{
+		{path: "m", expected: []uint32{}},
+		{path: "m/44'/111111h/0'/1/2", expected: []uint32{HardenedKeyStart + 44, HardenedKeyStart + 111111,
+			HardenedKeyStart, 1, 2}},
+		{path: "44'/0", expectedError: true},
+		{path: "m/a", expectedError: true},
+		{path: "m/2147483648", expectedError: true},
+	}
+	for _, test := range tests {
+		indexes, err := ParsePath(test.path)
+		if test.expectedError {
+			if err == nil {
+				t.Fatalf("expected an error when parsing %s", test.path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ParsePath(%s): %s", test.path, err)
+		}
+		if len(indexes) != len(test.expected) {
+			t.Fatalf("ParsePath(%s): expected %v, got %v", test.path, test.expected, indexes)
+		}
+		for i := range indexes {
+			if indexes[i] != test.expected[i] {
+				t.Fatalf("ParsePath(%s): expected %v, got %v", test.path, test.expected, indexes)
+			}
+		}
+	}
+}
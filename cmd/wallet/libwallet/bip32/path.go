@@ -0,0 +1,37 @@
+package bip32
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParsePath parses a derivation path such as "m/44'/111111'/0'/0/1" into
+// child indexes. Hardened indexes are marked with either ' or h.
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, errors.Errorf("derivation path %s must start with m", path)
+	}
+
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		isHardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if isHardened {
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid index %s in derivation path %s", part, path)
+		}
+		if index >= HardenedKeyStart {
+			return nil, errors.Errorf("index %d in derivation path %s is out of range", index, path)
+		}
+		if isHardened {
+			index += HardenedKeyStart
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
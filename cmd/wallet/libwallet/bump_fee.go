@@ -0,0 +1,173 @@
+package libwallet
+
+import (
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet/bip32"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/constants"
+	"github.com/kaspanet/kaspad/domain/dagconfig"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+// minimumFeeBumpRate is the lowest fee rate, in sompi per gram, by which a
+// fee bump raises the fee of the transaction it replaces. kaspad only accepts
+// replacements that pay their own minimum relay fee of 1 sompi per byte on
+// top of the fees of the transactions they replace, and a transaction's mass
+// is never lower than its size.
+const minimumFeeBumpRate = 1.0
+
+// CreateFeeBumpTransaction creates and signs a transaction that replaces the
+// given transaction of the wallet, which is still in kaspad's mempool. It
+// spends the same UTXOs and pays the same outputs, except for the change
+// output, which pays for the higher fee. utxos must include the UTXOs that
+// the original transaction spends, and addresses must include its change
+// address. replacedFee is the total fee of the original transaction and of
+// all the transactions in the mempool that descend from it, which kaspad
+// removes along with it (see ReplacedFee). The new fee is the higher of
+// paying feeRate, and out-bidding replacedFee by minimumFeeBumpRate. Change
+// that kaspad would reject as dust is added to the fee instead. The original
+// transaction must be replaceable, and so is the new one, so that its fee
+// can be bumped again.
+func CreateFeeBumpTransaction(params *dagconfig.Params, accountPrivateKey *bip32.ExtendedKey,
+	original *appmessage.TransactionVerboseData, replacedFee uint64, utxos []*UTXO,
+	addresses []*WalletAddress, feeRate float64) (transaction *externalapi.DomainTransaction, fee uint64, err error) {
+
+	isReplaceable := false
+	for _, input := range original.TransactionVerboseInputs {
+		if input.Sequence < constants.MaxTxInSequenceNum-1 {
+			isReplaceable = true
+			break
+		}
+	}
+	if !isReplaceable {
+		return nil, 0, errors.Errorf("transaction %s is final, so kaspad won't replace it. Only transactions "+
+			"that were sent with --replaceable can be replaced", original.TxID)
+	}
+
+	utxosByOutpoint := make(map[externalapi.DomainOutpoint]*UTXO, len(utxos))
+	for _, utxo := range utxos {
+		utxosByOutpoint[utxo.Outpoint] = utxo
+	}
+	spentUTXOs := make([]*UTXO, len(original.TransactionVerboseInputs))
+	totalInput := uint64(0)
+	for i, input := range original.TransactionVerboseInputs {
+		outpoint, err := OutpointFromRPC(&appmessage.RPCOutpoint{TransactionID: input.TxID, Index: input.OutputIndex})
+		if err != nil {
+			return nil, 0, err
+		}
+		utxo, ok := utxosByOutpoint[*outpoint]
+		if !ok {
+			return nil, 0, errors.Errorf("input %d of transaction %s doesn't spend a UTXO of this wallet",
+				i, original.TxID)
+		}
+		spentUTXOs[i] = utxo
+		totalInput += utxo.Amount
+	}
+
+	addressesByString := make(map[string]*WalletAddress, len(addresses))
+	for _, address := range addresses {
+		addressesByString[address.Address.String()] = address
+	}
+	payments := make([]*Payment, len(original.TransactionVerboseOutputs))
+	changeIndex := -1
+	totalOutput := uint64(0)
+	for i, output := range original.TransactionVerboseOutputs {
+		address, err := util.DecodeAddress(output.ScriptPubKey.Address, params.Prefix)
+		if err != nil {
+			return nil, 0, err
+		}
+		payments[i] = &Payment{Address: address, Amount: output.Value}
+		totalOutput += output.Value
+
+		walletAddress, ok := addressesByString[address.String()]
+		if ok && walletAddress.Keychain == InternalKeychain && changeIndex == -1 {
+			changeIndex = i
+		}
+	}
+	if changeIndex == -1 {
+		return nil, 0, errors.Errorf("transaction %s has no change output to pay the higher fee from",
+			original.TxID)
+	}
+
+	// The mass doesn't depend on the amounts, so it's calculated once
+	// with the original outputs
+	transaction, err = CreateSignedTransaction(accountPrivateKey, spentUTXOs, payments, true)
+	if err != nil {
+		return nil, 0, err
+	}
+	mass, err := TransactionMass(params, transaction, spentUTXOs)
+	if err != nil {
+		return nil, 0, err
+	}
+	fee = RequiredFee(mass, feeRate)
+	if minimumFee := replacedFee + RequiredFee(mass, minimumFeeBumpRate); fee < minimumFee {
+		fee = minimumFee
+	}
+
+	totalPayments := totalOutput - payments[changeIndex].Amount
+	if totalInput < totalPayments+fee {
+		return nil, 0, errors.Errorf("the change of transaction %s can't cover a fee of %f KAS",
+			original.TxID, float64(fee)/util.SompiPerKaspa)
+	}
+	payments[changeIndex].Amount = totalInput - totalPayments - fee
+
+	// Dropping the change output only lowers the mass, so the fee still
+	// covers it
+	isChangeDust, err := isDustPayment(payments[changeIndex])
+	if err != nil {
+		return nil, 0, err
+	}
+	if isChangeDust {
+		fee += payments[changeIndex].Amount
+		payments = append(payments[:changeIndex], payments[changeIndex+1:]...)
+	}
+
+	transaction, err = CreateSignedTransaction(accountPrivateKey, spentUTXOs, payments, true)
+	if err != nil {
+		return nil, 0, err
+	}
+	return transaction, fee, nil
+}
+
+// ReplacedFee returns the total fee of the transaction with the given ID and
+// of all the transactions that descend from it among the given mempool
+// entries. These are all the transactions that kaspad removes when it
+// accepts a replacement of the given transaction, so the replacement has to
+// pay more than all of them together.
+func ReplacedFee(entries []*appmessage.MempoolEntry, txID string) (uint64, error) {
+	childrenByParentID := make(map[string][]*appmessage.MempoolEntry)
+	var original *appmessage.MempoolEntry
+	for _, entry := range entries {
+		verboseData := entry.TransactionVerboseData
+		if verboseData.TxID == txID {
+			original = entry
+		}
+		parentIDs := make(map[string]struct{})
+		for _, input := range verboseData.TransactionVerboseInputs {
+			if _, ok := parentIDs[input.TxID]; ok {
+				continue
+			}
+			parentIDs[input.TxID] = struct{}{}
+			childrenByParentID[input.TxID] = append(childrenByParentID[input.TxID], entry)
+		}
+	}
+	if original == nil {
+		return 0, errors.Errorf("transaction %s is not in the mempool", txID)
+	}
+
+	fee := uint64(0)
+	visited := make(map[string]struct{})
+	queue := []*appmessage.MempoolEntry{original}
+	for len(queue) > 0 {
+		entry := queue[0]
+		queue = queue[1:]
+		if _, ok := visited[entry.TransactionVerboseData.TxID]; ok {
+			continue
+		}
+		visited[entry.TransactionVerboseData.TxID] = struct{}{}
+		fee += entry.Fee
+		queue = append(queue, childrenByParentID[entry.TransactionVerboseData.TxID]...)
+	}
+	return fee, nil
+}
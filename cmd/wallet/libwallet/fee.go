@@ -0,0 +1,240 @@
+package libwallet
+
+import (
+	"math"
+
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet/bip32"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/estimatedsize"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
+	"github.com/kaspanet/kaspad/domain/dagconfig"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+// FeePriority selects which of kaspad's fee rate estimates the wallet pays
+type FeePriority string
+
+// The fee priorities, matching the buckets of kaspad's GetFeeEstimate
+const (
+	LowFeePriority      FeePriority = "low"
+	NormalFeePriority   FeePriority = "normal"
+	PriorityFeePriority FeePriority = "priority"
+)
+
+// FetchFeeRate returns kaspad's current fee rate estimate, in sompi per
+// gram, for the given priority
+func FetchFeeRate(client *rpcclient.RPCClient, priority FeePriority) (float64, error) {
+	feeEstimate, err := client.GetFeeEstimate()
+	if err != nil {
+		return 0, errors.Wrap(err, "error fetching the fee estimate")
+	}
+	switch priority {
+	case LowFeePriority:
+		return feeEstimate.LowFeeRate, nil
+	case NormalFeePriority, "":
+		return feeEstimate.NormalFeeRate, nil
+	case PriorityFeePriority:
+		return feeEstimate.PriorityFeeRate, nil
+	default:
+		return 0, errors.Errorf("unknown fee priority %q", priority)
+	}
+}
+
+// defaultMinimumRelayFee is kaspad's default minimum relay fee, in sompi
+// per 1000 bytes, which determines which outputs it rejects as dust
+const defaultMinimumRelayFee = 1000
+
+// TransactionMass returns the mass of the given signed transaction, which
+// spends the given UTXOs, the same way kaspad calculates it
+func TransactionMass(params *dagconfig.Params, transaction *externalapi.DomainTransaction,
+	utxos []*UTXO) (uint64, error) {
+
+	scriptPublicKeys := make([]*externalapi.ScriptPublicKey, len(utxos))
+	for i, utxo := range utxos {
+		var err error
+		scriptPublicKeys[i], err = txscript.PayToAddrScript(utxo.Address.Address)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return transactionMass(params, transaction, scriptPublicKeys), nil
+}
+
+// transactionMass returns the mass of the given signed transaction, whose
+// inputs spend outputs of the given script public keys
+func transactionMass(params *dagconfig.Params, transaction *externalapi.DomainTransaction,
+	scriptPublicKeys []*externalapi.ScriptPublicKey) uint64 {
+
+	size := estimatedsize.TransactionEstimatedSerializedSize(transaction)
+
+	totalScriptPubKeySize := uint64(0)
+	for _, output := range transaction.Outputs {
+		totalScriptPubKeySize += 2 // output.ScriptPublicKey.Version (uint16)
+		totalScriptPubKeySize += uint64(len(output.ScriptPublicKey.Script))
+	}
+
+	sigOpsCount := uint64(0)
+	for i, input := range transaction.Inputs {
+		sigOpsCount += uint64(txscript.GetPreciseSigOpCount(input.SignatureScript, scriptPublicKeys[i], false))
+	}
+
+	return size*params.MassPerTxByte + totalScriptPubKeySize*params.MassPerScriptPubKeyByte +
+		sigOpsCount*params.MassPerSigOp
+}
+
+// RequiredFee returns the fee, in sompi, that a transaction of the given
+// mass pays at the given fee rate
+func RequiredFee(mass uint64, feeRate float64) uint64 {
+	return uint64(math.Ceil(float64(mass) * feeRate))
+}
+
+// CreateSignedTransactionWithFee creates and signs a transaction that sends
+// amount to toAddress and the change to changeAddress, and pays a fee of
+// feeRate sompi per gram. UTXOs are selected in the given order. Change that
+// kaspad would reject as dust is added to the fee instead. See
+// CreateUnsignedTransaction for isReplaceable. It returns the transaction,
+// the UTXOs it spends and the fee it pays.
+func CreateSignedTransactionWithFee(params *dagconfig.Params, accountPrivateKey *bip32.ExtendedKey,
+	utxos []*UTXO, toAddress util.Address, amount uint64, changeAddress util.Address, feeRate float64,
+	isReplaceable bool) (transaction *externalapi.DomainTransaction, selectedUTXOs []*UTXO, fee uint64, err error) {
+
+	transaction, selectedUTXOs, _, fee, err = createTransactionWithFee(utxos, toAddress, amount, changeAddress,
+		feeRate, func(selectedUTXOs []*UTXO, payments []*Payment) (*externalapi.DomainTransaction, uint64, error) {
+			transaction, err := CreateSignedTransaction(accountPrivateKey, selectedUTXOs, payments, isReplaceable)
+			if err != nil {
+				return nil, 0, err
+			}
+			mass, err := TransactionMass(params, transaction, selectedUTXOs)
+			if err != nil {
+				return nil, 0, err
+			}
+			return transaction, mass, nil
+		})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return transaction, selectedUTXOs, fee, nil
+}
+
+// CreateMultisigTransactionWithFee creates an unsigned transaction that
+// spends UTXOs of the multisig address of the given redeem script, sends
+// amount to toAddress and the change back to the multisig address, and pays
+// a fee of feeRate sompi per gram. The mass of a multisig transaction isn't
+// known until it's signed, so the fee is paid for the mass it has once it's
+// signed by the minimum number of cosigners.
+func CreateMultisigTransactionWithFee(params *dagconfig.Params, redeemScript []byte, utxos []*UTXO,
+	toAddress util.Address, amount uint64, feeRate float64) (pskt *PartiallySignedTransaction, fee uint64, err error) {
+
+	_, minimumSignatures, err := multisigRedeemScriptDetails(redeemScript)
+	if err != nil {
+		return nil, 0, err
+	}
+	multisigAddress, err := MultisigAddress(redeemScript, params.Prefix)
+	if err != nil {
+		return nil, 0, err
+	}
+	scriptPublicKey, err := txscript.PayToAddrScript(multisigAddress)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Signatures are 64 bytes long, followed by their sighash type
+	signatureScriptBuilder := txscript.NewScriptBuilder()
+	for i := 0; i < minimumSignatures; i++ {
+		signatureScriptBuilder.AddData(make([]byte, 65))
+	}
+	signatureScriptBuilder.AddData(redeemScript)
+	signatureScript, err := signatureScriptBuilder.Script()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	_, selectedUTXOs, payments, fee, err := createTransactionWithFee(utxos, toAddress, amount, multisigAddress,
+		feeRate, func(selectedUTXOs []*UTXO, payments []*Payment) (*externalapi.DomainTransaction, uint64, error) {
+			transaction, err := CreateUnsignedTransaction(selectedUTXOs, payments, false)
+			if err != nil {
+				return nil, 0, err
+			}
+			scriptPublicKeys := make([]*externalapi.ScriptPublicKey, len(transaction.Inputs))
+			for i, input := range transaction.Inputs {
+				input.SignatureScript = signatureScript
+				scriptPublicKeys[i] = scriptPublicKey
+			}
+			return transaction, transactionMass(params, transaction, scriptPublicKeys), nil
+		})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pskt, err = CreatePartiallySignedTransaction(redeemScript, selectedUTXOs, payments)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pskt, fee, nil
+}
+
+// createTransactionWithFee creates a transaction with createTransaction,
+// which also returns the mass the transaction has once it's signed. The
+// transaction sends amount to toAddress and the change to changeAddress,
+// pays a fee of feeRate sompi per gram, and spends UTXOs that are selected
+// in the given order. Change that kaspad would reject as dust is added to
+// the fee instead.
+func createTransactionWithFee(utxos []*UTXO, toAddress util.Address, amount uint64, changeAddress util.Address,
+	feeRate float64, createTransaction func(selectedUTXOs []*UTXO, payments []*Payment) (
+		*externalapi.DomainTransaction, uint64, error)) (transaction *externalapi.DomainTransaction,
+	selectedUTXOs []*UTXO, payments []*Payment, fee uint64, err error) {
+
+	// The fee depends on the mass of the transaction, which depends on
+	// the number of the selected UTXOs, which depends on the fee. Since
+	// the fee only grows between iterations, and with it the selected
+	// UTXOs, this converges once the selected UTXOs cover the fee of
+	// the transaction that spends them.
+	for {
+		var change uint64
+		selectedUTXOs, change, err = SelectUTXOs(utxos, amount+fee)
+		if err != nil {
+			return nil, nil, nil, 0, err
+		}
+		payments = []*Payment{{Address: toAddress, Amount: amount}}
+		changePayment := &Payment{Address: changeAddress, Amount: change}
+		isChangeDust, err := isDustPayment(changePayment)
+		if err != nil {
+			return nil, nil, nil, 0, err
+		}
+		if !isChangeDust {
+			payments = append(payments, changePayment)
+		}
+
+		var mass uint64
+		transaction, mass, err = createTransaction(selectedUTXOs, payments)
+		if err != nil {
+			return nil, nil, nil, 0, err
+		}
+		requiredFee := RequiredFee(mass, feeRate)
+		if requiredFee <= fee {
+			if isChangeDust {
+				fee += change
+			}
+			return transaction, selectedUTXOs, payments, fee, nil
+		}
+		fee = requiredFee
+	}
+}
+
+// isDustPayment returns whether kaspad, with its default policy, rejects
+// an output that makes the given payment as dust. This follows isDust in
+// kaspad's mempool, which considers an output dust if spending it costs more
+// than a third of its amount in minimum relay fees.
+func isDustPayment(payment *Payment) (bool, error) {
+	scriptPublicKey, err := txscript.PayToAddrScript(payment.Address)
+	if err != nil {
+		return false, err
+	}
+	output := &externalapi.DomainTransactionOutput{Value: payment.Amount, ScriptPublicKey: scriptPublicKey}
+
+	// 148 bytes is the size of the typical input that spends the output
+	totalSize := estimatedsize.TransactionOutputEstimatedSerializedSize(output) + 148
+	return payment.Amount*1000/(3*totalSize) < defaultMinimumRelayFee, nil
+}
@@ -0,0 +1,364 @@
+package libwallet
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet/bip32"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/transactionid"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
+	"github.com/kaspanet/kaspad/domain/dagconfig"
+	"github.com/kaspanet/kaspad/util"
+)
+
+// walletForTest returns the account key of a new wallet, its first external
+// address, and utxoCount UTXOs of the given amount that belong to that address
+func walletForTest(t *testing.T, utxoCount int, utxoAmount uint64) (
+	accountPrivateKey *bip32.ExtendedKey, address *WalletAddress, utxos []*UTXO) {
+
+	mnemonic, err := CreateMnemonic()
+	if err != nil {
+		t.Fatalf("CreateMnemonic: %s", err)
+	}
+	accountPrivateKey, err = AccountKeyFromMnemonic(mnemonic)
+	if err != nil {
+		t.Fatalf("AccountKeyFromMnemonic: %s", err)
+	}
+	derivedAddress, err := DeriveAddress(accountPrivateKey, ExternalKeychain, 0, util.Bech32PrefixKaspaSim)
+	if err != nil {
+		t.Fatalf("DeriveAddress: %s", err)
+	}
+	address = &WalletAddress{Address: derivedAddress, Keychain: ExternalKeychain, Index: 0}
+
+	utxos = make([]*UTXO, utxoCount)
+	for i := range utxos {
+		transactionID, err := transactionid.FromString(fmt.Sprintf("%064x", i+1))
+		if err != nil {
+			t.Fatalf("FromString: %s", err)
+		}
+		utxos[i] = &UTXO{
+			Outpoint: externalapi.DomainOutpoint{TransactionID: *transactionID},
+			Amount:   utxoAmount,
+			Address:  address,
+		}
+	}
+	return accountPrivateKey, address, utxos
+}
+
+func TestCreateSignedTransactionWithFee(t *testing.T) {
+	// Spending a UTXO costs most of its amount in fees, so every
+	// additional input requires selecting yet another UTXO
+	const utxoAmount = 20000
+	accountPrivateKey, walletAddress, utxos := walletForTest(t, 10, utxoAmount)
+	address := walletAddress.Address
+
+	params := &dagconfig.SimnetParams
+	const feeRate = 1.5
+	transaction, selectedUTXOs, fee, err := CreateSignedTransactionWithFee(params, accountPrivateKey,
+		utxos, address, utxoAmount, address, feeRate, false)
+	if err != nil {
+		t.Fatalf("CreateSignedTransactionWithFee: %s", err)
+	}
+
+	mass, err := TransactionMass(params, transaction, selectedUTXOs)
+	if err != nil {
+		t.Fatalf("TransactionMass: %s", err)
+	}
+	if mass < uint64(len(selectedUTXOs))*params.MassPerSigOp {
+		t.Fatalf("expected the mass to account for the signature operations, got %d", mass)
+	}
+	if fee < RequiredFee(mass, feeRate) {
+		t.Fatalf("expected a fee of at least %d, got %d", RequiredFee(mass, feeRate), fee)
+	}
+
+	totalInputs := uint64(0)
+	for _, utxo := range selectedUTXOs {
+		totalInputs += utxo.Amount
+	}
+	totalOutputs := uint64(0)
+	for _, output := range transaction.Outputs {
+		totalOutputs += output.Value
+	}
+	if totalInputs-totalOutputs != fee {
+		t.Fatalf("expected the transaction to pay a fee of %d, but it pays %d", fee, totalInputs-totalOutputs)
+	}
+
+	_, _, _, err = CreateSignedTransactionWithFee(params, accountPrivateKey,
+		utxos, address, uint64(len(utxos))*utxoAmount, address, feeRate, false)
+	if err == nil {
+		t.Fatalf("expected spending all the funds without leaving room for the fee to fail")
+	}
+}
+
+func TestCreateSignedTransactionWithFeeAddsDustChangeToFee(t *testing.T) {
+	const utxoAmount = 100000
+	accountPrivateKey, walletAddress, utxos := walletForTest(t, 1, utxoAmount)
+	address := walletAddress.Address
+
+	params := &dagconfig.SimnetParams
+	const feeRate = 1
+	_, _, feeWithChange, err := CreateSignedTransactionWithFee(params, accountPrivateKey,
+		utxos, address, utxoAmount/2, address, feeRate, false)
+	if err != nil {
+		t.Fatalf("CreateSignedTransactionWithFee: %s", err)
+	}
+
+	// Sending all but a few sompi of what the fee leaves would leave change
+	// that isn't worth spending
+	amount := utxoAmount - feeWithChange - 10
+	transaction, _, fee, err := CreateSignedTransactionWithFee(params, accountPrivateKey,
+		utxos, address, amount, address, feeRate, false)
+	if err != nil {
+		t.Fatalf("CreateSignedTransactionWithFee: %s", err)
+	}
+	if len(transaction.Outputs) != 1 {
+		t.Fatalf("expected the dust change output to be left out, got %d outputs", len(transaction.Outputs))
+	}
+	if fee != utxoAmount-amount {
+		t.Fatalf("expected the dust change to be added to the fee, got a fee of %d instead of %d",
+			fee, utxoAmount-amount)
+	}
+}
+
+func TestCreateMultisigTransactionWithFee(t *testing.T) {
+	publicKeys, privateKeys := multisigKeysForTest(t, 3)
+	redeemScript, err := CreateMultisigRedeemScript(publicKeys, 2)
+	if err != nil {
+		t.Fatalf("CreateMultisigRedeemScript: %s", err)
+	}
+	params := &dagconfig.SimnetParams
+	address, err := MultisigAddress(redeemScript, params.Prefix)
+	if err != nil {
+		t.Fatalf("MultisigAddress: %s", err)
+	}
+	utxos := make([]*UTXO, 2)
+	for i := range utxos {
+		transactionID, err := transactionid.FromString(fmt.Sprintf("%064x", i+1))
+		if err != nil {
+			t.Fatalf("FromString: %s", err)
+		}
+		utxos[i] = &UTXO{Outpoint: externalapi.DomainOutpoint{TransactionID: *transactionID}, Amount: 1000000}
+	}
+
+	const feeRate = 2
+	pskt, fee, err := CreateMultisigTransactionWithFee(params, redeemScript, utxos, address, 1500000, feeRate)
+	if err != nil {
+		t.Fatalf("CreateMultisigTransactionWithFee: %s", err)
+	}
+	psktFee, err := pskt.Fee()
+	if err != nil {
+		t.Fatalf("Fee: %s", err)
+	}
+	if psktFee != fee {
+		t.Fatalf("expected the transaction to pay a fee of %d, but it pays %d", fee, psktFee)
+	}
+
+	// The fee pays for the mass of the transaction once the minimum number
+	// of cosigners sign it
+	for _, privateKey := range privateKeys[:2] {
+		_, err = pskt.Sign(privateKey)
+		if err != nil {
+			t.Fatalf("Sign: %s", err)
+		}
+	}
+	transaction, err := pskt.FinalizedTransaction()
+	if err != nil {
+		t.Fatalf("FinalizedTransaction: %s", err)
+	}
+	scriptPublicKey, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: %s", err)
+	}
+	scriptPublicKeys := []*externalapi.ScriptPublicKey{scriptPublicKey, scriptPublicKey}
+	mass := transactionMass(params, transaction, scriptPublicKeys)
+	if fee != RequiredFee(mass, feeRate) {
+		t.Fatalf("expected a fee of %d for a signed transaction of mass %d, got %d",
+			RequiredFee(mass, feeRate), mass, fee)
+	}
+}
+
+func TestRequiredFee(t *testing.T) {
+	tests := []struct {
+		mass        uint64
+		feeRate     float64
+		expectedFee uint64
+	}{
+		{mass: 1000, feeRate: 1, expectedFee: 1000},
+		{mass: 1000, feeRate: 0.0005, expectedFee: 1},
+		{mass: 3, feeRate: 1.5, expectedFee: 5},
+		{mass: 0, feeRate: 10, expectedFee: 0},
+	}
+	for _, test := range tests {
+		fee := RequiredFee(test.mass, test.feeRate)
+		if fee != test.expectedFee {
+			t.Errorf("RequiredFee(%d, %f): expected %d, got %d", test.mass, test.feeRate, test.expectedFee, fee)
+		}
+	}
+}
+
+// verboseDataForTest returns the verbose data kaspad returns for the given
+// transaction
+func verboseDataForTest(t *testing.T, transaction *externalapi.DomainTransaction,
+	params *dagconfig.Params) *appmessage.TransactionVerboseData {
+
+	verboseData := &appmessage.TransactionVerboseData{
+		TxID: consensushashing.TransactionID(transaction).String(),
+	}
+	for _, input := range transaction.Inputs {
+		verboseData.TransactionVerboseInputs = append(verboseData.TransactionVerboseInputs,
+			&appmessage.TransactionVerboseInput{
+				TxID:        input.PreviousOutpoint.TransactionID.String(),
+				OutputIndex: input.PreviousOutpoint.Index,
+				Sequence:    input.Sequence,
+			})
+	}
+	for i, output := range transaction.Outputs {
+		_, address, err := txscript.ExtractScriptPubKeyAddress(output.ScriptPublicKey, params)
+		if err != nil {
+			t.Fatalf("ExtractScriptPubKeyAddress: %s", err)
+		}
+		verboseData.TransactionVerboseOutputs = append(verboseData.TransactionVerboseOutputs,
+			&appmessage.TransactionVerboseOutput{
+				Value:        output.Value,
+				Index:        uint32(i),
+				ScriptPubKey: &appmessage.ScriptPubKeyResult{Address: address.String()},
+			})
+	}
+	return verboseData
+}
+
+func TestCreateFeeBumpTransaction(t *testing.T) {
+	params := &dagconfig.SimnetParams
+	accountPrivateKey, walletAddress, utxos := walletForTest(t, 2, 1000000)
+	changeAddress, err := DeriveAddress(accountPrivateKey, InternalKeychain, 0, util.Bech32PrefixKaspaSim)
+	if err != nil {
+		t.Fatalf("DeriveAddress: %s", err)
+	}
+	addresses := []*WalletAddress{walletAddress, {Address: changeAddress, Keychain: InternalKeychain}}
+
+	const sendAmount = 1500000
+	original, spentUTXOs, originalFee, err := CreateSignedTransactionWithFee(params, accountPrivateKey,
+		utxos, walletAddress.Address, sendAmount, changeAddress, 1, true)
+	if err != nil {
+		t.Fatalf("CreateSignedTransactionWithFee: %s", err)
+	}
+	originalMass, err := TransactionMass(params, original, spentUTXOs)
+	if err != nil {
+		t.Fatalf("TransactionMass: %s", err)
+	}
+	verboseData := verboseDataForTest(t, original, params)
+
+	// Bumping to a fee rate lower than the original one still out-bids it
+	bumped, fee, err := CreateFeeBumpTransaction(params, accountPrivateKey, verboseData, originalFee,
+		utxos, addresses, 0.5)
+	if err != nil {
+		t.Fatalf("CreateFeeBumpTransaction: %s", err)
+	}
+	if fee != originalFee+originalMass {
+		t.Fatalf("expected the fee to be out-bid by a fee rate of 1, got a fee of %d instead of %d",
+			fee, originalFee)
+	}
+	if len(bumped.Inputs) != len(original.Inputs) {
+		t.Fatalf("expected the replacement to spend the same UTXOs")
+	}
+	for i, input := range bumped.Inputs {
+		if input.PreviousOutpoint != original.Inputs[i].PreviousOutpoint {
+			t.Fatalf("expected input %d of the replacement to spend the same UTXO", i)
+		}
+		if input.Sequence != replaceableSequence {
+			t.Fatalf("expected the replacement to be replaceable as well")
+		}
+	}
+	if bumped.Outputs[0].Value != sendAmount {
+		t.Fatalf("expected the payment to be unchanged, got %d", bumped.Outputs[0].Value)
+	}
+	if original.Outputs[1].Value-bumped.Outputs[1].Value != fee-originalFee {
+		t.Fatalf("expected the higher fee to be paid out of the change")
+	}
+
+	// A higher fee rate is paid in full
+	_, fee, err = CreateFeeBumpTransaction(params, accountPrivateKey, verboseData, originalFee,
+		utxos, addresses, 10)
+	if err != nil {
+		t.Fatalf("CreateFeeBumpTransaction: %s", err)
+	}
+	if fee != RequiredFee(originalMass, 10) {
+		t.Fatalf("expected a fee of %d, got %d", RequiredFee(originalMass, 10), fee)
+	}
+
+	// Change that would be dust is added to the fee
+	originalChange := original.Outputs[1].Value
+	replacedFee := originalFee + originalChange - originalMass - 10
+	bumped, fee, err = CreateFeeBumpTransaction(params, accountPrivateKey, verboseData, replacedFee,
+		utxos, addresses, 0.5)
+	if err != nil {
+		t.Fatalf("CreateFeeBumpTransaction: %s", err)
+	}
+	if len(bumped.Outputs) != 1 {
+		t.Fatalf("expected the dust change output to be dropped, got %d outputs", len(bumped.Outputs))
+	}
+	if fee != originalFee+originalChange {
+		t.Fatalf("expected the dust change to be added to the fee, got a fee of %d instead of %d",
+			fee, originalFee+originalChange)
+	}
+
+	// The fee can only be paid out of the change
+	_, _, err = CreateFeeBumpTransaction(params, accountPrivateKey, verboseData, originalFee,
+		utxos, addresses, 1000)
+	if err == nil {
+		t.Fatalf("expected a fee higher than the change to be rejected")
+	}
+	_, _, err = CreateFeeBumpTransaction(params, accountPrivateKey, verboseData, originalFee,
+		utxos, addresses[:1], 10)
+	if err == nil {
+		t.Fatalf("expected a transaction without a change output to be rejected")
+	}
+
+	// Transactions that weren't sent as replaceable are final
+	final, _, finalFee, err := CreateSignedTransactionWithFee(params, accountPrivateKey,
+		utxos, walletAddress.Address, sendAmount, changeAddress, 1, false)
+	if err != nil {
+		t.Fatalf("CreateSignedTransactionWithFee: %s", err)
+	}
+	_, _, err = CreateFeeBumpTransaction(params, accountPrivateKey, verboseDataForTest(t, final, params), finalFee,
+		utxos, addresses, 10)
+	if err == nil || !strings.Contains(err.Error(), "is final") {
+		t.Fatalf("expected a final transaction to be rejected, got %v", err)
+	}
+}
+
+func TestReplacedFee(t *testing.T) {
+	entry := func(txID string, fee uint64, parentIDs ...string) *appmessage.MempoolEntry {
+		verboseData := &appmessage.TransactionVerboseData{TxID: txID}
+		for _, parentID := range parentIDs {
+			verboseData.TransactionVerboseInputs = append(verboseData.TransactionVerboseInputs,
+				&appmessage.TransactionVerboseInput{TxID: parentID})
+		}
+		return &appmessage.MempoolEntry{Fee: fee, TransactionVerboseData: verboseData}
+	}
+	entries := []*appmessage.MempoolEntry{
+		entry("grandchild", 1000, "child", "otherChild"),
+		entry("original", 100, "confirmed"),
+		entry("child", 200, "original", "original"),
+		entry("otherChild", 300, "original"),
+		entry("unrelated", 10000, "confirmed"),
+	}
+
+	fee, err := ReplacedFee(entries, "original")
+	if err != nil {
+		t.Fatalf("ReplacedFee: %s", err)
+	}
+	if fee != 1600 {
+		t.Fatalf("expected the fees of the original transaction and its descendants to add up to 1600, "+
+			"got %d", fee)
+	}
+
+	_, err = ReplacedFee(entries, "missing")
+	if err == nil {
+		t.Fatalf("expected a transaction that is not in the mempool to be rejected")
+	}
+}
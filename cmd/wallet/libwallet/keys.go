@@ -0,0 +1,106 @@
+package libwallet
+
+import (
+	"fmt"
+
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet/bip32"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// AccountDerivationPath is the BIP44 derivation path of the wallet account
+// key. 111111 is the coin type registered for Kaspa in SLIP-0044.
+const AccountDerivationPath = "m/44'/111111'/0'"
+
+// mnemonicEntropySize is the entropy size, in bits, of newly created mnemonics.
+// 256 bits of entropy result in a 24-word mnemonic.
+const mnemonicEntropySize = 256
+
+// Keychain is the BIP44 "change" level of the derivation path, separating
+// addresses that are given out to receive funds from addresses that are
+// used to receive change
+type Keychain uint32
+
+const (
+	// ExternalKeychain is the keychain of receive addresses
+	ExternalKeychain Keychain = 0
+
+	// InternalKeychain is the keychain of change addresses
+	InternalKeychain Keychain = 1
+
+	// MultisigKeychain is the keychain of the key the wallet uses as
+	// a cosigner of multisig addresses
+	MultisigKeychain Keychain = 2
+)
+
+func (keychain Keychain) String() string {
+	switch keychain {
+	case ExternalKeychain:
+		return "receive"
+	case InternalKeychain:
+		return "change"
+	case MultisigKeychain:
+		return "multisig"
+	default:
+		return "unknown"
+	}
+}
+
+// CreateMnemonic creates a new random BIP39 mnemonic
+func CreateMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(mnemonicEntropySize)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// AccountKeyFromMnemonic derives the account extended private key of the
+// given BIP39 mnemonic
+func AccountKeyFromMnemonic(mnemonic string) (*bip32.ExtendedKey, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid mnemonic")
+	}
+	masterKey, err := bip32.NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	path, err := bip32.ParsePath(AccountDerivationPath)
+	if err != nil {
+		return nil, err
+	}
+	return masterKey.DerivePath(path)
+}
+
+// DerivationPath returns the full derivation path of the address
+// at the given keychain and index
+func DerivationPath(keychain Keychain, index uint32) string {
+	return fmt.Sprintf("%s/%d/%d", AccountDerivationPath, keychain, index)
+}
+
+// DeriveAddress derives the address at the given keychain and index from
+// the given account key, which may be either private or public
+func DeriveAddress(accountKey *bip32.ExtendedKey, keychain Keychain, index uint32,
+	prefix util.Bech32Prefix) (*util.AddressPubKeyHash, error) {
+
+	addressKey, err := accountKey.DerivePath([]uint32{uint32(keychain), index})
+	if err != nil {
+		return nil, err
+	}
+	return addressKey.Address(prefix)
+}
+
+// DerivePrivateKey derives the private key of the address at the given
+// keychain and index from the given account private key
+func DerivePrivateKey(accountPrivateKey *bip32.ExtendedKey, keychain Keychain,
+	index uint32) (*secp256k1.SchnorrKeyPair, error) {
+
+	addressKey, err := accountPrivateKey.DerivePath([]uint32{uint32(keychain), index})
+	if err != nil {
+		return nil, err
+	}
+	return addressKey.PrivateKey()
+}
@@ -0,0 +1,293 @@
+package libwallet
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet/bip32"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// keystoreVersion is the version of the keystore file format
+const keystoreVersion = 1
+
+// Argon2id parameters used to derive the encryption key from the passphrase
+const (
+	argon2Time    = 1
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	saltLength    = 16
+)
+
+// ErrWrongPassphrase is returned when a keystore can't be decrypted with
+// the given passphrase
+var ErrWrongPassphrase = errors.New("wrong passphrase")
+
+// Keystore is an on-disk wallet. The mnemonic is kept encrypted with
+// a key derived from the user's passphrase, while the account extended
+// public key is kept in the clear so that addresses can be derived and
+// balances checked without the passphrase.
+type Keystore struct {
+	Version              int                `json:"version"`
+	EncryptedMnemonic    *encryptedMnemonic `json:"encryptedMnemonic"`
+	AccountPublicKey     string             `json:"accountPublicKey"`
+	ExternalAddressCount uint32             `json:"externalAddressCount"`
+	InternalAddressCount uint32             `json:"internalAddressCount"`
+
+	path string
+}
+
+type encryptedMnemonic struct {
+	Cipher string `json:"cipher"`
+	Salt   string `json:"salt"`
+}
+
+// WalletAddress is an address that was derived by the wallet
+type WalletAddress struct {
+	Keychain Keychain
+	Index    uint32
+	Address  *util.AddressPubKeyHash
+}
+
+// CreateKeystore creates a new keystore at the given path for the given
+// mnemonic, encrypted with the given passphrase. The keystore starts with
+// a single receive address.
+func CreateKeystore(path string, mnemonic string, passphrase []byte) (*Keystore, error) {
+	exists, err := fileExists(path)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.Errorf("a wallet already exists at %s", path)
+	}
+
+	accountKey, err := AccountKeyFromMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	accountPublicKey, err := accountKey.Public()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := encryptMnemonic(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	keystore := &Keystore{
+		Version:              keystoreVersion,
+		EncryptedMnemonic:    encrypted,
+		AccountPublicKey:     hex.EncodeToString(accountPublicKey.Serialize()),
+		ExternalAddressCount: 1,
+		InternalAddressCount: 0,
+		path:                 path,
+	}
+	err = keystore.Save()
+	if err != nil {
+		return nil, err
+	}
+	return keystore, nil
+}
+
+// LoadKeystore loads the keystore at the given path
+func LoadKeystore(path string) (*Keystore, error) {
+	serialized, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("no wallet was found at %s. Use the create command to create one", path)
+		}
+		return nil, err
+	}
+	keystore := &Keystore{}
+	err = json.Unmarshal(serialized, keystore)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing the wallet at %s", path)
+	}
+	if keystore.Version != keystoreVersion {
+		return nil, errors.Errorf("unsupported wallet version %d in %s", keystore.Version, path)
+	}
+	keystore.path = path
+	return keystore, nil
+}
+
+// Save writes the keystore to its path. The file is replaced atomically
+// so that a failure never leaves a partially written wallet behind.
+func (ks *Keystore) Save() error {
+	serialized, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(ks.path), 0700)
+	if err != nil {
+		return err
+	}
+	temporaryPath := ks.path + ".tmp"
+	err = ioutil.WriteFile(temporaryPath, serialized, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, ks.path)
+}
+
+// Path returns the path of the keystore file
+func (ks *Keystore) Path() string {
+	return ks.path
+}
+
+// DecryptMnemonic decrypts the mnemonic of the keystore with the given passphrase
+func (ks *Keystore) DecryptMnemonic(passphrase []byte) (string, error) {
+	return decryptMnemonic(ks.EncryptedMnemonic, passphrase)
+}
+
+// AccountPrivateKey decrypts the keystore and returns its account extended private key
+func (ks *Keystore) AccountPrivateKey(passphrase []byte) (*bip32.ExtendedKey, error) {
+	mnemonic, err := ks.DecryptMnemonic(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return AccountKeyFromMnemonic(mnemonic)
+}
+
+// AccountPublicKeyExtended returns the account extended public key of the keystore
+func (ks *Keystore) AccountPublicKeyExtended() (*bip32.ExtendedKey, error) {
+	serialized, err := hex.DecodeString(ks.AccountPublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed account public key")
+	}
+	return bip32.DeserializeExtendedKey(serialized)
+}
+
+// NewAddress derives the next unused address in the given keychain.
+// The caller is responsible to Save the keystore afterwards.
+func (ks *Keystore) NewAddress(keychain Keychain, prefix util.Bech32Prefix) (*WalletAddress, error) {
+	accountPublicKey, err := ks.AccountPublicKeyExtended()
+	if err != nil {
+		return nil, err
+	}
+
+	count := &ks.ExternalAddressCount
+	if keychain == InternalKeychain {
+		count = &ks.InternalAddressCount
+	}
+	for {
+		index := *count
+		*count++
+		address, err := DeriveAddress(accountPublicKey, keychain, index, prefix)
+		if errors.Is(err, bip32.ErrInvalidChild) {
+			// BIP32 requires skipping indexes that derive invalid keys
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &WalletAddress{Keychain: keychain, Index: index, Address: address}, nil
+	}
+}
+
+// Addresses returns all the addresses that were derived by the wallet,
+// receive addresses first
+func (ks *Keystore) Addresses(prefix util.Bech32Prefix) ([]*WalletAddress, error) {
+	accountPublicKey, err := ks.AccountPublicKeyExtended()
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]*WalletAddress, 0, ks.ExternalAddressCount+ks.InternalAddressCount)
+	keychains := []struct {
+		keychain Keychain
+		count    uint32
+	}{
+		{keychain: ExternalKeychain, count: ks.ExternalAddressCount},
+		{keychain: InternalKeychain, count: ks.InternalAddressCount},
+	}
+	for _, keychain := range keychains {
+		for index := uint32(0); index < keychain.count; index++ {
+			address, err := DeriveAddress(accountPublicKey, keychain.keychain, index, prefix)
+			if errors.Is(err, bip32.ErrInvalidChild) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			addresses = append(addresses, &WalletAddress{Keychain: keychain.keychain, Index: index, Address: address})
+		}
+	}
+	return addresses, nil
+}
+
+func encryptMnemonic(mnemonic string, passphrase []byte) (*encryptedMnemonic, error) {
+	salt := make([]byte, saltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(mnemonic)+aead.Overhead())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	// The nonce is prepended to the cipher text
+	cipherText := aead.Seal(nonce, nonce, []byte(mnemonic), nil)
+
+	return &encryptedMnemonic{
+		Cipher: hex.EncodeToString(cipherText),
+		Salt:   hex.EncodeToString(salt),
+	}, nil
+}
+
+func decryptMnemonic(encrypted *encryptedMnemonic, passphrase []byte) (string, error) {
+	if encrypted == nil {
+		return "", errors.New("the wallet has no encrypted mnemonic")
+	}
+	salt, err := hex.DecodeString(encrypted.Salt)
+	if err != nil {
+		return "", errors.Wrap(err, "malformed salt")
+	}
+	cipherText, err := hex.DecodeString(encrypted.Cipher)
+	if err != nil {
+		return "", errors.Wrap(err, "malformed cipher")
+	}
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	if len(cipherText) < aead.NonceSize() {
+		return "", errors.New("malformed cipher")
+	}
+
+	nonce, encryptedMnemonicBytes := cipherText[:aead.NonceSize()], cipherText[aead.NonceSize():]
+	mnemonic, err := aead.Open(nil, nonce, encryptedMnemonicBytes, nil)
+	if err != nil {
+		return "", ErrWrongPassphrase
+	}
+	return string(mnemonic), nil
+}
+
+func newAEAD(passphrase []byte, salt []byte) (cipher.AEAD, error) {
+	key := argon2.IDKey(passphrase, salt, argon2Time, argon2Memory, argon2Threads, chacha20poly1305.KeySize)
+	return chacha20poly1305.NewX(key)
+}
+
+func fileExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
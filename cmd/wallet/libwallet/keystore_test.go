@@ -0,0 +1,113 @@
+package libwallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+func TestKeystore(t *testing.T) {
+	directory, err := ioutil.TempDir("", "TestKeystore")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "keys.json")
+
+	mnemonic, err := CreateMnemonic()
+	if err != nil {
+		t.Fatalf("CreateMnemonic: %s", err)
+	}
+	passphrase := []byte("correct horse battery staple")
+	keystore, err := CreateKeystore(path, mnemonic, passphrase)
+	if err != nil {
+		t.Fatalf("CreateKeystore: %s", err)
+	}
+
+	_, err = CreateKeystore(path, mnemonic, passphrase)
+	if err == nil {
+		t.Fatalf("expected CreateKeystore to refuse overwriting an existing wallet")
+	}
+
+	newAddress, err := keystore.NewAddress(ExternalKeychain, util.Bech32PrefixKaspaTest)
+	if err != nil {
+		t.Fatalf("NewAddress: %s", err)
+	}
+	if newAddress.Index != 1 {
+		t.Fatalf("expected the first new receive address to have index 1, got %d", newAddress.Index)
+	}
+	_, err = keystore.NewAddress(InternalKeychain, util.Bech32PrefixKaspaTest)
+	if err != nil {
+		t.Fatalf("NewAddress: %s", err)
+	}
+	err = keystore.Save()
+	if err != nil {
+		t.Fatalf("Save: %s", err)
+	}
+
+	loadedKeystore, err := LoadKeystore(path)
+	if err != nil {
+		t.Fatalf("LoadKeystore: %s", err)
+	}
+	addresses, err := loadedKeystore.Addresses(util.Bech32PrefixKaspaTest)
+	if err != nil {
+		t.Fatalf("Addresses: %s", err)
+	}
+	if len(addresses) != 3 {
+		t.Fatalf("expected 3 addresses, got %d", len(addresses))
+	}
+	if addresses[1].Address.String() != newAddress.Address.String() {
+		t.Fatalf("expected the second address to be %s, got %s", newAddress.Address, addresses[1].Address)
+	}
+
+	_, err = loadedKeystore.DecryptMnemonic([]byte("wrong passphrase"))
+	if !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("expected ErrWrongPassphrase, got %v", err)
+	}
+	decryptedMnemonic, err := loadedKeystore.DecryptMnemonic(passphrase)
+	if err != nil {
+		t.Fatalf("DecryptMnemonic: %s", err)
+	}
+	if decryptedMnemonic != mnemonic {
+		t.Fatalf("decrypted mnemonic doesn't match the original one")
+	}
+
+	// Every derived address must be spendable with the keys derived from the mnemonic
+	accountPrivateKey, err := loadedKeystore.AccountPrivateKey(passphrase)
+	if err != nil {
+		t.Fatalf("AccountPrivateKey: %s", err)
+	}
+	for _, address := range addresses {
+		keyPair, err := DerivePrivateKey(accountPrivateKey, address.Keychain, address.Index)
+		if err != nil {
+			t.Fatalf("DerivePrivateKey: %s", err)
+		}
+		publicKey, err := keyPair.SchnorrPublicKey()
+		if err != nil {
+			t.Fatalf("SchnorrPublicKey: %s", err)
+		}
+		serializedPublicKey, err := publicKey.Serialize()
+		if err != nil {
+			t.Fatalf("Serialize: %s", err)
+		}
+		expectedAddress, err := util.NewAddressPubKeyHashFromPublicKey(serializedPublicKey[:], util.Bech32PrefixKaspaTest)
+		if err != nil {
+			t.Fatalf("NewAddressPubKeyHashFromPublicKey: %s", err)
+		}
+		if expectedAddress.String() != address.Address.String() {
+			t.Fatalf("the private key at %s doesn't match its address",
+				DerivationPath(address.Keychain, address.Index))
+		}
+	}
+}
+
+func TestAccountKeyFromMnemonicRejectsInvalidMnemonics(t *testing.T) {
+	_, err := AccountKeyFromMnemonic("abandon abandon abandon")
+	if err == nil {
+		t.Fatalf("expected an invalid mnemonic to be rejected")
+	}
+}
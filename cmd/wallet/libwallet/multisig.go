@@ -0,0 +1,92 @@
+package libwallet
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet/bip32"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+// multisigKeyIndex is the index, in MultisigKeychain, of the key the wallet
+// cosigns multisig addresses with. All of the wallet's multisig addresses
+// share the same key.
+const multisigKeyIndex = 0
+
+// MultisigPublicKey returns the serialized public key that the wallet of the
+// given account key, which may be either private or public, cosigns multisig
+// addresses with
+func MultisigPublicKey(accountKey *bip32.ExtendedKey) ([]byte, error) {
+	multisigKey, err := accountKey.DerivePath([]uint32{uint32(MultisigKeychain), multisigKeyIndex})
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := multisigKey.SchnorrPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	serializedPublicKey, err := publicKey.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return serializedPublicKey[:], nil
+}
+
+// MultisigPrivateKey returns the key that the wallet of the given account
+// private key cosigns multisig addresses with
+func MultisigPrivateKey(accountPrivateKey *bip32.ExtendedKey) (*secp256k1.SchnorrKeyPair, error) {
+	return DerivePrivateKey(accountPrivateKey, MultisigKeychain, multisigKeyIndex)
+}
+
+// CreateMultisigRedeemScript creates the redeem script of an m-of-n multisig
+// address, where m is minimumSignatures and n is the number of the given
+// public keys. The public keys are sorted first, so all the cosigners get the
+// same address no matter in which order they list the keys.
+func CreateMultisigRedeemScript(publicKeys [][]byte, minimumSignatures int) ([]byte, error) {
+	sortedPublicKeys := make([][]byte, len(publicKeys))
+	copy(sortedPublicKeys, publicKeys)
+	sort.Slice(sortedPublicKeys, func(i, j int) bool {
+		return bytes.Compare(sortedPublicKeys[i], sortedPublicKeys[j]) < 0
+	})
+	for i := 1; i < len(sortedPublicKeys); i++ {
+		if bytes.Equal(sortedPublicKeys[i-1], sortedPublicKeys[i]) {
+			return nil, errors.Errorf("public key %x appears more than once", sortedPublicKeys[i])
+		}
+	}
+	for _, publicKey := range sortedPublicKeys {
+		_, err := secp256k1.DeserializeSchnorrPubKey(publicKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid public key %x", publicKey)
+		}
+	}
+
+	return txscript.MultiSigScript(sortedPublicKeys, minimumSignatures)
+}
+
+// MultisigAddress returns the pay-to-script-hash address of the given
+// multisig redeem script
+func MultisigAddress(redeemScript []byte, prefix util.Bech32Prefix) (*util.AddressScriptHash, error) {
+	_, _, err := txscript.CalcMultiSigStats(redeemScript)
+	if err != nil {
+		return nil, err
+	}
+	return util.NewAddressScriptHash(redeemScript, prefix)
+}
+
+// multisigRedeemScriptDetails returns the public keys of the given multisig
+// redeem script, in the order their signatures must appear in the signature
+// script, and the number of signatures required to spend from it
+func multisigRedeemScriptDetails(redeemScript []byte) (publicKeys [][]byte, minimumSignatures int, err error) {
+	_, minimumSignatures, err = txscript.CalcMultiSigStats(redeemScript)
+	if err != nil {
+		return nil, 0, err
+	}
+	publicKeys, err = txscript.PushedData(redeemScript)
+	if err != nil {
+		return nil, 0, err
+	}
+	return publicKeys, minimumSignatures, nil
+}
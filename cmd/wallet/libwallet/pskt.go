@@ -0,0 +1,397 @@
+package libwallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
+	"github.com/pkg/errors"
+)
+
+// partiallySignedTransactionVersion is the version of the partially signed
+// transaction format
+const partiallySignedTransactionVersion = 1
+
+// PartiallySignedTransaction is a transaction that spends from multisig
+// addresses, along with everything the cosigners need in order to sign it
+// offline and the signatures collected so far. It serializes to JSON, so it
+// can be passed between the cosigners as a file.
+type PartiallySignedTransaction struct {
+	Version     int                        `json:"version"`
+	Transaction *appmessage.RPCTransaction `json:"transaction"`
+	Inputs      []*PartiallySignedInput    `json:"inputs"`
+}
+
+// PartiallySignedInput is the signing information of a single input of a
+// PartiallySignedTransaction
+type PartiallySignedInput struct {
+	// Amount is the amount of the spent UTXO, as claimed by the creator of
+	// the transaction. The signatures don't commit to it, so the fee that
+	// is derived from it may be trusted only after VerifyInputAmounts.
+	Amount uint64 `json:"amount"`
+
+	// RedeemScript is the hex-encoded multisig redeem script of the
+	// address the spent UTXO belongs to
+	RedeemScript string `json:"redeemScript"`
+
+	// Signatures maps hex-encoded public keys of the redeem script to the
+	// hex-encoded signatures made by them
+	Signatures map[string]string `json:"signatures"`
+}
+
+// CreatePartiallySignedTransaction creates an unsigned transaction that spends
+// the given UTXOs, all of which belong to the multisig address of the given
+// redeem script, and pays the given payments
+func CreatePartiallySignedTransaction(redeemScript []byte, utxos []*UTXO,
+	payments []*Payment) (*PartiallySignedTransaction, error) {
+
+	_, _, err := multisigRedeemScriptDetails(redeemScript)
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := CreateUnsignedTransaction(utxos, payments, false)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := make([]*PartiallySignedInput, len(utxos))
+	for i, utxo := range utxos {
+		inputs[i] = &PartiallySignedInput{
+			Amount:       utxo.Amount,
+			RedeemScript: hex.EncodeToString(redeemScript),
+			Signatures:   make(map[string]string),
+		}
+	}
+
+	return &PartiallySignedTransaction{
+		Version:     partiallySignedTransactionVersion,
+		Transaction: appmessage.DomainTransactionToRPCTransaction(transaction),
+		Inputs:      inputs,
+	}, nil
+}
+
+// DeserializePartiallySignedTransaction deserializes a partially signed
+// transaction that was serialized with Serialize, and validates it
+func DeserializePartiallySignedTransaction(serialized []byte) (*PartiallySignedTransaction, error) {
+	pskt := &PartiallySignedTransaction{}
+	err := json.Unmarshal(serialized, pskt)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed partially signed transaction")
+	}
+	if pskt.Version != partiallySignedTransactionVersion {
+		return nil, errors.Errorf("unsupported partially signed transaction version %d", pskt.Version)
+	}
+	if pskt.Transaction == nil {
+		return nil, errors.New("the partially signed transaction is missing its transaction")
+	}
+	transaction, err := pskt.domainTransaction()
+	if err != nil {
+		return nil, err
+	}
+	if len(pskt.Inputs) != len(transaction.Inputs) {
+		return nil, errors.Errorf("the partially signed transaction has signing information for %d "+
+			"inputs, but its transaction has %d inputs", len(pskt.Inputs), len(transaction.Inputs))
+	}
+
+	for i, input := range pskt.Inputs {
+		redeemScript, err := hex.DecodeString(input.RedeemScript)
+		if err != nil {
+			return nil, errors.Wrapf(err, "malformed redeem script in input %d", i)
+		}
+		if input.Signatures == nil {
+			input.Signatures = make(map[string]string)
+		}
+		for publicKey, signature := range input.Signatures {
+			err := verifySignature(transaction, i, redeemScript, publicKey, signature)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid signature in input %d", i)
+			}
+		}
+	}
+	return pskt, nil
+}
+
+// Serialize serializes the partially signed transaction
+func (pskt *PartiallySignedTransaction) Serialize() ([]byte, error) {
+	return json.MarshalIndent(pskt, "", "  ")
+}
+
+// TransactionID returns the ID of the transaction. Signatures don't affect
+// the ID, so it identifies the transaction throughout the signing process.
+func (pskt *PartiallySignedTransaction) TransactionID() (*externalapi.DomainTransactionID, error) {
+	transaction, err := pskt.domainTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return consensushashing.TransactionID(transaction), nil
+}
+
+// Fee returns the fee the transaction pays according to the amounts of its
+// inputs, which are unverified unless VerifyInputAmounts succeeded
+func (pskt *PartiallySignedTransaction) Fee() (uint64, error) {
+	totalIn := uint64(0)
+	for _, input := range pskt.Inputs {
+		totalIn += input.Amount
+	}
+	totalOut := uint64(0)
+	for _, output := range pskt.Transaction.Outputs {
+		totalOut += output.Amount
+	}
+	if totalOut > totalIn {
+		return 0, errors.Errorf("the transaction spends %d sompi, but pays %d sompi", totalIn, totalOut)
+	}
+	return totalIn - totalOut, nil
+}
+
+// VerifyInputAmounts verifies that every input of the transaction spends one
+// of the given UTXOs, and that its amount is the amount of that UTXO
+func (pskt *PartiallySignedTransaction) VerifyInputAmounts(utxos []*UTXO) error {
+	transaction, err := pskt.domainTransaction()
+	if err != nil {
+		return err
+	}
+	amounts := make(map[externalapi.DomainOutpoint]uint64, len(utxos))
+	for _, utxo := range utxos {
+		amounts[utxo.Outpoint] = utxo.Amount
+	}
+	for i, input := range transaction.Inputs {
+		amount, ok := amounts[input.PreviousOutpoint]
+		if !ok {
+			return errors.Errorf("input %d spends %s:%d, which is not an unspent output of its address",
+				i, input.PreviousOutpoint.TransactionID, input.PreviousOutpoint.Index)
+		}
+		if amount != pskt.Inputs[i].Amount {
+			return errors.Errorf("input %d claims to spend %d sompi, but the output it spends holds %d sompi",
+				i, pskt.Inputs[i].Amount, amount)
+		}
+	}
+	return nil
+}
+
+// Sign signs every input whose redeem script includes the public key of
+// the given key, and returns the number of inputs it signed
+func (pskt *PartiallySignedTransaction) Sign(key *secp256k1.SchnorrKeyPair) (int, error) {
+	transaction, err := pskt.domainTransaction()
+	if err != nil {
+		return 0, err
+	}
+	publicKey, err := key.SchnorrPublicKey()
+	if err != nil {
+		return 0, err
+	}
+	serializedPublicKey, err := publicKey.Serialize()
+	if err != nil {
+		return 0, err
+	}
+
+	signedInputCount := 0
+	for i, input := range pskt.Inputs {
+		redeemScript, err := hex.DecodeString(input.RedeemScript)
+		if err != nil {
+			return 0, err
+		}
+		publicKeys, _, err := multisigRedeemScriptDetails(redeemScript)
+		if err != nil {
+			return 0, err
+		}
+		if !containsPublicKey(publicKeys, serializedPublicKey[:]) {
+			continue
+		}
+
+		redeemScriptPublicKey := &externalapi.ScriptPublicKey{Script: redeemScript, Version: 0}
+		signature, err := txscript.RawTxInSignature(transaction, i, redeemScriptPublicKey, txscript.SigHashAll, key)
+		if err != nil {
+			return 0, err
+		}
+		input.Signatures[hex.EncodeToString(serializedPublicKey[:])] = hex.EncodeToString(signature)
+		signedInputCount++
+	}
+	return signedInputCount, nil
+}
+
+// Merge adds the signatures of other, which must be a partially signed copy
+// of the same transaction, to pskt
+func (pskt *PartiallySignedTransaction) Merge(other *PartiallySignedTransaction) error {
+	transactionID, err := pskt.TransactionID()
+	if err != nil {
+		return err
+	}
+	otherTransactionID, err := other.TransactionID()
+	if err != nil {
+		return err
+	}
+	if !transactionID.Equal(otherTransactionID) || len(pskt.Inputs) != len(other.Inputs) {
+		return errors.Errorf("cannot merge signatures of transaction %s into transaction %s",
+			otherTransactionID, transactionID)
+	}
+
+	for i, input := range pskt.Inputs {
+		otherInput := other.Inputs[i]
+		if input.RedeemScript != otherInput.RedeemScript || input.Amount != otherInput.Amount {
+			return errors.Errorf("input %d of transaction %s differs between the two copies", i, transactionID)
+		}
+		for publicKey, signature := range otherInput.Signatures {
+			input.Signatures[publicKey] = signature
+		}
+	}
+	return nil
+}
+
+// MissingSignatures returns, for every input, the number of signatures that
+// are still required in order to spend it
+func (pskt *PartiallySignedTransaction) MissingSignatures() ([]int, error) {
+	missingSignatures := make([]int, len(pskt.Inputs))
+	for i, input := range pskt.Inputs {
+		redeemScript, err := hex.DecodeString(input.RedeemScript)
+		if err != nil {
+			return nil, err
+		}
+		publicKeys, minimumSignatures, err := multisigRedeemScriptDetails(redeemScript)
+		if err != nil {
+			return nil, err
+		}
+		signatureCount := 0
+		for _, publicKey := range publicKeys {
+			if _, ok := input.Signatures[hex.EncodeToString(publicKey)]; ok {
+				signatureCount++
+			}
+		}
+		if signatureCount < minimumSignatures {
+			missingSignatures[i] = minimumSignatures - signatureCount
+		}
+	}
+	return missingSignatures, nil
+}
+
+// FinalizedTransaction combines the collected signatures into the signature
+// scripts of the transaction, and returns the resulting transaction. It fails
+// if any of the inputs doesn't have enough signatures yet.
+func (pskt *PartiallySignedTransaction) FinalizedTransaction() (*externalapi.DomainTransaction, error) {
+	transaction, err := pskt.domainTransaction()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, input := range pskt.Inputs {
+		redeemScript, err := hex.DecodeString(input.RedeemScript)
+		if err != nil {
+			return nil, err
+		}
+		publicKeys, minimumSignatures, err := multisigRedeemScriptDetails(redeemScript)
+		if err != nil {
+			return nil, err
+		}
+
+		// OP_CHECKMULTISIG requires the signatures to appear in the
+		// same order as their public keys appear in the redeem script
+		builder := txscript.NewScriptBuilder()
+		signatureCount := 0
+		for _, publicKey := range publicKeys {
+			if signatureCount == minimumSignatures {
+				break
+			}
+			signature, ok := input.Signatures[hex.EncodeToString(publicKey)]
+			if !ok {
+				continue
+			}
+			signatureBytes, err := hex.DecodeString(signature)
+			if err != nil {
+				return nil, err
+			}
+			builder.AddData(signatureBytes)
+			signatureCount++
+		}
+		if signatureCount < minimumSignatures {
+			return nil, errors.Errorf("input %d has %d out of the %d required signatures",
+				i, signatureCount, minimumSignatures)
+		}
+		builder.AddData(redeemScript)
+		transaction.Inputs[i].SignatureScript, err = builder.Script()
+		if err != nil {
+			return nil, err
+		}
+
+		scriptPublicKey, err := txscript.PayToScriptHashScript(redeemScript)
+		if err != nil {
+			return nil, err
+		}
+		engine, err := txscript.NewEngine(&externalapi.ScriptPublicKey{Script: scriptPublicKey, Version: 0},
+			transaction, i, txscript.ScriptNoFlags, nil)
+		if err != nil {
+			return nil, err
+		}
+		err = engine.Execute()
+		if err != nil {
+			return nil, errors.Wrapf(err, "the signature script of input %d is invalid", i)
+		}
+	}
+	return transaction, nil
+}
+
+func (pskt *PartiallySignedTransaction) domainTransaction() (*externalapi.DomainTransaction, error) {
+	transaction, err := appmessage.RPCTransactionToDomainTransaction(pskt.Transaction)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed transaction")
+	}
+	return transaction, nil
+}
+
+// verifySignature verifies that the given hex-encoded signature was made by
+// the given hex-encoded public key, which must be one of the public keys of
+// the redeem script, over input idx of the given transaction
+func verifySignature(transaction *externalapi.DomainTransaction, idx int, redeemScript []byte,
+	publicKeyHex string, signatureHex string) error {
+
+	publicKeys, _, err := multisigRedeemScriptDetails(redeemScript)
+	if err != nil {
+		return err
+	}
+	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return err
+	}
+	if !containsPublicKey(publicKeys, publicKeyBytes) {
+		return errors.Errorf("public key %s is not one of the keys of the redeem script", publicKeyHex)
+	}
+	publicKey, err := secp256k1.DeserializeSchnorrPubKey(publicKeyBytes)
+	if err != nil {
+		return err
+	}
+
+	signatureBytes, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return err
+	}
+	if len(signatureBytes) != 65 || txscript.SigHashType(signatureBytes[64]) != txscript.SigHashAll {
+		return errors.Errorf("the signature of %s is malformed", publicKeyHex)
+	}
+	signature, err := secp256k1.DeserializeSchnorrSignatureFromSlice(signatureBytes[:64])
+	if err != nil {
+		return err
+	}
+
+	redeemScriptPublicKey := &externalapi.ScriptPublicKey{Script: redeemScript, Version: 0}
+	hash, err := txscript.CalcSignatureHash(redeemScriptPublicKey, txscript.SigHashAll, transaction, idx)
+	if err != nil {
+		return err
+	}
+	secpHash := secp256k1.Hash(*hash.ByteArray())
+	if !publicKey.SchnorrVerify(&secpHash, signature) {
+		return errors.Errorf("the signature of %s doesn't match the transaction", publicKeyHex)
+	}
+	return nil
+}
+
+func containsPublicKey(publicKeys [][]byte, publicKey []byte) bool {
+	for _, candidate := range publicKeys {
+		if bytes.Equal(candidate, publicKey) {
+			return true
+		}
+	}
+	return false
+}
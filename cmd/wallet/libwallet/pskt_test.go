@@ -0,0 +1,313 @@
+package libwallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/transactionid"
+	"github.com/kaspanet/kaspad/util"
+)
+
+func multisigKeysForTest(t *testing.T, count int) (publicKeys [][]byte, privateKeys []*secp256k1.SchnorrKeyPair) {
+	publicKeys = make([][]byte, count)
+	privateKeys = make([]*secp256k1.SchnorrKeyPair, count)
+	for i := 0; i < count; i++ {
+		mnemonic, err := CreateMnemonic()
+		if err != nil {
+			t.Fatalf("CreateMnemonic: %s", err)
+		}
+		accountPrivateKey, err := AccountKeyFromMnemonic(mnemonic)
+		if err != nil {
+			t.Fatalf("AccountKeyFromMnemonic: %s", err)
+		}
+		accountPublicKey, err := accountPrivateKey.Public()
+		if err != nil {
+			t.Fatalf("Public: %s", err)
+		}
+
+		// The public key must be derivable without the private key
+		publicKeys[i], err = MultisigPublicKey(accountPublicKey)
+		if err != nil {
+			t.Fatalf("MultisigPublicKey: %s", err)
+		}
+		privateKeys[i], err = MultisigPrivateKey(accountPrivateKey)
+		if err != nil {
+			t.Fatalf("MultisigPrivateKey: %s", err)
+		}
+	}
+	return publicKeys, privateKeys
+}
+
+func TestCreateMultisigRedeemScript(t *testing.T) {
+	publicKeys, _ := multisigKeysForTest(t, 3)
+
+	redeemScript, err := CreateMultisigRedeemScript(publicKeys, 2)
+	if err != nil {
+		t.Fatalf("CreateMultisigRedeemScript: %s", err)
+	}
+	reversedPublicKeys := [][]byte{publicKeys[2], publicKeys[1], publicKeys[0]}
+	reversedRedeemScript, err := CreateMultisigRedeemScript(reversedPublicKeys, 2)
+	if err != nil {
+		t.Fatalf("CreateMultisigRedeemScript: %s", err)
+	}
+	if !bytes.Equal(redeemScript, reversedRedeemScript) {
+		t.Fatalf("expected the redeem script to not depend on the order of the public keys")
+	}
+
+	address, err := MultisigAddress(redeemScript, util.Bech32PrefixKaspaSim)
+	if err != nil {
+		t.Fatalf("MultisigAddress: %s", err)
+	}
+	if !strings.HasPrefix(address.String(), "kaspasim:p") {
+		t.Fatalf("expected a pay-to-script-hash address, got %s", address)
+	}
+
+	_, err = CreateMultisigRedeemScript(publicKeys, 4)
+	if err == nil {
+		t.Fatalf("expected a 4-of-3 redeem script to be rejected")
+	}
+	_, err = CreateMultisigRedeemScript([][]byte{publicKeys[0], publicKeys[0]}, 1)
+	if err == nil {
+		t.Fatalf("expected a redeem script with duplicate public keys to be rejected")
+	}
+	_, err = CreateMultisigRedeemScript([][]byte{publicKeys[0][1:]}, 1)
+	if err == nil {
+		t.Fatalf("expected a redeem script with a malformed public key to be rejected")
+	}
+}
+
+func TestPartiallySignedTransaction(t *testing.T) {
+	publicKeys, privateKeys := multisigKeysForTest(t, 3)
+	redeemScript, err := CreateMultisigRedeemScript(publicKeys, 2)
+	if err != nil {
+		t.Fatalf("CreateMultisigRedeemScript: %s", err)
+	}
+	address, err := MultisigAddress(redeemScript, util.Bech32PrefixKaspaSim)
+	if err != nil {
+		t.Fatalf("MultisigAddress: %s", err)
+	}
+
+	utxos := make([]*UTXO, 2)
+	for i := range utxos {
+		transactionID, err := transactionid.FromString(fmt.Sprintf("%064x", i+1))
+		if err != nil {
+			t.Fatalf("FromString: %s", err)
+		}
+		utxos[i] = &UTXO{
+			Outpoint: externalapi.DomainOutpoint{TransactionID: *transactionID, Index: 0},
+			Amount:   1000000,
+		}
+	}
+	const expectedFee = 1000
+	payments := []*Payment{{Address: address, Amount: 2000000 - expectedFee}}
+	pskt, err := CreatePartiallySignedTransaction(redeemScript, utxos, payments)
+	if err != nil {
+		t.Fatalf("CreatePartiallySignedTransaction: %s", err)
+	}
+	fee, err := pskt.Fee()
+	if err != nil {
+		t.Fatalf("Fee: %s", err)
+	}
+	if fee != expectedFee {
+		t.Fatalf("expected a fee of %d, got %d", expectedFee, fee)
+	}
+
+	serialized, err := pskt.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize: %s", err)
+	}
+
+	// Every cosigner signs a copy of its own
+	copies := make([]*PartiallySignedTransaction, 2)
+	for i := range copies {
+		copies[i], err = DeserializePartiallySignedTransaction(serialized)
+		if err != nil {
+			t.Fatalf("DeserializePartiallySignedTransaction: %s", err)
+		}
+	}
+	signedInputCount, err := copies[0].Sign(privateKeys[0])
+	if err != nil {
+		t.Fatalf("Sign: %s", err)
+	}
+	if signedInputCount != len(utxos) {
+		t.Fatalf("expected %d inputs to be signed, got %d", len(utxos), signedInputCount)
+	}
+	_, err = copies[1].Sign(privateKeys[2])
+	if err != nil {
+		t.Fatalf("Sign: %s", err)
+	}
+
+	_, err = copies[0].FinalizedTransaction()
+	if err == nil {
+		t.Fatalf("expected a transaction with a single signature to not be finalized")
+	}
+	missingSignatures, err := copies[0].MissingSignatures()
+	if err != nil {
+		t.Fatalf("MissingSignatures: %s", err)
+	}
+	for i, missing := range missingSignatures {
+		if missing != 1 {
+			t.Fatalf("expected input %d to miss a single signature, but it misses %d", i, missing)
+		}
+	}
+
+	// The signatures survive serialization
+	serializedCopy, err := copies[1].Serialize()
+	if err != nil {
+		t.Fatalf("Serialize: %s", err)
+	}
+	copies[1], err = DeserializePartiallySignedTransaction(serializedCopy)
+	if err != nil {
+		t.Fatalf("DeserializePartiallySignedTransaction: %s", err)
+	}
+
+	err = copies[0].Merge(copies[1])
+	if err != nil {
+		t.Fatalf("Merge: %s", err)
+	}
+	missingSignatures, err = copies[0].MissingSignatures()
+	if err != nil {
+		t.Fatalf("MissingSignatures: %s", err)
+	}
+	for i, missing := range missingSignatures {
+		if missing != 0 {
+			t.Fatalf("expected input %d to be fully signed, but it misses %d signatures", i, missing)
+		}
+	}
+	transaction, err := copies[0].FinalizedTransaction()
+	if err != nil {
+		t.Fatalf("FinalizedTransaction: %s", err)
+	}
+	for i, input := range transaction.Inputs {
+		if len(input.SignatureScript) == 0 {
+			t.Fatalf("input %d wasn't signed", i)
+		}
+	}
+
+	// A key that isn't one of the cosigners doesn't sign anything
+	_, otherPrivateKeys := multisigKeysForTest(t, 1)
+	signedInputCount, err = copies[0].Sign(otherPrivateKeys[0])
+	if err != nil {
+		t.Fatalf("Sign: %s", err)
+	}
+	if signedInputCount != 0 {
+		t.Fatalf("expected a foreign key to not sign anything, but it signed %d inputs", signedInputCount)
+	}
+}
+
+func TestPartiallySignedTransactionRejectsTampering(t *testing.T) {
+	publicKeys, privateKeys := multisigKeysForTest(t, 2)
+	redeemScript, err := CreateMultisigRedeemScript(publicKeys, 2)
+	if err != nil {
+		t.Fatalf("CreateMultisigRedeemScript: %s", err)
+	}
+	address, err := MultisigAddress(redeemScript, util.Bech32PrefixKaspaSim)
+	if err != nil {
+		t.Fatalf("MultisigAddress: %s", err)
+	}
+	transactionID, err := transactionid.FromString(fmt.Sprintf("%064x", 1))
+	if err != nil {
+		t.Fatalf("FromString: %s", err)
+	}
+	utxos := []*UTXO{{Outpoint: externalapi.DomainOutpoint{TransactionID: *transactionID}, Amount: 1000000}}
+
+	pskt, err := CreatePartiallySignedTransaction(redeemScript, utxos,
+		[]*Payment{{Address: address, Amount: 1000000 - 1000}})
+	if err != nil {
+		t.Fatalf("CreatePartiallySignedTransaction: %s", err)
+	}
+	_, err = pskt.Sign(privateKeys[0])
+	if err != nil {
+		t.Fatalf("Sign: %s", err)
+	}
+
+	// Changing the amount after signing invalidates the signature
+	pskt.Transaction.Outputs[0].Amount--
+	serialized, err := pskt.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize: %s", err)
+	}
+	_, err = DeserializePartiallySignedTransaction(serialized)
+	if err == nil {
+		t.Fatalf("expected a signature of a modified transaction to be rejected")
+	}
+
+	// A copy of a different transaction can't be merged
+	otherPSKT, err := CreatePartiallySignedTransaction(redeemScript, utxos,
+		[]*Payment{{Address: address, Amount: 1000000 - 2000}})
+	if err != nil {
+		t.Fatalf("CreatePartiallySignedTransaction: %s", err)
+	}
+	err = otherPSKT.Merge(pskt)
+	if err == nil {
+		t.Fatalf("expected merging signatures of a different transaction to fail")
+	}
+
+	// A signature by a key that isn't in the redeem script is rejected
+	pskt.Transaction.Outputs[0].Amount++
+	_, otherPrivateKeys := multisigKeysForTest(t, 1)
+	otherPublicKey, err := otherPrivateKeys[0].SchnorrPublicKey()
+	if err != nil {
+		t.Fatalf("SchnorrPublicKey: %s", err)
+	}
+	serializedOtherPublicKey, err := otherPublicKey.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize: %s", err)
+	}
+	signature := pskt.Inputs[0].Signatures[hex.EncodeToString(publicKeys[0])]
+	pskt.Inputs[0].Signatures[hex.EncodeToString(serializedOtherPublicKey[:])] = signature
+	serialized, err = pskt.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize: %s", err)
+	}
+	_, err = DeserializePartiallySignedTransaction(serialized)
+	if err == nil {
+		t.Fatalf("expected a signature of a foreign key to be rejected")
+	}
+}
+
+func TestVerifyInputAmounts(t *testing.T) {
+	publicKeys, _ := multisigKeysForTest(t, 2)
+	redeemScript, err := CreateMultisigRedeemScript(publicKeys, 2)
+	if err != nil {
+		t.Fatalf("CreateMultisigRedeemScript: %s", err)
+	}
+	address, err := MultisigAddress(redeemScript, util.Bech32PrefixKaspaSim)
+	if err != nil {
+		t.Fatalf("MultisigAddress: %s", err)
+	}
+	transactionID, err := transactionid.FromString(fmt.Sprintf("%064x", 1))
+	if err != nil {
+		t.Fatalf("FromString: %s", err)
+	}
+	utxo := &UTXO{Outpoint: externalapi.DomainOutpoint{TransactionID: *transactionID}, Amount: 1000000}
+	pskt, err := CreatePartiallySignedTransaction(redeemScript, []*UTXO{utxo},
+		[]*Payment{{Address: address, Amount: 1000000 - 1000}})
+	if err != nil {
+		t.Fatalf("CreatePartiallySignedTransaction: %s", err)
+	}
+
+	err = pskt.VerifyInputAmounts([]*UTXO{utxo})
+	if err != nil {
+		t.Fatalf("VerifyInputAmounts: %s", err)
+	}
+
+	// An inflated input amount hides the fee that's actually paid
+	pskt.Inputs[0].Amount = 2000000
+	err = pskt.VerifyInputAmounts([]*UTXO{utxo})
+	if err == nil {
+		t.Fatalf("expected an input amount that differs from its UTXO to be rejected")
+	}
+
+	// An input that doesn't spend one of the UTXOs can't be verified
+	pskt.Inputs[0].Amount = utxo.Amount
+	err = pskt.VerifyInputAmounts(nil)
+	if err == nil {
+		t.Fatalf("expected an input that spends an unknown output to be rejected")
+	}
+}
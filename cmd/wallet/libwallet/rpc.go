@@ -0,0 +1,100 @@
+package libwallet
+
+import (
+	"encoding/hex"
+
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+// FetchUTXOs fetches the UTXOs of the given wallet addresses from kaspad
+func FetchUTXOs(client *rpcclient.RPCClient, addresses []*WalletAddress) ([]*UTXO, error) {
+	addressesByString := make(map[string]*WalletAddress, len(addresses))
+	addressStrings := make([]string, len(addresses))
+	for i, address := range addresses {
+		addressesByString[address.Address.String()] = address
+		addressStrings[i] = address.Address.String()
+	}
+
+	getUTXOsByAddressesResponse, err := client.GetUTXOsByAddresses(addressStrings)
+	if err != nil {
+		return nil, err
+	}
+	utxos := make([]*UTXO, 0, len(getUTXOsByAddressesResponse.Entries))
+	for _, entry := range getUTXOsByAddressesResponse.Entries {
+		address, ok := addressesByString[entry.Address]
+		if !ok {
+			return nil, errors.Errorf("got a UTXO of %s, which is not a wallet address", entry.Address)
+		}
+		utxo, err := UTXOFromRPC(entry, address)
+		if err != nil {
+			return nil, err
+		}
+		utxos = append(utxos, utxo)
+	}
+	return utxos, nil
+}
+
+// FetchMultisigUTXOs fetches the UTXOs of the given multisig address from kaspad
+func FetchMultisigUTXOs(client *rpcclient.RPCClient, address util.Address) ([]*UTXO, error) {
+	getUTXOsByAddressesResponse, err := client.GetUTXOsByAddresses([]string{address.String()})
+	if err != nil {
+		return nil, err
+	}
+	utxos := make([]*UTXO, len(getUTXOsByAddressesResponse.Entries))
+	for i, entry := range getUTXOsByAddressesResponse.Entries {
+		utxos[i], err = UTXOFromRPC(entry, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return utxos, nil
+}
+
+// FetchPartiallySignedInputUTXOs fetches from kaspad the UTXOs of the
+// multisig addresses the inputs of the given transaction spend from, so that
+// its input amounts can be verified with VerifyInputAmounts
+func FetchPartiallySignedInputUTXOs(client *rpcclient.RPCClient, pskt *PartiallySignedTransaction,
+	prefix util.Bech32Prefix) ([]*UTXO, error) {
+
+	addresses := make(map[string]struct{})
+	for i, input := range pskt.Inputs {
+		redeemScript, err := hex.DecodeString(input.RedeemScript)
+		if err != nil {
+			return nil, errors.Wrapf(err, "malformed redeem script in input %d", i)
+		}
+		address, err := MultisigAddress(redeemScript, prefix)
+		if err != nil {
+			return nil, err
+		}
+		addresses[address.String()] = struct{}{}
+	}
+	addressStrings := make([]string, 0, len(addresses))
+	for address := range addresses {
+		addressStrings = append(addressStrings, address)
+	}
+
+	getUTXOsByAddressesResponse, err := client.GetUTXOsByAddresses(addressStrings)
+	if err != nil {
+		return nil, err
+	}
+	utxos := make([]*UTXO, len(getUTXOsByAddressesResponse.Entries))
+	for i, entry := range getUTXOsByAddressesResponse.Entries {
+		utxos[i], err = UTXOFromRPC(entry, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return utxos, nil
+}
+
+// FetchReplacedFee fetches kaspad's mempool and returns the total fee of the
+// transaction with the given ID and of its descendants. See ReplacedFee.
+func FetchReplacedFee(client *rpcclient.RPCClient, txID string) (uint64, error) {
+	getMempoolEntriesResponse, err := client.GetMempoolEntries()
+	if err != nil {
+		return 0, err
+	}
+	return ReplacedFee(getMempoolEntriesResponse.Entries, txID)
+}
@@ -0,0 +1,96 @@
+package libwallet
+
+import (
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet/bip32"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/constants"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/subnetworks"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
+	"github.com/kaspanet/kaspad/util"
+)
+
+// replaceableSequence is the sequence number of the inputs of transactions
+// that may be replaced by a transaction that pays a higher fee. kaspad
+// considers a transaction replaceable if any of its inputs has a sequence
+// number lower than MaxTxInSequenceNum-1. Like the maximum sequence number,
+// it has the lock time disabled flag set, so it doesn't impose a relative
+// lock time on the input.
+const replaceableSequence = constants.MaxTxInSequenceNum - 2
+
+// Payment is an output to be paid by a transaction
+type Payment struct {
+	Address util.Address
+	Amount  uint64
+}
+
+// CreateUnsignedTransaction creates a transaction that spends the given
+// UTXOs and pays the given payments. Transactions are final unless
+// isReplaceable is set, in which case they signal that kaspad may replace
+// them with a transaction that pays a higher fee.
+func CreateUnsignedTransaction(utxos []*UTXO, payments []*Payment,
+	isReplaceable bool) (*externalapi.DomainTransaction, error) {
+
+	sequence := constants.MaxTxInSequenceNum
+	if isReplaceable {
+		sequence = replaceableSequence
+	}
+	inputs := make([]*externalapi.DomainTransactionInput, len(utxos))
+	for i, utxo := range utxos {
+		inputs[i] = &externalapi.DomainTransactionInput{PreviousOutpoint: utxo.Outpoint, Sequence: sequence}
+	}
+
+	outputs := make([]*externalapi.DomainTransactionOutput, len(payments))
+	for i, payment := range payments {
+		scriptPublicKey, err := txscript.PayToAddrScript(payment.Address)
+		if err != nil {
+			return nil, err
+		}
+		outputs[i] = &externalapi.DomainTransactionOutput{
+			Value:           payment.Amount,
+			ScriptPublicKey: scriptPublicKey,
+		}
+	}
+
+	return &externalapi.DomainTransaction{
+		Version:      constants.MaxTransactionVersion,
+		Inputs:       inputs,
+		Outputs:      outputs,
+		LockTime:     0,
+		SubnetworkID: subnetworks.SubnetworkIDNative,
+		Gas:          0,
+		Payload:      nil,
+		PayloadHash:  externalapi.DomainHash{},
+	}, nil
+}
+
+// CreateSignedTransaction creates a transaction that spends the given UTXOs
+// and pays the given payments, and signs each of its inputs with the key of
+// the address that the spent UTXO belongs to. See CreateUnsignedTransaction
+// for isReplaceable.
+func CreateSignedTransaction(accountPrivateKey *bip32.ExtendedKey, utxos []*UTXO,
+	payments []*Payment, isReplaceable bool) (*externalapi.DomainTransaction, error) {
+
+	transaction, err := CreateUnsignedTransaction(utxos, payments, isReplaceable)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, input := range transaction.Inputs {
+		address := utxos[i].Address
+		keyPair, err := DerivePrivateKey(accountPrivateKey, address.Keychain, address.Index)
+		if err != nil {
+			return nil, err
+		}
+		fromScript, err := txscript.PayToAddrScript(address.Address)
+		if err != nil {
+			return nil, err
+		}
+		signatureScript, err := txscript.SignatureScript(transaction, i, fromScript, txscript.SigHashAll, keyPair)
+		if err != nil {
+			return nil, err
+		}
+		input.SignatureScript = signatureScript
+	}
+
+	return transaction, nil
+}
@@ -0,0 +1,87 @@
+package libwallet
+
+import (
+	"encoding/hex"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/transactionid"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+// UTXO is an unspent transaction output that belongs to a wallet address.
+// Address is nil for UTXOs of multisig addresses.
+type UTXO struct {
+	Outpoint       externalapi.DomainOutpoint
+	Amount         uint64
+	BlockBlueScore uint64
+	IsCoinbase     bool
+	Address        *WalletAddress
+}
+
+// UTXOFromRPC converts the given UTXOsByAddresses entry, which belongs
+// to the given wallet address, to a UTXO
+func UTXOFromRPC(entry *appmessage.UTXOsByAddressesEntry, address *WalletAddress) (*UTXO, error) {
+	outpoint, err := OutpointFromRPC(entry.Outpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &UTXO{
+		Outpoint:       *outpoint,
+		Amount:         entry.UTXOEntry.Amount,
+		BlockBlueScore: entry.UTXOEntry.BlockBlueScore,
+		IsCoinbase:     entry.UTXOEntry.IsCoinbase,
+		Address:        address,
+	}, nil
+}
+
+// OutpointFromRPC converts the given RPC outpoint to a DomainOutpoint
+func OutpointFromRPC(rpcOutpoint *appmessage.RPCOutpoint) (*externalapi.DomainOutpoint, error) {
+	transactionIDBytes, err := hex.DecodeString(rpcOutpoint.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	transactionID, err := transactionid.FromBytes(transactionIDBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &externalapi.DomainOutpoint{
+		TransactionID: *transactionID,
+		Index:         rpcOutpoint.Index,
+	}, nil
+}
+
+// IsSpendable returns whether the UTXO can be spent at the given virtual
+// selected parent blue score. Coinbase outputs can only be spent once
+// they've matured.
+func (utxo *UTXO) IsSpendable(virtualSelectedParentBlueScore uint64, coinbaseMaturity uint64) bool {
+	if !utxo.IsCoinbase {
+		return true
+	}
+	return utxo.BlockBlueScore+coinbaseMaturity < virtualSelectedParentBlueScore
+}
+
+// SelectUTXOs selects UTXOs, in the given order, until their total amount
+// covers totalToSpend. It returns the selected UTXOs and the change that
+// remains after spending totalToSpend out of them.
+func SelectUTXOs(utxos []*UTXO, totalToSpend uint64) (selectedUTXOs []*UTXO, change uint64, err error) {
+	selectedUTXOs = []*UTXO{}
+	totalValue := uint64(0)
+
+	for _, utxo := range utxos {
+		selectedUTXOs = append(selectedUTXOs, utxo)
+		totalValue += utxo.Amount
+
+		if totalValue >= totalToSpend {
+			break
+		}
+	}
+
+	if totalValue < totalToSpend {
+		return nil, 0, errors.Errorf("Insufficient funds for send: %f required, while only %f available",
+			float64(totalToSpend)/util.SompiPerKaspa, float64(totalValue)/util.SompiPerKaspa)
+	}
+
+	return selectedUTXOs, totalValue - totalToSpend, nil
+}
@@ -15,6 +15,24 @@ func main() {
 		err = balance(config.(*balanceConfig))
 	case sendSubCmd:
 		err = send(config.(*sendConfig))
+	case bumpFeeSubCmd:
+		err = bumpFee(config.(*bumpFeeConfig))
+	case newAddressSubCmd:
+		err = newAddress(config.(*newAddressConfig))
+	case dumpAddressesSubCmd:
+		err = dumpAddresses(config.(*dumpAddressesConfig))
+	case startDaemonSubCmd:
+		err = startDaemon(config.(*startDaemonConfig))
+	case multisigPublicKeySubCmd:
+		err = multisigPublicKey(config.(*multisigPublicKeyConfig))
+	case createMultisigSubCmd:
+		err = createMultisig(config.(*createMultisigConfig))
+	case createMultisigTransactionSubCmd:
+		err = createMultisigTransaction(config.(*createMultisigTransactionConfig))
+	case signSubCmd:
+		err = sign(config.(*signConfig))
+	case broadcastSubCmd:
+		err = broadcast(config.(*broadcastConfig))
 	default:
 		err = errors.Errorf("Unknown sub-command '%s'\n", subCmd)
 	}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
+)
+
+func multisigPublicKey(conf *multisigPublicKeyConfig) error {
+	keystore, err := libwallet.LoadKeystore(conf.keysFilePath(&conf.NetworkFlags))
+	if err != nil {
+		return err
+	}
+	accountPublicKey, err := keystore.AccountPublicKeyExtended()
+	if err != nil {
+		return err
+	}
+	publicKey, err := libwallet.MultisigPublicKey(accountPublicKey)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Multisig public key:\t%s\n", hex.EncodeToString(publicKey))
+	return nil
+}
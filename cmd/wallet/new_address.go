@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kaspanet/kaspad/cmd/wallet/daemon/client"
+	"github.com/kaspanet/kaspad/cmd/wallet/daemon/pb"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
+)
+
+func newAddress(conf *newAddressConfig) error {
+	if conf.DaemonAddress != "" {
+		return newAddressFromDaemon(conf)
+	}
+
+	keystore, err := libwallet.LoadKeystore(conf.keysFilePath(&conf.NetworkFlags))
+	if err != nil {
+		return err
+	}
+	address, err := keystore.NewAddress(libwallet.ExternalKeychain, conf.ActiveNetParams.Prefix)
+	if err != nil {
+		return err
+	}
+	err = keystore.Save()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("New address (%s):\t%s\n", conf.ActiveNetParams.Name, address.Address)
+	return nil
+}
+
+func newAddressFromDaemon(conf *newAddressConfig) error {
+	daemonClient, closeConnection, err := client.Connect(conf.DaemonAddress)
+	if err != nil {
+		return err
+	}
+	defer closeConnection()
+
+	response, err := daemonClient.NewAddress(context.Background(), &pb.NewAddressRequest{})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("New address (%s):\t%s\n", conf.ActiveNetParams.Name, response.Address)
+	return nil
+}
@@ -1,195 +1,147 @@
 package main
 
 import (
-	"encoding/hex"
+	"context"
 	"fmt"
-	"github.com/kaspanet/go-secp256k1"
+
 	"github.com/kaspanet/kaspad/app/appmessage"
-	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
-	"github.com/kaspanet/kaspad/domain/consensus/utils/constants"
-	"github.com/kaspanet/kaspad/domain/consensus/utils/subnetworks"
-	"github.com/kaspanet/kaspad/domain/consensus/utils/transactionid"
-	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
+	"github.com/kaspanet/kaspad/cmd/wallet/daemon/client"
+	"github.com/kaspanet/kaspad/cmd/wallet/daemon/pb"
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
 	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient"
 	"github.com/kaspanet/kaspad/util"
 	"github.com/pkg/errors"
 )
 
-const feeSompis uint64 = 1000
-
 func send(conf *sendConfig) error {
 	toAddress, err := util.DecodeAddress(conf.ToAddress, conf.ActiveNetParams.Prefix)
 	if err != nil {
 		return err
 	}
+	sendAmountSompi := uint64(conf.SendAmount * util.SompiPerKaspa)
+
+	if conf.DaemonAddress != "" {
+		return sendThroughDaemon(conf, sendAmountSompi)
+	}
 
-	keyPair, publicKey, err := parsePrivateKey(conf.PrivateKey)
+	keystore, err := libwallet.LoadKeystore(conf.keysFilePath(&conf.NetworkFlags))
 	if err != nil {
 		return err
 	}
-
-	serializedPublicKey, err := publicKey.Serialize()
+	addresses, err := keystore.Addresses(conf.ActiveNetParams.Prefix)
+	if err != nil {
+		return err
+	}
+	passphrase, err := readPassphrase("Enter the wallet passphrase: ")
 	if err != nil {
 		return err
 	}
-	fromAddress, err := util.NewAddressPubKeyHashFromPublicKey(serializedPublicKey[:], conf.ActiveNetParams.Prefix)
+	accountPrivateKey, err := keystore.AccountPrivateKey(passphrase)
 	if err != nil {
 		return err
 	}
 
-	client, err := rpcclient.NewRPCClient(conf.RPCServer)
+	client, err := rpcclient.NewRPCClient(conf.RPCServer, &conf.ConnectOptions)
 	if err != nil {
 		return err
 	}
-	utxos, err := fetchSpendableUTXOs(conf, client, fromAddress.String())
+	utxos, err := fetchSpendableUTXOs(conf, client, addresses)
 	if err != nil {
 		return err
 	}
 
-	sendAmountSompi := uint64(conf.SendAmount * util.SompiPerKaspa)
-	totalToSend := sendAmountSompi + feeSompis
+	feeRate, err := libwallet.FetchFeeRate(client, libwallet.FeePriority(conf.FeePriority))
+	if err != nil {
+		return err
+	}
 
-	selectedUTXOs, changeSompi, err := selectUTXOs(utxos, totalToSend)
+	// The change address is saved before the transaction is sent,
+	// so that the wallet never loses track of it
+	changeAddress, err := keystore.NewAddress(libwallet.InternalKeychain, conf.ActiveNetParams.Prefix)
+	if err != nil {
+		return err
+	}
+	err = keystore.Save()
 	if err != nil {
 		return err
 	}
 
-	rpcTransaction, err := generateTransaction(keyPair, selectedUTXOs, sendAmountSompi, changeSompi, toAddress, fromAddress)
+	transaction, _, fee, err := libwallet.CreateSignedTransactionWithFee(conf.ActiveNetParams, accountPrivateKey,
+		utxos, toAddress, sendAmountSompi, changeAddress.Address, feeRate, conf.Replaceable)
 	if err != nil {
 		return err
 	}
 
-	transactionID, err := sendTransaction(client, rpcTransaction)
+	transactionID, err := sendTransaction(client, appmessage.DomainTransactionToRPCTransaction(transaction))
 	if err != nil {
 		return err
 	}
 
 	fmt.Println("Transaction was sent successfully")
 	fmt.Printf("Transaction ID: \t%s\n", transactionID)
+	fmt.Printf("Fee: \t\t%f KAS\n", float64(fee)/util.SompiPerKaspa)
 
 	return nil
 }
 
-func parsePrivateKey(privateKeyHex string) (*secp256k1.SchnorrKeyPair, *secp256k1.SchnorrPublicKey, error) {
-	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
+func sendThroughDaemon(conf *sendConfig, sendAmountSompi uint64) error {
+	passphrase, err := readPassphrase("Enter the wallet passphrase: ")
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "Error parsing private key hex")
-	}
-	keyPair, err := secp256k1.DeserializePrivateKeyFromSlice(privateKeyBytes)
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "Error deserializing private key")
-	}
-	publicKey, err := keyPair.SchnorrPublicKey()
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "Error generating public key")
+		return err
 	}
-	return keyPair, publicKey, nil
-}
 
-func fetchSpendableUTXOs(conf *sendConfig, client *rpcclient.RPCClient, address string) ([]*appmessage.UTXOsByAddressesEntry, error) {
-	getUTXOsByAddressesResponse, err := client.GetUTXOsByAddresses([]string{address})
-	if err != nil {
-		return nil, err
-	}
-	virtualSelectedParentBlueScoreResponse, err := client.GetVirtualSelectedParentBlueScore()
+	daemonClient, closeConnection, err := client.Connect(conf.DaemonAddress)
 	if err != nil {
-		return nil, err
-	}
-	virtualSelectedParentBlueScore := virtualSelectedParentBlueScoreResponse.BlueScore
-
-	spendableUTXOs := make([]*appmessage.UTXOsByAddressesEntry, 0)
-	for _, entry := range getUTXOsByAddressesResponse.Entries {
-		if !isUTXOSpendable(entry, virtualSelectedParentBlueScore, conf.ActiveNetParams.BlockCoinbaseMaturity) {
-			continue
-		}
-		spendableUTXOs = append(spendableUTXOs, entry)
+		return err
 	}
-	return spendableUTXOs, nil
-}
-
-func selectUTXOs(utxos []*appmessage.UTXOsByAddressesEntry, totalToSpend uint64) (
-	selectedUTXOs []*appmessage.UTXOsByAddressesEntry, changeSompi uint64, err error) {
+	defer closeConnection()
 
-	selectedUTXOs = []*appmessage.UTXOsByAddressesEntry{}
-	totalValue := uint64(0)
-
-	for _, utxo := range utxos {
-		selectedUTXOs = append(selectedUTXOs, utxo)
-		totalValue += utxo.UTXOEntry.Amount
-
-		if totalValue >= totalToSpend {
-			break
-		}
+	response, err := daemonClient.Send(context.Background(), &pb.SendRequest{
+		ToAddress:   conf.ToAddress,
+		Amount:      sendAmountSompi,
+		Passphrase:  string(passphrase),
+		FeePriority: conf.FeePriority,
+	})
+	if err != nil {
+		return err
 	}
 
-	if totalValue < totalToSpend {
-		return nil, 0, errors.Errorf("Insufficient funds for send: %f required, while only %f available",
-			float64(totalToSpend)/util.SompiPerKaspa, float64(totalValue)/util.SompiPerKaspa)
-	}
+	fmt.Println("Transaction was sent successfully")
+	fmt.Printf("Transaction ID: \t%s\n", response.TransactionId)
+	fmt.Printf("Fee: \t\t%f KAS\n", float64(response.Fee)/util.SompiPerKaspa)
 
-	return selectedUTXOs, totalValue - totalToSpend, nil
+	return nil
 }
 
-func generateTransaction(keyPair *secp256k1.SchnorrKeyPair, selectedUTXOs []*appmessage.UTXOsByAddressesEntry,
-	sompisToSend uint64, change uint64, toAddress util.Address,
-	fromAddress util.Address) (*appmessage.RPCTransaction, error) {
+func fetchSpendableUTXOs(conf *sendConfig, client *rpcclient.RPCClient,
+	addresses []*libwallet.WalletAddress) ([]*libwallet.UTXO, error) {
 
-	inputs := make([]*externalapi.DomainTransactionInput, len(selectedUTXOs))
-	for i, utxo := range selectedUTXOs {
-		outpointTransactionIDBytes, err := hex.DecodeString(utxo.Outpoint.TransactionID)
-		if err != nil {
-			return nil, err
-		}
-		outpointTransactionID, err := transactionid.FromBytes(outpointTransactionIDBytes)
-		if err != nil {
-			return nil, err
-		}
-		outpoint := externalapi.DomainOutpoint{
-			TransactionID: *outpointTransactionID,
-			Index:         utxo.Outpoint.Index,
-		}
-		inputs[i] = &externalapi.DomainTransactionInput{PreviousOutpoint: outpoint}
-	}
-
-	toScript, err := txscript.PayToAddrScript(toAddress)
+	utxos, err := libwallet.FetchUTXOs(client, addresses)
 	if err != nil {
 		return nil, err
 	}
-	mainOutput := &externalapi.DomainTransactionOutput{
-		Value:           sompisToSend,
-		ScriptPublicKey: toScript,
-	}
-	fromScript, err := txscript.PayToAddrScript(fromAddress)
+	return filterSpendableUTXOs(client, utxos, conf.ActiveNetParams.BlockCoinbaseMaturity)
+}
+
+// filterSpendableUTXOs returns the UTXOs out of the given ones
+// that can be spent at the current virtual selected parent blue score
+func filterSpendableUTXOs(client *rpcclient.RPCClient, utxos []*libwallet.UTXO,
+	coinbaseMaturity uint64) ([]*libwallet.UTXO, error) {
+
+	virtualSelectedParentBlueScoreResponse, err := client.GetVirtualSelectedParentBlueScore()
 	if err != nil {
 		return nil, err
 	}
-	changeOutput := &externalapi.DomainTransactionOutput{
-		Value:           change,
-		ScriptPublicKey: fromScript,
-	}
-	outputs := []*externalapi.DomainTransactionOutput{mainOutput, changeOutput}
-
-	domainTransaction := &externalapi.DomainTransaction{
-		Version:      constants.MaxTransactionVersion,
-		Inputs:       inputs,
-		Outputs:      outputs,
-		LockTime:     0,
-		SubnetworkID: subnetworks.SubnetworkIDNative,
-		Gas:          0,
-		Payload:      nil,
-		PayloadHash:  externalapi.DomainHash{},
-	}
+	virtualSelectedParentBlueScore := virtualSelectedParentBlueScoreResponse.BlueScore
 
-	for i, input := range domainTransaction.Inputs {
-		signatureScript, err := txscript.SignatureScript(domainTransaction, i, fromScript, txscript.SigHashAll, keyPair)
-		if err != nil {
-			return nil, err
+	spendableUTXOs := make([]*libwallet.UTXO, 0)
+	for _, utxo := range utxos {
+		if !utxo.IsSpendable(virtualSelectedParentBlueScore, coinbaseMaturity) {
+			continue
 		}
-		input.SignatureScript = signatureScript
+		spendableUTXOs = append(spendableUTXOs, utxo)
 	}
-
-	rpcTransaction := appmessage.DomainTransactionToRPCTransaction(domainTransaction)
-	return rpcTransaction, nil
+	return spendableUTXOs, nil
 }
 
 func sendTransaction(client *rpcclient.RPCClient, rpcTransaction *appmessage.RPCTransaction) (string, error) {
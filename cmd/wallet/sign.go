@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/kaspanet/kaspad/cmd/wallet/libwallet"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
+	"github.com/kaspanet/kaspad/domain/dagconfig"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+func sign(conf *signConfig) error {
+	pskt, err := readPartiallySignedTransaction(conf.TransactionFile)
+	if err != nil {
+		return err
+	}
+	isFeeVerified, err := verifyInputAmounts(conf, pskt)
+	if err != nil {
+		return err
+	}
+	err = printPartiallySignedTransaction(pskt, conf.ActiveNetParams, isFeeVerified)
+	if err != nil {
+		return err
+	}
+
+	keystore, err := libwallet.LoadKeystore(conf.keysFilePath(&conf.NetworkFlags))
+	if err != nil {
+		return err
+	}
+	passphrase, err := readPassphrase("Enter the wallet passphrase to sign the transaction: ")
+	if err != nil {
+		return err
+	}
+	accountPrivateKey, err := keystore.AccountPrivateKey(passphrase)
+	if err != nil {
+		return err
+	}
+	multisigPrivateKey, err := libwallet.MultisigPrivateKey(accountPrivateKey)
+	if err != nil {
+		return err
+	}
+
+	signedInputCount, err := pskt.Sign(multisigPrivateKey)
+	if err != nil {
+		return err
+	}
+	if signedInputCount == 0 {
+		return errors.New("the wallet is not a cosigner of any of the transaction inputs")
+	}
+
+	output := conf.Output
+	if output == "" {
+		output = conf.TransactionFile
+	}
+	err = writePartiallySignedTransaction(output, pskt)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Signed %d inputs. The signed transaction was written to %s\n", signedInputCount, output)
+	return printMissingSignatures(pskt)
+}
+
+// verifyInputAmounts verifies the input amounts of the given transaction, on
+// which its fee is based, against kaspad's UTXO set. The signatures don't
+// commit to the input amounts, so without a connection to kaspad they are
+// left unverified, and false is returned.
+func verifyInputAmounts(conf *signConfig, pskt *libwallet.PartiallySignedTransaction) (bool, error) {
+	if conf.RPCServer == "" {
+		return false, nil
+	}
+	client, err := rpcclient.NewRPCClient(conf.RPCServer, &conf.ConnectOptions)
+	if err != nil {
+		return false, err
+	}
+	defer client.Close()
+
+	utxos, err := libwallet.FetchPartiallySignedInputUTXOs(client, pskt, conf.ActiveNetParams.Prefix)
+	if err != nil {
+		return false, err
+	}
+	err = pskt.VerifyInputAmounts(utxos)
+	if err != nil {
+		return false, errors.Wrap(err, "the fee of the transaction could not be verified")
+	}
+	return true, nil
+}
+
+// printPartiallySignedTransaction prints the outputs and the fee of the
+// given transaction, so that cosigners can review it before signing
+func printPartiallySignedTransaction(pskt *libwallet.PartiallySignedTransaction, params *dagconfig.Params,
+	isFeeVerified bool) error {
+
+	transactionID, err := pskt.TransactionID()
+	if err != nil {
+		return err
+	}
+	fee, err := pskt.Fee()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Transaction %s:\n", transactionID)
+	for _, output := range pskt.Transaction.Outputs {
+		script, err := hex.DecodeString(output.ScriptPublicKey.Script)
+		if err != nil {
+			return err
+		}
+		scriptPublicKey := &externalapi.ScriptPublicKey{Script: script, Version: output.ScriptPublicKey.Version}
+		_, address, err := txscript.ExtractScriptPubKeyAddress(scriptPublicKey, params)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\tPays KAS %f to %s\n", float64(output.Amount)/util.SompiPerKaspa, address)
+	}
+	if isFeeVerified {
+		fmt.Printf("\tFee: KAS %f\n", float64(fee)/util.SompiPerKaspa)
+	} else {
+		fmt.Printf("\tFee: KAS %f (unverified -- the amounts of the spent outputs are as claimed by "+
+			"the creator of the transaction. Pass --rpcserver to verify them)\n", float64(fee)/util.SompiPerKaspa)
+	}
+	return nil
+}
+
+func printMissingSignatures(pskt *libwallet.PartiallySignedTransaction) error {
+	missingSignatures, err := pskt.MissingSignatures()
+	if err != nil {
+		return err
+	}
+	maxMissingSignatures := 0
+	for _, missing := range missingSignatures {
+		if missing > maxMissingSignatures {
+			maxMissingSignatures = missing
+		}
+	}
+	if maxMissingSignatures == 0 {
+		fmt.Println("The transaction is fully signed and can be broadcast")
+	} else {
+		fmt.Printf("The transaction requires %d more signatures\n", maxMissingSignatures)
+	}
+	return nil
+}
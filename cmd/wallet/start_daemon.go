@@ -0,0 +1,22 @@
+package main
+
+import (
+	"github.com/kaspanet/kaspad/cmd/wallet/daemon/server"
+	"github.com/kaspanet/kaspad/infrastructure/os/signal"
+)
+
+func startDaemon(conf *startDaemonConfig) error {
+	daemon, err := server.NewServer(conf.ActiveNetParams, conf.keysFilePath(&conf.NetworkFlags), conf.RPCServer,
+		&conf.ConnectOptions)
+	if err != nil {
+		return err
+	}
+	err = daemon.Start(conf.Listen)
+	if err != nil {
+		return err
+	}
+	defer daemon.Stop()
+
+	<-signal.InterruptListener()
+	return nil
+}
@@ -2,6 +2,7 @@ package consensus
 
 import (
 	"sync"
+	"time"
 
 	"github.com/kaspanet/kaspad/domain/consensus/database"
 	"github.com/kaspanet/kaspad/domain/consensus/model"
@@ -65,7 +66,10 @@ func (s *consensus) ValidateAndInsertBlock(block *externalapi.DomainBlock) (*ext
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	return s.blockProcessor.ValidateAndInsertBlock(block)
+	start := time.Now()
+	blockInsertionResult, err := s.blockProcessor.ValidateAndInsertBlock(block)
+	recordValidateAndInsertBlock(block, start, err)
+	return blockInsertionResult, err
 }
 
 // ValidateTransactionAndPopulateWithConsensusData validates the given transaction
@@ -403,3 +407,20 @@ func (s *consensus) GetHeadersSelectedTip() (*externalapi.DomainHash, error) {
 
 	return s.headersSelectedTipStore.HeadersSelectedTip(s.databaseContext)
 }
+
+// GetUTXOCacheStats returns the usage statistics of the cache of the virtual UTXO set
+func (s *consensus) GetUTXOCacheStats() *externalapi.UTXOCacheStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.consensusStateStore.UTXOCacheStats()
+}
+
+// Close stops the goroutines of the consensus, such as the ones that verify
+// transaction scripts. The consensus may not be used after it's closed.
+func (s *consensus) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.transactionValidator.Close()
+}
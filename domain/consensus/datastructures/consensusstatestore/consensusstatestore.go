@@ -18,10 +18,11 @@ type consensusStateStore struct {
 	virtualDiffParentsCache []*externalapi.DomainHash
 }
 
-// New instantiates a new ConsensusStateStore
-func New(utxoSetCacheSize int) model.ConsensusStateStore {
+// New instantiates a new ConsensusStateStore, whose cache of the virtual
+// UTXO set holds up to maxUTXOCacheSize bytes of UTXO entries
+func New(maxUTXOCacheSize uint64) model.ConsensusStateStore {
 	return &consensusStateStore{
-		virtualUTXOSetCache: utxolrucache.New(utxoSetCacheSize),
+		virtualUTXOSetCache: utxolrucache.New(maxUTXOCacheSize),
 	}
 }
 
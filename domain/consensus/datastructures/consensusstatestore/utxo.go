@@ -141,12 +141,24 @@ func (css *consensusStateStore) hasUTXOByOutpointFromStagedVirtualUTXODiff(dbCon
 		}
 	}
 
-	key, err := utxoKey(outpoint)
+	if css.virtualUTXOSetCache.Has(outpoint) {
+		return true, nil
+	}
+
+	// The entry is read rather than only checked for existence, so that
+	// it's cached for the lookup that usually follows
+	_, err := css.utxoByOutpointFromStagedVirtualUTXODiff(dbContext, outpoint)
 	if err != nil {
+		if database.IsNotFoundError(err) {
+			return false, nil
+		}
 		return false, err
 	}
+	return true, nil
+}
 
-	return dbContext.Has(key)
+func (css *consensusStateStore) UTXOCacheStats() *externalapi.UTXOCacheStats {
+	return css.virtualUTXOSetCache.Stats()
 }
 
 func (css *consensusStateStore) VirtualUTXOSetIterator(dbContext model.DBReader) (model.ReadOnlyUTXOSetIterator, error) {
@@ -2,7 +2,6 @@ package consensus
 
 import (
 	"github.com/kaspanet/kaspad/domain/consensus/datastructures/headersselectedchainstore"
-	"io/ioutil"
 	"os"
 	"sync"
 
@@ -40,21 +39,37 @@ import (
 	"github.com/kaspanet/kaspad/domain/consensus/processes/reachabilitymanager"
 	"github.com/kaspanet/kaspad/domain/consensus/processes/syncmanager"
 	"github.com/kaspanet/kaspad/domain/consensus/processes/transactionvalidator"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
 	"github.com/kaspanet/kaspad/domain/dagconfig"
 	infrastructuredatabase "github.com/kaspanet/kaspad/infrastructure/db/database"
 	"github.com/kaspanet/kaspad/infrastructure/db/database/ldb"
+	"github.com/kaspanet/kaspad/infrastructure/db/database/memorydb"
+	"github.com/kaspanet/kaspad/infrastructure/tracing"
 )
 
 // Factory instantiates new Consensuses
 type Factory interface {
-	NewConsensus(dagParams *dagconfig.Params, db infrastructuredatabase.Database, isArchivalNode bool) (
-		externalapi.Consensus, error)
+	NewConsensus(dagParams *dagconfig.Params, db infrastructuredatabase.Database, isArchivalNode bool,
+		maxUTXOCacheSize uint64, sigCache *txscript.SigCache, scriptVerificationWorkers int,
+		tracer *tracing.Tracer) (externalapi.Consensus, error)
 	NewTestConsensus(dagParams *dagconfig.Params, isArchivalNode bool, testName string) (
 		tc testapi.TestConsensus, teardown func(keepDataDir bool), err error)
 	NewTestConsensusWithDataDir(dagParams *dagconfig.Params, dataDir string, isArchivalNode bool) (
 		tc testapi.TestConsensus, teardown func(keepDataDir bool), err error)
 }
 
+// testMaxUTXOCacheSize is the maximum size, in bytes, of the cache of the
+// virtual UTXO set in test consensuses
+const testMaxUTXOCacheSize = 10_000_000
+
+// testSigCacheMaxSize is the maximum number of entries in the signature
+// cache of test consensuses
+const testSigCacheMaxSize = 10_000
+
+// testScriptVerificationWorkers is the number of goroutines that verify
+// transaction scripts in test consensuses. 0 means one per CPU
+const testScriptVerificationWorkers = 0
+
 type factory struct{}
 
 // NewFactory creates a new Consensus factory
@@ -62,9 +77,14 @@ func NewFactory() Factory {
 	return &factory{}
 }
 
-// NewConsensus instantiates a new Consensus
-func (f *factory) NewConsensus(dagParams *dagconfig.Params, db infrastructuredatabase.Database, isArchivalNode bool) (
-	externalapi.Consensus, error) {
+// NewConsensus instantiates a new Consensus. maxUTXOCacheSize is the maximum
+// size, in bytes, of the cache of the virtual UTXO set. sigCache caches the
+// signatures verified by transaction script validation, which is spread
+// across scriptVerificationWorkers goroutines (0 means one per CPU). If tracer
+// isn't nil, the processing of every block is traced with it.
+func (f *factory) NewConsensus(dagParams *dagconfig.Params, db infrastructuredatabase.Database, isArchivalNode bool,
+	maxUTXOCacheSize uint64, sigCache *txscript.SigCache, scriptVerificationWorkers int,
+	tracer *tracing.Tracer) (externalapi.Consensus, error) {
 
 	dbManager := consensusdatabase.New(db)
 
@@ -86,7 +106,7 @@ func (f *factory) NewConsensus(dagParams *dagconfig.Params, db infrastructuredat
 	pruningStore := pruningstore.New()
 	reachabilityDataStore := reachabilitydatastore.New(pruningWindowSizeForCaches)
 	utxoDiffStore := utxodiffstore.New(200)
-	consensusStateStore := consensusstatestore.New(10_000)
+	consensusStateStore := consensusstatestore.New(maxUTXOCacheSize)
 	ghostdagDataStore := ghostdagdatastore.New(pruningWindowSizeForCaches)
 	headersSelectedTipStore := headersselectedtipstore.New()
 	finalityStore := finalitystore.New(200)
@@ -128,7 +148,9 @@ func (f *factory) NewConsensus(dagParams *dagconfig.Params, db infrastructuredat
 		dagParams.MaxCoinbasePayloadLength,
 		dbManager,
 		pastMedianTimeManager,
-		ghostdagDataStore)
+		ghostdagDataStore,
+		sigCache,
+		scriptVerificationWorkers)
 	difficultyManager := difficultymanager.New(
 		dbManager,
 		ghostdagManager,
@@ -193,6 +215,8 @@ func (f *factory) NewConsensus(dagParams *dagconfig.Params, db infrastructuredat
 		blockStatusStore,
 		reachabilityDataStore,
 		consensusStateStore,
+
+		tracer,
 	)
 	consensusStateManager, err := consensusstatemanager.New(
 		dbManager,
@@ -223,7 +247,9 @@ func (f *factory) NewConsensus(dagParams *dagconfig.Params, db infrastructuredat
 		acceptanceDataStore,
 		blockHeaderStore,
 		headersSelectedTipStore,
-		pruningStore)
+		pruningStore,
+
+		tracer)
 	if err != nil {
 		return nil, err
 	}
@@ -305,7 +331,9 @@ func (f *factory) NewConsensus(dagParams *dagconfig.Params, db infrastructuredat
 		blockHeaderStore,
 		headersSelectedTipStore,
 		finalityStore,
-		headersSelectedChainStore)
+		headersSelectedChainStore,
+
+		tracer)
 
 	c := &consensus{
 		lock:            &sync.Mutex{},
@@ -347,26 +375,31 @@ func (f *factory) NewConsensus(dagParams *dagconfig.Params, db infrastructuredat
 
 	genesisInfo, err := c.GetBlockInfo(genesisHash)
 	if err != nil {
+		c.Close()
 		return nil, err
 	}
 
 	if !genesisInfo.Exists {
 		_, err = c.ValidateAndInsertBlock(dagParams.GenesisBlock)
 		if err != nil {
+			c.Close()
 			return nil, err
 		}
 	}
 
 	err = consensusStateManager.RecoverUTXOIfRequired()
 	if err != nil {
+		c.Close()
 		return nil, err
 	}
 	err = pruningManager.ClearImportedPruningPointData()
 	if err != nil {
+		c.Close()
 		return nil, err
 	}
 	err = pruningManager.UpdatePruningPointUTXOSetIfRequired()
 	if err != nil {
+		c.Close()
 		return nil, err
 	}
 
@@ -376,12 +409,20 @@ func (f *factory) NewConsensus(dagParams *dagconfig.Params, db infrastructuredat
 func (f *factory) NewTestConsensus(dagParams *dagconfig.Params, isArchivalNode bool, testName string) (
 	tc testapi.TestConsensus, teardown func(keepDataDir bool), err error) {
 
-	dataDir, err := ioutil.TempDir("", testName)
+	// Test consensuses are kept in memory, so that tests don't have to
+	// write their DAGs to the disk
+	db := memorydb.NewMemoryDB()
+	tc, err = f.newTestConsensusWithDatabase(dagParams, db, isArchivalNode)
 	if err != nil {
+		db.Close()
 		return nil, nil, err
 	}
+	teardown = func(_ bool) {
+		tc.Close()
+		db.Close()
+	}
 
-	return f.NewTestConsensusWithDataDir(dagParams, dataDir, isArchivalNode)
+	return tc, teardown, nil
 }
 
 func (f *factory) NewTestConsensusWithDataDir(dagParams *dagconfig.Params, dataDir string, isArchivalNode bool) (
@@ -391,10 +432,33 @@ func (f *factory) NewTestConsensusWithDataDir(dagParams *dagconfig.Params, dataD
 	if err != nil {
 		return nil, nil, err
 	}
-	consensusAsInterface, err := f.NewConsensus(dagParams, db, isArchivalNode)
+	tc, err = f.newTestConsensusWithDatabase(dagParams, db, isArchivalNode)
 	if err != nil {
+		db.Close()
 		return nil, nil, err
 	}
+	teardown = func(keepDataDir bool) {
+		tc.Close()
+		db.Close()
+		if !keepDataDir {
+			err := os.RemoveAll(dataDir)
+			if err != nil {
+				log.Errorf("Error removing data directory for test consensus: %s", err)
+			}
+		}
+	}
+
+	return tc, teardown, nil
+}
+
+func (f *factory) newTestConsensusWithDatabase(dagParams *dagconfig.Params, db infrastructuredatabase.Database,
+	isArchivalNode bool) (*testConsensus, error) {
+
+	consensusAsInterface, err := f.NewConsensus(dagParams, db, isArchivalNode, testMaxUTXOCacheSize,
+		txscript.NewSigCache(testSigCacheMaxSize), testScriptVerificationWorkers, nil)
+	if err != nil {
+		return nil, err
+	}
 
 	consensusAsImplementation := consensusAsInterface.(*consensus)
 
@@ -412,15 +476,6 @@ func (f *factory) NewTestConsensusWithDataDir(dagParams *dagconfig.Params, dataD
 		testTransactionValidator: testTransactionValidator,
 	}
 	tstConsensus.testBlockBuilder = blockbuilder.NewTestBlockBuilder(consensusAsImplementation.blockBuilder, tstConsensus)
-	teardown = func(keepDataDir bool) {
-		db.Close()
-		if !keepDataDir {
-			err := os.RemoveAll(dataDir)
-			if err != nil {
-				log.Errorf("Error removing data directory for test consensus: %s", err)
-			}
-		}
-	}
 
-	return tstConsensus, teardown, nil
+	return tstConsensus, nil
 }
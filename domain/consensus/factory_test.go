@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
 	"github.com/kaspanet/kaspad/domain/dagconfig"
 	"github.com/kaspanet/kaspad/infrastructure/db/database/ldb"
 )
@@ -23,7 +24,8 @@ func TestNewConsensus(t *testing.T) {
 		t.Fatalf("error in NewLevelDB: %s", err)
 	}
 
-	_, err = f.NewConsensus(dagParams, db, false)
+	_, err = f.NewConsensus(dagParams, db, false, testMaxUTXOCacheSize, txscript.NewSigCache(testSigCacheMaxSize),
+		testScriptVerificationWorkers, nil)
 	if err != nil {
 		t.Fatalf("error in NewConsensus: %+v", err)
 	}
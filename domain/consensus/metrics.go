@@ -0,0 +1,60 @@
+package consensus
+
+import (
+	"time"
+
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/ruleerrors"
+	"github.com/kaspanet/kaspad/infrastructure/metrics"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	blockKindHeader = "header"
+	blockKindBlock  = "block"
+)
+
+var (
+	blocksProcessedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "consensus",
+		Name:      "blocks_processed_total",
+		Help:      "Number of blocks and block headers that were validated and inserted",
+	}, []string{"kind"})
+
+	blocksRejectedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "consensus",
+		Name:      "blocks_rejected_total",
+		Help:      "Number of blocks and block headers that were rejected, by the rule they broke",
+	}, []string{"kind", "rule"})
+
+	validateAndInsertBlockDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "consensus",
+		Name:      "validate_and_insert_block_duration_seconds",
+		Help:      "Time it takes to validate and insert a block or block header",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+	}, []string{"kind"})
+)
+
+// recordValidateAndInsertBlock updates the block processing metrics with
+// the result of a call to ValidateAndInsertBlock that started at start
+func recordValidateAndInsertBlock(block *externalapi.DomainBlock, start time.Time, err error) {
+	kind := blockKindBlock
+	if block.Transactions == nil {
+		kind = blockKindHeader
+	}
+	validateAndInsertBlockDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		blocksProcessedCounter.WithLabelValues(kind).Inc()
+		return
+	}
+	ruleError := ruleerrors.RuleError{}
+	if errors.As(err, &ruleError) {
+		blocksRejectedCounter.WithLabelValues(kind, ruleError.Name()).Inc()
+	}
+}
@@ -0,0 +1,37 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/ruleerrors"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordValidateAndInsertBlock(t *testing.T) {
+	header := &externalapi.DomainBlock{}
+	block := &externalapi.DomainBlock{Transactions: []*externalapi.DomainTransaction{}}
+
+	processedBlocksBefore := testutil.ToFloat64(blocksProcessedCounter.WithLabelValues(blockKindBlock))
+	recordValidateAndInsertBlock(block, time.Now(), nil)
+	processedBlocks := testutil.ToFloat64(blocksProcessedCounter.WithLabelValues(blockKindBlock))
+	if processedBlocks != processedBlocksBefore+1 {
+		t.Fatalf("expected the processed block to be counted")
+	}
+
+	rejectedHeadersBefore := testutil.ToFloat64(blocksRejectedCounter.WithLabelValues(blockKindHeader, "ErrInvalidPoW"))
+	recordValidateAndInsertBlock(header, time.Now(), errors.Wrapf(ruleerrors.ErrInvalidPoW, "invalid"))
+	rejectedHeaders := testutil.ToFloat64(blocksRejectedCounter.WithLabelValues(blockKindHeader, "ErrInvalidPoW"))
+	if rejectedHeaders != rejectedHeadersBefore+1 {
+		t.Fatalf("expected the rejected header to be counted by the rule it broke")
+	}
+
+	// Errors that aren't rule errors aren't rejections
+	rejectionKindsBefore := testutil.CollectAndCount(blocksRejectedCounter)
+	recordValidateAndInsertBlock(header, time.Now(), errors.New("database error"))
+	if testutil.CollectAndCount(blocksRejectedCounter) != rejectionKindsBefore {
+		t.Fatalf("expected an error that isn't a rule error not to be counted as a rejection")
+	}
+}
@@ -29,4 +29,6 @@ type Consensus interface {
 	GetVirtualSelectedParentChainFromBlock(blockHash *DomainHash) (*SelectedChainPath, error)
 	IsInSelectedParentChainOf(blockHashA *DomainHash, blockHashB *DomainHash) (bool, error)
 	GetHeadersSelectedTip() (*DomainHash, error)
+	GetUTXOCacheStats() *UTXOCacheStats
+	Close()
 }
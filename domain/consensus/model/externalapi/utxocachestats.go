@@ -0,0 +1,16 @@
+package externalapi
+
+// UTXOCacheStats describes the usage of the cache of the virtual UTXO set
+type UTXOCacheStats struct {
+	EntryCount uint64
+
+	// Size is the estimated number of bytes the cached entries take,
+	// which is kept within MaxSize
+	Size    uint64
+	MaxSize uint64
+
+	// Hits and Misses count the lookups that were served by the cache,
+	// and the ones that had to read from the database
+	Hits   uint64
+	Misses uint64
+}
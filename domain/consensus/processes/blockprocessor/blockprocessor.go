@@ -3,7 +3,9 @@ package blockprocessor
 import (
 	"github.com/kaspanet/kaspad/domain/consensus/model"
 	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
 	"github.com/kaspanet/kaspad/infrastructure/logger"
+	"github.com/kaspanet/kaspad/infrastructure/tracing"
 	"time"
 )
 
@@ -41,6 +43,8 @@ type blockProcessor struct {
 	finalityStore             model.FinalityStore
 	headersSelectedChainStore model.HeadersSelectedChainStore
 
+	tracer *tracing.Tracer
+
 	stores []model.Store
 }
 
@@ -75,6 +79,8 @@ func New(
 	headersSelectedTipStore model.HeaderSelectedTipStore,
 	finalityStore model.FinalityStore,
 	headersSelectedChainStore model.HeadersSelectedChainStore,
+
+	tracer *tracing.Tracer,
 ) model.BlockProcessor {
 
 	return &blockProcessor{
@@ -108,6 +114,8 @@ func New(
 		finalityStore:             finalityStore,
 		headersSelectedChainStore: headersSelectedChainStore,
 
+		tracer: tracer,
+
 		stores: []model.Store{
 			consensusStateStore,
 			acceptanceDataStore,
@@ -134,7 +142,30 @@ func (bp *blockProcessor) ValidateAndInsertBlock(block *externalapi.DomainBlock)
 	onEnd := logger.LogAndMeasureExecutionTime(log, "ValidateAndInsertBlock")
 	defer onEnd()
 
-	return bp.validateAndInsertBlock(block, false)
+	span := bp.startBlockTrace("ValidateAndInsertBlock", block)
+	defer span.End()
+
+	blockInsertionResult, err := bp.validateAndInsertBlock(block, false)
+	span.SetError(err)
+	return blockInsertionResult, err
+}
+
+// startBlockTrace starts a trace of the processing of the given block, whose
+// ID is derived from the block hash
+func (bp *blockProcessor) startBlockTrace(name string, block *externalapi.DomainBlock) *tracing.Span {
+	if bp.tracer == nil {
+		return nil
+	}
+
+	blockHash := consensushashing.BlockHash(block)
+	span := bp.tracer.StartTrace(name, tracing.NewTraceID(blockHash.ByteSlice()))
+	span.SetAttribute("block.hash", blockHash.String())
+	if isHeaderOnlyBlock(block) {
+		span.SetAttribute("block.kind", "header")
+	} else {
+		span.SetAttribute("block.kind", "block")
+	}
+	return span
 }
 
 func (bp *blockProcessor) ValidateAndInsertImportedPruningPoint(newPruningPoint *externalapi.DomainBlock) error {
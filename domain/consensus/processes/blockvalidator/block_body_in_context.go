@@ -17,6 +17,9 @@ func (v *blockValidator) ValidateBodyInContext(blockHash *externalapi.DomainHash
 	onEnd := logger.LogAndMeasureExecutionTime(log, "ValidateBodyInContext")
 	defer onEnd()
 
+	span := v.tracer.StartSpan("ValidateBodyInContext")
+	defer span.End()
+
 	err := v.checkBlockIsNotPruned(blockHash)
 	if err != nil {
 		return err
@@ -18,6 +18,9 @@ func (v *blockValidator) ValidateBodyInIsolation(blockHash *externalapi.DomainHa
 	onEnd := logger.LogAndMeasureExecutionTime(log, "ValidateBodyInContext")
 	defer onEnd()
 
+	span := v.tracer.StartSpan("ValidateBodyInIsolation")
+	defer span.End()
+
 	block, err := v.blockStore.Block(v.databaseContext, blockHash)
 	if err != nil {
 		return err
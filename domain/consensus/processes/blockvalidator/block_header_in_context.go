@@ -16,6 +16,9 @@ func (v *blockValidator) ValidateHeaderInContext(blockHash *externalapi.DomainHa
 	onEnd := logger.LogAndMeasureExecutionTime(log, "ValidateHeaderInContext")
 	defer onEnd()
 
+	span := v.tracer.StartSpan("ValidateHeaderInContext")
+	defer span.End()
+
 	header, err := v.blockHeaderStore.BlockHeader(v.databaseContext, blockHash)
 	if err != nil {
 		return err
@@ -27,7 +30,9 @@ func (v *blockValidator) ValidateHeaderInContext(blockHash *externalapi.DomainHa
 	}
 
 	if !hasValidatedHeader {
+		ghostdagSpan := v.tracer.StartSpan("GHOSTDAG")
 		err = v.ghostdagManager.GHOSTDAG(blockHash)
+		ghostdagSpan.End()
 		if err != nil {
 			return err
 		}
@@ -67,7 +72,9 @@ func (v *blockValidator) ValidateHeaderInContext(blockHash *externalapi.DomainHa
 		return err
 	}
 	if !hasReachabilityData {
+		reachabilitySpan := v.tracer.StartSpan("reachability.AddBlock")
 		err = v.reachabilityManager.AddBlock(blockHash)
+		reachabilitySpan.End()
 		if err != nil {
 			return err
 		}
@@ -16,6 +16,9 @@ func (v *blockValidator) ValidateHeaderInIsolation(blockHash *externalapi.Domain
 	onEnd := logger.LogAndMeasureExecutionTime(log, "ValidateHeaderInIsolation")
 	defer onEnd()
 
+	span := v.tracer.StartSpan("ValidateHeaderInIsolation")
+	defer span.End()
+
 	header, err := v.blockHeaderStore.BlockHeader(v.databaseContext, blockHash)
 	if err != nil {
 		return err
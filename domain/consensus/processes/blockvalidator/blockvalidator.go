@@ -7,6 +7,7 @@ import (
 
 	"github.com/kaspanet/kaspad/domain/consensus/model"
 	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/infrastructure/tracing"
 )
 
 // blockValidator exposes a set of validation classes, after which
@@ -41,6 +42,8 @@ type blockValidator struct {
 	blockStatusStore    model.BlockStatusStore
 	reachabilityStore   model.ReachabilityDataStore
 	consensusStateStore model.ConsensusStateStore
+
+	tracer *tracing.Tracer
 }
 
 // New instantiates a new BlockValidator
@@ -74,6 +77,8 @@ func New(powMax *big.Int,
 	blockStatusStore model.BlockStatusStore,
 	reachabilityStore model.ReachabilityDataStore,
 	consensusStateStore model.ConsensusStateStore,
+
+	tracer *tracing.Tracer,
 ) model.BlockValidator {
 
 	return &blockValidator{
@@ -106,5 +111,7 @@ func New(powMax *big.Int,
 		blockStatusStore:    blockStatusStore,
 		reachabilityStore:   reachabilityStore,
 		consensusStateStore: consensusStateStore,
+
+		tracer: tracer,
 	}
 }
@@ -15,6 +15,9 @@ func (v *blockValidator) ValidatePruningPointViolationAndProofOfWorkAndDifficult
 	onEnd := logger.LogAndMeasureExecutionTime(log, "ValidatePruningPointViolationAndProofOfWorkAndDifficulty")
 	defer onEnd()
 
+	span := v.tracer.StartSpan("ValidatePruningPointViolationAndProofOfWorkAndDifficulty")
+	defer span.End()
+
 	header, err := v.blockHeaderStore.BlockHeader(v.databaseContext, blockHash)
 	if err != nil {
 		return err
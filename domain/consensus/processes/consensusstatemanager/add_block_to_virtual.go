@@ -13,6 +13,9 @@ func (csm *consensusStateManager) AddBlock(blockHash *externalapi.DomainHash) (*
 	onEnd := logger.LogAndMeasureExecutionTime(log, "csm.AddBlock")
 	defer onEnd()
 
+	span := csm.tracer.StartSpan("csm.AddBlock")
+	defer span.End()
+
 	log.Debugf("Resolving whether the block %s is the next virtual selected parent", blockHash)
 	isCandidateToBeNextVirtualSelectedParent, err := csm.isCandidateToBeNextVirtualSelectedParent(blockHash)
 	if err != nil {
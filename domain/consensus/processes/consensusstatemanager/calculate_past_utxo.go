@@ -140,6 +140,12 @@ func (csm *consensusStateManager) applyMergeSetBlocks(blockHash *externalapi.Dom
 		isSelectedParent := i == 0
 		log.Tracef("Is merge set block %s the selected parent: %t", mergeSetBlockHash, isSelectedParent)
 
+		prevalidationResults, err := csm.prevalidateTransactions(mergeSetBlock.Transactions, blockHash,
+			accumulatedUTXODiff.ToImmutable(), selectedParentMedianTime)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		for j, transaction := range mergeSetBlock.Transactions {
 			var isAccepted bool
 
@@ -148,7 +154,8 @@ func (csm *consensusStateManager) applyMergeSetBlocks(blockHash *externalapi.Dom
 				transactionID, mergeSetBlockHash)
 
 			isAccepted, accumulatedMass, err = csm.maybeAcceptTransaction(transaction, blockHash, isSelectedParent,
-				accumulatedUTXODiff, accumulatedMass, selectedParentMedianTime, ghostdagData.BlueScore())
+				accumulatedUTXODiff, accumulatedMass, selectedParentMedianTime, ghostdagData.BlueScore(),
+				prevalidationResults)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -176,9 +183,78 @@ func (csm *consensusStateManager) applyMergeSetBlocks(blockHash *externalapi.Dom
 	return multiblockAcceptanceData, accumulatedUTXODiff, nil
 }
 
+// prevalidationResult is the result of validating a transaction ahead of the
+// point in its block at which it's accepted
+type prevalidationResult struct {
+	err  error
+	fee  uint64
+	mass uint64
+}
+
+// prevalidateTransactions validates, as a single batch, the transactions of a
+// merge set block whose inputs are all in the given accumulated UTXO diff or in
+// the virtual UTXO set, before any of the block's transactions is accepted. A
+// transaction whose inputs are all still there once it's reached spends the
+// very same UTXO entries, so its result holds then as well. The UTXO entries
+// and the fees and masses are taken off the transactions afterwards, since
+// earlier transactions of the block may spend the same outpoints. It returns
+// the results of the validated transactions.
+func (csm *consensusStateManager) prevalidateTransactions(transactions []*externalapi.DomainTransaction,
+	blockHash *externalapi.DomainHash, accumulatedUTXODiff model.UTXODiff, selectedParentPastMedianTime int64) (
+	map[*externalapi.DomainTransaction]*prevalidationResult, error) {
+
+	var populatedInputs []*externalapi.DomainTransactionInput
+	feesBefore := make(map[*externalapi.DomainTransaction]uint64, len(transactions))
+	massesBefore := make(map[*externalapi.DomainTransaction]uint64, len(transactions))
+	defer func() {
+		for _, input := range populatedInputs {
+			input.UTXOEntry = nil
+		}
+		for transaction, fee := range feesBefore {
+			transaction.Fee = fee
+			transaction.Mass = massesBefore[transaction]
+		}
+	}()
+
+	transactionsToValidate := make([]*externalapi.DomainTransaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		if transactionhelper.IsCoinBase(transaction) {
+			continue
+		}
+		for _, input := range transaction.Inputs {
+			if input.UTXOEntry == nil {
+				populatedInputs = append(populatedInputs, input)
+			}
+		}
+		err := csm.populateTransactionWithUTXOEntriesFromVirtualOrDiff(transaction, accumulatedUTXODiff)
+		if err != nil {
+			if !errors.As(err, &(ruleerrors.RuleError{})) {
+				return nil, err
+			}
+			continue
+		}
+		transactionsToValidate = append(transactionsToValidate, transaction)
+		feesBefore[transaction] = transaction.Fee
+		massesBefore[transaction] = transaction.Mass
+	}
+
+	transactionErrors := csm.transactionValidator.ValidateTransactionsInContextAndPopulateMassAndFee(
+		transactionsToValidate, blockHash, selectedParentPastMedianTime)
+	prevalidationResults := make(map[*externalapi.DomainTransaction]*prevalidationResult, len(transactionsToValidate))
+	for i, transaction := range transactionsToValidate {
+		prevalidationResults[transaction] = &prevalidationResult{
+			err:  transactionErrors[i],
+			fee:  transaction.Fee,
+			mass: transaction.Mass,
+		}
+	}
+	return prevalidationResults, nil
+}
+
 func (csm *consensusStateManager) maybeAcceptTransaction(transaction *externalapi.DomainTransaction,
 	blockHash *externalapi.DomainHash, isSelectedParent bool, accumulatedUTXODiff model.MutableUTXODiff,
-	accumulatedMassBefore uint64, selectedParentPastMedianTime int64, blockBlueScore uint64) (
+	accumulatedMassBefore uint64, selectedParentPastMedianTime int64, blockBlueScore uint64,
+	prevalidationResults map[*externalapi.DomainTransaction]*prevalidationResult) (
 	isAccepted bool, accumulatedMassAfter uint64, err error) {
 
 	transactionID := consensushashing.TransactionID(transaction)
@@ -206,8 +282,14 @@ func (csm *consensusStateManager) maybeAcceptTransaction(transaction *externalap
 		log.Tracef("Transaction %s is the coinbase of block %s", transactionID, blockHash)
 	} else {
 		log.Tracef("Validating transaction %s in block %s", transactionID, blockHash)
-		err = csm.transactionValidator.ValidateTransactionInContextAndPopulateMassAndFee(
-			transaction, blockHash, selectedParentPastMedianTime)
+		if result, ok := prevalidationResults[transaction]; ok {
+			err = result.err
+			transaction.Fee = result.fee
+			transaction.Mass = result.mass
+		} else {
+			err = csm.transactionValidator.ValidateTransactionInContextAndPopulateMassAndFee(
+				transaction, blockHash, selectedParentPastMedianTime)
+		}
 		if err != nil {
 			if !errors.As(err, &(ruleerrors.RuleError{})) {
 				return false, 0, err
@@ -3,6 +3,7 @@ package consensusstatemanager
 import (
 	"github.com/kaspanet/kaspad/domain/consensus/model"
 	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/infrastructure/tracing"
 )
 
 // consensusStateManager manages the node's consensus state
@@ -37,6 +38,8 @@ type consensusStateManager struct {
 	blockHeaderStore        model.BlockHeaderStore
 	pruningStore            model.PruningStore
 
+	tracer *tracing.Tracer
+
 	stores []model.Store
 }
 
@@ -70,7 +73,9 @@ func New(
 	acceptanceDataStore model.AcceptanceDataStore,
 	blockHeaderStore model.BlockHeaderStore,
 	headersSelectedTipStore model.HeaderSelectedTipStore,
-	pruningStore model.PruningStore) (model.ConsensusStateManager, error) {
+	pruningStore model.PruningStore,
+
+	tracer *tracing.Tracer) (model.ConsensusStateManager, error) {
 
 	csm := &consensusStateManager{
 		pruningDepth:           pruningDepth,
@@ -103,6 +108,8 @@ func New(
 		headersSelectedTipStore: headersSelectedTipStore,
 		pruningStore:            pruningStore,
 
+		tracer: tracer,
+
 		stores: []model.Store{
 			consensusStateStore,
 			acceptanceDataStore,
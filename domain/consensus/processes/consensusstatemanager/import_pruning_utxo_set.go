@@ -113,22 +113,18 @@ func (csm *consensusStateManager) importPruningPoint(newPruningPoint *externalap
 	log.Tracef("The past median time of pruning block %s is %d",
 		newPruningPointHash, newPruningPointSelectedParentMedianTime)
 
+	transactions := make([]*externalapi.DomainTransaction, 0, len(newPruningPointClone.Transactions))
 	for i, transaction := range newPruningPointClone.Transactions {
-		transactionID := consensushashing.TransactionID(transaction)
-		log.Tracef("Validating transaction %s in pruning block %s against "+
-			"the pruning point's past UTXO", transactionID, newPruningPointHash)
 		if i == transactionhelper.CoinbaseTransactionIndex {
-			log.Tracef("Skipping transaction %s because it is the coinbase", transactionID)
+			log.Tracef("Skipping transaction %s because it is the coinbase",
+				consensushashing.TransactionID(transaction))
 			continue
 		}
-		log.Tracef("Validating transaction %s and populating it with mass and fee", transactionID)
-		err = csm.transactionValidator.ValidateTransactionInContextAndPopulateMassAndFee(
-			transaction, newPruningPointHash, newPruningPointSelectedParentMedianTime)
-		if err != nil {
-			return err
-		}
-		log.Tracef("Validation against the pruning point's past UTXO "+
-			"passed for transaction %s", transactionID)
+		transactions = append(transactions, transaction)
+	}
+	err = csm.validateTransactionsInContext(transactions, newPruningPointHash, newPruningPointSelectedParentMedianTime)
+	if err != nil {
+		return err
 	}
 
 	log.Debugf("Staging the new pruning point as %s", externalapi.StatusUTXOValid)
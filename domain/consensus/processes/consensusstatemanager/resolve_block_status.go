@@ -11,6 +11,9 @@ func (csm *consensusStateManager) resolveBlockStatus(blockHash *externalapi.Doma
 	log.Debugf("resolveBlockStatus start for block %s", blockHash)
 	defer log.Debugf("resolveBlockStatus end for block %s", blockHash)
 
+	span := csm.tracer.StartSpan("resolveBlockStatus")
+	defer span.End()
+
 	log.Debugf("Getting a list of all blocks in the selected "+
 		"parent chain of %s that have no yet resolved their status", blockHash)
 	unverifiedBlocks, err := csm.getUnverifiedChainBlocks(blockHash)
@@ -11,6 +11,9 @@ func (csm *consensusStateManager) updateVirtual(newBlockHash *externalapi.Domain
 	log.Debugf("updateVirtual start for block %s", newBlockHash)
 	defer log.Debugf("updateVirtual end for block %s", newBlockHash)
 
+	span := csm.tracer.StartSpan("updateVirtual")
+	defer span.End()
+
 	log.Debugf("Saving a reference to the GHOSTDAG data of the old virtual")
 	var oldVirtualSelectedParent *externalapi.DomainHash
 	if !newBlockHash.Equal(csm.genesisHash) {
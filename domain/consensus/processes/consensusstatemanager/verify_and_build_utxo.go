@@ -69,29 +69,50 @@ func (csm *consensusStateManager) validateBlockTransactionsAgainstPastUTXO(block
 	}
 	log.Tracef("The past median time of %s is %d", blockHash, selectedParentMedianTime)
 
+	transactions := make([]*externalapi.DomainTransaction, 0, len(block.Transactions))
+	var populateErr error
 	for i, transaction := range block.Transactions {
 		transactionID := consensushashing.TransactionID(transaction)
-		log.Tracef("Validating transaction %s in block %s against "+
-			"the block's past UTXO", transactionID, blockHash)
 		if i == transactionhelper.CoinbaseTransactionIndex {
 			log.Tracef("Skipping transaction %s because it is the coinbase", transactionID)
 			continue
 		}
 
 		log.Tracef("Populating transaction %s with UTXO entries", transactionID)
-		err = csm.populateTransactionWithUTXOEntriesFromVirtualOrDiff(transaction, pastUTXODiff)
-		if err != nil {
-			return err
+		populateErr = csm.populateTransactionWithUTXOEntriesFromVirtualOrDiff(transaction, pastUTXODiff)
+		if populateErr != nil {
+			break
 		}
+		transactions = append(transactions, transaction)
+	}
+
+	// The transactions before the one that couldn't be populated are
+	// validated first, so that the error of the first invalid transaction
+	// is the one that's returned
+	err = csm.validateTransactionsInContext(transactions, blockHash, selectedParentMedianTime)
+	if err != nil {
+		return err
+	}
+	return populateErr
+}
 
-		log.Tracef("Validating transaction %s and populating it with mass and fee", transactionID)
-		err = csm.transactionValidator.ValidateTransactionInContextAndPopulateMassAndFee(
-			transaction, blockHash, selectedParentMedianTime)
+// validateTransactionsInContext validates the given transactions of the given
+// block, whose inputs are already populated with their UTXO entries, and
+// populates them with their mass and fee. Their input scripts are verified as
+// a single batch. It returns the error of the first invalid transaction.
+func (csm *consensusStateManager) validateTransactionsInContext(transactions []*externalapi.DomainTransaction,
+	blockHash *externalapi.DomainHash, selectedParentMedianTime int64) error {
+
+	log.Tracef("Validating %d transactions in block %s and populating them with mass and fee",
+		len(transactions), blockHash)
+	transactionErrors := csm.transactionValidator.ValidateTransactionsInContextAndPopulateMassAndFee(
+		transactions, blockHash, selectedParentMedianTime)
+	for i, err := range transactionErrors {
 		if err != nil {
 			return err
 		}
-		log.Tracef("Validation against the block's past UTXO "+
-			"passed for transaction %s in block %s", transactionID, blockHash)
+		log.Tracef("Validation passed for transaction %s in block %s",
+			consensushashing.TransactionID(transactions[i]), blockHash)
 	}
 	return nil
 }
@@ -0,0 +1,111 @@
+package transactionvalidator_test
+
+import (
+	"testing"
+
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/kaspanet/kaspad/domain/consensus"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/constants"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/subnetworks"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/utxo"
+	"github.com/kaspanet/kaspad/domain/dagconfig"
+	"github.com/kaspanet/kaspad/util"
+)
+
+// createBlockTransactions creates count transactions with one or two
+// pay-to-pubkey-hash inputs each, with all their inputs signed and populated
+// with their UTXO entries
+func createBlockTransactions(b *testing.B, count int) []*externalapi.DomainTransaction {
+	privateKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		b.Fatalf("GeneratePrivateKey: %s", err)
+	}
+	publicKey, err := privateKey.SchnorrPublicKey()
+	if err != nil {
+		b.Fatalf("SchnorrPublicKey: %s", err)
+	}
+	serializedPublicKey, err := publicKey.Serialize()
+	if err != nil {
+		b.Fatalf("Serialize: %s", err)
+	}
+	address, err := util.NewAddressPubKeyHash(util.Hash160(serializedPublicKey[:]), util.Bech32PrefixKaspaSim)
+	if err != nil {
+		b.Fatalf("NewAddressPubKeyHash: %s", err)
+	}
+	scriptPublicKey, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		b.Fatalf("PayToAddrScript: %s", err)
+	}
+
+	txs := make([]*externalapi.DomainTransaction, count)
+	for i := range txs {
+		inputs := make([]*externalapi.DomainTransactionInput, i%2+1)
+		for j := range inputs {
+			inputs[j] = &externalapi.DomainTransactionInput{
+				PreviousOutpoint: externalapi.DomainOutpoint{Index: uint32(2*i + j)},
+				Sequence:         constants.MaxTxInSequenceNum,
+				UTXOEntry:        utxo.NewUTXOEntry(1000, scriptPublicKey, false, 0),
+			}
+		}
+		tx := &externalapi.DomainTransaction{
+			Version: constants.MaxTransactionVersion,
+			Inputs:  inputs,
+			Outputs: []*externalapi.DomainTransactionOutput{{
+				Value:           uint64(len(inputs))*1000 - 100,
+				ScriptPublicKey: scriptPublicKey,
+			}},
+			SubnetworkID: subnetworks.SubnetworkIDNative,
+		}
+		for j, input := range tx.Inputs {
+			input.SignatureScript, err = txscript.SignatureScript(tx, j, scriptPublicKey, txscript.SigHashAll, privateKey)
+			if err != nil {
+				b.Fatalf("SignatureScript: %s", err)
+			}
+		}
+		txs[i] = tx
+	}
+	return txs
+}
+
+// BenchmarkValidateBlockTransactions compares validating a block's worth of
+// transactions with one or two inputs each one transaction at a time, with
+// validating them as a single batch
+func BenchmarkValidateBlockTransactions(b *testing.B) {
+	params := dagconfig.SimnetParams
+	tc, teardown, err := consensus.NewFactory().NewTestConsensus(&params, false, "BenchmarkValidateBlockTransactions")
+	if err != nil {
+		b.Fatalf("Error setting up consensus: %+v", err)
+	}
+	defer teardown(false)
+
+	validator := tc.TransactionValidator()
+	validator.SetSigCache(txscript.NewSigCache(0))
+	txs := createBlockTransactions(b, 1000)
+	selectedParentMedianTime := params.GenesisBlock.Header.TimeInMilliseconds() + 1
+
+	b.Run("one at a time", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, tx := range txs {
+				err := validator.ValidateTransactionInContextAndPopulateMassAndFee(tx, params.GenesisHash,
+					selectedParentMedianTime)
+				if err != nil {
+					b.Fatalf("ValidateTransactionInContextAndPopulateMassAndFee: %s", err)
+				}
+			}
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			txErrors := validator.ValidateTransactionsInContextAndPopulateMassAndFee(txs, params.GenesisHash,
+				selectedParentMedianTime)
+			for _, err := range txErrors {
+				if err != nil {
+					b.Fatalf("ValidateTransactionsInContextAndPopulateMassAndFee: %s", err)
+				}
+			}
+		}
+	})
+}
@@ -1,6 +1,9 @@
 package transactionvalidator
 
 import (
+	"sync"
+	"sync/atomic"
+
 	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
 	"github.com/kaspanet/kaspad/domain/consensus/ruleerrors"
 	"github.com/kaspanet/kaspad/domain/consensus/utils/constants"
@@ -16,39 +19,70 @@ import (
 func (v *transactionValidator) ValidateTransactionInContextAndPopulateMassAndFee(tx *externalapi.DomainTransaction,
 	povBlockHash *externalapi.DomainHash, selectedParentMedianTime int64) error {
 
-	err := v.checkTransactionCoinbaseMaturity(povBlockHash, tx)
-	if err != nil {
-		return err
-	}
+	return v.ValidateTransactionsInContextAndPopulateMassAndFee(
+		[]*externalapi.DomainTransaction{tx}, povBlockHash, selectedParentMedianTime)[0]
+}
 
-	totalSompiIn, err := v.checkTransactionInputAmounts(tx)
-	if err != nil {
-		return err
+// ValidateTransactionsInContextAndPopulateMassAndFee validates each of the given transactions as
+// ValidateTransactionInContextAndPopulateMassAndFee does, and returns the error of every transaction,
+// which is nil if it's valid. The input scripts of all the transactions are verified as a single batch,
+// so that the script verification workers are kept busy even by transactions with few inputs.
+func (v *transactionValidator) ValidateTransactionsInContextAndPopulateMassAndFee(txs []*externalapi.DomainTransaction,
+	povBlockHash *externalapi.DomainHash, selectedParentMedianTime int64) []error {
+
+	txErrors := make([]error, len(txs))
+	txsToVerify := make([]*externalapi.DomainTransaction, 0, len(txs))
+	txsToVerifyIndexes := make([]int, 0, len(txs))
+	for i, tx := range txs {
+		err := v.validateTransactionInContextExceptScripts(tx, povBlockHash, selectedParentMedianTime)
+		if err != nil {
+			txErrors[i] = err
+			continue
+		}
+		txsToVerify = append(txsToVerify, tx)
+		txsToVerifyIndexes = append(txsToVerifyIndexes, i)
 	}
 
-	totalSompiOut, err := v.checkTransactionOutputAmounts(tx, totalSompiIn)
-	if err != nil {
-		return err
+	scriptErrors := v.validateTransactionsScripts(txsToVerify)
+	for i, tx := range txsToVerify {
+		txIndex := txsToVerifyIndexes[i]
+		if scriptErrors[i] != nil {
+			txErrors[txIndex] = scriptErrors[i]
+			continue
+		}
+		var err error
+		tx.Mass, err = v.transactionMass(tx)
+		if err != nil {
+			txErrors[txIndex] = err
+		}
 	}
+	return txErrors
+}
 
-	tx.Fee = totalSompiIn - totalSompiOut
+// validateTransactionInContextExceptScripts runs the checks of
+// ValidateTransactionInContextAndPopulateMassAndFee that come before the
+// input scripts are verified, and populates the fee of the transaction
+func (v *transactionValidator) validateTransactionInContextExceptScripts(tx *externalapi.DomainTransaction,
+	povBlockHash *externalapi.DomainHash, selectedParentMedianTime int64) error {
 
-	err = v.checkTransactionSequenceLock(povBlockHash, tx, selectedParentMedianTime)
+	err := v.checkTransactionCoinbaseMaturity(povBlockHash, tx)
 	if err != nil {
 		return err
 	}
 
-	err = v.validateTransactionScripts(tx)
+	totalSompiIn, err := v.checkTransactionInputAmounts(tx)
 	if err != nil {
 		return err
 	}
 
-	tx.Mass, err = v.transactionMass(tx)
+	totalSompiOut, err := v.checkTransactionOutputAmounts(tx, totalSompiIn)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	tx.Fee = totalSompiIn - totalSompiOut
+
+	return v.checkTransactionSequenceLock(povBlockHash, tx, selectedParentMedianTime)
 }
 
 func (v *transactionValidator) checkTransactionCoinbaseMaturity(
@@ -175,42 +209,150 @@ func (v *transactionValidator) checkTransactionSequenceLock(povBlockHash *extern
 	return nil
 }
 
+// validateTransactionScripts verifies the input scripts of the given transaction
+// on the validator's script verification workers. If several inputs fail, the
+// error of the one with the lowest index is returned, regardless of the order in
+// which they were verified.
 func (v *transactionValidator) validateTransactionScripts(tx *externalapi.DomainTransaction) error {
+	return v.validateTransactionsScripts([]*externalapi.DomainTransaction{tx})[0]
+}
 
-	var missingOutpoints []*externalapi.DomainOutpoint
-	for i, input := range tx.Inputs {
-		// Create a new script engine for the script pair.
-		sigScript := input.SignatureScript
-		utxoEntry := input.UTXOEntry
-		if utxoEntry == nil {
-			missingOutpoints = append(missingOutpoints, &input.PreviousOutpoint)
-			continue
+// validateTransactionsScripts verifies the input scripts of the given
+// transactions as validateTransactionScripts does, and returns the error of
+// every transaction. The inputs of all the transactions are handed out to the
+// workers before waiting for any of them, so that a block of transactions with
+// few inputs each keeps all the workers busy.
+func (v *transactionValidator) validateTransactionsScripts(txs []*externalapi.DomainTransaction) []error {
+	txErrors := make([]error, len(txs))
+	if atomic.LoadUint32(&v.isClosed) != 0 {
+		for i := range txErrors {
+			txErrors[i] = errClosed
+		}
+		return txErrors
+	}
+	v.startScriptVerificationWorkersOnce.Do(v.startScriptVerificationWorkers)
+
+	verifications := make([]*scriptVerification, len(txs))
+	for i, tx := range txs {
+		verification := &scriptVerification{
+			tx:               tx,
+			firstFailedIndex: int64(len(tx.Inputs)),
+			inputErrors:      make([]error, len(tx.Inputs)),
+		}
+		verifications[i] = verification
+		for inputIndex, input := range tx.Inputs {
+			if input.UTXOEntry == nil {
+				verification.missingOutpoints = append(verification.missingOutpoints, &input.PreviousOutpoint)
+				continue
+			}
+			// Inputs above one that already failed can't change the
+			// result, so they aren't even handed out
+			if int64(inputIndex) > atomic.LoadInt64(&verification.firstFailedIndex) {
+				break
+			}
+			verification.waitGroup.Add(1)
+			v.scriptVerificationJobs <- &scriptVerificationJob{verification: verification, inputIndex: inputIndex}
 		}
+	}
 
-		scriptPubKey := utxoEntry.ScriptPublicKey()
-		vm, err := txscript.NewEngine(scriptPubKey, tx,
-			i, txscript.ScriptNoFlags, v.sigCache)
-		if err != nil {
-			return errors.Wrapf(ruleerrors.ErrScriptMalformed, "failed to parse input "+
-				"%d which references output %s - "+
-				"%s (input script bytes %x, prev "+
-				"output script bytes %x)",
-				i,
-				input.PreviousOutpoint, err, sigScript, scriptPubKey)
+	for i, verification := range verifications {
+		verification.waitGroup.Wait()
+
+		firstFailedIndex := verification.firstFailedIndex
+		if firstFailedIndex < int64(len(verification.tx.Inputs)) {
+			txErrors[i] = verification.inputErrors[firstFailedIndex]
+			continue
 		}
+		if len(verification.missingOutpoints) > 0 {
+			txErrors[i] = ruleerrors.NewErrMissingTxOut(verification.missingOutpoints)
+		}
+	}
+	return txErrors
+}
+
+// errClosed is returned when scripts are verified by a validator that was closed
+var errClosed = errors.New("the transaction validator is closed")
+
+// scriptVerification is the state shared by the jobs that verify the input
+// scripts of a single transaction
+type scriptVerification struct {
+	tx        *externalapi.DomainTransaction
+	waitGroup sync.WaitGroup
+
+	// firstFailedIndex is the lowest index of an input that failed so far,
+	// or the number of inputs if none did. It's only ever lowered, with
+	// compare-and-swap, so inputs below it are always verified.
+	firstFailedIndex int64
+	inputErrors      []error
+	missingOutpoints []*externalapi.DomainOutpoint
+}
+
+type scriptVerificationJob struct {
+	verification *scriptVerification
+	inputIndex   int
+}
+
+// startScriptVerificationWorkers starts the goroutines that verify input
+// scripts. They're shared by all the transactions the validator verifies,
+// whether they come from the same block or not, and live until it's closed.
+func (v *transactionValidator) startScriptVerificationWorkers() {
+	v.scriptVerificationJobs = make(chan *scriptVerificationJob)
+	for i := 0; i < v.scriptVerificationWorkers; i++ {
+		spawn("transactionValidator-scriptVerificationWorker", func() {
+			for job := range v.scriptVerificationJobs {
+				v.runScriptVerificationJob(job)
+			}
+		})
+	}
+}
+
+func (v *transactionValidator) runScriptVerificationJob(job *scriptVerificationJob) {
+	verification := job.verification
+	defer verification.waitGroup.Done()
 
-		// Execute the script pair.
-		if err := vm.Execute(); err != nil {
-			return errors.Wrapf(ruleerrors.ErrScriptValidation, "failed to validate input "+
-				"%d which references output %s - "+
-				"%s (input script bytes %x, prev output "+
-				"script bytes %x)",
-				i,
-				input.PreviousOutpoint, err, sigScript, scriptPubKey)
+	inputIndex := int64(job.inputIndex)
+	if inputIndex > atomic.LoadInt64(&verification.firstFailedIndex) {
+		return
+	}
+	err := v.validateInputScript(verification.tx, job.inputIndex)
+	if err == nil {
+		return
+	}
+	verification.inputErrors[inputIndex] = err
+	for {
+		firstFailedIndex := atomic.LoadInt64(&verification.firstFailedIndex)
+		if inputIndex >= firstFailedIndex ||
+			atomic.CompareAndSwapInt64(&verification.firstFailedIndex, firstFailedIndex, inputIndex) {
+			return
 		}
 	}
-	if len(missingOutpoints) > 0 {
-		return ruleerrors.NewErrMissingTxOut(missingOutpoints)
+}
+
+func (v *transactionValidator) validateInputScript(tx *externalapi.DomainTransaction, inputIndex int) error {
+	input := tx.Inputs[inputIndex]
+
+	// Create a new script engine for the script pair.
+	sigScript := input.SignatureScript
+	scriptPubKey := input.UTXOEntry.ScriptPublicKey()
+	vm, err := txscript.NewEngine(scriptPubKey, tx,
+		inputIndex, txscript.ScriptNoFlags, v.sigCache)
+	if err != nil {
+		return errors.Wrapf(ruleerrors.ErrScriptMalformed, "failed to parse input "+
+			"%d which references output %s - "+
+			"%s (input script bytes %x, prev "+
+			"output script bytes %x)",
+			inputIndex,
+			input.PreviousOutpoint, err, sigScript, scriptPubKey)
+	}
+
+	// Execute the script pair.
+	if err := vm.Execute(); err != nil {
+		return errors.Wrapf(ruleerrors.ErrScriptValidation, "failed to validate input "+
+			"%d which references output %s - "+
+			"%s (input script bytes %x, prev output "+
+			"script bytes %x)",
+			inputIndex,
+			input.PreviousOutpoint, err, sigScript, scriptPubKey)
 	}
 	return nil
 }
@@ -1,8 +1,21 @@
 package transactionvalidator
 
 import (
-	"github.com/kaspanet/kaspad/util/mstime"
+	"fmt"
+	"runtime"
+	"strings"
 	"testing"
+
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/ruleerrors"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/constants"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/subnetworks"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/utxo"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/mstime"
+	"github.com/pkg/errors"
 )
 
 // TestSequenceLocksActive tests the SequenceLockActive function to ensure it
@@ -42,3 +55,210 @@ func TestSequenceLocksActive(t *testing.T) {
 		}
 	}
 }
+
+// createSignedTransaction creates a transaction spending inputCount
+// pay-to-pubkey-hash outputs, with all its inputs signed and populated
+// with their UTXO entries
+func createSignedTransaction(tb testing.TB, inputCount int) *externalapi.DomainTransaction {
+	privateKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		tb.Fatalf("GeneratePrivateKey: %s", err)
+	}
+	publicKey, err := privateKey.SchnorrPublicKey()
+	if err != nil {
+		tb.Fatalf("SchnorrPublicKey: %s", err)
+	}
+	serializedPublicKey, err := publicKey.Serialize()
+	if err != nil {
+		tb.Fatalf("Serialize: %s", err)
+	}
+	address, err := util.NewAddressPubKeyHash(util.Hash160(serializedPublicKey[:]), util.Bech32PrefixKaspaTest)
+	if err != nil {
+		tb.Fatalf("NewAddressPubKeyHash: %s", err)
+	}
+	scriptPublicKey, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		tb.Fatalf("PayToAddrScript: %s", err)
+	}
+
+	inputs := make([]*externalapi.DomainTransactionInput, inputCount)
+	for i := range inputs {
+		inputs[i] = &externalapi.DomainTransactionInput{
+			PreviousOutpoint: externalapi.DomainOutpoint{Index: uint32(i)},
+			Sequence:         constants.MaxTxInSequenceNum,
+			UTXOEntry:        utxo.NewUTXOEntry(1000, scriptPublicKey, false, 0),
+		}
+	}
+	tx := &externalapi.DomainTransaction{
+		Version: constants.MaxTransactionVersion,
+		Inputs:  inputs,
+		Outputs: []*externalapi.DomainTransactionOutput{{
+			Value:           uint64(inputCount) * 1000,
+			ScriptPublicKey: scriptPublicKey,
+		}},
+		SubnetworkID: subnetworks.SubnetworkIDNative,
+	}
+
+	for i, input := range tx.Inputs {
+		input.SignatureScript, err = txscript.SignatureScript(tx, i, scriptPublicKey, txscript.SigHashAll, privateKey)
+		if err != nil {
+			tb.Fatalf("SignatureScript: %s", err)
+		}
+	}
+	return tx
+}
+
+// BenchmarkValidateTransactionScripts compares validating the scripts of
+// a transaction that hasn't been seen before, with validating the scripts
+// of a transaction whose signatures were already verified when it entered
+// the mempool
+func BenchmarkValidateTransactionScripts(b *testing.B) {
+	tx := createSignedTransaction(b, 100)
+
+	b.Run("cold cache", func(b *testing.B) {
+		validator := transactionValidator{scriptVerificationWorkers: 1}
+		defer validator.Close()
+		for i := 0; i < b.N; i++ {
+			validator.sigCache = txscript.NewSigCache(1000)
+			err := validator.validateTransactionScripts(tx)
+			if err != nil {
+				b.Fatalf("validateTransactionScripts: %s", err)
+			}
+		}
+	})
+
+	b.Run("warm cache", func(b *testing.B) {
+		validator := transactionValidator{sigCache: txscript.NewSigCache(1000), scriptVerificationWorkers: 1}
+		defer validator.Close()
+		err := validator.validateTransactionScripts(tx)
+		if err != nil {
+			b.Fatalf("validateTransactionScripts: %s", err)
+		}
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			err := validator.validateTransactionScripts(tx)
+			if err != nil {
+				b.Fatalf("validateTransactionScripts: %s", err)
+			}
+		}
+	})
+}
+
+func TestValidateTransactionScriptsReportsFirstFailingInput(t *testing.T) {
+	tx := createSignedTransaction(t, 50)
+
+	// Break the signatures of a few inputs, by making them sign
+	// inputs other than their own
+	for _, i := range []int{17, 30, 44} {
+		tx.Inputs[i].SignatureScript = tx.Inputs[i-1].SignatureScript
+	}
+
+	for _, workers := range []int{1, 4, 16} {
+		validator := transactionValidator{sigCache: txscript.NewSigCache(0), scriptVerificationWorkers: workers}
+		err := validator.validateTransactionScripts(tx)
+		validator.Close()
+		if !errors.Is(err, ruleerrors.ErrScriptValidation) {
+			t.Fatalf("validateTransactionScripts with %d workers: expected ErrScriptValidation, got %v", workers, err)
+		}
+		if !strings.Contains(err.Error(), "failed to validate input 17 ") {
+			t.Fatalf("validateTransactionScripts with %d workers: expected input 17 to be reported, got %s",
+				workers, err)
+		}
+	}
+}
+
+func TestValidateTransactionScriptsReportsLowerOfTwoFailingInputs(t *testing.T) {
+	tx := createSignedTransaction(t, 8)
+
+	// Break the signatures of two adjacent inputs, so that the higher one is
+	// often verified first
+	for _, i := range []int{1, 2} {
+		tx.Inputs[i].SignatureScript = tx.Inputs[i+1].SignatureScript
+	}
+
+	for _, workers := range []int{2, 8} {
+		validator := transactionValidator{sigCache: txscript.NewSigCache(0), scriptVerificationWorkers: workers}
+		defer validator.Close()
+		for i := 0; i < 100; i++ {
+			err := validator.validateTransactionScripts(tx)
+			if !errors.Is(err, ruleerrors.ErrScriptValidation) {
+				t.Fatalf("validateTransactionScripts with %d workers: expected ErrScriptValidation, got %v",
+					workers, err)
+			}
+			if !strings.Contains(err.Error(), "failed to validate input 1 ") {
+				t.Fatalf("validateTransactionScripts with %d workers: expected input 1 to be reported, got %s",
+					workers, err)
+			}
+		}
+	}
+}
+
+// BenchmarkValidateTransactionScriptsParallel measures verifying the scripts of
+// a transaction with as many inputs as fit in a block, with a varying number of
+// workers
+func BenchmarkValidateTransactionScriptsParallel(b *testing.B) {
+	tx := createSignedTransaction(b, 1000)
+
+	for _, workers := range []int{1, 2, 4, runtime.NumCPU()} {
+		b.Run(fmt.Sprintf("%d workers", workers), func(b *testing.B) {
+			validator := transactionValidator{sigCache: txscript.NewSigCache(0), scriptVerificationWorkers: workers}
+			defer validator.Close()
+			for i := 0; i < b.N; i++ {
+				err := validator.validateTransactionScripts(tx)
+				if err != nil {
+					b.Fatalf("validateTransactionScripts: %s", err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateTransactionsScriptsReportsFirstFailingInputOfEachTransaction(t *testing.T) {
+	txs := make([]*externalapi.DomainTransaction, 20)
+	for i := range txs {
+		txs[i] = createSignedTransaction(t, 2)
+	}
+
+	// Break the second input of one transaction and both inputs of another
+	txs[3].Inputs[1].SignatureScript = txs[3].Inputs[0].SignatureScript
+	txs[11].Inputs[0].SignatureScript = txs[11].Inputs[1].SignatureScript
+	txs[11].Inputs[1].SignatureScript = txs[12].Inputs[1].SignatureScript
+	expectedFailedInputs := map[int]int{3: 1, 11: 0}
+
+	for _, workers := range []int{1, 4, 16} {
+		validator := transactionValidator{sigCache: txscript.NewSigCache(0), scriptVerificationWorkers: workers}
+		txErrors := validator.validateTransactionsScripts(txs)
+		validator.Close()
+
+		for i, err := range txErrors {
+			expectedFailedInput, ok := expectedFailedInputs[i]
+			if !ok {
+				if err != nil {
+					t.Fatalf("validateTransactionsScripts with %d workers: unexpected error in transaction %d: %s",
+						workers, i, err)
+				}
+				continue
+			}
+			if !errors.Is(err, ruleerrors.ErrScriptValidation) {
+				t.Fatalf("validateTransactionsScripts with %d workers: expected ErrScriptValidation "+
+					"in transaction %d, got %v", workers, i, err)
+			}
+			if !strings.Contains(err.Error(), fmt.Sprintf("failed to validate input %d ", expectedFailedInput)) {
+				t.Fatalf("validateTransactionsScripts with %d workers: expected input %d of transaction %d "+
+					"to be reported, got %s", workers, expectedFailedInput, i, err)
+			}
+		}
+	}
+}
+
+func TestValidateTransactionScriptsAfterClose(t *testing.T) {
+	validator := transactionValidator{sigCache: txscript.NewSigCache(0), scriptVerificationWorkers: 1}
+	validator.Close()
+	validator.Close()
+
+	err := validator.validateTransactionScripts(createSignedTransaction(t, 1))
+	if !errors.Is(err, errClosed) {
+		t.Fatalf("expected validating scripts after Close to fail with errClosed, got %v", err)
+	}
+}
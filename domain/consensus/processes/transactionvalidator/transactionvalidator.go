@@ -1,12 +1,14 @@
 package transactionvalidator
 
 import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
 	"github.com/kaspanet/kaspad/domain/consensus/model"
 	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
 )
 
-const sigCacheSize = 10_000
-
 // transactionValidator exposes a set of validation classes, after which
 // it's possible to determine whether either a transaction is valid
 type transactionValidator struct {
@@ -20,9 +22,18 @@ type transactionValidator struct {
 	massPerSigOp               uint64
 	maxCoinbasePayloadLength   uint64
 	sigCache                   *txscript.SigCache
+	scriptVerificationWorkers  int
+
+	startScriptVerificationWorkersOnce sync.Once
+	stopScriptVerificationWorkersOnce  sync.Once
+	scriptVerificationJobs             chan *scriptVerificationJob
+	isClosed                           uint32
 }
 
-// New instantiates a new TransactionValidator
+// New instantiates a new TransactionValidator. scriptVerificationWorkers is the
+// number of goroutines that verify the input scripts of transactions, where 0
+// means one per CPU. They're started the first time scripts are verified, and
+// stopped by Close.
 func New(blockCoinbaseMaturity uint64,
 	enableNonNativeSubnetworks bool,
 	massPerTxByte uint64,
@@ -31,7 +42,14 @@ func New(blockCoinbaseMaturity uint64,
 	maxCoinbasePayloadLength uint64,
 	databaseContext model.DBReader,
 	pastMedianTimeManager model.PastMedianTimeManager,
-	ghostdagDataStore model.GHOSTDAGDataStore) model.TransactionValidator {
+	ghostdagDataStore model.GHOSTDAGDataStore,
+	sigCache *txscript.SigCache,
+	scriptVerificationWorkers int) model.TransactionValidator {
+
+	if scriptVerificationWorkers == 0 {
+		scriptVerificationWorkers = runtime.NumCPU()
+	}
+
 	return &transactionValidator{
 		blockCoinbaseMaturity:      blockCoinbaseMaturity,
 		enableNonNativeSubnetworks: enableNonNativeSubnetworks,
@@ -42,6 +60,22 @@ func New(blockCoinbaseMaturity uint64,
 		databaseContext:            databaseContext,
 		pastMedianTimeManager:      pastMedianTimeManager,
 		ghostdagDataStore:          ghostdagDataStore,
-		sigCache:                   txscript.NewSigCache(sigCacheSize),
+		sigCache:                   sigCache,
+		scriptVerificationWorkers:  scriptVerificationWorkers,
 	}
 }
+
+// Close stops the script verification workers. The validator may not be
+// used after it's closed.
+func (v *transactionValidator) Close() {
+	atomic.StoreUint32(&v.isClosed, 1)
+
+	// Once the workers are stopped they must never be started, so the
+	// jobs channel is created here if they weren't started yet
+	v.startScriptVerificationWorkersOnce.Do(func() {
+		v.scriptVerificationJobs = make(chan *scriptVerificationJob)
+	})
+	v.stopScriptVerificationWorkersOnce.Do(func() {
+		close(v.scriptVerificationJobs)
+	})
+}
@@ -211,6 +211,12 @@ func (e RuleError) Error() string {
 	return e.message
 }
 
+// Name returns the name of the rule that was broken, such as
+// ErrDuplicateBlock
+func (e RuleError) Name() string {
+	return e.message
+}
+
 // Unwrap satisfies the errors.Unwrap interface
 func (e RuleError) Unwrap() error {
 	return e.inner
@@ -0,0 +1,106 @@
+package consensus
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
+	"github.com/kaspanet/kaspad/domain/dagconfig"
+	"github.com/kaspanet/kaspad/infrastructure/db/database/ldb"
+	"github.com/kaspanet/kaspad/infrastructure/tracing"
+)
+
+type testTraceExporter struct {
+	traces [][]*tracing.SpanData
+}
+
+func (e *testTraceExporter) ExportTrace(spans []*tracing.SpanData) error {
+	e.traces = append(e.traces, spans)
+	return nil
+}
+
+func (e *testTraceExporter) Close() error {
+	return nil
+}
+
+func TestValidateAndInsertBlockTracing(t *testing.T) {
+	dagParams := dagconfig.SimnetParams
+	dagParams.SkipProofOfWork = true
+
+	tmpDir, err := ioutil.TempDir("", "TestValidateAndInsertBlockTracing")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	db, err := ldb.NewLevelDB(tmpDir)
+	if err != nil {
+		t.Fatalf("error in NewLevelDB: %s", err)
+	}
+	defer db.Close()
+
+	exporter := &testTraceExporter{}
+	consensus, err := NewFactory().NewConsensus(&dagParams, db, false, testMaxUTXOCacheSize,
+		txscript.NewSigCache(testSigCacheMaxSize), testScriptVerificationWorkers, tracing.New(exporter))
+	if err != nil {
+		t.Fatalf("error in NewConsensus: %+v", err)
+	}
+
+	block, err := consensus.BuildBlock(&externalapi.DomainCoinbaseData{
+		ScriptPublicKey: &externalapi.ScriptPublicKey{Script: nil, Version: 0},
+	}, nil)
+	if err != nil {
+		t.Fatalf("BuildBlock: %+v", err)
+	}
+	// The genesis is inserted when consensus is created, so only
+	// traces from now on are of interest
+	exporter.traces = nil
+	_, err = consensus.ValidateAndInsertBlock(block)
+	if err != nil {
+		t.Fatalf("ValidateAndInsertBlock: %+v", err)
+	}
+
+	if len(exporter.traces) != 1 {
+		t.Fatalf("expected a single trace, got %d", len(exporter.traces))
+	}
+	spans := exporter.traces[0]
+	blockHash := consensushashing.BlockHash(block)
+	spansByName := make(map[string]*tracing.SpanData)
+	for _, span := range spans {
+		if span.TraceID != tracing.NewTraceID(blockHash.ByteSlice()) {
+			t.Errorf("span %s has trace ID %s that doesn't match block %s", span.Name, span.TraceID, blockHash)
+		}
+		spansByName[span.Name] = span
+	}
+
+	root, ok := spansByName["ValidateAndInsertBlock"]
+	if !ok {
+		t.Fatalf("expected the trace to have a ValidateAndInsertBlock span")
+	}
+	if root.Attributes["block.hash"] != blockHash.String() || root.Attributes["block.kind"] != "block" {
+		t.Errorf("unexpected attributes of the root span: %v", root.Attributes)
+	}
+
+	expectedParents := map[string]string{
+		"ValidateHeaderInIsolation": "ValidateAndInsertBlock",
+		"ValidateHeaderInContext":   "ValidateAndInsertBlock",
+		"GHOSTDAG":                  "ValidateHeaderInContext",
+		"reachability.AddBlock":     "ValidateHeaderInContext",
+		"csm.AddBlock":              "ValidateAndInsertBlock",
+		"resolveBlockStatus":        "csm.AddBlock",
+		"updateVirtual":             "csm.AddBlock",
+	}
+	for name, parentName := range expectedParents {
+		span, ok := spansByName[name]
+		if !ok {
+			t.Errorf("expected the trace to have a %s span", name)
+			continue
+		}
+		if span.ParentSpanID != spansByName[parentName].SpanID {
+			t.Errorf("expected the parent of %s to be %s", name, parentName)
+		}
+	}
+}
@@ -901,3 +901,87 @@ nexttest:
 		}
 	}
 }
+
+func TestMultiSigPayToScriptHash(t *testing.T) {
+	keys := make([]*secp256k1.SchnorrKeyPair, 3)
+	pubKeys := make([][]byte, len(keys))
+	for i := range keys {
+		key, err := secp256k1.GeneratePrivateKey()
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		pubKey, err := key.SchnorrPublicKey()
+		if err != nil {
+			t.Fatalf("failed to get public key: %v", err)
+		}
+		serializedPubKey, err := pubKey.Serialize()
+		if err != nil {
+			t.Fatalf("failed to serialize public key: %v", err)
+		}
+		keys[i] = key
+		pubKeys[i] = serializedPubKey[:]
+	}
+
+	redeemScript, err := MultiSigScript(pubKeys, 2)
+	if err != nil {
+		t.Fatalf("MultiSigScript: %v", err)
+	}
+	scriptPubKeyBytes, err := PayToScriptHashScript(redeemScript)
+	if err != nil {
+		t.Fatalf("PayToScriptHashScript: %v", err)
+	}
+	scriptPubKey := &externalapi.ScriptPublicKey{Script: scriptPubKeyBytes, Version: 0}
+	redeemScriptPublicKey := &externalapi.ScriptPublicKey{Script: redeemScript, Version: 0}
+
+	tx := &externalapi.DomainTransaction{
+		Version: 0,
+		Inputs: []*externalapi.DomainTransactionInput{{
+			PreviousOutpoint: externalapi.DomainOutpoint{Index: 0},
+			Sequence:         4294967295,
+		}},
+		Outputs: []*externalapi.DomainTransactionOutput{{
+			Value:           1,
+			ScriptPublicKey: &externalapi.ScriptPublicKey{Script: nil, Version: 0},
+		}},
+	}
+
+	signatures := make([][]byte, len(keys))
+	for i, key := range keys {
+		signatures[i], err = RawTxInSignature(tx, 0, redeemScriptPublicKey, SigHashAll, key)
+		if err != nil {
+			t.Fatalf("RawTxInSignature: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		signatures    [][]byte
+		shouldSucceed bool
+	}{
+		{name: "first and second keys", signatures: [][]byte{signatures[0], signatures[1]}, shouldSucceed: true},
+		{name: "first and third keys", signatures: [][]byte{signatures[0], signatures[2]}, shouldSucceed: true},
+		{name: "second and third keys", signatures: [][]byte{signatures[1], signatures[2]}, shouldSucceed: true},
+		{name: "wrong order", signatures: [][]byte{signatures[2], signatures[0]}, shouldSucceed: false},
+		{name: "same key twice", signatures: [][]byte{signatures[0], signatures[0]}, shouldSucceed: false},
+		{name: "single signature", signatures: [][]byte{signatures[0]}, shouldSucceed: false},
+	}
+	for _, test := range tests {
+		builder := NewScriptBuilder()
+		for _, signature := range test.signatures {
+			builder.AddData(signature)
+		}
+		builder.AddData(redeemScript)
+		sigScript, err := builder.Script()
+		if err != nil {
+			t.Fatalf("%s: failed to build signature script: %v", test.name, err)
+		}
+
+		err = checkScripts(test.name, tx, 0, sigScript, scriptPubKey)
+		if test.shouldSucceed && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if !test.shouldSucceed && err == nil {
+			t.Errorf("%s: expected the signature script to fail", test.name)
+		}
+	}
+}
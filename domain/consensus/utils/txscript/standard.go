@@ -241,6 +241,86 @@ func PayToScriptHashSignatureScript(redeemScript []byte, signature []byte) ([]by
 	return signatureScript, nil
 }
 
+// isMultiSig returns true if the passed script is a multisig script in the
+// form: OP_<nRequired> <pubkey> ... <pubkey> OP_<nPubKeys> OP_CHECKMULTISIG
+func isMultiSig(pops []parsedOpcode) bool {
+	numPops := len(pops)
+	if numPops < 4 {
+		return false
+	}
+	if !isSmallInt(pops[0].opcode) || !isSmallInt(pops[numPops-2].opcode) {
+		return false
+	}
+	if pops[numPops-1].opcode.value != OpCheckMultiSig {
+		return false
+	}
+
+	numPubKeys := asSmallInt(pops[numPops-2].opcode)
+	numSigs := asSmallInt(pops[0].opcode)
+	if numPubKeys != numPops-3 || numSigs < 1 || numSigs > numPubKeys {
+		return false
+	}
+	for _, pop := range pops[1 : numPops-2] {
+		if len(pop.data) != 32 {
+			return false
+		}
+	}
+	return true
+}
+
+// MultiSigScript returns a valid script for a multisignature redemption where
+// nRequired of the keys in pubKeys are required to have signed the transaction
+// for success. The signatures in the signature script must appear in the same
+// order as their public keys appear in pubKeys. An Error with the error code
+// ErrTooManyRequiredSigs will be returned if nRequired is larger than the
+// number of keys provided.
+func MultiSigScript(pubKeys [][]byte, nRequired int) ([]byte, error) {
+	if len(pubKeys) < nRequired {
+		str := fmt.Sprintf("unable to generate multisig script with "+
+			"%d required signatures when there are only %d public "+
+			"keys available", nRequired, len(pubKeys))
+		return nil, scriptError(ErrTooManyRequiredSigs, str)
+	}
+	if nRequired < 1 || len(pubKeys) > 16 {
+		str := fmt.Sprintf("unable to generate a %d-of-%d multisig script",
+			nRequired, len(pubKeys))
+		return nil, scriptError(ErrNotMultisigScript, str)
+	}
+
+	builder := NewScriptBuilder().AddInt64(int64(nRequired))
+	for _, pubKey := range pubKeys {
+		if len(pubKey) != 32 {
+			str := fmt.Sprintf("public key %x is not a serialized Schnorr public key", pubKey)
+			return nil, scriptError(ErrNotMultisigScript, str)
+		}
+		builder.AddData(pubKey)
+	}
+	builder.AddInt64(int64(len(pubKeys)))
+	builder.AddOp(OpCheckMultiSig)
+
+	return builder.Script()
+}
+
+// CalcMultiSigStats returns the number of public keys and signatures from
+// a multi-signature transaction script. An Error with the error code
+// ErrNotMultisigScript will be returned if the script is not a
+// multi-signature script.
+func CalcMultiSigStats(script []byte) (int, int, error) {
+	pops, err := parseScript(script)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !isMultiSig(pops) {
+		str := "script is not a multisig script"
+		return 0, 0, scriptError(ErrNotMultisigScript, str)
+	}
+
+	numPubKeys := asSmallInt(pops[len(pops)-2].opcode)
+	numSigs := asSmallInt(pops[0].opcode)
+	return numPubKeys, numSigs, nil
+}
+
 // PushedData returns an array of byte slices containing any pushed data found
 // in the passed script. This includes OP_0, but not OP_1 - OP_16.
 func PushedData(script []byte) ([][]byte, error) {
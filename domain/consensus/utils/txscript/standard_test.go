@@ -542,3 +542,58 @@ func TestStringifyClass(t *testing.T) {
 		}
 	}
 }
+
+func TestMultiSigScript(t *testing.T) {
+	pubKeys := [][]byte{
+		bytes.Repeat([]byte{0x01}, 32),
+		bytes.Repeat([]byte{0x02}, 32),
+		bytes.Repeat([]byte{0x03}, 32),
+	}
+
+	script, err := MultiSigScript(pubKeys, 2)
+	if err != nil {
+		t.Fatalf("MultiSigScript: %v", err)
+	}
+	expected := mustParseShortForm("2 DATA_32 0x"+
+		"0101010101010101010101010101010101010101010101010101010101010101 DATA_32 0x"+
+		"0202020202020202020202020202020202020202020202020202020202020202 DATA_32 0x"+
+		"0303030303030303030303030303030303030303030303030303030303030303 3 CHECKMULTISIG", 0)
+	if !bytes.Equal(script, expected) {
+		t.Fatalf("MultiSigScript: got %x, want %x", script, expected)
+	}
+
+	numPubKeys, numSigs, err := CalcMultiSigStats(script)
+	if err != nil {
+		t.Fatalf("CalcMultiSigStats: %v", err)
+	}
+	if numPubKeys != 3 || numSigs != 2 {
+		t.Fatalf("CalcMultiSigStats: got %d-of-%d, want 2-of-3", numSigs, numPubKeys)
+	}
+	pushedData, err := PushedData(script)
+	if err != nil {
+		t.Fatalf("PushedData: %v", err)
+	}
+	if !reflect.DeepEqual(pushedData, pubKeys) {
+		t.Fatalf("PushedData: got %x, want %x", pushedData, pubKeys)
+	}
+
+	_, err = MultiSigScript(pubKeys, 4)
+	if !IsErrorCode(err, ErrTooManyRequiredSigs) {
+		t.Fatalf("MultiSigScript: expected ErrTooManyRequiredSigs, got %v", err)
+	}
+	_, err = MultiSigScript(pubKeys, 0)
+	if !IsErrorCode(err, ErrNotMultisigScript) {
+		t.Fatalf("MultiSigScript: expected ErrNotMultisigScript, got %v", err)
+	}
+	_, err = MultiSigScript([][]byte{bytes.Repeat([]byte{0x01}, 33)}, 1)
+	if !IsErrorCode(err, ErrNotMultisigScript) {
+		t.Fatalf("MultiSigScript: expected ErrNotMultisigScript, got %v", err)
+	}
+
+	payToPubKeyHash := mustParseShortForm("DUP HASH160 DATA_20 0x0000000000000000000000000000000000000000 "+
+		"EQUALVERIFY CHECKSIG", 0)
+	_, _, err = CalcMultiSigStats(payToPubKeyHash)
+	if !IsErrorCode(err, ErrNotMultisigScript) {
+		t.Fatalf("CalcMultiSigStats: expected ErrNotMultisigScript, got %v", err)
+	}
+}
@@ -1,71 +1,126 @@
 package utxolrucache
 
 import (
+	"container/list"
+
 	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
 )
 
+// entryOverhead is the estimated number of bytes every cached entry takes on
+// top of its outpoint and UTXO entry: the map bucket, the list element, and
+// the pointers between them
+const entryOverhead = 150
+
 // LRUCache is a least-recently-used cache for UTXO entries
-// indexed by DomainOutpoint
+// indexed by DomainOutpoint. Its size is bounded by the
+// estimated number of bytes its entries take in memory.
 type LRUCache struct {
-	cache    map[externalapi.DomainOutpoint]externalapi.UTXOEntry
-	capacity int
+	elements map[externalapi.DomainOutpoint]*list.Element
+
+	// recency holds the cached entries, from the most recently used
+	// to the least recently used
+	recency *list.List
+
+	size    uint64
+	maxSize uint64
+
+	hits   uint64
+	misses uint64
+}
+
+type cacheEntry struct {
+	outpoint externalapi.DomainOutpoint
+	entry    externalapi.UTXOEntry
+	size     uint64
 }
 
-// New creates a new LRUCache
-func New(capacity int) *LRUCache {
+// New creates a new LRUCache that holds up to maxSize bytes of entries
+func New(maxSize uint64) *LRUCache {
 	return &LRUCache{
-		cache:    make(map[externalapi.DomainOutpoint]externalapi.UTXOEntry, capacity+1),
-		capacity: capacity,
+		elements: make(map[externalapi.DomainOutpoint]*list.Element),
+		recency:  list.New(),
+		maxSize:  maxSize,
 	}
 }
 
-// Add adds an entry to the LRUCache
+// Add adds an entry to the LRUCache, evicting the least recently used
+// entries if the cache grows beyond its maximum size
 func (c *LRUCache) Add(key *externalapi.DomainOutpoint, value externalapi.UTXOEntry) {
-	c.cache[*key] = value
+	c.Remove(key)
+
+	entry := &cacheEntry{
+		outpoint: *key,
+		entry:    value,
+		size:     entrySize(value),
+	}
+	c.elements[*key] = c.recency.PushFront(entry)
+	c.size += entry.size
 
-	if len(c.cache) > c.capacity {
-		c.evictRandom()
+	for c.size > c.maxSize {
+		c.removeElement(c.recency.Back())
 	}
 }
 
 // Get returns the entry for the given key, or (nil, false) otherwise
 func (c *LRUCache) Get(key *externalapi.DomainOutpoint) (externalapi.UTXOEntry, bool) {
-	value, ok := c.cache[*key]
+	element, ok := c.elements[*key]
 	if !ok {
+		c.misses++
 		return nil, false
 	}
-	return value, true
+	c.hits++
+	c.recency.MoveToFront(element)
+	return element.Value.(*cacheEntry).entry, true
 }
 
 // Has returns whether the LRUCache contains the given key
 func (c *LRUCache) Has(key *externalapi.DomainOutpoint) bool {
-	_, ok := c.cache[*key]
+	_, ok := c.elements[*key]
 	return ok
 }
 
 // Remove removes the entry for the the given key. Does nothing if
 // the entry does not exist
 func (c *LRUCache) Remove(key *externalapi.DomainOutpoint) {
-	delete(c.cache, *key)
+	element, ok := c.elements[*key]
+	if !ok {
+		return
+	}
+	c.removeElement(element)
 }
 
 // Clear clears the cache
 func (c *LRUCache) Clear() {
-	keys := make([]externalapi.DomainOutpoint, len(c.cache))
-	for outpoint := range c.cache {
-		keys = append(keys, outpoint)
-	}
+	c.elements = make(map[externalapi.DomainOutpoint]*list.Element)
+	c.recency.Init()
+	c.size = 0
+}
 
-	for _, key := range keys {
-		delete(c.cache, key)
+// Stats returns the usage statistics of the cache
+func (c *LRUCache) Stats() *externalapi.UTXOCacheStats {
+	return &externalapi.UTXOCacheStats{
+		EntryCount: uint64(len(c.elements)),
+		Size:       c.size,
+		MaxSize:    c.maxSize,
+		Hits:       c.hits,
+		Misses:     c.misses,
 	}
 }
 
-func (c *LRUCache) evictRandom() {
-	var keyToEvict externalapi.DomainOutpoint
-	for key := range c.cache {
-		keyToEvict = key
-		break
+func (c *LRUCache) removeElement(element *list.Element) {
+	entry := c.recency.Remove(element).(*cacheEntry)
+	delete(c.elements, entry.outpoint)
+	c.size -= entry.size
+}
+
+// entrySize returns the estimated number of bytes a cached entry takes
+func entrySize(entry externalapi.UTXOEntry) uint64 {
+	const outpointSize = externalapi.DomainHashSize + 4
+	const fixedEntrySize = 8 + 8 + 1 + 2 // amount, blue score, is coinbase and script version
+
+	scriptSize := 0
+	if entry.ScriptPublicKey() != nil {
+		scriptSize = len(entry.ScriptPublicKey().Script)
 	}
-	c.Remove(&keyToEvict)
+	return entryOverhead + outpointSize + fixedEntrySize + uint64(scriptSize)
 }
@@ -0,0 +1,62 @@
+package utxolrucache
+
+import (
+	"testing"
+
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/utxo"
+)
+
+func TestLRUCache(t *testing.T) {
+	entry := utxo.NewUTXOEntry(1000, &externalapi.ScriptPublicKey{Script: make([]byte, 34)}, false, 0)
+	size := entrySize(entry)
+	outpoints := make([]*externalapi.DomainOutpoint, 4)
+	for i := range outpoints {
+		outpoints[i] = &externalapi.DomainOutpoint{Index: uint32(i)}
+	}
+
+	// The cache has room for three entries
+	cache := New(3*size + size/2)
+	for _, outpoint := range outpoints[:3] {
+		cache.Add(outpoint, entry)
+	}
+
+	// Using the first entry makes the second the least recently used one,
+	// so it's the one evicted once a fourth entry is added
+	_, ok := cache.Get(outpoints[0])
+	if !ok {
+		t.Fatalf("expected the first entry to be cached")
+	}
+	cache.Add(outpoints[3], entry)
+	if cache.Has(outpoints[1]) {
+		t.Fatalf("expected the least recently used entry to be evicted")
+	}
+	for _, i := range []int{0, 2, 3} {
+		if !cache.Has(outpoints[i]) {
+			t.Fatalf("expected entry %d to remain cached", i)
+		}
+	}
+
+	// Adding an entry that is already cached doesn't count it twice
+	cache.Add(outpoints[3], entry)
+	_, ok = cache.Get(outpoints[1])
+	if ok {
+		t.Fatalf("expected the evicted entry to be missing")
+	}
+	stats := cache.Stats()
+	if stats.EntryCount != 3 || stats.Size != 3*size {
+		t.Fatalf("expected 3 entries taking %d bytes, got %d entries taking %d bytes", 3*size, stats.EntryCount, stats.Size)
+	}
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("expected a single hit and a single miss, got %d hits and %d misses", stats.Hits, stats.Misses)
+	}
+
+	cache.Remove(outpoints[0])
+	if cache.Stats().Size != 2*size {
+		t.Fatalf("expected removing an entry to free its size")
+	}
+	cache.Clear()
+	if cache.Stats().EntryCount != 0 || cache.Stats().Size != 0 {
+		t.Fatalf("expected the cache to be empty after clearing it")
+	}
+}
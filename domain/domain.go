@@ -3,9 +3,11 @@ package domain
 import (
 	"github.com/kaspanet/kaspad/domain/consensus"
 	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/txscript"
 	"github.com/kaspanet/kaspad/domain/dagconfig"
 	"github.com/kaspanet/kaspad/domain/miningmanager"
 	infrastructuredatabase "github.com/kaspanet/kaspad/infrastructure/db/database"
+	"github.com/kaspanet/kaspad/infrastructure/tracing"
 )
 
 // Domain provides a reference to the domain's external aps
@@ -27,17 +29,29 @@ func (d domain) MiningManager() miningmanager.MiningManager {
 	return d.miningManager
 }
 
-// New instantiates a new instance of a Domain object
-func New(dagParams *dagconfig.Params, db infrastructuredatabase.Database, isArchivalNode bool) (Domain, error) {
+// New instantiates a new instance of a Domain object.
+// A single signature cache of up to sigCacheMaxSize entries is shared by all
+// script validation, so that signatures verified when a transaction enters the
+// mempool aren't verified again once it arrives inside a block. The input
+// scripts of each transaction are verified by scriptVerificationWorkers
+// goroutines, where 0 means one per CPU. The processing of blocks is traced
+// with tracer, unless it's nil
+func New(dagParams *dagconfig.Params, db infrastructuredatabase.Database, isArchivalNode bool,
+	maxMempoolMass uint64, maxUTXOCacheSize uint64, sigCacheMaxSize uint, scriptVerificationWorkers int,
+	tracer *tracing.Tracer) (Domain, error) {
+
+	sigCache := txscript.NewSigCache(sigCacheMaxSize)
+
 	consensusFactory := consensus.NewFactory()
-	consensusInstance, err := consensusFactory.NewConsensus(dagParams, db, isArchivalNode)
+	consensusInstance, err := consensusFactory.NewConsensus(dagParams, db, isArchivalNode, maxUTXOCacheSize, sigCache,
+		scriptVerificationWorkers, tracer)
 	if err != nil {
 		return nil, err
 	}
 
 	miningManagerFactory := miningmanager.NewFactory()
 	miningManager := miningManagerFactory.NewMiningManager(consensusInstance, dagParams.MaxMassAcceptedByBlock,
-		dagParams.RelayNonStdTxs)
+		dagParams.RelayNonStdTxs, maxMempoolMass)
 
 	return &domain{
 		consensus:     consensusInstance,
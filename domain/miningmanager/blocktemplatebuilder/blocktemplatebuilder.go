@@ -7,6 +7,7 @@ import (
 
 	consensusexternalapi "github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
 	"github.com/kaspanet/kaspad/domain/consensus/ruleerrors"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
 	"github.com/kaspanet/kaspad/domain/consensus/utils/subnetworks"
 	miningmanagerapi "github.com/kaspanet/kaspad/domain/miningmanager/model"
 	"github.com/pkg/errors"
@@ -17,13 +18,33 @@ type candidateTx struct {
 	txValue  float64
 	gasLimit uint64
 
+	// descendants are the chained transactions in the mempool that spend
+	// the outputs of the candidate, directly or indirectly
+	descendants []*descendantTx
+
 	p     float64
 	start float64
 	end   float64
 
+	// isValueChanged is set when txValue changes after p was calculated.
+	// p, and with it the ranges of all the candidates, is recalculated in
+	// the next rebalance.
+	isValueChanged      bool
 	isMarkedForDeletion bool
 }
 
+// descendantTx is a chained transaction in the mempool, which can only be
+// included in a later block once all of its candidate ancestors are. Its fee
+// and mass are split evenly between the candidate ancestors that weren't
+// selected yet, so that it adds to the value of the candidates it depends on
+// without being counted more than once.
+type descendantTx struct {
+	fee                    uint64
+	mass                   uint64
+	ancestors              []*candidateTx
+	remainingAncestorCount int
+}
+
 // blockTemplateBuilder creates block templates for a miner to consume
 type blockTemplateBuilder struct {
 	consensus consensusexternalapi.Consensus
@@ -105,25 +126,7 @@ func New(consensus consensusexternalapi.Consensus, mempool miningmanagerapi.Memp
 //   -----------------------------------  --
 
 func (btb *blockTemplateBuilder) GetBlockTemplate(coinbaseData *consensusexternalapi.DomainCoinbaseData) (*consensusexternalapi.DomainBlock, error) {
-	mempoolTransactions := btb.mempool.BlockCandidateTransactions()
-	candidateTxs := make([]*candidateTx, 0, len(mempoolTransactions))
-	for _, tx := range mempoolTransactions {
-		// Calculate the tx value
-		gasLimit := uint64(0)
-		if !subnetworks.IsBuiltInOrNative(tx.SubnetworkID) {
-			panic("We currently don't support non native subnetworks")
-		}
-		candidateTxs = append(candidateTxs, &candidateTx{
-			DomainTransaction: tx,
-			txValue:           btb.calcTxValue(tx),
-			gasLimit:          gasLimit,
-		})
-	}
-
-	// Sort the candidate txs by subnetworkID.
-	sort.Slice(candidateTxs, func(i, j int) bool {
-		return subnetworks.Less(candidateTxs[i].SubnetworkID, candidateTxs[j].SubnetworkID)
-	})
+	candidateTxs := btb.candidateTxs(btb.mempool.BlockCandidateTransactions())
 
 	log.Debugf("Considering %d transactions for inclusion to new block",
 		len(candidateTxs))
@@ -153,6 +156,92 @@ func (btb *blockTemplateBuilder) GetBlockTemplate(coinbaseData *consensusexterna
 	return blk, nil
 }
 
+// candidateTxs returns the candidate transactions for inclusion in the block
+// template out of the given mempool candidates, sorted by subnetworkID
+func (btb *blockTemplateBuilder) candidateTxs(
+	mempoolCandidates []*miningmanagerapi.BlockCandidateTransaction) []*candidateTx {
+
+	candidateTxs := make([]*candidateTx, 0, len(mempoolCandidates))
+	descendantTxs := make(map[consensusexternalapi.DomainTransactionID]*descendantTx)
+	for _, mempoolCandidate := range mempoolCandidates {
+		tx := mempoolCandidate.Transaction
+
+		gasLimit := uint64(0)
+		if !subnetworks.IsBuiltInOrNative(tx.SubnetworkID) {
+			panic("We currently don't support non native subnetworks")
+		}
+		candidate := &candidateTx{
+			DomainTransaction: tx,
+			gasLimit:          gasLimit,
+			descendants:       make([]*descendantTx, 0, len(mempoolCandidate.Descendants)),
+		}
+		for _, descendant := range mempoolCandidate.Descendants {
+			descendantID := *consensushashing.TransactionID(descendant)
+			candidateDescendant, ok := descendantTxs[descendantID]
+			if !ok {
+				candidateDescendant = &descendantTx{fee: descendant.Fee, mass: descendant.Mass}
+				descendantTxs[descendantID] = candidateDescendant
+			}
+			candidateDescendant.ancestors = append(candidateDescendant.ancestors, candidate)
+			candidateDescendant.remainingAncestorCount++
+			candidate.descendants = append(candidate.descendants, candidateDescendant)
+		}
+		candidateTxs = append(candidateTxs, candidate)
+	}
+
+	// The tx values are calculated once the descendants of all the
+	// candidates are known
+	for _, candidate := range candidateTxs {
+		candidate.txValue = btb.calcCandidateTxValue(candidate)
+	}
+
+	// Sort the candidate txs by subnetworkID.
+	sort.Slice(candidateTxs, func(i, j int) bool {
+		return subnetworks.Less(candidateTxs[i].SubnetworkID, candidateTxs[j].SubnetworkID)
+	})
+	return candidateTxs
+}
+
+// calcCandidateTxValue calculates the value of a candidate transaction, which
+// is the value of the candidate along with its shares of its descendants, if
+// that is higher than the value of the transaction alone. Chained transactions
+// can only be included in blocks once the transactions they spend are
+// accepted, so a transaction that pays a low fee is still worth including
+// when the transactions that spend its outputs pay high fees (child pays for
+// parent).
+func (btb *blockTemplateBuilder) calcCandidateTxValue(candidate *candidateTx) float64 {
+	txValue := btb.calcTxValue(candidate.DomainTransaction)
+	if !subnetworks.IsBuiltInOrNative(candidate.SubnetworkID) || len(candidate.descendants) == 0 {
+		return txValue
+	}
+	packageFee := float64(candidate.Fee)
+	packageMass := float64(candidate.Mass)
+	for _, descendant := range candidate.descendants {
+		packageFee += float64(descendant.fee) / float64(descendant.remainingAncestorCount)
+		packageMass += float64(descendant.mass) / float64(descendant.remainingAncestorCount)
+	}
+	packageValue := packageFee / (packageMass / float64(btb.policy.BlockMaxMass))
+	return math.Max(txValue, packageValue)
+}
+
+// onCandidateTxSelected hands the shares of the given candidate, which was
+// just selected, in its descendants over to their candidate ancestors that
+// weren't selected yet, and recalculates their values. Their p values are
+// left for the next rebalance, since changing a p means recalculating the
+// ranges of all the candidates.
+func (btb *blockTemplateBuilder) onCandidateTxSelected(selectedTx *candidateTx) {
+	for _, descendant := range selectedTx.descendants {
+		descendant.remainingAncestorCount--
+		for _, ancestor := range descendant.ancestors {
+			if ancestor.isMarkedForDeletion {
+				continue
+			}
+			ancestor.txValue = btb.calcCandidateTxValue(ancestor)
+			ancestor.isValueChanged = true
+		}
+	}
+}
+
 // calcTxValue calculates a value to be used in transaction selection.
 // The higher the number the more likely it is that the transaction will be
 // included in the block.
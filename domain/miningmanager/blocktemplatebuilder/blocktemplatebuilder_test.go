@@ -0,0 +1,180 @@
+package blocktemplatebuilder
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	consensusexternalapi "github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/subnetworks"
+	miningmanagerapi "github.com/kaspanet/kaspad/domain/miningmanager/model"
+)
+
+// transactionForTest is a mempool transaction that is a block candidate
+// once its parent, if it has one, is mined
+type transactionForTest struct {
+	transaction *consensusexternalapi.DomainTransaction
+	parent      *transactionForTest
+	isMined     bool
+}
+
+func newTransactionForTest(index uint32, parent *transactionForTest, fee uint64) *transactionForTest {
+	previousOutpoint := consensusexternalapi.DomainOutpoint{Index: index}
+	if parent != nil {
+		previousOutpoint = consensusexternalapi.DomainOutpoint{
+			TransactionID: *consensushashing.TransactionID(parent.transaction),
+		}
+	}
+	return &transactionForTest{
+		transaction: &consensusexternalapi.DomainTransaction{
+			Inputs: []*consensusexternalapi.DomainTransactionInput{{PreviousOutpoint: previousOutpoint}},
+			Outputs: []*consensusexternalapi.DomainTransactionOutput{{
+				Value:           1000,
+				ScriptPublicKey: &consensusexternalapi.ScriptPublicKey{Script: []byte{0x51}},
+			}},
+			SubnetworkID: subnetworks.SubnetworkIDNative,
+			Mass:         100,
+			Fee:          fee,
+		},
+		parent: parent,
+	}
+}
+
+// blockCandidatesForTest returns the block candidates out of the given
+// transactions, along with their descendants, the same way the mempool does
+func blockCandidatesForTest(transactions []*transactionForTest) []*miningmanagerapi.BlockCandidateTransaction {
+	var addDescendants func(candidate *miningmanagerapi.BlockCandidateTransaction, transaction *transactionForTest)
+	addDescendants = func(candidate *miningmanagerapi.BlockCandidateTransaction, transaction *transactionForTest) {
+		for _, child := range transactions {
+			if child.parent == transaction && !child.isMined {
+				candidate.Descendants = append(candidate.Descendants, child.transaction)
+				addDescendants(candidate, child)
+			}
+		}
+	}
+
+	candidates := make([]*miningmanagerapi.BlockCandidateTransaction, 0)
+	for _, transaction := range transactions {
+		if transaction.isMined || (transaction.parent != nil && !transaction.parent.isMined) {
+			continue
+		}
+		candidate := &miningmanagerapi.BlockCandidateTransaction{Transaction: transaction.transaction}
+		addDescendants(candidate, transaction)
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+func TestSelectTransactionsChildPaysForParent(t *testing.T) {
+	rand.Seed(0)
+
+	// The block fits a single transaction
+	btb := &blockTemplateBuilder{policy: policy{BlockMaxMass: 100}}
+
+	// templateFees returns the total fees of the first two templates built
+	// out of a mempool of a parent that pays a low fee, its child that pays
+	// a high fee, and an unrelated transaction that pays a fee in between
+	templateFees := func(isPackageAware bool) uint64 {
+		const trials = 100
+		totalFees := uint64(0)
+		for i := 0; i < trials; i++ {
+			parent := newTransactionForTest(0, nil, 100)
+			child := newTransactionForTest(0, parent, 2000)
+			other := newTransactionForTest(1, nil, 500)
+			transactions := []*transactionForTest{parent, child, other}
+
+			for block := 0; block < 2; block++ {
+				mempoolCandidates := blockCandidatesForTest(transactions)
+				if !isPackageAware {
+					for _, mempoolCandidate := range mempoolCandidates {
+						mempoolCandidate.Descendants = nil
+					}
+				}
+				selected := btb.selectTransactions(btb.candidateTxs(mempoolCandidates))
+				totalFees += selected.totalFees
+
+				for _, selectedTx := range selected.selectedTxs {
+					for _, transaction := range transactions {
+						if transaction.transaction == selectedTx {
+							transaction.isMined = true
+						}
+					}
+				}
+			}
+		}
+		return totalFees
+	}
+
+	packageAwareFees := templateFees(true)
+	packageUnawareFees := templateFees(false)
+	if packageAwareFees <= packageUnawareFees {
+		t.Fatalf("expected the templates to pay more fees when the child pays for its parent, "+
+			"but they paid %d compared to %d", packageAwareFees, packageUnawareFees)
+	}
+}
+
+func TestCalcCandidateTxValue(t *testing.T) {
+	btb := &blockTemplateBuilder{policy: policy{BlockMaxMass: 1000}}
+	transaction := newTransactionForTest(0, nil, 500).transaction
+	transactionValue := btb.calcTxValue(transaction)
+
+	// A child that pays a higher fee rate raises its parent's value
+	candidates := btb.candidateTxs([]*miningmanagerapi.BlockCandidateTransaction{{
+		Transaction: transaction,
+		Descendants: []*consensusexternalapi.DomainTransaction{{Fee: 1500, Mass: 100}},
+	}})
+	if candidates[0].txValue != 2*transactionValue {
+		t.Fatalf("expected the value of the package to be double the value of the transaction, got %f",
+			candidates[0].txValue)
+	}
+
+	// A child that pays a lower fee rate doesn't lower it
+	candidates = btb.candidateTxs([]*miningmanagerapi.BlockCandidateTransaction{{
+		Transaction: transaction,
+		Descendants: []*consensusexternalapi.DomainTransaction{{Fee: 100, Mass: 100}},
+	}})
+	if candidates[0].txValue != transactionValue {
+		t.Fatalf("expected the value of the transaction alone, got %f instead of %f",
+			candidates[0].txValue, transactionValue)
+	}
+}
+
+func TestSharedDescendantIsCountedOnce(t *testing.T) {
+	btb := &blockTemplateBuilder{policy: policy{BlockMaxMass: 1000}}
+	firstParent := newTransactionForTest(0, nil, 500).transaction
+	secondParent := newTransactionForTest(1, nil, 500).transaction
+	child := &consensusexternalapi.DomainTransaction{Fee: 2500, Mass: 100}
+
+	candidates := btb.candidateTxs([]*miningmanagerapi.BlockCandidateTransaction{
+		{Transaction: firstParent, Descendants: []*consensusexternalapi.DomainTransaction{child}},
+		{Transaction: secondParent, Descendants: []*consensusexternalapi.DomainTransaction{child}},
+	})
+
+	// Each parent gets half of the child: (500 + 1250) / (100 + 50)
+	sharedValue := btb.calcTxValue(firstParent) * 1750 / 150 / 5
+	for _, candidate := range candidates {
+		if math.Abs(candidate.txValue-sharedValue) > 1e-9 {
+			t.Fatalf("expected each parent to have a value of %f, got %f", sharedValue, candidate.txValue)
+		}
+	}
+
+	// Once the first parent is selected, the second one gets the whole
+	// child: (500 + 2500) / (100 + 100)
+	selected, remaining := candidates[0], candidates[1]
+	selected.isMarkedForDeletion = true
+	btb.onCandidateTxSelected(selected)
+	if !remaining.isValueChanged {
+		t.Fatalf("expected the value of the second parent to change")
+	}
+	wholeValue := btb.calcTxValue(secondParent) * 3000 / 200 / 5
+	if math.Abs(remaining.txValue-wholeValue) > 1e-9 {
+		t.Fatalf("expected the second parent to have a value of %f, got %f", wholeValue, remaining.txValue)
+	}
+
+	// The p of the second parent follows its value from the next rebalance
+	candidates, totalP := rebalanceCandidates(candidates, false)
+	if len(candidates) != 1 || remaining.p != math.Pow(remaining.txValue, alpha) || totalP != remaining.p {
+		t.Fatalf("expected the p of the second parent to follow its value after a rebalance")
+	}
+}
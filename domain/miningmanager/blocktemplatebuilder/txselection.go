@@ -150,6 +150,11 @@ func (btb *blockTemplateBuilder) selectTransactions(candidateTxs []*candidateTx)
 			consensushashing.TransactionID(tx), selectedTx.Fee*1e6/selectedTx.Mass)
 
 		markCandidateTxForDeletion(selectedTx)
+
+		// The candidates that share descendants with the selected
+		// candidate are now worth more. Their ranges are recalculated
+		// in the next rebalance.
+		btb.onCandidateTxSelected(selectedTx)
 	}
 
 	sort.Slice(selectedTxs, func(i, j int) bool {
@@ -178,8 +183,9 @@ func rebalanceCandidates(oldCandidateTxs []*candidateTx, isFirstRun bool) (
 	}
 
 	for _, candidateTx := range candidateTxs {
-		if isFirstRun {
+		if isFirstRun || candidateTx.isValueChanged {
 			candidateTx.p = math.Pow(candidateTx.txValue, alpha)
+			candidateTx.isValueChanged = false
 		}
 		candidateTx.start = totalP
 		candidateTx.end = totalP + candidateTx.p
@@ -3,24 +3,35 @@ package miningmanager
 import (
 	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
 	"github.com/kaspanet/kaspad/domain/miningmanager/blocktemplatebuilder"
+	"github.com/kaspanet/kaspad/domain/miningmanager/feeestimator"
 	mempoolpkg "github.com/kaspanet/kaspad/domain/miningmanager/mempool"
 )
 
 // Factory instantiates new mining managers
 type Factory interface {
-	NewMiningManager(consensus externalapi.Consensus, blockMaxMass uint64, acceptNonStd bool) MiningManager
+	NewMiningManager(consensus externalapi.Consensus, blockMaxMass uint64, acceptNonStd bool,
+		maxMempoolMass uint64) MiningManager
 }
 
 type factory struct{}
 
 // NewMiningManager instantiate a new mining manager
-func (f *factory) NewMiningManager(consensus externalapi.Consensus, blockMaxMass uint64, acceptNonStd bool) MiningManager {
-	mempool := mempoolpkg.New(consensus, acceptNonStd)
+func (f *factory) NewMiningManager(consensus externalapi.Consensus, blockMaxMass uint64, acceptNonStd bool,
+	maxMempoolMass uint64) MiningManager {
+
+	mempool := mempoolpkg.New(consensus, acceptNonStd, maxMempoolMass)
 	blockTemplateBuilder := blocktemplatebuilder.New(consensus, mempool, blockMaxMass)
 
+	// A transaction's mass is never lower than its size, so the minimum
+	// relay fee, which is per kB, is met by paying the same rate per
+	// thousand grams
+	minimumFeeRate := float64(mempoolpkg.DefaultMinRelayTxFee) / 1000
+	feeEstimator := feeestimator.New(mempool, blockMaxMass, minimumFeeRate)
+
 	return &miningManager{
 		mempool:              mempool,
 		blockTemplateBuilder: blockTemplateBuilder,
+		feeEstimator:         feeEstimator,
 	}
 }
 
@@ -0,0 +1,189 @@
+package feeestimator
+
+import (
+	"sort"
+	"sync"
+
+	consensusexternalapi "github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/transactionhelper"
+	miningmanagermodel "github.com/kaspanet/kaspad/domain/miningmanager/model"
+)
+
+const (
+	// recentBlocksWindow is the number of recent blocks whose accepted
+	// transactions are taken into account
+	recentBlocksWindow = 100
+
+	// The number of blocks within which transactions paying the low,
+	// normal and priority fee rates are expected to be included, given
+	// the current mempool contents
+	lowBlockCount      = 20
+	normalBlockCount   = 5
+	priorityBlockCount = 1
+
+	// The percentiles of the fee rates of recently accepted transactions
+	// that the low, normal and priority fee rates are at least as high as
+	lowPercentile      = 0.1
+	normalPercentile   = 0.5
+	priorityPercentile = 0.75
+)
+
+// feeEstimator estimates fee rates by combining two sources: the mempool,
+// which a transaction needs to out-bid enough of in order to fit in the
+// blocks that are going to be mined next, and the fee rates paid by the
+// transactions accepted in recent blocks, which show what it currently
+// takes to get into a block. Every estimate is at least minimumFeeRate, and
+// the rolling minimum fee rate the mempool requires once it's full.
+//
+// Transactions in blocks don't carry their fee, so only the transactions
+// that passed through this node's mempool are learned from. Transactions
+// that reached miners only through other nodes are not taken into account,
+// so a node that just started, or that is poorly connected, relies mostly
+// on the contents of its mempool.
+type feeEstimator struct {
+	mempool        miningmanagermodel.Mempool
+	blockMaxMass   uint64
+	minimumFeeRate float64
+
+	// recentBlocksFeeRates holds, for each of the recent blocks, the fee
+	// rates of its transactions. It is used as a ring buffer, where
+	// nextBlockIndex is the index to be overwritten by the next block.
+	recentBlocksFeeRates [][]float64
+	nextBlockIndex       int
+	lock                 sync.Mutex
+}
+
+// New creates a new FeeEstimator. minimumFeeRate is the fee rate, in sompi
+// per gram, that the estimates never go below.
+func New(mempool miningmanagermodel.Mempool, blockMaxMass uint64,
+	minimumFeeRate float64) miningmanagermodel.FeeEstimator {
+
+	return &feeEstimator{
+		mempool:              mempool,
+		blockMaxMass:         blockMaxMass,
+		minimumFeeRate:       minimumFeeRate,
+		recentBlocksFeeRates: make([][]float64, 0, recentBlocksWindow),
+	}
+}
+
+// HandleNewBlockTransactions records the fee rates of the transactions of
+// a newly accepted block. It must be called before the transactions are
+// removed from the mempool: transactions in blocks don't carry their fee,
+// so only the fee rates of transactions that are known to the mempool are
+// recorded.
+func (fe *feeEstimator) HandleNewBlockTransactions(txs []*consensusexternalapi.DomainTransaction) {
+	feeRates := make([]float64, 0, len(txs))
+	for _, tx := range txs {
+		if transactionhelper.IsCoinBase(tx) {
+			continue
+		}
+		mempoolTransaction, ok := fe.mempool.GetTransaction(consensushashing.TransactionID(tx))
+		if !ok || mempoolTransaction.Mass == 0 {
+			continue
+		}
+		feeRates = append(feeRates, feeRate(mempoolTransaction))
+	}
+
+	fe.lock.Lock()
+	defer fe.lock.Unlock()
+
+	if len(fe.recentBlocksFeeRates) < recentBlocksWindow {
+		fe.recentBlocksFeeRates = append(fe.recentBlocksFeeRates, feeRates)
+		return
+	}
+	fe.recentBlocksFeeRates[fe.nextBlockIndex] = feeRates
+	fe.nextBlockIndex = (fe.nextBlockIndex + 1) % recentBlocksWindow
+}
+
+// FeeEstimate returns the current fee rate estimate
+func (fe *feeEstimator) FeeEstimate() *miningmanagermodel.FeeEstimate {
+	mempoolFeeRates := fe.mempoolFeeRatesByMass()
+	recentFeeRates := fe.recentFeeRates()
+
+	minimumFeeRate := fe.minimumFeeRate
+	if rollingMinimumFeeRate := fe.mempool.Info().RollingMinimumFeeRate; rollingMinimumFeeRate > minimumFeeRate {
+		minimumFeeRate = rollingMinimumFeeRate
+	}
+
+	return &miningmanagermodel.FeeEstimate{
+		LowFeeRate: fe.estimate(minimumFeeRate, mempoolFeeRates, lowBlockCount,
+			recentFeeRates, lowPercentile),
+		NormalFeeRate: fe.estimate(minimumFeeRate, mempoolFeeRates, normalBlockCount,
+			recentFeeRates, normalPercentile),
+		PriorityFeeRate: fe.estimate(minimumFeeRate, mempoolFeeRates, priorityBlockCount,
+			recentFeeRates, priorityPercentile),
+	}
+}
+
+func (fe *feeEstimator) estimate(minimumFeeRate float64, mempoolFeeRates []*massAndFeeRate, blockCount uint64,
+	recentFeeRates []float64, percentile float64) float64 {
+
+	estimate := minimumFeeRate
+	mempoolEstimate := fe.mempoolFeeRate(mempoolFeeRates, blockCount)
+	if mempoolEstimate > estimate {
+		estimate = mempoolEstimate
+	}
+	if len(recentFeeRates) > 0 {
+		recentEstimate := recentFeeRates[int(percentile*float64(len(recentFeeRates)-1))]
+		if recentEstimate > estimate {
+			estimate = recentEstimate
+		}
+	}
+	return estimate
+}
+
+type massAndFeeRate struct {
+	mass    uint64
+	feeRate float64
+}
+
+// mempoolFeeRatesByMass returns the masses and fee rates of the
+// transactions in the mempool, sorted by fee rate from highest to lowest,
+// which is roughly the order in which they are going to be mined
+func (fe *feeEstimator) mempoolFeeRatesByMass() []*massAndFeeRate {
+	transactions := fe.mempool.AllTransactions()
+	result := make([]*massAndFeeRate, 0, len(transactions))
+	for _, transaction := range transactions {
+		if transaction.Mass == 0 {
+			continue
+		}
+		result = append(result, &massAndFeeRate{mass: transaction.Mass, feeRate: feeRate(transaction)})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].feeRate > result[j].feeRate })
+	return result
+}
+
+// mempoolFeeRate returns the fee rate a transaction has to out-bid in
+// order to be included within the given number of blocks, assuming no
+// further transactions arrive. It returns 0 if the mempool isn't large
+// enough to fill that many blocks.
+func (fe *feeEstimator) mempoolFeeRate(mempoolFeeRates []*massAndFeeRate, blockCount uint64) float64 {
+	availableMass := blockCount * fe.blockMaxMass
+	totalMass := uint64(0)
+	for _, transaction := range mempoolFeeRates {
+		totalMass += transaction.mass
+		if totalMass > availableMass {
+			return transaction.feeRate
+		}
+	}
+	return 0
+}
+
+// recentFeeRates returns the fee rates of the transactions accepted in
+// recent blocks, sorted from lowest to highest
+func (fe *feeEstimator) recentFeeRates() []float64 {
+	fe.lock.Lock()
+	defer fe.lock.Unlock()
+
+	feeRates := make([]float64, 0)
+	for _, blockFeeRates := range fe.recentBlocksFeeRates {
+		feeRates = append(feeRates, blockFeeRates...)
+	}
+	sort.Float64s(feeRates)
+	return feeRates
+}
+
+func feeRate(transaction *consensusexternalapi.DomainTransaction) float64 {
+	return float64(transaction.Fee) / float64(transaction.Mass)
+}
@@ -0,0 +1,176 @@
+package feeestimator
+
+import (
+	"testing"
+
+	consensusexternalapi "github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/subnetworks"
+	miningmanagermodel "github.com/kaspanet/kaspad/domain/miningmanager/model"
+)
+
+// fakeMempool is a mempool that holds its transactions without validating them
+type fakeMempool struct {
+	transactions          map[consensusexternalapi.DomainTransactionID]*consensusexternalapi.DomainTransaction
+	rollingMinimumFeeRate float64
+}
+
+func (fm *fakeMempool) HandleNewBlockTransactions(
+	txs []*consensusexternalapi.DomainTransaction) ([]*consensusexternalapi.DomainTransaction, error) {
+
+	fm.RemoveTransactions(txs)
+	return nil, nil
+}
+
+func (fm *fakeMempool) BlockCandidateTransactions() []*miningmanagermodel.BlockCandidateTransaction {
+	candidates := make([]*miningmanagermodel.BlockCandidateTransaction, 0, len(fm.transactions))
+	for _, transaction := range fm.transactions {
+		candidates = append(candidates, &miningmanagermodel.BlockCandidateTransaction{
+			Transaction: transaction,
+		})
+	}
+	return candidates
+}
+
+func (fm *fakeMempool) ValidateAndInsertTransaction(transaction *consensusexternalapi.DomainTransaction, _ bool) error {
+	fm.transactions[*consensushashing.TransactionID(transaction)] = transaction
+	return nil
+}
+
+func (fm *fakeMempool) RemoveTransactions(txs []*consensusexternalapi.DomainTransaction) {
+	for _, tx := range txs {
+		delete(fm.transactions, *consensushashing.TransactionID(tx))
+	}
+}
+
+func (fm *fakeMempool) GetTransaction(
+	transactionID *consensusexternalapi.DomainTransactionID) (*consensusexternalapi.DomainTransaction, bool) {
+
+	transaction, ok := fm.transactions[*transactionID]
+	return transaction, ok
+}
+
+func (fm *fakeMempool) AllTransactions() []*consensusexternalapi.DomainTransaction {
+	transactions := make([]*consensusexternalapi.DomainTransaction, 0, len(fm.transactions))
+	for _, transaction := range fm.transactions {
+		transactions = append(transactions, transaction)
+	}
+	return transactions
+}
+
+func (fm *fakeMempool) Info() *miningmanagermodel.MempoolInfo {
+	return &miningmanagermodel.MempoolInfo{
+		TransactionCount:      len(fm.transactions),
+		RollingMinimumFeeRate: fm.rollingMinimumFeeRate,
+	}
+}
+
+func (fm *fakeMempool) SaveToFile(string) error {
+	return nil
+}
+
+func (fm *fakeMempool) LoadFromFile(string) error {
+	return nil
+}
+
+// transactionForTest returns a unique transaction with the given fee and mass
+func transactionForTest(index uint32, fee uint64, mass uint64) *consensusexternalapi.DomainTransaction {
+	return &consensusexternalapi.DomainTransaction{
+		Version: 0,
+		Inputs: []*consensusexternalapi.DomainTransactionInput{{
+			PreviousOutpoint: consensusexternalapi.DomainOutpoint{Index: index},
+		}},
+		SubnetworkID: subnetworks.SubnetworkIDNative,
+		Fee:          fee,
+		Mass:         mass,
+	}
+}
+
+func TestFeeEstimateFloor(t *testing.T) {
+	mempool := &fakeMempool{transactions: make(map[consensusexternalapi.DomainTransactionID]*consensusexternalapi.DomainTransaction)}
+	feeEstimator := New(mempool, 1000, 1)
+
+	// An empty mempool and no history fall back to the minimum fee rate
+	estimate := feeEstimator.FeeEstimate()
+	if estimate.LowFeeRate != 1 || estimate.NormalFeeRate != 1 || estimate.PriorityFeeRate != 1 {
+		t.Fatalf("expected all the fee rates to be the minimum, got %+v", estimate)
+	}
+
+	// A mempool that fits in the next block doesn't raise the estimate
+	for i := uint32(0); i < 5; i++ {
+		_ = mempool.ValidateAndInsertTransaction(transactionForTest(i, 1000, 100), false)
+	}
+	estimate = feeEstimator.FeeEstimate()
+	if estimate.PriorityFeeRate != 1 {
+		t.Fatalf("expected a mempool that fits in a block to not raise the priority fee rate, got %f",
+			estimate.PriorityFeeRate)
+	}
+
+	// Once the mempool is full, no estimate goes below its rolling minimum
+	mempool.rollingMinimumFeeRate = 3
+	estimate = feeEstimator.FeeEstimate()
+	if estimate.LowFeeRate != 3 {
+		t.Fatalf("expected the low fee rate to be the rolling minimum fee rate, got %f", estimate.LowFeeRate)
+	}
+}
+
+func TestFeeEstimateFromMempool(t *testing.T) {
+	mempool := &fakeMempool{transactions: make(map[consensusexternalapi.DomainTransactionID]*consensusexternalapi.DomainTransaction)}
+	const blockMaxMass = 1000
+	feeEstimator := New(mempool, blockMaxMass, 1)
+
+	// Fill the mempool with 3 blocks worth of transactions, where each
+	// block's worth pays a lower fee rate than the one before
+	feeRates := []uint64{30, 20, 10}
+	index := uint32(0)
+	for _, feeRate := range feeRates {
+		for i := 0; i < 10; i++ {
+			_ = mempool.ValidateAndInsertTransaction(transactionForTest(index, feeRate*100, 100), false)
+			index++
+		}
+	}
+
+	estimate := feeEstimator.FeeEstimate()
+	if estimate.PriorityFeeRate != 20 {
+		t.Fatalf("expected the priority fee rate to out-bid the second block's worth, got %f",
+			estimate.PriorityFeeRate)
+	}
+	if estimate.NormalFeeRate != 1 || estimate.LowFeeRate != 1 {
+		t.Fatalf("expected the normal and low fee rates to be the minimum, got %+v", estimate)
+	}
+}
+
+func TestFeeEstimateFromRecentBlocks(t *testing.T) {
+	mempool := &fakeMempool{transactions: make(map[consensusexternalapi.DomainTransactionID]*consensusexternalapi.DomainTransaction)}
+	feeEstimator := New(mempool, 1000000, 1)
+
+	// Accept a block whose transactions pay fee rates of 1 to 100
+	blockTransactions := make([]*consensusexternalapi.DomainTransaction, 0, 100)
+	for i := uint32(0); i < 100; i++ {
+		transaction := transactionForTest(i, uint64(i+1)*100, 100)
+		_ = mempool.ValidateAndInsertTransaction(transaction, false)
+		blockTransactions = append(blockTransactions, transaction)
+	}
+	// A transaction that wasn't in the mempool has an unknown fee, and is ignored
+	blockTransactions = append(blockTransactions, transactionForTest(1000, 0, 0))
+	feeEstimator.HandleNewBlockTransactions(blockTransactions)
+	_, _ = mempool.HandleNewBlockTransactions(blockTransactions)
+
+	estimate := feeEstimator.FeeEstimate()
+	if !(estimate.LowFeeRate < estimate.NormalFeeRate && estimate.NormalFeeRate < estimate.PriorityFeeRate) {
+		t.Fatalf("expected the fee rates to rise from low to priority, got %+v", estimate)
+	}
+	if estimate.NormalFeeRate != 50 {
+		t.Fatalf("expected the normal fee rate to be the median of the recent fee rates, got %f",
+			estimate.NormalFeeRate)
+	}
+
+	// Once enough empty blocks are accepted, the history is forgotten
+	for i := 0; i < recentBlocksWindow; i++ {
+		feeEstimator.HandleNewBlockTransactions(nil)
+	}
+	estimate = feeEstimator.FeeEstimate()
+	if estimate.PriorityFeeRate != 1 {
+		t.Fatalf("expected old blocks to be forgotten, got %+v", estimate)
+	}
+}
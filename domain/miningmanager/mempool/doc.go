@@ -35,7 +35,9 @@ be an exhaustive list.
  - Maintain a pool of fully validated transactions
    - Reject non-fully-spent duplicate transactions
    - Reject coinbase transactions
-   - Reject double spends (both from the DAG and other transactions in pool)
+   - Reject double spends (both from the DAG and other transactions in pool),
+     unless they replace pool transactions that opted in to be replaced, and
+     pay their fees plus the minimum relay fee
    - Reject invalid transactions according to the network consensus rules
    - Full script execution and validation with signature cache support
    - Individual transaction query support
@@ -49,12 +51,16 @@ be an exhaustive list.
    - Option to accept or reject transactions based on priority calculations
    - Max signature operations per transaction
    - Max number of orphan transactions allowed
+   - Max total mass of the pool, above which the transactions that pay the
+     lowest fee rates are evicted
  - Additional metadata tracking for each transaction
    - Timestamp when the transaction was added to the pool
    - The fee the transaction pays
    - The starting priority for the transaction
  - Manual control of transaction removal
    - Recursive removal of all dependent transactions
+ - Saving the pool to a file, and loading it back after revalidating every
+   transaction against the current state of the DAG
 
 Errors
 
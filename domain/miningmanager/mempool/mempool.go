@@ -61,6 +61,11 @@ type policy struct {
 	// MinRelayTxFee defines the minimum transaction fee in KAS/kB to be
 	// considered a non-zero fee.
 	MinRelayTxFee util.Amount
+
+	// MaxMempoolMass is the maximum total mass of the transactions in the
+	// main pool and the chained transactions. Once it is exceeded, the
+	// transactions that pay the lowest fee rates are evicted.
+	MaxMempoolMass uint64
 }
 
 // mempool is used as a source of transactions that need to be mined into blocks
@@ -84,19 +89,39 @@ type mempool struct {
 	// to on an unconditional timer.
 	nextExpireScan mstime.Time
 
+	// totalMass is the total mass of the transactions in the main pool
+	// and the chained transactions
+	totalMass uint64
+
+	// transactionPackages holds the package of every transaction in the
+	// main pool and the chained transactions, and packagesByFeeRate orders
+	// them by fee rate, so that the package to evict is found right away
+	transactionPackages map[consensusexternalapi.DomainTransactionID]*transactionPackage
+	packagesByFeeRate   packageHeap
+
+	// evictedTransactionCount is the number of transactions that were
+	// evicted in order to keep totalMass within MaxMempoolMass
+	evictedTransactionCount uint64
+
+	// rollingMinimumFeeRateBase is the rolling minimum fee rate as of
+	// rollingMinimumFeeRateUpdateTime. See rollingMinimumFeeRate.
+	rollingMinimumFeeRateBase       float64
+	rollingMinimumFeeRateUpdateTime mstime.Time
+
 	mtx    sync.RWMutex
 	policy policy
 }
 
 // New returns a new memory pool for validating and storing standalone
 // transactions until they are mined into a block.
-func New(consensus consensusexternalapi.Consensus, acceptNonStd bool) miningmanagermodel.Mempool {
+func New(consensus consensusexternalapi.Consensus, acceptNonStd bool, maxMempoolMass uint64) miningmanagermodel.Mempool {
 	policy := policy{
 		MaxTxVersion:    constants.MaxTransactionVersion,
 		AcceptNonStd:    acceptNonStd,
 		MaxOrphanTxs:    5,
 		MaxOrphanTxSize: 100000,
 		MinRelayTxFee:   1000, // 1 sompi per byte
+		MaxMempoolMass:  maxMempoolMass,
 	}
 	return &mempool{
 		mtx:                                  sync.RWMutex{},
@@ -108,6 +133,7 @@ func New(consensus consensusexternalapi.Consensus, acceptNonStd bool) miningmana
 		orphansByPrev:                        make(map[consensusexternalapi.DomainOutpoint]map[consensusexternalapi.DomainTransactionID]*consensusexternalapi.DomainTransaction),
 		mempoolUTXOSet:                       newMempoolUTXOSet(),
 		consensus:                            consensus,
+		transactionPackages:                  make(map[consensusexternalapi.DomainTransactionID]*transactionPackage),
 		nextExpireScan:                       mstime.Now().Add(orphanExpireScanInterval),
 	}
 }
@@ -408,15 +434,17 @@ func (mp *mempool) removeTransactionAndItsChainedTransactions(tx *consensusexter
 		}
 	}
 
-	if _, exists := mp.chainedTransactions[*txID]; exists {
-		mp.removeChainTransaction(tx)
-	}
+	_, isChained := mp.chainedTransactions[*txID]
 
 	err := mp.cleanTransactionFromSets(tx)
 	if err != nil {
 		return err
 	}
 
+	if isChained {
+		mp.removeChainTransaction(tx)
+	}
+
 	return nil
 }
 
@@ -431,6 +459,10 @@ func (mp *mempool) cleanTransactionFromSets(tx *consensusexternalapi.DomainTrans
 	}
 
 	txID := consensushashing.TransactionID(tx)
+	if _, exists := mp.fetchTxDesc(txID); exists {
+		mp.totalMass -= tx.Mass
+		mp.removeTransactionPackage(tx)
+	}
 	delete(mp.pool, *txID)
 	delete(mp.chainedTransactions, *txID)
 
@@ -526,27 +558,41 @@ func (mp *mempool) addTransaction(tx *consensusexternalapi.DomainTransaction, ma
 	if err != nil {
 		return nil, err
 	}
+	mp.totalMass += tx.Mass
+	mp.addTransactionPackage(tx)
 
 	return txDescriptor, nil
 }
 
 // checkPoolDoubleSpend checks whether or not the passed transaction is
 // attempting to spend coins already spent by other transactions in the pool.
+// If all of these transactions opted in to be replaced, they are returned,
+// so that the passed transaction may replace them if it pays enough fees.
 // Note it does not check for double spends against transactions already in the
 // DAG.
 //
 // This function MUST be called with the mempool lock held (for reads).
-func (mp *mempool) checkPoolDoubleSpend(tx *consensusexternalapi.DomainTransaction) error {
+func (mp *mempool) checkPoolDoubleSpend(tx *consensusexternalapi.DomainTransaction) (
+	conflicts []*consensusexternalapi.DomainTransaction, err error) {
+
+	conflictIDs := make(map[consensusexternalapi.DomainTransactionID]struct{})
 	for _, txIn := range tx.Inputs {
 		if txR, exists := mp.mempoolUTXOSet.poolTransactionBySpendingOutpoint(txIn.PreviousOutpoint); exists {
-			str := fmt.Sprintf("output %s already spent by "+
-				"transaction %s in the memory pool",
-				txIn.PreviousOutpoint, consensushashing.TransactionID(txR))
-			return txRuleError(RejectDuplicate, str)
+			if !isReplaceable(txR) {
+				str := fmt.Sprintf("output %s already spent by "+
+					"transaction %s in the memory pool",
+					txIn.PreviousOutpoint, consensushashing.TransactionID(txR))
+				return nil, txRuleError(RejectDuplicate, str)
+			}
+			txRID := consensushashing.TransactionID(txR)
+			if _, ok := conflictIDs[*txRID]; !ok {
+				conflictIDs[*txRID] = struct{}{}
+				conflicts = append(conflicts, txR)
+			}
 		}
 	}
 
-	return nil
+	return conflicts, nil
 }
 
 // This function MUST be called with the mempool lock held (for reads).
@@ -614,14 +660,18 @@ func (mp *mempool) maybeAcceptTransaction(tx *consensusexternalapi.DomainTransac
 	// at this point. There is a more in-depth check that happens later
 	// after fetching the referenced transaction inputs from the DAG
 	// which examines the actual spend data and prevents double spends.
-	err := mp.checkPoolDoubleSpend(tx)
+	//
+	// Transactions that opted in to be replaced are the exception: they
+	// are replaced later on if this transaction pays enough fees.
+	conflicts, err := mp.checkPoolDoubleSpend(tx)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// Don't allow the transaction if it exists in the DAG and is
-	// not already fully spent.
-	if mp.mempoolUTXOSet.checkExists(tx) {
+	// not already fully spent. The outputs spent by the transactions it
+	// replaces were already checked above.
+	if len(conflicts) == 0 && mp.mempoolUTXOSet.checkExists(tx) {
 		return nil, nil, txRuleError(RejectDuplicate, "transaction already exists")
 	}
 
@@ -672,12 +722,39 @@ func (mp *mempool) maybeAcceptTransaction(tx *consensusexternalapi.DomainTransac
 			minFee)
 		return nil, nil, txRuleError(RejectInsufficientFee, str)
 	}
+
+	// Don't allow transactions that pay less than the transactions that
+	// were recently evicted from the mempool
+	err = mp.checkRollingMinimumFeeRate(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(conflicts) > 0 {
+		err = mp.replaceTransactions(tx, conflicts, parentsInPool)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Add to transaction pool.
 	txDesc, err := mp.addTransaction(tx, tx.Mass, tx.Fee, parentsInPool)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	// Make room for the transaction. It might end up being the one
+	// that is evicted, if it pays the lowest fee rate.
+	err = mp.limitMempoolMass()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !mp.isTransactionInPool(txID) {
+		str := fmt.Sprintf("transaction %s was not accepted because the mempool is full, "+
+			"and it pays a lower fee rate than the transactions in it", txID)
+		return nil, nil, txRuleError(RejectInsufficientFee, str)
+	}
+
 	log.Debugf("Accepted transaction %s (pool size: %d)", txID,
 		len(mp.pool))
 
@@ -857,19 +934,23 @@ func (mp *mempool) ChainedCount() int {
 	return len(mp.chainedTransactions)
 }
 
-// BlockCandidateTransactions returns a slice of all the candidate transactions for the next block
+// BlockCandidateTransactions returns a slice of all the candidate transactions for the next block,
+// along with their descendants
 // This is safe for concurrent use
-func (mp *mempool) BlockCandidateTransactions() []*consensusexternalapi.DomainTransaction {
+func (mp *mempool) BlockCandidateTransactions() []*miningmanagermodel.BlockCandidateTransaction {
 	mp.mtx.RLock()
 	defer mp.mtx.RUnlock()
-	descs := make([]*consensusexternalapi.DomainTransaction, len(mp.pool))
+	candidates := make([]*miningmanagermodel.BlockCandidateTransaction, len(mp.pool))
 	i := 0
 	for _, desc := range mp.pool {
-		descs[i] = desc.DomainTransaction
+		candidates[i] = &miningmanagermodel.BlockCandidateTransaction{
+			Transaction: desc.DomainTransaction,
+			Descendants: mp.descendants(desc.DomainTransaction),
+		}
 		i++
 	}
 
-	return descs
+	return candidates
 }
 
 // HandleNewBlockTransactions removes all the transactions in the new block
@@ -921,3 +1002,21 @@ func (mp *mempool) RemoveTransactions(txs []*consensusexternalapi.DomainTransact
 		mp.removeOrphan(tx, true)
 	}
 }
+
+// Info returns the current state of the mempool
+//
+// This function is safe for concurrent access.
+func (mp *mempool) Info() *miningmanagermodel.MempoolInfo {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	return &miningmanagermodel.MempoolInfo{
+		TransactionCount:        len(mp.pool),
+		ChainedTransactionCount: len(mp.chainedTransactions),
+		OrphanCount:             len(mp.orphans),
+		TotalMass:               mp.totalMass,
+		MaxMass:                 mp.policy.MaxMempoolMass,
+		RollingMinimumFeeRate:   mp.rollingMinimumFeeRate(),
+		EvictedTransactionCount: mp.evictedTransactionCount,
+	}
+}
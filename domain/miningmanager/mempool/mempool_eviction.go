@@ -0,0 +1,399 @@
+package mempool
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"time"
+
+	consensusexternalapi "github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/util/mstime"
+)
+
+const (
+	// incrementalFeeRate is the fee rate, in sompi per gram, by which the
+	// rolling minimum fee rate rises above the fee rate of the transactions
+	// that were evicted, so that they can't immediately re-enter the mempool
+	incrementalFeeRate = 1.0
+
+	// rollingMinimumFeeRateHalfLife is the time it takes the rolling minimum
+	// fee rate to drop by half, so the mempool gradually accepts cheaper
+	// transactions again once it stops filling up
+	rollingMinimumFeeRateHalfLife = time.Hour
+)
+
+// transactionPackage is a transaction in the mempool along with all the
+// mempool transactions that depend on it, directly or indirectly. The
+// package of every mempool transaction is kept up to date as transactions
+// are added to and removed from the mempool.
+type transactionPackage struct {
+	transaction *consensusexternalapi.DomainTransaction
+	count       int
+	mass        uint64
+	fee         uint64
+
+	// index is the index of the package in mempool.packagesByFeeRate
+	index int
+}
+
+func (tp *transactionPackage) feeRate() float64 {
+	if tp.mass == 0 {
+		return 0
+	}
+	return float64(tp.fee) / float64(tp.mass)
+}
+
+// packageHeap is a heap of transaction packages, in which the package that
+// pays the lowest fee rate is at the top. It implements heap.Interface.
+type packageHeap []*transactionPackage
+
+func (h packageHeap) Len() int {
+	return len(h)
+}
+
+func (h packageHeap) Less(i, j int) bool {
+	return h[i].feeRate() < h[j].feeRate()
+}
+
+func (h packageHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *packageHeap) Push(x interface{}) {
+	txPackage := x.(*transactionPackage)
+	txPackage.index = len(*h)
+	*h = append(*h, txPackage)
+}
+
+func (h *packageHeap) Pop() interface{} {
+	oldHeap := *h
+	txPackage := oldHeap[len(oldHeap)-1]
+	oldHeap[len(oldHeap)-1] = nil
+	*h = oldHeap[:len(oldHeap)-1]
+	return txPackage
+}
+
+// descendants returns the transactions in the mempool that spend the outputs
+// of the given transaction, directly or indirectly, each of them once
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *mempool) descendants(tx *consensusexternalapi.DomainTransaction) []*consensusexternalapi.DomainTransaction {
+	var descendants []*consensusexternalapi.DomainTransaction
+	visited := make(map[consensusexternalapi.DomainTransactionID]struct{})
+	queue := []*consensusexternalapi.DomainTransaction{tx}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		currentID := consensushashing.TransactionID(current)
+		for i := range current.Outputs {
+			outpoint := consensusexternalapi.DomainOutpoint{TransactionID: *currentID, Index: uint32(i)}
+			redeemer, exists := mp.mempoolUTXOSet.poolTransactionBySpendingOutpoint(outpoint)
+			if !exists {
+				continue
+			}
+			redeemerID := consensushashing.TransactionID(redeemer)
+			if _, ok := visited[*redeemerID]; ok {
+				continue
+			}
+			visited[*redeemerID] = struct{}{}
+			descendants = append(descendants, redeemer)
+			queue = append(queue, redeemer)
+		}
+	}
+	return descendants
+}
+
+// addToTransactionPackage adds the given transaction and all of its
+// descendants that aren't in visited yet to the given package, which isn't
+// one of the packages the mempool keeps. It is used to sum up transactions
+// whose packages overlap, counting every transaction once.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *mempool) addToTransactionPackage(txPackage *transactionPackage, tx *consensusexternalapi.DomainTransaction,
+	visited map[consensusexternalapi.DomainTransactionID]struct{}) {
+
+	txID := consensushashing.TransactionID(tx)
+	if _, ok := visited[*txID]; ok {
+		return
+	}
+	visited[*txID] = struct{}{}
+
+	txPackage.count++
+	txPackage.mass += tx.Mass
+	txPackage.fee += tx.Fee
+
+	for i := range tx.Outputs {
+		outpoint := consensusexternalapi.DomainOutpoint{TransactionID: *txID, Index: uint32(i)}
+		if redeemer, exists := mp.mempoolUTXOSet.poolTransactionBySpendingOutpoint(outpoint); exists {
+			mp.addToTransactionPackage(txPackage, redeemer, visited)
+		}
+	}
+}
+
+// addTransactionPackage creates the package of the given transaction, which
+// was just added to the mempool, and adds the transaction to the packages
+// of all its ancestors
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *mempool) addTransactionPackage(tx *consensusexternalapi.DomainTransaction) {
+	txPackage := &transactionPackage{transaction: tx, count: 1, mass: tx.Mass, fee: tx.Fee}
+	mp.transactionPackages[*consensushashing.TransactionID(tx)] = txPackage
+	heap.Push(&mp.packagesByFeeRate, txPackage)
+
+	mp.forEachAncestorPackage(tx, func(ancestorPackage *transactionPackage) {
+		ancestorPackage.count++
+		ancestorPackage.mass += tx.Mass
+		ancestorPackage.fee += tx.Fee
+		heap.Fix(&mp.packagesByFeeRate, ancestorPackage.index)
+	})
+}
+
+// removeTransactionPackage removes the package of the given transaction,
+// which is being removed from the mempool, and removes the transaction from
+// the packages of all its ancestors. Transactions are removed before the
+// transactions they depend on, so the ancestors of a removed transaction
+// are still in the mempool.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *mempool) removeTransactionPackage(tx *consensusexternalapi.DomainTransaction) {
+	txID := consensushashing.TransactionID(tx)
+	txPackage, ok := mp.transactionPackages[*txID]
+	if !ok {
+		return
+	}
+	heap.Remove(&mp.packagesByFeeRate, txPackage.index)
+	delete(mp.transactionPackages, *txID)
+
+	mp.forEachAncestorPackage(tx, func(ancestorPackage *transactionPackage) {
+		ancestorPackage.count--
+		ancestorPackage.mass -= tx.Mass
+		ancestorPackage.fee -= tx.Fee
+		heap.Fix(&mp.packagesByFeeRate, ancestorPackage.index)
+	})
+}
+
+// forEachAncestorPackage calls f once for the package of every mempool
+// transaction that the given transaction depends on, directly or indirectly
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *mempool) forEachAncestorPackage(tx *consensusexternalapi.DomainTransaction,
+	f func(ancestorPackage *transactionPackage)) {
+
+	visited := make(map[consensusexternalapi.DomainTransactionID]struct{})
+	queue := []*consensusexternalapi.DomainTransaction{tx}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, input := range current.Inputs {
+			parentID := input.PreviousOutpoint.TransactionID
+			if _, ok := visited[parentID]; ok {
+				continue
+			}
+			parentPackage, ok := mp.transactionPackages[parentID]
+			if !ok {
+				continue
+			}
+			visited[parentID] = struct{}{}
+			f(parentPackage)
+			queue = append(queue, parentPackage.transaction)
+		}
+	}
+}
+
+// lowestFeeRatePackage returns the transaction package in the mempool that
+// pays the lowest fee rate, or nil if the mempool is empty
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *mempool) lowestFeeRatePackage() *transactionPackage {
+	if len(mp.packagesByFeeRate) == 0 {
+		return nil
+	}
+	return mp.packagesByFeeRate[0]
+}
+
+// limitMempoolMass evicts the transaction packages that pay the lowest fee
+// rates until the total mass of the mempool is back within its limit, and
+// raises the rolling minimum fee rate above the fee rates of the evicted
+// packages.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *mempool) limitMempoolMass() error {
+	for mp.totalMass > mp.policy.MaxMempoolMass {
+		txPackage := mp.lowestFeeRatePackage()
+		if txPackage == nil {
+			return nil
+		}
+
+		// Removing the transaction updates its package, so the package
+		// is described before it's removed
+		tx, count, feeRate := txPackage.transaction, txPackage.count, txPackage.feeRate()
+		err := mp.removeTransactionAndItsChainedTransactions(tx)
+		if err != nil {
+			return err
+		}
+		mp.evictedTransactionCount += uint64(count)
+		mp.raiseRollingMinimumFeeRate(feeRate + incrementalFeeRate)
+
+		log.Debugf("Evicted transaction %s and %d dependent transactions paying %f sompi per gram "+
+			"(mempool mass: %d)", consensushashing.TransactionID(tx), count-1, feeRate, mp.totalMass)
+	}
+	return nil
+}
+
+// survivesEviction returns whether limitMempoolMass would keep the given
+// transaction, once the replaced transactions, whose IDs are in replacedIDs,
+// are removed and the transaction is added to the mempool. The eviction is
+// run on copies of the transaction packages, so that the mempool isn't
+// changed by a replacement that would be evicted right away.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *mempool) survivesEviction(tx *consensusexternalapi.DomainTransaction, replaced *transactionPackage,
+	replacedIDs map[consensusexternalapi.DomainTransactionID]struct{}) bool {
+
+	totalMass := mp.totalMass - replaced.mass + tx.Mass
+	if totalMass <= mp.policy.MaxMempoolMass {
+		return true
+	}
+
+	packages := make(map[consensusexternalapi.DomainTransactionID]*transactionPackage, len(mp.transactionPackages)+1)
+	for txID, txPackage := range mp.transactionPackages {
+		if _, ok := replacedIDs[txID]; ok {
+			continue
+		}
+		packageCopy := *txPackage
+		packages[txID] = &packageCopy
+	}
+	forEachAncestorPackageCopy := func(tx *consensusexternalapi.DomainTransaction, f func(*transactionPackage)) {
+		mp.forEachAncestorPackage(tx, func(ancestorPackage *transactionPackage) {
+			ancestorPackageCopy, ok := packages[*consensushashing.TransactionID(ancestorPackage.transaction)]
+			if ok {
+				f(ancestorPackageCopy)
+			}
+		})
+	}
+
+	for replacedTxID := range replacedIDs {
+		replacedTx := mp.transactionPackages[replacedTxID].transaction
+		forEachAncestorPackageCopy(replacedTx, func(ancestorPackage *transactionPackage) {
+			ancestorPackage.count--
+			ancestorPackage.mass -= replacedTx.Mass
+			ancestorPackage.fee -= replacedTx.Fee
+		})
+	}
+	txID := consensushashing.TransactionID(tx)
+	txPackage := &transactionPackage{transaction: tx, count: 1, mass: tx.Mass, fee: tx.Fee}
+	forEachAncestorPackageCopy(tx, func(ancestorPackage *transactionPackage) {
+		ancestorPackage.count++
+		ancestorPackage.mass += tx.Mass
+		ancestorPackage.fee += tx.Fee
+	})
+	packages[*txID] = txPackage
+
+	packagesByFeeRate := make(packageHeap, 0, len(packages))
+	for _, txPackage := range packages {
+		txPackage.index = len(packagesByFeeRate)
+		packagesByFeeRate = append(packagesByFeeRate, txPackage)
+	}
+	heap.Init(&packagesByFeeRate)
+
+	// evict removes the given transaction and its descendants from the
+	// copies, the same way removeTransactionAndItsChainedTransactions
+	// removes them from the mempool. It returns false if the given
+	// transaction is among them.
+	var evict func(evictedTx *consensusexternalapi.DomainTransaction) bool
+	evict = func(evictedTx *consensusexternalapi.DomainTransaction) bool {
+		evictedTxID := consensushashing.TransactionID(evictedTx)
+		evictedPackage, ok := packages[*evictedTxID]
+		if !ok {
+			return true
+		}
+		if evictedPackage == txPackage {
+			return false
+		}
+		for _, input := range tx.Inputs {
+			if input.PreviousOutpoint.TransactionID == *evictedTxID {
+				return false
+			}
+		}
+		for i := range evictedTx.Outputs {
+			outpoint := consensusexternalapi.DomainOutpoint{TransactionID: *evictedTxID, Index: uint32(i)}
+			if redeemer, exists := mp.mempoolUTXOSet.poolTransactionBySpendingOutpoint(outpoint); exists {
+				if !evict(redeemer) {
+					return false
+				}
+			}
+		}
+
+		heap.Remove(&packagesByFeeRate, evictedPackage.index)
+		delete(packages, *evictedTxID)
+		totalMass -= evictedTx.Mass
+		forEachAncestorPackageCopy(evictedTx, func(ancestorPackage *transactionPackage) {
+			ancestorPackage.count--
+			ancestorPackage.mass -= evictedTx.Mass
+			ancestorPackage.fee -= evictedTx.Fee
+			heap.Fix(&packagesByFeeRate, ancestorPackage.index)
+		})
+		return true
+	}
+
+	for totalMass > mp.policy.MaxMempoolMass {
+		if !evict(packagesByFeeRate[0].transaction) {
+			return false
+		}
+	}
+	return true
+}
+
+// rollingMinimumFeeRate returns the fee rate, in sompi per gram, that
+// transactions must currently pay in order to enter the mempool on top of
+// the minimum relay fee. It is 0 unless the mempool had to evict
+// transactions recently.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *mempool) rollingMinimumFeeRate() float64 {
+	if mp.rollingMinimumFeeRateBase == 0 {
+		return 0
+	}
+	halfLives := float64(mstime.Since(mp.rollingMinimumFeeRateUpdateTime)) / float64(rollingMinimumFeeRateHalfLife)
+	rate := mp.rollingMinimumFeeRateBase * math.Pow(0.5, halfLives)
+
+	// Once the rate decays to the point where it barely makes a
+	// difference, it's dropped altogether
+	if rate < incrementalFeeRate/2 {
+		return 0
+	}
+	return rate
+}
+
+// raiseRollingMinimumFeeRate raises the rolling minimum fee rate to the
+// given rate, unless it is already higher
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *mempool) raiseRollingMinimumFeeRate(rate float64) {
+	if currentRate := mp.rollingMinimumFeeRate(); currentRate > rate {
+		rate = currentRate
+	}
+	mp.rollingMinimumFeeRateBase = rate
+	mp.rollingMinimumFeeRateUpdateTime = mstime.Now()
+}
+
+// checkRollingMinimumFeeRate rejects transactions that don't pay the
+// rolling minimum fee rate
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *mempool) checkRollingMinimumFeeRate(tx *consensusexternalapi.DomainTransaction) error {
+	rollingMinimumFeeRate := mp.rollingMinimumFeeRate()
+	if rollingMinimumFeeRate == 0 {
+		return nil
+	}
+	minimumFee := uint64(math.Ceil(rollingMinimumFeeRate * float64(tx.Mass)))
+	if tx.Fee < minimumFee {
+		str := fmt.Sprintf("transaction %s has %d fees which is under the required amount of %d "+
+			"while the mempool is full", consensushashing.TransactionID(tx), tx.Fee, minimumFee)
+		return txRuleError(RejectInsufficientFee, str)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package mempool
+
+import (
+	"testing"
+
+	consensusexternalapi "github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/subnetworks"
+	"github.com/kaspanet/kaspad/util/mstime"
+)
+
+// transactionForMempoolTest returns a transaction that spends the given
+// outpoint, and pays the given fee rate for a mass of 100
+func transactionForMempoolTest(previousOutpoint consensusexternalapi.DomainOutpoint,
+	feeRate uint64) *consensusexternalapi.DomainTransaction {
+
+	return &consensusexternalapi.DomainTransaction{
+		Inputs: []*consensusexternalapi.DomainTransactionInput{{PreviousOutpoint: previousOutpoint}},
+		Outputs: []*consensusexternalapi.DomainTransactionOutput{{
+			Value:           1000,
+			ScriptPublicKey: &consensusexternalapi.ScriptPublicKey{Script: []byte{0x51}},
+		}},
+		SubnetworkID: subnetworks.SubnetworkIDNative,
+		Mass:         100,
+		Fee:          feeRate * 100,
+	}
+}
+
+func TestLimitMempoolMass(t *testing.T) {
+	mp := New(nil, true, 300).(*mempool)
+
+	addTransaction := func(tx *consensusexternalapi.DomainTransaction,
+		parentsInPool ...consensusexternalapi.DomainOutpoint) {
+
+		_, err := mp.addTransaction(tx, tx.Mass, tx.Fee, parentsInPool)
+		if err != nil {
+			t.Fatalf("addTransaction: %s", err)
+		}
+	}
+	outpointForTest := func(index uint32) consensusexternalapi.DomainOutpoint {
+		return consensusexternalapi.DomainOutpoint{Index: index}
+	}
+
+	// The package of the parent and its chained child pays 1.5 sompi per
+	// gram on average, which is less than any of the other transactions,
+	// even though the child alone pays more than one of them
+	parent := transactionForMempoolTest(outpointForTest(0), 1)
+	addTransaction(parent)
+	childOutpoint := consensusexternalapi.DomainOutpoint{TransactionID: *consensushashing.TransactionID(parent)}
+	child := transactionForMempoolTest(childOutpoint, 2)
+	addTransaction(child, childOutpoint)
+	highFeeRateTransaction := transactionForMempoolTest(outpointForTest(1), 5)
+	addTransaction(highFeeRateTransaction)
+
+	err := mp.limitMempoolMass()
+	if err != nil {
+		t.Fatalf("limitMempoolMass: %s", err)
+	}
+	if mp.evictedTransactionCount != 0 {
+		t.Fatalf("expected nothing to be evicted while the mempool is within its mass limit")
+	}
+	if mp.rollingMinimumFeeRate() != 0 {
+		t.Fatalf("expected no rolling minimum fee rate before anything is evicted")
+	}
+
+	lowFeeRateTransaction := transactionForMempoolTest(outpointForTest(2), 3)
+	addTransaction(lowFeeRateTransaction)
+	err = mp.limitMempoolMass()
+	if err != nil {
+		t.Fatalf("limitMempoolMass: %s", err)
+	}
+
+	info := mp.Info()
+	if info.EvictedTransactionCount != 2 {
+		t.Fatalf("expected the parent and its child to be evicted, got %d evicted transactions",
+			info.EvictedTransactionCount)
+	}
+	for _, tx := range []*consensusexternalapi.DomainTransaction{parent, child} {
+		if mp.isTransactionInPool(consensushashing.TransactionID(tx)) {
+			t.Fatalf("expected transaction %s to be evicted", consensushashing.TransactionID(tx))
+		}
+	}
+	if info.TransactionCount != 2 || info.ChainedTransactionCount != 0 || info.TotalMass != 200 {
+		t.Fatalf("unexpected mempool info after eviction: %+v", info)
+	}
+	if len(mp.chainedTransactionByPreviousOutpoint) != 0 {
+		t.Fatalf("expected the evicted child to be removed from the chained transaction index")
+	}
+
+	// The evicted transactions can't re-enter right away
+	if rollingMinimumFeeRate := mp.rollingMinimumFeeRate(); rollingMinimumFeeRate < 1.5+incrementalFeeRate-0.01 {
+		t.Fatalf("expected the rolling minimum fee rate to rise above the evicted package, got %f",
+			rollingMinimumFeeRate)
+	}
+	err = mp.checkRollingMinimumFeeRate(transactionForMempoolTest(outpointForTest(3), 2))
+	if err == nil {
+		t.Fatalf("expected a transaction below the rolling minimum fee rate to be rejected")
+	}
+	err = mp.checkRollingMinimumFeeRate(transactionForMempoolTest(outpointForTest(3), 3))
+	if err != nil {
+		t.Fatalf("expected a transaction above the rolling minimum fee rate to be accepted, got: %s", err)
+	}
+
+	// The rolling minimum fee rate decays, until it's dropped altogether
+	mp.rollingMinimumFeeRateUpdateTime = mstime.Now().Add(-rollingMinimumFeeRateHalfLife)
+	if rollingMinimumFeeRate := mp.rollingMinimumFeeRate(); rollingMinimumFeeRate > 1.26 || rollingMinimumFeeRate < 1.24 {
+		t.Fatalf("expected the rolling minimum fee rate to halve after one half life, got %f",
+			rollingMinimumFeeRate)
+	}
+	mp.rollingMinimumFeeRateUpdateTime = mstime.Now().Add(-3 * rollingMinimumFeeRateHalfLife)
+	if mp.rollingMinimumFeeRate() != 0 {
+		t.Fatalf("expected the rolling minimum fee rate to be dropped once it decays, got %f",
+			mp.rollingMinimumFeeRate())
+	}
+}
+
+func TestTransactionPackagesFollowMempoolChanges(t *testing.T) {
+	mp := New(nil, true, 1000000).(*mempool)
+
+	addTransaction := func(tx *consensusexternalapi.DomainTransaction,
+		parentsInPool ...consensusexternalapi.DomainOutpoint) {
+
+		_, err := mp.addTransaction(tx, tx.Mass, tx.Fee, parentsInPool)
+		if err != nil {
+			t.Fatalf("addTransaction: %s", err)
+		}
+	}
+	outpointOf := func(tx *consensusexternalapi.DomainTransaction, index uint32) consensusexternalapi.DomainOutpoint {
+		return consensusexternalapi.DomainOutpoint{TransactionID: *consensushashing.TransactionID(tx), Index: index}
+	}
+
+	// The descendant of both children is a descendant of the parent
+	// through two paths
+	parent := transactionForMempoolTest(consensusexternalapi.DomainOutpoint{Index: 0}, 4)
+	parent.Outputs = append(parent.Outputs, parent.Outputs[0])
+	addTransaction(parent)
+	firstChild := transactionForMempoolTest(outpointOf(parent, 0), 3)
+	addTransaction(firstChild, outpointOf(parent, 0))
+	secondChild := transactionForMempoolTest(outpointOf(parent, 1), 5)
+	addTransaction(secondChild, outpointOf(parent, 1))
+	descendant := transactionForMempoolTest(outpointOf(firstChild, 0), 1)
+	descendant.Inputs = append(descendant.Inputs,
+		&consensusexternalapi.DomainTransactionInput{PreviousOutpoint: outpointOf(secondChild, 0)})
+	addTransaction(descendant, outpointOf(firstChild, 0), outpointOf(secondChild, 0))
+
+	expectPackages := func(expectedCounts map[*consensusexternalapi.DomainTransaction]int) {
+		if len(mp.transactionPackages) != len(expectedCounts) || len(mp.packagesByFeeRate) != len(expectedCounts) {
+			t.Fatalf("expected %d packages, got %d packages and %d heap entries", len(expectedCounts),
+				len(mp.transactionPackages), len(mp.packagesByFeeRate))
+		}
+		for tx, expectedCount := range expectedCounts {
+			expected := &transactionPackage{}
+			mp.addToTransactionPackage(expected, tx, make(map[consensusexternalapi.DomainTransactionID]struct{}))
+			txPackage := mp.transactionPackages[*consensushashing.TransactionID(tx)]
+			if txPackage.count != expectedCount || txPackage.count != expected.count ||
+				txPackage.mass != expected.mass || txPackage.fee != expected.fee {
+				t.Fatalf("the package of %s has %d transactions of mass %d and fee %d, "+
+					"but %d transactions of mass %d and fee %d are expected", consensushashing.TransactionID(tx),
+					txPackage.count, txPackage.mass, txPackage.fee, expectedCount, expected.mass, expected.fee)
+			}
+			if txPackage.feeRate() < mp.lowestFeeRatePackage().feeRate() {
+				t.Fatalf("the package of %s pays less than the lowest fee rate package",
+					consensushashing.TransactionID(tx))
+			}
+		}
+	}
+	expectPackages(map[*consensusexternalapi.DomainTransaction]int{
+		parent: 4, firstChild: 2, secondChild: 2, descendant: 1})
+	if mp.lowestFeeRatePackage().transaction != descendant {
+		t.Fatalf("expected the descendant to have the lowest fee rate package")
+	}
+
+	// The descendant is reachable from the parent through both children,
+	// but it's only one of the parent's descendants once
+	candidates := mp.BlockCandidateTransactions()
+	if len(candidates) != 1 || candidates[0].Transaction != parent {
+		t.Fatalf("expected the parent to be the only block candidate")
+	}
+	if len(candidates[0].Descendants) != 3 {
+		t.Fatalf("expected the parent to have 3 descendants, got %d", len(candidates[0].Descendants))
+	}
+
+	err := mp.removeTransactionAndItsChainedTransactions(firstChild)
+	if err != nil {
+		t.Fatalf("removeTransactionAndItsChainedTransactions: %s", err)
+	}
+	expectPackages(map[*consensusexternalapi.DomainTransaction]int{parent: 2, secondChild: 1})
+	if mp.lowestFeeRatePackage().transaction != parent {
+		t.Fatalf("expected the parent to have the lowest fee rate package")
+	}
+}
+
+func TestBlockCandidateTransactionDescendants(t *testing.T) {
+	mp := New(nil, true, 1000000).(*mempool)
+
+	parent := transactionForMempoolTest(consensusexternalapi.DomainOutpoint{Index: 0}, 1)
+	_, err := mp.addTransaction(parent, parent.Mass, parent.Fee, nil)
+	if err != nil {
+		t.Fatalf("addTransaction: %s", err)
+	}
+	childOutpoint := consensusexternalapi.DomainOutpoint{TransactionID: *consensushashing.TransactionID(parent)}
+	child := transactionForMempoolTest(childOutpoint, 5)
+	_, err = mp.addTransaction(child, child.Mass, child.Fee, []consensusexternalapi.DomainOutpoint{childOutpoint})
+	if err != nil {
+		t.Fatalf("addTransaction: %s", err)
+	}
+
+	// The chained child isn't a candidate, but it's one of its parent's descendants
+	candidates := mp.BlockCandidateTransactions()
+	if len(candidates) != 1 || candidates[0].Transaction != parent {
+		t.Fatalf("expected only the parent to be a block candidate")
+	}
+	if len(candidates[0].Descendants) != 1 || candidates[0].Descendants[0] != child {
+		t.Fatalf("expected the child to be the only descendant of the parent")
+	}
+}
@@ -0,0 +1,282 @@
+package mempool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/kaspanet/kaspad/domain/consensus/database/serialization"
+	consensusexternalapi "github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/pkg/errors"
+)
+
+// mempoolFileVersion is the version of the format of the file the mempool
+// is saved to. Files of other versions are not loaded.
+const mempoolFileVersion uint32 = 1
+
+// maxSerializedTransactionSize is the maximum size of a serialized
+// transaction in the mempool file. A transaction's mass is never lower than
+// its size, so no valid transaction is bigger than a block's maximum mass.
+const maxSerializedTransactionSize = 1000000
+
+// SaveToFile writes all the transactions and orphans in the mempool to the
+// file at the given path, to be loaded with LoadFromFile when the node
+// restarts
+func (mp *mempool) SaveToFile(path string) error {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	transactions := mp.transactionsInDependencyOrder()
+	orphans := make([]*consensusexternalapi.DomainTransaction, 0, len(mp.orphans))
+	for _, orphan := range mp.orphans {
+		orphans = append(orphans, orphan.tx)
+	}
+
+	// The file is written under a temporary name first, so that a crash
+	// while writing it never leaves a truncated file behind
+	temporaryPath := path + ".tmp"
+	err := writeMempoolFile(temporaryPath, transactions, orphans)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(temporaryPath, path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	log.Infof("Saved %d mempool transactions and %d orphans to %s", len(transactions), len(orphans), path)
+	return nil
+}
+
+// LoadFromFile revalidates the transactions and orphans saved by SaveToFile
+// against the current virtual, and inserts the ones that are still valid.
+// Transactions that were accepted in the meantime are no longer valid, so
+// they are dropped along with the invalid ones. Nothing is loaded if the
+// file doesn't exist, and a file that can't be read is moved aside, so
+// that the node starts with an empty mempool rather than not starting.
+func (mp *mempool) LoadFromFile(path string) error {
+	loadedCount, droppedCount, err := mp.loadFromFile(path)
+	if err != nil {
+		return err
+	}
+	if loadedCount+droppedCount > 0 {
+		log.Infof("Loaded %d out of %d transactions from %s. Dropped %d transactions that were accepted "+
+			"since the mempool was saved or are no longer valid", loadedCount, loadedCount+droppedCount, path,
+			droppedCount)
+	}
+	return nil
+}
+
+// loadFromFile implements LoadFromFile. It returns the number of
+// transactions that were inserted into the mempool, and the number of
+// transactions that were dropped.
+func (mp *mempool) loadFromFile(path string) (loadedCount int, droppedCount int, err error) {
+	transactions, orphans, err := readMempoolFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, 0, nil
+		}
+		unreadablePath := path + ".bad"
+		log.Warnf("Starting with an empty mempool, since the mempool file %s can't be read: %s. "+
+			"It was moved to %s", path, err, unreadablePath)
+		err = os.Rename(path, unreadablePath)
+		if err != nil {
+			return 0, 0, errors.WithStack(err)
+		}
+		return 0, 0, nil
+	}
+
+	// The file is removed once it's read, so that a crash doesn't bring
+	// back transactions that were accepted, evicted or replaced since
+	err = os.Remove(path)
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+
+	insertTransactions := func(transactions []*consensusexternalapi.DomainTransaction, allowOrphan bool) error {
+		for _, transaction := range transactions {
+			err := mp.ValidateAndInsertTransaction(transaction, allowOrphan)
+			if err != nil {
+				if !errors.As(err, &RuleError{}) {
+					return err
+				}
+				log.Debugf("Dropped transaction %s loaded from %s: %s",
+					consensushashing.TransactionID(transaction), path, err)
+				droppedCount++
+				continue
+			}
+			loadedCount++
+		}
+		return nil
+	}
+
+	// Transactions are saved after the transactions they spend outputs of,
+	// so none of them is mistaken for an orphan
+	err = insertTransactions(transactions, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = insertTransactions(orphans, true)
+	if err != nil {
+		return 0, 0, err
+	}
+	return loadedCount, droppedCount, nil
+}
+
+// transactionsInDependencyOrder returns the transactions in the main pool
+// and the chained transactions, ordered so that every transaction comes
+// after the transactions whose outputs it spends
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *mempool) transactionsInDependencyOrder() []*consensusexternalapi.DomainTransaction {
+	transactions := make([]*consensusexternalapi.DomainTransaction, 0, len(mp.pool)+len(mp.chainedTransactions))
+	visited := make(map[consensusexternalapi.DomainTransactionID]struct{})
+
+	var visit func(transaction *consensusexternalapi.DomainTransaction)
+	visit = func(transaction *consensusexternalapi.DomainTransaction) {
+		transactionID := consensushashing.TransactionID(transaction)
+		if _, ok := visited[*transactionID]; ok {
+			return
+		}
+		visited[*transactionID] = struct{}{}
+
+		for _, input := range transaction.Inputs {
+			parent, ok := mp.fetchTxDesc(&input.PreviousOutpoint.TransactionID)
+			if ok {
+				visit(parent.DomainTransaction)
+			}
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	for _, txDesc := range mp.pool {
+		visit(txDesc.DomainTransaction)
+	}
+	for _, txDesc := range mp.chainedTransactions {
+		visit(txDesc.DomainTransaction)
+	}
+	return transactions
+}
+
+func writeMempoolFile(path string, transactions []*consensusexternalapi.DomainTransaction,
+	orphans []*consensusexternalapi.DomainTransaction) (err error) {
+
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		closeErr := file.Close()
+		if err == nil {
+			err = errors.WithStack(closeErr)
+		}
+	}()
+
+	writer := bufio.NewWriter(file)
+	err = binary.Write(writer, binary.LittleEndian, mempoolFileVersion)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = writeTransactions(writer, transactions)
+	if err != nil {
+		return err
+	}
+	err = writeTransactions(writer, orphans)
+	if err != nil {
+		return err
+	}
+	return errors.WithStack(writer.Flush())
+}
+
+func readMempoolFile(path string) (transactions []*consensusexternalapi.DomainTransaction,
+	orphans []*consensusexternalapi.DomainTransaction, err error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var version uint32
+	err = binary.Read(reader, binary.LittleEndian, &version)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "error reading the mempool file %s", path)
+	}
+	if version != mempoolFileVersion {
+		return nil, nil, errors.Errorf("mempool file %s is of version %d, while only version %d is supported",
+			path, version, mempoolFileVersion)
+	}
+	transactions, err = readTransactions(reader)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "error reading the mempool file %s", path)
+	}
+	orphans, err = readTransactions(reader)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "error reading the mempool file %s", path)
+	}
+	return transactions, orphans, nil
+}
+
+func writeTransactions(writer io.Writer, transactions []*consensusexternalapi.DomainTransaction) error {
+	err := binary.Write(writer, binary.LittleEndian, uint64(len(transactions)))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, transaction := range transactions {
+		serializedTransaction, err := proto.Marshal(serialization.DomainTransactionToDbTransaction(transaction))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = binary.Write(writer, binary.LittleEndian, uint32(len(serializedTransaction)))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		_, err = writer.Write(serializedTransaction)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func readTransactions(reader io.Reader) ([]*consensusexternalapi.DomainTransaction, error) {
+	var count uint64
+	err := binary.Read(reader, binary.LittleEndian, &count)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	transactions := make([]*consensusexternalapi.DomainTransaction, 0)
+	for i := uint64(0); i < count; i++ {
+		var size uint32
+		err := binary.Read(reader, binary.LittleEndian, &size)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if size > maxSerializedTransactionSize {
+			return nil, errors.Errorf("transaction of size %d is bigger than the maximum of %d",
+				size, maxSerializedTransactionSize)
+		}
+		serializedTransaction := make([]byte, size)
+		_, err = io.ReadFull(reader, serializedTransaction)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		dbTransaction := &serialization.DbTransaction{}
+		err = proto.Unmarshal(serializedTransaction, dbTransaction)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		transaction, err := serialization.DbTransactionToDomainTransaction(dbTransaction)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, nil
+}
@@ -0,0 +1,157 @@
+package mempool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	consensusexternalapi "github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/constants"
+)
+
+func TestSaveToFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestSaveToFile")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mempool.dat")
+
+	mp := New(nil, true, 1000000).(*mempool)
+
+	// A chain of transactions, each spending the output of the previous one
+	chain := make([]*consensusexternalapi.DomainTransaction, 5)
+	var parentsInPool []consensusexternalapi.DomainOutpoint
+	outpoint := consensusexternalapi.DomainOutpoint{Index: 1}
+	for i := range chain {
+		chain[i] = transactionForMempoolTest(outpoint, 1)
+		_, err := mp.addTransaction(chain[i], chain[i].Mass, chain[i].Fee, parentsInPool)
+		if err != nil {
+			t.Fatalf("addTransaction: %s", err)
+		}
+		outpoint = consensusexternalapi.DomainOutpoint{TransactionID: *consensushashing.TransactionID(chain[i])}
+		parentsInPool = []consensusexternalapi.DomainOutpoint{outpoint}
+	}
+	orphan := transactionForMempoolTest(consensusexternalapi.DomainOutpoint{Index: 2}, 1)
+	mp.addOrphan(orphan)
+
+	err = mp.SaveToFile(path)
+	if err != nil {
+		t.Fatalf("SaveToFile: %s", err)
+	}
+	transactions, orphans, err := readMempoolFile(path)
+	if err != nil {
+		t.Fatalf("readMempoolFile: %s", err)
+	}
+
+	// The transactions are saved in the order they spend each other's
+	// outputs, no matter in which order the mempool holds them
+	if len(transactions) != len(chain) {
+		t.Fatalf("expected %d transactions to be saved, got %d", len(chain), len(transactions))
+	}
+	for i, transaction := range transactions {
+		if !consensushashing.TransactionID(transaction).Equal(consensushashing.TransactionID(chain[i])) {
+			t.Fatalf("expected transaction %d of the chain to be saved in place %d", i, i)
+		}
+	}
+	if len(orphans) != 1 || !consensushashing.TransactionID(orphans[0]).Equal(consensushashing.TransactionID(orphan)) {
+		t.Fatalf("expected the orphan to be saved")
+	}
+}
+
+func TestLoadFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestLoadFromFile")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mempool.dat")
+
+	tc, fundingTransaction, teardown := consensusForMempoolTest(t, "TestLoadFromFile")
+	defer teardown()
+	mp := New(tc, true, 1000000).(*mempool)
+
+	// A node that never saved its mempool starts with an empty one
+	loadedCount, droppedCount, err := mp.loadFromFile(path)
+	if err != nil {
+		t.Fatalf("loadFromFile: %s", err)
+	}
+	if loadedCount != 0 || droppedCount != 0 {
+		t.Fatalf("expected nothing to be loaded without a file, got %d loaded and %d dropped transactions",
+			loadedCount, droppedCount)
+	}
+
+	// A parent and its chained child are saved, and the parent is accepted
+	// by the DAG before the mempool is loaded, so only the child is loaded
+	parent := spendingTransactionForTest(t, fundingTransaction, 1000, constants.MaxTxInSequenceNum)
+	child := spendingTransactionForTest(t, parent, 1000, constants.MaxTxInSequenceNum)
+	err = writeMempoolFile(path, []*consensusexternalapi.DomainTransaction{parent, child}, nil)
+	if err != nil {
+		t.Fatalf("writeMempoolFile: %s", err)
+	}
+	virtualInfo, err := tc.GetVirtualInfo()
+	if err != nil {
+		t.Fatalf("GetVirtualInfo: %+v", err)
+	}
+	_, _, err = tc.AddBlock(virtualInfo.ParentHashes, nil, []*consensusexternalapi.DomainTransaction{parent})
+	if err != nil {
+		t.Fatalf("AddBlock: %+v", err)
+	}
+
+	loadedCount, droppedCount, err = mp.loadFromFile(path)
+	if err != nil {
+		t.Fatalf("loadFromFile: %s", err)
+	}
+	if loadedCount != 1 || droppedCount != 1 {
+		t.Fatalf("expected a single transaction to be loaded and a single one to be dropped, "+
+			"got %d loaded and %d dropped transactions", loadedCount, droppedCount)
+	}
+	if mp.isTransactionInPool(consensushashing.TransactionID(parent)) ||
+		!mp.isTransactionInPool(consensushashing.TransactionID(child)) {
+		t.Fatalf("expected only the child to be loaded")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected the file to be removed once it's loaded")
+	}
+
+	// Files that can't be read, such as files of other versions and
+	// truncated files, are moved aside, and nothing is loaded from them
+	unsupportedVersion := make([]byte, 4)
+	binary.LittleEndian.PutUint32(unsupportedVersion, mempoolFileVersion+1)
+	err = writeMempoolFile(path, []*consensusexternalapi.DomainTransaction{
+		transactionForMempoolTest(consensusexternalapi.DomainOutpoint{}, 1)}, nil)
+	if err != nil {
+		t.Fatalf("writeMempoolFile: %s", err)
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %s", err)
+	}
+	for _, unreadableContent := range [][]byte{unsupportedVersion, content[:len(content)-1]} {
+		err = ioutil.WriteFile(path, unreadableContent, 0600)
+		if err != nil {
+			t.Fatalf("WriteFile: %s", err)
+		}
+		loadedCount, droppedCount, err = mp.loadFromFile(path)
+		if err != nil {
+			t.Fatalf("loadFromFile: %s", err)
+		}
+		if loadedCount != 0 || droppedCount != 0 {
+			t.Fatalf("expected nothing to be loaded from an unreadable file")
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("expected the unreadable file to be moved aside")
+		}
+		movedContent, err := ioutil.ReadFile(path + ".bad")
+		if err != nil {
+			t.Fatalf("ReadFile: %s", err)
+		}
+		if !bytes.Equal(movedContent, unreadableContent) {
+			t.Fatalf("expected the unreadable file to be moved as is")
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package mempool
+
+import (
+	"fmt"
+
+	consensusexternalapi "github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/constants"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/estimatedsize"
+)
+
+const (
+	// maxReplacedTransactions is the maximum number of transactions,
+	// including descendants, that a single transaction may replace. It
+	// bounds the work a replacement can cause, since every replaced
+	// transaction had to be validated and relayed.
+	maxReplacedTransactions = 100
+
+	// replaceableSequenceThreshold is the sequence number below which an
+	// input signals that its transaction may be replaced by a transaction
+	// that pays a higher fee. Transactions that use the maximum sequence
+	// numbers are final, and are never replaced.
+	replaceableSequenceThreshold = constants.MaxTxInSequenceNum - 1
+)
+
+// isReplaceable returns whether the given transaction opted in to be
+// replaced by a conflicting transaction that pays a higher fee
+func isReplaceable(tx *consensusexternalapi.DomainTransaction) bool {
+	for _, input := range tx.Inputs {
+		if input.Sequence < replaceableSequenceThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceTransactions removes the given transactions, which the passed
+// transaction double spends, along with all the transactions that depend on
+// them, so that the passed transaction can take their place. It fails if the
+// passed transaction doesn't pay the fees of all the transactions it replaces
+// together plus its own minimum relay fee, if it doesn't pay a higher fee
+// rate than any of the transactions it directly conflicts with, or if the
+// mempool is full and it would be evicted right after replacing them. All the
+// checks are done before anything is removed, so a replacement that fails
+// leaves the mempool as it was.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *mempool) replaceTransactions(tx *consensusexternalapi.DomainTransaction,
+	conflicts []*consensusexternalapi.DomainTransaction, parentsInPool []consensusexternalapi.DomainOutpoint) error {
+
+	txID := consensushashing.TransactionID(tx)
+
+	// All the conflicts, along with their descendants, are collected into
+	// a single package, so descendants shared between conflicts are
+	// counted once
+	replaced := &transactionPackage{}
+	visited := make(map[consensusexternalapi.DomainTransactionID]struct{})
+	for _, conflict := range conflicts {
+		mp.addToTransactionPackage(replaced, conflict, visited)
+	}
+
+	if replaced.count > maxReplacedTransactions {
+		str := fmt.Sprintf("transaction %s would replace %d transactions, which is more than the "+
+			"maximum of %d", txID, replaced.count, maxReplacedTransactions)
+		return txRuleError(RejectNonstandard, str)
+	}
+
+	for _, parentOutpoint := range parentsInPool {
+		if _, ok := visited[parentOutpoint.TransactionID]; ok {
+			str := fmt.Sprintf("transaction %s spends an output of transaction %s, which it replaces",
+				txID, parentOutpoint.TransactionID)
+			return txRuleError(RejectInvalid, str)
+		}
+	}
+
+	// The replacement pays for its own relay on top of the fees of the
+	// transactions it replaces, so that every replacement of the same
+	// outputs costs at least the minimum relay fee
+	serializedSize := int64(estimatedsize.TransactionEstimatedSerializedSize(tx))
+	minimumFee := replaced.fee + uint64(calcMinRequiredTxRelayFee(serializedSize, mp.policy.MinRelayTxFee))
+	if tx.Fee < minimumFee {
+		str := fmt.Sprintf("transaction %s has %d fees, which is under the %d fees of the %d transactions "+
+			"it replaces plus its minimum relay fee, %d in total", txID, tx.Fee, replaced.fee, replaced.count,
+			minimumFee)
+		return txRuleError(RejectInsufficientFee, str)
+	}
+
+	txFeeRate := float64(tx.Fee) / float64(tx.Mass)
+	for _, conflict := range conflicts {
+		conflictFeeRate := float64(conflict.Fee) / float64(conflict.Mass)
+		if txFeeRate <= conflictFeeRate {
+			str := fmt.Sprintf("transaction %s pays %f sompi per gram, which is not higher than the "+
+				"%f sompi per gram of transaction %s, which it replaces", txID, txFeeRate, conflictFeeRate,
+				consensushashing.TransactionID(conflict))
+			return txRuleError(RejectInsufficientFee, str)
+		}
+	}
+
+	if !mp.survivesEviction(tx, replaced, visited) {
+		str := fmt.Sprintf("transaction %s was not accepted because the mempool is full, and it would be "+
+			"evicted right after replacing %d transactions", txID, replaced.count)
+		return txRuleError(RejectInsufficientFee, str)
+	}
+
+	// Make sure the transaction can take the place of the transactions it
+	// replaces, so that they're never removed for nothing
+	err := mp.mempoolUTXOSet.checkAddTx(tx, visited)
+	if err != nil {
+		return err
+	}
+
+	for _, conflict := range conflicts {
+		// A conflict may have already been removed as a descendant of
+		// another conflict
+		if !mp.isTransactionInPool(consensushashing.TransactionID(conflict)) {
+			continue
+		}
+		err := mp.removeTransactionAndItsChainedTransactions(conflict)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Debugf("Transaction %s replaced %d transactions", txID, replaced.count)
+	return nil
+}
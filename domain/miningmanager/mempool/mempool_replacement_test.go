@@ -0,0 +1,265 @@
+package mempool
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kaspanet/kaspad/domain/consensus"
+	consensusexternalapi "github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/model/testapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/constants"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/testutils"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/transactionhelper"
+	"github.com/kaspanet/kaspad/domain/dagconfig"
+)
+
+func TestReplaceTransactions(t *testing.T) {
+	mp := New(nil, true, 1000000).(*mempool)
+
+	addTransaction := func(tx *consensusexternalapi.DomainTransaction,
+		parentsInPool ...consensusexternalapi.DomainOutpoint) {
+
+		_, err := mp.addTransaction(tx, tx.Mass, tx.Fee, parentsInPool)
+		if err != nil {
+			t.Fatalf("addTransaction: %s", err)
+		}
+	}
+	outputOf := func(tx *consensusexternalapi.DomainTransaction) consensusexternalapi.DomainOutpoint {
+		return consensusexternalapi.DomainOutpoint{TransactionID: *consensushashing.TransactionID(tx)}
+	}
+
+	// A final transaction can't be replaced
+	finalOutpoint := consensusexternalapi.DomainOutpoint{Index: 0}
+	final := transactionForMempoolTest(finalOutpoint, 1)
+	final.Inputs[0].Sequence = constants.MaxTxInSequenceNum
+	addTransaction(final)
+	_, err := mp.checkPoolDoubleSpend(transactionForMempoolTest(finalOutpoint, 10))
+	if err == nil {
+		t.Fatalf("expected a double spend of a final transaction to be rejected")
+	}
+
+	// A replaceable transaction with a chained child, paying 600 sompi together
+	originalOutpoint := consensusexternalapi.DomainOutpoint{Index: 1}
+	original := transactionForMempoolTest(originalOutpoint, 5)
+	addTransaction(original)
+	child := transactionForMempoolTest(outputOf(original), 1)
+	addTransaction(child, outputOf(original))
+
+	tests := []struct {
+		name          string
+		replacement   *consensusexternalapi.DomainTransaction
+		parentsInPool []consensusexternalapi.DomainOutpoint
+		expectedError string
+	}{
+		{
+			name:          "lower total fee",
+			replacement:   transactionForMempoolTest(originalOutpoint, 6),
+			expectedError: "under the 600 fees of the 2 transactions it replaces plus its minimum relay fee",
+		},
+		{
+			name: "lower fee rate",
+			replacement: func() *consensusexternalapi.DomainTransaction {
+				tx := transactionForMempoolTest(originalOutpoint, 1)
+				tx.Mass = 1000
+				tx.Fee = 1000
+				return tx
+			}(),
+			expectedError: "sompi per gram",
+		},
+		{
+			name: "spends a replaced transaction",
+			replacement: func() *consensusexternalapi.DomainTransaction {
+				tx := transactionForMempoolTest(originalOutpoint, 10)
+				tx.Inputs = append(tx.Inputs, &consensusexternalapi.DomainTransactionInput{
+					PreviousOutpoint: consensusexternalapi.DomainOutpoint{
+						TransactionID: *consensushashing.TransactionID(child)},
+				})
+				return tx
+			}(),
+			parentsInPool: []consensusexternalapi.DomainOutpoint{{TransactionID: *consensushashing.TransactionID(child)}},
+			expectedError: "which it replaces",
+		},
+	}
+	for _, test := range tests {
+		conflicts, err := mp.checkPoolDoubleSpend(test.replacement)
+		if err != nil {
+			t.Fatalf("%s: checkPoolDoubleSpend: %s", test.name, err)
+		}
+		if len(conflicts) != 1 {
+			t.Fatalf("%s: expected a single conflict, got %d", test.name, len(conflicts))
+		}
+		err = mp.replaceTransactions(test.replacement, conflicts, test.parentsInPool)
+		if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+			t.Fatalf("%s: expected an error containing %q, got %v", test.name, test.expectedError, err)
+		}
+		if !mp.isTransactionInPool(consensushashing.TransactionID(original)) {
+			t.Fatalf("%s: expected a rejected replacement to leave the original in the mempool", test.name)
+		}
+	}
+
+	// A replacement that couldn't be added after replacing its conflicts,
+	// here because it also spends the output of the final transaction,
+	// leaves them in the mempool
+	unaddableReplacement := transactionForMempoolTest(originalOutpoint, 10)
+	unaddableReplacement.Inputs = append(unaddableReplacement.Inputs,
+		&consensusexternalapi.DomainTransactionInput{PreviousOutpoint: finalOutpoint})
+	err = mp.replaceTransactions(unaddableReplacement, []*consensusexternalapi.DomainTransaction{original}, nil)
+	if err == nil || !strings.Contains(err.Error(), "is already used by") {
+		t.Fatalf("expected a replacement that can't be added to fail, got %v", err)
+	}
+	for _, tx := range []*consensusexternalapi.DomainTransaction{original, child} {
+		if !mp.isTransactionInPool(consensushashing.TransactionID(tx)) {
+			t.Fatalf("expected a failed replacement to leave transaction %s in the mempool",
+				consensushashing.TransactionID(tx))
+		}
+	}
+
+	replacement := transactionForMempoolTest(originalOutpoint, 8)
+	conflicts, err := mp.checkPoolDoubleSpend(replacement)
+	if err != nil {
+		t.Fatalf("checkPoolDoubleSpend: %s", err)
+	}
+	err = mp.replaceTransactions(replacement, conflicts, nil)
+	if err != nil {
+		t.Fatalf("replaceTransactions: %s", err)
+	}
+	for _, tx := range []*consensusexternalapi.DomainTransaction{original, child} {
+		if mp.isTransactionInPool(consensushashing.TransactionID(tx)) {
+			t.Fatalf("expected transaction %s to be replaced", consensushashing.TransactionID(tx))
+		}
+	}
+	addTransaction(replacement)
+	if mp.totalMass != 200 {
+		t.Fatalf("expected the mempool to only hold the final transaction and the replacement, "+
+			"but its mass is %d", mp.totalMass)
+	}
+}
+
+func TestReplaceTooManyTransactions(t *testing.T) {
+	mp := New(nil, true, 1000000).(*mempool)
+
+	originalOutpoint := consensusexternalapi.DomainOutpoint{Index: 0}
+	parent := transactionForMempoolTest(originalOutpoint, 1)
+	_, err := mp.addTransaction(parent, parent.Mass, parent.Fee, nil)
+	if err != nil {
+		t.Fatalf("addTransaction: %s", err)
+	}
+	for i := 0; i < maxReplacedTransactions; i++ {
+		parentOutpoint := consensusexternalapi.DomainOutpoint{TransactionID: *consensushashing.TransactionID(parent)}
+		child := transactionForMempoolTest(parentOutpoint, 1)
+		_, err := mp.addTransaction(child, child.Mass, child.Fee, []consensusexternalapi.DomainOutpoint{parentOutpoint})
+		if err != nil {
+			t.Fatalf("addTransaction: %s", err)
+		}
+		parent = child
+	}
+
+	replacement := transactionForMempoolTest(originalOutpoint, 1000)
+	conflicts, err := mp.checkPoolDoubleSpend(replacement)
+	if err != nil {
+		t.Fatalf("checkPoolDoubleSpend: %s", err)
+	}
+	err = mp.replaceTransactions(replacement, conflicts, nil)
+	if err == nil || !strings.Contains(err.Error(), "more than the maximum") {
+		t.Fatalf("expected replacing too many transactions to fail, got %v", err)
+	}
+}
+
+// consensusForMempoolTest returns a test consensus whose virtual UTXO set
+// holds the coinbase output of a block, which pays to an OP_TRUE script and
+// can be spent right away
+func consensusForMempoolTest(t *testing.T, testName string) (tc testapi.TestConsensus,
+	fundingTransaction *consensusexternalapi.DomainTransaction, teardown func()) {
+
+	params := dagconfig.SimnetParams
+	params.SkipProofOfWork = true
+	params.BlockCoinbaseMaturity = 0
+	tc, teardownConsensus, err := consensus.NewFactory().NewTestConsensus(&params, false, testName)
+	if err != nil {
+		t.Fatalf("NewTestConsensus: %+v", err)
+	}
+
+	firstBlockHash, _, err := tc.AddBlock([]*consensusexternalapi.DomainHash{params.GenesisHash}, nil, nil)
+	if err != nil {
+		t.Fatalf("AddBlock: %+v", err)
+	}
+	fundingBlockHash, _, err := tc.AddBlock([]*consensusexternalapi.DomainHash{firstBlockHash}, nil, nil)
+	if err != nil {
+		t.Fatalf("AddBlock: %+v", err)
+	}
+	fundingBlock, err := tc.GetBlock(fundingBlockHash)
+	if err != nil {
+		t.Fatalf("GetBlock: %+v", err)
+	}
+	return tc, fundingBlock.Transactions[transactionhelper.CoinbaseTransactionIndex], func() { teardownConsensus(false) }
+}
+
+// spendingTransactionForTest returns a transaction that spends the first
+// output of the given transaction and pays the given fee. The sequence
+// number of its input decides whether it can be replaced.
+func spendingTransactionForTest(t *testing.T, txToSpend *consensusexternalapi.DomainTransaction, fee uint64,
+	sequence uint64) *consensusexternalapi.DomainTransaction {
+
+	tx, err := testutils.CreateTransaction(txToSpend)
+	if err != nil {
+		t.Fatalf("CreateTransaction: %+v", err)
+	}
+	tx.Inputs[0].Sequence = sequence
+	tx.Outputs[0].Value = txToSpend.Outputs[0].Value - fee
+	return tx
+}
+
+func TestValidateAndInsertReplacement(t *testing.T) {
+	tc, fundingTransaction, teardown := consensusForMempoolTest(t, "TestValidateAndInsertReplacement")
+	defer teardown()
+	mp := New(tc, true, 1000000).(*mempool)
+
+	const replaceableSequence = constants.MaxTxInSequenceNum - 2
+	original := spendingTransactionForTest(t, fundingTransaction, 1000, replaceableSequence)
+	err := mp.ValidateAndInsertTransaction(original, false)
+	if err != nil {
+		t.Fatalf("ValidateAndInsertTransaction: %+v", err)
+	}
+
+	// A replacement has to pay its own minimum relay fee on top of the
+	// fee of the transaction it replaces
+	replacement := spendingTransactionForTest(t, fundingTransaction, 1001, replaceableSequence)
+	err = mp.ValidateAndInsertTransaction(replacement, false)
+	if err == nil || !strings.Contains(err.Error(), "plus its minimum relay fee") {
+		t.Fatalf("expected a replacement that doesn't pay for its own relay to be rejected, got %v", err)
+	}
+
+	// A replacement that the mempool would evict right away, since it's
+	// bigger than the whole mempool, leaves the original in place
+	mp.policy.MaxMempoolMass = original.Mass
+	replacement = spendingTransactionForTest(t, fundingTransaction, 5000, replaceableSequence)
+	replacement.Outputs = append(replacement.Outputs, replacement.Outputs[0].Clone())
+	replacement.Outputs[0].Value -= 1000
+	replacement.Outputs[1].Value = 1000
+	err = mp.ValidateAndInsertTransaction(replacement, false)
+	if err == nil || !strings.Contains(err.Error(), "evicted right after replacing") {
+		t.Fatalf("expected a replacement that would be evicted to be rejected, got %v", err)
+	}
+	if !mp.isTransactionInPool(consensushashing.TransactionID(original)) {
+		t.Fatalf("expected a rejected replacement to leave the original in the mempool")
+	}
+	mp.policy.MaxMempoolMass = 1000000
+
+	// A final replacement takes the place of the original, and can't be
+	// replaced itself
+	replacement = spendingTransactionForTest(t, fundingTransaction, 5000, constants.MaxTxInSequenceNum)
+	err = mp.ValidateAndInsertTransaction(replacement, false)
+	if err != nil {
+		t.Fatalf("ValidateAndInsertTransaction: %+v", err)
+	}
+	if mp.isTransactionInPool(consensushashing.TransactionID(original)) ||
+		!mp.isTransactionInPool(consensushashing.TransactionID(replacement)) {
+		t.Fatalf("expected the replacement to take the place of the original")
+	}
+	err = mp.ValidateAndInsertTransaction(spendingTransactionForTest(t, fundingTransaction, 50000,
+		constants.MaxTxInSequenceNum), false)
+	if err == nil {
+		t.Fatalf("expected a double spend of a final transaction to be rejected")
+	}
+}
@@ -57,20 +57,47 @@ func (mpus *mempoolUTXOSet) checkExists(tx *consensusexternalapi.DomainTransacti
 // addTx adds a transaction to the mempool UTXO set. It assumes that it doesn't double spend another transaction
 // in the mempool, and that its outputs doesn't exist in the mempool UTXO set, and returns error otherwise.
 func (mpus *mempoolUTXOSet) addTx(tx *consensusexternalapi.DomainTransaction) error {
+	err := mpus.checkAddTx(tx, nil)
+	if err != nil {
+		return err
+	}
+
 	for _, txIn := range tx.Inputs {
-		if existingTx, exists := mpus.transactionByPreviousOutpoint[txIn.PreviousOutpoint]; exists {
-			return errors.Errorf("outpoint %s is already used by %s", txIn.PreviousOutpoint, consensushashing.TransactionID(existingTx))
-		}
 		mpus.transactionByPreviousOutpoint[txIn.PreviousOutpoint] = tx
 	}
 
 	for i, txOut := range tx.Outputs {
 		outpoint := consensusexternalapi.DomainOutpoint{TransactionID: *consensushashing.TransactionID(tx), Index: uint32(i)}
+		mpus.poolUnspentOutputs[outpoint] =
+			utxo.NewUTXOEntry(txOut.Value, txOut.ScriptPublicKey, false, unacceptedBlueScore)
+	}
+	return nil
+}
+
+// checkAddTx checks whether the given transaction could be added to the
+// mempool UTXO set once the transactions with the given IDs are removed
+// from it, without modifying it.
+func (mpus *mempoolUTXOSet) checkAddTx(tx *consensusexternalapi.DomainTransaction,
+	removedTxIDs map[consensusexternalapi.DomainTransactionID]struct{}) error {
+
+	for _, txIn := range tx.Inputs {
+		if existingTx, exists := mpus.transactionByPreviousOutpoint[txIn.PreviousOutpoint]; exists {
+			existingTxID := consensushashing.TransactionID(existingTx)
+			if _, ok := removedTxIDs[*existingTxID]; !ok {
+				return errors.Errorf("outpoint %s is already used by %s", txIn.PreviousOutpoint, existingTxID)
+			}
+		}
+	}
+
+	txID := consensushashing.TransactionID(tx)
+	if _, ok := removedTxIDs[*txID]; ok {
+		return nil
+	}
+	for i := range tx.Outputs {
+		outpoint := consensusexternalapi.DomainOutpoint{TransactionID: *txID, Index: uint32(i)}
 		if _, exists := mpus.poolUnspentOutputs[outpoint]; exists {
 			return errors.Errorf("outpoint %s already exists", outpoint)
 		}
-		mpus.poolUnspentOutputs[outpoint] =
-			utxo.NewUTXOEntry(txOut.Value, txOut.ScriptPublicKey, false, unacceptedBlueScore)
 	}
 	return nil
 }
@@ -13,11 +13,16 @@ type MiningManager interface {
 	AllTransactions() []*consensusexternalapi.DomainTransaction
 	HandleNewBlockTransactions(txs []*consensusexternalapi.DomainTransaction) ([]*consensusexternalapi.DomainTransaction, error)
 	ValidateAndInsertTransaction(transaction *consensusexternalapi.DomainTransaction, allowOrphan bool) error
+	GetFeeEstimate() *miningmanagermodel.FeeEstimate
+	GetMempoolInfo() *miningmanagermodel.MempoolInfo
+	SaveMempool(path string) error
+	LoadMempool(path string) error
 }
 
 type miningManager struct {
 	mempool              miningmanagermodel.Mempool
 	blockTemplateBuilder miningmanagermodel.BlockTemplateBuilder
+	feeEstimator         miningmanagermodel.FeeEstimator
 }
 
 // GetBlockTemplate creates a block template for a miner to consume
@@ -27,6 +32,9 @@ func (mm *miningManager) GetBlockTemplate(coinbaseData *consensusexternalapi.Dom
 
 // HandleNewBlock handles the transactions for a new block that was just added to the DAG
 func (mm *miningManager) HandleNewBlockTransactions(txs []*consensusexternalapi.DomainTransaction) ([]*consensusexternalapi.DomainTransaction, error) {
+	// The fee estimator needs the transactions' fees, which are only
+	// known while the transactions are still in the mempool
+	mm.feeEstimator.HandleNewBlockTransactions(txs)
 	return mm.mempool.HandleNewBlockTransactions(txs)
 }
 
@@ -46,3 +54,26 @@ func (mm *miningManager) GetTransaction(
 func (mm *miningManager) AllTransactions() []*consensusexternalapi.DomainTransaction {
 	return mm.mempool.AllTransactions()
 }
+
+// GetFeeEstimate returns the fee rates, in sompi per gram, that transactions
+// currently need to pay in order to be included in a block
+func (mm *miningManager) GetFeeEstimate() *miningmanagermodel.FeeEstimate {
+	return mm.feeEstimator.FeeEstimate()
+}
+
+// GetMempoolInfo returns the current state of the mempool
+func (mm *miningManager) GetMempoolInfo() *miningmanagermodel.MempoolInfo {
+	return mm.mempool.Info()
+}
+
+// SaveMempool writes the transactions in the mempool to the file at the
+// given path, so that they survive a restart
+func (mm *miningManager) SaveMempool(path string) error {
+	return mm.mempool.SaveToFile(path)
+}
+
+// LoadMempool revalidates the transactions saved by SaveMempool and inserts
+// the ones that are still valid into the mempool
+func (mm *miningManager) LoadMempool(path string) error {
+	return mm.mempool.LoadFromFile(path)
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	consensusexternalapi "github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+)
+
+// FeeEstimate is an estimate of the fee rates, in sompi per gram of
+// transaction mass, that transactions need to pay in order to be
+// included in a block within different time frames
+type FeeEstimate struct {
+	// LowFeeRate is the fee rate for transactions that can wait
+	LowFeeRate float64
+
+	// NormalFeeRate is the fee rate for transactions that should be
+	// included within a few blocks
+	NormalFeeRate float64
+
+	// PriorityFeeRate is the fee rate for transactions that should be
+	// included in the next block
+	PriorityFeeRate float64
+}
+
+// FeeEstimator estimates transaction fee rates based on the contents of
+// the mempool and the fee rates of transactions accepted in recent blocks.
+// Only block transactions that are in the mempool when their block arrives
+// are learned from, since block transactions don't carry their fee.
+type FeeEstimator interface {
+	HandleNewBlockTransactions(txs []*consensusexternalapi.DomainTransaction)
+	FeeEstimate() *FeeEstimate
+}
@@ -8,9 +8,51 @@ import (
 // are intended to be mined into new blocks
 type Mempool interface {
 	HandleNewBlockTransactions(txs []*consensusexternalapi.DomainTransaction) ([]*consensusexternalapi.DomainTransaction, error)
-	BlockCandidateTransactions() []*consensusexternalapi.DomainTransaction
+	BlockCandidateTransactions() []*BlockCandidateTransaction
 	ValidateAndInsertTransaction(transaction *consensusexternalapi.DomainTransaction, allowOrphan bool) error
 	RemoveTransactions(txs []*consensusexternalapi.DomainTransaction)
 	GetTransaction(transactionID *consensusexternalapi.DomainTransactionID) (*consensusexternalapi.DomainTransaction, bool)
 	AllTransactions() []*consensusexternalapi.DomainTransaction
+	Info() *MempoolInfo
+	SaveToFile(path string) error
+	LoadFromFile(path string) error
+}
+
+// BlockCandidateTransaction is a transaction that can be included in the next
+// block, along with its descendants: the chained transactions in the mempool
+// that spend its outputs, directly or indirectly. Chained transactions can't
+// be included in the same block as the transactions they spend, but they
+// become block candidates once those are accepted. A descendant of several
+// candidates is among the descendants of each of them.
+type BlockCandidateTransaction struct {
+	Transaction *consensusexternalapi.DomainTransaction
+	Descendants []*consensusexternalapi.DomainTransaction
+}
+
+// MempoolInfo describes the current state of the mempool
+type MempoolInfo struct {
+	// TransactionCount is the number of transactions that can be
+	// included in the next block
+	TransactionCount int
+
+	// ChainedTransactionCount is the number of transactions that spend
+	// outputs of other transactions in the mempool
+	ChainedTransactionCount int
+
+	OrphanCount int
+
+	// TotalMass is the total mass of the transactions and the chained
+	// transactions, which is kept within MaxMass
+	TotalMass uint64
+	MaxMass   uint64
+
+	// RollingMinimumFeeRate is the fee rate, in sompi per gram, that
+	// transactions must pay in order to enter the mempool after it had
+	// to evict transactions. It decays over time, and is 0 when the
+	// mempool didn't evict transactions recently.
+	RollingMinimumFeeRate float64
+
+	// EvictedTransactionCount is the number of transactions that were
+	// evicted since the node started, due to the mempool being full
+	EvictedTransactionCount uint64
 }
@@ -0,0 +1,15 @@
+package txindex
+
+import (
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+)
+
+// TXIndexEntry is the location of an accepted transaction in the DAG
+type TXIndexEntry struct {
+	// BlockHash is the hash of the block that contains the transaction
+	BlockHash *externalapi.DomainHash
+
+	// AcceptingBlockHash is the hash of the selected chain block that
+	// accepted the transaction
+	AcceptingBlockHash *externalapi.DomainHash
+}
@@ -0,0 +1,203 @@
+package txindex
+
+import (
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/infrastructure/db/database"
+	"github.com/kaspanet/kaspad/infrastructure/logger"
+	"github.com/pkg/errors"
+)
+
+var txIndexBucket = database.MakeBucket([]byte("tx-index"))
+var txIndexTipKey = database.MakeBucket(nil).Key([]byte("tx-index-tip"))
+
+type txIndexStore struct {
+	database database.Database
+	toAdd    map[externalapi.DomainTransactionID]*TXIndexEntry
+	toRemove map[externalapi.DomainTransactionID]struct{}
+	tip      *externalapi.DomainHash
+}
+
+func newTXIndexStore(database database.Database) *txIndexStore {
+	return &txIndexStore{
+		database: database,
+		toAdd:    make(map[externalapi.DomainTransactionID]*TXIndexEntry),
+		toRemove: make(map[externalapi.DomainTransactionID]struct{}),
+	}
+}
+
+func (tis *txIndexStore) add(transactionID *externalapi.DomainTransactionID, entry *TXIndexEntry) {
+	log.Tracef("Adding transaction %s to the transaction index", transactionID)
+
+	delete(tis.toRemove, *transactionID)
+	tis.toAdd[*transactionID] = entry
+}
+
+func (tis *txIndexStore) remove(transactionID *externalapi.DomainTransactionID) {
+	log.Tracef("Removing transaction %s from the transaction index", transactionID)
+
+	delete(tis.toAdd, *transactionID)
+	tis.toRemove[*transactionID] = struct{}{}
+}
+
+// setTip stages the selected chain block up to which the index is built
+func (tis *txIndexStore) setTip(tip *externalapi.DomainHash) {
+	tis.tip = tip
+}
+
+func (tis *txIndexStore) discard() {
+	tis.toAdd = make(map[externalapi.DomainTransactionID]*TXIndexEntry)
+	tis.toRemove = make(map[externalapi.DomainTransactionID]struct{})
+	tis.tip = nil
+}
+
+func (tis *txIndexStore) commit() error {
+	onEnd := logger.LogAndMeasureExecutionTime(log, "txIndexStore.commit")
+	defer onEnd()
+
+	dbTransaction, err := tis.database.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTransaction.RollbackUnlessClosed()
+
+	for transactionID := range tis.toRemove {
+		err := dbTransaction.Delete(tis.transactionIDKey(&transactionID))
+		if err != nil {
+			return err
+		}
+	}
+
+	for transactionID, entry := range tis.toAdd {
+		err := dbTransaction.Put(tis.transactionIDKey(&transactionID), serializeEntry(entry))
+		if err != nil {
+			return err
+		}
+	}
+
+	if tis.tip != nil {
+		err := dbTransaction.Put(txIndexTipKey, tis.tip.ByteSlice())
+		if err != nil {
+			return err
+		}
+	}
+
+	err = dbTransaction.Commit()
+	if err != nil {
+		return err
+	}
+
+	tis.discard()
+	return nil
+}
+
+func (tis *txIndexStore) isAnythingStaged() bool {
+	return len(tis.toAdd) > 0 || len(tis.toRemove) > 0 || tis.tip != nil
+}
+
+// getTip returns the selected chain block up to which the index is built.
+// It returns false if the index was never built.
+func (tis *txIndexStore) getTip() (*externalapi.DomainHash, bool, error) {
+	serializedTip, err := tis.database.Get(txIndexTipKey)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	tip, err := externalapi.NewDomainHashFromByteSlice(serializedTip)
+	if err != nil {
+		return nil, false, err
+	}
+	return tip, true, nil
+}
+
+// deleteAll deletes all the entries of the index, and its tip
+func (tis *txIndexStore) deleteAll() error {
+	if tis.isAnythingStaged() {
+		return errors.Errorf("cannot delete the transaction index while staging isn't empty")
+	}
+
+	cursor, err := tis.database.Cursor(txIndexBucket)
+	if err != nil {
+		return err
+	}
+	var keys []*database.Key
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		key, err := cursor.Key()
+		if err != nil {
+			cursor.Close()
+			return err
+		}
+		keys = append(keys, txIndexBucket.Key(append([]byte{}, key.Suffix()...)))
+	}
+	err = cursor.Close()
+	if err != nil {
+		return err
+	}
+
+	dbTransaction, err := tis.database.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTransaction.RollbackUnlessClosed()
+
+	for _, key := range keys {
+		err := dbTransaction.Delete(key)
+		if err != nil {
+			return err
+		}
+	}
+	err = dbTransaction.Delete(txIndexTipKey)
+	if err != nil {
+		return err
+	}
+	return dbTransaction.Commit()
+}
+
+func (tis *txIndexStore) get(transactionID *externalapi.DomainTransactionID) (*TXIndexEntry, bool, error) {
+	if tis.isAnythingStaged() {
+		return nil, false, errors.Errorf("cannot get a transaction index entry while staging isn't empty")
+	}
+
+	serializedEntry, err := tis.database.Get(tis.transactionIDKey(transactionID))
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	entry, err := deserializeEntry(serializedEntry)
+	if err != nil {
+		return nil, false, err
+	}
+	return entry, true, nil
+}
+
+func (tis *txIndexStore) transactionIDKey(transactionID *externalapi.DomainTransactionID) *database.Key {
+	return txIndexBucket.Key(transactionID.ByteSlice())
+}
+
+func serializeEntry(entry *TXIndexEntry) []byte {
+	serializedEntry := make([]byte, 0, externalapi.DomainHashSize*2)
+	serializedEntry = append(serializedEntry, entry.BlockHash.ByteSlice()...)
+	serializedEntry = append(serializedEntry, entry.AcceptingBlockHash.ByteSlice()...)
+	return serializedEntry
+}
+
+func deserializeEntry(serializedEntry []byte) (*TXIndexEntry, error) {
+	if len(serializedEntry) != externalapi.DomainHashSize*2 {
+		return nil, errors.Errorf("transaction index entry has unexpected length %d", len(serializedEntry))
+	}
+	blockHash, err := externalapi.NewDomainHashFromByteSlice(serializedEntry[:externalapi.DomainHashSize])
+	if err != nil {
+		return nil, err
+	}
+	acceptingBlockHash, err := externalapi.NewDomainHashFromByteSlice(serializedEntry[externalapi.DomainHashSize:])
+	if err != nil {
+		return nil, err
+	}
+	return &TXIndexEntry{
+		BlockHash:          blockHash,
+		AcceptingBlockHash: acceptingBlockHash,
+	}, nil
+}
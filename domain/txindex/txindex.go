@@ -0,0 +1,193 @@
+package txindex
+
+import (
+	"sync"
+
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/infrastructure/db/database"
+	"github.com/kaspanet/kaspad/infrastructure/logger"
+)
+
+// TXIndex maintains an index between transaction IDs and the
+// blocks that contain and accept them
+type TXIndex struct {
+	consensus externalapi.Consensus
+	store     *txIndexStore
+
+	mutex sync.Mutex
+}
+
+// backfillBatchSize is the number of selected chain blocks that are added to
+// the index in every database transaction while it's backfilled
+const backfillBatchSize = 1000
+
+// New creates a new transaction index, and brings it up to date with the
+// current selected chain. This also indexes the blocks that were added while
+// kaspad ran without --txindex.
+func New(consensus externalapi.Consensus, database database.Database) (*TXIndex, error) {
+	store := newTXIndexStore(database)
+	txIndex := &TXIndex{
+		consensus: consensus,
+		store:     store,
+	}
+	err := txIndex.sync()
+	if err != nil {
+		return nil, err
+	}
+	return txIndex, nil
+}
+
+// sync brings the index up to date with the current selected chain. If the
+// index was never built, or if its tip can't be reached from the current
+// selected chain, for example because it was pruned, the index is rebuilt
+// from the pruning point. Transactions that were accepted at or below the
+// pruning point can't be indexed, since their acceptance data is pruned.
+func (ti *TXIndex) sync() error {
+	onEnd := logger.LogAndMeasureExecutionTime(log, "TXIndex.sync")
+	defer onEnd()
+
+	ti.mutex.Lock()
+	defer ti.mutex.Unlock()
+
+	tip, found, err := ti.store.getTip()
+	if err != nil {
+		return err
+	}
+	if found {
+		chainChanges, err := ti.consensus.GetVirtualSelectedParentChainFromBlock(tip)
+		if err == nil {
+			err = ti.updateInBatches(chainChanges)
+			if err == nil {
+				return nil
+			}
+		}
+		log.Warnf("Rebuilding the transaction index, since it can't be updated from its tip %s: %s", tip, err)
+		err = ti.store.deleteAll()
+		if err != nil {
+			return err
+		}
+	}
+
+	pruningPoint, err := ti.consensus.PruningPoint()
+	if err != nil {
+		return err
+	}
+	chainChanges, err := ti.consensus.GetVirtualSelectedParentChainFromBlock(pruningPoint)
+	if err != nil {
+		return err
+	}
+	log.Infof("Building the transaction index from %d selected chain blocks", len(chainChanges.Added))
+	ti.store.setTip(pruningPoint)
+	return ti.updateInBatches(chainChanges)
+}
+
+// updateInBatches updates the index with the given chain changes, and commits
+// every backfillBatchSize added blocks, so that long chain changes don't have
+// to be staged in memory all at once
+func (ti *TXIndex) updateInBatches(chainChanges *externalapi.SelectedChainPath) error {
+	for _, removedBlockHash := range chainChanges.Removed {
+		err := ti.removeBlock(removedBlockHash)
+		if err != nil {
+			ti.store.discard()
+			return err
+		}
+	}
+	for i, addedBlockHash := range chainChanges.Added {
+		err := ti.addBlock(addedBlockHash)
+		if err != nil {
+			ti.store.discard()
+			return err
+		}
+		ti.store.setTip(addedBlockHash)
+		if (i+1)%backfillBatchSize == 0 {
+			err := ti.store.commit()
+			if err != nil {
+				return err
+			}
+			log.Infof("Indexed %d out of %d selected chain blocks", i+1, len(chainChanges.Added))
+		}
+	}
+	return ti.store.commit()
+}
+
+// Update updates the transaction index with the given DAG selected parent chain changes
+func (ti *TXIndex) Update(chainChanges *externalapi.SelectedChainPath) error {
+	onEnd := logger.LogAndMeasureExecutionTime(log, "TXIndex.Update")
+	defer onEnd()
+
+	ti.mutex.Lock()
+	defer ti.mutex.Unlock()
+
+	log.Tracef("Updating transaction index with chainChanges: %+v", chainChanges)
+	for _, removedBlockHash := range chainChanges.Removed {
+		err := ti.removeBlock(removedBlockHash)
+		if err != nil {
+			ti.store.discard()
+			return err
+		}
+	}
+	for _, addedBlockHash := range chainChanges.Added {
+		err := ti.addBlock(addedBlockHash)
+		if err != nil {
+			ti.store.discard()
+			return err
+		}
+	}
+	if len(chainChanges.Added) > 0 {
+		ti.store.setTip(chainChanges.Added[len(chainChanges.Added)-1])
+	}
+
+	return ti.store.commit()
+}
+
+func (ti *TXIndex) addBlock(acceptingBlockHash *externalapi.DomainHash) error {
+	log.Tracef("Adding block %s to the transaction index", acceptingBlockHash)
+	acceptanceData, err := ti.consensus.GetBlockAcceptanceData(acceptingBlockHash)
+	if err != nil {
+		return err
+	}
+	for _, blockAcceptanceData := range acceptanceData {
+		for _, transactionAcceptanceData := range blockAcceptanceData.TransactionAcceptanceData {
+			if !transactionAcceptanceData.IsAccepted {
+				continue
+			}
+			transactionID := consensushashing.TransactionID(transactionAcceptanceData.Transaction)
+			ti.store.add(transactionID, &TXIndexEntry{
+				BlockHash:          blockAcceptanceData.BlockHash,
+				AcceptingBlockHash: acceptingBlockHash,
+			})
+		}
+	}
+	return nil
+}
+
+func (ti *TXIndex) removeBlock(acceptingBlockHash *externalapi.DomainHash) error {
+	log.Tracef("Removing block %s from the transaction index", acceptingBlockHash)
+	acceptanceData, err := ti.consensus.GetBlockAcceptanceData(acceptingBlockHash)
+	if err != nil {
+		return err
+	}
+	for _, blockAcceptanceData := range acceptanceData {
+		for _, transactionAcceptanceData := range blockAcceptanceData.TransactionAcceptanceData {
+			if !transactionAcceptanceData.IsAccepted {
+				continue
+			}
+			ti.store.remove(consensushashing.TransactionID(transactionAcceptanceData.Transaction))
+		}
+	}
+	return nil
+}
+
+// TransactionEntry returns the blocks that contain and accept the
+// transaction with the given ID. It returns false if the transaction
+// wasn't accepted by the current selected chain.
+func (ti *TXIndex) TransactionEntry(transactionID *externalapi.DomainTransactionID) (*TXIndexEntry, bool, error) {
+	onEnd := logger.LogAndMeasureExecutionTime(log, "TXIndex.TransactionEntry")
+	defer onEnd()
+
+	ti.mutex.Lock()
+	defer ti.mutex.Unlock()
+
+	return ti.store.get(transactionID)
+}
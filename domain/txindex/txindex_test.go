@@ -0,0 +1,123 @@
+package txindex
+
+import (
+	"testing"
+
+	"github.com/kaspanet/kaspad/domain/consensus"
+	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
+	"github.com/kaspanet/kaspad/domain/consensus/model/testapi"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+	"github.com/kaspanet/kaspad/domain/consensus/utils/testutils"
+	"github.com/kaspanet/kaspad/domain/dagconfig"
+	"github.com/kaspanet/kaspad/infrastructure/db/database/memorydb"
+)
+
+func TestTXIndexReorg(t *testing.T) {
+	testutils.ForAllNets(t, true, func(t *testing.T, params *dagconfig.Params) {
+		factory := consensus.NewFactory()
+		tc, teardown, err := factory.NewTestConsensus(params, false, "TestTXIndexReorg")
+		if err != nil {
+			t.Fatalf("Error setting up consensus: %+v", err)
+		}
+		defer teardown(false)
+
+		txIndex, err := New(tc, memorydb.NewMemoryDB())
+		if err != nil {
+			t.Fatalf("New: %+v", err)
+		}
+		// Every block gets its own coinbase extra data, since otherwise
+		// blocks with the same blue score would have the same coinbase
+		// transaction
+		blockCount := 0
+		addBlock := func(parentHash *externalapi.DomainHash) *externalapi.DomainHash {
+			blockCount++
+			coinbaseData := &externalapi.DomainCoinbaseData{
+				ScriptPublicKey: &externalapi.ScriptPublicKey{Script: nil, Version: 0},
+				ExtraData:       []byte{byte(blockCount)},
+			}
+			blockHash, blockInsertionResult, err := tc.AddBlock([]*externalapi.DomainHash{parentHash}, coinbaseData, nil)
+			if err != nil {
+				t.Fatalf("AddBlock: %+v", err)
+			}
+			err = txIndex.Update(blockInsertionResult.VirtualSelectedParentChainChanges)
+			if err != nil {
+				t.Fatalf("Update: %+v", err)
+			}
+			return blockHash
+		}
+
+		// The coinbase transaction of every selected chain block is
+		// accepted by the next selected chain block
+		chainA1 := addBlock(params.GenesisHash)
+		chainA2 := addBlock(chainA1)
+		expectEntry(t, tc, txIndex, chainA1, chainA2)
+
+		// A heavier chain that doesn't merge chainA1 reorgs it out
+		chainB1 := addBlock(params.GenesisHash)
+		chainB2 := addBlock(chainB1)
+		chainB3 := addBlock(chainB2)
+		expectNoEntry(t, tc, txIndex, chainA1)
+		expectEntry(t, tc, txIndex, chainB1, chainB2)
+		expectEntry(t, tc, txIndex, chainB2, chainB3)
+
+		// A new index is backfilled from the selected chain
+		backfilledTXIndex, err := New(tc, memorydb.NewMemoryDB())
+		if err != nil {
+			t.Fatalf("New: %+v", err)
+		}
+		expectNoEntry(t, tc, backfilledTXIndex, chainA1)
+		expectEntry(t, tc, backfilledTXIndex, chainB1, chainB2)
+		expectEntry(t, tc, backfilledTXIndex, chainB2, chainB3)
+
+		// An index that missed some updates is brought up to date from
+		// its tip when it's reopened
+		database := memorydb.NewMemoryDB()
+		_, err = New(tc, database)
+		if err != nil {
+			t.Fatalf("New: %+v", err)
+		}
+		chainB4 := addBlock(chainB3)
+		reopenedTXIndex, err := New(tc, database)
+		if err != nil {
+			t.Fatalf("New: %+v", err)
+		}
+		expectEntry(t, tc, reopenedTXIndex, chainB3, chainB4)
+	})
+}
+
+func coinbaseTransactionID(t *testing.T, tc testapi.TestConsensus,
+	blockHash *externalapi.DomainHash) *externalapi.DomainTransactionID {
+
+	block, err := tc.GetBlock(blockHash)
+	if err != nil {
+		t.Fatalf("GetBlock: %+v", err)
+	}
+	return consensushashing.TransactionID(block.Transactions[0])
+}
+
+func expectEntry(t *testing.T, tc testapi.TestConsensus, txIndex *TXIndex,
+	blockHash *externalapi.DomainHash, acceptingBlockHash *externalapi.DomainHash) {
+
+	entry, found, err := txIndex.TransactionEntry(coinbaseTransactionID(t, tc, blockHash))
+	if err != nil {
+		t.Fatalf("TransactionEntry: %+v", err)
+	}
+	if !found {
+		t.Fatalf("The coinbase transaction of %s was not found", blockHash)
+	}
+	if !entry.BlockHash.Equal(blockHash) || !entry.AcceptingBlockHash.Equal(acceptingBlockHash) {
+		t.Fatalf("The coinbase transaction of %s is expected in block %s accepted by %s, "+
+			"but got block %s accepted by %s", blockHash, blockHash, acceptingBlockHash,
+			entry.BlockHash, entry.AcceptingBlockHash)
+	}
+}
+
+func expectNoEntry(t *testing.T, tc testapi.TestConsensus, txIndex *TXIndex, blockHash *externalapi.DomainHash) {
+	_, found, err := txIndex.TransactionEntry(coinbaseTransactionID(t, tc, blockHash))
+	if err != nil {
+		t.Fatalf("TransactionEntry: %+v", err)
+	}
+	if found {
+		t.Fatalf("The coinbase transaction of %s was unexpectedly found", blockHash)
+	}
+}
@@ -6,6 +6,7 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"net"
 	"os"
 	"path/filepath"
@@ -36,6 +37,8 @@ const (
 	defaultMaxInboundPeers     = 117
 	defaultBanDuration         = time.Hour * 24
 	defaultBanThreshold        = 100
+	defaultMinorBanScore       = 20
+	defaultSevereBanScore      = 60
 	//DefaultConnectTimeout is the default connection timeout when dialing
 	DefaultConnectTimeout        = time.Second * 30
 	defaultMaxRPCClients         = 10
@@ -46,22 +49,39 @@ const (
 	blockMaxMassMax              = 10000000
 	defaultMinRelayTxFee         = 1e-5 // 1 sompi per byte
 	defaultMaxOrphanTransactions = 100
+	defaultMaxMempoolMass        = 500000000
 	//DefaultMaxOrphanTxSize is the default maximum size for an orphan transaction
 	DefaultMaxOrphanTxSize  = 100000
 	defaultSigCacheMaxSize  = 100000
 	sampleConfigFilename    = "sample-kaspad.conf"
-	defaultMaxUTXOCacheSize = 5000000000
+	defaultMaxUTXOCacheSize = 500000000
+	defaultDbType           = LevelDBType
 )
 
+// The database backends that can be selected with --dbtype
+const (
+	// LevelDBType is the database type of the LevelDB backend, which keeps
+	// the data in the data directory
+	LevelDBType = "leveldb"
+
+	// MemoryDBType is the database type of the in-memory backend, which
+	// loses all of its data when kaspad shuts down
+	MemoryDBType = "memory"
+)
+
+var knownDbTypes = []string{LevelDBType, MemoryDBType}
+
 var (
 	// DefaultHomeDir is the default home directory for kaspad.
 	DefaultHomeDir = util.AppDataDir("kaspad", false)
 
-	defaultConfigFile  = filepath.Join(DefaultHomeDir, defaultConfigFilename)
-	defaultDataDir     = filepath.Join(DefaultHomeDir, defaultDataDirname)
-	defaultRPCKeyFile  = filepath.Join(DefaultHomeDir, "rpc.key")
-	defaultRPCCertFile = filepath.Join(DefaultHomeDir, "rpc.cert")
-	defaultLogDir      = filepath.Join(DefaultHomeDir, defaultLogDirname)
+	// DefaultRPCCertFile is the RPC certificate that kaspad generates by default.
+	DefaultRPCCertFile = filepath.Join(DefaultHomeDir, "rpc.cert")
+
+	defaultConfigFile = filepath.Join(DefaultHomeDir, defaultConfigFilename)
+	defaultDataDir    = filepath.Join(DefaultHomeDir, defaultDataDirname)
+	defaultRPCKeyFile = filepath.Join(DefaultHomeDir, "rpc.key")
+	defaultLogDir     = filepath.Join(DefaultHomeDir, defaultLogDirname)
 )
 
 // RunServiceCommand is only set to a real function on Windows. It is used
@@ -84,38 +104,53 @@ type Flags struct {
 	MaxInboundPeers      int           `long:"maxinpeers" description:"Max number of inbound peers"`
 	DisableBanning       bool          `long:"nobanning" description:"Disable banning of misbehaving peers"`
 	BanDuration          time.Duration `long:"banduration" description:"How long to ban misbehaving peers. Valid time units are {s, m, h}. Minimum 1 second"`
-	BanThreshold         uint32        `long:"banthreshold" description:"Maximum allowed ban score before disconnecting and banning misbehaving peers."`
-	Whitelists           []string      `long:"whitelist" description:"Add an IP network or IP that will not be banned. (eg. 192.168.1.0/24 or ::1)"`
+	BanThreshold         uint32        `long:"banthreshold" description:"Ban score at which misbehaving peers are disconnected and banned"`
+	MinorBanScore        uint32        `long:"minorbanscore" description:"Ban score added for misbehavior that could be the result of a bug or a network issue, such as sending an unexpected message"`
+	SevereBanScore       uint32        `long:"severebanscore" description:"Ban score added for misbehavior that is most likely malicious, such as sending an invalid block"`
+	Whitelists           []string      `long:"whitelist" description:"Add an IP network or IP that will not be banned or evicted. (eg. 192.168.1.0/24 or ::1)"`
 	RPCListeners         []string      `long:"rpclisten" description:"Add an interface/port to listen for RPC connections (default port: 16110, testnet: 16210)"`
 	RPCCert              string        `long:"rpccert" description:"File containing the certificate file"`
 	RPCKey               string        `long:"rpckey" description:"File containing the certificate key"`
-	RPCMaxClients        int           `long:"rpcmaxclients" description:"Max number of RPC clients for standard connections"`
-	RPCMaxWebsockets     int           `long:"rpcmaxwebsockets" description:"Max number of RPC websocket connections"`
-	RPCMaxConcurrentReqs int           `long:"rpcmaxconcurrentreqs" description:"Max number of concurrent RPC requests that may be processed concurrently"`
+	RPCUser              string        `short:"u" long:"rpcuser" description:"Username for RPC connections"`
+	RPCPass              string        `short:"P" long:"rpcpass" default-mask:"-" description:"Password for RPC connections"`
+	RPCAuthToken         string        `long:"rpcauthtoken" default-mask:"-" description:"Token for RPC connections, accepted in addition to or instead of --rpcuser/--rpcpass"`
+	DisableTLS           bool          `long:"notls" description:"Disable TLS for the RPC server -- NOTE: This is only allowed if the RPC server is bound to localhost"`
+	RPCMaxClients        int           `long:"rpcmaxclients" description:"Max number of RPC clients for standard connections -- 0 means no limit"`
+	RPCMaxWebsockets     int           `long:"rpcmaxwebsockets" description:"Max number of RPC clients that may register for notifications -- 0 means no limit"`
+	RPCMaxConcurrentReqs int           `long:"rpcmaxconcurrentreqs" description:"Max number of concurrent RPC requests that may be processed concurrently -- 0 means no limit"`
 	DisableRPC           bool          `long:"norpc" description:"Disable built-in RPC server"`
 	DisableDNSSeed       bool          `long:"nodnsseed" description:"Disable DNS seeding for peers"`
 	DNSSeed              string        `long:"dnsseed" description:"Override DNS seeds with specified hostname (Only 1 hostname allowed)"`
 	GRPCSeed             string        `long:"grpcseed" description:"Hostname of gRPC server for seeding peers"`
 	ExternalIPs          []string      `long:"externalip" description:"Add an ip to the list of local addresses we claim to listen on to peers"`
-	Proxy                string        `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
+	Proxy                string        `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050) -- Peers with .onion addresses are always connected to through it"`
 	ProxyUser            string        `long:"proxyuser" description:"Username for proxy server"`
 	ProxyPass            string        `long:"proxypass" default-mask:"-" description:"Password for proxy server"`
-	DbType               string        `long:"dbtype" description:"Database backend to use for the Block DAG"`
+	TorControl           string        `long:"torcontrol" description:"Publish this node as a Tor onion service through the Tor control port at the given address (eg. 127.0.0.1:9051)"`
+	TorPassword          string        `long:"torpassword" default-mask:"-" description:"Password for the Tor control port, if it uses password authentication"`
+	OnionListen          string        `long:"onionlisten" description:"Interface/port that Tor forwards the incoming connections of the onion service to, so that they're told apart from other local peers (default: 127.0.0.1, on the port after the first listen port, or the one before it if that is the last port)"`
+	DbType               string        `long:"dbtype" description:"Database backend to use for the Block DAG {leveldb, memory} -- The memory backend loses all of its data on shutdown, and the mempool is not saved along with it"`
 	Profile              string        `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
+	MetricsListen        string        `long:"metricslisten" description:"Serve Prometheus metrics over HTTP at /metrics on the given interface/port (eg. 127.0.0.1:16112)"`
+	TraceFile            string        `long:"tracefile" description:"Append traces of the processing of every block to the given file, as a JSON object per span"`
 	DebugLevel           string        `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
-	Upnp                 bool          `long:"upnp" description:"Use UPnP to map our listening port outside of NAT"`
+	Upnp                 bool          `long:"upnp" description:"Use UPnP, or NAT-PMP if UPnP is unavailable, to map our listening port outside of NAT"`
 	MinRelayTxFee        float64       `long:"minrelaytxfee" description:"The minimum transaction fee in KAS/kB to be considered a non-zero fee."`
 	MaxOrphanTxs         int           `long:"maxorphantx" description:"Max number of orphan transactions to keep in memory"`
+	MaxMempoolMass       uint64        `long:"maxmempoolmass" description:"Max total mass of the transactions to keep in the mempool -- Once it is exceeded, the transactions that pay the lowest fee rates are evicted -- May not be less than blockmaxmass"`
 	BlockMaxMass         uint64        `long:"blockmaxmass" description:"Maximum transaction mass to be used when creating a block"`
 	UserAgentComments    []string      `long:"uacomment" description:"Comment to add to the user agent -- See BIP 14 for more information."`
 	NoPeerBloomFilters   bool          `long:"nopeerbloomfilters" description:"Disable bloom filtering support"`
 	SigCacheMaxSize      uint          `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
+	ScriptVerifyWorkers  int           `long:"scriptverifyworkers" description:"Number of goroutines that verify the input scripts of a transaction in parallel -- 0 means one per CPU"`
 	BlocksOnly           bool          `long:"blocksonly" description:"Do not accept transactions from remote peers."`
 	RelayNonStd          bool          `long:"relaynonstd" description:"Relay non-standard transactions regardless of the default settings for the active network."`
 	RejectNonStd         bool          `long:"rejectnonstd" description:"Reject non-standard transactions regardless of the default settings for the active network."`
 	ResetDatabase        bool          `long:"reset-db" description:"Reset database before starting node. It's needed when switching between subnetworks."`
-	MaxUTXOCacheSize     uint64        `long:"maxutxocachesize" description:"Max size of loaded UTXO into ram from the disk in bytes"`
+	MaxUTXOCacheSize     uint64        `long:"maxutxocachesize" description:"Max size, in bytes, of the UTXO entries kept in memory (default: 500 MB) -- Once it is exceeded, the least recently used UTXO entries are evicted and read from the disk when needed again"`
+	UTXOSnapshot         string        `long:"utxosnapshot" description:"Load the UTXO set of the pruning point in IBD from the given snapshot file instead of from peers, if it is of the same pruning point"`
 	UTXOIndex            bool          `long:"utxoindex" description:"Enable the UTXO index"`
+	TXIndex              bool          `long:"txindex" description:"Enable the transaction index, which is required by the GetTransaction RPC"`
 	IsArchivalNode       bool          `long:"archival" description:"Run as an archival node: don't delete old block data when moving the pruning point (Warning: heavy disk usage)'"`
 	NetworkFlags
 	ServiceOptions *ServiceOptions
@@ -171,22 +206,74 @@ func defaultFlags() *Flags {
 		MaxInboundPeers:      defaultMaxInboundPeers,
 		BanDuration:          defaultBanDuration,
 		BanThreshold:         defaultBanThreshold,
+		MinorBanScore:        defaultMinorBanScore,
+		SevereBanScore:       defaultSevereBanScore,
 		RPCMaxClients:        defaultMaxRPCClients,
 		RPCMaxWebsockets:     defaultMaxRPCWebsockets,
 		RPCMaxConcurrentReqs: defaultMaxRPCConcurrentReqs,
 		DataDir:              defaultDataDir,
 		LogDir:               defaultLogDir,
 		RPCKey:               defaultRPCKeyFile,
-		RPCCert:              defaultRPCCertFile,
+		RPCCert:              DefaultRPCCertFile,
 		BlockMaxMass:         defaultBlockMaxMass,
 		MaxOrphanTxs:         defaultMaxOrphanTransactions,
+		MaxMempoolMass:       defaultMaxMempoolMass,
 		SigCacheMaxSize:      defaultSigCacheMaxSize,
 		MinRelayTxFee:        defaultMinRelayTxFee,
 		MaxUTXOCacheSize:     defaultMaxUTXOCacheSize,
+		DbType:               defaultDbType,
 		ServiceOptions:       &ServiceOptions{},
 	}
 }
 
+// listenAddressesOverlap returns whether listening on both of the given
+// addresses would accept connections on the same port of the same
+// interface, which is the case if their ports are equal and either their
+// hosts are equal or one of them listens on all interfaces
+func listenAddressesOverlap(address, otherAddress string) (bool, error) {
+	host, port, err := splitListenAddress(address)
+	if err != nil {
+		return false, err
+	}
+	otherHost, otherPort, err := splitListenAddress(otherAddress)
+	if err != nil {
+		return false, err
+	}
+	if port != otherPort {
+		return false, nil
+	}
+	isAllInterfaces := func(host string) bool {
+		ip := net.ParseIP(host)
+		return host == "" || (ip != nil && ip.IsUnspecified())
+	}
+	return host == otherHost || isAllInterfaces(host) || isAllInterfaces(otherHost), nil
+}
+
+// splitListenAddress splits the given listen address into its host and its
+// port, which must be a valid TCP port
+func splitListenAddress(address string) (host string, port uint16, err error) {
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", 0, errors.WithStack(err)
+	}
+	parsedPort, err := strconv.ParseUint(portString, 10, 16)
+	if err != nil || parsedPort == 0 {
+		return "", 0, errors.Errorf("invalid port %s", portString)
+	}
+	return host, uint16(parsedPort), nil
+}
+
+// validDbType returns whether or not dbType is a supported database type.
+func validDbType(dbType string) bool {
+	for _, knownType := range knownDbTypes {
+		if dbType == knownType {
+			return true
+		}
+	}
+
+	return false
+}
+
 // DefaultConfig returns the default kaspad configuration
 func DefaultConfig() *Config {
 	config := &Config{Flags: defaultFlags()}
@@ -325,6 +412,13 @@ func LoadConfig() (*Config, error) {
 	cfg.LogDir = cleanAndExpandPath(cfg.LogDir)
 	cfg.LogDir = filepath.Join(cfg.LogDir, cfg.NetParams().Name)
 
+	if cfg.TraceFile != "" {
+		cfg.TraceFile = cleanAndExpandPath(cfg.TraceFile)
+	}
+	if cfg.UTXOSnapshot != "" {
+		cfg.UTXOSnapshot = cleanAndExpandPath(cfg.UTXOSnapshot)
+	}
+
 	// Special show command to list supported subsystems and exit.
 	if cfg.DebugLevel == "show" {
 		fmt.Println("Supported subsystems", logger.SupportedSubsystems())
@@ -355,6 +449,39 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	// Validate database type.
+	if !validDbType(cfg.DbType) {
+		str := "%s: The specified database type [%s] is invalid -- " +
+			"supported types %s"
+		err := errors.Errorf(str, funcName, cfg.DbType, knownDbTypes)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, err
+	}
+
+	// Validate the metrics listen address
+	if cfg.MetricsListen != "" {
+		_, _, err := net.SplitHostPort(cfg.MetricsListen)
+		if err != nil {
+			str := "%s: The metricslisten address '%s' is invalid: %s"
+			err := errors.Errorf(str, funcName, cfg.MetricsListen, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, err
+		}
+	}
+
+	// Validate that the UTXO snapshot file exists
+	if cfg.UTXOSnapshot != "" {
+		if _, err := os.Stat(cfg.UTXOSnapshot); err != nil {
+			str := "%s: The utxosnapshot file can't be used: %s"
+			err := errors.Errorf(str, funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, err
+		}
+	}
+
 	// Don't allow ban durations that are too short.
 	if cfg.BanDuration < time.Second {
 		str := "%s: The banduration option may not be less than 1s -- parsed [%s]"
@@ -365,7 +492,7 @@ func LoadConfig() (*Config, error) {
 	}
 
 	// Validate any given whitelisted IP addresses and networks.
-	if len(cfg.Whitelists) > 0 {
+	if len(cfg.Flags.Whitelists) > 0 {
 		var ip net.IP
 		cfg.Whitelists = make([]*net.IPNet, 0, len(cfg.Flags.Whitelists))
 
@@ -412,6 +539,17 @@ func LoadConfig() (*Config, error) {
 		cfg.DisableListen = true
 	}
 
+	// --torcontrol publishes the listening port as an onion service, so it
+	// requires listening.
+	if cfg.TorControl != "" && cfg.DisableListen {
+		str := "%s: the --torcontrol option requires listening for incoming " +
+			"connections -- specify listen addresses via --listen"
+		err := errors.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, err
+	}
+
 	// ConnectPeers means no DNS seeding and no outbound peers
 	if len(cfg.ConnectPeers) > 0 {
 		cfg.DisableDNSSeed = true
@@ -481,6 +619,18 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	// The mempool has to hold at least a block's worth of transactions,
+	// or it evicts the transactions it accepts right away.
+	if cfg.MaxMempoolMass < cfg.BlockMaxMass {
+		str := "%s: The maxmempoolmass option may not be less than " +
+			"blockmaxmass [%d] -- parsed [%d]"
+		err := errors.Errorf(str, funcName, cfg.BlockMaxMass,
+			cfg.MaxMempoolMass)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, err
+	}
+
 	// Limit the max orphan count to a sane value.
 	if cfg.MaxOrphanTxs < 0 {
 		str := "%s: The maxorphantx option may not be less than 0 " +
@@ -491,6 +641,15 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	if cfg.ScriptVerifyWorkers < 0 {
+		str := "%s: The scriptverifyworkers option may not be less than 0 " +
+			"-- parsed [%d]"
+		err := errors.Errorf(str, funcName, cfg.ScriptVerifyWorkers)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, err
+	}
+
 	// Look for illegal characters in the user agent comments.
 	for _, uaComment := range cfg.UserAgentComments {
 		if strings.ContainsAny(uaComment, "/:()") {
@@ -519,6 +678,40 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	// --rpcuser and --rpcpass must be used together
+	if (cfg.RPCUser == "") != (cfg.RPCPass == "") {
+		str := "%s: --rpcuser and --rpcpass must be used together"
+		err := errors.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, err
+	}
+
+	// Only allow TLS to be disabled if the RPC server is bound to
+	// localhost addresses.
+	if !cfg.DisableRPC && cfg.DisableTLS {
+		for _, rpcListener := range cfg.RPCListeners {
+			if !isLoopbackAddress(rpcListener) {
+				str := "%s: the --notls option may not be used when binding " +
+					"RPC to non localhost addresses: %s"
+				err := errors.Errorf(str, funcName, rpcListener)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, err
+			}
+		}
+	}
+
+	if !cfg.DisableRPC && cfg.RPCUser == "" && cfg.RPCAuthToken == "" {
+		for _, rpcListener := range cfg.RPCListeners {
+			if !isLoopbackAddress(rpcListener) {
+				log.Warnf("The RPC server is bound to the non localhost address %s, "+
+					"but neither --rpcuser/--rpcpass nor --rpcauthtoken are set", rpcListener)
+				break
+			}
+		}
+	}
+
 	// Disallow --addpeer and --connect used together
 	if len(cfg.AddPeers) > 0 && len(cfg.ConnectPeers) > 0 {
 		str := "%s: --addpeer and --connect can not be used together"
@@ -567,6 +760,59 @@ func LoadConfig() (*Config, error) {
 		cfg.Dial = proxy.DialTimeout
 	}
 
+	if cfg.TorControl != "" {
+		_, _, err := net.SplitHostPort(cfg.TorControl)
+		if err != nil {
+			str := "%s: Tor control port address '%s' is invalid: %s"
+			err := errors.Errorf(str, funcName, cfg.TorControl, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, err
+		}
+
+		// Incoming connections of the onion service come from Tor over
+		// loopback, so they get a listener of their own to be told apart
+		// from other local peers
+		if cfg.OnionListen == "" {
+			_, portString, err := net.SplitHostPort(cfg.Listeners[0])
+			if err != nil {
+				return nil, err
+			}
+			port, err := strconv.ParseUint(portString, 10, 16)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid listen port %s", portString)
+			}
+			onionPort := port + 1
+			if onionPort > math.MaxUint16 {
+				onionPort = port - 1
+			}
+			cfg.OnionListen = net.JoinHostPort("127.0.0.1", strconv.FormatUint(onionPort, 10))
+		}
+		onionListeners, err := network.NormalizeAddresses([]string{cfg.OnionListen},
+			cfg.NetParams().DefaultPort)
+		if err != nil {
+			return nil, err
+		}
+		cfg.OnionListen = onionListeners[0]
+		for _, listener := range cfg.Listeners {
+			overlap, err := listenAddressesOverlap(listener, cfg.OnionListen)
+			if err != nil {
+				str := "%s: the onion service listen address %s is invalid: %s"
+				err := errors.Errorf(str, funcName, cfg.OnionListen, err)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, err
+			}
+			if overlap {
+				str := "%s: the onion service listen address %s overlaps the listen address %s"
+				err := errors.Errorf(str, funcName, cfg.OnionListen, listener)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, err
+			}
+		}
+	}
+
 	// Warn about missing config file only after all other configuration is
 	// done. This prevents the warning on help messages and invalid
 	// options. Note this should go directly before the return.
@@ -597,3 +843,17 @@ func createDefaultConfigFile(destinationPath string) error {
 
 	return err
 }
+
+// isLoopbackAddress returns whether the given host:port address
+// is bound to localhost
+func isLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
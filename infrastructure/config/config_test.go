@@ -2,6 +2,7 @@ package config
 
 import (
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -70,3 +71,142 @@ func TestConstants(t *testing.T) {
 		t.Errorf("subnetworks.SubnetworkIDRegistry value was changed from 2, therefore you probably need to update the help text for SubnetworkID")
 	}
 }
+
+// TestLoadConfigWhitelists makes sure that the --whitelist flags are parsed
+// into the whitelisted IP networks
+func TestLoadConfigWhitelists(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestLoadConfigWhitelists")
+	if err != nil {
+		t.Fatalf("Failed creating a temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+	os.Args = []string{"kaspad", "--simnet", "--datadir", tmpDir, "--logdir", tmpDir,
+		"--whitelist", "10.0.0.0/8", "--whitelist", "::1"}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %s", err)
+	}
+	if len(cfg.Whitelists) != 2 {
+		t.Fatalf("Expected 2 whitelisted networks, got %d", len(cfg.Whitelists))
+	}
+	for _, ip := range []string{"10.1.2.3", "::1"} {
+		isWhitelisted := false
+		for _, whitelist := range cfg.Whitelists {
+			if whitelist.Contains(net.ParseIP(ip)) {
+				isWhitelisted = true
+			}
+		}
+		if !isWhitelisted {
+			t.Errorf("Expected %s to be whitelisted", ip)
+		}
+	}
+	for _, whitelist := range cfg.Whitelists {
+		if whitelist.Contains(net.ParseIP("11.1.2.3")) {
+			t.Errorf("Expected 11.1.2.3 not to be whitelisted by %s", whitelist)
+		}
+	}
+
+	os.Args = []string{"kaspad", "--simnet", "--datadir", tmpDir, "--logdir", tmpDir,
+		"--whitelist", "not-an-ip"}
+	_, err = LoadConfig()
+	if err == nil {
+		t.Fatalf("Expected LoadConfig to fail on an invalid whitelist")
+	}
+}
+
+// TestLoadConfigMaxMempoolMass makes sure that a mempool that can't hold a
+// block's worth of transactions is rejected
+func TestLoadConfigMaxMempoolMass(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestLoadConfigMaxMempoolMass")
+	if err != nil {
+		t.Fatalf("Failed creating a temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	tests := []struct {
+		args          []string
+		expectedError bool
+	}{
+		{args: nil, expectedError: false},
+		{args: []string{"--maxmempoolmass", "0"}, expectedError: true},
+		{args: []string{"--blockmaxmass", "2000", "--maxmempoolmass", "1999"}, expectedError: true},
+		{args: []string{"--blockmaxmass", "2000", "--maxmempoolmass", "2000"}, expectedError: false},
+	}
+	for _, test := range tests {
+		os.Args = append([]string{"kaspad", "--simnet", "--datadir", tmpDir, "--logdir", tmpDir}, test.args...)
+		_, err := LoadConfig()
+		if (err != nil) != test.expectedError {
+			t.Errorf("LoadConfig with %v: expected error: %t, got %v", test.args, test.expectedError, err)
+		}
+	}
+}
+
+// TestLoadConfigOnionListen makes sure that the onion service gets a valid
+// listen address of its own
+func TestLoadConfigOnionListen(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestLoadConfigOnionListen")
+	if err != nil {
+		t.Fatalf("Failed creating a temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	tests := []struct {
+		args                []string
+		expectedOnionListen string
+		expectedError       bool
+	}{
+		{
+			args:                []string{"--listen", "0.0.0.0:16111"},
+			expectedOnionListen: "127.0.0.1:16112",
+		},
+		{
+			args:                []string{"--listen", "0.0.0.0:65535"},
+			expectedOnionListen: "127.0.0.1:65534",
+		},
+		{
+			args:          []string{"--listen", "127.0.0.1:16111", "--listen", "0.0.0.0:16112"},
+			expectedError: true,
+		},
+		{
+			args:          []string{"--listen", "127.0.0.1:16111", "--onionlisten", "0.0.0.0:16111"},
+			expectedError: true,
+		},
+		{
+			args:                []string{"--listen", "127.0.0.1:16111", "--onionlisten", "127.0.0.2:16111"},
+			expectedOnionListen: "127.0.0.2:16111",
+		},
+		{
+			args:          []string{"--listen", "0.0.0.0:16111", "--onionlisten", "127.0.0.1:0"},
+			expectedError: true,
+		},
+	}
+	for _, test := range tests {
+		os.Args = append([]string{"kaspad", "--simnet", "--datadir", tmpDir, "--logdir", tmpDir,
+			"--torcontrol", "127.0.0.1:9051"}, test.args...)
+		cfg, err := LoadConfig()
+		if test.expectedError {
+			if err == nil {
+				t.Errorf("LoadConfig with %v: expected an error", test.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("LoadConfig with %v: %s", test.args, err)
+			continue
+		}
+		if cfg.OnionListen != test.expectedOnionListen {
+			t.Errorf("LoadConfig with %v: expected the onion service to listen on %s, got %s",
+				test.args, test.expectedOnionListen, cfg.OnionListen)
+		}
+	}
+}
@@ -17,6 +17,24 @@ var sampleConfig = `[Application Options]
 ; $VARIABLE here. Also, ~ is expanded to $LOCALAPPDATA on Windows.
 ; datadir=~/.kaspad/data
 
+; The database backend to store the block DAG in. Valid types are leveldb, which
+; is the default, and memory. The memory backend keeps the block DAG in memory
+; only, so it is lost when kaspad shuts down. It is meant for ephemeral nodes,
+; such as simnet nodes in tests.
+; dbtype=leveldb
+
+; The maximum size, in bytes, of the cache of the UTXO set. UTXO entries are
+; read from the database when they are not cached, and the least recently used
+; entries are evicted once the cache grows beyond this size. The default of
+; 500 MB fits ordinary hardware. A larger cache needs fewer database reads.
+; maxutxocachesize=500000000
+
+; Load the UTXO set of the pruning point from a snapshot file written by
+; kaspadag export-snapshot, instead of downloading it from a peer, when the
+; pruning point of the snapshot is the pruning point the node syncs to. The
+; headers and the blocks above the pruning point are still synced from peers.
+; utxosnapshot=~/kaspa-snapshot.kutx
+
 
 ; ------------------------------------------------------------------------------
 ; Network settings
@@ -27,14 +45,27 @@ var sampleConfig = `[Application Options]
 
 ; Connect via a SOCKS5 proxy. NOTE: Specifying a proxy will disable listening
 ; for incoming connections unless listen addresses are provided via the 'listen'
-; option.
+; option. Peers with .onion addresses are always connected to through the proxy,
+; so it should be a Tor SOCKS5 proxy to connect to them.
 ; proxy=127.0.0.1:9050
 ; proxyuser=
 ; proxypass=
 
-; Use Universal Plug and Play (UPnP) to automatically open the listen port
-; and obtain the external IP address from supported devices. NOTE: This option
-; will have no effect if external IP addresses are specified.
+; Publish this node as a Tor onion service through the control port of a local
+; Tor daemon, and advertise its .onion address to peers. Tor forwards incoming
+; connections to a listener of their own, on 127.0.0.1 and the port after the
+; first listen port unless onionlisten is given, so that they're told apart from
+; other local peers. The key of the onion service is kept in the data directory,
+; so that its address stays the same across restarts. The password is only
+; needed if the control port uses password authentication.
+; torcontrol=127.0.0.1:9051
+; torpassword=
+; onionlisten=127.0.0.1:16112
+
+; Use Universal Plug and Play (UPnP), or NAT-PMP on devices that don't support
+; UPnP, to automatically open the listen port and obtain the external IP address
+; from supported devices. NOTE: This option will have no effect if external IP
+; addresses are specified.
 ; upnp=1
 
 ; Specify the external IP addresses your node is listening on. One address per
@@ -92,16 +123,24 @@ var sampleConfig = `[Application Options]
 ; Disable banning of misbehaving peers.
 ; nobanning=1
 
-; Maximum allowed ban score before disconnecting and banning misbehaving peers.
+; Ban score at which misbehaving peers are disconnected and banned.
 ; banthreshold=100
 
+; Ban scores added for minor misbehavior, which could be the result of a bug or a
+; network issue, and for severe misbehavior, which is most likely malicious. By
+; default, a peer is banned for its fifth minor or its second severe misbehavior
+; in a short time, and for a severe misbehavior every ten minutes.
+; minorbanscore=20
+; severebanscore=60
+
 ; How long to ban misbehaving peers. Valid time units are {s, m, h}.
 ; Minimum 1s.
 ; banduration=24h
 ; banduration=11h30m15s
 
 ; Add whitelisted IP networks and IPs. Connected peers whose IP matches a
-; whitelist will not have their ban score increased.
+; whitelist will not have their ban score increased, nor be evicted to make room
+; for new inbound peers.
 ; whitelist=127.0.0.1
 ; whitelist=::1
 ; whitelist=192.168.0.0/24
@@ -155,6 +194,25 @@ var sampleConfig = `[Application Options]
 ; which is used to control and query information from a running kaspad process.
 ; ------------------------------------------------------------------------------
 
+; Secure the RPC API by specifying the username and password. RPC clients are
+; required to provide them on every connection.
+; rpcuser=whatever_username_you_want
+; rpcpass=
+
+; Alternatively, or in addition to the username and password, secure the RPC
+; API with a token that RPC clients provide.
+; rpcauthtoken=
+
+; The RPC server uses TLS by default. If the certificate and key don't exist, a
+; self-signed pair is generated on startup. Clients should be given the
+; certificate in order to verify the server.
+; rpccert=~/.kaspad/rpc.cert
+; rpckey=~/.kaspad/rpc.key
+
+; Use the following setting to disable TLS for the RPC server. This is only
+; allowed when the RPC server only listens on localhost.
+; notls=1
+
 ; Specify the interfaces for the RPC server listen on. One listen address per
 ; line. NOTE: The default port is modified by some options such as 'testnet',
 ; so it is recommended to not specify a port and allow a proper default to be
@@ -202,6 +260,11 @@ var sampleConfig = `[Application Options]
 ; Limit orphan transaction pool to 100 transactions.
 ; maxorphantx=100
 
+; Limit the total mass of the transactions in the mempool. Once it is
+; exceeded, the transactions that pay the lowest fee rates are evicted. It may
+; not be less than blockmaxmass.
+; maxmempoolmass=500000000
+
 ; Do not accept transactions from remote peers.
 ; blocksonly=1
 
@@ -220,6 +283,15 @@ var sampleConfig = `[Application Options]
 ; sigcachemaxsize=50000
 
 
+; ------------------------------------------------------------------------------
+; Script Verification
+; ------------------------------------------------------------------------------
+
+; Verify the input scripts of each transaction across 4 goroutines. The default
+; of 0 uses one goroutine per CPU.
+; scriptverifyworkers=4
+
+
 ; ------------------------------------------------------------------------------
 ; Debug
 ; ------------------------------------------------------------------------------
@@ -235,4 +307,15 @@ var sampleConfig = `[Application Options]
 ; be disabled if this option is not specified. The profile information can be
 ; accessed at http://localhost:<profileport>/debug/pprof once running.
 ; profile=6061
+
+; The interface/port used to serve metrics in the Prometheus format over HTTP.
+; The metrics server will be disabled if this option is not specified. The
+; metrics can be scraped from http://<metricslisten>/metrics once running.
+; metricslisten=127.0.0.1:16112
+
+; The file to append traces of the processing of every block to, for offline
+; analysis. Every span of a trace is written as a JSON object on a line of its
+; own, and the ID of the trace is made of the first bytes of the block hash.
+; Tracing will be disabled if this option is not specified.
+; tracefile=~/.kaspad/traces.json
 `
@@ -6,8 +6,9 @@ Overview
 This package provides a database layer to store and retrieve data in a simple
 and efficient manner.
 
-The current backend is ffldb, which makes use of leveldb, flat files, and strict
-checksums in key areas to ensure data integrity.
+There are two backends: ldb, which stores the data in leveldb, and memorydb,
+which keeps the data in memory only and is meant for tests and ephemeral nodes.
+Both backends pass the tests in this package.
 
 Implementors of additional backends are required to implement the following interfaces:
 
@@ -0,0 +1,135 @@
+package memorydb
+
+import (
+	"bytes"
+
+	"github.com/kaspanet/kaspad/infrastructure/db/database"
+	"github.com/pkg/errors"
+)
+
+// MemoryDBCursor iterates over the keys of a bucket in a snapshot of a
+// MemoryDB.
+type MemoryDBCursor struct {
+	iterator *treapIterator
+	bucket   *database.Bucket
+
+	isStarted bool
+	isClosed  bool
+}
+
+// Cursor begins a new cursor over the given prefix.
+func (db *MemoryDB) Cursor(bucket *database.Bucket) (database.Cursor, error) {
+	root, err := db.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	return &MemoryDBCursor{
+		iterator:  newTreapIterator(root),
+		bucket:    bucket,
+		isStarted: false,
+		isClosed:  false,
+	}, nil
+}
+
+// Next moves the iterator to the next key/value pair. It returns whether the
+// iterator is exhausted. Panics if the cursor is closed.
+func (c *MemoryDBCursor) Next() bool {
+	if c.isClosed {
+		panic("cannot call next on a closed cursor")
+	}
+	if !c.isStarted {
+		return c.First()
+	}
+	if c.iterator.current == nil {
+		return false
+	}
+	return c.iterator.next() && c.isCurrentInBucket()
+}
+
+// First moves the iterator to the first key/value pair. It returns false if
+// such a pair does not exist. Panics if the cursor is closed.
+func (c *MemoryDBCursor) First() bool {
+	if c.isClosed {
+		panic("cannot call first on a closed cursor")
+	}
+	c.isStarted = true
+	return c.iterator.seek(c.bucket.Path()) && c.isCurrentInBucket()
+}
+
+// Seek moves the iterator to the first key/value pair whose key is greater
+// than or equal to the given key. It returns ErrNotFound if such pair does not
+// exist.
+func (c *MemoryDBCursor) Seek(key *database.Key) error {
+	if c.isClosed {
+		return errors.New("cannot seek a closed cursor")
+	}
+	c.isStarted = true
+
+	// Keys that come before the bucket are seeked to the start of the
+	// bucket, like LevelDB iterators over a prefix do
+	keyBytes := key.Bytes()
+	if bytes.Compare(keyBytes, c.bucket.Path()) < 0 {
+		keyBytes = c.bucket.Path()
+	}
+
+	found := c.iterator.seek(keyBytes) && c.isCurrentInBucket()
+	if !found || !bytes.Equal(c.iterator.current.key, key.Bytes()) {
+		return errors.Wrapf(database.ErrNotFound, "key %s not found", key)
+	}
+	return nil
+}
+
+// Key returns the key of the current key/value pair, or ErrNotFound if done.
+// Note that the key is trimmed to not include the prefix the cursor was opened
+// with. The caller should not modify the contents of the returned slice.
+func (c *MemoryDBCursor) Key() (*database.Key, error) {
+	if c.isClosed {
+		return nil, errors.New("cannot get the key of a closed cursor")
+	}
+	if c.iterator.current == nil {
+		return nil, errors.Wrapf(database.ErrNotFound, "cannot get the "+
+			"key of an exhausted cursor")
+	}
+	suffix := bytes.TrimPrefix(c.iterator.current.key, c.bucket.Path())
+	return c.bucket.Key(suffix), nil
+}
+
+// Value returns the value of the current key/value pair, or ErrNotFound if done.
+// The caller should not modify the contents of the returned slice.
+func (c *MemoryDBCursor) Value() ([]byte, error) {
+	if c.isClosed {
+		return nil, errors.New("cannot get the value of a closed cursor")
+	}
+	if c.iterator.current == nil {
+		return nil, errors.Wrapf(database.ErrNotFound, "cannot get the "+
+			"value of an exhausted cursor")
+	}
+	return c.iterator.current.value, nil
+}
+
+// Close releases associated resources.
+func (c *MemoryDBCursor) Close() error {
+	if c.isClosed {
+		return errors.New("cannot close an already closed cursor")
+	}
+	c.isClosed = true
+
+	c.iterator = nil
+	return nil
+}
+
+// isCurrentInBucket returns whether the iterator is at a key in the bucket
+// of the cursor. Keys are sorted, so once the iterator moves past the keys of
+// the bucket it's exhausted.
+func (c *MemoryDBCursor) isCurrentInBucket() bool {
+	if c.iterator.current == nil {
+		return false
+	}
+	if !bytes.HasPrefix(c.iterator.current.key, c.bucket.Path()) {
+		c.iterator.current = nil
+		c.iterator.stack = nil
+		return false
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package memorydb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kaspanet/kaspad/infrastructure/db/database"
+)
+
+// TestCursorSnapshot makes sure that a cursor iterates over the database as
+// it was when the cursor was opened, like a LevelDB iterator
+func TestCursorSnapshot(t *testing.T) {
+	db, teardownFunc := prepareDatabaseForTest(t, "TestCursorSnapshot")
+	defer teardownFunc()
+
+	bucket := database.MakeBucket([]byte("bucket"))
+	for i := 0; i < 10; i++ {
+		err := db.Put(bucket.Key([]byte(fmt.Sprintf("key%d", i))), []byte(fmt.Sprintf("value%d", i)))
+		if err != nil {
+			t.Fatalf("TestCursorSnapshot: Put "+
+				"unexpectedly failed: %s", err)
+		}
+	}
+
+	cursor, err := db.Cursor(bucket)
+	if err != nil {
+		t.Fatalf("TestCursorSnapshot: Cursor "+
+			"unexpectedly failed: %s", err)
+	}
+	defer cursor.Close()
+
+	// Change the database while the cursor is open
+	for i := 0; i < 10; i += 2 {
+		err := db.Delete(bucket.Key([]byte(fmt.Sprintf("key%d", i))))
+		if err != nil {
+			t.Fatalf("TestCursorSnapshot: Delete "+
+				"unexpectedly failed: %s", err)
+		}
+	}
+	err = db.Put(bucket.Key([]byte("key5")), []byte("changed"))
+	if err != nil {
+		t.Fatalf("TestCursorSnapshot: Put "+
+			"unexpectedly failed: %s", err)
+	}
+
+	i := 0
+	for ; cursor.Next(); i++ {
+		key, err := cursor.Key()
+		if err != nil {
+			t.Fatalf("TestCursorSnapshot: Key "+
+				"unexpectedly failed: %s", err)
+		}
+		value, err := cursor.Value()
+		if err != nil {
+			t.Fatalf("TestCursorSnapshot: Value "+
+				"unexpectedly failed: %s", err)
+		}
+		if string(key.Suffix()) != fmt.Sprintf("key%d", i) || string(value) != fmt.Sprintf("value%d", i) {
+			t.Fatalf("TestCursorSnapshot: unexpected key/value pair "+
+				"%s/%s at index %d", key.Suffix(), value, i)
+		}
+	}
+	if i != 10 {
+		t.Fatalf("TestCursorSnapshot: the cursor iterated "+
+			"over %d keys instead of 10", i)
+	}
+}
+
+// TestCursorBucketBoundaries makes sure that a cursor never moves out of the
+// bucket it was opened over
+func TestCursorBucketBoundaries(t *testing.T) {
+	db, teardownFunc := prepareDatabaseForTest(t, "TestCursorBucketBoundaries")
+	defer teardownFunc()
+
+	bucket := database.MakeBucket([]byte("b"))
+	keys := []*database.Key{
+		database.MakeBucket([]byte("a")).Key([]byte("key")),
+		bucket.Key([]byte("key1")),
+		bucket.Key([]byte("key2")),
+		database.MakeBucket([]byte("c")).Key([]byte("key")),
+	}
+	for _, key := range keys {
+		err := db.Put(key, []byte("value"))
+		if err != nil {
+			t.Fatalf("TestCursorBucketBoundaries: Put "+
+				"unexpectedly failed: %s", err)
+		}
+	}
+
+	cursor, err := db.Cursor(bucket)
+	if err != nil {
+		t.Fatalf("TestCursorBucketBoundaries: Cursor "+
+			"unexpectedly failed: %s", err)
+	}
+	defer cursor.Close()
+
+	// Seeking to a key before the bucket moves to the start of the bucket
+	err = cursor.Seek(keys[0])
+	if !database.IsNotFoundError(err) {
+		t.Fatalf("TestCursorBucketBoundaries: Seek "+
+			"to a key out of the bucket returned wrong error: %v", err)
+	}
+	key, err := cursor.Key()
+	if err != nil {
+		t.Fatalf("TestCursorBucketBoundaries: Key "+
+			"unexpectedly failed: %s", err)
+	}
+	if string(key.Suffix()) != "key1" {
+		t.Fatalf("TestCursorBucketBoundaries: Seek before the bucket "+
+			"moved to %s instead of key1", key.Suffix())
+	}
+
+	// Next never moves past the last key of the bucket
+	if !cursor.Next() {
+		t.Fatalf("TestCursorBucketBoundaries: Next " +
+			"unexpectedly returned non-existence")
+	}
+	if cursor.Next() {
+		t.Fatalf("TestCursorBucketBoundaries: Next " +
+			"unexpectedly moved out of the bucket")
+	}
+	if cursor.Next() {
+		t.Fatalf("TestCursorBucketBoundaries: Next " +
+			"unexpectedly moved out of the bucket after the cursor was exhausted")
+	}
+	err = cursor.Seek(keys[3])
+	if !database.IsNotFoundError(err) {
+		t.Fatalf("TestCursorBucketBoundaries: Seek "+
+			"to a key after the bucket returned wrong error: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package memorydb
+
+import (
+	"sync"
+
+	"github.com/kaspanet/kaspad/infrastructure/db/database"
+	"github.com/pkg/errors"
+)
+
+// MemoryDB is a database that keeps all of its data in memory. Its data is
+// lost once it's closed.
+//
+// The data is kept in an immutable treap, so cursors iterate over a snapshot
+// of the database as it was when they were opened, like LevelDB iterators.
+type MemoryDB struct {
+	lock     sync.RWMutex
+	root     *treapNode
+	isClosed bool
+}
+
+// NewMemoryDB returns a new empty in-memory database.
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{}
+}
+
+// Close closes the database and releases its data.
+func (db *MemoryDB) Close() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.isClosed {
+		return errors.New("cannot close an already closed database")
+	}
+	db.isClosed = true
+	db.root = nil
+	return nil
+}
+
+// Put sets the value for the given key. It overwrites
+// any previous value for that key.
+func (db *MemoryDB) Put(key *database.Key, value []byte) error {
+	return db.write([]*batchOperation{newPutOperation(key, value)})
+}
+
+// Get gets the value for the given key. It returns
+// ErrNotFound if the given key does not exist.
+func (db *MemoryDB) Get(key *database.Key) ([]byte, error) {
+	root, err := db.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	value, exists := treapGet(root, key.Bytes())
+	if !exists {
+		return nil, errors.Wrapf(database.ErrNotFound, "key %s not found", key)
+	}
+
+	// Copy the value so that the caller may modify it
+	valueCopy := make([]byte, len(value))
+	copy(valueCopy, value)
+	return valueCopy, nil
+}
+
+// Has returns true if the database does contains the
+// given key.
+func (db *MemoryDB) Has(key *database.Key) (bool, error) {
+	root, err := db.snapshot()
+	if err != nil {
+		return false, err
+	}
+	_, exists := treapGet(root, key.Bytes())
+	return exists, nil
+}
+
+// Delete deletes the value for the given key. Will not
+// return an error if the key doesn't exist.
+func (db *MemoryDB) Delete(key *database.Key) error {
+	return db.write([]*batchOperation{newDeleteOperation(key)})
+}
+
+// snapshot returns the root of the treap of the database as it is now
+func (db *MemoryDB) snapshot() (*treapNode, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.isClosed {
+		return nil, errors.New("cannot read from a closed database")
+	}
+	return db.root, nil
+}
+
+// write applies the given operations to the database, atomically
+func (db *MemoryDB) write(operations []*batchOperation) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.isClosed {
+		return errors.New("cannot write to a closed database")
+	}
+	root := db.root
+	for _, operation := range operations {
+		if operation.isDelete {
+			root = treapDelete(root, operation.key)
+		} else {
+			root = treapPut(root, operation.key, operation.value, keyPriority(operation.key))
+		}
+	}
+	db.root = root
+	return nil
+}
+
+// batchOperation is a put or a delete of a key, waiting to be written
+type batchOperation struct {
+	key      []byte
+	value    []byte
+	isDelete bool
+}
+
+func newPutOperation(key *database.Key, value []byte) *batchOperation {
+	// Copy the value so that the caller may modify it after it's put
+	valueCopy := make([]byte, len(value))
+	copy(valueCopy, value)
+	return &batchOperation{key: key.Bytes(), value: valueCopy}
+}
+
+func newDeleteOperation(key *database.Key) *batchOperation {
+	return &batchOperation{key: key.Bytes(), isDelete: true}
+}
@@ -0,0 +1,97 @@
+package memorydb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kaspanet/kaspad/infrastructure/db/database"
+)
+
+func prepareDatabaseForTest(t *testing.T, testName string) (db *MemoryDB, teardownFunc func()) {
+	db = NewMemoryDB()
+	teardownFunc = func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("%s: Close unexpectedly "+
+				"failed: %s", testName, err)
+		}
+	}
+	return db, teardownFunc
+}
+
+func TestMemoryDBSanity(t *testing.T) {
+	db, teardownFunc := prepareDatabaseForTest(t, "TestMemoryDBSanity")
+	defer teardownFunc()
+
+	// Put something into the db
+	key := database.MakeBucket(nil).Key([]byte("key"))
+	putData := []byte("Hello world!")
+	err := db.Put(key, putData)
+	if err != nil {
+		t.Fatalf("TestMemoryDBSanity: Put returned "+
+			"unexpected error: %s", err)
+	}
+
+	// Modifying the put data must not modify the data in the db
+	putData[0] = 'J'
+
+	// Get from the key previously put to
+	getData, err := db.Get(key)
+	if err != nil {
+		t.Fatalf("TestMemoryDBSanity: Get returned "+
+			"unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(getData, []byte("Hello world!")) {
+		t.Fatalf("TestMemoryDBSanity: get data and "+
+			"put data are not equal. Put: Hello world!, got: %s",
+			string(getData))
+	}
+
+	// Modifying the get data must not modify the data in the db either
+	getData[0] = 'J'
+	getData, err = db.Get(key)
+	if err != nil {
+		t.Fatalf("TestMemoryDBSanity: Get returned "+
+			"unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(getData, []byte("Hello world!")) {
+		t.Fatalf("TestMemoryDBSanity: the data in the db was "+
+			"modified through the get data. Got: %s", string(getData))
+	}
+}
+
+func TestMemoryDBCloseErrors(t *testing.T) {
+	db := NewMemoryDB()
+	err := db.Close()
+	if err != nil {
+		t.Fatalf("TestMemoryDBCloseErrors: Close "+
+			"unexpectedly failed: %s", err)
+	}
+
+	key := database.MakeBucket(nil).Key([]byte("key"))
+	err = db.Put(key, []byte("value"))
+	if err == nil {
+		t.Fatalf("TestMemoryDBCloseErrors: Put into a closed " +
+			"database unexpectedly succeeded")
+	}
+	_, err = db.Get(key)
+	if err == nil {
+		t.Fatalf("TestMemoryDBCloseErrors: Get from a closed " +
+			"database unexpectedly succeeded")
+	}
+	_, err = db.Begin()
+	if err == nil {
+		t.Fatalf("TestMemoryDBCloseErrors: Begin on a closed " +
+			"database unexpectedly succeeded")
+	}
+	_, err = db.Cursor(database.MakeBucket(nil))
+	if err == nil {
+		t.Fatalf("TestMemoryDBCloseErrors: Cursor on a closed " +
+			"database unexpectedly succeeded")
+	}
+	err = db.Close()
+	if err == nil {
+		t.Fatalf("TestMemoryDBCloseErrors: closing a closed " +
+			"database unexpectedly succeeded")
+	}
+}
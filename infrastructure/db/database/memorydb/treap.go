@@ -0,0 +1,180 @@
+package memorydb
+
+import (
+	"bytes"
+	"hash/fnv"
+)
+
+// treapNode is a node of an immutable treap: a binary search tree over the
+// keys, which is also a heap over the priorities of the nodes. Nodes are never
+// modified once they are created. Every change to the treap creates new nodes
+// along the path to the changed node, and returns a new root, so that older
+// roots remain valid snapshots of the treap.
+type treapNode struct {
+	key      []byte
+	value    []byte
+	priority uint64
+	left     *treapNode
+	right    *treapNode
+}
+
+// keyPriority returns the priority of the node of the given key. Priorities
+// are derived from the keys, rather than drawn at random, so that the shape
+// of a treap depends only on the keys in it.
+func keyPriority(key []byte) uint64 {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write(key)
+
+	// Keys often differ only in their last bytes, which FNV doesn't spread
+	// over all the bits of the hash, so the hash is mixed with the
+	// finalizer of MurmurHash3
+	priority := hasher.Sum64()
+	priority ^= priority >> 33
+	priority *= 0xff51afd7ed558ccd
+	priority ^= priority >> 33
+	priority *= 0xc4ceb9fe1a85ec53
+	priority ^= priority >> 33
+	return priority
+}
+
+// treapGet returns the value of the given key in the treap with the given
+// root, and whether the key exists
+func treapGet(root *treapNode, key []byte) ([]byte, bool) {
+	node := root
+	for node != nil {
+		compareResult := bytes.Compare(key, node.key)
+		switch {
+		case compareResult < 0:
+			node = node.left
+		case compareResult > 0:
+			node = node.right
+		default:
+			return node.value, true
+		}
+	}
+	return nil, false
+}
+
+// treapPut returns the root of a treap that is the treap with the given root,
+// with the given key set to the given value
+func treapPut(root *treapNode, key []byte, value []byte, priority uint64) *treapNode {
+	if root == nil {
+		return &treapNode{key: key, value: value, priority: priority}
+	}
+
+	compareResult := bytes.Compare(key, root.key)
+	switch {
+	case compareResult < 0:
+		left := treapPut(root.left, key, value, priority)
+		if left.priority > root.priority {
+			// Rotate right, so that left becomes the root
+			return &treapNode{key: left.key, value: left.value, priority: left.priority, left: left.left,
+				right: &treapNode{key: root.key, value: root.value, priority: root.priority,
+					left: left.right, right: root.right}}
+		}
+		return &treapNode{key: root.key, value: root.value, priority: root.priority, left: left, right: root.right}
+	case compareResult > 0:
+		right := treapPut(root.right, key, value, priority)
+		if right.priority > root.priority {
+			// Rotate left, so that right becomes the root
+			return &treapNode{key: right.key, value: right.value, priority: right.priority,
+				left: &treapNode{key: root.key, value: root.value, priority: root.priority,
+					left: root.left, right: right.left},
+				right: right.right}
+		}
+		return &treapNode{key: root.key, value: root.value, priority: root.priority, left: root.left, right: right}
+	default:
+		return &treapNode{key: root.key, value: value, priority: root.priority, left: root.left, right: root.right}
+	}
+}
+
+// treapDelete returns the root of a treap that is the treap with the given
+// root, without the given key. The given root is returned as is if the key
+// doesn't exist.
+func treapDelete(root *treapNode, key []byte) *treapNode {
+	if root == nil {
+		return nil
+	}
+
+	compareResult := bytes.Compare(key, root.key)
+	switch {
+	case compareResult < 0:
+		left := treapDelete(root.left, key)
+		if left == root.left {
+			return root
+		}
+		return &treapNode{key: root.key, value: root.value, priority: root.priority, left: left, right: root.right}
+	case compareResult > 0:
+		right := treapDelete(root.right, key)
+		if right == root.right {
+			return root
+		}
+		return &treapNode{key: root.key, value: root.value, priority: root.priority, left: root.left, right: right}
+	default:
+		return treapMerge(root.left, root.right)
+	}
+}
+
+// treapMerge returns the root of a treap with the nodes of both of the given
+// treaps. All the keys in left must be smaller than all the keys in right.
+func treapMerge(left *treapNode, right *treapNode) *treapNode {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+	if left.priority > right.priority {
+		return &treapNode{key: left.key, value: left.value, priority: left.priority, left: left.left,
+			right: treapMerge(left.right, right)}
+	}
+	return &treapNode{key: right.key, value: right.value, priority: right.priority,
+		left: treapMerge(left, right.left), right: right.right}
+}
+
+// treapIterator iterates over the nodes of a treap in the order of their
+// keys
+type treapIterator struct {
+	root    *treapNode
+	current *treapNode
+
+	// stack holds the nodes that come after current and that weren't
+	// visited yet, with the next node at the top. The nodes in the right
+	// subtrees of the nodes in stack aren't in it.
+	stack []*treapNode
+}
+
+func newTreapIterator(root *treapNode) *treapIterator {
+	return &treapIterator{root: root}
+}
+
+// seek moves the iterator to the first node whose key is greater than or
+// equal to the given key. It returns false if there is no such node.
+func (it *treapIterator) seek(key []byte) bool {
+	it.stack = it.stack[:0]
+	node := it.root
+	for node != nil {
+		if bytes.Compare(node.key, key) >= 0 {
+			it.stack = append(it.stack, node)
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+	return it.next()
+}
+
+// next moves the iterator to the node that comes after the current one. It
+// returns false if there is no such node.
+func (it *treapIterator) next() bool {
+	if len(it.stack) == 0 {
+		it.current = nil
+		return false
+	}
+	it.current = it.stack[len(it.stack)-1]
+	it.stack = it.stack[:len(it.stack)-1]
+	for node := it.current.right; node != nil; node = node.left {
+		it.stack = append(it.stack, node)
+	}
+	return true
+}
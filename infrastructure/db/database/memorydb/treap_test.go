@@ -0,0 +1,78 @@
+package memorydb
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// TestTreap applies random puts and deletes to a treap and to a map, and
+// makes sure that they always have the same keys and values, that the treap
+// stays ordered, and that older roots are left untouched.
+func TestTreap(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	var root *treapNode
+	expected := make(map[string]string)
+
+	for i := 0; i < 5000; i++ {
+		key := []byte(fmt.Sprintf("key%d", random.Intn(500)))
+		previousRoot := root
+		previousValue, previousExists := treapGet(previousRoot, key)
+
+		if random.Intn(3) == 0 {
+			root = treapDelete(root, key)
+			delete(expected, string(key))
+		} else {
+			value := []byte(fmt.Sprintf("value%d", i))
+			root = treapPut(root, key, value, keyPriority(key))
+			expected[string(key)] = string(value)
+		}
+
+		value, exists := treapGet(previousRoot, key)
+		if exists != previousExists || !bytes.Equal(value, previousValue) {
+			t.Fatalf("TestTreap: a change to the treap modified a previous root")
+		}
+	}
+
+	expectedKeys := make([]string, 0, len(expected))
+	for key := range expected {
+		expectedKeys = append(expectedKeys, key)
+	}
+	sort.Strings(expectedKeys)
+
+	iterator := newTreapIterator(root)
+	i := 0
+	for hasNext := iterator.seek(nil); hasNext; hasNext = iterator.next() {
+		if i >= len(expectedKeys) {
+			t.Fatalf("TestTreap: the treap has more than the expected %d keys", len(expectedKeys))
+		}
+		if string(iterator.current.key) != expectedKeys[i] {
+			t.Fatalf("TestTreap: key %d is %s instead of %s", i, iterator.current.key, expectedKeys[i])
+		}
+		if string(iterator.current.value) != expected[expectedKeys[i]] {
+			t.Fatalf("TestTreap: the value of %s is %s instead of %s", iterator.current.key,
+				iterator.current.value, expected[expectedKeys[i]])
+		}
+		i++
+	}
+	if i != len(expectedKeys) {
+		t.Fatalf("TestTreap: the treap has %d keys instead of %d", i, len(expectedKeys))
+	}
+
+	if depth := treapDepth(root); depth > 30 {
+		t.Fatalf("TestTreap: the treap of %d keys is unbalanced with a depth of %d", i, depth)
+	}
+}
+
+func treapDepth(node *treapNode) int {
+	if node == nil {
+		return 0
+	}
+	leftDepth, rightDepth := treapDepth(node.left), treapDepth(node.right)
+	if leftDepth > rightDepth {
+		return leftDepth + 1
+	}
+	return rightDepth + 1
+}
@@ -56,6 +56,11 @@ var (
 	reacLog = BackendLog.Logger("REAC")
 	prnmLog = BackendLog.Logger("PRNM")
 	blvlLog = BackendLog.Logger("BLVL")
+	natmLog = BackendLog.Logger("NATM")
+	torcLog = BackendLog.Logger("TORC")
+	mtrcLog = BackendLog.Logger("MTRC")
+	trceLog = BackendLog.Logger("TRCE")
+	dagfLog = BackendLog.Logger("DAGF")
 )
 
 // SubsystemTags is an enum of all sub system tags
@@ -89,7 +94,12 @@ var SubsystemTags = struct {
 	WSVC,
 	REAC,
 	PRNM,
-	BLVL string
+	BLVL,
+	NATM,
+	TORC,
+	MTRC,
+	TRCE,
+	DAGF string
 }{
 	ADXR: "ADXR",
 	AMGR: "AMGR",
@@ -121,6 +131,11 @@ var SubsystemTags = struct {
 	REAC: "REAC",
 	PRNM: "PRNM",
 	BLVL: "BLVL",
+	NATM: "NATM",
+	TORC: "TORC",
+	MTRC: "MTRC",
+	TRCE: "TRCE",
+	DAGF: "DAGF",
 }
 
 // subsystemLoggers maps each subsystem identifier to its associated logger.
@@ -155,6 +170,11 @@ var subsystemLoggers = map[string]*Logger{
 	SubsystemTags.REAC: reacLog,
 	SubsystemTags.PRNM: prnmLog,
 	SubsystemTags.BLVL: blvlLog,
+	SubsystemTags.NATM: natmLog,
+	SubsystemTags.TORC: torcLog,
+	SubsystemTags.MTRC: mtrcLog,
+	SubsystemTags.TRCE: trceLog,
+	SubsystemTags.DAGF: dagfLog,
 }
 
 // InitLog attaches log file and error log file to the backend log.
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace is the prefix of the names of all of kaspad's metrics
+const Namespace = "kaspad"
+
+// isEnabled is set once a metrics server is created. Metrics that are costly
+// to record are only recorded while it's set, since nothing serves them
+// otherwise.
+var isEnabled uint32
+
+// IsEnabled returns whether this process serves metrics
+func IsEnabled() bool {
+	return atomic.LoadUint32(&isEnabled) != 0
+}
+
+// Server serves the metrics of the node over HTTP at /metrics, in the
+// Prometheus exposition format
+type Server struct {
+	listener   net.Listener
+	httpServer *http.Server
+}
+
+// NewServer returns a Server that listens on the given address, and serves
+// the metrics registered with the default Prometheus registry alongside the
+// given collectors. The collectors are for the metrics that belong to this
+// instance of the node, rather than to the process.
+func NewServer(listenAddress string, collectors ...prometheus.Collector) (*Server, error) {
+	registry := prometheus.NewRegistry()
+	for _, collector := range collectors {
+		err := registry.Register(collector)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	gatherers := prometheus.Gatherers{prometheus.DefaultGatherer, registry}
+
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listening on %s", listenAddress)
+	}
+
+	atomic.StoreUint32(&isEnabled, 1)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+	}))
+
+	return &Server{
+		listener:   listener,
+		httpServer: &http.Server{Handler: mux},
+	}, nil
+}
+
+// Start begins serving the metrics
+func (s *Server) Start() {
+	spawn("metrics.Server.serve", func() {
+		log.Infof("Metrics server listening on %s", s.listener.Addr())
+		err := s.httpServer.Serve(s.listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("Error serving metrics: %s", err)
+		}
+	})
+}
+
+// Stop stops serving the metrics
+func (s *Server) Stop() error {
+	return errors.WithStack(s.httpServer.Close())
+}
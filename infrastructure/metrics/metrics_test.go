@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestServer(t *testing.T) {
+	nodeGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "test_node_gauge",
+		Help:      "A gauge that belongs to the node",
+	})
+	nodeGauge.Set(42)
+
+	server, err := NewServer("127.0.0.1:0", nodeGauge)
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+	if !IsEnabled() {
+		t.Fatalf("expected metrics to be enabled once a server is created")
+	}
+	server.Start()
+	defer server.Stop()
+
+	response, err := http.Get("http://" + server.listener.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %s", response.Status)
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("ReadAll: %s", err)
+	}
+
+	// Both the metrics of the node and the ones of the process are served
+	for _, expectedLine := range []string{"kaspad_test_node_gauge 42", "go_goroutines "} {
+		if !strings.Contains(string(body), expectedLine) {
+			t.Errorf("expected the metrics to contain %q, got:\n%s", expectedLine, body)
+		}
+	}
+
+	// Another node in the same process registers its own collectors
+	otherServer, err := NewServer("127.0.0.1:0", prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "test_node_gauge",
+		Help:      "A gauge that belongs to the node",
+	}))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+	otherServer.Start()
+	defer otherServer.Stop()
+}
@@ -5,10 +5,13 @@
 package addressmanager
 
 import (
+	"crypto/ed25519"
 	"net"
 	"sync"
 
 	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/infrastructure/db/database"
+	"github.com/kaspanet/kaspad/util/mstime"
 	"github.com/pkg/errors"
 )
 
@@ -18,17 +21,22 @@ type addressRandomizer interface {
 	RandomAddresses(addresses []*appmessage.NetAddress, count int) []*appmessage.NetAddress
 }
 
-// addressKey represents a pair of IP and port, the IP is always in V6 representation
-type addressKey struct {
-	port    uint16
+// hostKey represents the host of an address: either an IP, always in V6
+// representation, or the public key of an onion service
+type hostKey struct {
 	address ipv6
+	onion   onionPublicKey
+}
+
+// addressKey represents a pair of host and port
+type addressKey struct {
+	port uint16
+	hostKey
 }
 
 type ipv6 [net.IPv6len]byte
 
-func (i ipv6) equal(other ipv6) bool {
-	return i == other
-}
+type onionPublicKey [ed25519.PublicKeySize]byte
 
 // ErrAddressNotFound is an error returned from some functions when a
 // given address is not found in the address manager
@@ -37,6 +45,10 @@ var ErrAddressNotFound = errors.New("address not found")
 // NetAddressKey returns a key of the ip address to use it in maps.
 func netAddressKey(netAddress *appmessage.NetAddress) addressKey {
 	key := addressKey{port: netAddress.Port}
+	if netAddress.IsOnion() {
+		copy(key.onion[:], netAddress.OnionPublicKey)
+		return key
+	}
 	// all IPv4 can be represented as IPv6.
 	copy(key.address[:], netAddress.IP.To16())
 	return key
@@ -56,67 +68,228 @@ func netAddressesKeys(netAddresses []*appmessage.NetAddress) map[addressKey]bool
 // AddressManager provides a concurrency safe address manager for caching potential
 // peers on the Kaspa network.
 type AddressManager struct {
-	addresses       map[addressKey]*appmessage.NetAddress
-	bannedAddresses map[ipv6]*appmessage.NetAddress
-	localAddresses  *localAddressManager
-	mutex           sync.Mutex
-	cfg             *Config
-	random          addressRandomizer
+	store          *addressStore
+	tables         *addressTables
+	localAddresses *localAddressManager
+	mutex          sync.Mutex
+	cfg            *Config
+	random         addressRandomizer
 }
 
 // New returns a new Kaspa address manager.
-func New(cfg *Config) (*AddressManager, error) {
+func New(cfg *Config, database database.Database) (*AddressManager, error) {
+	addressStore, err := newAddressStore(database)
+	if err != nil {
+		return nil, err
+	}
 	localAddresses, err := newLocalAddressManager(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return &AddressManager{
-		addresses:       map[addressKey]*appmessage.NetAddress{},
-		bannedAddresses: map[ipv6]*appmessage.NetAddress{},
-		localAddresses:  localAddresses,
-		random:          NewAddressRandomize(),
-		cfg:             cfg,
-	}, nil
+	addressManager := &AddressManager{
+		store:          addressStore,
+		tables:         newAddressTables(),
+		localAddresses: localAddresses,
+		random:         NewAddressRandomize(),
+		cfg:            cfg,
+	}
+	for _, knownAddress := range addressStore.getAllNotBanned() {
+		addressManager.tables.add(netAddressKey(knownAddress.netAddress), knownAddress)
+	}
+
+	return addressManager, nil
 }
 
-func (am *AddressManager) addAddressNoLock(address *appmessage.NetAddress) {
+func (am *AddressManager) addAddressNoLock(address *appmessage.NetAddress, sourceAddress *appmessage.NetAddress) error {
 	if !IsRoutable(address, am.cfg.AcceptUnroutable) {
-		return
+		return nil
 	}
 
 	key := netAddressKey(address)
-	_, ok := am.addresses[key]
-	if !ok {
-		am.addresses[key] = address
+	if _, ok := am.store.getBanned(key); ok {
+		return nil
 	}
+
+	if knownAddress, ok := am.store.get(key); ok {
+		// Update the last seen time and services of an address we already know
+		if !address.Timestamp.After(knownAddress.netAddress.Timestamp) &&
+			knownAddress.netAddress.HasService(address.Services) {
+			return nil
+		}
+		updatedNetAddress := *knownAddress.netAddress
+		if address.Timestamp.After(updatedNetAddress.Timestamp) {
+			updatedNetAddress.Timestamp = address.Timestamp
+		}
+		updatedNetAddress.AddService(address.Services)
+		knownAddress.netAddress = &updatedNetAddress
+		return am.store.set(key, knownAddress)
+	}
+
+	bucket := newBucketIndex(am.store.bucketingKey, am.GroupKey(address), am.GroupKey(sourceAddress))
+	if am.tables.isNewBucketFull(bucket) {
+		err := am.evictFromNewBucketNoLock(bucket)
+		if err != nil {
+			return err
+		}
+	}
+
+	knownAddress := &knownAddress{
+		netAddress: address,
+		bucket:     bucket,
+	}
+	am.tables.add(key, knownAddress)
+	return am.store.set(key, knownAddress)
+}
+
+func (am *AddressManager) evictFromNewBucketNoLock(bucket uint32) error {
+	key, knownAddress := am.tables.newBucketEvictionCandidate(bucket)
+	log.Tracef("Evicting %s from new bucket %d", knownAddress.netAddress, bucket)
+	return am.removeAddressNoLock(key, knownAddress)
+}
+
+func (am *AddressManager) removeAddressNoLock(key addressKey, knownAddress *knownAddress) error {
+	am.tables.remove(key, knownAddress)
+	return am.store.remove(key)
 }
 
 // AddAddress adds address to the address manager
-func (am *AddressManager) AddAddress(address *appmessage.NetAddress) {
+func (am *AddressManager) AddAddress(address *appmessage.NetAddress) error {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	return am.addAddressNoLock(address, address)
+}
+
+// AddAddresses adds addresses to the address manager. Each address is
+// considered to be its own source.
+func (am *AddressManager) AddAddresses(addresses ...*appmessage.NetAddress) error {
 	am.mutex.Lock()
 	defer am.mutex.Unlock()
 
-	am.addAddressNoLock(address)
+	for _, address := range addresses {
+		err := am.addAddressNoLock(address, address)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// AddAddresses adds addresses to the address manager
-func (am *AddressManager) AddAddresses(addresses ...*appmessage.NetAddress) {
+// AddAddressesFromSource adds addresses that were received from sourceAddress
+// to the address manager. The addresses are bucketed according to the network
+// group of the source, which limits how much of the address manager a single
+// source is able to populate.
+func (am *AddressManager) AddAddressesFromSource(sourceAddress *appmessage.NetAddress,
+	addresses ...*appmessage.NetAddress) error {
+
 	am.mutex.Lock()
 	defer am.mutex.Unlock()
 
 	for _, address := range addresses {
-		am.addAddressNoLock(address)
+		err := am.addAddressNoLock(address, sourceAddress)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // RemoveAddress removes addresses from the address manager
-func (am *AddressManager) RemoveAddress(address *appmessage.NetAddress) {
+func (am *AddressManager) RemoveAddress(address *appmessage.NetAddress) error {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	key := netAddressKey(address)
+	knownAddress, ok := am.store.get(key)
+	if !ok {
+		return nil
+	}
+	return am.removeAddressNoLock(key, knownAddress)
+}
+
+// Attempt marks that a connection attempt to the given address is about
+// to be made. Addresses that had too many attempts without a success are
+// considered bad. Bad addresses are not selected by RandomAddresses and
+// are the first to be evicted when their bucket is full.
+func (am *AddressManager) Attempt(address *appmessage.NetAddress) error {
 	am.mutex.Lock()
 	defer am.mutex.Unlock()
 
 	key := netAddressKey(address)
-	delete(am.addresses, key)
+	knownAddress, ok := am.store.get(key)
+	if !ok {
+		return nil
+	}
+
+	knownAddress.attempts++
+	knownAddress.lastAttempt = mstime.Now()
+	return am.store.set(key, knownAddress)
+}
+
+// Good marks the given address as good. This should be called after a
+// successful outgoing connection to the address. Good addresses are moved
+// to the tried table.
+func (am *AddressManager) Good(address *appmessage.NetAddress) error {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	key := netAddressKey(address)
+	knownAddress, ok := am.store.get(key)
+	if !ok {
+		return nil
+	}
+
+	now := mstime.Now()
+	knownAddress.lastSuccess = now
+	knownAddress.lastAttempt = now
+	knownAddress.attempts = 0
+	updatedNetAddress := *knownAddress.netAddress
+	updatedNetAddress.Timestamp = now
+	knownAddress.netAddress = &updatedNetAddress
+
+	if knownAddress.isTried {
+		return am.store.set(key, knownAddress)
+	}
+
+	am.tables.remove(key, knownAddress)
+
+	triedBucket := triedBucketIndex(am.store.bucketingKey, am.GroupKey(knownAddress.netAddress), key)
+	if am.tables.isTriedBucketFull(triedBucket) {
+		err := am.moveFromTriedToNewNoLock(triedBucket)
+		if err != nil {
+			return err
+		}
+	}
+
+	knownAddress.isTried = true
+	knownAddress.bucket = triedBucket
+	am.tables.add(key, knownAddress)
+	return am.store.set(key, knownAddress)
+}
+
+// moveFromTriedToNewNoLock makes room in the given tried bucket by moving
+// its oldest address back to the new table
+func (am *AddressManager) moveFromTriedToNewNoLock(triedBucket uint32) error {
+	key, knownAddress := am.tables.triedBucketEvictionCandidate(triedBucket)
+	log.Tracef("Moving %s from tried bucket %d back to the new table",
+		knownAddress.netAddress, triedBucket)
+
+	am.tables.remove(key, knownAddress)
+
+	group := am.GroupKey(knownAddress.netAddress)
+	newBucket := newBucketIndex(am.store.bucketingKey, group, group)
+	if am.tables.isNewBucketFull(newBucket) {
+		err := am.evictFromNewBucketNoLock(newBucket)
+		if err != nil {
+			return err
+		}
+	}
+
+	knownAddress.isTried = false
+	knownAddress.bucket = newBucket
+	am.tables.add(key, knownAddress)
+	return am.store.set(key, knownAddress)
 }
 
 // Addresses returns all addresses
@@ -124,9 +297,10 @@ func (am *AddressManager) Addresses() []*appmessage.NetAddress {
 	am.mutex.Lock()
 	defer am.mutex.Unlock()
 
-	result := make([]*appmessage.NetAddress, 0, len(am.addresses))
-	for _, address := range am.addresses {
-		result = append(result, address)
+	knownAddresses := am.store.getAllNotBanned()
+	result := make([]*appmessage.NetAddress, 0, len(knownAddresses))
+	for _, knownAddress := range knownAddresses {
+		result = append(result, knownAddress.netAddress)
 	}
 
 	return result
@@ -137,40 +311,76 @@ func (am *AddressManager) BannedAddresses() []*appmessage.NetAddress {
 	am.mutex.Lock()
 	defer am.mutex.Unlock()
 
-	result := make([]*appmessage.NetAddress, 0, len(am.bannedAddresses))
-	for _, address := range am.bannedAddresses {
-		result = append(result, address)
+	bannedAddresses := am.store.getAllBanned()
+	result := make([]*appmessage.NetAddress, 0, len(bannedAddresses))
+	for _, bannedAddress := range bannedAddresses {
+		if am.isBanExpired(bannedAddress) {
+			continue
+		}
+		result = append(result, bannedAddress.netAddress)
 	}
 
 	return result
 }
 
-// notBannedAddressesWithException returns all not banned addresses with excpetion
-func (am *AddressManager) notBannedAddressesWithException(exceptions []*appmessage.NetAddress) []*appmessage.NetAddress {
+// selectionCandidates returns all not bad addresses that aren't in exceptions,
+// split to tried addresses and new addresses. Onion services are only
+// returned if they're reachable through a proxy.
+func (am *AddressManager) selectionCandidates(exceptions []*appmessage.NetAddress) (
+	triedAddresses []*appmessage.NetAddress, newAddresses []*appmessage.NetAddress) {
+
 	exceptionsKeys := netAddressesKeys(exceptions)
 	am.mutex.Lock()
 	defer am.mutex.Unlock()
 
-	result := make([]*appmessage.NetAddress, 0, len(am.addresses))
-	for key, address := range am.addresses {
-		if !exceptionsKeys[key] {
-			result = append(result, address)
+	for _, knownAddress := range am.store.getAllNotBanned() {
+		if exceptionsKeys[netAddressKey(knownAddress.netAddress)] || knownAddress.isBad() {
+			continue
+		}
+		if knownAddress.netAddress.IsOnion() && !am.cfg.OnionReachable {
+			continue
+		}
+		if knownAddress.isTried {
+			triedAddresses = append(triedAddresses, knownAddress.netAddress)
+		} else {
+			newAddresses = append(newAddresses, knownAddress.netAddress)
 		}
 	}
 
-	return result
+	return triedAddresses, newAddresses
 }
 
 // RandomAddress returns a random address that isn't banned and isn't in exceptions
 func (am *AddressManager) RandomAddress(exceptions []*appmessage.NetAddress) *appmessage.NetAddress {
-	validAddresses := am.notBannedAddressesWithException(exceptions)
-	return am.random.RandomAddress(validAddresses)
+	triedAddresses, newAddresses := am.selectionCandidates(exceptions)
+	if len(triedAddresses) > 0 {
+		return am.random.RandomAddress(triedAddresses)
+	}
+	return am.random.RandomAddress(newAddresses)
 }
 
-// RandomAddresses returns count addresses at random that aren't banned and aren't in exceptions
+// RandomAddresses returns count addresses at random that aren't banned and aren't in exceptions.
+// Up to half (rounded up) of the returned addresses are taken from the tried table,
+// and the rest from the new table. If either table doesn't have enough addresses,
+// the remainder is taken from the other one.
 func (am *AddressManager) RandomAddresses(count int, exceptions []*appmessage.NetAddress) []*appmessage.NetAddress {
-	validAddresses := am.notBannedAddressesWithException(exceptions)
-	return am.random.RandomAddresses(validAddresses, count)
+	triedAddresses, newAddresses := am.selectionCandidates(exceptions)
+
+	triedCount := (count + 1) / 2
+	if triedCount > len(triedAddresses) {
+		triedCount = len(triedAddresses)
+	}
+	newCount := count - triedCount
+	if newCount > len(newAddresses) {
+		newCount = len(newAddresses)
+		triedCount = count - newCount
+		if triedCount > len(triedAddresses) {
+			triedCount = len(triedAddresses)
+		}
+	}
+
+	result := am.random.RandomAddresses(triedAddresses, triedCount)
+	return append(result, am.random.RandomAddresses(newAddresses, newCount)...)
 }
 
 // BestLocalAddress returns the most appropriate local address to use
@@ -179,24 +389,29 @@ func (am *AddressManager) BestLocalAddress(remoteAddress *appmessage.NetAddress)
 	return am.localAddresses.bestLocalAddress(remoteAddress)
 }
 
+// AddLocalAddress adds an address this node is reachable at to the addresses
+// advertised to peers. priority describes how the address was discovered.
+func (am *AddressManager) AddLocalAddress(address *appmessage.NetAddress, priority AddressPriority) error {
+	return am.localAddresses.addLocalNetAddress(address, priority)
+}
+
 // Ban marks the given address as banned
-func (am *AddressManager) Ban(addressToBan *appmessage.NetAddress) {
+func (am *AddressManager) Ban(addressToBan *appmessage.NetAddress) error {
 	am.mutex.Lock()
 	defer am.mutex.Unlock()
 
 	keyToBan := netAddressKey(addressToBan)
-	keysToDelete := make([]addressKey, 0)
-	for _, address := range am.addresses {
-		key := netAddressKey(address)
-		if key.address.equal(keyToBan.address) {
-			keysToDelete = append(keysToDelete, key)
+	for _, knownAddress := range am.store.getAllNotBanned() {
+		key := netAddressKey(knownAddress.netAddress)
+		if key.hostKey == keyToBan.hostKey {
+			err := am.removeAddressNoLock(key, knownAddress)
+			if err != nil {
+				return err
+			}
 		}
 	}
-	for _, key := range keysToDelete {
-		delete(am.addresses, key)
-	}
 
-	am.bannedAddresses[keyToBan.address] = addressToBan
+	return am.store.addBanned(keyToBan, addressToBan, mstime.Now())
 }
 
 // Unban unmarks the given address as banned
@@ -205,15 +420,22 @@ func (am *AddressManager) Unban(address *appmessage.NetAddress) error {
 	defer am.mutex.Unlock()
 
 	key := netAddressKey(address)
-	bannedAddress, ok := am.bannedAddresses[key.address]
-	if !ok {
+	bannedAddress, ok := am.store.getBanned(key)
+	if !ok || am.isBanExpired(bannedAddress) {
 		return errors.Wrapf(ErrAddressNotFound, "address %s "+
-			"is not registered with the address manager as banned", address.TCPAddress())
+			"is not registered with the address manager as banned", address)
 	}
 
-	delete(am.bannedAddresses, key.address)
-	am.addresses[key] = bannedAddress
-	return nil
+	return am.unbanNoLock(key, bannedAddress)
+}
+
+func (am *AddressManager) unbanNoLock(key addressKey, bannedAddress *bannedAddress) error {
+	err := am.store.removeBanned(key)
+	if err != nil {
+		return err
+	}
+
+	return am.addAddressNoLock(bannedAddress.netAddress, bannedAddress.netAddress)
 }
 
 // IsBanned returns true if the given address is marked as banned
@@ -222,14 +444,29 @@ func (am *AddressManager) IsBanned(address *appmessage.NetAddress) (bool, error)
 	defer am.mutex.Unlock()
 
 	key := netAddressKey(address)
-	if _, ok := am.bannedAddresses[key.address]; !ok {
-		if _, ok = am.addresses[key]; !ok {
+	bannedAddress, ok := am.store.getBanned(key)
+	if !ok {
+		if _, ok := am.store.get(key); !ok {
 			return false, errors.Wrapf(ErrAddressNotFound, "address %s "+
-				"is not registered with the address manager", address.TCPAddress())
+				"is not registered with the address manager", address)
+		}
+		return false, nil
+	}
+
+	if am.isBanExpired(bannedAddress) {
+		log.Debugf("Ban of %s has expired. Unbanning it", address)
+		err := am.unbanNoLock(key, bannedAddress)
+		if err != nil {
+			return false, err
 		}
 		return false, nil
 	}
 
 	return true, nil
+}
 
+// isBanExpired returns whether more than BanDuration has passed since
+// the given address was banned
+func (am *AddressManager) isBanExpired(bannedAddress *bannedAddress) bool {
+	return mstime.Since(bannedAddress.bannedAt) > am.cfg.BanDuration
 }
@@ -5,10 +5,15 @@
 package addressmanager
 
 import (
+	"io/ioutil"
 	"net"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/infrastructure/db/database/ldb"
+	"github.com/kaspanet/kaspad/util/mstime"
 
 	"github.com/kaspanet/kaspad/infrastructure/config"
 )
@@ -16,12 +21,29 @@ import (
 func newAddrManagerForTest(t *testing.T, testName string) (addressManager *AddressManager, teardown func()) {
 	cfg := config.DefaultConfig()
 
-	addressManager, err := New(NewConfig(cfg))
+	datadir, err := ioutil.TempDir("", testName)
+	if err != nil {
+		t.Fatalf("%s: TempDir unexpectedly failed: %s", testName, err)
+	}
+	database, err := ldb.NewLevelDB(datadir)
+	if err != nil {
+		t.Fatalf("%s: error creating database: %s", testName, err)
+	}
+
+	addressManager, err = New(NewConfig(cfg), database)
 	if err != nil {
 		t.Fatalf("error creating address manager: %s", err)
 	}
 
 	return addressManager, func() {
+		err := database.Close()
+		if err != nil {
+			t.Fatalf("%s: database.Close unexpectedly failed: %s", testName, err)
+		}
+		err = os.RemoveAll(datadir)
+		if err != nil {
+			t.Fatalf("%s: os.RemoveAll unexpectedly failed: %s", testName, err)
+		}
 	}
 }
 
@@ -107,3 +129,197 @@ func TestBestLocalAddress(t *testing.T) {
 		}
 	}
 }
+
+func TestAddressManagerPersistence(t *testing.T) {
+	cfg := config.DefaultConfig()
+
+	datadir, err := ioutil.TempDir("", "TestAddressManagerPersistence")
+	if err != nil {
+		t.Fatalf("TempDir unexpectedly failed: %s", err)
+	}
+	defer os.RemoveAll(datadir)
+
+	database, err := ldb.NewLevelDB(datadir)
+	if err != nil {
+		t.Fatalf("error creating database: %s", err)
+	}
+
+	amgr, err := New(NewConfig(cfg), database)
+	if err != nil {
+		t.Fatalf("error creating address manager: %s", err)
+	}
+
+	address := appmessage.NewNetAddressIPPort(net.ParseIP("204.124.8.1"), 16111, appmessage.SFNodeNetwork)
+	addressToBan := appmessage.NewNetAddressIPPort(net.ParseIP("204.124.8.2"), 16111, appmessage.SFNodeNetwork)
+	onionPublicKey := make([]byte, 32)
+	onionPublicKey[0] = 1
+	onionAddressToBan := appmessage.NewNetAddressOnion(onionPublicKey, 16111, appmessage.SFNodeNetwork)
+	err = amgr.AddAddresses(address, addressToBan, onionAddressToBan)
+	if err != nil {
+		t.Fatalf("AddAddresses: %s", err)
+	}
+	err = amgr.Ban(addressToBan)
+	if err != nil {
+		t.Fatalf("Ban: %s", err)
+	}
+	err = amgr.Ban(onionAddressToBan)
+	if err != nil {
+		t.Fatalf("Ban: %s", err)
+	}
+	err = amgr.Good(address)
+	if err != nil {
+		t.Fatalf("Good: %s", err)
+	}
+	goodAddress := amgr.Addresses()[0]
+
+	err = database.Close()
+	if err != nil {
+		t.Fatalf("database.Close: %s", err)
+	}
+
+	// Reopen the database and make sure the address manager
+	// restores the addresses it had before
+	database, err = ldb.NewLevelDB(datadir)
+	if err != nil {
+		t.Fatalf("error reopening database: %s", err)
+	}
+	defer database.Close()
+
+	amgr, err = New(NewConfig(cfg), database)
+	if err != nil {
+		t.Fatalf("error creating address manager: %s", err)
+	}
+
+	addresses := amgr.Addresses()
+	if len(addresses) != 1 {
+		t.Fatalf("expected 1 address after restart, got %d", len(addresses))
+	}
+	if !addresses[0].IP.Equal(goodAddress.IP) || addresses[0].Port != goodAddress.Port ||
+		addresses[0].Services != goodAddress.Services ||
+		addresses[0].Timestamp.UnixMilliseconds() != goodAddress.Timestamp.UnixMilliseconds() {
+		t.Fatalf("expected restored address %+v, got %+v", goodAddress, addresses[0])
+	}
+	knownAddress, _ := amgr.store.get(netAddressKey(address))
+	if !knownAddress.isTried {
+		t.Fatalf("expected %s to remain in the tried table after restart", address.TCPAddress())
+	}
+	if _, ok := amgr.tables.triedBuckets[knownAddress.bucket][netAddressKey(address)]; !ok {
+		t.Fatalf("expected %s to be restored into tried bucket %d", address.TCPAddress(), knownAddress.bucket)
+	}
+
+	isBanned, err := amgr.IsBanned(addressToBan)
+	if err != nil {
+		t.Fatalf("IsBanned: %s", err)
+	}
+	if !isBanned {
+		t.Fatalf("expected %s to remain banned after restart", addressToBan.TCPAddress())
+	}
+	isBanned, err = amgr.IsBanned(onionAddressToBan)
+	if err != nil {
+		t.Fatalf("IsBanned: %s", err)
+	}
+	if !isBanned {
+		t.Fatalf("expected %s to remain banned after restart", onionAddressToBan)
+	}
+}
+
+func TestAddressStoreVersioning(t *testing.T) {
+	cfg := config.DefaultConfig()
+
+	datadir, err := ioutil.TempDir("", "TestAddressStoreVersioning")
+	if err != nil {
+		t.Fatalf("TempDir unexpectedly failed: %s", err)
+	}
+	defer os.RemoveAll(datadir)
+
+	database, err := ldb.NewLevelDB(datadir)
+	if err != nil {
+		t.Fatalf("error creating database: %s", err)
+	}
+	defer database.Close()
+
+	store := &addressStore{}
+	address := appmessage.NewNetAddressIPPort(net.ParseIP("204.124.8.1"), 16111, appmessage.SFNodeNetwork)
+	err = database.Put(store.notBannedDatabaseKey(netAddressKey(address)),
+		store.addVersionPrefix(store.serializeKnownAddress(&knownAddress{netAddress: address})))
+	if err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+
+	// Entries of another version are discarded rather than misread
+	corruptAddress := appmessage.NewNetAddressIPPort(net.ParseIP("204.124.8.3"), 16111, appmessage.SFNodeNetwork)
+	corruptAddressKey := store.notBannedDatabaseKey(netAddressKey(corruptAddress))
+	err = database.Put(corruptAddressKey,
+		append([]byte{storeVersion + 1}, store.serializeKnownAddress(&knownAddress{netAddress: corruptAddress})...))
+	if err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+	amgr, err := New(NewConfig(cfg), database)
+	if err != nil {
+		t.Fatalf("error creating address manager: %s", err)
+	}
+	if _, ok := amgr.store.get(netAddressKey(corruptAddress)); ok {
+		t.Fatalf("expected %s of another version to be discarded", corruptAddress.TCPAddress())
+	}
+	if _, ok := amgr.store.get(netAddressKey(address)); !ok {
+		t.Fatalf("expected %s to be restored", address.TCPAddress())
+	}
+
+	// Entries of the current version that can't be read, such as ones with
+	// buckets that are out of range, are discarded rather than failing startup
+	for _, outOfRangeAddress := range []*knownAddress{
+		{netAddress: corruptAddress, bucket: newBucketCount},
+		{netAddress: corruptAddress, isTried: true, bucket: triedBucketCount},
+	} {
+		err = database.Put(corruptAddressKey, store.addVersionPrefix(store.serializeKnownAddress(outOfRangeAddress)))
+		if err != nil {
+			t.Fatalf("Put: %s", err)
+		}
+		amgr, err = New(NewConfig(cfg), database)
+		if err != nil {
+			t.Fatalf("error creating address manager: %s", err)
+		}
+		if _, ok := amgr.store.get(netAddressKey(corruptAddress)); ok {
+			t.Fatalf("expected %s with an out of range bucket to be discarded", corruptAddress.TCPAddress())
+		}
+		exists, err := database.Has(corruptAddressKey)
+		if err != nil {
+			t.Fatalf("Has: %s", err)
+		}
+		if exists {
+			t.Fatalf("expected %s to be deleted from the database", corruptAddress.TCPAddress())
+		}
+	}
+}
+
+func TestBanExpiry(t *testing.T) {
+	amgr, teardown := newAddrManagerForTest(t, "TestBanExpiry")
+	defer teardown()
+
+	address := appmessage.NewNetAddressIPPort(net.ParseIP("204.124.8.1"), 16111, appmessage.SFNodeNetwork)
+	err := amgr.AddAddresses(address)
+	if err != nil {
+		t.Fatalf("AddAddresses: %s", err)
+	}
+
+	// Ban the address as if it was banned more than BanDuration ago
+	err = amgr.store.addBanned(netAddressKey(address), address, mstime.Now().Add(-amgr.cfg.BanDuration-time.Second))
+	if err != nil {
+		t.Fatalf("addBanned: %s", err)
+	}
+
+	if len(amgr.BannedAddresses()) != 0 {
+		t.Fatalf("expected expired bans not to be returned by BannedAddresses")
+	}
+
+	isBanned, err := amgr.IsBanned(address)
+	if err != nil {
+		t.Fatalf("IsBanned: %s", err)
+	}
+	if isBanned {
+		t.Fatalf("expected the ban of %s to have expired", address.TCPAddress())
+	}
+	if len(amgr.Addresses()) != 1 {
+		t.Fatalf("expected the address to return to the address manager after its ban expired")
+	}
+}
@@ -0,0 +1,200 @@
+package addressmanager
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/kaspanet/kaspad/util/mstime"
+)
+
+const (
+	// newBucketCount is the number of buckets that we spread new addresses
+	// over.
+	newBucketCount = 1024
+
+	// newBucketSize is the maximum number of addresses in each new address
+	// bucket.
+	newBucketSize = 64
+
+	// newBucketsPerSourceGroup is the number of new buckets over which a
+	// source address group will be spread. This limits the amount of
+	// addresses a single peer (or a group of peers) is able to insert.
+	newBucketsPerSourceGroup = 64
+
+	// triedBucketCount is the number of buckets we split tried addresses
+	// over.
+	triedBucketCount = 64
+
+	// triedBucketSize is the maximum number of addresses in each tried
+	// address bucket.
+	triedBucketSize = 256
+
+	// triedBucketsPerGroup is the number of tried buckets over which an
+	// address group will be spread.
+	triedBucketsPerGroup = 8
+)
+
+// addressTables holds the new and tried address tables. New addresses are
+// addresses that we've heard about but never successfully connected to,
+// while tried addresses are addresses we've connected to at least once.
+// Both tables are split into buckets in order to limit the influence a
+// single network group can have over the entire table.
+type addressTables struct {
+	newBuckets   [newBucketCount]map[addressKey]*knownAddress
+	triedBuckets [triedBucketCount]map[addressKey]*knownAddress
+}
+
+func newAddressTables() *addressTables {
+	tables := &addressTables{}
+	for i := range tables.newBuckets {
+		tables.newBuckets[i] = map[addressKey]*knownAddress{}
+	}
+	for i := range tables.triedBuckets {
+		tables.triedBuckets[i] = map[addressKey]*knownAddress{}
+	}
+	return tables
+}
+
+func (at *addressTables) bucketOf(knownAddress *knownAddress) map[addressKey]*knownAddress {
+	if knownAddress.isTried {
+		return at.triedBuckets[knownAddress.bucket]
+	}
+	return at.newBuckets[knownAddress.bucket]
+}
+
+func (at *addressTables) add(key addressKey, knownAddress *knownAddress) {
+	at.bucketOf(knownAddress)[key] = knownAddress
+}
+
+func (at *addressTables) remove(key addressKey, knownAddress *knownAddress) {
+	delete(at.bucketOf(knownAddress), key)
+}
+
+func (at *addressTables) isNewBucketFull(bucket uint32) bool {
+	return len(at.newBuckets[bucket]) >= newBucketSize
+}
+
+func (at *addressTables) isTriedBucketFull(bucket uint32) bool {
+	return len(at.triedBuckets[bucket]) >= triedBucketSize
+}
+
+// newBucketEvictionCandidate returns the address that should be evicted from
+// the given new bucket in order to make room for a new address. Addresses that
+// are bad are evicted first, and otherwise the address that was seen the least
+// recently is evicted.
+func (at *addressTables) newBucketEvictionCandidate(bucket uint32) (addressKey, *knownAddress) {
+	var candidateKey addressKey
+	var candidate *knownAddress
+	for key, knownAddress := range at.newBuckets[bucket] {
+		if knownAddress.isBad() {
+			if candidate == nil || !candidate.isBad() || isKeyLess(key, candidateKey) {
+				candidateKey, candidate = key, knownAddress
+			}
+			continue
+		}
+		if candidate != nil && candidate.isBad() {
+			continue
+		}
+		if candidate == nil || isOlder(knownAddress.netAddress.Timestamp, key,
+			candidate.netAddress.Timestamp, candidateKey) {
+			candidateKey, candidate = key, knownAddress
+		}
+	}
+	return candidateKey, candidate
+}
+
+// triedBucketEvictionCandidate returns the address that should be evicted from
+// the given tried bucket in order to make room for a new address. The address
+// that was successfully connected to the least recently is evicted.
+func (at *addressTables) triedBucketEvictionCandidate(bucket uint32) (addressKey, *knownAddress) {
+	var candidateKey addressKey
+	var candidate *knownAddress
+	for key, knownAddress := range at.triedBuckets[bucket] {
+		if candidate == nil || isOlder(knownAddress.lastSuccess, key, candidate.lastSuccess, candidateKey) {
+			candidateKey, candidate = key, knownAddress
+		}
+	}
+	return candidateKey, candidate
+}
+
+// newBucketIndex returns the new bucket for the given address group as
+// heard about from the given source group.
+// It's computed in the same way as bitcoind:
+// doublesha256(key + sourcegroup + int64(doublesha256(key + group + sourcegroup)) % buckets_per_source_group) % num_new_buckets
+func newBucketIndex(bucketingKey []byte, group string, sourceGroup string) uint32 {
+	data1 := make([]byte, 0, len(bucketingKey)+len(group)+len(sourceGroup))
+	data1 = append(data1, bucketingKey...)
+	data1 = append(data1, []byte(group)...)
+	data1 = append(data1, []byte(sourceGroup)...)
+	hash1 := doubleHash(data1)
+	hash64 := binary.LittleEndian.Uint64(hash1)
+	hash64 %= newBucketsPerSourceGroup
+	var hashBuf [8]byte
+	binary.LittleEndian.PutUint64(hashBuf[:], hash64)
+
+	data2 := make([]byte, 0, len(bucketingKey)+len(sourceGroup)+len(hashBuf))
+	data2 = append(data2, bucketingKey...)
+	data2 = append(data2, []byte(sourceGroup)...)
+	data2 = append(data2, hashBuf[:]...)
+	hash2 := doubleHash(data2)
+
+	return uint32(binary.LittleEndian.Uint64(hash2) % newBucketCount)
+}
+
+// triedBucketIndex returns the tried bucket for the given address key
+// within the given address group.
+// It's computed in the same way as bitcoind:
+// doublesha256(key + group + truncate_to_int64(doublesha256(key + addr)) % buckets_per_group) % num_buckets
+func triedBucketIndex(bucketingKey []byte, group string, key addressKey) uint32 {
+	data1 := make([]byte, 0, len(bucketingKey)+len(key.address)+len(key.onion)+2)
+	data1 = append(data1, bucketingKey...)
+	data1 = append(data1, key.address[:]...)
+	data1 = append(data1, key.onion[:]...)
+	data1 = append(data1, byte(key.port), byte(key.port>>8))
+	hash1 := doubleHash(data1)
+	hash64 := binary.LittleEndian.Uint64(hash1)
+	hash64 %= triedBucketsPerGroup
+	var hashBuf [8]byte
+	binary.LittleEndian.PutUint64(hashBuf[:], hash64)
+
+	data2 := make([]byte, 0, len(bucketingKey)+len(group)+len(hashBuf))
+	data2 = append(data2, bucketingKey...)
+	data2 = append(data2, []byte(group)...)
+	data2 = append(data2, hashBuf[:]...)
+	hash2 := doubleHash(data2)
+
+	return uint32(binary.LittleEndian.Uint64(hash2) % triedBucketCount)
+}
+
+func doubleHash(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// isOlder returns whether the given time is before the other time,
+// breaking ties deterministically using the addresses' keys
+func isOlder(time mstime.Time, key addressKey, otherTime mstime.Time, otherKey addressKey) bool {
+	if time.Before(otherTime) {
+		return true
+	}
+	if otherTime.Before(time) {
+		return false
+	}
+	return isKeyLess(key, otherKey)
+}
+
+// isKeyLess is used to break ties deterministically when choosing
+// between addresses
+func isKeyLess(key addressKey, other addressKey) bool {
+	comparison := bytes.Compare(key.address[:], other.address[:])
+	if comparison != 0 {
+		return comparison < 0
+	}
+	comparison = bytes.Compare(key.onion[:], other.onion[:])
+	if comparison != 0 {
+		return comparison < 0
+	}
+	return key.port < other.port
+}
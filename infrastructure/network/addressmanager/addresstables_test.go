@@ -0,0 +1,293 @@
+package addressmanager
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/util/mstime"
+)
+
+// deterministicRandomizer is an addressRandomizer that always returns
+// the addresses with the lowest keys, so that tests don't depend on
+// randomness nor on map iteration order
+type deterministicRandomizer struct{}
+
+func (deterministicRandomizer) RandomAddress(addresses []*appmessage.NetAddress) *appmessage.NetAddress {
+	result := deterministicRandomizer{}.RandomAddresses(addresses, 1)
+	if len(result) == 0 {
+		return nil
+	}
+	return result[0]
+}
+
+func (deterministicRandomizer) RandomAddresses(addresses []*appmessage.NetAddress, count int) []*appmessage.NetAddress {
+	sorted := make([]*appmessage.NetAddress, len(addresses))
+	copy(sorted, addresses)
+	sort.Slice(sorted, func(i, j int) bool {
+		return isKeyLess(netAddressKey(sorted[i]), netAddressKey(sorted[j]))
+	})
+	if count > len(sorted) {
+		count = len(sorted)
+	}
+	return sorted[:count]
+}
+
+func newDeterministicAddrManagerForTest(t *testing.T, testName string) (addressManager *AddressManager, teardown func()) {
+	addressManager, teardown = newAddrManagerForTest(t, testName)
+	addressManager.random = deterministicRandomizer{}
+	return addressManager, teardown
+}
+
+func TestNewBucketEviction(t *testing.T) {
+	amgr, teardown := newDeterministicAddrManagerForTest(t, "TestNewBucketEviction")
+	defer teardown()
+
+	// All addresses share the same group and source group, and
+	// therefore all of them fall into the same new bucket
+	sourceAddress := appmessage.NewNetAddressIPPort(net.ParseIP("173.1.2.3"), 16111, appmessage.SFNodeNetwork)
+	oldest := mstime.Now().Add(-time.Hour)
+	addresses := make([]*appmessage.NetAddress, newBucketSize+1)
+	for i := range addresses {
+		ip := net.ParseIP(fmt.Sprintf("12.1.%d.%d", i/256, i%256+1))
+		addresses[i] = appmessage.NewNetAddressTimestamp(oldest.Add(time.Duration(i)*time.Second),
+			appmessage.SFNodeNetwork, ip, 16111)
+	}
+
+	err := amgr.AddAddressesFromSource(sourceAddress, addresses...)
+	if err != nil {
+		t.Fatalf("AddAddressesFromSource: %s", err)
+	}
+
+	knownAddresses := amgr.Addresses()
+	if len(knownAddresses) != newBucketSize {
+		t.Fatalf("expected %d addresses after eviction, got %d", newBucketSize, len(knownAddresses))
+	}
+	for _, knownAddress := range knownAddresses {
+		if knownAddress.IP.Equal(addresses[0].IP) {
+			t.Fatalf("expected the oldest address %s to have been evicted", addresses[0].IP)
+		}
+	}
+}
+
+func TestNewBucketEvictionPrefersBadAddresses(t *testing.T) {
+	amgr, teardown := newDeterministicAddrManagerForTest(t, "TestNewBucketEvictionPrefersBadAddresses")
+	defer teardown()
+
+	sourceAddress := appmessage.NewNetAddressIPPort(net.ParseIP("173.1.2.3"), 16111, appmessage.SFNodeNetwork)
+	addresses := make([]*appmessage.NetAddress, newBucketSize)
+	for i := range addresses {
+		ip := net.ParseIP(fmt.Sprintf("12.1.0.%d", i+1))
+		addresses[i] = appmessage.NewNetAddressIPPort(ip, 16111, appmessage.SFNodeNetwork)
+	}
+	err := amgr.AddAddressesFromSource(sourceAddress, addresses...)
+	if err != nil {
+		t.Fatalf("AddAddressesFromSource: %s", err)
+	}
+
+	// Make the newest address bad
+	badAddress := addresses[len(addresses)-1]
+	knownBadAddress, _ := amgr.store.get(netAddressKey(badAddress))
+	knownBadAddress.attempts = numRetries
+	knownBadAddress.lastAttempt = mstime.Now().Add(-time.Hour)
+
+	err = amgr.AddAddressesFromSource(sourceAddress,
+		appmessage.NewNetAddressIPPort(net.ParseIP("12.1.1.1"), 16111, appmessage.SFNodeNetwork))
+	if err != nil {
+		t.Fatalf("AddAddressesFromSource: %s", err)
+	}
+
+	if _, ok := amgr.store.get(netAddressKey(badAddress)); ok {
+		t.Fatalf("expected the bad address %s to have been evicted", badAddress.IP)
+	}
+	if len(amgr.Addresses()) != newBucketSize {
+		t.Fatalf("expected %d addresses after eviction, got %d", newBucketSize, len(amgr.Addresses()))
+	}
+}
+
+func TestSingleSourceIsBounded(t *testing.T) {
+	amgr, teardown := newDeterministicAddrManagerForTest(t, "TestSingleSourceIsBounded")
+	defer teardown()
+
+	// Flood the address manager from a single source with addresses
+	// from many different groups
+	sourceAddress := appmessage.NewNetAddressIPPort(net.ParseIP("173.1.2.3"), 16111, appmessage.SFNodeNetwork)
+	const floodSize = newBucketsPerSourceGroup*newBucketSize + 1000
+	addresses := make([]*appmessage.NetAddress, floodSize)
+	for i := range addresses {
+		ip := net.ParseIP(fmt.Sprintf("%d.%d.1.1", 20+i/256, i%256))
+		addresses[i] = appmessage.NewNetAddressIPPort(ip, 16111, appmessage.SFNodeNetwork)
+	}
+	err := amgr.AddAddressesFromSource(sourceAddress, addresses...)
+	if err != nil {
+		t.Fatalf("AddAddressesFromSource: %s", err)
+	}
+
+	usedBuckets := make(map[uint32]struct{})
+	for _, knownAddress := range amgr.store.getAllNotBanned() {
+		usedBuckets[knownAddress.bucket] = struct{}{}
+	}
+	if len(usedBuckets) > newBucketsPerSourceGroup {
+		t.Fatalf("expected a single source to use at most %d new buckets, but it used %d",
+			newBucketsPerSourceGroup, len(usedBuckets))
+	}
+	if len(amgr.Addresses()) > newBucketsPerSourceGroup*newBucketSize {
+		t.Fatalf("expected a single source to add at most %d addresses, but it added %d",
+			newBucketsPerSourceGroup*newBucketSize, len(amgr.Addresses()))
+	}
+}
+
+func TestGoodMovesAddressToTried(t *testing.T) {
+	amgr, teardown := newDeterministicAddrManagerForTest(t, "TestGoodMovesAddressToTried")
+	defer teardown()
+
+	addresses := make([]*appmessage.NetAddress, 4)
+	for i := range addresses {
+		ip := net.ParseIP(fmt.Sprintf("%d.1.1.1", 20+i))
+		addresses[i] = appmessage.NewNetAddressIPPort(ip, 16111, appmessage.SFNodeNetwork)
+	}
+	err := amgr.AddAddresses(addresses...)
+	if err != nil {
+		t.Fatalf("AddAddresses: %s", err)
+	}
+
+	// The deterministic randomizer prefers low keys, so we
+	// mark the address with the highest key as good
+	goodAddress := addresses[len(addresses)-1]
+	err = amgr.Attempt(goodAddress)
+	if err != nil {
+		t.Fatalf("Attempt: %s", err)
+	}
+	err = amgr.Good(goodAddress)
+	if err != nil {
+		t.Fatalf("Good: %s", err)
+	}
+
+	knownGoodAddress, ok := amgr.store.get(netAddressKey(goodAddress))
+	if !ok {
+		t.Fatalf("good address %s is missing from the address manager", goodAddress.IP)
+	}
+	if !knownGoodAddress.isTried {
+		t.Fatalf("expected good address %s to be in the tried table", goodAddress.IP)
+	}
+	if knownGoodAddress.attempts != 0 || knownGoodAddress.lastSuccess.IsZero() {
+		t.Fatalf("expected good address %s to have its attempts reset and its last success set",
+			goodAddress.IP)
+	}
+	if _, ok := amgr.tables.triedBuckets[knownGoodAddress.bucket][netAddressKey(goodAddress)]; !ok {
+		t.Fatalf("expected good address %s to be in tried bucket %d", goodAddress.IP, knownGoodAddress.bucket)
+	}
+
+	randomAddress := amgr.RandomAddress(nil)
+	if !randomAddress.IP.Equal(goodAddress.IP) {
+		t.Fatalf("expected RandomAddress to prefer the tried address %s, got %s", goodAddress.IP, randomAddress.IP)
+	}
+
+	randomAddresses := amgr.RandomAddresses(2, nil)
+	if len(randomAddresses) != 2 {
+		t.Fatalf("expected 2 addresses, got %d", len(randomAddresses))
+	}
+	if !randomAddresses[0].IP.Equal(goodAddress.IP) || !randomAddresses[1].IP.Equal(addresses[0].IP) {
+		t.Fatalf("expected RandomAddresses to return the tried address %s and the new address %s, got %s and %s",
+			goodAddress.IP, addresses[0].IP, randomAddresses[0].IP, randomAddresses[1].IP)
+	}
+
+	// When there aren't enough tried addresses the rest are taken from the new table
+	randomAddresses = amgr.RandomAddresses(len(addresses)+1, nil)
+	if len(randomAddresses) != len(addresses) {
+		t.Fatalf("expected %d addresses, got %d", len(addresses), len(randomAddresses))
+	}
+}
+
+func TestTriedBucketEviction(t *testing.T) {
+	amgr, teardown := newDeterministicAddrManagerForTest(t, "TestTriedBucketEviction")
+	defer teardown()
+
+	// All addresses share the same group and therefore are spread
+	// over at most triedBucketsPerGroup tried buckets
+	const addressCount = triedBucketsPerGroup*triedBucketSize + 1
+	var lastAddress *appmessage.NetAddress
+	for i := 0; i < addressCount; i++ {
+		ip := net.ParseIP(fmt.Sprintf("12.1.%d.%d", i/256, i%256))
+		address := appmessage.NewNetAddressIPPort(ip, 16111, appmessage.SFNodeNetwork)
+		err := amgr.AddAddress(address)
+		if err != nil {
+			t.Fatalf("AddAddress: %s", err)
+		}
+		err = amgr.Good(address)
+		if err != nil {
+			t.Fatalf("Good: %s", err)
+		}
+		lastAddress = address
+	}
+
+	triedCount := 0
+	for bucket, triedBucket := range amgr.tables.triedBuckets {
+		if len(triedBucket) > triedBucketSize {
+			t.Fatalf("tried bucket %d has %d addresses, which is more than the maximum %d",
+				bucket, len(triedBucket), triedBucketSize)
+		}
+		triedCount += len(triedBucket)
+	}
+	if triedCount >= addressCount {
+		t.Fatalf("expected some tried addresses to be evicted, but %d out of %d are tried",
+			triedCount, addressCount)
+	}
+
+	knownLastAddress, ok := amgr.store.get(netAddressKey(lastAddress))
+	if !ok || !knownLastAddress.isTried {
+		t.Fatalf("expected the last good address %s to be tried", lastAddress.IP)
+	}
+}
+
+func TestBadAddressesAreNotSelected(t *testing.T) {
+	amgr, teardown := newDeterministicAddrManagerForTest(t, "TestBadAddressesAreNotSelected")
+	defer teardown()
+
+	address := appmessage.NewNetAddressIPPort(net.ParseIP("20.1.1.1"), 16111, appmessage.SFNodeNetwork)
+	err := amgr.AddAddresses(address)
+	if err != nil {
+		t.Fatalf("AddAddresses: %s", err)
+	}
+
+	for i := 0; i < numRetries; i++ {
+		err := amgr.Attempt(address)
+		if err != nil {
+			t.Fatalf("Attempt: %s", err)
+		}
+	}
+
+	// An address that was attempted in the last minute isn't considered bad
+	if len(amgr.RandomAddresses(1, nil)) != 1 {
+		t.Fatalf("expected a recently attempted address to be selectable")
+	}
+
+	knownAddress, _ := amgr.store.get(netAddressKey(address))
+	knownAddress.lastAttempt = mstime.Now().Add(-time.Hour)
+	if len(amgr.RandomAddresses(1, nil)) != 0 {
+		t.Fatalf("expected an address that failed %d times to not be selectable", numRetries)
+	}
+}
+
+func TestOnionAddressesAreSelectedOnlyIfReachable(t *testing.T) {
+	amgr, teardown := newDeterministicAddrManagerForTest(t, "TestOnionAddressesAreSelectedOnlyIfReachable")
+	defer teardown()
+
+	address := appmessage.NewNetAddressOnion(make([]byte, 32), 16111, appmessage.SFNodeNetwork)
+	err := amgr.AddAddresses(address)
+	if err != nil {
+		t.Fatalf("AddAddresses: %s", err)
+	}
+
+	if len(amgr.RandomAddresses(1, nil)) != 0 {
+		t.Fatalf("expected an onion address to not be selectable without a proxy")
+	}
+	amgr.cfg.OnionReachable = true
+	addresses := amgr.RandomAddresses(1, nil)
+	if len(addresses) != 1 || !addresses[0].IsOnion() {
+		t.Fatalf("expected the onion address to be selectable through a proxy, got %v", addresses)
+	}
+}
@@ -2,6 +2,7 @@ package addressmanager
 
 import (
 	"net"
+	"time"
 
 	"github.com/kaspanet/kaspad/infrastructure/config"
 )
@@ -9,19 +10,26 @@ import (
 // Config is a descriptor which specifies the AddressManager instance configuration.
 type Config struct {
 	AcceptUnroutable bool
+	BanDuration      time.Duration
 	DefaultPort      string
 	ExternalIPs      []string
 	Listeners        []string
 	Lookup           func(string) ([]net.IP, error)
+
+	// OnionReachable is whether outgoing connections to onion
+	// services are possible, which requires a Tor proxy
+	OnionReachable bool
 }
 
 // NewConfig returns a new address manager Config.
 func NewConfig(cfg *config.Config) *Config {
 	return &Config{
 		AcceptUnroutable: cfg.NetParams().AcceptUnroutable,
+		BanDuration:      cfg.BanDuration,
 		DefaultPort:      cfg.NetParams().DefaultPort,
 		ExternalIPs:      cfg.ExternalIPs,
 		Listeners:        cfg.Listeners,
 		Lookup:           cfg.Lookup,
+		OnionReachable:   cfg.Proxy != "",
 	}
 }
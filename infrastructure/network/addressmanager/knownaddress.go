@@ -0,0 +1,74 @@
+package addressmanager
+
+import (
+	"time"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/util/mstime"
+)
+
+const (
+	// numMissingDays is the number of days before which we assume an
+	// address has vanished if we have not seen it announced in that long.
+	numMissingDays = 30
+
+	// numRetries is the number of tries without a single success before
+	// we assume an address is bad.
+	numRetries = 3
+
+	// maxFailures is the maximum number of failures we will accept without
+	// a success before considering an address bad.
+	maxFailures = 10
+
+	// minBadDays is the number of days since the last success before we
+	// will consider evicting an address.
+	minBadDays = 7
+)
+
+// knownAddress tracks information about a known network address that is used
+// to determine how viable an address is.
+type knownAddress struct {
+	netAddress  *appmessage.NetAddress
+	attempts    uint32
+	lastAttempt mstime.Time
+	lastSuccess mstime.Time
+	isTried     bool
+	bucket      uint32
+}
+
+// isBad returns true if the address in question has not been tried in the last
+// minute and meets one of the following criteria:
+// 1) It claims to be from the future
+// 2) It hasn't been seen in over a month
+// 3) It has failed at least three times and never succeeded
+// 4) It has failed ten times in the last week
+// All addresses that meet these criteria are assumed to be worthless and not
+// worth keeping hold of.
+func (ka *knownAddress) isBad() bool {
+	if !ka.lastAttempt.IsZero() && mstime.Since(ka.lastAttempt) < time.Minute {
+		return false
+	}
+
+	// From the future?
+	if ka.netAddress.Timestamp.After(mstime.Now().Add(10 * time.Minute)) {
+		return true
+	}
+
+	// Over a month old?
+	if mstime.Since(ka.netAddress.Timestamp) > numMissingDays*24*time.Hour {
+		return true
+	}
+
+	// Never succeeded?
+	if ka.lastSuccess.IsZero() && ka.attempts >= numRetries {
+		return true
+	}
+
+	// Hasn't succeeded in too long?
+	if mstime.Since(ka.lastSuccess) > minBadDays*24*time.Hour &&
+		ka.attempts >= maxFailures {
+		return true
+	}
+
+	return false
+}
@@ -5,6 +5,8 @@
 package addressmanager
 
 import (
+	"crypto/ed25519"
+	"fmt"
 	"net"
 
 	"github.com/kaspanet/kaspad/app/appmessage"
@@ -197,7 +199,12 @@ func IsRFC6598(na *appmessage.NetAddress) bool {
 // considered invalid under the following circumstances:
 // IPv4: It is either a zero or all bits set address.
 // IPv6: It is either a zero or RFC3849 documentation address.
+// Onion service: Its public key isn't an ed25519 public key.
 func IsValid(na *appmessage.NetAddress) bool {
+	if na.IsOnion() {
+		return len(na.OnionPublicKey) == ed25519.PublicKeySize
+	}
+
 	// IsUnspecified returns if address is 0, so only all bits set, and
 	// RFC3849 need to be explicitly checked.
 	return na.IP != nil && !(na.IP.IsUnspecified() ||
@@ -206,8 +213,12 @@ func IsValid(na *appmessage.NetAddress) bool {
 
 // IsRoutable returns whether or not the passed address is routable over
 // the public internet. This is true as long as the address is valid and is not
-// in any reserved ranges.
+// in any reserved ranges. Valid onion services are always routable over Tor.
 func IsRoutable(na *appmessage.NetAddress, acceptUnroutable bool) bool {
+	if na.IsOnion() {
+		return IsValid(na)
+	}
+
 	if acceptUnroutable {
 		return !IsLocal(na)
 	}
@@ -219,9 +230,9 @@ func IsRoutable(na *appmessage.NetAddress, acceptUnroutable bool) bool {
 }
 
 // GroupKey returns a string representing the network group an address is part
-// of. This is the /16 for IPv4, the /32 (/36 for he.net) for IPv6, the string
-// "local" for a local address, and the string "unroutable" for an unroutable
-// address.
+// of. This is the /16 for IPv4, the /32 (/36 for he.net) for IPv6, the first
+// 4 bits of the public key for onion services, the string "local" for a local
+// address, and the string "unroutable" for an unroutable address.
 func (am *AddressManager) GroupKey(na *appmessage.NetAddress) string {
 	if IsLocal(na) {
 		return "local"
@@ -229,6 +240,9 @@ func (am *AddressManager) GroupKey(na *appmessage.NetAddress) string {
 	if !IsRoutable(na, am.cfg.AcceptUnroutable) {
 		return "unroutable"
 	}
+	if na.IsOnion() {
+		return fmt.Sprintf("onion:%d", na.OnionPublicKey[0]>>4)
+	}
 	if IsIPv4(na) {
 		return na.IP.Mask(net.CIDRMask(16, 32)).String()
 	}
@@ -204,3 +204,18 @@ func TestGroupKey(t *testing.T) {
 		}
 	}
 }
+
+func TestOnionGroupKey(t *testing.T) {
+	amgr, teardown := newAddrManagerForTest(t, "TestOnionGroupKey")
+	defer teardown()
+
+	onionPublicKey := make([]byte, 32)
+	onionPublicKey[0] = 0xa7
+	address := appmessage.NewNetAddressOnion(onionPublicKey, 16111, appmessage.SFNodeNetwork)
+	if !IsRoutable(address, false) {
+		t.Fatalf("expected %s to be routable", address)
+	}
+	if key := amgr.GroupKey(address); key != "onion:10" {
+		t.Fatalf("unexpected group key - got '%s', want 'onion:10'", key)
+	}
+}
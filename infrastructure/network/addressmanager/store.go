@@ -0,0 +1,500 @@
+package addressmanager
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/infrastructure/db/database"
+	"github.com/kaspanet/kaspad/util/mstime"
+	"github.com/pkg/errors"
+)
+
+var notBannedAddressBucket = database.MakeBucket([]byte("not-banned-addresses"))
+var bannedAddressBucket = database.MakeBucket([]byte("banned-addresses"))
+var bucketingKeyKey = database.MakeBucket(nil).Key([]byte("address-manager-bucketing-key"))
+
+const bucketingKeySize = 32
+
+// storeVersion is the version of the serialization format of the entries in
+// the address store. Every serialized entry is prefixed with the version it
+// was serialized with, so that entries of other versions are never misread.
+const storeVersion = 1
+
+// bannedAddress is a banned address alongside the time in which it was banned
+type bannedAddress struct {
+	netAddress *appmessage.NetAddress
+	bannedAt   mstime.Time
+}
+
+// addressStore is an in-memory view of the address book which
+// writes through every change to the database
+type addressStore struct {
+	database           database.Database
+	notBannedAddresses map[addressKey]*knownAddress
+	bannedAddresses    map[hostKey]*bannedAddress
+	bucketingKey       []byte
+}
+
+func newAddressStore(database database.Database) (*addressStore, error) {
+	addressStore := &addressStore{
+		database:           database,
+		notBannedAddresses: map[addressKey]*knownAddress{},
+		bannedAddresses:    map[hostKey]*bannedAddress{},
+	}
+	err := addressStore.restoreBucketingKey()
+	if err != nil {
+		return nil, err
+	}
+	err = addressStore.restoreNotBannedAddresses()
+	if err != nil {
+		return nil, err
+	}
+	err = addressStore.restoreBannedAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Loaded %d addresses and %d banned addresses",
+		len(addressStore.notBannedAddresses), len(addressStore.bannedAddresses))
+
+	return addressStore, nil
+}
+
+// restoreBucketingKey loads the secret key used to place addresses in
+// buckets, or generates and persists a new one if none exists
+func (as *addressStore) restoreBucketingKey() error {
+	bucketingKey, err := as.database.Get(bucketingKeyKey)
+	if err == nil {
+		as.bucketingKey = bucketingKey
+		return nil
+	}
+	if !database.IsNotFoundError(err) {
+		return err
+	}
+
+	bucketingKey = make([]byte, bucketingKeySize)
+	_, err = rand.Read(bucketingKey)
+	if err != nil {
+		return err
+	}
+	as.bucketingKey = bucketingKey
+	return as.database.Put(bucketingKeyKey, bucketingKey)
+}
+
+type bucketEntry struct {
+	key   *database.Key
+	value []byte
+}
+
+// bucketEntries reads copies of all the entries in the given bucket, so that
+// the bucket may be modified while they're being handled
+func (as *addressStore) bucketEntries(bucket *database.Bucket) ([]bucketEntry, error) {
+	cursor, err := as.database.Cursor(bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	var entries []bucketEntry
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		key, err := cursor.Key()
+		if err != nil {
+			return nil, err
+		}
+		value, err := cursor.Value()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, bucketEntry{
+			key:   bucket.Key(append([]byte{}, key.Suffix()...)),
+			value: append([]byte{}, value...),
+		})
+	}
+	return entries, nil
+}
+
+// discardEntries deletes the given entries, which can't be restored: the
+// entries of other versions were serialized with a version other than the
+// current one, for example by a newer version of kaspad, and the invalid
+// entries could not be deserialized
+func (as *addressStore) discardEntries(bucketName string, entriesOfOtherVersions []bucketEntry,
+	invalidEntries []bucketEntry) error {
+
+	if len(entriesOfOtherVersions) > 0 {
+		log.Warnf("Discarding %d %s that were serialized with a version other than %d",
+			len(entriesOfOtherVersions), bucketName, storeVersion)
+	}
+	if len(invalidEntries) > 0 {
+		log.Warnf("Discarding %d %s that could not be deserialized", len(invalidEntries), bucketName)
+	}
+	for _, entry := range append(entriesOfOtherVersions, invalidEntries...) {
+		err := as.database.Delete(entry.key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (as *addressStore) restoreNotBannedAddresses() error {
+	entries, err := as.bucketEntries(notBannedAddressBucket)
+	if err != nil {
+		return err
+	}
+	var entriesOfOtherVersions, invalidEntries []bucketEntry
+	for _, entry := range entries {
+		key, err := as.deserializeAddressKey(entry.key.Suffix())
+		if err != nil {
+			log.Debugf("Could not deserialize the key of an address: %s", err)
+			invalidEntries = append(invalidEntries, entry)
+			continue
+		}
+
+		serializedKnownAddress, ok := as.removeVersionPrefix(entry.value)
+		if !ok {
+			entriesOfOtherVersions = append(entriesOfOtherVersions, entry)
+			continue
+		}
+		knownAddress, err := as.deserializeKnownAddress(serializedKnownAddress)
+		if err != nil {
+			log.Debugf("Could not deserialize an address: %s", err)
+			invalidEntries = append(invalidEntries, entry)
+			continue
+		}
+		as.notBannedAddresses[key] = knownAddress
+	}
+	return as.discardEntries("addresses", entriesOfOtherVersions, invalidEntries)
+}
+
+func (as *addressStore) restoreBannedAddresses() error {
+	entries, err := as.bucketEntries(bannedAddressBucket)
+	if err != nil {
+		return err
+	}
+	var entriesOfOtherVersions, invalidEntries []bucketEntry
+	for _, entry := range entries {
+		hostKey, err := as.deserializeHostKey(entry.key.Suffix())
+		if err != nil {
+			log.Debugf("Could not deserialize the key of a banned address: %s", err)
+			invalidEntries = append(invalidEntries, entry)
+			continue
+		}
+
+		serializedBannedAddress, ok := as.removeVersionPrefix(entry.value)
+		if !ok {
+			entriesOfOtherVersions = append(entriesOfOtherVersions, entry)
+			continue
+		}
+		bannedAddress, err := as.deserializeBannedAddress(serializedBannedAddress)
+		if err != nil {
+			log.Debugf("Could not deserialize a banned address: %s", err)
+			invalidEntries = append(invalidEntries, entry)
+			continue
+		}
+		as.bannedAddresses[hostKey] = bannedAddress
+	}
+	return as.discardEntries("banned addresses", entriesOfOtherVersions, invalidEntries)
+}
+
+// set adds the given knownAddress to the store, or overwrites
+// it if it already exists
+func (as *addressStore) set(key addressKey, knownAddress *knownAddress) error {
+	as.notBannedAddresses[key] = knownAddress
+
+	databaseKey := as.notBannedDatabaseKey(key)
+	serializedKnownAddress := as.serializeKnownAddress(knownAddress)
+	return as.database.Put(databaseKey, as.addVersionPrefix(serializedKnownAddress))
+}
+
+func (as *addressStore) remove(key addressKey) error {
+	delete(as.notBannedAddresses, key)
+
+	databaseKey := as.notBannedDatabaseKey(key)
+	return as.database.Delete(databaseKey)
+}
+
+func (as *addressStore) get(key addressKey) (*knownAddress, bool) {
+	knownAddress, ok := as.notBannedAddresses[key]
+	return knownAddress, ok
+}
+
+func (as *addressStore) getAllNotBanned() []*knownAddress {
+	knownAddresses := make([]*knownAddress, 0, len(as.notBannedAddresses))
+	for _, knownAddress := range as.notBannedAddresses {
+		knownAddresses = append(knownAddresses, knownAddress)
+	}
+	return knownAddresses
+}
+
+func (as *addressStore) addBanned(key addressKey, netAddress *appmessage.NetAddress, bannedAt mstime.Time) error {
+	bannedAddress := &bannedAddress{
+		netAddress: netAddress,
+		bannedAt:   bannedAt,
+	}
+	as.bannedAddresses[key.hostKey] = bannedAddress
+
+	databaseKey := as.bannedDatabaseKey(key)
+	serializedBannedAddress := as.serializeBannedAddress(bannedAddress)
+	return as.database.Put(databaseKey, as.addVersionPrefix(serializedBannedAddress))
+}
+
+func (as *addressStore) removeBanned(key addressKey) error {
+	delete(as.bannedAddresses, key.hostKey)
+
+	databaseKey := as.bannedDatabaseKey(key)
+	return as.database.Delete(databaseKey)
+}
+
+func (as *addressStore) getAllBanned() []*bannedAddress {
+	bannedAddresses := make([]*bannedAddress, 0, len(as.bannedAddresses))
+	for _, bannedAddress := range as.bannedAddresses {
+		bannedAddresses = append(bannedAddresses, bannedAddress)
+	}
+	return bannedAddresses
+}
+
+func (as *addressStore) getBanned(key addressKey) (*bannedAddress, bool) {
+	bannedAddress, ok := as.bannedAddresses[key.hostKey]
+	return bannedAddress, ok
+}
+
+func (as *addressStore) notBannedDatabaseKey(key addressKey) *database.Key {
+	serializedKey := as.serializeAddressKey(key)
+	return notBannedAddressBucket.Key(serializedKey)
+}
+
+func (as *addressStore) bannedDatabaseKey(key addressKey) *database.Key {
+	return bannedAddressBucket.Key(as.serializeHostKey(key.hostKey))
+}
+
+func (as *addressStore) addVersionPrefix(serializedEntry []byte) []byte {
+	return append([]byte{storeVersion}, serializedEntry...)
+}
+
+// removeVersionPrefix returns the given serialized entry without its version
+// prefix, and false if the entry wasn't serialized with the current version
+func (as *addressStore) removeVersionPrefix(versionedEntry []byte) ([]byte, bool) {
+	if len(versionedEntry) == 0 || versionedEntry[0] != storeVersion {
+		return nil, false
+	}
+	return versionedEntry[1:], true
+}
+
+// serializeHostKey serializes IPs as their 16 bytes and onion services
+// as their 32 bytes public keys, so the two can be told apart by length
+func (as *addressStore) serializeHostKey(key hostKey) []byte {
+	if key.onion != (onionPublicKey{}) {
+		return append([]byte{}, key.onion[:]...)
+	}
+	return append([]byte{}, key.address[:]...)
+}
+
+func (as *addressStore) deserializeHostKey(serializedKey []byte) (hostKey, error) {
+	var key hostKey
+	switch len(serializedKey) {
+	case len(key.address):
+		copy(key.address[:], serializedKey)
+	case len(key.onion):
+		copy(key.onion[:], serializedKey)
+	default:
+		return hostKey{}, errors.Errorf("unexpected serialized host key length %d", len(serializedKey))
+	}
+	return key, nil
+}
+
+func (as *addressStore) serializeAddressKey(key addressKey) []byte {
+	serializedHostKey := as.serializeHostKey(key.hostKey)
+	serializedKey := make([]byte, len(serializedHostKey)+2) // host + port
+
+	copy(serializedKey[:], serializedHostKey)
+	binary.LittleEndian.PutUint16(serializedKey[len(serializedHostKey):], key.port)
+
+	return serializedKey
+}
+
+func (as *addressStore) deserializeAddressKey(serializedKey []byte) (addressKey, error) {
+	if len(serializedKey) < 2 {
+		return addressKey{}, errors.Errorf("unexpected serialized address key length %d", len(serializedKey))
+	}
+	hostKeyLength := len(serializedKey) - 2
+
+	hostKey, err := as.deserializeHostKey(serializedKey[:hostKeyLength])
+	if err != nil {
+		return addressKey{}, err
+	}
+
+	port := binary.LittleEndian.Uint16(serializedKey[hostKeyLength:])
+
+	return addressKey{
+		port:    port,
+		hostKey: hostKey,
+	}, nil
+}
+
+const (
+	// serializedNetAddressSize is the size of a serialized IP net address:
+	// ipv6 + port + timestamp + services
+	serializedNetAddressSize = 16 + 2 + 8 + 8
+
+	// serializedOnionNetAddressSize is the size of a serialized onion
+	// service net address: public key + port + timestamp + services
+	serializedOnionNetAddressSize = ed25519.PublicKeySize + 2 + 8 + 8
+)
+
+func (as *addressStore) serializeNetAddress(netAddress *appmessage.NetAddress) []byte {
+	var host []byte
+	if netAddress.IsOnion() {
+		host = netAddress.OnionPublicKey
+	} else {
+		host = netAddress.IP.To16()
+	}
+	serializedNetAddress := make([]byte, len(host)+2+8+8)
+
+	copy(serializedNetAddress[:], host)
+	offset := len(host)
+	binary.LittleEndian.PutUint16(serializedNetAddress[offset:], netAddress.Port)
+	offset += 2
+	as.serializeTime(serializedNetAddress[offset:], netAddress.Timestamp)
+	offset += 8
+	binary.LittleEndian.PutUint64(serializedNetAddress[offset:], uint64(netAddress.Services))
+
+	return serializedNetAddress
+}
+
+func (as *addressStore) deserializeNetAddress(serializedNetAddress []byte) (*appmessage.NetAddress, error) {
+	netAddress := &appmessage.NetAddress{}
+	var offset int
+	switch len(serializedNetAddress) {
+	case serializedNetAddressSize:
+		netAddress.IP = make(net.IP, 16)
+		copy(netAddress.IP, serializedNetAddress[:])
+		offset = 16
+	case serializedOnionNetAddressSize:
+		netAddress.OnionPublicKey = make([]byte, ed25519.PublicKeySize)
+		copy(netAddress.OnionPublicKey, serializedNetAddress[:])
+		offset = ed25519.PublicKeySize
+	default:
+		return nil, errors.Errorf("unexpected serialized net address length %d", len(serializedNetAddress))
+	}
+
+	netAddress.Port = binary.LittleEndian.Uint16(serializedNetAddress[offset:])
+	offset += 2
+	netAddress.Timestamp = as.deserializeTime(serializedNetAddress[offset:])
+	offset += 8
+	netAddress.Services = appmessage.ServiceFlag(binary.LittleEndian.Uint64(serializedNetAddress[offset:]))
+
+	return netAddress, nil
+}
+
+// knownAddressFieldsSize is the size of the fields of a serialized known
+// address that follow its net address:
+// attempts + lastAttempt + lastSuccess + isTried + bucket
+const knownAddressFieldsSize = 4 + 8 + 8 + 1 + 4
+
+func (as *addressStore) serializeKnownAddress(knownAddress *knownAddress) []byte {
+	serializedNetAddress := as.serializeNetAddress(knownAddress.netAddress)
+	serializedKnownAddress := make([]byte, len(serializedNetAddress)+knownAddressFieldsSize)
+
+	copy(serializedKnownAddress[:], serializedNetAddress)
+	offset := len(serializedNetAddress)
+	binary.LittleEndian.PutUint32(serializedKnownAddress[offset:], knownAddress.attempts)
+	offset += 4
+	as.serializeTime(serializedKnownAddress[offset:], knownAddress.lastAttempt)
+	offset += 8
+	as.serializeTime(serializedKnownAddress[offset:], knownAddress.lastSuccess)
+	offset += 8
+	if knownAddress.isTried {
+		serializedKnownAddress[offset] = 1
+	}
+	offset++
+	binary.LittleEndian.PutUint32(serializedKnownAddress[offset:], knownAddress.bucket)
+
+	return serializedKnownAddress
+}
+
+func (as *addressStore) deserializeKnownAddress(serializedKnownAddress []byte) (*knownAddress, error) {
+	if len(serializedKnownAddress) < knownAddressFieldsSize {
+		return nil, errors.Errorf("unexpected serialized known address length %d", len(serializedKnownAddress))
+	}
+	serializedNetAddressLength := len(serializedKnownAddress) - knownAddressFieldsSize
+
+	netAddress, err := as.deserializeNetAddress(serializedKnownAddress[:serializedNetAddressLength])
+	if err != nil {
+		return nil, err
+	}
+	offset := serializedNetAddressLength
+	attempts := binary.LittleEndian.Uint32(serializedKnownAddress[offset:])
+	offset += 4
+	lastAttempt := as.deserializeTime(serializedKnownAddress[offset:])
+	offset += 8
+	lastSuccess := as.deserializeTime(serializedKnownAddress[offset:])
+	offset += 8
+	isTried := serializedKnownAddress[offset] != 0
+	offset++
+	bucket := binary.LittleEndian.Uint32(serializedKnownAddress[offset:])
+	if isTried && bucket >= triedBucketCount {
+		return nil, errors.Errorf("tried bucket %d is out of range", bucket)
+	}
+	if !isTried && bucket >= newBucketCount {
+		return nil, errors.Errorf("new bucket %d is out of range", bucket)
+	}
+
+	return &knownAddress{
+		netAddress:  netAddress,
+		attempts:    attempts,
+		lastAttempt: lastAttempt,
+		lastSuccess: lastSuccess,
+		isTried:     isTried,
+		bucket:      bucket,
+	}, nil
+}
+
+// serializeTime serializes the given time as unix milliseconds. Zero times
+// are serialized as 0 since their unix milliseconds representation overflows
+func (as *addressStore) serializeTime(serializedTime []byte, time mstime.Time) {
+	unixMilliseconds := int64(0)
+	if !time.IsZero() {
+		unixMilliseconds = time.UnixMilliseconds()
+	}
+	binary.LittleEndian.PutUint64(serializedTime, uint64(unixMilliseconds))
+}
+
+func (as *addressStore) deserializeTime(serializedTime []byte) mstime.Time {
+	unixMilliseconds := int64(binary.LittleEndian.Uint64(serializedTime))
+	if unixMilliseconds == 0 {
+		return mstime.Time{}
+	}
+	return mstime.UnixMilliseconds(unixMilliseconds)
+}
+
+func (as *addressStore) serializeBannedAddress(bannedAddress *bannedAddress) []byte {
+	serializedNetAddress := as.serializeNetAddress(bannedAddress.netAddress)
+
+	serializedBannedAddress := make([]byte, len(serializedNetAddress)+8) // +8 for bannedAt
+	copy(serializedBannedAddress[:], serializedNetAddress)
+	binary.LittleEndian.PutUint64(serializedBannedAddress[len(serializedNetAddress):],
+		uint64(bannedAddress.bannedAt.UnixMilliseconds()))
+
+	return serializedBannedAddress
+}
+
+func (as *addressStore) deserializeBannedAddress(serializedBannedAddress []byte) (*bannedAddress, error) {
+	if len(serializedBannedAddress) < 8 {
+		return nil, errors.Errorf("unexpected serialized banned address length %d", len(serializedBannedAddress))
+	}
+	serializedNetAddressLength := len(serializedBannedAddress) - 8 // -8 for bannedAt
+
+	netAddress, err := as.deserializeNetAddress(serializedBannedAddress[:serializedNetAddressLength])
+	if err != nil {
+		return nil, err
+	}
+	bannedAt := mstime.UnixMilliseconds(int64(binary.LittleEndian.Uint64(serializedBannedAddress[serializedNetAddressLength:])))
+
+	return &bannedAddress{
+		netAddress: netAddress,
+		bannedAt:   bannedAt,
+	}, nil
+}
@@ -1,6 +1,7 @@
 package connmanager
 
 import (
+	"crypto/rand"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/kaspanet/kaspad/infrastructure/network/netadapter"
 
 	"github.com/kaspanet/kaspad/infrastructure/config"
+	"github.com/pkg/errors"
 )
 
 // connectionRequest represents a user request (either through CLI or RPC) to connect to a certain node
@@ -34,6 +36,9 @@ type ConnectionManager struct {
 	activeIncoming   map[string]struct{}
 	maxIncoming      int
 
+	peerStatsFunc PeerStatsFunc
+	evictionSalt  [32]byte
+
 	stop                   uint32
 	connectionRequestsLock sync.Mutex
 
@@ -55,6 +60,11 @@ func New(cfg *config.Config, netAdapter *netadapter.NetAdapter, addressManager *
 		loopTicker:       time.NewTicker(connectionsLoopInterval),
 	}
 
+	_, err := rand.Read(c.evictionSalt[:])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
 	connectPeers := cfg.AddPeers
 	if len(cfg.ConnectPeers) > 0 {
 		connectPeers = cfg.ConnectPeers
@@ -126,18 +136,26 @@ func (c *ConnectionManager) ConnectionCount() int {
 }
 
 // Ban marks the given netConnection as banned
-func (c *ConnectionManager) Ban(netConnection *netadapter.NetConnection) {
+func (c *ConnectionManager) Ban(netConnection *netadapter.NetConnection) error {
 	if c.isPermanent(netConnection.Address()) {
 		log.Infof("Cannot ban %s because it's a permanent connection", netConnection.Address())
-		return
+		return nil
 	}
 
-	c.addressManager.Ban(netConnection.NetAddress())
+	// Local peers, including everyone who connects through the onion
+	// service, share their address with each other, so banning one would
+	// ban them all
+	if addressmanager.IsLocal(netConnection.NetAddress()) {
+		log.Infof("Not banning %s because it's a local peer", netConnection)
+		return nil
+	}
+
+	return c.addressManager.Ban(netConnection.NetAddress())
 }
 
 // IsBanned returns whether the given netConnection is banned
 func (c *ConnectionManager) IsBanned(netConnection *netadapter.NetConnection) (bool, error) {
-	if c.isPermanent(netConnection.Address()) {
+	if c.isPermanent(netConnection.Address()) || addressmanager.IsLocal(netConnection.NetAddress()) {
 		return false, nil
 	}
 
@@ -1,8 +1,81 @@
 package connmanager
 
-// checkIncomingConnections makes sure there's no more than maxIncoming incoming connections
-// if there are - it randomly disconnects enough to go below that number
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"github.com/kaspanet/kaspad/infrastructure/network/netadapter"
+)
+
+// The amount of incoming connections that are protected from eviction by
+// each of the criteria in selectConnectionToEvict. These follow bitcoind.
+const (
+	protectedByGroupKeyCount        = 4
+	protectedByPingCount            = 8
+	protectedByTransactionTimeCount = 4
+	protectedByBlockTimeCount       = 4
+)
+
+// onionGroupKey is the group key of the connections that came in through
+// the onion service of this node
+const onionGroupKey = "onion"
+
+// PeerStats is what the ConnectionManager knows about how useful the peer on
+// an incoming connection is, which it uses to decide which connection to evict
+// once there are too many of them
+type PeerStats struct {
+	// PingDuration is the duration of the last ping to the peer,
+	// or zero if the peer was never pinged
+	PingDuration time.Duration
+
+	// LastBlockTime is the last time the peer relayed a block this node
+	// didn't know about, or the zero time if it never did
+	LastBlockTime time.Time
+
+	// LastTransactionTime is the last time the peer relayed a transaction
+	// that was accepted to the mempool, or the zero time if it never did
+	LastTransactionTime time.Time
+
+	// TimeConnected is how long the peer has been connected
+	TimeConnected time.Duration
+}
+
+// PeerStatsFunc returns the PeerStats of the peer on the given connection,
+// or false if the connection didn't complete its handshake
+type PeerStatsFunc func(netConnection *netadapter.NetConnection) (*PeerStats, bool)
+
+// SetPeerStatsFunc sets the function used to learn how useful the peers
+// on incoming connections are
+func (c *ConnectionManager) SetPeerStatsFunc(peerStatsFunc PeerStatsFunc) {
+	c.peerStatsFunc = peerStatsFunc
+}
+
+// evictionCandidate is an incoming connection that may be evicted
+type evictionCandidate struct {
+	connection *netadapter.NetConnection
+	groupKey   string
+
+	// groupKeyHash is the hash of groupKey, salted with a value that is
+	// unknown to peers, so that they can't tell which groups are protected
+	groupKeyHash uint64
+
+	stats *PeerStats
+}
+
+// checkIncomingConnections makes sure there's no more than maxIncoming incoming connections.
+// If there are, it evicts the least useful of the connections that were already there on the
+// previous check, so that new peers get a chance to connect. If all of those are protected,
+// the new connections are disconnected instead. Whitelisted connections are never evicted.
 func (c *ConnectionManager) checkIncomingConnections(incomingConnectionSet connectionSet) {
+	defer func() {
+		c.activeIncoming = make(map[string]struct{}, len(incomingConnectionSet))
+		for address := range incomingConnectionSet {
+			c.activeIncoming[address] = struct{}{}
+		}
+	}()
+
 	if len(incomingConnectionSet) <= c.maxIncoming {
 		return
 	}
@@ -11,14 +84,170 @@ func (c *ConnectionManager) checkIncomingConnections(incomingConnectionSet conne
 	log.Debugf("Got %d incoming connections while only %d are allowed. Disconnecting "+
 		"%d", len(incomingConnectionSet), c.maxIncoming, numConnectionsOverMax)
 
-	// randomly disconnect nodes until the number of incoming connections is smaller than maxIncoming
-	for _, connection := range incomingConnectionSet {
-		log.Debugf("Disconnecting %s due to exceeding incoming connections", connection)
+	candidates := make([]*evictionCandidate, 0, len(incomingConnectionSet))
+	var newConnections []*netadapter.NetConnection
+	for address, connection := range incomingConnectionSet {
+		if c.IsWhitelisted(connection) {
+			continue
+		}
+		if _, ok := c.activeIncoming[address]; !ok {
+			newConnections = append(newConnections, connection)
+			continue
+		}
+		candidates = append(candidates, c.newEvictionCandidate(connection))
+	}
+
+	for ; numConnectionsOverMax > 0; numConnectionsOverMax-- {
+		var connection *netadapter.NetConnection
+		if candidate := selectConnectionToEvict(candidates); candidate != nil {
+			connection = candidate.connection
+			candidates = removeEvictionCandidate(candidates, candidate)
+			log.Debugf("Evicting %s to make room for new incoming connections", connection)
+		} else if len(newConnections) > 0 {
+			connection, newConnections = newConnections[0], newConnections[1:]
+			log.Debugf("Disconnecting %s because all other incoming connections are protected "+
+				"from eviction", connection)
+		} else {
+			log.Debugf("Cannot disconnect %d more incoming connections because they are all whitelisted",
+				numConnectionsOverMax)
+			return
+		}
+
 		connection.Disconnect()
+		incomingConnectionSet.remove(connection)
+	}
+}
+
+func (c *ConnectionManager) newEvictionCandidate(connection *netadapter.NetConnection) *evictionCandidate {
+	// Connections that came in through the onion service all have the
+	// loopback address Tor connected from, so they make a group of their
+	// own rather than share one with other local peers
+	groupKey := onionGroupKey
+	if !connection.IsOnion() {
+		groupKey = c.addressManager.GroupKey(connection.NetAddress())
+	}
+
+	hash := sha256.New()
+	hash.Write(c.evictionSalt[:])
+	hash.Write([]byte(groupKey))
+	groupKeyHash := binary.LittleEndian.Uint64(hash.Sum(nil))
+
+	// A connection that didn't complete its handshake by now is as
+	// useless as a peer can be
+	stats := &PeerStats{}
+	if c.peerStatsFunc != nil {
+		if peerStats, ok := c.peerStatsFunc(connection); ok {
+			stats = peerStats
+		}
+	}
+
+	return &evictionCandidate{
+		connection:   connection,
+		groupKey:     groupKey,
+		groupKeyHash: groupKeyHash,
+		stats:        stats,
+	}
+}
+
+// selectConnectionToEvict returns the candidate to evict, or nil if all of
+// them are protected.
+//
+// Like in bitcoind, an attacker shouldn't be able to make this node evict
+// its good peers, which means it would need to beat them at every one of the
+// following: being in a variety of network groups, having low latency,
+// relaying new transactions and blocks, and being connected for a long time.
+// Out of the candidates that remain, the newest connection in the network
+// group with the most connections is evicted.
+func selectConnectionToEvict(candidates []*evictionCandidate) *evictionCandidate {
+	remaining := make([]*evictionCandidate, len(candidates))
+	copy(remaining, candidates)
+
+	remaining = protect(remaining, protectedByGroupKeyCount, nil, func(a, b *evictionCandidate) bool {
+		return a.groupKeyHash < b.groupKeyHash
+	})
+	remaining = protect(remaining, protectedByPingCount,
+		func(candidate *evictionCandidate) bool { return candidate.stats.PingDuration > 0 },
+		func(a, b *evictionCandidate) bool { return a.stats.PingDuration < b.stats.PingDuration })
+	remaining = protect(remaining, protectedByTransactionTimeCount,
+		func(candidate *evictionCandidate) bool { return !candidate.stats.LastTransactionTime.IsZero() },
+		func(a, b *evictionCandidate) bool {
+			return a.stats.LastTransactionTime.After(b.stats.LastTransactionTime)
+		})
+	remaining = protect(remaining, protectedByBlockTimeCount,
+		func(candidate *evictionCandidate) bool { return !candidate.stats.LastBlockTime.IsZero() },
+		func(a, b *evictionCandidate) bool { return a.stats.LastBlockTime.After(b.stats.LastBlockTime) })
+	remaining = protect(remaining, len(remaining)/2, nil, func(a, b *evictionCandidate) bool {
+		return a.stats.TimeConnected > b.stats.TimeConnected
+	})
+	if len(remaining) == 0 {
+		return nil
+	}
+
+	groups := make(map[string][]*evictionCandidate)
+	for _, candidate := range remaining {
+		groups[candidate.groupKey] = append(groups[candidate.groupKey], candidate)
+	}
+
+	// Evict the newest connection of the largest group. Out of groups of the
+	// same size, the one with the newest connection is picked.
+	var toEvict *evictionCandidate
+	largestGroupSize := 0
+	for _, group := range groups {
+		newest := group[0]
+		for _, candidate := range group[1:] {
+			if candidate.stats.TimeConnected < newest.stats.TimeConnected {
+				newest = candidate
+			}
+		}
+		if len(group) > largestGroupSize ||
+			(len(group) == largestGroupSize && newest.stats.TimeConnected < toEvict.stats.TimeConnected) {
+
+			toEvict = newest
+			largestGroupSize = len(group)
+		}
+	}
+	return toEvict
+}
+
+// protect removes from candidates up to count of the ones that come first
+// according to less, and returns the rest. Candidates for which isEligible
+// returns false are never protected. A nil isEligible makes all the
+// candidates eligible.
+func protect(candidates []*evictionCandidate, count int, isEligible func(*evictionCandidate) bool,
+	less func(a, b *evictionCandidate) bool) []*evictionCandidate {
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return less(candidates[i], candidates[j])
+	})
+
+	remaining := candidates[:0]
+	for _, candidate := range candidates {
+		if count > 0 && (isEligible == nil || isEligible(candidate)) {
+			count--
+			continue
+		}
+		remaining = append(remaining, candidate)
+	}
+	return remaining
+}
+
+func removeEvictionCandidate(candidates []*evictionCandidate, toRemove *evictionCandidate) []*evictionCandidate {
+	for i, candidate := range candidates {
+		if candidate == toRemove {
+			return append(candidates[:i], candidates[i+1:]...)
+		}
+	}
+	return candidates
+}
 
-		numConnectionsOverMax--
-		if numConnectionsOverMax == 0 {
-			break
+// IsWhitelisted returns whether the address of the given connection
+// is in one of the whitelisted IP networks
+func (c *ConnectionManager) IsWhitelisted(netConnection *netadapter.NetConnection) bool {
+	ip := netConnection.NetAddress().IP
+	for _, whitelist := range c.cfg.Whitelists {
+		if whitelist.Contains(ip) {
+			return true
 		}
 	}
+	return false
 }
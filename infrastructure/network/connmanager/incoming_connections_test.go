@@ -0,0 +1,93 @@
+package connmanager
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newTestEvictionCandidate(groupKey string, groupKeyHash uint64, stats PeerStats) *evictionCandidate {
+	return &evictionCandidate{
+		groupKey:     groupKey,
+		groupKeyHash: groupKeyHash,
+		stats:        &stats,
+	}
+}
+
+func TestSelectConnectionToEvict(t *testing.T) {
+	now := time.Now()
+
+	// Peers with the useful properties that protect them from eviction,
+	// each in a network group of its own
+	var candidates []*evictionCandidate
+	for i := 0; i < 4; i++ {
+		candidates = append(candidates, newTestEvictionCandidate(fmt.Sprintf("10.%d.0.0", i), uint64(i),
+			PeerStats{TimeConnected: time.Minute}))
+	}
+	for i := 0; i < 8; i++ {
+		candidates = append(candidates, newTestEvictionCandidate(fmt.Sprintf("20.%d.0.0", i), 1000,
+			PeerStats{PingDuration: time.Millisecond, TimeConnected: time.Minute}))
+	}
+	for i := 0; i < 4; i++ {
+		candidates = append(candidates, newTestEvictionCandidate(fmt.Sprintf("30.%d.0.0", i), 1000,
+			PeerStats{LastTransactionTime: now, TimeConnected: time.Minute}))
+	}
+	for i := 0; i < 4; i++ {
+		candidates = append(candidates, newTestEvictionCandidate(fmt.Sprintf("40.%d.0.0", i), 1000,
+			PeerStats{LastBlockTime: now, TimeConnected: time.Minute}))
+	}
+	if selectConnectionToEvict(candidates) != nil {
+		t.Fatalf("expected all the peers to be protected from eviction")
+	}
+
+	// An attacker that fills the rest of the slots from a single network
+	// group only gets its own connections evicted
+	var attackerCandidates []*evictionCandidate
+	for i := 0; i < 20; i++ {
+		attackerCandidate := newTestEvictionCandidate("66.6.0.0", 1000,
+			PeerStats{PingDuration: time.Second, TimeConnected: time.Duration(i+1) * time.Hour})
+		attackerCandidates = append(attackerCandidates, attackerCandidate)
+		candidates = append(candidates, attackerCandidate)
+	}
+	toEvict := selectConnectionToEvict(candidates)
+	if toEvict != attackerCandidates[0] {
+		t.Fatalf("expected the newest connection of the attacker to be evicted, got %+v", toEvict)
+	}
+
+	// The attacker's connections are evicted one by one, newest first,
+	// until only the protected peers remain
+	evictedCount := 0
+	for toEvict := selectConnectionToEvict(candidates); toEvict != nil; toEvict = selectConnectionToEvict(candidates) {
+		if toEvict != attackerCandidates[evictedCount] {
+			t.Fatalf("expected connection #%d of the attacker to be evicted, got %+v", evictedCount, toEvict)
+		}
+		candidates = removeEvictionCandidate(candidates, toEvict)
+		evictedCount++
+	}
+	if evictedCount != len(attackerCandidates) {
+		t.Fatalf("expected all %d connections of the attacker to be evicted, got %d",
+			len(attackerCandidates), evictedCount)
+	}
+}
+
+func TestSelectConnectionToEvictFromLargestGroup(t *testing.T) {
+	var candidates []*evictionCandidate
+	// Connections that are protected by their group key hash
+	for i := 0; i < protectedByGroupKeyCount; i++ {
+		candidates = append(candidates, newTestEvictionCandidate(fmt.Sprintf("10.%d.0.0", i), uint64(i), PeerStats{}))
+	}
+	// Old connections that are protected by the time they've been connected
+	for i := 0; i < 3; i++ {
+		candidates = append(candidates, newTestEvictionCandidate("20.0.0.0", 1000,
+			PeerStats{TimeConnected: 24 * time.Hour}))
+	}
+	small := newTestEvictionCandidate("30.0.0.0", 1000, PeerStats{TimeConnected: time.Second})
+	largeOld := newTestEvictionCandidate("40.0.0.0", 1000, PeerStats{TimeConnected: time.Hour})
+	largeNew := newTestEvictionCandidate("40.0.0.0", 1000, PeerStats{TimeConnected: time.Minute})
+	candidates = append(candidates, small, largeOld, largeNew)
+
+	toEvict := selectConnectionToEvict(candidates)
+	if toEvict != largeNew {
+		t.Fatalf("expected the newest connection in the largest group to be evicted, got %+v", toEvict)
+	}
+}
@@ -34,18 +34,27 @@ func (c *ConnectionManager) checkOutgoingConnections(connSet connectionSet) {
 	netAddresses := c.addressManager.RandomAddresses(connectionsNeededCount, connectedAddresses)
 
 	for _, netAddress := range netAddresses {
-		addressString := netAddress.TCPAddress().String()
+		addressString := netAddress.String()
 
 		log.Debugf("Connecting to %s because we have %d outgoing connections and the target is "+
 			"%d", addressString, len(c.activeOutgoing), c.targetOutgoing)
 
-		err := c.initiateConnection(addressString)
+		err := c.addressManager.Attempt(netAddress)
+		if err != nil {
+			log.Errorf("Couldn't mark a connection attempt to %s: %s", addressString, err)
+		}
+
+		err = c.initiateConnection(addressString)
 		if err != nil {
 			log.Infof("Couldn't connect to %s: %s", addressString, err)
-			c.addressManager.RemoveAddress(netAddress)
 			continue
 		}
 
+		err = c.addressManager.Good(netAddress)
+		if err != nil {
+			log.Errorf("Couldn't mark %s as good: %s", addressString, err)
+		}
+
 		c.activeOutgoing[addressString] = struct{}{}
 	}
 }
@@ -0,0 +1,9 @@
+package natmanager
+
+import (
+	"github.com/kaspanet/kaspad/infrastructure/logger"
+	"github.com/kaspanet/kaspad/util/panics"
+)
+
+var log, _ = logger.Get(logger.SubsystemTags.NATM)
+var spawn = panics.GoroutineWrapperFunc(log)
@@ -0,0 +1,175 @@
+package natmanager
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/infrastructure/config"
+	"github.com/kaspanet/kaspad/infrastructure/network/addressmanager"
+	"github.com/pkg/errors"
+)
+
+const (
+	// mappingLifetime is the lifetime requested for port mappings.
+	// Mappings are renewed well before it's over, and are removed by the
+	// gateway on their own if kaspad stops without removing them.
+	mappingLifetime = 20 * time.Minute
+	renewInterval   = mappingLifetime / 2
+
+	mappingProtocol    = "tcp"
+	mappingDescription = "kaspad listen port"
+)
+
+// NAT is a gateway that translates between the local network and the
+// internet, and that can be asked to forward a port to this node
+type NAT interface {
+	// ExternalIP returns the address of the gateway on the internet
+	ExternalIP() (net.IP, error)
+
+	// AddPortMapping forwards externalPort on the gateway to internalPort on
+	// this node for the given lifetime, and returns the external port that
+	// the gateway actually mapped
+	AddPortMapping(protocol string, externalPort uint16, internalPort uint16, description string,
+		lifetime time.Duration) (uint16, error)
+
+	// DeletePortMapping removes a mapping added by AddPortMapping
+	DeletePortMapping(protocol string, externalPort uint16, internalPort uint16) error
+}
+
+// Discover looks for a NAT gateway on the local network, trying UPnP first
+// and falling back to NAT-PMP
+func Discover() (NAT, error) {
+	upnp, upnpErr := discoverUPnP(ssdpMulticastAddress)
+	if upnpErr == nil {
+		return upnp, nil
+	}
+	log.Debugf("UPnP discovery failed: %s", upnpErr)
+
+	natPMP, natPMPErr := discoverNATPMP(natPMPGatewayCandidates())
+	if natPMPErr == nil {
+		return natPMP, nil
+	}
+	log.Debugf("NAT-PMP discovery failed: %s", natPMPErr)
+
+	return nil, errors.New("found neither a UPnP nor a NAT-PMP gateway")
+}
+
+// Manager maps the P2P listening port of this node on the NAT gateway,
+// and registers the resulting external address with the AddressManager,
+// so that it's advertised to peers
+type Manager struct {
+	addressManager *addressmanager.AddressManager
+	listenPort     uint16
+	discover       func() (NAT, error)
+
+	// externalPort is the port mapped on the gateway, or 0 if there's
+	// no mapping
+	externalPort uint16
+	externalIP   net.IP
+
+	stop      chan struct{}
+	waitGroup sync.WaitGroup
+}
+
+// New returns a new Manager that maps the port of the first P2P listener
+func New(cfg *config.Config, addressManager *addressmanager.AddressManager) (*Manager, error) {
+	if len(cfg.Listeners) == 0 {
+		return nil, errors.New("cannot map a port without listeners")
+	}
+	_, portString, err := net.SplitHostPort(cfg.Listeners[0])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	listenPort, err := strconv.ParseUint(portString, 10, 16)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid listen port %s", portString)
+	}
+
+	return &Manager{
+		addressManager: addressManager,
+		listenPort:     uint16(listenPort),
+		discover:       Discover,
+		stop:           make(chan struct{}),
+	}, nil
+}
+
+// Start discovers the gateway and maps the listening port in the background
+func (m *Manager) Start() {
+	m.waitGroup.Add(1)
+	spawn("natmanager.Manager.run", func() {
+		defer m.waitGroup.Done()
+		m.run()
+	})
+}
+
+// Stop removes the port mapping from the gateway, and stops renewing it
+func (m *Manager) Stop() {
+	close(m.stop)
+	m.waitGroup.Wait()
+}
+
+func (m *Manager) run() {
+	nat, err := m.discover()
+	if err != nil {
+		log.Warnf("Cannot map the listening port: %s", err)
+		return
+	}
+
+	err = m.mapPort(nat)
+	if err != nil {
+		log.Warnf("Error mapping the listening port: %s", err)
+	}
+
+	ticker := time.NewTicker(renewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := m.mapPort(nat)
+			if err != nil {
+				log.Warnf("Error renewing the mapping of the listening port: %s", err)
+			}
+		case <-m.stop:
+			m.unmapPort(nat)
+			return
+		}
+	}
+}
+
+func (m *Manager) mapPort(nat NAT) error {
+	externalPort, err := nat.AddPortMapping(mappingProtocol, m.listenPort, m.listenPort,
+		mappingDescription, mappingLifetime)
+	if err != nil {
+		return err
+	}
+	externalIP, err := nat.ExternalIP()
+	if err != nil {
+		return err
+	}
+
+	if externalPort != m.externalPort || !externalIP.Equal(m.externalIP) {
+		log.Infof("Mapped listening port %d to external address %s",
+			m.listenPort, net.JoinHostPort(externalIP.String(), strconv.Itoa(int(externalPort))))
+	}
+	m.externalPort = externalPort
+	m.externalIP = externalIP
+
+	netAddress := appmessage.NewNetAddressIPPort(externalIP, externalPort, appmessage.DefaultServices)
+	return m.addressManager.AddLocalAddress(netAddress, addressmanager.UpnpPrio)
+}
+
+func (m *Manager) unmapPort(nat NAT) {
+	if m.externalPort == 0 {
+		return
+	}
+	err := nat.DeletePortMapping(mappingProtocol, m.externalPort, m.listenPort)
+	if err != nil {
+		log.Warnf("Error removing the mapping of the listening port: %s", err)
+		return
+	}
+	log.Infof("Removed the mapping of listening port %d", m.listenPort)
+	m.externalPort = 0
+}
@@ -0,0 +1,69 @@
+package natmanager
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/infrastructure/config"
+	"github.com/kaspanet/kaspad/infrastructure/db/database/ldb"
+	"github.com/kaspanet/kaspad/infrastructure/network/addressmanager"
+)
+
+func TestManager(t *testing.T) {
+	datadir, err := ioutil.TempDir("", "TestManager")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(datadir)
+	database, err := ldb.NewLevelDB(datadir)
+	if err != nil {
+		t.Fatalf("NewLevelDB: %s", err)
+	}
+	defer database.Close()
+	addressManager, err := addressmanager.New(addressmanager.NewConfig(config.DefaultConfig()), database)
+	if err != nil {
+		t.Fatalf("addressmanager.New: %s", err)
+	}
+
+	gateway := newFakeUPnPGateway(t)
+	defer gateway.close()
+
+	cfg := config.DefaultConfig()
+	cfg.Listeners = []string{"0.0.0.0:16111"}
+	manager, err := New(cfg, addressManager)
+	if err != nil {
+		t.Fatalf("New: %+v", err)
+	}
+	manager.discover = func() (NAT, error) {
+		return discoverUPnP(gateway.ssdpAddress())
+	}
+
+	manager.Start()
+
+	// The external address is advertised to peers once the port is mapped
+	remoteAddress := appmessage.NewNetAddressIPPort(net.ParseIP("5.6.7.8"), 16111, appmessage.DefaultServices)
+	expectedAddress := appmessage.NewNetAddressIPPort(net.ParseIP(fakeExternalIP), 16111, appmessage.DefaultServices)
+	deadline := time.Now().Add(10 * time.Second)
+	for !addressManager.BestLocalAddress(remoteAddress).IP.Equal(expectedAddress.IP) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %s to be advertised, got %s", expectedAddress.IP,
+				addressManager.BestLocalAddress(remoteAddress).IP)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if bestAddress := addressManager.BestLocalAddress(remoteAddress); bestAddress.Port != 16111 {
+		t.Fatalf("expected the mapped port 16111 to be advertised, got %d", bestAddress.Port)
+	}
+	if _, ok := gateway.mapping(16111); !ok {
+		t.Fatalf("expected the listening port to be mapped")
+	}
+
+	manager.Stop()
+	if _, ok := gateway.mapping(16111); ok {
+		t.Fatalf("expected the mapping to be removed on shutdown")
+	}
+}
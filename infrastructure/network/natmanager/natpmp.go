@@ -0,0 +1,228 @@
+package natmanager
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// NAT-PMP is defined in RFC 6886
+const (
+	natPMPPort    = 5351
+	natPMPVersion = 0
+
+	natPMPOpExternalAddress = 0
+	natPMPOpMapUDP          = 1
+	natPMPOpMapTCP          = 2
+
+	// Responses carry the opcode of their request plus natPMPResponseOpOffset
+	natPMPResponseOpOffset = 128
+
+	natPMPExternalAddressResponseLength = 12
+	natPMPMappingResponseLength         = 16
+
+	// Requests are retransmitted with a timeout that starts at
+	// natPMPInitialTimeout and doubles with every attempt
+	natPMPInitialTimeout = 250 * time.Millisecond
+	natPMPAttempts       = 3
+)
+
+// natPMPNAT is a gateway that speaks NAT-PMP
+type natPMPNAT struct {
+	gateway *net.UDPAddr
+}
+
+// discoverNATPMP returns the first of the given gateways that answers
+// NAT-PMP requests
+func discoverNATPMP(gateways []*net.UDPAddr) (*natPMPNAT, error) {
+	for _, gateway := range gateways {
+		nat := &natPMPNAT{gateway: gateway}
+		_, err := nat.ExternalIP()
+		if err != nil {
+			log.Debugf("No NAT-PMP gateway at %s: %s", gateway, err)
+			continue
+		}
+		return nat, nil
+	}
+	return nil, errors.New("no NAT-PMP gateway answered")
+}
+
+// natPMPGatewayCandidates returns the addresses at which a NAT-PMP gateway may
+// be listening: the default gateway, if the routing table is readable, and the
+// first address of every private IPv4 network this node is on, which is where
+// home routers usually are
+func natPMPGatewayCandidates() []*net.UDPAddr {
+	var candidates []*net.UDPAddr
+	addCandidate := func(ip net.IP) {
+		for _, candidate := range candidates {
+			if candidate.IP.Equal(ip) {
+				return
+			}
+		}
+		candidates = append(candidates, &net.UDPAddr{IP: ip, Port: natPMPPort})
+	}
+
+	defaultGateway, err := linuxDefaultGateway()
+	if err == nil {
+		addCandidate(defaultGateway)
+	}
+
+	interfaceAddresses, err := net.InterfaceAddrs()
+	if err != nil {
+		return candidates
+	}
+	for _, interfaceAddress := range interfaceAddresses {
+		ipNet, ok := interfaceAddress.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipNet.IP.To4()
+		if ip == nil || !isPrivateIPv4(ip) {
+			continue
+		}
+		guess := ip.Mask(ipNet.Mask)
+		if guess == nil {
+			continue
+		}
+		guess[len(guess)-1]++
+		addCandidate(guess)
+	}
+	return candidates
+}
+
+// linuxDefaultGateway returns the IPv4 default gateway, as listed in
+// the routing table of the Linux kernel
+func linuxDefaultGateway() (net.IP, error) {
+	routes, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer routes.Close()
+
+	// Every line past the header is a route, whose destination and
+	// gateway are its second and third fields, in little endian hex
+	scanner := bufio.NewScanner(routes)
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		gateway, err := hex.DecodeString(fields[2])
+		if err != nil || len(gateway) != net.IPv4len {
+			continue
+		}
+		return net.IPv4(gateway[3], gateway[2], gateway[1], gateway[0]), nil
+	}
+	return nil, errors.New("no default gateway found")
+}
+
+func isPrivateIPv4(ip net.IP) bool {
+	return ip[0] == 10 ||
+		(ip[0] == 172 && ip[1]&0xf0 == 16) ||
+		(ip[0] == 192 && ip[1] == 168)
+}
+
+// ExternalIP returns the address of the gateway on the internet
+func (n *natPMPNAT) ExternalIP() (net.IP, error) {
+	request := []byte{natPMPVersion, natPMPOpExternalAddress}
+	response, err := n.request(request, natPMPExternalAddressResponseLength)
+	if err != nil {
+		return nil, err
+	}
+	return net.IPv4(response[8], response[9], response[10], response[11]), nil
+}
+
+// AddPortMapping forwards externalPort on the gateway to internalPort on this
+// node. The gateway may choose to map a different external port, which is
+// the one returned.
+func (n *natPMPNAT) AddPortMapping(protocol string, externalPort uint16, internalPort uint16, _ string,
+	lifetime time.Duration) (uint16, error) {
+
+	response, err := n.requestMapping(protocol, externalPort, internalPort, lifetime)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(response[10:12]), nil
+}
+
+// DeletePortMapping removes a mapping added by AddPortMapping
+func (n *natPMPNAT) DeletePortMapping(protocol string, _ uint16, internalPort uint16) error {
+	// A mapping is deleted by requesting it again with a zero
+	// lifetime and a zero external port
+	_, err := n.requestMapping(protocol, 0, internalPort, 0)
+	return err
+}
+
+func (n *natPMPNAT) requestMapping(protocol string, externalPort uint16, internalPort uint16,
+	lifetime time.Duration) ([]byte, error) {
+
+	var op byte
+	switch protocol {
+	case "tcp":
+		op = natPMPOpMapTCP
+	case "udp":
+		op = natPMPOpMapUDP
+	default:
+		return nil, errors.Errorf("unsupported protocol %s", protocol)
+	}
+
+	request := make([]byte, 12)
+	request[0] = natPMPVersion
+	request[1] = op
+	binary.BigEndian.PutUint16(request[4:6], internalPort)
+	binary.BigEndian.PutUint16(request[6:8], externalPort)
+	binary.BigEndian.PutUint32(request[8:12], uint32(lifetime/time.Second))
+	return n.request(request, natPMPMappingResponseLength)
+}
+
+// request sends the given request to the gateway until it answers, and
+// returns its response after making sure that it reports success
+func (n *natPMPNAT) request(request []byte, responseLength int) ([]byte, error) {
+	conn, err := net.DialUDP("udp4", nil, n.gateway)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer conn.Close()
+
+	response := make([]byte, natPMPMappingResponseLength)
+	timeout := natPMPInitialTimeout
+	for i := 0; i < natPMPAttempts; i++ {
+		_, err := conn.Write(request)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		err = conn.SetReadDeadline(time.Now().Add(timeout))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		timeout *= 2
+
+		length, err := conn.Read(response)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				continue
+			}
+			return nil, errors.WithStack(err)
+		}
+		if length < 4 || response[0] != natPMPVersion || response[1] != request[1]+natPMPResponseOpOffset {
+			return nil, errors.Errorf("unexpected response from NAT-PMP gateway %s", n.gateway)
+		}
+		resultCode := binary.BigEndian.Uint16(response[2:4])
+		if resultCode != 0 {
+			return nil, errors.Errorf("NAT-PMP gateway %s failed the request with result code %d",
+				n.gateway, resultCode)
+		}
+		if length != responseLength {
+			return nil, errors.Errorf("unexpected response length %d from NAT-PMP gateway %s", length, n.gateway)
+		}
+		return response[:length], nil
+	}
+	return nil, errors.Errorf("NAT-PMP gateway %s did not answer", n.gateway)
+}
@@ -0,0 +1,143 @@
+package natmanager
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNATPMPGateway is an in-process NAT-PMP gateway listening on a local UDP
+// port. It maps every requested port to the port right above it, to check that
+// the external port chosen by the gateway is respected.
+type fakeNATPMPGateway struct {
+	conn *net.UDPConn
+
+	mutex sync.Mutex
+	// mappings maps internal TCP ports to their lifetime in seconds
+	mappings map[uint16]uint32
+}
+
+func newFakeNATPMPGateway(t *testing.T) *fakeNATPMPGateway {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %s", err)
+	}
+	gateway := &fakeNATPMPGateway{
+		conn:     conn,
+		mappings: make(map[uint16]uint32),
+	}
+	go gateway.answerRequests()
+	return gateway
+}
+
+func (g *fakeNATPMPGateway) address() *net.UDPAddr {
+	return g.conn.LocalAddr().(*net.UDPAddr)
+}
+
+func (g *fakeNATPMPGateway) close() {
+	g.conn.Close()
+}
+
+func (g *fakeNATPMPGateway) mapping(internalPort uint16) (lifetime uint32, ok bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	lifetime, ok = g.mappings[internalPort]
+	return lifetime, ok
+}
+
+func (g *fakeNATPMPGateway) answerRequests() {
+	request := make([]byte, 12)
+	for {
+		length, address, err := g.conn.ReadFromUDP(request)
+		if err != nil {
+			return
+		}
+
+		var response []byte
+		switch {
+		case length == 2 && request[1] == natPMPOpExternalAddress:
+			response = make([]byte, natPMPExternalAddressResponseLength)
+			copy(response[8:12], net.ParseIP(fakeExternalIP).To4())
+		case length == 12 && request[1] == natPMPOpMapTCP:
+			internalPort := binary.BigEndian.Uint16(request[4:6])
+			lifetime := binary.BigEndian.Uint32(request[8:12])
+
+			g.mutex.Lock()
+			if lifetime == 0 {
+				delete(g.mappings, internalPort)
+			} else {
+				g.mappings[internalPort] = lifetime
+			}
+			g.mutex.Unlock()
+
+			response = make([]byte, natPMPMappingResponseLength)
+			copy(response[8:10], request[4:6])
+			binary.BigEndian.PutUint16(response[10:12], internalPort+1)
+			copy(response[12:16], request[8:12])
+		default:
+			// Unsupported opcode
+			response = make([]byte, 8)
+			binary.BigEndian.PutUint16(response[2:4], 5)
+		}
+		response[0] = natPMPVersion
+		response[1] = request[1] + natPMPResponseOpOffset
+		_, _ = g.conn.WriteToUDP(response, address)
+	}
+}
+
+func TestNATPMP(t *testing.T) {
+	gateway := newFakeNATPMPGateway(t)
+	defer gateway.close()
+
+	// Nothing listens on the first candidate
+	unresponsiveConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %s", err)
+	}
+	unresponsiveAddress := unresponsiveConn.LocalAddr().(*net.UDPAddr)
+	unresponsiveConn.Close()
+
+	nat, err := discoverNATPMP([]*net.UDPAddr{unresponsiveAddress, gateway.address()})
+	if err != nil {
+		t.Fatalf("discoverNATPMP: %+v", err)
+	}
+	if nat.gateway.String() != gateway.address().String() {
+		t.Fatalf("expected the gateway at %s to be discovered, got %s", gateway.address(), nat.gateway)
+	}
+
+	externalIP, err := nat.ExternalIP()
+	if err != nil {
+		t.Fatalf("ExternalIP: %+v", err)
+	}
+	if externalIP.String() != fakeExternalIP {
+		t.Fatalf("expected external IP %s, got %s", fakeExternalIP, externalIP)
+	}
+
+	externalPort, err := nat.AddPortMapping("tcp", 16111, 16111, mappingDescription, 20*time.Minute)
+	if err != nil {
+		t.Fatalf("AddPortMapping: %+v", err)
+	}
+	if externalPort != 16112 {
+		t.Fatalf("expected the external port chosen by the gateway, 16112, got %d", externalPort)
+	}
+	lifetime, ok := gateway.mapping(16111)
+	if !ok || lifetime != 1200 {
+		t.Fatalf("expected the port to be mapped for 1200 seconds, got %t, %d", ok, lifetime)
+	}
+
+	err = nat.DeletePortMapping("tcp", externalPort, 16111)
+	if err != nil {
+		t.Fatalf("DeletePortMapping: %+v", err)
+	}
+	if _, ok := gateway.mapping(16111); ok {
+		t.Fatalf("expected the mapping to be removed")
+	}
+
+	_, err = nat.AddPortMapping("udp", 16111, 16111, mappingDescription, 20*time.Minute)
+	if err == nil {
+		t.Fatalf("expected a request the gateway doesn't support to fail")
+	}
+}
@@ -0,0 +1,325 @@
+package natmanager
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ssdpMulticastAddress      = "239.255.255.250:1900"
+	internetGatewayDeviceType = "urn:schemas-upnp-org:device:InternetGatewayDevice:1"
+	ssdpAttempts              = 3
+	ssdpTimeout               = time.Second
+	upnpHTTPTimeout           = 5 * time.Second
+
+	// upnpErrorOnlyPermanentLeasesSupported is returned by gateways that
+	// refuse to map ports for a limited lifetime
+	upnpErrorOnlyPermanentLeasesSupported = 725
+)
+
+// wanConnectionServiceTypes are the UPnP services that are able to map ports,
+// in order of preference
+var wanConnectionServiceTypes = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:2",
+	"urn:schemas-upnp-org:service:WANIPConnection:1",
+	"urn:schemas-upnp-org:service:WANPPPConnection:1",
+}
+
+// upnpNAT is a UPnP Internet Gateway Device, controlled through SOAP
+// requests to the control URL of its WAN connection service
+type upnpNAT struct {
+	controlURL  string
+	serviceType string
+	internalIP  net.IP
+	httpClient  *http.Client
+}
+
+// discoverUPnP multicasts an SSDP search for Internet Gateway Devices to
+// ssdpAddress, and returns the first device that has a WAN connection service
+func discoverUPnP(ssdpAddress string) (*upnpNAT, error) {
+	ssdpUDPAddress, err := net.ResolveUDPAddr("udp4", ssdpAddress)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer conn.Close()
+
+	request := []byte("M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + ssdpMulticastAddress + "\r\n" +
+		"ST: " + internetGatewayDeviceType + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 1\r\n\r\n")
+	response := make([]byte, 2048)
+	for i := 0; i < ssdpAttempts; i++ {
+		_, err := conn.WriteToUDP(request, ssdpUDPAddress)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		err = conn.SetReadDeadline(time.Now().Add(ssdpTimeout))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		// Several devices may answer a single search, so keep reading
+		// until the deadline
+		for {
+			length, _, err := conn.ReadFromUDP(response)
+			if err != nil {
+				break
+			}
+			location, ok := parseSSDPResponse(response[:length])
+			if !ok {
+				continue
+			}
+			nat, err := newUPnPNAT(location)
+			if err != nil {
+				log.Debugf("Skipping UPnP device at %s: %s", location, err)
+				continue
+			}
+			return nat, nil
+		}
+	}
+	return nil, errors.New("no UPnP Internet Gateway Device answered")
+}
+
+// parseSSDPResponse returns the location of the device description of an
+// Internet Gateway Device, if the given message is a response to its search
+func parseSSDPResponse(message []byte) (location string, ok bool) {
+	response, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(message)), nil)
+	if err != nil {
+		return "", false
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK || response.Header.Get("ST") != internetGatewayDeviceType {
+		return "", false
+	}
+	location = response.Header.Get("Location")
+	return location, location != ""
+}
+
+type upnpDeviceDescription struct {
+	Device upnpDevice `xml:"device"`
+}
+
+type upnpDevice struct {
+	DeviceType string        `xml:"deviceType"`
+	Devices    []upnpDevice  `xml:"deviceList>device"`
+	Services   []upnpService `xml:"serviceList>service"`
+}
+
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+// findService returns the service of the given type in this device
+// or any of its embedded devices
+func (d *upnpDevice) findService(serviceType string) (*upnpService, bool) {
+	for i := range d.Services {
+		if d.Services[i].ServiceType == serviceType {
+			return &d.Services[i], true
+		}
+	}
+	for i := range d.Devices {
+		service, ok := d.Devices[i].findService(serviceType)
+		if ok {
+			return service, true
+		}
+	}
+	return nil, false
+}
+
+// newUPnPNAT fetches the device description at location, and returns a
+// upnpNAT for the WAN connection service it describes
+func newUPnPNAT(location string) (*upnpNAT, error) {
+	locationURL, err := url.Parse(location)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	httpClient := &http.Client{Timeout: upnpHTTPTimeout}
+	response, err := httpClient.Get(location)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("fetching the device description failed with HTTP status %s", response.Status)
+	}
+
+	var description upnpDeviceDescription
+	err = xml.NewDecoder(response.Body).Decode(&description)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed device description")
+	}
+	if description.Device.DeviceType != internetGatewayDeviceType {
+		return nil, errors.Errorf("unexpected device type %s", description.Device.DeviceType)
+	}
+
+	for _, serviceType := range wanConnectionServiceTypes {
+		service, ok := description.Device.findService(serviceType)
+		if !ok {
+			continue
+		}
+		controlURL, err := locationURL.Parse(service.ControlURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "malformed control URL %s", service.ControlURL)
+		}
+
+		// The gateway forwards ports to the address this node
+		// reaches it from
+		conn, err := net.Dial("udp4", locationURL.Host)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		internalIP := conn.LocalAddr().(*net.UDPAddr).IP
+		err = conn.Close()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return &upnpNAT{
+			controlURL:  controlURL.String(),
+			serviceType: serviceType,
+			internalIP:  internalIP,
+			httpClient:  httpClient,
+		}, nil
+	}
+	return nil, errors.New("the device has no WAN connection service")
+}
+
+type upnpExternalIPAddressResponse struct {
+	ExternalIPAddress string `xml:"Body>GetExternalIPAddressResponse>NewExternalIPAddress"`
+}
+
+// ExternalIP returns the address of the gateway on the internet
+func (n *upnpNAT) ExternalIP() (net.IP, error) {
+	var response upnpExternalIPAddressResponse
+	err := n.soapRequest("GetExternalIPAddress", "", &response)
+	if err != nil {
+		return nil, err
+	}
+	externalIP := net.ParseIP(strings.TrimSpace(response.ExternalIPAddress))
+	if externalIP == nil {
+		return nil, errors.Errorf("malformed external IP address %s", response.ExternalIPAddress)
+	}
+	return externalIP, nil
+}
+
+// AddPortMapping forwards externalPort on the gateway to internalPort on
+// this node. UPnP gateways either map the requested external port or fail,
+// so the returned port is always externalPort.
+func (n *upnpNAT) AddPortMapping(protocol string, externalPort uint16, internalPort uint16, description string,
+	lifetime time.Duration) (uint16, error) {
+
+	err := n.addPortMapping(protocol, externalPort, internalPort, description, lifetime)
+	upnpErr := &upnpError{}
+	if errors.As(err, &upnpErr) && upnpErr.code == upnpErrorOnlyPermanentLeasesSupported {
+		// The mapping is removed on shutdown, but if kaspad stops
+		// without removing it, it remains until the gateway restarts
+		err = n.addPortMapping(protocol, externalPort, internalPort, description, 0)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return externalPort, nil
+}
+
+func (n *upnpNAT) addPortMapping(protocol string, externalPort uint16, internalPort uint16, description string,
+	lifetime time.Duration) error {
+
+	arguments := fmt.Sprintf("<NewRemoteHost></NewRemoteHost>"+
+		"<NewExternalPort>%d</NewExternalPort>"+
+		"<NewProtocol>%s</NewProtocol>"+
+		"<NewInternalPort>%d</NewInternalPort>"+
+		"<NewInternalClient>%s</NewInternalClient>"+
+		"<NewEnabled>1</NewEnabled>"+
+		"<NewPortMappingDescription>%s</NewPortMappingDescription>"+
+		"<NewLeaseDuration>%d</NewLeaseDuration>",
+		externalPort, strings.ToUpper(protocol), internalPort, n.internalIP, xmlEscape(description),
+		int64(lifetime/time.Second))
+	return n.soapRequest("AddPortMapping", arguments, nil)
+}
+
+// DeletePortMapping removes a mapping added by AddPortMapping
+func (n *upnpNAT) DeletePortMapping(protocol string, externalPort uint16, _ uint16) error {
+	arguments := fmt.Sprintf("<NewRemoteHost></NewRemoteHost>"+
+		"<NewExternalPort>%d</NewExternalPort>"+
+		"<NewProtocol>%s</NewProtocol>",
+		externalPort, strings.ToUpper(protocol))
+	return n.soapRequest("DeletePortMapping", arguments, nil)
+}
+
+// upnpError is an error reported by a UPnP device in a SOAP fault
+type upnpError struct {
+	action      string
+	code        int
+	description string
+}
+
+func (e *upnpError) Error() string {
+	return fmt.Sprintf("%s failed with UPnP error %d: %s", e.action, e.code, e.description)
+}
+
+type upnpFaultResponse struct {
+	ErrorCode        int    `xml:"Body>Fault>detail>UPnPError>errorCode"`
+	ErrorDescription string `xml:"Body>Fault>detail>UPnPError>errorDescription"`
+}
+
+// soapRequest invokes the given action of the WAN connection service, and
+// decodes its response into response, unless it's nil
+func (n *upnpNAT) soapRequest(action string, arguments string, response interface{}) error {
+	body := `<?xml version="1.0"?>` + "\r\n" +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" ` +
+		`s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body><u:` + action + ` xmlns:u="` + n.serviceType + `">` + arguments + `</u:` + action + `></s:Body>` +
+		`</s:Envelope>`
+	request, err := http.NewRequest(http.MethodPost, n.controlURL, strings.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	request.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	request.Header.Set("SOAPAction", `"`+n.serviceType+"#"+action+`"`)
+
+	httpResponse, err := n.httpClient.Do(request)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		var fault upnpFaultResponse
+		err := xml.NewDecoder(httpResponse.Body).Decode(&fault)
+		if err == nil && fault.ErrorCode != 0 {
+			return &upnpError{action: action, code: fault.ErrorCode, description: fault.ErrorDescription}
+		}
+		return errors.Errorf("%s failed with HTTP status %s", action, httpResponse.Status)
+	}
+	if response == nil {
+		return nil
+	}
+	err = xml.NewDecoder(httpResponse.Body).Decode(response)
+	if err != nil {
+		return errors.Wrapf(err, "malformed %s response", action)
+	}
+	return nil
+}
+
+func xmlEscape(text string) string {
+	escaped := &strings.Builder{}
+	// xml.EscapeText only fails if writing to escaped fails
+	_ = xml.EscapeText(escaped, []byte(text))
+	return escaped.String()
+}
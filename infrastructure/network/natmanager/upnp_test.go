@@ -0,0 +1,289 @@
+package natmanager
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const fakeExternalIP = "1.2.3.4"
+
+type fakeUPnPMapping struct {
+	internalClient string
+	internalPort   string
+	leaseDuration  string
+}
+
+// fakeUPnPGateway is an in-process Internet Gateway Device that answers
+// SSDP searches on a local UDP port and SOAP requests over HTTP
+type fakeUPnPGateway struct {
+	ssdpConn   *net.UDPConn
+	httpServer *httptest.Server
+
+	// onlyPermanentLeases makes the gateway refuse mappings with
+	// a limited lifetime
+	onlyPermanentLeases bool
+
+	mutex    sync.Mutex
+	mappings map[string]fakeUPnPMapping
+}
+
+func newFakeUPnPGateway(t *testing.T) *fakeUPnPGateway {
+	ssdpConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %s", err)
+	}
+	gateway := &fakeUPnPGateway{
+		ssdpConn: ssdpConn,
+		mappings: make(map[string]fakeUPnPMapping),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rootDesc.xml", gateway.handleDeviceDescription)
+	mux.HandleFunc("/ctl/IPConn", gateway.handleControl)
+	gateway.httpServer = httptest.NewServer(mux)
+
+	go gateway.answerSSDPSearches()
+	return gateway
+}
+
+func (g *fakeUPnPGateway) ssdpAddress() string {
+	return g.ssdpConn.LocalAddr().String()
+}
+
+func (g *fakeUPnPGateway) close() {
+	g.ssdpConn.Close()
+	g.httpServer.Close()
+}
+
+func (g *fakeUPnPGateway) mapping(externalPort uint16) (fakeUPnPMapping, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	mapping, ok := g.mappings[fmt.Sprintf("TCP:%d", externalPort)]
+	return mapping, ok
+}
+
+func (g *fakeUPnPGateway) answerSSDPSearches() {
+	buffer := make([]byte, 2048)
+	for {
+		length, address, err := g.ssdpConn.ReadFromUDP(buffer)
+		if err != nil {
+			return
+		}
+		if !strings.HasPrefix(string(buffer[:length]), "M-SEARCH") {
+			continue
+		}
+
+		// Unrelated devices answer searches as well
+		_, _ = g.ssdpConn.WriteToUDP([]byte("HTTP/1.1 200 OK\r\n"+
+			"ST: urn:schemas-upnp-org:device:MediaServer:1\r\n"+
+			"LOCATION: http://127.0.0.1:1/unrelated.xml\r\n\r\n"), address)
+		_, _ = g.ssdpConn.WriteToUDP([]byte("HTTP/1.1 200 OK\r\n"+
+			"CACHE-CONTROL: max-age=120\r\n"+
+			"ST: "+internetGatewayDeviceType+"\r\n"+
+			"LOCATION: "+g.httpServer.URL+"/rootDesc.xml\r\n\r\n"), address)
+	}
+}
+
+func (g *fakeUPnPGateway) handleDeviceDescription(writer http.ResponseWriter, _ *http.Request) {
+	fmt.Fprint(writer, `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+    <serviceList>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:Layer3Forwarding:1</serviceType>
+        <controlURL>/ctl/L3F</controlURL>
+      </service>
+    </serviceList>
+    <deviceList>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+        <deviceList>
+          <device>
+            <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+            <serviceList>
+              <service>
+                <serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>
+                <controlURL>/ctl/IPConn</controlURL>
+              </service>
+            </serviceList>
+          </device>
+        </deviceList>
+      </device>
+    </deviceList>
+  </device>
+</root>`)
+}
+
+type fakeSOAPRequest struct {
+	Body struct {
+		Action struct {
+			XMLName        xml.Name
+			ExternalPort   string `xml:"NewExternalPort"`
+			Protocol       string `xml:"NewProtocol"`
+			InternalPort   string `xml:"NewInternalPort"`
+			InternalClient string `xml:"NewInternalClient"`
+			LeaseDuration  string `xml:"NewLeaseDuration"`
+		} `xml:",any"`
+	}
+}
+
+func (g *fakeUPnPGateway) handleControl(writer http.ResponseWriter, request *http.Request) {
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var soapRequest fakeSOAPRequest
+	err = xml.Unmarshal(body, &soapRequest)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	action := soapRequest.Body.Action
+	expectedSOAPAction := `"urn:schemas-upnp-org:service:WANIPConnection:1#` + action.XMLName.Local + `"`
+	if request.Header.Get("SOAPAction") != expectedSOAPAction {
+		http.Error(writer, "unexpected SOAPAction", http.StatusBadRequest)
+		return
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	key := action.Protocol + ":" + action.ExternalPort
+	switch action.XMLName.Local {
+	case "GetExternalIPAddress":
+		writeFakeSOAPResponse(writer, "GetExternalIPAddress",
+			"<NewExternalIPAddress>"+fakeExternalIP+"</NewExternalIPAddress>")
+	case "AddPortMapping":
+		if g.onlyPermanentLeases && action.LeaseDuration != "0" {
+			writeFakeSOAPFault(writer, upnpErrorOnlyPermanentLeasesSupported, "OnlyPermanentLeasesSupported")
+			return
+		}
+		g.mappings[key] = fakeUPnPMapping{
+			internalClient: action.InternalClient,
+			internalPort:   action.InternalPort,
+			leaseDuration:  action.LeaseDuration,
+		}
+		writeFakeSOAPResponse(writer, "AddPortMapping", "")
+	case "DeletePortMapping":
+		if _, ok := g.mappings[key]; !ok {
+			writeFakeSOAPFault(writer, 714, "NoSuchEntryInArray")
+			return
+		}
+		delete(g.mappings, key)
+		writeFakeSOAPResponse(writer, "DeletePortMapping", "")
+	default:
+		writeFakeSOAPFault(writer, 401, "Invalid Action")
+	}
+}
+
+func writeFakeSOAPResponse(writer http.ResponseWriter, action string, arguments string) {
+	fmt.Fprintf(writer, `<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/">
+  <s:Body>
+    <u:%sResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1">%s</u:%sResponse>
+  </s:Body>
+</s:Envelope>`, action, arguments, action)
+}
+
+func writeFakeSOAPFault(writer http.ResponseWriter, code int, description string) {
+	writer.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprintf(writer, `<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/">
+  <s:Body>
+    <s:Fault>
+      <faultcode>s:Client</faultcode>
+      <faultstring>UPnPError</faultstring>
+      <detail>
+        <UPnPError xmlns="urn:schemas-upnp-org:control-1-0">
+          <errorCode>%d</errorCode>
+          <errorDescription>%s</errorDescription>
+        </UPnPError>
+      </detail>
+    </s:Fault>
+  </s:Body>
+</s:Envelope>`, code, description)
+}
+
+func TestUPnP(t *testing.T) {
+	gateway := newFakeUPnPGateway(t)
+	defer gateway.close()
+
+	nat, err := discoverUPnP(gateway.ssdpAddress())
+	if err != nil {
+		t.Fatalf("discoverUPnP: %+v", err)
+	}
+	if !nat.internalIP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Fatalf("expected the internal IP to be 127.0.0.1, got %s", nat.internalIP)
+	}
+
+	externalIP, err := nat.ExternalIP()
+	if err != nil {
+		t.Fatalf("ExternalIP: %+v", err)
+	}
+	if externalIP.String() != fakeExternalIP {
+		t.Fatalf("expected external IP %s, got %s", fakeExternalIP, externalIP)
+	}
+
+	externalPort, err := nat.AddPortMapping("tcp", 16111, 16111, mappingDescription, 20*time.Minute)
+	if err != nil {
+		t.Fatalf("AddPortMapping: %+v", err)
+	}
+	if externalPort != 16111 {
+		t.Fatalf("expected external port 16111, got %d", externalPort)
+	}
+	mapping, ok := gateway.mapping(16111)
+	if !ok {
+		t.Fatalf("expected the port to be mapped")
+	}
+	if mapping.internalClient != "127.0.0.1" || mapping.internalPort != "16111" || mapping.leaseDuration != "1200" {
+		t.Fatalf("unexpected mapping %+v", mapping)
+	}
+
+	err = nat.DeletePortMapping("tcp", 16111, 16111)
+	if err != nil {
+		t.Fatalf("DeletePortMapping: %+v", err)
+	}
+	if _, ok := gateway.mapping(16111); ok {
+		t.Fatalf("expected the mapping to be removed")
+	}
+
+	err = nat.DeletePortMapping("tcp", 16111, 16111)
+	upnpErr := &upnpError{}
+	if !errors.As(err, &upnpErr) || upnpErr.code != 714 {
+		t.Fatalf("expected UPnP error 714 when removing a missing mapping, got %v", err)
+	}
+}
+
+func TestUPnPOnlyPermanentLeases(t *testing.T) {
+	gateway := newFakeUPnPGateway(t)
+	defer gateway.close()
+	gateway.mutex.Lock()
+	gateway.onlyPermanentLeases = true
+	gateway.mutex.Unlock()
+
+	nat, err := discoverUPnP(gateway.ssdpAddress())
+	if err != nil {
+		t.Fatalf("discoverUPnP: %+v", err)
+	}
+	_, err = nat.AddPortMapping("tcp", 16111, 16111, mappingDescription, 20*time.Minute)
+	if err != nil {
+		t.Fatalf("AddPortMapping: %+v", err)
+	}
+	mapping, ok := gateway.mapping(16111)
+	if !ok || mapping.leaseDuration != "0" {
+		t.Fatalf("expected a permanent mapping, got %+v", mapping)
+	}
+}
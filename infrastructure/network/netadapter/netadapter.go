@@ -42,11 +42,25 @@ func NewNetAdapter(cfg *config.Config) (*NetAdapter, error) {
 	if err != nil {
 		return nil, err
 	}
-	p2pServer, err := grpcserver.NewP2PServer(cfg.Listeners)
+	// Onion services are only reachable through a Tor proxy
+	var dialOnion grpcserver.DialFunc
+	if cfg.Proxy != "" {
+		dialOnion = cfg.Dial
+	}
+	p2pServer, err := grpcserver.NewP2PServer(cfg.Listeners, cfg.OnionListen, dialOnion)
 	if err != nil {
 		return nil, err
 	}
-	rpcServer, err := grpcserver.NewRPCServer(cfg.RPCListeners)
+	rpcTLSConfig, err := rpcTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	rpcAuth := &grpcserver.RPCAuth{
+		User:      cfg.RPCUser,
+		Password:  cfg.RPCPass,
+		AuthToken: cfg.RPCAuthToken,
+	}
+	rpcServer, err := grpcserver.NewRPCServer(cfg.RPCListeners, rpcTLSConfig, rpcAuth)
 	if err != nil {
 		return nil, err
 	}
@@ -74,9 +74,16 @@ func (c *NetConnection) IsOutbound() bool {
 	return c.connection.IsOutbound()
 }
 
+// IsOnion returns whether the connection is to an onion service, or came in
+// through the onion service of this node. The address of the latter is the
+// loopback address Tor connected from.
+func (c *NetConnection) IsOnion() bool {
+	return c.connection.IsOnion()
+}
+
 // NetAddress returns the NetAddress associated with this connection
 func (c *NetConnection) NetAddress() *appmessage.NetAddress {
-	return appmessage.NewNetAddress(c.connection.Address(), 0)
+	return c.connection.Address()
 }
 
 func (c *NetConnection) setOnDisconnectedHandler(onDisconnectedHandler server.OnDisconnectedHandler) {
@@ -87,11 +87,15 @@ func (r *Route) DequeueWithTimeout(timeout time.Duration) (appmessage.Message, e
 	}
 }
 
-// Close closes this route
+// Close closes this route. Messages that were enqueued before the route was
+// closed may still be dequeued. Closing a closed route does nothing.
 func (r *Route) Close() {
 	r.closeLock.Lock()
 	defer r.closeLock.Unlock()
 
+	if r.closed {
+		return
+	}
 	r.closed = true
 	close(r.channel)
 }
@@ -0,0 +1,96 @@
+package netadapter
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kaspanet/kaspad/infrastructure/config"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+// rpcCertValidity is how long auto-generated RPC certificates are valid for
+const rpcCertValidity = 10 * 365 * 24 * time.Hour
+
+// rpcTLSConfig returns the TLS configuration for the RPC server, or nil
+// if TLS is disabled or the RPC server has nothing to listen on. If neither
+// the certificate nor the key exist, a new self-signed certificate pair is
+// generated.
+func rpcTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	if cfg.DisableRPC || cfg.DisableTLS || len(cfg.RPCListeners) == 0 {
+		return nil, nil
+	}
+
+	certExists, err := fileExists(cfg.RPCCert)
+	if err != nil {
+		return nil, err
+	}
+	keyExists, err := fileExists(cfg.RPCKey)
+	if err != nil {
+		return nil, err
+	}
+	if certExists != keyExists {
+		return nil, errors.Errorf("only one of the RPC certificate %s and key %s exists. "+
+			"Either provide both or remove the existing one in order to generate a new pair",
+			cfg.RPCCert, cfg.RPCKey)
+	}
+	if !certExists {
+		err := generateRPCCertPair(cfg.RPCCert, cfg.RPCKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	keyPair, err := tls.LoadX509KeyPair(cfg.RPCCert, cfg.RPCKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading the RPC certificate pair")
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{keyPair},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// generateRPCCertPair generates a self-signed certificate pair and
+// writes it to the given paths
+func generateRPCCertPair(certFile string, keyFile string) error {
+	log.Infof("Generating TLS certificates...")
+
+	cert, key, err := util.NewTLSCertPair("kaspad autogenerated cert", time.Now().Add(rpcCertValidity), nil)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range []string{certFile, keyFile} {
+		err := os.MkdirAll(filepath.Dir(file), 0700)
+		if err != nil {
+			return err
+		}
+	}
+	err = ioutil.WriteFile(certFile, cert, 0644)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(keyFile, key, 0600)
+	if err != nil {
+		os.Remove(certFile)
+		return err
+	}
+
+	log.Infof("Done generating TLS certificates: %s", certFile)
+	return nil
+}
+
+func fileExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, errors.WithStack(err)
+}
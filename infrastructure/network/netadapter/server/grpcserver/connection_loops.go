@@ -50,6 +50,7 @@ func (c *gRPCConnection) sendLoop() error {
 		if err != nil {
 			return err
 		}
+		c.recordMessage(directionSent, message.Command(), messageProto)
 	}
 	return nil
 }
@@ -72,6 +73,8 @@ func (c *gRPCConnection) receiveLoop() error {
 			return err
 		}
 
+		c.recordMessage(directionReceived, message.Command(), protoMessage)
+
 		messageNumber++
 		message.SetMessageNumber(messageNumber)
 		message.SetReceivedAt(time.Now())
@@ -1,10 +1,10 @@
 package grpcserver
 
 import (
+	"github.com/kaspanet/kaspad/app/appmessage"
 	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/router"
 	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/server/grpcserver/protowire"
 	"github.com/pkg/errors"
-	"net"
 	"sync"
 	"sync/atomic"
 
@@ -14,7 +14,7 @@ import (
 
 type gRPCConnection struct {
 	server                   *gRPCServer
-	address                  *net.TCPAddr
+	address                  *appmessage.NetAddress
 	stream                   grpcStream
 	router                   *router.Router
 	lowLevelClientConnection *grpc.ClientConn
@@ -31,6 +31,10 @@ type gRPCConnection struct {
 	onInvalidMessageHandler server.OnInvalidMessageHandler
 
 	isConnected uint32
+
+	// isInboundOnion is whether the connection came in through the
+	// onion service of this node
+	isInboundOnion bool
 }
 
 type grpcStream interface {
@@ -38,7 +42,7 @@ type grpcStream interface {
 	Recv() (*protowire.KaspadMessage, error)
 }
 
-func newConnection(server *gRPCServer, address *net.TCPAddr, stream grpcStream,
+func newConnection(server *gRPCServer, address *appmessage.NetAddress, stream grpcStream,
 	lowLevelClientConnection *grpc.ClientConn) *gRPCConnection {
 	connection := &gRPCConnection{
 		server:                   server,
@@ -71,6 +75,12 @@ func (c *gRPCConnection) String() string {
 	return c.Address().String()
 }
 
+// IsOnion returns whether the connection is to an onion service, or came in
+// through the onion service of this node
+func (c *gRPCConnection) IsOnion() bool {
+	return c.isInboundOnion || c.address.IsOnion()
+}
+
 func (c *gRPCConnection) IsConnected() bool {
 	return atomic.LoadUint32(&c.isConnected) != 0
 }
@@ -110,7 +120,7 @@ func (c *gRPCConnection) Disconnect() {
 	}
 }
 
-func (c *gRPCConnection) Address() *net.TCPAddr {
+func (c *gRPCConnection) Address() *appmessage.NetAddress {
 	return c.address
 }
 
@@ -3,6 +3,7 @@ package grpcserver
 import (
 	"context"
 	"fmt"
+	"github.com/kaspanet/kaspad/app/appmessage"
 	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/server"
 	"github.com/kaspanet/kaspad/util/panics"
 	"github.com/pkg/errors"
@@ -20,10 +21,13 @@ type gRPCServer struct {
 }
 
 // newGRPCServer creates a gRPC server
-func newGRPCServer(listeningAddresses []string, maxMessageSize int, name string) *gRPCServer {
+func newGRPCServer(listeningAddresses []string, maxMessageSize int, name string,
+	serverOptions ...grpc.ServerOption) *gRPCServer {
+
 	log.Debugf("Created new %s GRPC server with maxMessageSize %d", name, maxMessageSize)
+	serverOptions = append(serverOptions, grpc.MaxRecvMsgSize(maxMessageSize), grpc.MaxSendMsgSize(maxMessageSize))
 	return &gRPCServer{
-		server:             grpc.NewServer(grpc.MaxRecvMsgSize(maxMessageSize), grpc.MaxSendMsgSize(maxMessageSize)),
+		server:             grpc.NewServer(serverOptions...),
 		listeningAddresses: listeningAddresses,
 		name:               name,
 	}
@@ -50,7 +54,12 @@ func (s *gRPCServer) listenOn(listenAddr string) error {
 		return errors.Wrapf(err, "%s error listening on %s", s.name, listenAddr)
 	}
 
-	spawn(fmt.Sprintf("%s.gRPCServer.listenOn-Serve", s.name), func() {
+	s.serve(listener, listenAddr)
+	return nil
+}
+
+func (s *gRPCServer) serve(listener net.Listener, listenAddr string) {
+	spawn(fmt.Sprintf("%s.gRPCServer.serve-Serve", s.name), func() {
 		err := s.server.Serve(listener)
 		if err != nil {
 			panics.Exit(log, fmt.Sprintf("error serving %s on %s: %+v", s.name, listenAddr, err))
@@ -58,7 +67,6 @@ func (s *gRPCServer) listenOn(listenAddr string) error {
 	})
 
 	log.Infof("%s Server listening on %s", s.name, listenAddr)
-	return nil
 }
 
 func (s *gRPCServer) Stop() error {
@@ -90,12 +98,18 @@ func (s *gRPCServer) handleInboundConnection(ctx context.Context, stream grpcStr
 	if !ok {
 		return errors.Errorf("Error getting stream peer info from context")
 	}
-	tcpAddress, ok := peerInfo.Addr.(*net.TCPAddr)
+	address := peerInfo.Addr
+	onionAddress, isOnion := address.(onionPeerAddr)
+	if isOnion {
+		address = onionAddress.Addr
+	}
+	tcpAddress, ok := address.(*net.TCPAddr)
 	if !ok {
 		return errors.Errorf("non-tcp connections are not supported")
 	}
 
-	connection := newConnection(s, tcpAddress, stream, nil)
+	connection := newConnection(s, appmessage.NewNetAddress(tcpAddress, 0), stream, nil)
+	connection.isInboundOnion = isOnion
 
 	err := s.onConnectedHandler(connection)
 	if err != nil {
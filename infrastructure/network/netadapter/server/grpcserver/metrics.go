@@ -0,0 +1,47 @@
+package grpcserver
+
+import (
+	"strings"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/infrastructure/metrics"
+	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/server/grpcserver/protowire"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	directionSent     = "sent"
+	directionReceived = "received"
+)
+
+var (
+	messagesCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "network",
+		Name:      "messages_total",
+		Help:      "Number of messages sent and received, by server and message command",
+	}, []string{"server", "direction", "command"})
+
+	messageBytesCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "network",
+		Name:      "message_bytes_total",
+		Help:      "Number of bytes of messages sent and received, by server and message command",
+	}, []string{"server", "direction", "command"})
+)
+
+// recordMessage counts the given message, which was sent or received over
+// this connection. Measuring a message walks all of it, so nothing is
+// recorded unless metrics are served.
+func (c *gRPCConnection) recordMessage(direction string, command appmessage.MessageCommand,
+	messageProto *protowire.KaspadMessage) {
+
+	if !metrics.IsEnabled() {
+		return
+	}
+	serverName := strings.ToLower(c.server.name)
+	messagesCounter.WithLabelValues(serverName, direction, command.String()).Inc()
+	messageBytesCounter.WithLabelValues(serverName, direction, command.String()).Add(float64(proto.Size(messageProto)))
+}
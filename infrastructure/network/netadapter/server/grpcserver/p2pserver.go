@@ -2,6 +2,7 @@ package grpcserver
 
 import (
 	"context"
+	"github.com/kaspanet/kaspad/app/appmessage"
 	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/server"
 	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/server/grpcserver/protowire"
 	"github.com/kaspanet/kaspad/util/panics"
@@ -10,24 +11,64 @@ import (
 	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/peer"
 	"net"
+	"strconv"
 	"time"
 )
 
+// DialFunc dials the given address over the given network, failing after timeout
+type DialFunc func(network string, address string, timeout time.Duration) (net.Conn, error)
+
 type p2pServer struct {
 	protowire.UnimplementedP2PServer
 	gRPCServer
+
+	// dialOnion dials onion services through a Tor proxy. It's nil if
+	// no proxy is configured.
+	dialOnion DialFunc
+
+	// onionListeningAddress is where Tor forwards the connections of
+	// the onion service of this node. It's empty if there's none.
+	onionListeningAddress string
 }
 
 const p2pMaxMessageSize = 10 * 1024 * 1024 // 10MB
 
-// NewP2PServer creates a new P2PServer
-func NewP2PServer(listeningAddresses []string) (server.P2PServer, error) {
+// NewP2PServer creates a new P2PServer. Connections to onion services are
+// dialed with dialOnion, which may be nil if they aren't supported. Incoming
+// connections on onionListeningAddress, if it's not empty, are treated as
+// connections that came in through the onion service of this node.
+func NewP2PServer(listeningAddresses []string, onionListeningAddress string,
+	dialOnion DialFunc) (server.P2PServer, error) {
+
 	gRPCServer := newGRPCServer(listeningAddresses, p2pMaxMessageSize, "P2P")
-	p2pServer := &p2pServer{gRPCServer: *gRPCServer}
+	p2pServer := &p2pServer{
+		gRPCServer:            *gRPCServer,
+		dialOnion:             dialOnion,
+		onionListeningAddress: onionListeningAddress,
+	}
 	protowire.RegisterP2PServer(gRPCServer.server, p2pServer)
 	return p2pServer, nil
 }
 
+// Start starts listening on the listening addresses, and on the onion service
+// listening address if there is one
+func (p *p2pServer) Start() error {
+	err := p.gRPCServer.Start()
+	if err != nil {
+		return err
+	}
+	if p.onionListeningAddress == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", p.onionListeningAddress)
+	if err != nil {
+		return errors.Wrapf(err, "%s error listening on %s", p.name, p.onionListeningAddress)
+	}
+	p.serve(onionListener{Listener: listener}, p.onionListeningAddress)
+	return nil
+}
+
 func (p *p2pServer) MessageStream(stream protowire.P2P_MessageStreamServer) error {
 	defer panics.HandlePanic(log, "p2pServer.MessageStream", nil)
 
@@ -39,11 +80,32 @@ func (p *p2pServer) MessageStream(stream protowire.P2P_MessageStreamServer) erro
 func (p *p2pServer) Connect(address string) (server.Connection, error) {
 	log.Infof("%s Dialing to %s", p.name, address)
 
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s error parsing address %s", p.name, address)
+	}
+	isOnion := appmessage.IsOnionHost(host)
+
 	const dialTimeout = 30 * time.Second
 	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
 	defer cancel()
 
-	gRPCClientConnection, err := grpc.DialContext(ctx, address, grpc.WithInsecure(), grpc.WithBlock())
+	dialOptions := []grpc.DialOption{grpc.WithInsecure(), grpc.WithBlock()}
+	if isOnion {
+		if p.dialOnion == nil {
+			return nil, errors.Errorf("%s cannot connect to onion service %s without a proxy", p.name, address)
+		}
+		dialOptions = append(dialOptions, grpc.WithContextDialer(
+			func(ctx context.Context, address string) (net.Conn, error) {
+				timeout := dialTimeout
+				if deadline, ok := ctx.Deadline(); ok {
+					timeout = time.Until(deadline)
+				}
+				return p.dialOnion("tcp", address, timeout)
+			}))
+	}
+
+	gRPCClientConnection, err := grpc.DialContext(ctx, address, dialOptions...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "%s error connecting to %s", p.name, address)
 	}
@@ -55,16 +117,32 @@ func (p *p2pServer) Connect(address string) (server.Connection, error) {
 		return nil, errors.Wrapf(err, "%s error getting client stream for %s", p.name, address)
 	}
 
-	peerInfo, ok := peer.FromContext(stream.Context())
-	if !ok {
-		return nil, errors.Errorf("%s error getting stream peer info from context for %s", p.name, address)
-	}
-	tcpAddress, ok := peerInfo.Addr.(*net.TCPAddr)
-	if !ok {
-		return nil, errors.Errorf("non-tcp addresses are not supported")
+	var netAddress *appmessage.NetAddress
+	if isOnion {
+		// The peer address of connections through the proxy is the
+		// address of the proxy, so the onion service is taken from
+		// the dialed address instead
+		port, err := strconv.ParseUint(portString, 10, 16)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s invalid port in %s", p.name, address)
+		}
+		netAddress, err = appmessage.NewNetAddressHostPort(host, uint16(port), 0)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		peerInfo, ok := peer.FromContext(stream.Context())
+		if !ok {
+			return nil, errors.Errorf("%s error getting stream peer info from context for %s", p.name, address)
+		}
+		tcpAddress, ok := peerInfo.Addr.(*net.TCPAddr)
+		if !ok {
+			return nil, errors.Errorf("non-tcp addresses are not supported")
+		}
+		netAddress = appmessage.NewNetAddress(tcpAddress, 0)
 	}
 
-	connection := newConnection(&p.gRPCServer, tcpAddress, stream, gRPCClientConnection)
+	connection := newConnection(&p.gRPCServer, netAddress, stream, gRPCClientConnection)
 
 	err = p.onConnectedHandler(connection)
 	if err != nil {
@@ -75,3 +153,33 @@ func (p *p2pServer) Connect(address string) (server.Connection, error) {
 
 	return connection, nil
 }
+
+// onionListener accepts the connections that Tor forwards from the onion
+// service of this node. They all come from a loopback address, so their
+// remote addresses are wrapped in onionPeerAddr to tell them apart from
+// the connections of other local peers.
+type onionListener struct {
+	net.Listener
+}
+
+func (l onionListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return onionConn{Conn: conn}, nil
+}
+
+type onionConn struct {
+	net.Conn
+}
+
+func (c onionConn) RemoteAddr() net.Addr {
+	return onionPeerAddr{Addr: c.Conn.RemoteAddr()}
+}
+
+// onionPeerAddr is the address of a peer that connected through the onion
+// service of this node
+type onionPeerAddr struct {
+	net.Addr
+}
@@ -0,0 +1,74 @@
+package grpcserver
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/server"
+)
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %s", err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestP2PServerTellsApartOnionConnections(t *testing.T) {
+	listenAddress := freeAddress(t)
+	onionListenAddress := freeAddress(t)
+	p2pServer, err := NewP2PServer([]string{listenAddress}, onionListenAddress, nil)
+	if err != nil {
+		t.Fatalf("NewP2PServer: %s", err)
+	}
+	inboundConnections := make(chan server.Connection, 1)
+	p2pServer.SetOnConnectedHandler(func(connection server.Connection) error {
+		inboundConnections <- connection
+		return nil
+	})
+	err = p2pServer.Start()
+	if err != nil {
+		t.Fatalf("Start: %+v", err)
+	}
+	defer p2pServer.Stop()
+
+	client, err := NewP2PServer(nil, "", nil)
+	if err != nil {
+		t.Fatalf("NewP2PServer: %s", err)
+	}
+	client.SetOnConnectedHandler(func(connection server.Connection) error { return nil })
+
+	tests := []struct {
+		address         string
+		expectedIsOnion bool
+	}{
+		{address: listenAddress, expectedIsOnion: false},
+		{address: onionListenAddress, expectedIsOnion: true},
+	}
+	for _, test := range tests {
+		outboundConnection, err := client.Connect(test.address)
+		if err != nil {
+			t.Fatalf("Connect: %+v", err)
+		}
+		if outboundConnection.IsOnion() {
+			t.Fatalf("expected the outbound connection to %s not to be onion", test.address)
+		}
+
+		select {
+		case inboundConnection := <-inboundConnections:
+			if inboundConnection.IsOnion() != test.expectedIsOnion {
+				t.Fatalf("expected IsOnion of the connection on %s to be %t", test.address, test.expectedIsOnion)
+			}
+			if !inboundConnection.Address().IP.IsLoopback() {
+				t.Fatalf("expected the connection on %s to come from loopback, got %s",
+					test.address, inboundConnection.Address())
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for the connection on %s", test.address)
+		}
+		outboundConnection.Disconnect()
+	}
+}
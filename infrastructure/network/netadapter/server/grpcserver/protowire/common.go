@@ -1,6 +1,7 @@
 package protowire
 
 import (
+	"crypto/ed25519"
 	"math"
 
 	"github.com/kaspanet/kaspad/domain/consensus/model/externalapi"
@@ -92,19 +93,29 @@ func (x *NetAddress) toAppMessage() (*appmessage.NetAddress, error) {
 	if x.Port > math.MaxUint16 {
 		return nil, errors.Errorf("port number is larger than %d", math.MaxUint16)
 	}
+	if len(x.OnionPublicKey) != 0 {
+		if len(x.OnionPublicKey) != ed25519.PublicKeySize {
+			return nil, errors.Errorf("invalid onion public key length %d", len(x.OnionPublicKey))
+		}
+		if len(x.Ip) != 0 {
+			return nil, errors.New("an address can't be both an IP and an onion service")
+		}
+	}
 	return &appmessage.NetAddress{
-		Timestamp: mstime.UnixMilliseconds(x.Timestamp),
-		Services:  appmessage.ServiceFlag(x.Services),
-		IP:        x.Ip,
-		Port:      uint16(x.Port),
+		Timestamp:      mstime.UnixMilliseconds(x.Timestamp),
+		Services:       appmessage.ServiceFlag(x.Services),
+		IP:             x.Ip,
+		OnionPublicKey: x.OnionPublicKey,
+		Port:           uint16(x.Port),
 	}, nil
 }
 
 func appMessageNetAddressToProto(address *appmessage.NetAddress) *NetAddress {
 	return &NetAddress{
-		Timestamp: address.Timestamp.UnixMilliseconds(),
-		Services:  uint64(address.Services),
-		Ip:        address.IP,
-		Port:      uint32(address.Port),
+		Timestamp:      address.Timestamp.UnixMilliseconds(),
+		Services:       uint64(address.Services),
+		Ip:             address.IP,
+		OnionPublicKey: address.OnionPublicKey,
+		Port:           uint32(address.Port),
 	}
 }
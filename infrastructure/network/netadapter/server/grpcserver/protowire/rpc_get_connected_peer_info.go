@@ -29,6 +29,7 @@ func (x *KaspadMessage_GetConnectedPeerInfoResponse) toAppMessage() (appmessage.
 			AdvertisedProtocolVersion: info.AdvertisedProtocolVersion,
 			TimeConnected:             info.TimeOffset,
 			IsIBDPeer:                 info.IsIbdPeer,
+			BanScore:                  info.BanScore,
 		}
 	}
 	return &appmessage.GetConnectedPeerInfoResponseMessage{
@@ -54,6 +55,7 @@ func (x *KaspadMessage_GetConnectedPeerInfoResponse) fromAppMessage(message *app
 			AdvertisedProtocolVersion: info.AdvertisedProtocolVersion,
 			TimeConnected:             info.TimeOffset,
 			IsIbdPeer:                 info.IsIBDPeer,
+			BanScore:                  info.BanScore,
 		}
 	}
 	x.GetConnectedPeerInfoResponse = &GetConnectedPeerInfoResponseMessage{
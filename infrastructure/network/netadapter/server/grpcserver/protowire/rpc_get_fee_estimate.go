@@ -0,0 +1,39 @@
+package protowire
+
+import "github.com/kaspanet/kaspad/app/appmessage"
+
+func (x *KaspadMessage_GetFeeEstimateRequest) toAppMessage() (appmessage.Message, error) {
+	return &appmessage.GetFeeEstimateRequestMessage{}, nil
+}
+
+func (x *KaspadMessage_GetFeeEstimateRequest) fromAppMessage(_ *appmessage.GetFeeEstimateRequestMessage) error {
+	x.GetFeeEstimateRequest = &GetFeeEstimateRequestMessage{}
+	return nil
+}
+
+func (x *KaspadMessage_GetFeeEstimateResponse) toAppMessage() (appmessage.Message, error) {
+	var err *appmessage.RPCError
+	if x.GetFeeEstimateResponse.Error != nil {
+		err = &appmessage.RPCError{Message: x.GetFeeEstimateResponse.Error.Message}
+	}
+	return &appmessage.GetFeeEstimateResponseMessage{
+		LowFeeRate:      x.GetFeeEstimateResponse.LowFeeRate,
+		NormalFeeRate:   x.GetFeeEstimateResponse.NormalFeeRate,
+		PriorityFeeRate: x.GetFeeEstimateResponse.PriorityFeeRate,
+		Error:           err,
+	}, nil
+}
+
+func (x *KaspadMessage_GetFeeEstimateResponse) fromAppMessage(message *appmessage.GetFeeEstimateResponseMessage) error {
+	var err *RPCError
+	if message.Error != nil {
+		err = &RPCError{Message: message.Error.Message}
+	}
+	x.GetFeeEstimateResponse = &GetFeeEstimateResponseMessage{
+		LowFeeRate:      message.LowFeeRate,
+		NormalFeeRate:   message.NormalFeeRate,
+		PriorityFeeRate: message.PriorityFeeRate,
+		Error:           err,
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package protowire
+
+import "github.com/kaspanet/kaspad/app/appmessage"
+
+func (x *KaspadMessage_GetMempoolInfoRequest) toAppMessage() (appmessage.Message, error) {
+	return &appmessage.GetMempoolInfoRequestMessage{}, nil
+}
+
+func (x *KaspadMessage_GetMempoolInfoRequest) fromAppMessage(_ *appmessage.GetMempoolInfoRequestMessage) error {
+	x.GetMempoolInfoRequest = &GetMempoolInfoRequestMessage{}
+	return nil
+}
+
+func (x *KaspadMessage_GetMempoolInfoResponse) toAppMessage() (appmessage.Message, error) {
+	var err *appmessage.RPCError
+	if x.GetMempoolInfoResponse.Error != nil {
+		err = &appmessage.RPCError{Message: x.GetMempoolInfoResponse.Error.Message}
+	}
+	return &appmessage.GetMempoolInfoResponseMessage{
+		TransactionCount:        x.GetMempoolInfoResponse.TransactionCount,
+		ChainedTransactionCount: x.GetMempoolInfoResponse.ChainedTransactionCount,
+		OrphanCount:             x.GetMempoolInfoResponse.OrphanCount,
+		TotalMass:               x.GetMempoolInfoResponse.TotalMass,
+		MaxMass:                 x.GetMempoolInfoResponse.MaxMass,
+		RollingMinimumFeeRate:   x.GetMempoolInfoResponse.RollingMinimumFeeRate,
+		EvictedTransactionCount: x.GetMempoolInfoResponse.EvictedTransactionCount,
+		Error:                   err,
+	}, nil
+}
+
+func (x *KaspadMessage_GetMempoolInfoResponse) fromAppMessage(message *appmessage.GetMempoolInfoResponseMessage) error {
+	var err *RPCError
+	if message.Error != nil {
+		err = &RPCError{Message: message.Error.Message}
+	}
+	x.GetMempoolInfoResponse = &GetMempoolInfoResponseMessage{
+		TransactionCount:        message.TransactionCount,
+		ChainedTransactionCount: message.ChainedTransactionCount,
+		OrphanCount:             message.OrphanCount,
+		TotalMass:               message.TotalMass,
+		MaxMass:                 message.MaxMass,
+		RollingMinimumFeeRate:   message.RollingMinimumFeeRate,
+		EvictedTransactionCount: message.EvictedTransactionCount,
+		Error:                   err,
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package protowire
+
+import "github.com/kaspanet/kaspad/app/appmessage"
+
+func (x *KaspadMessage_GetTransactionRequest) toAppMessage() (appmessage.Message, error) {
+	return &appmessage.GetTransactionRequestMessage{
+		TxID: x.GetTransactionRequest.TxId,
+	}, nil
+}
+
+func (x *KaspadMessage_GetTransactionRequest) fromAppMessage(message *appmessage.GetTransactionRequestMessage) error {
+	x.GetTransactionRequest = &GetTransactionRequestMessage{
+		TxId: message.TxID,
+	}
+	return nil
+}
+
+func (x *KaspadMessage_GetTransactionResponse) toAppMessage() (appmessage.Message, error) {
+	var rpcErr *appmessage.RPCError
+	if x.GetTransactionResponse.Error != nil {
+		rpcErr = &appmessage.RPCError{Message: x.GetTransactionResponse.Error.Message}
+	}
+	var transactionVerboseData *appmessage.TransactionVerboseData
+	if x.GetTransactionResponse.TransactionVerboseData != nil {
+		var err error
+		transactionVerboseData, err = x.GetTransactionResponse.TransactionVerboseData.toAppMessage()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &appmessage.GetTransactionResponseMessage{
+		TransactionVerboseData: transactionVerboseData,
+		BlockHash:              x.GetTransactionResponse.BlockHash,
+		AcceptingBlockHash:     x.GetTransactionResponse.AcceptingBlockHash,
+		Confirmations:          x.GetTransactionResponse.Confirmations,
+		Error:                  rpcErr,
+	}, nil
+}
+
+func (x *KaspadMessage_GetTransactionResponse) fromAppMessage(message *appmessage.GetTransactionResponseMessage) error {
+	var rpcErr *RPCError
+	if message.Error != nil {
+		rpcErr = &RPCError{Message: message.Error.Message}
+	}
+	var transactionVerboseData *TransactionVerboseData
+	if message.TransactionVerboseData != nil {
+		transactionVerboseData = new(TransactionVerboseData)
+		err := transactionVerboseData.fromAppMessage(message.TransactionVerboseData)
+		if err != nil {
+			return err
+		}
+	}
+	x.GetTransactionResponse = &GetTransactionResponseMessage{
+		TransactionVerboseData: transactionVerboseData,
+		BlockHash:              message.BlockHash,
+		AcceptingBlockHash:     message.AcceptingBlockHash,
+		Confirmations:          message.Confirmations,
+		Error:                  rpcErr,
+	}
+	return nil
+}
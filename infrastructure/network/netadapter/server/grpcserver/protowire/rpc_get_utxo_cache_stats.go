@@ -0,0 +1,43 @@
+package protowire
+
+import "github.com/kaspanet/kaspad/app/appmessage"
+
+func (x *KaspadMessage_GetUtxoCacheStatsRequest) toAppMessage() (appmessage.Message, error) {
+	return &appmessage.GetUTXOCacheStatsRequestMessage{}, nil
+}
+
+func (x *KaspadMessage_GetUtxoCacheStatsRequest) fromAppMessage(_ *appmessage.GetUTXOCacheStatsRequestMessage) error {
+	x.GetUtxoCacheStatsRequest = &GetUtxoCacheStatsRequestMessage{}
+	return nil
+}
+
+func (x *KaspadMessage_GetUtxoCacheStatsResponse) toAppMessage() (appmessage.Message, error) {
+	var err *appmessage.RPCError
+	if x.GetUtxoCacheStatsResponse.Error != nil {
+		err = &appmessage.RPCError{Message: x.GetUtxoCacheStatsResponse.Error.Message}
+	}
+	return &appmessage.GetUTXOCacheStatsResponseMessage{
+		EntryCount: x.GetUtxoCacheStatsResponse.EntryCount,
+		Size:       x.GetUtxoCacheStatsResponse.Size,
+		MaxSize:    x.GetUtxoCacheStatsResponse.MaxSize,
+		Hits:       x.GetUtxoCacheStatsResponse.Hits,
+		Misses:     x.GetUtxoCacheStatsResponse.Misses,
+		Error:      err,
+	}, nil
+}
+
+func (x *KaspadMessage_GetUtxoCacheStatsResponse) fromAppMessage(message *appmessage.GetUTXOCacheStatsResponseMessage) error {
+	var err *RPCError
+	if message.Error != nil {
+		err = &RPCError{Message: message.Error.Message}
+	}
+	x.GetUtxoCacheStatsResponse = &GetUtxoCacheStatsResponseMessage{
+		EntryCount: message.EntryCount,
+		Size:       message.Size,
+		MaxSize:    message.MaxSize,
+		Hits:       message.Hits,
+		Misses:     message.Misses,
+		Error:      err,
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRPCAuth(t *testing.T) {
+	contextWithAuthorization := func(authorization string) context.Context {
+		return metadata.NewIncomingContext(context.Background(),
+			metadata.Pairs(AuthorizationMetadataKey, authorization))
+	}
+
+	tests := []struct {
+		name          string
+		auth          *RPCAuth
+		ctx           context.Context
+		expectsAccept bool
+	}{
+		{
+			name:          "auth disabled",
+			auth:          nil,
+			ctx:           context.Background(),
+			expectsAccept: true,
+		},
+		{
+			name:          "missing credentials",
+			auth:          &RPCAuth{User: "user", Password: "pass"},
+			ctx:           context.Background(),
+			expectsAccept: false,
+		},
+		{
+			name:          "correct username and password",
+			auth:          &RPCAuth{User: "user", Password: "pass"},
+			ctx:           contextWithAuthorization(BasicAuthorization("user", "pass")),
+			expectsAccept: true,
+		},
+		{
+			name:          "wrong password",
+			auth:          &RPCAuth{User: "user", Password: "pass"},
+			ctx:           contextWithAuthorization(BasicAuthorization("user", "wrong")),
+			expectsAccept: false,
+		},
+		{
+			name:          "correct token",
+			auth:          &RPCAuth{User: "user", Password: "pass", AuthToken: "token"},
+			ctx:           contextWithAuthorization(BearerAuthorization("token")),
+			expectsAccept: true,
+		},
+		{
+			name:          "wrong token",
+			auth:          &RPCAuth{AuthToken: "token"},
+			ctx:           contextWithAuthorization(BearerAuthorization("wrong")),
+			expectsAccept: false,
+		},
+		{
+			name:          "token sent when only username and password are set",
+			auth:          &RPCAuth{User: "user", Password: "pass"},
+			ctx:           contextWithAuthorization(BearerAuthorization("")),
+			expectsAccept: false,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.auth.authenticate(test.ctx)
+		if test.expectsAccept {
+			if err != nil {
+				t.Errorf("%s: expected authentication to succeed, but got: %s", test.name, err)
+			}
+			continue
+		}
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%s: expected an Unauthenticated error, but got: %v", test.name, err)
+		}
+	}
+}
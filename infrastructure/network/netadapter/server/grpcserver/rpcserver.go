@@ -1,23 +1,34 @@
 package grpcserver
 
 import (
+	"crypto/tls"
+
 	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/server"
 	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/server/grpcserver/protowire"
 	"github.com/kaspanet/kaspad/util/panics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 type rpcServer struct {
 	protowire.UnimplementedRPCServer
 	gRPCServer
+	auth *RPCAuth
 }
 
 // RPCMaxMessageSize is the max message size for the RPC server to send and receive
 const RPCMaxMessageSize = 1024 * 1024 * 1024 // 1 GB
 
-// NewRPCServer creates a new RPCServer
-func NewRPCServer(listeningAddresses []string) (server.Server, error) {
-	gRPCServer := newGRPCServer(listeningAddresses, RPCMaxMessageSize, "RPC")
-	rpcServer := &rpcServer{gRPCServer: *gRPCServer}
+// NewRPCServer creates a new RPCServer.
+// If tlsConfig is nil the server listens in plaintext, and if auth is
+// nil or empty clients are not required to authenticate.
+func NewRPCServer(listeningAddresses []string, tlsConfig *tls.Config, auth *RPCAuth) (server.Server, error) {
+	var serverOptions []grpc.ServerOption
+	if tlsConfig != nil {
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	gRPCServer := newGRPCServer(listeningAddresses, RPCMaxMessageSize, "RPC", serverOptions...)
+	rpcServer := &rpcServer{gRPCServer: *gRPCServer, auth: auth}
 	protowire.RegisterRPCServer(gRPCServer.server, rpcServer)
 	return rpcServer, nil
 }
@@ -25,5 +36,10 @@ func NewRPCServer(listeningAddresses []string) (server.Server, error) {
 func (r *rpcServer) MessageStream(stream protowire.RPC_MessageStreamServer) error {
 	defer panics.HandlePanic(log, "rpcServer.MessageStream", nil)
 
+	err := r.auth.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+
 	return r.handleInboundConnection(stream.Context(), stream)
 }
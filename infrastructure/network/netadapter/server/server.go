@@ -2,8 +2,8 @@ package server
 
 import (
 	"fmt"
-	"net"
 
+	"github.com/kaspanet/kaspad/app/appmessage"
 	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/router"
 )
 
@@ -40,7 +40,8 @@ type Connection interface {
 	Disconnect()
 	IsConnected() bool
 	IsOutbound() bool
+	IsOnion() bool
 	SetOnDisconnectedHandler(onDisconnectedHandler OnDisconnectedHandler)
 	SetOnInvalidMessageHandler(onInvalidMessageHandler OnInvalidMessageHandler)
-	Address() *net.TCPAddr
+	Address() *appmessage.NetAddress
 }
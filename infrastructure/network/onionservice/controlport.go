@@ -0,0 +1,327 @@
+package onionservice
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The Tor control protocol is defined in
+// https://gitweb.torproject.org/torspec.git/tree/control-spec.txt
+const (
+	controlPortTimeout = 10 * time.Second
+	replyCodeOK        = 250
+
+	// newOnionServiceKey asks Tor to generate the key of a new onion service
+	newOnionServiceKey = "NEW:ED25519-V3"
+
+	cookieSize          = 32
+	safeCookieNonceSize = 32
+
+	safeCookieServerToControllerKey = "Tor safe cookie authentication server-to-controller hash"
+	safeCookieControllerToServerKey = "Tor safe cookie authentication controller-to-server hash"
+)
+
+// controlConn is an authenticated connection to the control port of a
+// Tor daemon
+type controlConn struct {
+	address string
+	netConn net.Conn
+	conn    *textproto.Conn
+}
+
+// dialControlPort connects to the Tor control port at the given address
+// and authenticates with it. password is only used if the control port
+// requires password authentication.
+func dialControlPort(address string, password string) (*controlConn, error) {
+	netConn, err := net.DialTimeout("tcp", address, controlPortTimeout)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	c := &controlConn{
+		address: address,
+		netConn: netConn,
+		conn:    textproto.NewConn(netConn),
+	}
+
+	err = c.authenticate(password)
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *controlConn) close() {
+	// The connection is useless once there's an error closing it
+	_ = c.conn.Close()
+}
+
+// waitForClose blocks until the connection is closed, by either side
+func (c *controlConn) waitForClose() {
+	err := c.netConn.SetDeadline(time.Time{})
+	if err != nil {
+		return
+	}
+	// Tor sends nothing but asynchronous events, which aren't
+	// subscribed to, unless it's sent commands
+	for {
+		_, err := c.conn.ReadLine()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// command sends the given command to Tor, and returns the lines of its reply
+// if Tor reports that the command succeeded
+func (c *controlConn) command(command string, arguments ...string) ([]string, error) {
+	err := c.netConn.SetDeadline(time.Now().Add(controlPortTimeout))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	line := strings.Join(append([]string{command}, arguments...), " ")
+	err = c.conn.PrintfLine("%s", line)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	_, message, err := c.conn.ReadResponse(replyCodeOK)
+	if err != nil {
+		replyErr := &textproto.Error{}
+		if errors.As(err, &replyErr) {
+			return nil, errors.Errorf("%s failed with reply %d %s", command, replyErr.Code, replyErr.Msg)
+		}
+		return nil, errors.WithStack(err)
+	}
+	return strings.Split(message, "\n"), nil
+}
+
+// authenticate authenticates with the first method out of the ones the
+// control port accepts that doesn't require configuration, unless a
+// password is given
+func (c *controlConn) authenticate(password string) error {
+	authMethods, cookieFile, err := c.protocolInfo()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case password != "":
+		if !authMethods["HASHEDPASSWORD"] {
+			return errors.New("the Tor control port doesn't accept password authentication")
+		}
+		_, err = c.command("AUTHENTICATE", quote(password))
+	case authMethods["NULL"]:
+		_, err = c.command("AUTHENTICATE")
+	case authMethods["SAFECOOKIE"]:
+		err = c.authenticateSafeCookie(cookieFile)
+	case authMethods["COOKIE"]:
+		var cookie []byte
+		cookie, err = readCookie(cookieFile)
+		if err != nil {
+			return err
+		}
+		_, err = c.command("AUTHENTICATE", hex.EncodeToString(cookie))
+	case authMethods["HASHEDPASSWORD"]:
+		return errors.New("the Tor control port requires a password -- set it with --torpassword")
+	default:
+		return errors.New("the Tor control port accepts no supported authentication method")
+	}
+	return err
+}
+
+// protocolInfo returns the authentication methods the control port accepts,
+// and the location of its authentication cookie
+func (c *controlConn) protocolInfo() (authMethods map[string]bool, cookieFile string, err error) {
+	lines, err := c.command("PROTOCOLINFO", "1")
+	if err != nil {
+		return nil, "", err
+	}
+
+	const authPrefix = "AUTH "
+	authMethods = make(map[string]bool)
+	for _, line := range lines {
+		if !strings.HasPrefix(line, authPrefix) {
+			continue
+		}
+		arguments, err := parseKeywordArguments(line[len(authPrefix):])
+		if err != nil {
+			return nil, "", err
+		}
+		for _, method := range strings.Split(arguments["METHODS"], ",") {
+			authMethods[method] = true
+		}
+		cookieFile = arguments["COOKIEFILE"]
+	}
+	return authMethods, cookieFile, nil
+}
+
+// authenticateSafeCookie proves that this node can read the authentication
+// cookie, after making sure that the control port can read it as well
+func (c *controlConn) authenticateSafeCookie(cookieFile string) error {
+	cookie, err := readCookie(cookieFile)
+	if err != nil {
+		return err
+	}
+	clientNonce := make([]byte, safeCookieNonceSize)
+	_, err = rand.Read(clientNonce)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lines, err := c.command("AUTHCHALLENGE", "SAFECOOKIE", hex.EncodeToString(clientNonce))
+	if err != nil {
+		return err
+	}
+	const authChallengePrefix = "AUTHCHALLENGE "
+	if !strings.HasPrefix(lines[0], authChallengePrefix) {
+		return errors.Errorf("unexpected AUTHCHALLENGE reply %s", lines[0])
+	}
+	arguments, err := parseKeywordArguments(lines[0][len(authChallengePrefix):])
+	if err != nil {
+		return err
+	}
+	serverHash, err := hex.DecodeString(arguments["SERVERHASH"])
+	if err != nil {
+		return errors.Wrap(err, "malformed SERVERHASH")
+	}
+	serverNonce, err := hex.DecodeString(arguments["SERVERNONCE"])
+	if err != nil {
+		return errors.Wrap(err, "malformed SERVERNONCE")
+	}
+
+	message := make([]byte, 0, len(cookie)+len(clientNonce)+len(serverNonce))
+	message = append(message, cookie...)
+	message = append(message, clientNonce...)
+	message = append(message, serverNonce...)
+	if !hmac.Equal(serverHash, safeCookieHash(safeCookieServerToControllerKey, message)) {
+		return errors.New("the Tor control port failed to prove it knows the authentication cookie")
+	}
+
+	clientHash := safeCookieHash(safeCookieControllerToServerKey, message)
+	_, err = c.command("AUTHENTICATE", hex.EncodeToString(clientHash))
+	return err
+}
+
+func safeCookieHash(key string, message []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+func readCookie(cookieFile string) ([]byte, error) {
+	if cookieFile == "" {
+		return nil, errors.New("the Tor control port didn't report where its authentication cookie is")
+	}
+	cookie, err := ioutil.ReadFile(cookieFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading the Tor authentication cookie")
+	}
+	if len(cookie) != cookieSize {
+		return nil, errors.Errorf("unexpected Tor authentication cookie length %d", len(cookie))
+	}
+	return cookie, nil
+}
+
+// addOnion adds an onion service that forwards virtualPort to target, and
+// lives as long as this connection is open. privateKey is the key of the
+// service, or newOnionServiceKey to generate a new one. The ID of the service,
+// which is its .onion host name without the suffix, and its private key are
+// returned.
+func (c *controlConn) addOnion(privateKey string, virtualPort string, target string) (
+	serviceID string, servicePrivateKey string, err error) {
+
+	lines, err := c.command("ADD_ONION", privateKey, "Port="+virtualPort+","+target)
+	if err != nil {
+		return "", "", err
+	}
+
+	servicePrivateKey = privateKey
+	for _, line := range lines {
+		arguments, err := parseKeywordArguments(line)
+		if err != nil {
+			continue
+		}
+		if id, ok := arguments["ServiceID"]; ok {
+			serviceID = id
+		}
+		if key, ok := arguments["PrivateKey"]; ok {
+			servicePrivateKey = key
+		}
+	}
+	if serviceID == "" {
+		return "", "", errors.New("the ADD_ONION reply has no ServiceID")
+	}
+	if servicePrivateKey == newOnionServiceKey {
+		return "", "", errors.New("the ADD_ONION reply has no PrivateKey")
+	}
+	return serviceID, servicePrivateKey, nil
+}
+
+// parseKeywordArguments parses space separated KEYWORD=VALUE arguments,
+// whose values may be quoted strings
+func parseKeywordArguments(text string) (map[string]string, error) {
+	arguments := make(map[string]string)
+	for text = strings.TrimLeft(text, " "); text != ""; text = strings.TrimLeft(text, " ") {
+		equalsIndex := strings.IndexByte(text, '=')
+		if equalsIndex <= 0 {
+			return nil, errors.Errorf("malformed argument %s", text)
+		}
+		keyword := text[:equalsIndex]
+		text = text[equalsIndex+1:]
+
+		var value string
+		if strings.HasPrefix(text, `"`) {
+			var err error
+			value, text, err = unquote(text)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			spaceIndex := strings.IndexByte(text, ' ')
+			if spaceIndex < 0 {
+				spaceIndex = len(text)
+			}
+			value, text = text[:spaceIndex], text[spaceIndex:]
+		}
+		arguments[keyword] = value
+	}
+	return arguments, nil
+}
+
+// quote encodes text as a quoted string, escaping backslashes and quotes
+func quote(text string) string {
+	escaped := strings.ReplaceAll(text, `\`, `\\`)
+	escaped = strings.ReplaceAll(escaped, `"`, `\"`)
+	return `"` + escaped + `"`
+}
+
+// unquote decodes the quoted string at the start of text, and returns it
+// alongside the rest of text
+func unquote(text string) (value string, rest string, err error) {
+	unquoted := &strings.Builder{}
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '"':
+			return unquoted.String(), text[i+1:], nil
+		case '\\':
+			i++
+			if i == len(text) {
+				break
+			}
+			unquoted.WriteByte(text[i])
+		default:
+			unquoted.WriteByte(text[i])
+		}
+	}
+	return "", "", errors.Errorf("unterminated quoted string %s", text)
+}
@@ -0,0 +1,275 @@
+package onionservice
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+)
+
+// fakeTorControlPort is an in-process Tor control port. Like Tor, it removes
+// the onion services added over a control connection once it's closed.
+type fakeTorControlPort struct {
+	listener net.Listener
+
+	authMethods string
+	password    string
+	cookieFile  string
+	cookie      []byte
+
+	mutex sync.Mutex
+	// services maps the IDs of the onion services to their Port argument
+	services map[string]string
+}
+
+func newFakeTorControlPort(t *testing.T, authMethods string) *fakeTorControlPort {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %s", err)
+	}
+	cookie := make([]byte, cookieSize)
+	for i := range cookie {
+		cookie[i] = byte(i)
+	}
+	cookieDir, err := ioutil.TempDir("", "fakeTorControlPort")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	// The quote and backslash check that the path is unquoted
+	cookieFile := filepath.Join(cookieDir, `control "auth" \ cookie`)
+	err = ioutil.WriteFile(cookieFile, cookie, 0600)
+	if err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+
+	controlPort := &fakeTorControlPort{
+		listener:    listener,
+		authMethods: authMethods,
+		cookieFile:  cookieFile,
+		cookie:      cookie,
+		services:    make(map[string]string),
+	}
+	go controlPort.acceptConnections()
+	return controlPort
+}
+
+func (p *fakeTorControlPort) address() string {
+	return p.listener.Addr().String()
+}
+
+func (p *fakeTorControlPort) close() {
+	p.listener.Close()
+	os.RemoveAll(filepath.Dir(p.cookieFile))
+}
+
+func (p *fakeTorControlPort) service(serviceID string) (port string, ok bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	port, ok = p.services[serviceID]
+	return port, ok
+}
+
+func (p *fakeTorControlPort) acceptConnections() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		go p.handleConnection(textproto.NewConn(conn))
+	}
+}
+
+func (p *fakeTorControlPort) handleConnection(conn *textproto.Conn) {
+	var addedServices []string
+	defer func() {
+		conn.Close()
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		for _, serviceID := range addedServices {
+			delete(p.services, serviceID)
+		}
+	}()
+
+	isAuthenticated := false
+	var safeCookieMessage []byte
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		fields := strings.SplitN(line, " ", 2)
+		command := fields[0]
+		argument := ""
+		if len(fields) > 1 {
+			argument = fields[1]
+		}
+
+		switch {
+		case command == "PROTOCOLINFO":
+			conn.PrintfLine("250-PROTOCOLINFO 1")
+			conn.PrintfLine("250-AUTH METHODS=%s COOKIEFILE=%s", p.authMethods, quote(p.cookieFile))
+			conn.PrintfLine(`250-VERSION Tor="0.4.5.7"`)
+			conn.PrintfLine("250 OK")
+		case command == "AUTHCHALLENGE":
+			clientNonce, _ := hex.DecodeString(strings.TrimPrefix(argument, "SAFECOOKIE "))
+			serverNonce := make([]byte, safeCookieNonceSize)
+			safeCookieMessage = append(append(append([]byte{}, p.cookie...), clientNonce...), serverNonce...)
+			serverHash := safeCookieHash(safeCookieServerToControllerKey, safeCookieMessage)
+			conn.PrintfLine("250 AUTHCHALLENGE SERVERHASH=%x SERVERNONCE=%x", serverHash, serverNonce)
+		case command == "AUTHENTICATE":
+			if !p.isAuthenticationValid(argument, safeCookieMessage) {
+				conn.PrintfLine("515 Authentication failed")
+				return
+			}
+			isAuthenticated = true
+			conn.PrintfLine("250 OK")
+		case !isAuthenticated:
+			conn.PrintfLine("514 Authentication required.")
+			return
+		case command == "ADD_ONION":
+			serviceID, privateKey, ok := p.addOnion(argument)
+			if !ok {
+				conn.PrintfLine("512 Invalid argument")
+				continue
+			}
+			addedServices = append(addedServices, serviceID)
+			conn.PrintfLine("250-ServiceID=%s", serviceID)
+			if privateKey != "" {
+				conn.PrintfLine("250-PrivateKey=%s", privateKey)
+			}
+			conn.PrintfLine("250 OK")
+		default:
+			conn.PrintfLine(`510 Unrecognized command "%s"`, command)
+		}
+	}
+}
+
+func (p *fakeTorControlPort) isAuthenticationValid(argument string, safeCookieMessage []byte) bool {
+	switch p.authMethods {
+	case "NULL":
+		return true
+	case "HASHEDPASSWORD":
+		password, _, err := unquote(argument)
+		return err == nil && password == p.password
+	case "COOKIE":
+		return argument == hex.EncodeToString(p.cookie)
+	case "COOKIE,SAFECOOKIE":
+		clientHash, err := hex.DecodeString(argument)
+		return err == nil && safeCookieMessage != nil &&
+			hmac.Equal(clientHash, safeCookieHash(safeCookieControllerToServerKey, safeCookieMessage))
+	}
+	return false
+}
+
+// addOnion adds the onion service described by the given ADD_ONION
+// arguments, and returns its ID, and its private key if it's new
+func (p *fakeTorControlPort) addOnion(argument string) (serviceID string, newPrivateKey string, ok bool) {
+	fields := strings.Fields(argument)
+	if len(fields) != 2 || !strings.HasPrefix(fields[1], "Port=") {
+		return "", "", false
+	}
+
+	var privateKey ed25519.PrivateKey
+	if fields[0] == newOnionServiceKey {
+		var err error
+		_, privateKey, err = ed25519.GenerateKey(nil)
+		if err != nil {
+			return "", "", false
+		}
+		newPrivateKey = "ED25519-V3:" + base64.StdEncoding.EncodeToString(privateKey)
+	} else {
+		encodedKey := strings.TrimPrefix(fields[0], "ED25519-V3:")
+		decodedKey, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil || len(decodedKey) != ed25519.PrivateKeySize {
+			return "", "", false
+		}
+		privateKey = decodedKey
+	}
+
+	host := appmessage.OnionHost(privateKey.Public().(ed25519.PublicKey))
+	serviceID = strings.TrimSuffix(host, ".onion")
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.services[serviceID] = strings.TrimPrefix(fields[1], "Port=")
+	return serviceID, newPrivateKey, true
+}
+
+func TestControlPortAuthentication(t *testing.T) {
+	const password = `pass "word" \`
+	tests := []struct {
+		authMethods   string
+		password      string
+		expectSuccess bool
+	}{
+		{authMethods: "NULL", expectSuccess: true},
+		{authMethods: "HASHEDPASSWORD", password: password, expectSuccess: true},
+		{authMethods: "HASHEDPASSWORD", password: "wrong", expectSuccess: false},
+		{authMethods: "HASHEDPASSWORD", expectSuccess: false},
+		{authMethods: "COOKIE", expectSuccess: true},
+		{authMethods: "COOKIE,SAFECOOKIE", expectSuccess: true},
+		{authMethods: "NULL", password: password, expectSuccess: false},
+	}
+	for _, test := range tests {
+		name := fmt.Sprintf("%s with password %q", test.authMethods, test.password)
+		controlPort := newFakeTorControlPort(t, test.authMethods)
+		controlPort.password = password
+
+		conn, err := dialControlPort(controlPort.address(), test.password)
+		if !test.expectSuccess {
+			if err == nil {
+				t.Errorf("%s: expected authentication to fail", name)
+				conn.close()
+			}
+			controlPort.close()
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: dialControlPort: %+v", name, err)
+			controlPort.close()
+			continue
+		}
+
+		serviceID, privateKey, err := conn.addOnion(newOnionServiceKey, "16111", "127.0.0.1:16111")
+		if err != nil {
+			t.Errorf("%s: addOnion: %+v", name, err)
+		} else if port, ok := controlPort.service(serviceID); !ok || port != "16111,127.0.0.1:16111" {
+			t.Errorf("%s: expected service %s to forward 16111 to 127.0.0.1:16111, got %t, %s",
+				name, serviceID, ok, port)
+		} else if !strings.HasPrefix(privateKey, "ED25519-V3:") {
+			t.Errorf("%s: unexpected private key %s", name, privateKey)
+		}
+		conn.close()
+		controlPort.close()
+	}
+}
+
+func TestParseKeywordArguments(t *testing.T) {
+	arguments, err := parseKeywordArguments(`METHODS=COOKIE,SAFECOOKIE COOKIEFILE="C:\\Tor\\control \"auth\" cookie"`)
+	if err != nil {
+		t.Fatalf("parseKeywordArguments: %+v", err)
+	}
+	if arguments["METHODS"] != "COOKIE,SAFECOOKIE" {
+		t.Errorf("unexpected METHODS %s", arguments["METHODS"])
+	}
+	if arguments["COOKIEFILE"] != `C:\Tor\control "auth" cookie` {
+		t.Errorf("unexpected COOKIEFILE %s", arguments["COOKIEFILE"])
+	}
+
+	_, err = parseKeywordArguments(`COOKIEFILE="unterminated`)
+	if err == nil {
+		t.Errorf("expected an unterminated quoted string to be rejected")
+	}
+}
@@ -0,0 +1,189 @@
+package onionservice
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/infrastructure/config"
+	"github.com/kaspanet/kaspad/infrastructure/network/addressmanager"
+	"github.com/pkg/errors"
+)
+
+const (
+	// privateKeyFilename is the name of the file in the data directory
+	// that holds the key of the onion service, so that its address stays
+	// the same across restarts
+	privateKeyFilename = "onion_v3_private_key"
+
+	// retryInterval is how long to wait before publishing the onion
+	// service again, after Tor could not be reached or it closed the
+	// control connection
+	retryInterval = time.Minute
+)
+
+// Manager publishes the P2P listening port of this node as a Tor onion
+// service through the Tor control port, and registers the address of the
+// service with the AddressManager, so that it's advertised to peers.
+// Tor removes the service once the control connection is closed.
+type Manager struct {
+	addressManager *addressmanager.AddressManager
+	controlAddress string
+	password       string
+	privateKeyPath string
+
+	// virtualPort is the port of the onion service, which Tor
+	// forwards to target, the listener that accepts only the
+	// connections of the onion service
+	virtualPort uint16
+	target      string
+
+	stop      chan struct{}
+	waitGroup sync.WaitGroup
+}
+
+// New returns a new Manager that publishes the port of the first P2P
+// listener, and has Tor forward it to the onion service listener
+func New(cfg *config.Config, addressManager *addressmanager.AddressManager) (*Manager, error) {
+	if len(cfg.Listeners) == 0 {
+		return nil, errors.New("cannot publish an onion service without listeners")
+	}
+	if cfg.OnionListen == "" {
+		return nil, errors.New("cannot publish an onion service without an onion service listener")
+	}
+	_, portString, err := net.SplitHostPort(cfg.Listeners[0])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	listenPort, err := strconv.ParseUint(portString, 10, 16)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid listen port %s", portString)
+	}
+
+	return &Manager{
+		addressManager: addressManager,
+		controlAddress: cfg.TorControl,
+		password:       cfg.TorPassword,
+		privateKeyPath: filepath.Join(cfg.DataDir, privateKeyFilename),
+		virtualPort:    uint16(listenPort),
+		target:         cfg.OnionListen,
+		stop:           make(chan struct{}),
+	}, nil
+}
+
+// Start publishes the onion service in the background
+func (m *Manager) Start() {
+	m.waitGroup.Add(1)
+	spawn("onionservice.Manager.run", func() {
+		defer m.waitGroup.Done()
+		m.run()
+	})
+}
+
+// Stop closes the control connection, which removes the onion service
+func (m *Manager) Stop() {
+	close(m.stop)
+	m.waitGroup.Wait()
+}
+
+func (m *Manager) run() {
+	for {
+		conn, err := m.publish()
+		if err != nil {
+			log.Warnf("Error publishing the onion service: %s", err)
+		} else {
+			closed := make(chan struct{})
+			spawn("onionservice.Manager.run-waitForClose", func() {
+				conn.waitForClose()
+				close(closed)
+			})
+
+			select {
+			case <-closed:
+				log.Warnf("Tor closed the control connection, so the onion service is no longer published")
+			case <-m.stop:
+				conn.close()
+				<-closed
+				log.Infof("Removed the onion service")
+				return
+			}
+		}
+
+		select {
+		case <-time.After(retryInterval):
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// publish adds the onion service, and returns the control connection it
+// lives on
+func (m *Manager) publish() (*controlConn, error) {
+	conn, err := dialControlPort(m.controlAddress, m.password)
+	if err != nil {
+		return nil, err
+	}
+	isPublished := false
+	defer func() {
+		if !isPublished {
+			conn.close()
+		}
+	}()
+
+	privateKey, err := m.loadPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	serviceID, newPrivateKey, err := conn.addOnion(privateKey, strconv.Itoa(int(m.virtualPort)), m.target)
+	if err != nil {
+		return nil, err
+	}
+	if newPrivateKey != privateKey {
+		err := m.savePrivateKey(newPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	onionPublicKey, err := appmessage.ParseOnionHost(serviceID + ".onion")
+	if err != nil {
+		return nil, err
+	}
+	netAddress := appmessage.NewNetAddressOnion(onionPublicKey, m.virtualPort, appmessage.DefaultServices)
+	err = m.addressManager.AddLocalAddress(netAddress, addressmanager.ManualPrio)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Published onion service %s, forwarding to %s", netAddress, m.target)
+
+	isPublished = true
+	return conn, nil
+}
+
+// loadPrivateKey returns the key of the onion service this node published
+// before, or newOnionServiceKey if there's none
+func (m *Manager) loadPrivateKey() (string, error) {
+	privateKey, err := ioutil.ReadFile(m.privateKeyPath)
+	if os.IsNotExist(err) {
+		return newOnionServiceKey, nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "error reading the onion service key")
+	}
+	return strings.TrimSpace(string(privateKey)), nil
+}
+
+func (m *Manager) savePrivateKey(privateKey string) error {
+	err := ioutil.WriteFile(m.privateKeyPath, []byte(privateKey), 0600)
+	if err != nil {
+		return errors.Wrap(err, "error saving the onion service key")
+	}
+	return nil
+}
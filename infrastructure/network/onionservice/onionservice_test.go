@@ -0,0 +1,98 @@
+package onionservice
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/app/appmessage"
+	"github.com/kaspanet/kaspad/infrastructure/config"
+	"github.com/kaspanet/kaspad/infrastructure/db/database/ldb"
+	"github.com/kaspanet/kaspad/infrastructure/network/addressmanager"
+)
+
+func TestManager(t *testing.T) {
+	datadir, err := ioutil.TempDir("", "TestManager")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(datadir)
+	database, err := ldb.NewLevelDB(filepath.Join(datadir, "db"))
+	if err != nil {
+		t.Fatalf("NewLevelDB: %s", err)
+	}
+	defer database.Close()
+	addressManager, err := addressmanager.New(addressmanager.NewConfig(config.DefaultConfig()), database)
+	if err != nil {
+		t.Fatalf("addressmanager.New: %s", err)
+	}
+
+	controlPort := newFakeTorControlPort(t, "NULL")
+	defer controlPort.close()
+
+	cfg := config.DefaultConfig()
+	cfg.DataDir = datadir
+	cfg.Listeners = []string{"0.0.0.0:16111"}
+	cfg.TorControl = controlPort.address()
+	cfg.OnionListen = "127.0.0.1:16112"
+
+	waitFor := func(condition func() bool, failureMessage string) {
+		deadline := time.Now().Add(10 * time.Second)
+		for !condition() {
+			if time.Now().After(deadline) {
+				t.Fatalf(failureMessage)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	manager, err := New(cfg, addressManager)
+	if err != nil {
+		t.Fatalf("New: %+v", err)
+	}
+	manager.Start()
+
+	// The onion service is advertised to peers on Tor once it's published
+	remoteAddress := appmessage.NewNetAddressOnion(make([]byte, 32), 16111, appmessage.DefaultServices)
+	waitFor(func() bool { return addressManager.BestLocalAddress(remoteAddress).IsOnion() },
+		"expected the onion service to be advertised")
+	bestAddress := addressManager.BestLocalAddress(remoteAddress)
+	if bestAddress.Port != 16111 {
+		t.Fatalf("expected port 16111 to be advertised, got %d", bestAddress.Port)
+	}
+	serviceID := strings.TrimSuffix(bestAddress.Host(), ".onion")
+	port, ok := controlPort.service(serviceID)
+	if !ok || port != "16111,127.0.0.1:16112" {
+		t.Fatalf("expected the onion service to forward to the onion service listener, got %t, %s", ok, port)
+	}
+	privateKey, err := ioutil.ReadFile(filepath.Join(datadir, privateKeyFilename))
+	if err != nil {
+		t.Fatalf("expected the key of the onion service to be saved: %s", err)
+	}
+
+	isPublished := func() bool {
+		_, ok := controlPort.service(serviceID)
+		return ok
+	}
+	manager.Stop()
+	waitFor(func() bool { return !isPublished() }, "expected the onion service to be removed on shutdown")
+
+	// The onion service keeps its address after a restart
+	manager, err = New(cfg, addressManager)
+	if err != nil {
+		t.Fatalf("New: %+v", err)
+	}
+	manager.Start()
+	defer manager.Stop()
+	waitFor(isPublished, "expected the onion service to be published again at the same address")
+	privateKeyAfterRestart, err := ioutil.ReadFile(filepath.Join(datadir, privateKeyFilename))
+	if err != nil {
+		t.Fatalf("ReadFile: %s", err)
+	}
+	if string(privateKeyAfterRestart) != string(privateKey) {
+		t.Fatalf("expected the key of the onion service not to change")
+	}
+}
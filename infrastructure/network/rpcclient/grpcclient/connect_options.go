@@ -0,0 +1,71 @@
+package grpcclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+
+	"github.com/kaspanet/kaspad/infrastructure/network/netadapter/server/grpcserver"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// ConnectOptions defines how to connect and authenticate to the RPC server.
+// It's meant to be embedded in the command-line flags of RPC clients.
+type ConnectOptions struct {
+	DisableTLS   bool   `long:"notls" description:"Disable TLS when connecting to the RPC server"`
+	RPCCert      string `long:"rpccert" description:"RPC server certificate chain for validation (default: kaspad's auto-generated certificate if it exists, otherwise the system's root certificates)"`
+	RPCUser      string `long:"rpcuser" description:"RPC username"`
+	RPCPassword  string `long:"rpcpass" default-mask:"-" description:"RPC password"`
+	RPCAuthToken string `long:"rpcauthtoken" default-mask:"-" description:"RPC authentication token, used instead of --rpcuser/--rpcpass"`
+
+	// DefaultRPCCert is the certificate chain to validate the RPC server
+	// against when RPCCert isn't set, if it exists. Clients usually set it
+	// to the certificate that kaspad generates by default.
+	DefaultRPCCert string `no-flag:"true"`
+}
+
+func (o *ConnectOptions) dialOptions() ([]grpc.DialOption, error) {
+	if o.DisableTLS {
+		return []grpc.DialOption{grpc.WithInsecure()}, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	certFile := o.RPCCert
+	if certFile == "" && o.DefaultRPCCert != "" {
+		if _, err := os.Stat(o.DefaultRPCCert); err == nil {
+			certFile = o.DefaultRPCCert
+		}
+	}
+	if certFile != "" {
+		cert, err := ioutil.ReadFile(certFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading the RPC certificate")
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(cert) {
+			return nil, errors.Errorf("no valid certificates found in %s", certFile)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+	return []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}, nil
+}
+
+// streamContext returns the context with which to open the message
+// stream, carrying the authentication credentials if there are any
+func (o *ConnectOptions) streamContext() context.Context {
+	ctx := context.Background()
+	if o.RPCAuthToken != "" {
+		return metadata.AppendToOutgoingContext(ctx, grpcserver.AuthorizationMetadataKey,
+			grpcserver.BearerAuthorization(o.RPCAuthToken))
+	}
+	if o.RPCUser != "" {
+		return metadata.AppendToOutgoingContext(ctx, grpcserver.AuthorizationMetadataKey,
+			grpcserver.BasicAuthorization(o.RPCUser, o.RPCPassword))
+	}
+	return ctx
+}
@@ -23,18 +23,29 @@ type GRPCClient struct {
 }
 
 // Connect connects to the RPC server with the given address
-func Connect(address string) (*GRPCClient, error) {
+// using the given connection options. If options is nil, the
+// connection is neither encrypted nor authenticated.
+func Connect(address string, options *ConnectOptions) (*GRPCClient, error) {
+	if options == nil {
+		options = &ConnectOptions{DisableTLS: true}
+	}
+
 	const dialTimeout = 5 * time.Second
 	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
 	defer cancel()
 
-	gRPCConnection, err := grpc.DialContext(ctx, address, grpc.WithInsecure(), grpc.WithBlock())
+	dialOptions, err := options.dialOptions()
+	if err != nil {
+		return nil, err
+	}
+	dialOptions = append(dialOptions, grpc.WithBlock())
+	gRPCConnection, err := grpc.DialContext(ctx, address, dialOptions...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error connecting to %s", address)
 	}
 
 	grpcClient := protowire.NewRPCClient(gRPCConnection)
-	stream, err := grpcClient.MessageStream(context.Background(), grpc.UseCompressor(gzip.Name),
+	stream, err := grpcClient.MessageStream(options.streamContext(), grpc.UseCompressor(gzip.Name),
 		grpc.MaxCallRecvMsgSize(grpcserver.RPCMaxMessageSize), grpc.MaxCallSendMsgSize(grpcserver.RPCMaxMessageSize))
 	if err != nil {
 		return nil, errors.Wrapf(err, "error getting client stream for %s", address)
@@ -0,0 +1,20 @@
+package rpcclient
+
+import "github.com/kaspanet/kaspad/app/appmessage"
+
+// GetUTXOCacheStats sends an RPC request respective to the function's name and returns the RPC server's response
+func (c *RPCClient) GetUTXOCacheStats() (*appmessage.GetUTXOCacheStatsResponseMessage, error) {
+	err := c.rpcRouter.outgoingRoute().Enqueue(appmessage.NewGetUTXOCacheStatsRequestMessage())
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.route(appmessage.CmdGetUTXOCacheStatsResponseMessage).DequeueWithTimeout(c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	getUTXOCacheStatsResponse := response.(*appmessage.GetUTXOCacheStatsResponseMessage)
+	if getUTXOCacheStatsResponse.Error != nil {
+		return nil, c.convertRPCError(getUTXOCacheStatsResponse.Error)
+	}
+	return getUTXOCacheStatsResponse, nil
+}
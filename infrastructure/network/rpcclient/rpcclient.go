@@ -22,9 +22,10 @@ type RPCClient struct {
 	timeout time.Duration
 }
 
-// NewRPCClient creates a new RPC client
-func NewRPCClient(rpcAddress string) (*RPCClient, error) {
-	rpcClient, err := grpcclient.Connect(rpcAddress)
+// NewRPCClient creates a new RPC client that connects to
+// rpcAddress using the given connection options
+func NewRPCClient(rpcAddress string, options *grpcclient.ConnectOptions) (*RPCClient, error) {
+	rpcClient, err := grpcclient.Connect(rpcAddress, options)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error connecting to address %s", rpcAddress)
 	}
@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// JSONFileExporter is an Exporter that appends spans to a file, one JSON
+// object per line, for offline analysis. The field names follow the ones
+// of OpenTelemetry spans.
+type JSONFileExporter struct {
+	lock sync.Mutex
+	file *os.File
+}
+
+type jsonSpan struct {
+	TraceID           string                 `json:"traceId"`
+	SpanID            string                 `json:"spanId"`
+	ParentSpanID      string                 `json:"parentSpanId,omitempty"`
+	Name              string                 `json:"name"`
+	StartTimeUnixNano int64                  `json:"startTimeUnixNano"`
+	EndTimeUnixNano   int64                  `json:"endTimeUnixNano"`
+	Attributes        map[string]interface{} `json:"attributes,omitempty"`
+	Status            jsonStatus             `json:"status"`
+}
+
+type jsonStatus struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// NewJSONFileExporter returns a JSONFileExporter that appends to the file
+// at the given path, creating it if it doesn't exist
+func NewJSONFileExporter(path string) (*JSONFileExporter, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &JSONFileExporter{file: file}, nil
+}
+
+// ExportTrace implements Exporter
+func (e *JSONFileExporter) ExportTrace(spans []*SpanData) error {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	for _, span := range spans {
+		spanToEncode := &jsonSpan{
+			TraceID:           span.TraceID.String(),
+			SpanID:            span.SpanID.String(),
+			Name:              span.Name,
+			StartTimeUnixNano: span.Start.UnixNano(),
+			EndTimeUnixNano:   span.End.UnixNano(),
+			Attributes:        span.Attributes,
+			Status:            jsonStatus{Code: "OK"},
+		}
+		if !span.ParentSpanID.IsZero() {
+			spanToEncode.ParentSpanID = span.ParentSpanID.String()
+		}
+		if span.Error != "" {
+			spanToEncode.Status = jsonStatus{Code: "ERROR", Message: span.Error}
+		}
+		err := encoder.Encode(spanToEncode)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	// The whole trace is written at once so that traces of different
+	// tracers that share the file don't interleave
+	_, err := e.file.Write(buffer.Bytes())
+	return errors.WithStack(err)
+}
+
+// Close implements Exporter
+func (e *JSONFileExporter) Close() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return errors.WithStack(e.file.Close())
+}
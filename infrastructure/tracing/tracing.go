@@ -0,0 +1,241 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// TraceID identifies all the spans of a single trace
+type TraceID [16]byte
+
+// NewTraceID returns the TraceID made of the first bytes of the given
+// bytes, so that a trace may be correlated with the hash of the object it
+// traces
+func NewTraceID(bytes []byte) TraceID {
+	var traceID TraceID
+	copy(traceID[:], bytes)
+	return traceID
+}
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID identifies a span within its trace
+type SpanID [8]byte
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsZero returns whether this is the zero SpanID, which is the parent
+// of the root span of a trace
+func (id SpanID) IsZero() bool {
+	return id == SpanID{}
+}
+
+// SpanData is everything that was recorded about a span once it had ended
+type SpanData struct {
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Name         string
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]interface{}
+	Error        string
+}
+
+// Exporter sends the spans of finished traces somewhere they can be
+// analyzed
+type Exporter interface {
+	// ExportTrace is called once the root span of a trace ends, with all
+	// the spans of the trace in the order in which they ended. It's called
+	// without the lock of the tracer held, so the traces of a tracer may be
+	// exported concurrently.
+	ExportTrace(spans []*SpanData) error
+	Close() error
+}
+
+// Tracer records a trace of spans at a time. Spans that are started while
+// another span is open become its children, which makes a Tracer suitable
+// for tracing work that is done by a single goroutine at a time, such as
+// the processing of a block by consensus.
+//
+// All the methods of Tracer and of Span may be called on nil, in which case
+// they do nothing, so that code that is traced needs no checks of whether
+// tracing is enabled.
+type Tracer struct {
+	exporter Exporter
+
+	lock        sync.Mutex
+	random      *rand.Rand
+	openSpans   []*Span
+	endedSpans  []*SpanData
+	activeTrace *TraceID
+}
+
+// New returns a Tracer that exports its traces to the given exporter
+func New(exporter Exporter) *Tracer {
+	return &Tracer{
+		exporter: exporter,
+		random:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Span is a single timed operation within a trace
+type Span struct {
+	tracer *Tracer
+	data   *SpanData
+}
+
+// StartTrace starts a new trace with the given ID, and returns its root span.
+// The trace is exported once the root span ends. A trace that is started
+// while another one is active is recorded as part of the active one.
+func (t *Tracer) StartTrace(name string, traceID TraceID) *Span {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.activeTrace == nil {
+		t.activeTrace = &traceID
+	}
+	return t.startSpan(name)
+}
+
+// StartSpan starts a span that is a child of the innermost open span. If no
+// trace is active StartSpan returns a nil Span, which records nothing.
+func (t *Tracer) StartSpan(name string) *Span {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.activeTrace == nil {
+		return nil
+	}
+	return t.startSpan(name)
+}
+
+func (t *Tracer) startSpan(name string) *Span {
+	var spanID SpanID
+	t.random.Read(spanID[:])
+
+	var parentSpanID SpanID
+	if len(t.openSpans) > 0 {
+		parentSpanID = t.openSpans[len(t.openSpans)-1].data.SpanID
+	}
+
+	span := &Span{
+		tracer: t,
+		data: &SpanData{
+			TraceID:      *t.activeTrace,
+			SpanID:       spanID,
+			ParentSpanID: parentSpanID,
+			Name:         name,
+			Start:        time.Now(),
+		},
+	}
+	t.openSpans = append(t.openSpans, span)
+	return span
+}
+
+// SetAttribute records a key-value pair that describes the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+
+	s.tracer.lock.Lock()
+	defer s.tracer.lock.Unlock()
+
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]interface{})
+	}
+	s.data.Attributes[key] = value
+}
+
+// SetError records that the operation of the span failed with the given
+// error. SetError does nothing if err is nil.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.tracer.lock.Lock()
+	defer s.tracer.lock.Unlock()
+
+	s.data.Error = err.Error()
+}
+
+// End records the time the span ended. Ending the root span of a trace
+// exports the trace.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	// The trace is exported without holding the lock, so that a slow
+	// exporter doesn't hold up the next trace
+	spans, isTraceEnded := s.tracer.endSpan(s)
+	if !isTraceEnded {
+		return
+	}
+	err := s.tracer.exporter.ExportTrace(spans)
+	if err != nil {
+		log.Warnf("Error exporting trace %s: %s", spans[0].TraceID, err)
+	}
+}
+
+// endSpan records the time the given span ended. If it was the last open
+// span of the trace, endSpan ends the trace, and returns copies of all its
+// spans, which may be used without the lock.
+func (t *Tracer) endSpan(s *Span) (spans []*SpanData, isTraceEnded bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for i := len(t.openSpans) - 1; i >= 0; i-- {
+		if t.openSpans[i] == s {
+			t.openSpans = append(t.openSpans[:i], t.openSpans[i+1:]...)
+			break
+		}
+	}
+	s.data.End = time.Now()
+	t.endedSpans = append(t.endedSpans, s.data)
+
+	if len(t.openSpans) > 0 {
+		return nil, false
+	}
+
+	// Spans may still be changed after they end, so the exporter gets
+	// copies of them
+	spans = make([]*SpanData, len(t.endedSpans))
+	for i, data := range t.endedSpans {
+		dataCopy := *data
+		if data.Attributes != nil {
+			dataCopy.Attributes = make(map[string]interface{}, len(data.Attributes))
+			for key, value := range data.Attributes {
+				dataCopy.Attributes[key] = value
+			}
+		}
+		spans[i] = &dataCopy
+	}
+	t.endedSpans = nil
+	t.activeTrace = nil
+	return spans, true
+}
+
+// Close closes the exporter of the tracer
+func (t *Tracer) Close() error {
+	if t == nil {
+		return nil
+	}
+	return t.exporter.Close()
+}
@@ -0,0 +1,210 @@
+package tracing
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type testExporter struct {
+	traces [][]*SpanData
+}
+
+func (e *testExporter) ExportTrace(spans []*SpanData) error {
+	e.traces = append(e.traces, spans)
+	return nil
+}
+
+func (e *testExporter) Close() error {
+	return nil
+}
+
+func TestTracer(t *testing.T) {
+	exporter := &testExporter{}
+	tracer := New(exporter)
+
+	// Spans outside of a trace aren't recorded
+	tracer.StartSpan("outside").End()
+	if len(exporter.traces) != 0 {
+		t.Fatalf("expected no traces to be exported, got %d", len(exporter.traces))
+	}
+
+	traceID := NewTraceID([]byte{1, 2, 3})
+	root := tracer.StartTrace("root", traceID)
+	root.SetAttribute("key", "value")
+	child := tracer.StartSpan("child")
+	grandchild := tracer.StartSpan("grandchild")
+	grandchild.SetError(errors.New("grandchild failed"))
+	grandchild.End()
+	child.End()
+	secondChild := tracer.StartSpan("secondChild")
+	secondChild.End()
+	if len(exporter.traces) != 0 {
+		t.Fatalf("expected the trace not to be exported before the root span ends")
+	}
+	root.End()
+
+	if len(exporter.traces) != 1 {
+		t.Fatalf("expected 1 trace to be exported, got %d", len(exporter.traces))
+	}
+	spans := exporter.traces[0]
+	spansByName := make(map[string]*SpanData)
+	for _, span := range spans {
+		if span.TraceID != traceID {
+			t.Errorf("span %s has trace ID %s, expected %s", span.Name, span.TraceID, traceID)
+		}
+		if span.End.Before(span.Start) {
+			t.Errorf("span %s ended before it started", span.Name)
+		}
+		spansByName[span.Name] = span
+	}
+	if len(spansByName) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+
+	expectedParents := map[string]string{
+		"child":       "root",
+		"grandchild":  "child",
+		"secondChild": "root",
+	}
+	for name, parentName := range expectedParents {
+		if spansByName[name].ParentSpanID != spansByName[parentName].SpanID {
+			t.Errorf("expected the parent of %s to be %s", name, parentName)
+		}
+	}
+	if !spansByName["root"].ParentSpanID.IsZero() {
+		t.Errorf("expected the root span to have no parent")
+	}
+	if spansByName["root"].Attributes["key"] != "value" {
+		t.Errorf("expected the root span to have its attribute set")
+	}
+	if spansByName["grandchild"].Error != "grandchild failed" || spansByName["child"].Error != "" {
+		t.Errorf("expected only the grandchild span to have an error")
+	}
+
+	// The next trace starts from scratch
+	tracer.StartTrace("another root", NewTraceID([]byte{4, 5, 6})).End()
+	if len(exporter.traces) != 2 || len(exporter.traces[1]) != 1 {
+		t.Fatalf("expected a second trace with a single span to be exported")
+	}
+}
+
+// tracingExporter is an Exporter that traces its own exports with the tracer
+// it exports, which only works if the tracer isn't locked while it exports
+type tracingExporter struct {
+	testExporter
+	tracer     *Tracer
+	isExported bool
+}
+
+func (e *tracingExporter) ExportTrace(spans []*SpanData) error {
+	if !e.isExported {
+		e.isExported = true
+		e.tracer.StartTrace("export", NewTraceID([]byte{7, 8, 9})).End()
+	}
+	return e.testExporter.ExportTrace(spans)
+}
+
+func TestTracerExportsWithoutTheLock(t *testing.T) {
+	exporter := &tracingExporter{}
+	tracer := New(exporter)
+	exporter.tracer = tracer
+
+	exported := make(chan struct{})
+	go func() {
+		tracer.StartTrace("root", NewTraceID([]byte{1, 2, 3})).End()
+		close(exported)
+	}()
+	select {
+	case <-exported:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timed out exporting the trace, which suggests the exporter was called with the lock held")
+	}
+	if len(exporter.traces) != 2 {
+		t.Fatalf("expected 2 traces to be exported, got %d", len(exporter.traces))
+	}
+
+	// Changing a span after it ended doesn't change what was exported
+	root := tracer.StartTrace("root", NewTraceID([]byte{4, 5, 6}))
+	root.SetAttribute("key", "value")
+	root.End()
+	root.SetAttribute("key", "changed")
+	if exporter.traces[2][0].Attributes["key"] != "value" {
+		t.Fatalf("expected the exported span to keep its attribute, got %v", exporter.traces[2][0].Attributes["key"])
+	}
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	span := tracer.StartTrace("root", TraceID{})
+	span.SetAttribute("key", "value")
+	span.SetError(errors.New("error"))
+	tracer.StartSpan("child").End()
+	span.End()
+	err := tracer.Close()
+	if err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+}
+
+func TestJSONFileExporter(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "TestJSONFileExporter")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(tempDir)
+	path := filepath.Join(tempDir, "traces.json")
+
+	exporter, err := NewJSONFileExporter(path)
+	if err != nil {
+		t.Fatalf("NewJSONFileExporter: %+v", err)
+	}
+	tracer := New(exporter)
+	root := tracer.StartTrace("root", NewTraceID([]byte{0xab, 0xcd}))
+	child := tracer.StartSpan("child")
+	child.SetError(errors.New("child failed"))
+	child.End()
+	root.End()
+	err = tracer.Close()
+	if err != nil {
+		t.Fatalf("Close: %+v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	defer file.Close()
+
+	var spans []*jsonSpan
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		span := &jsonSpan{}
+		err := json.Unmarshal(scanner.Bytes(), span)
+		if err != nil {
+			t.Fatalf("Unmarshal: %s", err)
+		}
+		spans = append(spans, span)
+	}
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	childSpan, rootSpan := spans[0], spans[1]
+	const expectedTraceID = "abcd0000000000000000000000000000"
+	if rootSpan.TraceID != expectedTraceID || childSpan.TraceID != expectedTraceID {
+		t.Errorf("expected both spans to have trace ID %s", expectedTraceID)
+	}
+	if rootSpan.ParentSpanID != "" || childSpan.ParentSpanID != rootSpan.SpanID {
+		t.Errorf("expected the child span to be the child of the root span")
+	}
+	if rootSpan.Status.Code != "OK" || childSpan.Status.Code != "ERROR" || childSpan.Status.Message != "child failed" {
+		t.Errorf("unexpected statuses %+v and %+v", rootSpan.Status, childSpan.Status)
+	}
+}
@@ -2,6 +2,7 @@ package integration
 
 import (
 	"io/ioutil"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -27,6 +28,9 @@ const (
 	miningAddress3           = "kaspasim:qz7n8gfak3j2wt9vywy5ljhs3v3xu7lgmutfjqaay5"
 	miningAddress3PrivateKey = "eb0af684f2cdbb4ed2d85fbfe0b7f40654a7777fb2c47f142ffb5543b594d1e4"
 
+	rpcUser = "user"
+	rpcPass = "pass"
+
 	defaultTimeout = 10 * time.Second
 )
 
@@ -35,7 +39,12 @@ func setConfig(t *testing.T, harness *appHarness) {
 	harness.config.DataDir = randomDirectory(t)
 	harness.config.Listeners = []string{harness.p2pAddress}
 	harness.config.RPCListeners = []string{harness.rpcAddress}
+	harness.config.RPCCert = filepath.Join(harness.config.DataDir, "rpc.cert")
+	harness.config.RPCKey = filepath.Join(harness.config.DataDir, "rpc.key")
+	harness.config.RPCUser = rpcUser
+	harness.config.RPCPass = rpcPass
 	harness.config.UTXOIndex = harness.utxoIndex
+	harness.config.TXIndex = harness.txIndex
 
 	if harness.overrideDAGParams != nil {
 		harness.config.ActiveNetParams = harness.overrideDAGParams
@@ -50,6 +59,7 @@ func commonConfig() *config.Config {
 	commonConfig.TargetOutboundPeers = 0
 	commonConfig.DisableDNSSeed = true
 	commonConfig.Simnet = true
+	commonConfig.DbType = config.MemoryDBType
 
 	return commonConfig
 }
@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient/grpcclient"
+)
+
+func TestRPCMaxClients(t *testing.T) {
+	harness, teardown := setupHarness(t, &harnessParams{
+		p2pAddress:              p2pAddress1,
+		rpcAddress:              rpcAddress1,
+		miningAddress:           miningAddress1,
+		miningAddressPrivateKey: miningAddress1PrivateKey,
+	})
+	defer teardown()
+
+	connectOptions := &grpcclient.ConnectOptions{
+		RPCCert:     harness.config.RPCCert,
+		RPCUser:     harness.config.RPCUser,
+		RPCPassword: harness.config.RPCPass,
+	}
+
+	// The harness's own client is the first client
+	var clients []*testRPCClient
+	defer func() {
+		for _, client := range clients {
+			client.Close()
+		}
+	}()
+	for i := 1; i < harness.config.RPCMaxClients; i++ {
+		client, err := newTestRPCClient(harness.rpcAddress, connectOptions)
+		if err != nil {
+			t.Fatalf("Error connecting client %d: %+v", i, err)
+		}
+		clients = append(clients, client)
+
+		_, err = client.GetBlockCount()
+		if err != nil {
+			t.Fatalf("Client %d unexpectedly failed to get the block count: %+v", i, err)
+		}
+	}
+
+	rejectedClient, err := newTestRPCClient(harness.rpcAddress, connectOptions)
+	if err != nil {
+		t.Fatalf("Error connecting the rejected client: %+v", err)
+	}
+	defer rejectedClient.Close()
+
+	_, err = rejectedClient.GetBlockCount()
+	if err == nil {
+		t.Fatalf("Expected a client beyond the maximum of %d clients to be rejected",
+			harness.config.RPCMaxClients)
+	}
+	if !strings.Contains(err.Error(), "maximum of") {
+		t.Fatalf("Expected the rejected client to get an error about the maximum number of clients, "+
+			"but got: %+v", err)
+	}
+
+	// Once a client disconnects, a new client is accepted
+	clients[0].Close()
+	clients = clients[1:]
+	waitForAcceptedClient(t, harness, connectOptions)
+}
+
+func waitForAcceptedClient(t *testing.T, harness *appHarness, connectOptions *grpcclient.ConnectOptions) {
+	var err error
+	for i := 0; i < 10; i++ {
+		var client *testRPCClient
+		client, err = newTestRPCClient(harness.rpcAddress, connectOptions)
+		if err != nil {
+			t.Fatalf("Error connecting a new client: %+v", err)
+		}
+		_, err = client.GetBlockCount()
+		client.Close()
+		if err == nil {
+			return
+		}
+	}
+	t.Fatalf("Expected a new client to be accepted after another client disconnected, but got: %+v", err)
+}
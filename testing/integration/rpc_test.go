@@ -2,6 +2,7 @@ package integration
 
 import (
 	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient/grpcclient"
 	"time"
 )
 
@@ -11,8 +12,8 @@ type testRPCClient struct {
 	*rpcclient.RPCClient
 }
 
-func newTestRPCClient(rpcAddress string) (*testRPCClient, error) {
-	rpcClient, err := rpcclient.NewRPCClient(rpcAddress)
+func newTestRPCClient(rpcAddress string, options *grpcclient.ConnectOptions) (*testRPCClient, error) {
+	rpcClient, err := rpcclient.NewRPCClient(rpcAddress, options)
 	if err != nil {
 		return nil, err
 	}
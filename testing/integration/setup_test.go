@@ -6,11 +6,13 @@ import (
 	"testing"
 
 	"github.com/kaspanet/kaspad/infrastructure/db/database/ldb"
+	"github.com/kaspanet/kaspad/infrastructure/db/database/memorydb"
 
 	"github.com/kaspanet/kaspad/infrastructure/db/database"
 
 	"github.com/kaspanet/kaspad/app"
 	"github.com/kaspanet/kaspad/infrastructure/config"
+	"github.com/kaspanet/kaspad/infrastructure/network/rpcclient/grpcclient"
 )
 
 type appHarness struct {
@@ -23,6 +25,7 @@ type appHarness struct {
 	config                  *config.Config
 	database                database.Database
 	utxoIndex               bool
+	txIndex                 bool
 	overrideDAGParams       *dagconfig.Params
 }
 
@@ -32,6 +35,7 @@ type harnessParams struct {
 	miningAddress           string
 	miningAddressPrivateKey string
 	utxoIndex               bool
+	txIndex                 bool
 	overrideDAGParams       *dagconfig.Params
 }
 
@@ -43,6 +47,7 @@ func setupHarness(t *testing.T, params *harnessParams) (harness *appHarness, tea
 		miningAddress:           params.miningAddress,
 		miningAddressPrivateKey: params.miningAddressPrivateKey,
 		utxoIndex:               params.utxoIndex,
+		txIndex:                 params.txIndex,
 		overrideDAGParams:       params.overrideDAGParams,
 	}
 
@@ -100,7 +105,11 @@ func standardSetup(t *testing.T) (appHarness1, appHarness2, appHarness3 *appHarn
 
 func setRPCClient(t *testing.T, harness *appHarness) {
 	var err error
-	harness.rpcClient, err = newTestRPCClient(harness.rpcAddress)
+	harness.rpcClient, err = newTestRPCClient(harness.rpcAddress, &grpcclient.ConnectOptions{
+		RPCCert:     harness.config.RPCCert,
+		RPCUser:     harness.config.RPCUser,
+		RPCPassword: harness.config.RPCPass,
+	})
 	if err != nil {
 		t.Fatalf("Error getting RPC client %+v", err)
 	}
@@ -133,6 +142,9 @@ func setDatabaseContext(t *testing.T, harness *appHarness) {
 }
 
 func openDB(cfg *config.Config) (database.Database, error) {
+	if cfg.DbType == config.MemoryDBType {
+		return memorydb.NewMemoryDB(), nil
+	}
 	dbPath := filepath.Join(cfg.DataDir, "db")
 	return ldb.NewLevelDB(dbPath)
 }
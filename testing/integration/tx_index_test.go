@@ -0,0 +1,93 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kaspanet/kaspad/domain/consensus/utils/consensushashing"
+)
+
+func TestTXIndex(t *testing.T) {
+	harnessParams := &harnessParams{
+		p2pAddress:              p2pAddress1,
+		rpcAddress:              rpcAddress1,
+		miningAddress:           miningAddress1,
+		miningAddressPrivateKey: miningAddress1PrivateKey,
+		utxoIndex:               true,
+		txIndex:                 true,
+	}
+	kaspad, teardown := setupHarness(t, harnessParams)
+	defer teardown()
+
+	// skip the first block because it's paying to genesis script,
+	// which contains no outputs
+	mineNextBlock(t, kaspad)
+
+	// Mine enough blocks for the first coinbase outputs to mature
+	const blockAmountToMine = 100
+	for i := 0; i < blockAmountToMine; i++ {
+		mineNextBlock(t, kaspad)
+	}
+
+	utxosByAddressesResponse, err := kaspad.rpcClient.GetUTXOsByAddresses([]string{miningAddress1})
+	if err != nil {
+		t.Fatalf("Failed to get UTXOs: %s", err)
+	}
+	if len(utxosByAddressesResponse.Entries) == 0 {
+		t.Fatalf("Couldn't find any UTXOs to spend")
+	}
+	oldestEntry := utxosByAddressesResponse.Entries[0]
+	for _, entry := range utxosByAddressesResponse.Entries {
+		if entry.UTXOEntry.BlockBlueScore < oldestEntry.UTXOEntry.BlockBlueScore {
+			oldestEntry = entry
+		}
+	}
+
+	rpcTransaction := buildTransactionForUTXOIndexTest(t, oldestEntry)
+	submitTransactionResponse, err := kaspad.rpcClient.SubmitTransaction(rpcTransaction)
+	if err != nil {
+		t.Fatalf("Error submitting transaction: %s", err)
+	}
+	transactionID := submitTransactionResponse.TransactionID
+
+	// The transaction isn't found before it's accepted
+	_, err = kaspad.rpcClient.GetTransaction(transactionID)
+	if err == nil || !strings.Contains(err.Error(), "was not found") {
+		t.Fatalf("Expected GetTransaction to not find an unaccepted transaction, but got: %v", err)
+	}
+
+	// Mine a block to include the transaction, and another one to accept it
+	containingBlock := mineNextBlock(t, kaspad)
+	acceptingBlock := mineNextBlock(t, kaspad)
+
+	getTransactionResponse, err := kaspad.rpcClient.GetTransaction(transactionID)
+	if err != nil {
+		t.Fatalf("Error getting transaction: %s", err)
+	}
+	if getTransactionResponse.TransactionVerboseData.TxID != transactionID {
+		t.Fatalf("Unexpected transaction ID. Want: %s, got: %s",
+			transactionID, getTransactionResponse.TransactionVerboseData.TxID)
+	}
+	containingBlockHash := consensushashing.BlockHash(containingBlock).String()
+	if getTransactionResponse.BlockHash != containingBlockHash {
+		t.Fatalf("Unexpected block hash. Want: %s, got: %s", containingBlockHash, getTransactionResponse.BlockHash)
+	}
+	acceptingBlockHash := consensushashing.BlockHash(acceptingBlock).String()
+	if getTransactionResponse.AcceptingBlockHash != acceptingBlockHash {
+		t.Fatalf("Unexpected accepting block hash. Want: %s, got: %s",
+			acceptingBlockHash, getTransactionResponse.AcceptingBlockHash)
+	}
+	if getTransactionResponse.Confirmations != 1 {
+		t.Fatalf("Unexpected confirmations. Want: 1, got: %d", getTransactionResponse.Confirmations)
+	}
+
+	// Every additional chain block adds a confirmation
+	mineNextBlock(t, kaspad)
+	getTransactionResponse, err = kaspad.rpcClient.GetTransaction(transactionID)
+	if err != nil {
+		t.Fatalf("Error getting transaction: %s", err)
+	}
+	if getTransactionResponse.Confirmations != 2 {
+		t.Fatalf("Unexpected confirmations. Want: 2, got: %d", getTransactionResponse.Confirmations)
+	}
+}